@@ -0,0 +1,117 @@
+// Command importprices loads a unit price list CSV into the database so
+// industry estimates can be priced from it.
+//
+//	go run ./cmd/importprices -file prices.csv -name "Q3 2026 Houston" -region tx-houston -effective 2026-07-01
+//
+// The CSV needs a header row with code, unit and unit_cost (or material_cost
+// and labor_cost) columns, and may add description, region and
+// effective_date. Rows without a region or effective date take the -region
+// and -effective flags.
+//
+// With -kind modifiers it loads regional cost modifiers instead:
+//
+//	go run ./cmd/importprices -kind modifiers -file modifiers.csv -source "2026 cost survey" -effective 2026-07-01
+//
+// That CSV needs state and factor columns, and may add county or zip (not
+// both), region, tax_rate and effective_date. A modifier for an existing location and
+// effective date replaces it.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/claimcoach/backend/internal/database"
+	"github.com/claimcoach/backend/internal/pricing"
+	"github.com/claimcoach/backend/internal/services"
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	kind := flag.String("kind", "prices", "what the CSV holds: prices or modifiers")
+	file := flag.String("file", "", "path to the CSV (required)")
+	name := flag.String("name", "", "price list name (required for prices)")
+	source := flag.String("source", "", "where the prices came from, e.g. a vendor or survey")
+	region := flag.String("region", pricing.DefaultRegion, "region for rows without one")
+	effective := flag.String("effective", "", "effective date (YYYY-MM-DD) for rows without one")
+	flag.Parse()
+
+	if *file == "" || (*kind == "prices" && *name == "") || (*kind != "prices" && *kind != "modifiers") {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	input := services.ImportPriceListInput{Name: *name, Region: *region}
+	if *source != "" {
+		input.Source = source
+	}
+	if *effective != "" {
+		date, err := time.Parse(pricing.DateLayout, *effective)
+		if err != nil {
+			log.Fatalf("Invalid -effective date %q: expected YYYY-MM-DD", *effective)
+		}
+		input.EffectiveDate = date
+	}
+
+	if _, err := os.Stat(".env"); err == nil {
+		if err := godotenv.Load(); err != nil {
+			log.Printf("Warning: Error loading .env file: %v", err)
+		}
+	}
+
+	// Only the database is needed, so skip config.Load and its API key checks
+	databaseURL := os.Getenv("DATABASE_URL")
+	if databaseURL == "" {
+		log.Fatal("DATABASE_URL is required")
+	}
+
+	db, err := database.Connect(databaseURL)
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer db.Close()
+
+	if err := database.RunMigrations(db); err != nil {
+		log.Fatalf("Failed to run migrations: %v", err)
+	}
+
+	f, err := os.Open(*file)
+	if err != nil {
+		log.Fatalf("Failed to open %s: %v", *file, err)
+	}
+	defer f.Close()
+
+	pricingService := services.NewPricingService(db)
+
+	if *kind == "modifiers" {
+		count, err := pricingService.ImportRegionalModifiers(context.Background(), input.Source, input.EffectiveDate, f)
+		if err != nil {
+			fail(err)
+		}
+		log.Printf("✓ Imported %d regional cost modifiers", count)
+		return
+	}
+
+	list, err := pricingService.ImportPriceList(context.Background(), input, f)
+	if err != nil {
+		fail(err)
+	}
+
+	log.Printf("✓ Imported %d prices into price list %q (%s)", list.RowCount, list.Name, list.ID)
+}
+
+// fail prints each invalid row of a rejected import and exits
+func fail(err error) {
+	var importErr *pricing.ImportError
+	if errors.As(err, &importErr) {
+		for _, row := range importErr.Rows {
+			fmt.Fprintf(os.Stderr, "line %d: %s\n", row.Line, row.Message)
+		}
+	}
+	log.Fatalf("Import failed: %v", err)
+}
@@ -0,0 +1,82 @@
+// Command prompteval replays the recorded prompt fixtures through a stub LLM
+// so prompt template changes can be regression-tested offline.
+//
+//	go run ./cmd/prompteval
+//
+// Each fixture is rendered with the active version of its prompt, checked for
+// the facts it must carry and the fields the parsers read, and its recorded
+// response is checked against the shape the services expect. To try a new
+// version before pinning it as active:
+//
+//	go run ./cmd/prompteval -prompt pm_brain -version 2
+//
+// It exits non-zero if any fixture fails.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/claimcoach/backend/internal/prompts"
+)
+
+func main() {
+	dir := flag.String("fixtures", "internal/prompts/fixtures", "directory of fixture JSON files")
+	name := flag.String("prompt", "", "only evaluate fixtures for this prompt")
+	version := flag.Int("version", 0, "evaluate against this version instead of the active one (requires -prompt)")
+	verbose := flag.Bool("v", false, "print passing fixtures too")
+	flag.Parse()
+
+	if *version != 0 && *name == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	fixtures, err := prompts.LoadFixtures(*dir)
+	if err != nil {
+		log.Fatalf("Failed to load fixtures: %v", err)
+	}
+
+	ctx := context.Background()
+	evaluated, failed := 0, 0
+	for _, f := range fixtures {
+		if *name != "" && f.Prompt != *name {
+			continue
+		}
+
+		var p *prompts.Prompt
+		if *version != 0 {
+			p, err = prompts.GetVersion(f.Prompt, *version)
+		} else {
+			p, err = prompts.Get(f.Prompt)
+		}
+		if err != nil {
+			log.Fatalf("%s: %v", f.File, err)
+		}
+
+		result := prompts.Evaluate(ctx, p, f)
+		evaluated++
+		if result.Passed() {
+			if *verbose {
+				fmt.Printf("PASS %s %s (%s)\n", result.Version, f.Name, f.File)
+			}
+			continue
+		}
+		failed++
+		fmt.Printf("FAIL %s %s (%s)\n", result.Version, f.Name, f.File)
+		for _, problem := range result.Problems {
+			fmt.Printf("     - %s\n", problem)
+		}
+	}
+
+	if evaluated == 0 {
+		log.Fatalf("No fixtures found in %s", *dir)
+	}
+	fmt.Printf("%d of %d fixtures passed\n", evaluated-failed, evaluated)
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
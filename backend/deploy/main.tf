@@ -117,6 +117,11 @@ resource "aws_lambda_function" "api" {
       SENDGRID_FROM_EMAIL = var.sendgrid_from_email
       SENDGRID_FROM_NAME  = var.sendgrid_from_name
       CLAIMCOACH_EMAIL    = var.claimcoach_email
+      CRON_SECRET         = var.cron_secret
+      TWILIO_ACCOUNT_SID  = var.twilio_account_sid
+      TWILIO_AUTH_TOKEN   = var.twilio_auth_token
+      TWILIO_FROM_NUMBER  = var.twilio_from_number
+      PUBLIC_API_URL      = var.public_api_url != "" ? var.public_api_url : aws_apigatewayv2_api.api.api_endpoint
     }
   }
 
@@ -199,3 +204,50 @@ resource "aws_lambda_permission" "api_gateway" {
   principal     = "apigateway.amazonaws.com"
   source_arn    = "${aws_apigatewayv2_api.api.execution_arn}/*/*"
 }
+
+# Scheduled magic link reminder sweep
+# EventBridge invokes the Lambda directly with a synthetic HTTP API event, so the
+# request goes through gin like any other call to the internal sweep endpoint.
+resource "aws_cloudwatch_event_rule" "magic_link_sweep" {
+  name                = "${var.project_name}-magic-link-sweep"
+  description         = "Send magic link reminders and expiry notices"
+  schedule_expression = var.magic_link_sweep_schedule
+
+  tags = {
+    Name        = "${var.project_name}-magic-link-sweep"
+    Project     = var.project_name
+    Environment = var.environment
+  }
+}
+
+resource "aws_cloudwatch_event_target" "magic_link_sweep" {
+  rule = aws_cloudwatch_event_rule.magic_link_sweep.name
+  arn  = aws_lambda_function.api.arn
+
+  input = jsonencode({
+    version        = "2.0"
+    routeKey       = "$default"
+    rawPath        = "/internal/magic-links/sweep"
+    rawQueryString = ""
+    headers = {
+      "x-cron-secret" = var.cron_secret
+    }
+    requestContext = {
+      http = {
+        method   = "POST"
+        path     = "/internal/magic-links/sweep"
+        protocol = "HTTP/1.1"
+        sourceIp = "127.0.0.1"
+      }
+    }
+    isBase64Encoded = false
+  })
+}
+
+resource "aws_lambda_permission" "magic_link_sweep" {
+  statement_id  = "AllowEventBridgeMagicLinkSweep"
+  action        = "lambda:InvokeFunction"
+  function_name = aws_lambda_function.api.function_name
+  principal     = "events.amazonaws.com"
+  source_arn    = aws_cloudwatch_event_rule.magic_link_sweep.arn
+}
@@ -77,6 +77,45 @@ variable "claimcoach_email" {
   default     = "jesse@claimcoach.ai"
 }
 
+variable "cron_secret" {
+  description = "Shared secret for scheduled internal endpoints"
+  type        = string
+  sensitive   = true
+  default     = ""
+}
+
+variable "magic_link_sweep_schedule" {
+  description = "EventBridge schedule for the magic link reminder sweep"
+  type        = string
+  default     = "rate(15 minutes)"
+}
+
+variable "twilio_account_sid" {
+  description = "Twilio account SID for SMS (mock SMS when empty)"
+  type        = string
+  sensitive   = true
+  default     = ""
+}
+
+variable "twilio_auth_token" {
+  description = "Twilio auth token"
+  type        = string
+  sensitive   = true
+  default     = ""
+}
+
+variable "twilio_from_number" {
+  description = "Twilio sending number in E.164 format"
+  type        = string
+  default     = ""
+}
+
+variable "public_api_url" {
+  description = "Public API base URL for SMS webhooks (defaults to the API Gateway endpoint)"
+  type        = string
+  default     = ""
+}
+
 variable "lambda_timeout" {
   description = "Lambda function timeout in seconds"
   type        = number
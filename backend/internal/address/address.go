@@ -0,0 +1,158 @@
+// Package address parses free-text US property addresses into the components
+// used to match a property to its cost region and state rules.
+package address
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Components are the parts of a parsed address. Fields that couldn't be
+// found are left empty.
+type Components struct {
+	Street string `json:"street"`
+	City   string `json:"city"`
+	// State is the two-letter USPS code
+	State string `json:"state"`
+	// ZIP is the five-digit ZIP code, without any +4 extension
+	ZIP string `json:"zip"`
+}
+
+var (
+	zipPattern     = regexp.MustCompile(`(?:^|[\s,])(\d{5})(?:-\d{4})?$`)
+	countrySuffix  = regexp.MustCompile(`(?i)[,\s]+(usa|u\.s\.a\.|us|u\.s\.|united states(?: of america)?)$`)
+	whitespace     = regexp.MustCompile(`\s+`)
+	separatorSpace = regexp.MustCompile(`\s*,\s*`)
+)
+
+// Parse splits a one- or multi-line US address such as
+// "123 Main St, Houston, TX 77002" into its components. It is forgiving
+// about punctuation and spelled-out state names, and returns whatever it
+// could find.
+func Parse(raw string) Components {
+	s := strings.NewReplacer("\r\n", ",", "\n", ",", "\r", ",").Replace(raw)
+	s = whitespace.ReplaceAllString(s, " ")
+	s = separatorSpace.ReplaceAllString(s, ", ")
+	s = strings.Trim(s, " ,")
+	s = strings.Trim(countrySuffix.ReplaceAllString(s, ""), " ,")
+
+	var c Components
+
+	if m := zipPattern.FindStringSubmatchIndex(s); m != nil {
+		c.ZIP = s[m[2]:m[3]]
+		s = strings.TrimRight(s[:m[2]], " ,")
+	}
+
+	if state, rest, ok := trailingState(s, c.ZIP != ""); ok {
+		c.State = state
+		s = strings.TrimRight(rest, " ,")
+	}
+
+	parts := strings.Split(s, ",")
+	for i := range parts {
+		parts[i] = strings.TrimSpace(parts[i])
+	}
+	// The city is the last comma-separated part, unless that part is the
+	// street itself because the address had no city
+	if len(parts) > 1 {
+		c.City = parts[len(parts)-1]
+		parts = parts[:len(parts)-1]
+	}
+	c.Street = strings.Join(nonEmpty(parts), ", ")
+
+	return c
+}
+
+// NormalizeState returns the two-letter code for a state code or name
+func NormalizeState(state string) (string, bool) {
+	key := strings.ToUpper(strings.TrimSpace(strings.ReplaceAll(state, ".", "")))
+	if _, ok := stateNames[key]; ok {
+		return key, true
+	}
+	code, ok := stateCodes[key]
+	return code, ok
+}
+
+// NormalizeCounty lower-cases a county name and drops a trailing "County",
+// "Parish" or "Borough", so "Harris County" and "harris" match
+func NormalizeCounty(county string) string {
+	county = strings.ToLower(whitespace.ReplaceAllString(strings.TrimSpace(county), " "))
+	for _, suffix := range []string{" county", " parish", " borough"} {
+		county = strings.TrimSuffix(county, suffix)
+	}
+	return county
+}
+
+// ValidZIP reports whether zip is a five-digit ZIP code
+func ValidZIP(zip string) bool {
+	if len(zip) != 5 {
+		return false
+	}
+	for _, r := range zip {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// trailingState finds a state code or name at the end of s, preferring the
+// longest match so "West Virginia" isn't read as "Virginia". Unless the
+// address had a ZIP, the state must follow a comma, so the "Ct" in
+// "12 Oak Ct" isn't read as Connecticut.
+func trailingState(s string, hasZIP bool) (state, rest string, ok bool) {
+	words := strings.Fields(s)
+	for n := 3; n >= 1; n-- {
+		if len(words) < n {
+			continue
+		}
+		tail := words[len(words)-n:]
+		if strings.HasSuffix(tail[0], ",") {
+			continue
+		}
+		code, found := NormalizeState(strings.Join(tail, " "))
+		if !found {
+			continue
+		}
+		rest = strings.Join(words[:len(words)-n], " ")
+		if !hasZIP && !strings.Contains(rest, ",") {
+			return "", s, false
+		}
+		return code, rest, true
+	}
+	return "", s, false
+}
+
+func nonEmpty(parts []string) []string {
+	out := parts[:0]
+	for _, p := range parts {
+		if p != "" {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+var stateNames = map[string]string{
+	"AL": "Alabama", "AK": "Alaska", "AZ": "Arizona", "AR": "Arkansas", "CA": "California",
+	"CO": "Colorado", "CT": "Connecticut", "DE": "Delaware", "DC": "District of Columbia",
+	"FL": "Florida", "GA": "Georgia", "HI": "Hawaii", "ID": "Idaho", "IL": "Illinois",
+	"IN": "Indiana", "IA": "Iowa", "KS": "Kansas", "KY": "Kentucky", "LA": "Louisiana",
+	"ME": "Maine", "MD": "Maryland", "MA": "Massachusetts", "MI": "Michigan", "MN": "Minnesota",
+	"MS": "Mississippi", "MO": "Missouri", "MT": "Montana", "NE": "Nebraska", "NV": "Nevada",
+	"NH": "New Hampshire", "NJ": "New Jersey", "NM": "New Mexico", "NY": "New York",
+	"NC": "North Carolina", "ND": "North Dakota", "OH": "Ohio", "OK": "Oklahoma", "OR": "Oregon",
+	"PA": "Pennsylvania", "RI": "Rhode Island", "SC": "South Carolina", "SD": "South Dakota",
+	"TN": "Tennessee", "TX": "Texas", "UT": "Utah", "VT": "Vermont", "VA": "Virginia",
+	"WA": "Washington", "WV": "West Virginia", "WI": "Wisconsin", "WY": "Wyoming",
+	"PR": "Puerto Rico",
+}
+
+// stateCodes maps upper-cased state names to their codes
+var stateCodes = func() map[string]string {
+	codes := make(map[string]string, len(stateNames))
+	for code, name := range stateNames {
+		codes[strings.ToUpper(name)] = code
+	}
+	return codes
+}()
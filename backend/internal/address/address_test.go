@@ -0,0 +1,69 @@
+package address
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want Components
+	}{
+		{
+			raw:  "123 Main St, Houston, TX 77002",
+			want: Components{Street: "123 Main St", City: "Houston", State: "TX", ZIP: "77002"},
+		},
+		{
+			raw:  "  456 Oak Ave Apt 2B,Springfield ,Illinois 62704-1234, USA ",
+			want: Components{Street: "456 Oak Ave Apt 2B", City: "Springfield", State: "IL", ZIP: "62704"},
+		},
+		{
+			raw:  "9 Ridge Rd\nCharleston, West Virginia 25301",
+			want: Components{Street: "9 Ridge Rd", City: "Charleston", State: "WV", ZIP: "25301"},
+		},
+		{
+			raw:  "1600 Pennsylvania Ave NW, Washington, DC 20500",
+			want: Components{Street: "1600 Pennsylvania Ave NW", City: "Washington", State: "DC", ZIP: "20500"},
+		},
+		{
+			raw:  "77 Elm St, Tulsa OK",
+			want: Components{Street: "77 Elm St", City: "Tulsa", State: "OK"},
+		},
+		{
+			// Without a ZIP or comma, a trailing "Ct" is a street suffix, not Connecticut
+			raw:  "12 Oak Ct",
+			want: Components{Street: "12 Oak Ct"},
+		},
+		{
+			raw:  "Lot 14 County Road 9",
+			want: Components{Street: "Lot 14 County Road 9"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			assert.Equal(t, tt.want, Parse(tt.raw))
+		})
+	}
+}
+
+func TestNormalizeState(t *testing.T) {
+	code, ok := NormalizeState("new york")
+	assert.True(t, ok)
+	assert.Equal(t, "NY", code)
+
+	code, ok = NormalizeState("fl")
+	assert.True(t, ok)
+	assert.Equal(t, "FL", code)
+
+	_, ok = NormalizeState("Ontario")
+	assert.False(t, ok)
+}
+
+func TestNormalizeCounty(t *testing.T) {
+	assert.Equal(t, "harris", NormalizeCounty(" Harris  County "))
+	assert.Equal(t, "st. tammany", NormalizeCounty("St. Tammany Parish"))
+	assert.Equal(t, "miami-dade", NormalizeCounty("miami-dade"))
+}
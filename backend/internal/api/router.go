@@ -1,10 +1,12 @@
 package api
 
 import (
+	"context"
 	"database/sql"
 	"log"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
@@ -13,6 +15,7 @@ import (
 	"github.com/claimcoach/backend/internal/config"
 	"github.com/claimcoach/backend/internal/handlers"
 	"github.com/claimcoach/backend/internal/llm"
+	"github.com/claimcoach/backend/internal/ratelimit"
 	"github.com/claimcoach/backend/internal/services"
 	"github.com/claimcoach/backend/internal/storage"
 )
@@ -56,8 +59,15 @@ func NewRouter(cfg *config.Config, db *sql.DB) (*gin.Engine, error) {
 		return nil, err
 	}
 
-	// Initialize LLM client (Claude for all AI features)
-	llmClient := llm.NewClaudeClient(cfg.AnthropicAPIKey, cfg.AnthropicModel, 120)
+	// Initialize LLM client (Claude for all AI features). It is shared so every
+	// feature sees the same circuit breaker.
+	llmClient := llm.NewClaudeClient(cfg.AnthropicAPIKey, cfg.AnthropicModel, 120, cfg.AnthropicMaxRetries)
+
+	// Cache estimate and PDF parsing responses unless LLM_CACHE_TTL_HOURS is 0
+	var llmCache *services.LLMCache
+	if cfg.LLMCacheTTLHours > 0 {
+		llmCache = services.NewLLMCache(db, "anthropic", cfg.AnthropicModel, time.Duration(cfg.LLMCacheTTLHours)*time.Hour)
+	}
 
 	// Initialize services needed for both public and protected routes
 	propertyService := services.NewPropertyService(db)
@@ -80,14 +90,41 @@ func NewRouter(cfg *config.Config, db *sql.DB) (*gin.Engine, error) {
 		log.Println("⚠ Using Mock email service (emails logged to console)")
 	}
 
-	magicLinkService := services.NewMagicLinkService(db, cfg, storageClient, claimService, emailService)
-	magicLinkHandler := handlers.NewMagicLinkHandler(magicLinkService)
+	// Conditionally use Twilio or Mock SMS service based on account SID
+	var smsService services.SMSService
+	if cfg.TwilioAccountSID != "" {
+		statusCallbackURL := ""
+		if cfg.PublicAPIURL != "" {
+			statusCallbackURL = cfg.PublicAPIURL + "/api/webhooks/sms/status"
+		}
+		smsService = services.NewTwilioSMSService(
+			cfg.TwilioAccountSID,
+			cfg.TwilioAuthToken,
+			cfg.TwilioFromNumber,
+			cfg.TwilioAPIBaseURL,
+			statusCallbackURL,
+		)
+		log.Println("✓ Using Twilio SMS service")
+	} else {
+		smsService = services.NewMockSMSService()
+		log.Println("⚠ Using Mock SMS service (texts logged to console)")
+	}
+
+	documentTextService := services.NewDocumentTextService(db, storageClient, llmClient, claimService)
+	documentTextHandler := handlers.NewDocumentTextHandler(documentTextService)
+	magicLinkService := services.NewMagicLinkService(db, cfg, storageClient, claimService, emailService, smsService)
+	magicLinkHandler := handlers.NewMagicLinkHandler(magicLinkService, documentTextService)
 	scopeSheetService := services.NewScopeSheetService(db)
-	scopeSheetHandler := handlers.NewScopeSheetHandler(scopeSheetService, magicLinkService, claimService)
-	auditService := services.NewAuditService(db, llmClient, scopeSheetService)
+	scopeSheetReviewService := services.NewScopeSheetReviewService(db, cfg, claimService, emailService)
+	scopeSheetHandler := handlers.NewScopeSheetHandler(scopeSheetService, scopeSheetReviewService, magicLinkService, claimService)
+	pricingService := services.NewPricingService(db)
+	auditService := services.NewAuditService(db, llmClient, scopeSheetService, documentTextService, pricingService, llmCache)
 	auditHandler := handlers.NewAuditHandler(auditService)
-	legalPackageService := services.NewLegalPackageService(db, storageClient, auditService)
+	archiveService := services.NewArchiveService(db, storageClient, claimService)
+	legalPackageService := services.NewLegalPackageService(db, auditService, archiveService)
 	legalPackageHandler := handlers.NewLegalPackageHandler(legalPackageService)
+	supplementService := services.NewSupplementService(db, auditService, claimService)
+	supplementHandler := handlers.NewSupplementHandler(supplementService)
 
 	// Phase 7 services and handlers
 	meetingService := services.NewMeetingService(db, emailService, claimService)
@@ -96,12 +133,33 @@ func NewRouter(cfg *config.Config, db *sql.DB) (*gin.Engine, error) {
 	paymentHandler := handlers.NewPaymentHandler(paymentService)
 	rcvDemandService := services.NewRCVDemandService(db, llmClient, claimService, paymentService)
 	rcvDemandHandler := handlers.NewRCVDemandHandler(rcvDemandService)
+	appraisalService := services.NewAppraisalService(db, llmClient, claimService, paymentService)
+	appraisalHandler := handlers.NewAppraisalHandler(appraisalService)
+
+	// Magic link reminders: an in-process ticker for long-running servers, and
+	// an internal endpoint for the EventBridge schedule on Lambda
+	reminderService := services.NewMagicLinkReminderService(db, cfg, emailService, smsService, claimService)
+	reminderHandler := handlers.NewMagicLinkReminderHandler(reminderService, cfg.CronSecret)
+	if cfg.MagicLinkSweepIntervalMinutes > 0 {
+		go reminderService.Start(context.Background(), time.Duration(cfg.MagicLinkSweepIntervalMinutes)*time.Minute)
+	}
 
 	// Public routes
 	r.GET("/health", func(c *gin.Context) {
 		c.JSON(http.StatusOK, gin.H{"status": "ok"})
 	})
 
+	// Internal scheduled jobs (authorized by CRON_SECRET, not user auth)
+	r.POST("/internal/magic-links/sweep", reminderHandler.Sweep)
+
+	// SMS provider webhooks (verified by provider signature, not user auth).
+	// Without Twilio there is no provider to sign them, so they aren't served.
+	if cfg.TwilioAccountSID != "" {
+		smsWebhookHandler := handlers.NewSMSWebhookHandler(smsService, services.NewSMSWebhookService(db), cfg.PublicAPIURL)
+		r.POST("/api/webhooks/sms/inbound", smsWebhookHandler.InboundMessage)
+		r.POST("/api/webhooks/sms/status", smsWebhookHandler.DeliveryStatus)
+	}
+
 	// Public auth endpoints (no auth required)
 	authHandler := handlers.NewAuthHandler(db, supabase)
 	r.POST("/api/auth/complete-signup", authHandler.CompleteSignup)
@@ -111,14 +169,42 @@ func NewRouter(cfg *config.Config, db *sql.DB) (*gin.Engine, error) {
 	mortgageBankHandler := handlers.NewMortgageBankHandler(mortgageBankService)
 	r.GET("/api/mortgage-banks", mortgageBankHandler.GetAllBanks)
 
-	// Public magic link endpoints (no auth required)
-	r.GET("/api/magic-links/:token/validate", magicLinkHandler.ValidateToken)
-	r.GET("/api/magic-links/:token/documents", magicLinkHandler.ListDocuments)
-	r.POST("/api/magic-links/:token/documents/upload-url", magicLinkHandler.RequestUploadURL)
-	r.POST("/api/magic-links/:token/documents/:documentId/confirm", magicLinkHandler.ConfirmUpload)
-	r.POST("/api/magic-links/:token/scope-sheet", scopeSheetHandler.CreateViaMagicLink)
-	r.POST("/api/magic-links/:token/scope-sheet/draft", scopeSheetHandler.SaveDraft)
-	r.GET("/api/magic-links/:token/scope-sheet/draft", scopeSheetHandler.GetDraft)
+	// Public scope category catalog (no auth required; the contractor wizard renders from it)
+	r.GET("/api/scope-catalog", scopeSheetHandler.GetCatalog)
+
+	// Public magic link endpoints (no auth required), rate limited by IP and token
+	var rateLimitStore ratelimit.Store
+	if cfg.RateLimitStore == "postgres" {
+		rateLimitStore = ratelimit.NewPostgresStore(db)
+	} else {
+		rateLimitStore = ratelimit.NewMemoryStore()
+	}
+	magicLinks := r.Group("/api/magic-links/:token")
+	magicLinks.Use(ratelimit.Middleware(rateLimitStore, ratelimit.Config{
+		Prefix: "magic-link",
+		Rules: []ratelimit.Rule{
+			{Name: "ip", Key: ratelimit.ByIP, Limit: cfg.MagicLinkIPRateLimit, Window: time.Minute},
+			{Name: "token", Key: ratelimit.ByParam("token"), Limit: cfg.MagicLinkTokenRateLimit, Window: time.Minute},
+		},
+		InvalidAttempts: &ratelimit.Rule{
+			Name: "invalid", Key: ratelimit.ByIP, Limit: cfg.MagicLinkInvalidAttemptLimit, Window: 15 * time.Minute,
+		},
+		InvalidStatuses: []int{http.StatusUnauthorized},
+		OnLimited: func(c *gin.Context, rule ratelimit.Rule) {
+			if err := magicLinkService.RecordRateLimitEvent(c.Request.Context(), c.Param("token")); err != nil {
+				log.Printf("Warning: %v", err)
+			}
+		},
+	}))
+	{
+		magicLinks.GET("/validate", magicLinkHandler.ValidateToken)
+		magicLinks.GET("/documents", magicLinkHandler.ListDocuments)
+		magicLinks.POST("/documents/upload-url", magicLinkHandler.RequestUploadURL)
+		magicLinks.POST("/documents/:documentId/confirm", magicLinkHandler.ConfirmUpload)
+		magicLinks.POST("/scope-sheet", scopeSheetHandler.CreateViaMagicLink)
+		magicLinks.POST("/scope-sheet/draft", scopeSheetHandler.SaveDraft)
+		magicLinks.GET("/scope-sheet/draft", scopeSheetHandler.GetDraft)
+	}
 
 	// Protected routes
 	api := r.Group("/api")
@@ -150,6 +236,25 @@ func NewRouter(cfg *config.Config, db *sql.DB) (*gin.Engine, error) {
 		api.POST("/properties/:id/policy/pdf/upload-url", policyHandler.RequestPDFUploadURL)
 		api.POST("/properties/:id/policy/pdf/confirm", policyHandler.ConfirmPDFUpload)
 
+		policyTermsService := services.NewPolicyTermsService(db, storageClient, llmClient, policyService)
+		policyTermsHandler := handlers.NewPolicyTermsHandler(policyTermsService)
+		api.POST("/properties/:id/policy/terms/extract", policyTermsHandler.Extract)
+		api.GET("/properties/:id/policy/terms", policyTermsHandler.Get)
+		api.POST("/properties/:id/policy/terms/:termsId/confirm", policyTermsHandler.Confirm)
+
+		deductibleService := services.NewDeductibleService(db, policyService, claimService)
+		deductibleHandler := handlers.NewDeductibleHandler(deductibleService)
+		api.GET("/properties/:id/policy/deductible-rules", deductibleHandler.ListRules)
+		api.POST("/properties/:id/policy/deductible-rules", deductibleHandler.CreateRule)
+		api.DELETE("/properties/:id/policy/deductible-rules/:ruleId", deductibleHandler.DeleteRule)
+		api.GET("/claims/:id/deductible", deductibleHandler.ResolveClaimDeductible)
+
+		policyQAService := services.NewPolicyQAService(db, storageClient, llmClient, claimService, policyService, documentTextService)
+		policyQAHandler := handlers.NewPolicyQAHandler(policyQAService)
+		api.POST("/claims/:id/policy/ask", policyQAHandler.Ask)
+		api.GET("/claims/:id/policy/questions", policyQAHandler.ListConversation)
+		api.POST("/claims/:id/policy/questions/:questionId/note", policyQAHandler.SaveAsNote)
+
 		// Claim routes
 		claimHandler := handlers.NewClaimHandler(claimService, emailService)
 
@@ -165,42 +270,87 @@ func NewRouter(cfg *config.Config, db *sql.DB) (*gin.Engine, error) {
 
 		// Document routes
 		documentService := services.NewDocumentService(db, storageClient, claimService)
-		documentHandler := handlers.NewDocumentHandler(documentService)
+		documentHandler := handlers.NewDocumentHandler(documentService, archiveService, documentTextService)
 
 		api.POST("/claims/:id/documents/upload-url", documentHandler.RequestUploadURL)
 		api.POST("/claims/:id/documents/:documentId/confirm", documentHandler.ConfirmUpload)
 		api.GET("/claims/:id/documents", documentHandler.ListDocuments)
+		api.GET("/claims/:id/documents/archive", documentHandler.DownloadArchive)
+		api.POST("/claims/:id/documents/extract-text", documentTextHandler.ExtractClaimText)
+		api.GET("/documents/search", documentTextHandler.Search)
 		api.GET("/documents/:id", documentHandler.GetDocument)
+		api.GET("/documents/:id/versions", documentHandler.ListDocumentVersions)
 
 		// Carrier Estimate routes
-		claudeClient := llm.NewClaudeClient(cfg.AnthropicAPIKey, cfg.AnthropicModel, 120)
 		carrierEstimateService := services.NewCarrierEstimateService(db, storageClient, claimService)
-		pdfParserService := services.NewPDFParserService(db, storageClient, claudeClient, claimService)
-		carrierEstimateHandler := handlers.NewCarrierEstimateHandler(carrierEstimateService, pdfParserService)
+		pdfParserService := services.NewPDFParserService(db, storageClient, llmClient, claimService, llmCache)
+		carrierEstimateHandler := handlers.NewCarrierEstimateHandler(carrierEstimateService, pdfParserService, documentTextService)
 
 		api.POST("/claims/:id/carrier-estimate/upload-url", carrierEstimateHandler.RequestUploadURL)
 		api.POST("/claims/:id/carrier-estimate/:estimateId/confirm", carrierEstimateHandler.ConfirmUpload)
 		api.GET("/claims/:id/carrier-estimate", carrierEstimateHandler.ListCarrierEstimates)
+		api.GET("/claims/:id/carrier-estimate/:estimateId/versions", carrierEstimateHandler.ListCarrierEstimateVersions)
 		api.POST("/claims/:id/carrier-estimate/:estimateId/parse", carrierEstimateHandler.ParseCarrierEstimate)
 
+		// Contractor directory routes
+		contractorService := services.NewContractorService(db)
+		contractorHandler := handlers.NewContractorHandler(contractorService)
+		api.POST("/contractors", contractorHandler.Create)
+		api.GET("/contractors", contractorHandler.List)
+		api.GET("/contractors/:id", contractorHandler.Get)
+		api.PATCH("/contractors/:id", contractorHandler.Update)
+		api.DELETE("/contractors/:id", contractorHandler.Delete)
+		api.GET("/contractors/:id/stats", contractorHandler.GetStats)
+
 		// Magic Link routes (protected - requires auth)
 		api.POST("/claims/:id/magic-link", magicLinkHandler.GenerateMagicLink)
 		api.GET("/claims/:id/magic-links", magicLinkHandler.GetMagicLinks)
+		api.POST("/claims/:id/magic-links/:linkId/revoke", magicLinkHandler.RevokeMagicLink)
+		api.POST("/claims/:id/magic-links/:linkId/reissue", magicLinkHandler.ReissueMagicLink)
+		api.POST("/claims/:id/magic-links/:linkId/unlock", magicLinkHandler.UnlockMagicLink)
 
 		// Scope Sheet routes (protected - requires auth)
 		api.GET("/claims/:id/scope-sheet", scopeSheetHandler.GetByClaimID)
+		api.GET("/claims/:id/scope-sheet/revisions", scopeSheetHandler.ListRevisions)
+		api.GET("/claims/:id/scope-sheet/revisions/:revision", scopeSheetHandler.GetRevision)
+		api.POST("/claims/:id/scope-sheet/:scopeSheetId/approve", scopeSheetHandler.Approve)
+		api.POST("/claims/:id/scope-sheet/:scopeSheetId/request-changes", scopeSheetHandler.RequestChanges)
 
 		// Audit routes (protected - requires auth)
 		api.POST("/claims/:id/audit/generate", auditHandler.GenerateIndustryEstimate)
 		api.POST("/claims/:id/audit/viability", auditHandler.AnalyzeClaimViability)
 		api.GET("/claims/:id/audit", auditHandler.GetAuditReport)
+		api.GET("/claims/:id/audit/runs", auditHandler.ListAuditRuns)
+		api.GET("/claims/:id/audit/runs/compare", auditHandler.CompareAuditRuns)
+		api.GET("/claims/:id/audit/runs/:runId", auditHandler.GetAuditRun)
 		api.POST("/claims/:id/audit/:auditId/pm-brain", auditHandler.RunPMBrain)
 		api.POST("/claims/:id/audit/:auditId/dispute-letter", auditHandler.GenerateDisputeLetter)
 		api.POST("/claims/:id/audit/:auditId/owner-pitch", auditHandler.GenerateOwnerPitch)
+		api.POST("/claims/:id/audit/:auditId/dispute-letter/stream", auditHandler.StreamDisputeLetter)
+		api.POST("/claims/:id/audit/:auditId/owner-pitch/stream", auditHandler.StreamOwnerPitch)
+
+		// Pricing routes (changes are admin-only; global price lists and regional modifiers are imported with cmd/importprices)
+		pricingHandler := handlers.NewPricingHandler(pricingService, propertyService)
+		api.GET("/pricing/prices", pricingHandler.ListPrices)
+		api.GET("/pricing/price-lists", pricingHandler.ListPriceLists)
+		api.GET("/pricing/overrides", pricingHandler.ListOverrides)
+		api.PUT("/pricing/overrides", pricingHandler.UpsertOverride)
+		api.POST("/pricing/overrides/import", pricingHandler.ImportOverrides)
+		api.DELETE("/pricing/overrides/:id", pricingHandler.DeleteOverride)
+		api.GET("/pricing/regional-modifiers", pricingHandler.ListRegionalModifiers)
+		api.GET("/properties/:id/regional-adjustment", pricingHandler.GetPropertyAdjustment)
 
 		// Legal Package routes
 		api.GET("/claims/:id/legal-package/download", legalPackageHandler.Download)
 
+		// Supplement routes
+		api.POST("/claims/:id/supplements", supplementHandler.Generate)
+		api.GET("/claims/:id/supplements", supplementHandler.List)
+		api.GET("/claims/:id/supplements/:supplementId", supplementHandler.Get)
+		api.PATCH("/claims/:id/supplements/:supplementId/status", supplementHandler.UpdateStatus)
+		api.GET("/claims/:id/supplements/:supplementId/pdf", supplementHandler.DownloadPDF)
+		api.GET("/claims/:id/supplements/:supplementId/csv", supplementHandler.DownloadCSV)
+
 		// Meeting routes (Phase 7 - protected)
 		api.POST("/claims/:id/meetings", meetingHandler.CreateMeeting)
 		api.GET("/claims/:id/meetings", meetingHandler.ListMeetingsByClaimID)
@@ -221,10 +371,21 @@ func NewRouter(cfg *config.Config, db *sql.DB) (*gin.Engine, error) {
 
 		// RCV Demand routes (Phase 7 - protected)
 		api.POST("/claims/:id/rcv-demand/generate", rcvDemandHandler.GenerateRCVDemandLetter)
+		api.POST("/claims/:id/rcv-demand/generate/stream", rcvDemandHandler.StreamRCVDemandLetter)
 		api.GET("/claims/:id/rcv-demand", rcvDemandHandler.ListRCVDemandLettersByClaimID)
 		api.GET("/rcv-demand/:id", rcvDemandHandler.GetRCVDemandLetter)
 		api.PATCH("/rcv-demand/:id/mark-sent", rcvDemandHandler.MarkAsSent)
 
+		// Appraisal routes
+		api.POST("/claims/:id/appraisals", appraisalHandler.Invoke)
+		api.GET("/claims/:id/appraisals", appraisalHandler.List)
+		api.GET("/claims/:id/appraisals/:appraisalId", appraisalHandler.Get)
+		api.PATCH("/claims/:id/appraisals/:appraisalId/invoked", appraisalHandler.MarkInvoked)
+		api.PUT("/claims/:id/appraisals/:appraisalId/parties/:role", appraisalHandler.SetParty)
+		api.PATCH("/claims/:id/appraisals/:appraisalId/deadlines", appraisalHandler.UpdateDeadlines)
+		api.POST("/claims/:id/appraisals/:appraisalId/award", appraisalHandler.RecordAward)
+		api.POST("/claims/:id/appraisals/:appraisalId/withdraw", appraisalHandler.Withdraw)
+
 	}
 
 	return r, nil
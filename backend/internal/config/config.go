@@ -5,6 +5,7 @@ import (
 	"log"
 	"os"
 	"strconv"
+	"strings"
 )
 
 type Config struct {
@@ -23,8 +24,12 @@ type Config struct {
 	PerplexityMaxRetries int
 
 	// Anthropic Claude API (for PDF parsing)
-	AnthropicAPIKey string
-	AnthropicModel  string
+	AnthropicAPIKey     string
+	AnthropicModel      string
+	AnthropicMaxRetries int
+
+	// How long cached LLM responses are reused; 0 turns the cache off
+	LLMCacheTTLHours int
 
 	// SendGrid Email Service (optional - falls back to mock if not provided)
 	SendGridAPIKey    string
@@ -32,9 +37,37 @@ type Config struct {
 	SendGridFromName  string
 	ClaimCoachEmail   string
 
+	// Twilio-compatible SMS (optional - falls back to mock if no account SID).
+	// PublicAPIURL is this API's public base URL, used for delivery status
+	// callbacks and to verify webhook signatures.
+	TwilioAccountSID string
+	TwilioAuthToken  string
+	TwilioFromNumber string
+	TwilioAPIBaseURL string
+	PublicAPIURL     string
+
 	// Legal escalation threshold — claims with delta >= this amount trigger legal prompt
 	// Configurable via LEGAL_ESCALATION_THRESHOLD_DOLLARS env var (default: 10000)
 	LegalEscalationThreshold float64
+
+	// Magic link abuse protection. RATE_LIMIT_STORE is "memory" or "postgres";
+	// it defaults to postgres on Lambda, where instances don't share memory.
+	RateLimitStore               string
+	MagicLinkIPRateLimit         int // requests per minute per IP
+	MagicLinkTokenRateLimit      int // requests per minute per token
+	MagicLinkInvalidAttemptLimit int // invalid-token responses per IP per 15 minutes
+	MagicLinkLockoutAttempts     int // failed actions before a link is locked
+	MagicLinkMaxUploads          int
+	MagicLinkMaxUploadBytes      int64
+
+	// Magic link reminder sweep. Each reminder point is in hours; 0 turns that
+	// reminder off. The sweep runs on an in-process ticker and, on Lambda, via
+	// POST /internal/magic-links/sweep authorized by CRON_SECRET.
+	MagicLinkReminderNeverOpenedHours int // after the link is sent, if never opened
+	MagicLinkReminderDraftHours       int // after the last draft save, if not submitted
+	MagicLinkReminderExpiringHours    int // before the link expires, if no response yet
+	MagicLinkSweepIntervalMinutes     int // 0 disables the in-process ticker
+	CronSecret                        string
 }
 
 func Load() (*Config, error) {
@@ -52,11 +85,30 @@ func Load() (*Config, error) {
 		PerplexityMaxRetries: getEnvIntOrDefault("PERPLEXITY_MAX_RETRIES", 3),
 		AnthropicAPIKey:      os.Getenv("ANTHROPIC_API_KEY"),
 		AnthropicModel:       getEnvOrDefault("ANTHROPIC_MODEL", "claude-opus-4-6"),
+		AnthropicMaxRetries:  getEnvIntOrDefault("ANTHROPIC_MAX_RETRIES", 3),
+		LLMCacheTTLHours:     getEnvIntOrDefault("LLM_CACHE_TTL_HOURS", 168),
 		SendGridAPIKey:       os.Getenv("SENDGRID_API_KEY"),
 		SendGridFromEmail:    getEnvOrDefault("SENDGRID_FROM_EMAIL", "claims@claimcoach.ai"),
 		SendGridFromName:     getEnvOrDefault("SENDGRID_FROM_NAME", "ClaimCoach AI"),
 		ClaimCoachEmail:          getEnvOrDefault("CLAIMCOACH_EMAIL", "jesse@claimcoach.ai"),
+		TwilioAccountSID:     os.Getenv("TWILIO_ACCOUNT_SID"),
+		TwilioAuthToken:      os.Getenv("TWILIO_AUTH_TOKEN"),
+		TwilioFromNumber:     os.Getenv("TWILIO_FROM_NUMBER"),
+		TwilioAPIBaseURL:     getEnvOrDefault("TWILIO_API_BASE_URL", "https://api.twilio.com"),
+		PublicAPIURL:         strings.TrimRight(os.Getenv("PUBLIC_API_URL"), "/"),
 		LegalEscalationThreshold: getEnvFloat64OrDefault("LEGAL_ESCALATION_THRESHOLD_DOLLARS", 10000),
+		RateLimitStore:               getEnvOrDefault("RATE_LIMIT_STORE", defaultRateLimitStore()),
+		MagicLinkIPRateLimit:         getEnvIntOrDefault("MAGIC_LINK_IP_RATE_LIMIT", 120),
+		MagicLinkTokenRateLimit:      getEnvIntOrDefault("MAGIC_LINK_TOKEN_RATE_LIMIT", 60),
+		MagicLinkInvalidAttemptLimit: getEnvIntOrDefault("MAGIC_LINK_INVALID_ATTEMPT_LIMIT", 20),
+		MagicLinkLockoutAttempts:     getEnvIntOrDefault("MAGIC_LINK_LOCKOUT_ATTEMPTS", 10),
+		MagicLinkMaxUploads:          getEnvIntOrDefault("MAGIC_LINK_MAX_UPLOADS", 200),
+		MagicLinkMaxUploadBytes:      int64(getEnvIntOrDefault("MAGIC_LINK_MAX_UPLOAD_MB", 2048)) * 1024 * 1024,
+		MagicLinkReminderNeverOpenedHours: getEnvIntOrDefault("MAGIC_LINK_REMINDER_NEVER_OPENED_HOURS", 24),
+		MagicLinkReminderDraftHours:       getEnvIntOrDefault("MAGIC_LINK_REMINDER_DRAFT_HOURS", 24),
+		MagicLinkReminderExpiringHours:    getEnvIntOrDefault("MAGIC_LINK_REMINDER_EXPIRING_HOURS", 12),
+		MagicLinkSweepIntervalMinutes:     getEnvIntOrDefault("MAGIC_LINK_SWEEP_INTERVAL_MINUTES", defaultSweepIntervalMinutes()),
+		CronSecret:                        os.Getenv("CRON_SECRET"),
 	}
 
 	if cfg.DatabaseURL == "" {
@@ -83,10 +135,53 @@ func Load() (*Config, error) {
 	if cfg.PerplexityMaxRetries <= 0 {
 		return nil, fmt.Errorf("PERPLEXITY_MAX_RETRIES must be positive, got %d", cfg.PerplexityMaxRetries)
 	}
+	if cfg.AnthropicMaxRetries <= 0 {
+		return nil, fmt.Errorf("ANTHROPIC_MAX_RETRIES must be positive, got %d", cfg.AnthropicMaxRetries)
+	}
+	if cfg.LLMCacheTTLHours < 0 {
+		return nil, fmt.Errorf("LLM_CACHE_TTL_HOURS must not be negative, got %d", cfg.LLMCacheTTLHours)
+	}
+
+	if cfg.TwilioAccountSID != "" && (cfg.TwilioAuthToken == "" || cfg.TwilioFromNumber == "") {
+		return nil, fmt.Errorf("TWILIO_AUTH_TOKEN and TWILIO_FROM_NUMBER are required when TWILIO_ACCOUNT_SID is set")
+	}
+
+	if cfg.RateLimitStore != "memory" && cfg.RateLimitStore != "postgres" {
+		return nil, fmt.Errorf("RATE_LIMIT_STORE must be memory or postgres, got %q", cfg.RateLimitStore)
+	}
+	if cfg.MagicLinkIPRateLimit <= 0 || cfg.MagicLinkTokenRateLimit <= 0 || cfg.MagicLinkInvalidAttemptLimit <= 0 {
+		return nil, fmt.Errorf("magic link rate limits must be positive")
+	}
+	if cfg.MagicLinkLockoutAttempts <= 0 {
+		return nil, fmt.Errorf("MAGIC_LINK_LOCKOUT_ATTEMPTS must be positive, got %d", cfg.MagicLinkLockoutAttempts)
+	}
+	if cfg.MagicLinkReminderNeverOpenedHours < 0 || cfg.MagicLinkReminderDraftHours < 0 || cfg.MagicLinkReminderExpiringHours < 0 {
+		return nil, fmt.Errorf("magic link reminder hours must not be negative")
+	}
+	if cfg.MagicLinkSweepIntervalMinutes < 0 {
+		return nil, fmt.Errorf("MAGIC_LINK_SWEEP_INTERVAL_MINUTES must not be negative, got %d", cfg.MagicLinkSweepIntervalMinutes)
+	}
 
 	return cfg, nil
 }
 
+// defaultRateLimitStore picks the shared Postgres store when running on Lambda
+func defaultRateLimitStore() string {
+	if os.Getenv("AWS_LAMBDA_FUNCTION_NAME") != "" {
+		return "postgres"
+	}
+	return "memory"
+}
+
+// defaultSweepIntervalMinutes disables the in-process sweep on Lambda, where
+// instances freeze between requests and EventBridge triggers the sweep instead
+func defaultSweepIntervalMinutes() int {
+	if os.Getenv("AWS_LAMBDA_FUNCTION_NAME") != "" {
+		return 0
+	}
+	return 15
+}
+
 func getEnvOrDefault(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
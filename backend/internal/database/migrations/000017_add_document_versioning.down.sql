@@ -0,0 +1,19 @@
+-- Rollback Document & Carrier Estimate Versioning
+
+ALTER TABLE audit_reports DROP COLUMN IF EXISTS contractor_estimate_version;
+ALTER TABLE audit_reports DROP COLUMN IF EXISTS contractor_estimate_document_id;
+ALTER TABLE audit_reports DROP COLUMN IF EXISTS carrier_estimate_version;
+
+DROP INDEX IF EXISTS idx_carrier_estimates_current;
+DROP INDEX IF EXISTS idx_carrier_estimates_supersedes;
+
+ALTER TABLE carrier_estimates DROP COLUMN IF EXISTS is_current;
+ALTER TABLE carrier_estimates DROP COLUMN IF EXISTS supersedes_estimate_id;
+ALTER TABLE carrier_estimates DROP COLUMN IF EXISTS version;
+
+DROP INDEX IF EXISTS idx_documents_current;
+DROP INDEX IF EXISTS idx_documents_supersedes;
+
+ALTER TABLE documents DROP COLUMN IF EXISTS is_current;
+ALTER TABLE documents DROP COLUMN IF EXISTS supersedes_document_id;
+ALTER TABLE documents DROP COLUMN IF EXISTS version;
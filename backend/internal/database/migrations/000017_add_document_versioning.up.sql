@@ -0,0 +1,49 @@
+-- Document & Carrier Estimate Versioning
+-- A re-uploaded document supersedes the previous one; only one version in a chain is current.
+
+ALTER TABLE documents ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE documents ADD COLUMN supersedes_document_id UUID REFERENCES documents(id) ON DELETE SET NULL;
+ALTER TABLE documents ADD COLUMN is_current BOOLEAN NOT NULL DEFAULT true;
+
+CREATE INDEX idx_documents_supersedes ON documents(supersedes_document_id);
+CREATE INDEX idx_documents_current ON documents(claim_id, document_type) WHERE is_current;
+
+ALTER TABLE carrier_estimates ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE carrier_estimates ADD COLUMN supersedes_estimate_id UUID REFERENCES carrier_estimates(id) ON DELETE SET NULL;
+ALTER TABLE carrier_estimates ADD COLUMN is_current BOOLEAN NOT NULL DEFAULT true;
+
+CREATE INDEX idx_carrier_estimates_supersedes ON carrier_estimates(supersedes_estimate_id);
+CREATE INDEX idx_carrier_estimates_current ON carrier_estimates(claim_id) WHERE is_current;
+
+-- Backfill: chain existing single-version document types by upload order so the
+-- most recent upload is current.
+WITH ordered AS (
+    SELECT id,
+           ROW_NUMBER() OVER (PARTITION BY claim_id, document_type ORDER BY created_at) AS rn,
+           LAG(id) OVER (PARTITION BY claim_id, document_type ORDER BY created_at) AS prev_id,
+           COUNT(*) OVER (PARTITION BY claim_id, document_type) AS total
+    FROM documents
+    WHERE status = 'confirmed'
+      AND document_type IN ('policy_pdf', 'contractor_estimate', 'carrier_estimate')
+)
+UPDATE documents d
+SET version = o.rn, supersedes_document_id = o.prev_id, is_current = (o.rn = o.total)
+FROM ordered o
+WHERE d.id = o.id;
+
+WITH ordered AS (
+    SELECT id,
+           ROW_NUMBER() OVER (PARTITION BY claim_id ORDER BY uploaded_at) AS rn,
+           LAG(id) OVER (PARTITION BY claim_id ORDER BY uploaded_at) AS prev_id,
+           COUNT(*) OVER (PARTITION BY claim_id) AS total
+    FROM carrier_estimates
+)
+UPDATE carrier_estimates ce
+SET version = o.rn, supersedes_estimate_id = o.prev_id, is_current = (o.rn = o.total)
+FROM ordered o
+WHERE ce.id = o.id;
+
+-- Record which input versions an audit report was generated from
+ALTER TABLE audit_reports ADD COLUMN carrier_estimate_version INTEGER;
+ALTER TABLE audit_reports ADD COLUMN contractor_estimate_document_id UUID REFERENCES documents(id) ON DELETE SET NULL;
+ALTER TABLE audit_reports ADD COLUMN contractor_estimate_version INTEGER;
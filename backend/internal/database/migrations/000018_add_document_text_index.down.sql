@@ -0,0 +1,9 @@
+ALTER TABLE carrier_estimates DROP COLUMN IF EXISTS text_extracted_at;
+ALTER TABLE carrier_estimates DROP COLUMN IF EXISTS text_extraction_error;
+ALTER TABLE carrier_estimates DROP COLUMN IF EXISTS text_extraction_status;
+
+ALTER TABLE documents DROP COLUMN IF EXISTS text_extracted_at;
+ALTER TABLE documents DROP COLUMN IF EXISTS text_extraction_error;
+ALTER TABLE documents DROP COLUMN IF EXISTS text_extraction_status;
+
+DROP TABLE IF EXISTS document_pages;
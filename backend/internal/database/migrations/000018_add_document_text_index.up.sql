@@ -0,0 +1,30 @@
+-- Full-text index over claim documents
+-- Extracted text is stored per page so search hits can point at a page, and so
+-- audit prompts can be grounded on text instead of re-sending PDFs.
+
+CREATE TABLE document_pages (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    claim_id UUID NOT NULL REFERENCES claims(id) ON DELETE CASCADE,
+    document_id UUID REFERENCES documents(id) ON DELETE CASCADE,
+    carrier_estimate_id UUID REFERENCES carrier_estimates(id) ON DELETE CASCADE,
+    page_number INTEGER NOT NULL CHECK (page_number > 0),
+    content TEXT NOT NULL,
+    search_vector TSVECTOR GENERATED ALWAYS AS (to_tsvector('english', content)) STORED,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    CHECK ((document_id IS NULL) <> (carrier_estimate_id IS NULL))
+);
+
+CREATE UNIQUE INDEX idx_document_pages_document_page ON document_pages(document_id, page_number) WHERE document_id IS NOT NULL;
+CREATE UNIQUE INDEX idx_document_pages_estimate_page ON document_pages(carrier_estimate_id, page_number) WHERE carrier_estimate_id IS NOT NULL;
+CREATE INDEX idx_document_pages_claim ON document_pages(claim_id);
+CREATE INDEX idx_document_pages_search ON document_pages USING GIN(search_vector);
+
+ALTER TABLE documents ADD COLUMN text_extraction_status VARCHAR(20) NOT NULL DEFAULT 'pending'
+    CHECK (text_extraction_status IN ('pending', 'processing', 'completed', 'failed', 'unsupported'));
+ALTER TABLE documents ADD COLUMN text_extraction_error TEXT;
+ALTER TABLE documents ADD COLUMN text_extracted_at TIMESTAMP WITH TIME ZONE;
+
+ALTER TABLE carrier_estimates ADD COLUMN text_extraction_status VARCHAR(20) NOT NULL DEFAULT 'pending'
+    CHECK (text_extraction_status IN ('pending', 'processing', 'completed', 'failed', 'unsupported'));
+ALTER TABLE carrier_estimates ADD COLUMN text_extraction_error TEXT;
+ALTER TABLE carrier_estimates ADD COLUMN text_extracted_at TIMESTAMP WITH TIME ZONE;
@@ -0,0 +1,11 @@
+-- Rollback Magic Link Scopes & Revocation
+
+UPDATE magic_links SET status = 'expired' WHERE status = 'revoked';
+
+ALTER TABLE magic_links DROP CONSTRAINT IF EXISTS magic_links_status_check;
+ALTER TABLE magic_links ADD CONSTRAINT magic_links_status_check
+    CHECK (status IN ('active', 'expired', 'completed'));
+
+ALTER TABLE magic_links DROP COLUMN IF EXISTS revoked_by_user_id;
+ALTER TABLE magic_links DROP COLUMN IF EXISTS revoked_at;
+ALTER TABLE magic_links DROP COLUMN IF EXISTS scope;
@@ -0,0 +1,12 @@
+-- Magic Link Scopes & Revocation
+-- A link grants a specific set of contractor actions and can be revoked by the PM.
+
+ALTER TABLE magic_links ADD COLUMN scope TEXT NOT NULL DEFAULT 'full'
+    CHECK (scope IN ('photo', 'scope_sheet', 'proof_of_repair', 'full'));
+
+ALTER TABLE magic_links ADD COLUMN revoked_at TIMESTAMP;
+ALTER TABLE magic_links ADD COLUMN revoked_by_user_id UUID REFERENCES users(id);
+
+ALTER TABLE magic_links DROP CONSTRAINT IF EXISTS magic_links_status_check;
+ALTER TABLE magic_links ADD CONSTRAINT magic_links_status_check
+    CHECK (status IN ('active', 'expired', 'completed', 'revoked'));
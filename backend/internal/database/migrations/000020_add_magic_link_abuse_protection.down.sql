@@ -0,0 +1,18 @@
+-- Rollback Magic Link Abuse Protection
+
+DROP TABLE IF EXISTS rate_limit_buckets;
+
+UPDATE magic_links SET status = 'revoked' WHERE status = 'locked';
+
+ALTER TABLE magic_links DROP CONSTRAINT IF EXISTS magic_links_status_check;
+ALTER TABLE magic_links ADD CONSTRAINT magic_links_status_check
+    CHECK (status IN ('active', 'expired', 'completed', 'revoked'));
+
+ALTER TABLE magic_links DROP COLUMN IF EXISTS last_rate_limited_at;
+ALTER TABLE magic_links DROP COLUMN IF EXISTS rate_limited_count;
+ALTER TABLE magic_links DROP COLUMN IF EXISTS locked_at;
+ALTER TABLE magic_links DROP COLUMN IF EXISTS failed_attempt_count;
+ALTER TABLE magic_links DROP COLUMN IF EXISTS upload_bytes;
+ALTER TABLE magic_links DROP COLUMN IF EXISTS upload_count;
+ALTER TABLE magic_links DROP COLUMN IF EXISTS max_upload_bytes;
+ALTER TABLE magic_links DROP COLUMN IF EXISTS max_uploads;
@@ -0,0 +1,26 @@
+-- Magic Link Abuse Protection
+-- Upload quotas, lockout after repeated failed attempts, and rate-limit tracking per link.
+
+ALTER TABLE magic_links ADD COLUMN max_uploads INTEGER NOT NULL DEFAULT 200;
+ALTER TABLE magic_links ADD COLUMN max_upload_bytes BIGINT NOT NULL DEFAULT 2147483648;
+ALTER TABLE magic_links ADD COLUMN upload_count INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE magic_links ADD COLUMN upload_bytes BIGINT NOT NULL DEFAULT 0;
+
+ALTER TABLE magic_links ADD COLUMN failed_attempt_count INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE magic_links ADD COLUMN locked_at TIMESTAMP;
+
+ALTER TABLE magic_links ADD COLUMN rate_limited_count INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE magic_links ADD COLUMN last_rate_limited_at TIMESTAMP;
+
+ALTER TABLE magic_links DROP CONSTRAINT IF EXISTS magic_links_status_check;
+ALTER TABLE magic_links ADD CONSTRAINT magic_links_status_check
+    CHECK (status IN ('active', 'expired', 'completed', 'revoked', 'locked'));
+
+-- Fixed-window rate limit counters shared across Lambda instances
+CREATE TABLE rate_limit_buckets (
+    key TEXT PRIMARY KEY,
+    window_start TIMESTAMP NOT NULL DEFAULT NOW(),
+    count INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE INDEX idx_rate_limit_buckets_window_start ON rate_limit_buckets(window_start);
@@ -0,0 +1,9 @@
+-- Rollback Contractor Directory
+
+DROP INDEX IF EXISTS idx_magic_links_contractor;
+DROP INDEX IF EXISTS idx_claims_contractor;
+
+ALTER TABLE magic_links DROP COLUMN IF EXISTS contractor_id;
+ALTER TABLE claims DROP COLUMN IF EXISTS contractor_id;
+
+DROP TABLE IF EXISTS contractors;
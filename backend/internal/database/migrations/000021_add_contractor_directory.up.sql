@@ -0,0 +1,63 @@
+-- Contractor Directory
+-- Reusable contractor profiles per organization, referenced by claims and magic links
+-- instead of free-text name/email fields.
+
+CREATE TABLE contractors (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    organization_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    company_name TEXT,
+    trade TEXT NOT NULL DEFAULT 'general'
+        CHECK (trade IN ('general', 'roofing', 'water_mitigation', 'plumbing', 'electrical', 'hvac', 'siding', 'painting', 'flooring', 'other')),
+    email TEXT,
+    phone TEXT,
+    license_number TEXT,
+    insurance_certificate_expires_at DATE,
+    notes TEXT,
+    is_active BOOLEAN NOT NULL DEFAULT true,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_contractors_organization ON contractors(organization_id, name);
+CREATE UNIQUE INDEX idx_contractors_org_email ON contractors(organization_id, LOWER(email)) WHERE email IS NOT NULL;
+
+ALTER TABLE claims ADD COLUMN contractor_id UUID REFERENCES contractors(id) ON DELETE SET NULL;
+ALTER TABLE magic_links ADD COLUMN contractor_id UUID REFERENCES contractors(id) ON DELETE SET NULL;
+
+CREATE INDEX idx_claims_contractor ON claims(contractor_id);
+CREATE INDEX idx_magic_links_contractor ON magic_links(contractor_id);
+
+-- Backfill: one contractor per organization and email, named after the most
+-- recent link or claim that used that email
+WITH contacts AS (
+    SELECT p.organization_id, ml.contractor_name AS name, ml.contractor_email AS email,
+           ml.contractor_phone AS phone, ml.created_at
+    FROM magic_links ml
+    JOIN claims c ON c.id = ml.claim_id
+    JOIN properties p ON p.id = c.property_id
+    WHERE ml.contractor_email <> ''
+    UNION ALL
+    SELECT p.organization_id, COALESCE(NULLIF(c.contractor_name, ''), c.contractor_email), c.contractor_email,
+           NULL, c.updated_at
+    FROM claims c
+    JOIN properties p ON p.id = c.property_id
+    WHERE c.contractor_email IS NOT NULL AND c.contractor_email <> ''
+)
+INSERT INTO contractors (organization_id, name, email, phone)
+SELECT DISTINCT ON (organization_id, LOWER(email)) organization_id, name, LOWER(email), phone
+FROM contacts
+ORDER BY organization_id, LOWER(email), created_at DESC;
+
+UPDATE magic_links ml
+SET contractor_id = ct.id
+FROM claims c
+JOIN properties p ON p.id = c.property_id
+JOIN contractors ct ON ct.organization_id = p.organization_id
+WHERE c.id = ml.claim_id AND LOWER(ml.contractor_email) = ct.email;
+
+UPDATE claims c
+SET contractor_id = ct.id
+FROM properties p
+JOIN contractors ct ON ct.organization_id = p.organization_id
+WHERE p.id = c.property_id AND LOWER(c.contractor_email) = ct.email;
@@ -0,0 +1,7 @@
+-- Rollback Magic Link Reminders
+
+DROP INDEX IF EXISTS idx_magic_links_active_expiry;
+
+ALTER TABLE magic_links DROP COLUMN IF EXISTS reissued_from_id;
+
+DROP TABLE IF EXISTS magic_link_reminders;
@@ -0,0 +1,19 @@
+-- Magic Link Reminders
+-- Each reminder kind is sent at most once per link; the row doubles as the send log.
+-- 'expired_unused' is the notice to the PM who created a link that expired without a response.
+
+CREATE TABLE magic_link_reminders (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    magic_link_id UUID NOT NULL REFERENCES magic_links(id) ON DELETE CASCADE,
+    kind TEXT NOT NULL
+        CHECK (kind IN ('never_opened', 'draft_not_submitted', 'expiring_soon', 'expired_unused')),
+    recipient_email TEXT NOT NULL,
+    sent_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    email_error TEXT,
+    UNIQUE (magic_link_id, kind)
+);
+
+-- Reissued links point back at the link they replace
+ALTER TABLE magic_links ADD COLUMN reissued_from_id UUID REFERENCES magic_links(id) ON DELETE SET NULL;
+
+CREATE INDEX idx_magic_links_active_expiry ON magic_links(expires_at) WHERE status = 'active';
@@ -0,0 +1,12 @@
+-- Rollback Magic Link SMS Delivery
+
+DROP TABLE IF EXISTS sms_opt_outs;
+
+DROP INDEX IF EXISTS idx_magic_links_sms_message;
+
+ALTER TABLE magic_links DROP COLUMN IF EXISTS sms_error;
+ALTER TABLE magic_links DROP COLUMN IF EXISTS sms_message_id;
+ALTER TABLE magic_links DROP COLUMN IF EXISTS sms_status;
+ALTER TABLE magic_links DROP COLUMN IF EXISTS sms_sent_at;
+ALTER TABLE magic_links DROP COLUMN IF EXISTS sms_sent;
+ALTER TABLE magic_links DROP COLUMN IF EXISTS delivery_channel;
@@ -0,0 +1,22 @@
+-- Magic Link SMS Delivery
+-- Links can go out by email, SMS or both. SMS delivery status is tracked next to
+-- the email columns and updated from the provider's status callbacks.
+
+ALTER TABLE magic_links ADD COLUMN delivery_channel TEXT NOT NULL DEFAULT 'email'
+    CHECK (delivery_channel IN ('email', 'sms', 'both'));
+
+ALTER TABLE magic_links ADD COLUMN sms_sent BOOLEAN NOT NULL DEFAULT false;
+ALTER TABLE magic_links ADD COLUMN sms_sent_at TIMESTAMP;
+ALTER TABLE magic_links ADD COLUMN sms_status TEXT;
+ALTER TABLE magic_links ADD COLUMN sms_message_id TEXT;
+ALTER TABLE magic_links ADD COLUMN sms_error TEXT;
+
+CREATE INDEX idx_magic_links_sms_message ON magic_links(sms_message_id) WHERE sms_message_id IS NOT NULL;
+
+-- Numbers that replied STOP. Opt-outs apply to our sending number, so they are
+-- global rather than per organization; START removes the row.
+CREATE TABLE sms_opt_outs (
+    phone_number TEXT PRIMARY KEY, -- E.164
+    keyword TEXT NOT NULL,
+    opted_out_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,15 @@
+-- Rollback Scope Sheet Revisions & Review
+
+ALTER TABLE audit_reports DROP COLUMN IF EXISTS scope_sheet_revision;
+
+DROP TABLE IF EXISTS scope_sheet_comments;
+
+DROP INDEX IF EXISTS idx_scope_sheets_claim_revision;
+
+ALTER TABLE scope_sheets DROP COLUMN IF EXISTS reopened_from_id;
+ALTER TABLE scope_sheets DROP COLUMN IF EXISTS magic_link_id;
+ALTER TABLE scope_sheets DROP COLUMN IF EXISTS review_notes;
+ALTER TABLE scope_sheets DROP COLUMN IF EXISTS reviewed_by_user_id;
+ALTER TABLE scope_sheets DROP COLUMN IF EXISTS reviewed_at;
+ALTER TABLE scope_sheets DROP COLUMN IF EXISTS review_status;
+ALTER TABLE scope_sheets DROP COLUMN IF EXISTS revision;
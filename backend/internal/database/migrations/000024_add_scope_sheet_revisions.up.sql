@@ -0,0 +1,49 @@
+-- Scope Sheet Revisions & Review
+-- Every contractor submission is kept as a numbered revision that the PM either
+-- approves or sends back with comments. Sending it back reopens a draft seeded
+-- from that revision, and audits record which revision they were built from.
+
+ALTER TABLE scope_sheets ADD COLUMN revision INTEGER;
+ALTER TABLE scope_sheets ADD COLUMN review_status TEXT
+    CHECK (review_status IN ('pending_review', 'approved', 'changes_requested', 'superseded'));
+ALTER TABLE scope_sheets ADD COLUMN reviewed_at TIMESTAMP;
+ALTER TABLE scope_sheets ADD COLUMN reviewed_by_user_id UUID REFERENCES users(id);
+ALTER TABLE scope_sheets ADD COLUMN review_notes TEXT;
+ALTER TABLE scope_sheets ADD COLUMN magic_link_id UUID REFERENCES magic_links(id) ON DELETE SET NULL;
+-- On a draft: the revision whose requested changes it addresses
+ALTER TABLE scope_sheets ADD COLUMN reopened_from_id UUID REFERENCES scope_sheets(id) ON DELETE SET NULL;
+
+-- Number existing submissions per claim; the newest awaits review, older ones are superseded
+WITH numbered AS (
+    SELECT id,
+           ROW_NUMBER() OVER (PARTITION BY claim_id ORDER BY created_at) AS revision,
+           ROW_NUMBER() OVER (PARTITION BY claim_id ORDER BY created_at DESC) AS recency
+    FROM scope_sheets
+    WHERE is_draft = false
+)
+UPDATE scope_sheets ss
+SET revision = numbered.revision,
+    review_status = CASE WHEN numbered.recency = 1 THEN 'pending_review' ELSE 'superseded' END
+FROM numbered
+WHERE ss.id = numbered.id;
+
+CREATE UNIQUE INDEX idx_scope_sheets_claim_revision ON scope_sheets(claim_id, revision) WHERE revision IS NOT NULL;
+
+-- PM comments on a revision, optionally pinned to one area (ScopeArea.id in the areas JSON)
+CREATE TABLE scope_sheet_comments (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    scope_sheet_id UUID NOT NULL REFERENCES scope_sheets(id) ON DELETE CASCADE,
+    area_id TEXT,
+    comment TEXT NOT NULL,
+    created_by_user_id UUID NOT NULL REFERENCES users(id),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_scope_sheet_comments_sheet ON scope_sheet_comments(scope_sheet_id, created_at);
+
+ALTER TABLE audit_reports ADD COLUMN scope_sheet_revision INTEGER;
+
+UPDATE audit_reports ar
+SET scope_sheet_revision = ss.revision
+FROM scope_sheets ss
+WHERE ss.id = ar.scope_sheet_id;
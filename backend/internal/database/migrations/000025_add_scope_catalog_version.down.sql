@@ -0,0 +1,3 @@
+-- Rollback Scope Catalog Version
+
+ALTER TABLE scope_sheets DROP COLUMN IF EXISTS catalog_version;
@@ -0,0 +1,5 @@
+-- Scope Catalog Version
+-- Scope sheets record the category catalog version they were validated against,
+-- so tags and dimensions stay interpretable after the catalog changes.
+
+ALTER TABLE scope_sheets ADD COLUMN catalog_version TEXT;
@@ -0,0 +1,3 @@
+-- Rollback Audit Takeoff
+
+ALTER TABLE audit_reports DROP COLUMN IF EXISTS takeoff;
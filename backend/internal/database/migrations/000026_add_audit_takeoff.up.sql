@@ -0,0 +1,5 @@
+-- Audit Takeoff
+-- The deterministic quantity takeoff the estimate was priced from, so the
+-- quantities shown in the audit can be reproduced and checked.
+
+ALTER TABLE audit_reports ADD COLUMN takeoff JSONB;
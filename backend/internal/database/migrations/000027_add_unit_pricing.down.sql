@@ -0,0 +1,5 @@
+-- Rollback Unit Pricing
+
+DROP TABLE IF EXISTS org_unit_prices;
+DROP TABLE IF EXISTS unit_prices;
+DROP TABLE IF EXISTS price_lists;
@@ -0,0 +1,46 @@
+-- Unit Pricing
+-- Unit costs per line-item code, region and effective date. Price lists are
+-- imported from CSV; organizations can override individual codes. Industry
+-- estimates are priced from these tables, not by the LLM.
+
+CREATE TABLE price_lists (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name TEXT NOT NULL,
+    source TEXT,
+    row_count INTEGER NOT NULL DEFAULT 0,
+    imported_by_user_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    imported_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE unit_prices (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    price_list_id UUID NOT NULL REFERENCES price_lists(id) ON DELETE CASCADE,
+    code TEXT NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    unit TEXT NOT NULL,
+    region TEXT NOT NULL,
+    effective_date DATE NOT NULL,
+    material_cost DECIMAL(12, 2),
+    labor_cost DECIMAL(12, 2),
+    unit_cost DECIMAL(12, 2) NOT NULL CHECK (unit_cost >= 0),
+    UNIQUE (price_list_id, code, region, effective_date)
+);
+
+CREATE INDEX idx_unit_prices_lookup ON unit_prices(code, region, effective_date DESC);
+
+-- Per-organization overrides. An empty region applies in every region.
+CREATE TABLE org_unit_prices (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    organization_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    code TEXT NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    unit TEXT NOT NULL,
+    unit_cost DECIMAL(12, 2) NOT NULL CHECK (unit_cost >= 0),
+    region TEXT NOT NULL DEFAULT '',
+    effective_date DATE NOT NULL,
+    notes TEXT,
+    created_by_user_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE (organization_id, code, region, effective_date)
+);
@@ -0,0 +1,10 @@
+-- Rollback Regional Cost Modifiers
+
+DROP TABLE IF EXISTS regional_cost_modifiers;
+
+DROP INDEX IF EXISTS idx_properties_address_zip;
+ALTER TABLE properties DROP COLUMN IF EXISTS address_county;
+ALTER TABLE properties DROP COLUMN IF EXISTS address_zip;
+ALTER TABLE properties DROP COLUMN IF EXISTS address_state;
+ALTER TABLE properties DROP COLUMN IF EXISTS address_city;
+ALTER TABLE properties DROP COLUMN IF EXISTS address_street;
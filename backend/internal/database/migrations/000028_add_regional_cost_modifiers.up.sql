@@ -0,0 +1,38 @@
+-- Regional Cost Modifiers
+-- Properties get structured address components so each one can be matched to
+-- its cost region, and regional modifiers scale national unit prices to the
+-- local market.
+
+ALTER TABLE properties ADD COLUMN address_street TEXT;
+ALTER TABLE properties ADD COLUMN address_city TEXT;
+ALTER TABLE properties ADD COLUMN address_state TEXT;
+ALTER TABLE properties ADD COLUMN address_zip TEXT;
+-- Not part of a mailing address, so entered by the user rather than parsed
+ALTER TABLE properties ADD COLUMN address_county TEXT;
+
+-- Best-effort backfill of "…, ST 12345" addresses; the application re-parses
+-- the full address whenever a property's address is saved
+UPDATE properties
+SET address_zip = substring(legal_address from '(\d{5})(?:-\d{4})?\s*$'),
+    address_state = upper(substring(legal_address from ',\s*([A-Za-z]{2})\s+\d{5}(?:-\d{4})?\s*$'));
+
+CREATE INDEX idx_properties_address_zip ON properties(address_zip);
+
+-- A modifier applies to a ZIP, a county, or (with neither) a whole state. The
+-- most specific match wins. Region names the price lists the location uses.
+CREATE TABLE regional_cost_modifiers (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    state TEXT NOT NULL,
+    county TEXT NOT NULL DEFAULT '',
+    zip TEXT NOT NULL DEFAULT '',
+    region TEXT NOT NULL DEFAULT 'national',
+    factor DECIMAL(6, 4) NOT NULL CHECK (factor > 0),
+    effective_date DATE NOT NULL,
+    source TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CHECK (zip = '' OR county = ''),
+    UNIQUE (state, county, zip, effective_date)
+);
+
+CREATE INDEX idx_regional_cost_modifiers_zip ON regional_cost_modifiers(zip) WHERE zip <> '';
@@ -0,0 +1,3 @@
+-- Rollback Regional Tax Rate
+
+ALTER TABLE regional_cost_modifiers DROP COLUMN IF EXISTS tax_rate;
@@ -0,0 +1,6 @@
+-- Regional Tax Rate
+-- Sales tax on repairs varies by location, so it is stored alongside the
+-- regional cost modifier and applied to generated estimates.
+
+ALTER TABLE regional_cost_modifiers
+    ADD COLUMN tax_rate DECIMAL(6, 4) NOT NULL DEFAULT 0 CHECK (tax_rate >= 0 AND tax_rate < 1);
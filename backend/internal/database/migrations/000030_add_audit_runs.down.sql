@@ -0,0 +1,4 @@
+-- Rollback Audit Runs
+
+DROP TABLE IF EXISTS audit_runs;
+DROP FUNCTION IF EXISTS reject_audit_run_update();
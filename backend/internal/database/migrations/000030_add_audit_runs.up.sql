@@ -0,0 +1,70 @@
+-- Audit Runs
+-- Every audit step (estimate generation, PM Brain, viability, dispute letter,
+-- owner pitch) is kept as an immutable snapshot of the report it produced and
+-- the input versions it ran against, so a claim's analysis history survives
+-- supplements and re-runs. audit_reports remains the latest working state.
+
+CREATE TABLE audit_runs (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    audit_report_id UUID NOT NULL REFERENCES audit_reports(id) ON DELETE CASCADE,
+    claim_id UUID NOT NULL REFERENCES claims(id) ON DELETE CASCADE,
+    kind TEXT NOT NULL CHECK (kind IN ('backfill', 'estimate', 'pm_brain', 'viability', 'dispute_letter', 'owner_pitch')),
+
+    -- Input versions. Not foreign keys: a snapshot keeps naming the versions it
+    -- used even after they are deleted.
+    scope_sheet_id UUID,
+    scope_sheet_revision INTEGER,
+    carrier_estimate_id UUID,
+    carrier_estimate_version INTEGER,
+    contractor_estimate_document_id UUID,
+    contractor_estimate_version INTEGER,
+    policy_id UUID,
+    policy_updated_at TIMESTAMP,
+    policy_document_id UUID,
+    policy_document_version INTEGER,
+
+    -- Outputs as of this run
+    generated_estimate JSONB,
+    takeoff JSONB,
+    pm_brain_analysis TEXT,
+    viability_analysis TEXT,
+    dispute_letter TEXT,
+    owner_pitch TEXT,
+    total_contractor_estimate DECIMAL(12, 2),
+    total_carrier_estimate DECIMAL(12, 2),
+    total_delta DECIMAL(12, 2),
+
+    created_by_user_id UUID REFERENCES users(id),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_audit_runs_claim ON audit_runs(claim_id, created_at DESC);
+CREATE INDEX idx_audit_runs_report ON audit_runs(audit_report_id);
+
+-- Snapshots are never edited. Deletes still cascade from their claim.
+CREATE FUNCTION reject_audit_run_update() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'audit runs are immutable';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_runs_immutable
+    BEFORE UPDATE ON audit_runs
+    FOR EACH ROW EXECUTE FUNCTION reject_audit_run_update();
+
+-- Keep the current state of existing reports as their first snapshot
+INSERT INTO audit_runs (
+    audit_report_id, claim_id, kind, scope_sheet_id, scope_sheet_revision,
+    carrier_estimate_id, carrier_estimate_version, contractor_estimate_document_id, contractor_estimate_version,
+    generated_estimate, takeoff, pm_brain_analysis, viability_analysis, dispute_letter, owner_pitch,
+    total_contractor_estimate, total_carrier_estimate, total_delta, created_by_user_id, created_at
+)
+SELECT id, claim_id, 'backfill', scope_sheet_id, scope_sheet_revision,
+       carrier_estimate_id, carrier_estimate_version, contractor_estimate_document_id, contractor_estimate_version,
+       generated_estimate, takeoff, pm_brain_analysis, viability_analysis, dispute_letter, owner_pitch,
+       COALESCE((pm_brain_analysis::jsonb->>'total_contractor_estimate')::numeric,
+                (generated_estimate->>'total')::numeric, total_contractor_estimate),
+       COALESCE((pm_brain_analysis::jsonb->>'total_carrier_estimate')::numeric, total_carrier_estimate),
+       COALESCE((pm_brain_analysis::jsonb->>'total_delta')::numeric, total_delta),
+       created_by_user_id, updated_at
+FROM audit_reports;
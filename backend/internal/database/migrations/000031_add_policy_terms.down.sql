@@ -0,0 +1,3 @@
+-- Rollback Policy Terms
+
+DROP TABLE IF EXISTS policy_terms;
@@ -0,0 +1,37 @@
+-- Policy Terms
+-- Coverage terms extracted from the policy PDF by the LLM. Each extraction is
+-- kept as a row a PM reviews, corrects and confirms; only confirmed terms are
+-- used by viability and PM Brain analysis. Confirming a new extraction
+-- supersedes the previously confirmed terms.
+
+CREATE TABLE policy_terms (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    policy_id UUID NOT NULL REFERENCES insurance_policies(id) ON DELETE CASCADE,
+    review_status TEXT NOT NULL DEFAULT 'pending_review'
+        CHECK (review_status IN ('pending_review', 'confirmed', 'superseded')),
+    source_pdf_url TEXT,
+    coverage_a_limit DECIMAL(12, 2) CHECK (coverage_a_limit >= 0),
+    coverage_b_limit DECIMAL(12, 2) CHECK (coverage_b_limit >= 0),
+    coverage_c_limit DECIMAL(12, 2) CHECK (coverage_c_limit >= 0),
+    coverage_d_limit DECIMAL(12, 2) CHECK (coverage_d_limit >= 0),
+    -- A wind_hail deductible applies to wind and hail losses; other perils
+    -- keep the policy's flat deductible_value
+    deductible_type TEXT CHECK (deductible_type IN ('flat', 'percentage', 'wind_hail')),
+    deductible_amount DECIMAL(12, 2) CHECK (deductible_amount >= 0),
+    -- Fraction of the Coverage A limit, e.g. 0.02 for 2%
+    deductible_percentage DECIMAL(6, 4) CHECK (deductible_percentage > 0 AND deductible_percentage < 1),
+    roof_settlement TEXT CHECK (roof_settlement IN ('acv', 'rcv')),
+    matching_endorsement BOOLEAN,
+    ordinance_and_law_endorsement BOOLEAN,
+    ordinance_and_law_limit DECIMAL(12, 2) CHECK (ordinance_and_law_limit >= 0),
+    exclusions JSONB NOT NULL DEFAULT '[]',
+    -- What the extractor could not read or had to discard, for the reviewer
+    extraction_notes JSONB NOT NULL DEFAULT '[]',
+    reviewed_by_user_id UUID REFERENCES users(id),
+    reviewed_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_policy_terms_policy ON policy_terms(policy_id, created_at DESC);
+CREATE UNIQUE INDEX idx_policy_terms_one_confirmed ON policy_terms(policy_id) WHERE review_status = 'confirmed';
@@ -0,0 +1,3 @@
+-- Rollback Deductible Rules
+
+DROP TABLE IF EXISTS policy_deductible_rules;
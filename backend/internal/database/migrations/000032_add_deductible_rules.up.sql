@@ -0,0 +1,44 @@
+-- Deductible Rules
+-- Deductibles modeled per peril. A claim's deductible comes from the rule that
+-- most specifically matches its loss type and date; claims no rule matches use
+-- the policy's flat deductible_value.
+
+CREATE TABLE policy_deductible_rules (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    policy_id UUID NOT NULL REFERENCES insurance_policies(id) ON DELETE CASCADE,
+    peril TEXT NOT NULL
+        CHECK (peril IN ('all_perils', 'wind_hail', 'named_storm', 'fire', 'water', 'wind', 'hail', 'other')),
+    basis TEXT NOT NULL CHECK (basis IN ('flat', 'percentage')),
+    amount DECIMAL(12, 2) CHECK (amount >= 0),
+    -- Fraction of the Coverage A limit, e.g. 0.02 for 2%
+    percentage DECIMAL(6, 4) CHECK (percentage > 0 AND percentage < 1),
+    minimum_amount DECIMAL(12, 2) CHECK (minimum_amount >= 0),
+    -- A rule only applies to losses within its dates; named storm rules cover
+    -- one storm, so they always have dates
+    starts_on DATE,
+    ends_on DATE,
+    -- policy_terms rules are rewritten whenever policy terms are confirmed
+    source TEXT NOT NULL DEFAULT 'manual' CHECK (source IN ('manual', 'policy_terms')),
+    created_by_user_id UUID REFERENCES users(id),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CHECK ((basis = 'flat' AND amount IS NOT NULL) OR (basis = 'percentage' AND percentage IS NOT NULL)),
+    CHECK (peril <> 'named_storm' OR (starts_on IS NOT NULL AND ends_on IS NOT NULL)),
+    CHECK (starts_on IS NULL OR ends_on IS NULL OR ends_on >= starts_on)
+);
+
+CREATE INDEX idx_policy_deductible_rules_policy ON policy_deductible_rules(policy_id);
+
+-- Rules for policy terms confirmed before rules existed
+INSERT INTO policy_deductible_rules (policy_id, peril, basis, amount, percentage, source, created_by_user_id)
+SELECT policy_id,
+       CASE WHEN deductible_type = 'wind_hail' THEN 'wind_hail' ELSE 'all_perils' END,
+       CASE WHEN deductible_type = 'flat' OR deductible_percentage IS NULL THEN 'flat' ELSE 'percentage' END,
+       deductible_amount,
+       CASE WHEN deductible_type = 'flat' THEN NULL ELSE deductible_percentage END,
+       'policy_terms',
+       reviewed_by_user_id
+FROM policy_terms
+WHERE review_status = 'confirmed'
+  AND deductible_type IS NOT NULL
+  AND (deductible_amount IS NOT NULL OR (deductible_type <> 'flat' AND deductible_percentage IS NOT NULL));
@@ -0,0 +1,3 @@
+-- Rollback Policy Questions
+
+DROP TABLE IF EXISTS policy_questions;
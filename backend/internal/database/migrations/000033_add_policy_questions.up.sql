@@ -0,0 +1,21 @@
+-- Policy Questions
+-- A claim's policy Q&A conversation. Each row is one question and the answer
+-- grounded in the policy PDF, with the page-cited quotes it relies on. An
+-- answer saved as a claim note links to the comment activity it created.
+
+CREATE TABLE policy_questions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    claim_id UUID NOT NULL REFERENCES claims(id) ON DELETE CASCADE,
+    policy_id UUID NOT NULL REFERENCES insurance_policies(id) ON DELETE CASCADE,
+    user_id UUID REFERENCES users(id),
+    question TEXT NOT NULL,
+    answer TEXT NOT NULL,
+    -- [{"page": 12, "quote": "..."}]
+    citations JSONB NOT NULL DEFAULT '[]',
+    -- False when no quoted provision of the policy answers the question
+    answered BOOLEAN NOT NULL,
+    note_activity_id UUID REFERENCES claim_activities(id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_policy_questions_claim ON policy_questions(claim_id, created_at);
@@ -0,0 +1,3 @@
+-- Rollback Supplements
+
+DROP TABLE IF EXISTS supplements;
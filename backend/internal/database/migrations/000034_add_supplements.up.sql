@@ -0,0 +1,27 @@
+-- Supplements
+-- Line-item supplement requests built from the comparison of the industry
+-- estimate with the carrier estimate. Items are stored as generated so the
+-- PDF and CSV sent to the adjuster can always be reproduced; status tracks the
+-- request from draft through the carrier's response.
+
+CREATE TABLE supplements (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    claim_id UUID NOT NULL REFERENCES claims(id) ON DELETE CASCADE,
+    supplement_number INTEGER NOT NULL,
+    audit_report_id UUID REFERENCES audit_reports(id) ON DELETE SET NULL,
+    carrier_estimate_id UUID REFERENCES carrier_estimates(id) ON DELETE SET NULL,
+    status TEXT NOT NULL DEFAULT 'draft'
+        CHECK (status IN ('draft', 'sent', 'approved', 'partially_approved', 'denied')),
+    items JSONB NOT NULL DEFAULT '[]',
+    total_requested DECIMAL(12, 2) NOT NULL,
+    approved_amount DECIMAL(12, 2) CHECK (approved_amount >= 0),
+    carrier_response TEXT,
+    sent_at TIMESTAMP,
+    responded_at TIMESTAMP,
+    created_by_user_id UUID REFERENCES users(id),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE (claim_id, supplement_number)
+);
+
+CREATE INDEX idx_supplements_claim ON supplements(claim_id, supplement_number);
@@ -0,0 +1,4 @@
+-- Rollback Appraisals
+
+DROP TABLE IF EXISTS appraisal_parties;
+DROP TABLE IF EXISTS appraisals;
@@ -0,0 +1,46 @@
+-- Appraisals
+-- Invoking the policy's appraisal condition when a dispute over the amount of
+-- loss stalls. Each appraisal keeps its demand letter, the selection deadlines
+-- the condition sets, the appraisers and umpire, and the award per coverage.
+-- Only one appraisal per claim can be open at a time.
+
+CREATE TABLE appraisals (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    claim_id UUID NOT NULL REFERENCES claims(id) ON DELETE CASCADE,
+    status TEXT NOT NULL DEFAULT 'draft'
+        CHECK (status IN ('draft', 'invoked', 'appraisers_selected', 'umpire_selected', 'awarded', 'withdrawn')),
+    disputed_amount DECIMAL(12, 2) CHECK (disputed_amount >= 0),
+    dispute_summary TEXT,
+    demand_letter TEXT NOT NULL,
+    invoked_at TIMESTAMP,
+    appraiser_deadline TIMESTAMP,
+    umpire_deadline TIMESTAMP,
+    award JSONB NOT NULL DEFAULT '[]',
+    award_rcv_total DECIMAL(12, 2),
+    award_acv_total DECIMAL(12, 2),
+    awarded_at TIMESTAMP,
+    acv_payment_id UUID REFERENCES payments(id) ON DELETE SET NULL,
+    rcv_payment_id UUID REFERENCES payments(id) ON DELETE SET NULL,
+    created_by_user_id UUID REFERENCES users(id),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_appraisals_claim ON appraisals(claim_id, created_at);
+CREATE UNIQUE INDEX idx_appraisals_open_per_claim ON appraisals(claim_id)
+    WHERE status NOT IN ('awarded', 'withdrawn');
+
+-- The appraiser each side selects and the umpire they agree on
+CREATE TABLE appraisal_parties (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    appraisal_id UUID NOT NULL REFERENCES appraisals(id) ON DELETE CASCADE,
+    role TEXT NOT NULL CHECK (role IN ('our_appraiser', 'carrier_appraiser', 'umpire')),
+    name TEXT NOT NULL,
+    firm TEXT,
+    email TEXT,
+    phone TEXT,
+    selected_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE (appraisal_id, role)
+);
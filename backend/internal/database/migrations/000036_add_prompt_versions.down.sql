@@ -0,0 +1,6 @@
+-- Rollback Prompt Versions
+
+ALTER TABLE carrier_estimates DROP COLUMN IF EXISTS parse_prompt_version;
+ALTER TABLE rcv_demand_letters DROP COLUMN IF EXISTS prompt_version;
+ALTER TABLE audit_runs DROP COLUMN IF EXISTS prompt_versions;
+ALTER TABLE audit_reports DROP COLUMN IF EXISTS prompt_versions;
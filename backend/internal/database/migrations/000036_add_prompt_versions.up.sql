@@ -0,0 +1,10 @@
+-- Prompt Versions
+-- Records which version of each prompt template generated an artifact, so a
+-- change in output can be traced to a prompt change. Audit reports and runs
+-- key the version ID (e.g. "pm_brain/v1") by prompt name since one report
+-- holds the output of several prompts.
+
+ALTER TABLE audit_reports ADD COLUMN prompt_versions JSONB NOT NULL DEFAULT '{}';
+ALTER TABLE audit_runs ADD COLUMN prompt_versions JSONB NOT NULL DEFAULT '{}';
+ALTER TABLE rcv_demand_letters ADD COLUMN prompt_version TEXT;
+ALTER TABLE carrier_estimates ADD COLUMN parse_prompt_version TEXT;
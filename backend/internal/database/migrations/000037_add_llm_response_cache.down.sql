@@ -0,0 +1,12 @@
+-- Rollback LLM Response Cache
+
+DROP INDEX IF EXISTS idx_api_logs_organization;
+ALTER TABLE api_usage_logs DROP COLUMN IF EXISTS cache_hit;
+ALTER TABLE api_usage_logs DROP COLUMN IF EXISTS total_tokens;
+ALTER TABLE api_usage_logs DROP COLUMN IF EXISTS completion_tokens;
+ALTER TABLE api_usage_logs DROP COLUMN IF EXISTS prompt_tokens;
+ALTER TABLE api_usage_logs DROP COLUMN IF EXISTS endpoint;
+ALTER TABLE api_usage_logs DROP COLUMN IF EXISTS model;
+ALTER TABLE api_usage_logs DROP COLUMN IF EXISTS organization_id;
+
+DROP TABLE IF EXISTS llm_response_cache;
@@ -0,0 +1,34 @@
+-- LLM Response Cache
+-- Content-addressed cache of LLM responses, so regenerating an estimate from an
+-- unchanged scope sheet or re-parsing the same carrier PDF doesn't pay for a
+-- second call. cache_key hashes the provider, model, prompt version and
+-- input_hash, the hash of the normalized messages or PDF bytes.
+
+CREATE TABLE llm_response_cache (
+    cache_key TEXT PRIMARY KEY,
+    provider TEXT NOT NULL,
+    model TEXT NOT NULL,
+    prompt_version TEXT NOT NULL,
+    input_hash TEXT NOT NULL,
+    response TEXT NOT NULL,
+    hit_count INTEGER NOT NULL DEFAULT 0,
+    last_hit_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX idx_llm_response_cache_expires ON llm_response_cache(expires_at);
+
+-- The services log usage per organization and model, which the original
+-- api_usage_logs columns couldn't hold. Cache hits are logged at zero cost.
+ALTER TABLE api_usage_logs ALTER COLUMN api_call_type DROP NOT NULL;
+ALTER TABLE api_usage_logs DROP CONSTRAINT IF EXISTS api_usage_logs_api_call_type_check;
+ALTER TABLE api_usage_logs ADD COLUMN organization_id UUID REFERENCES organizations(id) ON DELETE CASCADE;
+ALTER TABLE api_usage_logs ADD COLUMN model TEXT;
+ALTER TABLE api_usage_logs ADD COLUMN endpoint TEXT;
+ALTER TABLE api_usage_logs ADD COLUMN prompt_tokens INTEGER;
+ALTER TABLE api_usage_logs ADD COLUMN completion_tokens INTEGER;
+ALTER TABLE api_usage_logs ADD COLUMN total_tokens INTEGER;
+ALTER TABLE api_usage_logs ADD COLUMN cache_hit BOOLEAN NOT NULL DEFAULT false;
+
+CREATE INDEX idx_api_logs_organization ON api_usage_logs(organization_id);
@@ -0,0 +1,6 @@
+-- Rollback Carrier Estimate Parse Chunks
+
+ALTER TABLE carrier_estimates DROP COLUMN IF EXISTS totals_match;
+ALTER TABLE carrier_estimates DROP COLUMN IF EXISTS stated_total;
+ALTER TABLE carrier_estimates DROP COLUMN IF EXISTS parse_chunks;
+ALTER TABLE carrier_estimates DROP COLUMN IF EXISTS page_count;
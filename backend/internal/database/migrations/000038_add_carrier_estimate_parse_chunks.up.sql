@@ -0,0 +1,11 @@
+-- Carrier Estimate Parse Chunks
+-- Long carrier estimates are parsed a page range at a time so no response
+-- outgrows the LLM's output budget. parse_chunks records each range's status,
+-- line item count and the model's confidence. The line items are summed and
+-- cross-checked against the totals the estimate states; totals_match is NULL
+-- when no stated total was found.
+
+ALTER TABLE carrier_estimates ADD COLUMN page_count INTEGER;
+ALTER TABLE carrier_estimates ADD COLUMN parse_chunks JSONB;
+ALTER TABLE carrier_estimates ADD COLUMN stated_total NUMERIC(12,2);
+ALTER TABLE carrier_estimates ADD COLUMN totals_match BOOLEAN;
@@ -0,0 +1,4 @@
+-- Rollback Text Extraction Started At
+
+ALTER TABLE carrier_estimates DROP COLUMN IF EXISTS text_extraction_started_at;
+ALTER TABLE documents DROP COLUMN IF EXISTS text_extraction_started_at;
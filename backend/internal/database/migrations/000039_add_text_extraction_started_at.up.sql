@@ -0,0 +1,8 @@
+-- Text Extraction Started At
+-- Extraction runs in a background goroutine that dies with its Lambda
+-- invocation, which can leave a row in 'processing' forever. Recording when
+-- the run started lets a row that has been processing for longer than any run
+-- can last be claimed and extracted again.
+
+ALTER TABLE documents ADD COLUMN text_extraction_started_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE carrier_estimates ADD COLUMN text_extraction_started_at TIMESTAMP WITH TIME ZONE;
@@ -0,0 +1,3 @@
+-- Rollback Magic Link Reminder Attempts
+
+ALTER TABLE magic_link_reminders DROP COLUMN IF EXISTS attempts;
@@ -0,0 +1,5 @@
+-- Magic Link Reminder Attempts
+-- A reminder whose email failed is retried by later sweeps, up to a few
+-- attempts, instead of counting as sent. attempts counts the sends tried.
+
+ALTER TABLE magic_link_reminders ADD COLUMN attempts INTEGER NOT NULL DEFAULT 1;
@@ -0,0 +1,5 @@
+-- Rollback Document Magic Link
+
+DROP INDEX IF EXISTS idx_documents_magic_link;
+
+ALTER TABLE documents DROP COLUMN IF EXISTS magic_link_id;
@@ -0,0 +1,21 @@
+-- Document Magic Link
+-- Contractor uploads record the magic link they came through, so activity on a
+-- claim is credited to the contractor whose link was used rather than to every
+-- contractor with a link on the claim.
+
+ALTER TABLE documents ADD COLUMN magic_link_id UUID REFERENCES magic_links(id) ON DELETE SET NULL;
+
+CREATE INDEX idx_documents_magic_link ON documents(magic_link_id) WHERE magic_link_id IS NOT NULL;
+
+-- Attribute existing contractor uploads where exactly one link on the claim
+-- was live at the time; the rest stay unattributed
+UPDATE documents d
+SET magic_link_id = (
+    SELECT ml.id FROM magic_links ml
+    WHERE ml.claim_id = d.claim_id
+      AND ml.created_at <= d.created_at AND ml.expires_at >= d.created_at
+)
+WHERE d.uploaded_by_user_id IS NULL
+  AND (SELECT COUNT(*) FROM magic_links ml
+       WHERE ml.claim_id = d.claim_id
+         AND ml.created_at <= d.created_at AND ml.expires_at >= d.created_at) = 1;
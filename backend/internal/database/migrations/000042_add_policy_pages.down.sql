@@ -0,0 +1,3 @@
+-- Rollback Policy Pages
+
+DROP TABLE IF EXISTS policy_pages;
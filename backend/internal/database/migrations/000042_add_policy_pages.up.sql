@@ -0,0 +1,14 @@
+-- Policy Pages
+-- The text of a policy PDF, transcribed once per uploaded file and kept per
+-- page, so quotes in policy Q&A answers can be checked against the page they
+-- cite. pdf_path records which upload the text came from; a new upload is
+-- transcribed again.
+
+CREATE TABLE policy_pages (
+    policy_id UUID NOT NULL REFERENCES insurance_policies(id) ON DELETE CASCADE,
+    pdf_path TEXT NOT NULL,
+    page_number INTEGER NOT NULL CHECK (page_number > 0),
+    content TEXT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (policy_id, page_number)
+);
@@ -0,0 +1,8 @@
+-- Rollback More Prompt Versions
+
+ALTER TABLE appraisals DROP COLUMN IF EXISTS demand_prompt_version;
+ALTER TABLE policy_questions DROP COLUMN IF EXISTS prompt_version;
+ALTER TABLE policy_pages DROP COLUMN IF EXISTS prompt_version;
+ALTER TABLE carrier_estimates DROP COLUMN IF EXISTS text_extraction_prompt_version;
+ALTER TABLE documents DROP COLUMN IF EXISTS text_extraction_prompt_version;
+ALTER TABLE policy_terms DROP COLUMN IF EXISTS prompt_version;
@@ -0,0 +1,11 @@
+-- More Prompt Versions
+-- Records the version of the policy terms, text extraction, policy Q&A and
+-- appraisal demand prompts that produced each artifact, as 000036 does for
+-- the audit prompts.
+
+ALTER TABLE policy_terms ADD COLUMN prompt_version TEXT;
+ALTER TABLE documents ADD COLUMN text_extraction_prompt_version TEXT;
+ALTER TABLE carrier_estimates ADD COLUMN text_extraction_prompt_version TEXT;
+ALTER TABLE policy_pages ADD COLUMN prompt_version TEXT;
+ALTER TABLE policy_questions ADD COLUMN prompt_version TEXT;
+ALTER TABLE appraisals ADD COLUMN demand_prompt_version TEXT;
@@ -0,0 +1,6 @@
+-- Rollback Unique Current Versions
+
+DROP INDEX IF EXISTS idx_documents_current_versioned;
+
+DROP INDEX IF EXISTS idx_carrier_estimates_current;
+CREATE INDEX idx_carrier_estimates_current ON carrier_estimates(claim_id) WHERE is_current;
@@ -0,0 +1,34 @@
+-- Unique Current Versions
+-- A claim has one current carrier estimate and one current document of each
+-- single-version type (policy_pdf, contractor_estimate, carrier_estimate).
+-- Other document types, such as photos, keep many current documents.
+-- Concurrent confirms used to leave two current versions, so this retires all
+-- but the newest before making the indexes unique.
+
+WITH ranked AS (
+    SELECT id,
+           ROW_NUMBER() OVER (PARTITION BY claim_id ORDER BY version DESC, uploaded_at DESC) AS rn
+    FROM carrier_estimates
+    WHERE is_current
+)
+UPDATE carrier_estimates ce
+SET is_current = false
+FROM ranked r
+WHERE ce.id = r.id AND r.rn > 1;
+
+WITH ranked AS (
+    SELECT id,
+           ROW_NUMBER() OVER (PARTITION BY claim_id, document_type ORDER BY version DESC, created_at DESC) AS rn
+    FROM documents
+    WHERE is_current AND document_type IN ('policy_pdf', 'contractor_estimate', 'carrier_estimate')
+)
+UPDATE documents d
+SET is_current = false
+FROM ranked r
+WHERE d.id = r.id AND r.rn > 1;
+
+DROP INDEX IF EXISTS idx_carrier_estimates_current;
+CREATE UNIQUE INDEX idx_carrier_estimates_current ON carrier_estimates(claim_id) WHERE is_current;
+
+CREATE UNIQUE INDEX idx_documents_current_versioned ON documents(claim_id, document_type)
+    WHERE is_current AND document_type IN ('policy_pdf', 'contractor_estimate', 'carrier_estimate');
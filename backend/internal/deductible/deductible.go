@@ -0,0 +1,193 @@
+// Package deductible resolves the dollar deductible of a claim from its
+// policy's per-peril deductible rules, the claim's loss type and loss date.
+package deductible
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/claimcoach/backend/internal/models"
+)
+
+// Policy is what a deductible is resolved from
+type Policy struct {
+	// Deductible is the policy's flat deductible, used when no rule matches
+	Deductible float64
+	// CoverageALimit comes from confirmed policy terms; percentage rules
+	// cannot be resolved without it
+	CoverageALimit *float64
+	Rules          []models.DeductibleRule
+}
+
+// Result is a resolved deductible. RuleID and Peril are empty when the
+// policy's flat deductible applied.
+type Result struct {
+	Amount   float64  `json:"amount"`
+	RuleID   string   `json:"rule_id,omitempty"`
+	Peril    string   `json:"peril,omitempty"`
+	Basis    string   `json:"basis"`
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// perils are the values a rule's peril may take
+var perils = map[string]bool{
+	models.PerilAllPerils:  true,
+	models.PerilWindHail:   true,
+	models.PerilNamedStorm: true,
+	models.LossTypeFire:    true,
+	models.LossTypeWater:   true,
+	models.LossTypeWind:    true,
+	models.LossTypeHail:    true,
+	models.LossTypeOther:   true,
+}
+
+// Validate lists every problem with a rule
+func Validate(rule models.DeductibleRule) error {
+	var problems []string
+	if !perils[rule.Peril] {
+		problems = append(problems, fmt.Sprintf("unknown peril %q", rule.Peril))
+	}
+	switch rule.Basis {
+	case models.DeductibleFlat:
+		if rule.Amount == nil {
+			problems = append(problems, "a flat deductible needs an amount")
+		}
+	case models.DeductiblePercentage:
+		if rule.Percentage == nil {
+			problems = append(problems, "a percentage deductible needs a percentage")
+		}
+	default:
+		problems = append(problems, fmt.Sprintf("basis %q must be flat or percentage", rule.Basis))
+	}
+	if rule.Amount != nil && *rule.Amount < 0 {
+		problems = append(problems, "amount must not be negative")
+	}
+	if rule.MinimumAmount != nil && *rule.MinimumAmount < 0 {
+		problems = append(problems, "minimum_amount must not be negative")
+	}
+	if p := rule.Percentage; p != nil && (*p <= 0 || *p >= 1) {
+		problems = append(problems, fmt.Sprintf("percentage %g must be a fraction between 0 and 1", *p))
+	}
+	if rule.Peril == models.PerilNamedStorm && (rule.StartsOn == nil || rule.EndsOn == nil) {
+		problems = append(problems, "a named storm deductible needs the storm's start and end dates")
+	}
+	if rule.StartsOn != nil && rule.EndsOn != nil && rule.EndsOn.Before(*rule.StartsOn) {
+		problems = append(problems, "ends_on must not be before starts_on")
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("%s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// specificity ranks how closely a rule's peril matches a loss type, or -1 when
+// it does not apply. Named storm rules cover wind and hail losses within the
+// storm's dates and take precedence over everything else.
+func specificity(peril, lossType string) int {
+	windOrHail := lossType == models.LossTypeWind || lossType == models.LossTypeHail
+	switch {
+	case peril == models.PerilNamedStorm && windOrHail:
+		return 3
+	case peril == lossType:
+		return 2
+	case peril == models.PerilWindHail && windOrHail:
+		return 1
+	case peril == models.PerilAllPerils:
+		return 0
+	default:
+		return -1
+	}
+}
+
+// inWindow reports whether a loss date falls within a rule's dates, compared
+// by calendar day
+func inWindow(rule models.DeductibleRule, lossDate time.Time) bool {
+	day := lossDate.Format("2006-01-02")
+	if rule.StartsOn != nil && day < rule.StartsOn.Format("2006-01-02") {
+		return false
+	}
+	if rule.EndsOn != nil && day > rule.EndsOn.Format("2006-01-02") {
+		return false
+	}
+	return true
+}
+
+// Resolve picks the most specific rule for a loss. Ties go to manual rules
+// over ones derived from policy terms, then to the newest. A percentage rule
+// that cannot be resolved without a Coverage A limit is skipped with a warning.
+func Resolve(policy Policy, lossType string, lossDate time.Time) Result {
+	type candidate struct {
+		rule models.DeductibleRule
+		rank int
+	}
+	var candidates []candidate
+	for _, rule := range policy.Rules {
+		rank := specificity(rule.Peril, lossType)
+		if rank < 0 || !inWindow(rule, lossDate) {
+			continue
+		}
+		candidates = append(candidates, candidate{rule, rank})
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if a.rank != b.rank {
+			return a.rank > b.rank
+		}
+		if aManual, bManual := a.rule.Source == models.DeductibleSourceManual, b.rule.Source == models.DeductibleSourceManual; aManual != bManual {
+			return aManual
+		}
+		return a.rule.CreatedAt.After(b.rule.CreatedAt)
+	})
+
+	var warnings []string
+	for _, c := range candidates {
+		rule := c.rule
+		if rule.Basis == models.DeductibleFlat {
+			return Result{
+				Amount:   round2(*rule.Amount),
+				RuleID:   rule.ID,
+				Peril:    rule.Peril,
+				Basis:    fmt.Sprintf("%s deductible of $%.2f", perilName(rule.Peril), *rule.Amount),
+				Warnings: warnings,
+			}
+		}
+		if policy.CoverageALimit == nil {
+			warnings = append(warnings, fmt.Sprintf("%s deductible of %g%% of Coverage A skipped: no confirmed Coverage A limit",
+				perilName(rule.Peril), *rule.Percentage*100))
+			continue
+		}
+		amount := round2(*rule.Percentage * *policy.CoverageALimit)
+		basis := fmt.Sprintf("%s deductible of %g%% of Coverage A ($%.2f)", perilName(rule.Peril), *rule.Percentage*100, *policy.CoverageALimit)
+		if rule.MinimumAmount != nil && amount < *rule.MinimumAmount {
+			amount = round2(*rule.MinimumAmount)
+			basis += fmt.Sprintf(", raised to its $%.2f minimum", *rule.MinimumAmount)
+		}
+		return Result{Amount: amount, RuleID: rule.ID, Peril: rule.Peril, Basis: basis, Warnings: warnings}
+	}
+
+	return Result{
+		Amount:   round2(policy.Deductible),
+		Basis:    fmt.Sprintf("policy deductible of $%.2f", policy.Deductible),
+		Warnings: warnings,
+	}
+}
+
+func perilName(peril string) string {
+	switch peril {
+	case models.PerilAllPerils:
+		return "All perils"
+	case models.PerilWindHail:
+		return "Wind/hail"
+	case models.PerilNamedStorm:
+		return "Named storm"
+	default:
+		return strings.ToUpper(peril[:1]) + peril[1:]
+	}
+}
+
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
@@ -0,0 +1,104 @@
+package deductible
+
+import (
+	"testing"
+	"time"
+
+	"github.com/claimcoach/backend/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func amount(v float64) *float64 { return &v }
+
+func date(s string) *time.Time {
+	t, _ := time.Parse("2006-01-02", s)
+	return &t
+}
+
+func TestResolve(t *testing.T) {
+	coverageA := 300000.0
+	policy := Policy{
+		Deductible:     1000,
+		CoverageALimit: &coverageA,
+		Rules: []models.DeductibleRule{
+			{ID: "all", Peril: models.PerilAllPerils, Basis: models.DeductibleFlat, Amount: amount(2500), Source: models.DeductibleSourcePolicyTerms},
+			{ID: "wind-hail", Peril: models.PerilWindHail, Basis: models.DeductiblePercentage, Percentage: amount(0.02), Source: models.DeductibleSourcePolicyTerms},
+			{ID: "storm", Peril: models.PerilNamedStorm, Basis: models.DeductiblePercentage, Percentage: amount(0.05),
+				StartsOn: date("2026-09-10"), EndsOn: date("2026-09-14"), Source: models.DeductibleSourceManual},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		lossType string
+		lossDate string
+		rule     string
+		amount   float64
+	}{
+		{"fire uses the all perils rule", models.LossTypeFire, "2026-09-12", "all", 2500},
+		{"hail uses the wind/hail percentage", models.LossTypeHail, "2026-05-01", "wind-hail", 6000},
+		{"wind during a named storm", models.LossTypeWind, "2026-09-14", "storm", 15000},
+		{"wind after the storm", models.LossTypeWind, "2026-09-15", "wind-hail", 6000},
+		{"water during a named storm", models.LossTypeWater, "2026-09-12", "all", 2500},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lossDate, _ := time.Parse("2006-01-02", tt.lossDate)
+			result := Resolve(policy, tt.lossType, lossDate)
+			assert.Equal(t, tt.rule, result.RuleID)
+			assert.Equal(t, tt.amount, result.Amount)
+		})
+	}
+}
+
+func TestResolve_PrefersManualAndNewerRules(t *testing.T) {
+	now := time.Now()
+	policy := Policy{Rules: []models.DeductibleRule{
+		{ID: "terms", Peril: models.LossTypeHail, Basis: models.DeductibleFlat, Amount: amount(1000), Source: models.DeductibleSourcePolicyTerms, CreatedAt: now},
+		{ID: "old", Peril: models.LossTypeHail, Basis: models.DeductibleFlat, Amount: amount(1500), Source: models.DeductibleSourceManual, CreatedAt: now.Add(-time.Hour)},
+		{ID: "new", Peril: models.LossTypeHail, Basis: models.DeductibleFlat, Amount: amount(2000), Source: models.DeductibleSourceManual, CreatedAt: now.Add(-time.Minute)},
+	}}
+
+	result := Resolve(policy, models.LossTypeHail, now)
+	assert.Equal(t, "new", result.RuleID)
+	assert.Equal(t, "Hail deductible of $2000.00", result.Basis)
+}
+
+func TestResolve_PercentageNeedsCoverageA(t *testing.T) {
+	policy := Policy{
+		Deductible: 1000,
+		Rules: []models.DeductibleRule{
+			{ID: "wind-hail", Peril: models.PerilWindHail, Basis: models.DeductiblePercentage, Percentage: amount(0.01)},
+		},
+	}
+
+	result := Resolve(policy, models.LossTypeWind, time.Now())
+	assert.Equal(t, "", result.RuleID)
+	assert.Equal(t, 1000.0, result.Amount)
+	assert.Equal(t, "policy deductible of $1000.00", result.Basis)
+	assert.Equal(t, []string{"Wind/hail deductible of 1% of Coverage A skipped: no confirmed Coverage A limit"}, result.Warnings)
+}
+
+func TestResolve_AppliesMinimum(t *testing.T) {
+	coverageA := 100000.0
+	policy := Policy{
+		CoverageALimit: &coverageA,
+		Rules: []models.DeductibleRule{
+			{ID: "pct", Peril: models.PerilAllPerils, Basis: models.DeductiblePercentage, Percentage: amount(0.01), MinimumAmount: amount(2500)},
+		},
+	}
+
+	result := Resolve(policy, models.LossTypeFire, time.Now())
+	assert.Equal(t, 2500.0, result.Amount)
+	assert.Equal(t, "All perils deductible of 1% of Coverage A ($100000.00), raised to its $2500.00 minimum", result.Basis)
+}
+
+func TestValidate(t *testing.T) {
+	assert.NoError(t, Validate(models.DeductibleRule{Peril: models.LossTypeWind, Basis: models.DeductibleFlat, Amount: amount(500)}))
+
+	err := Validate(models.DeductibleRule{Peril: models.PerilNamedStorm, Basis: models.DeductiblePercentage, Percentage: amount(5)})
+	assert.EqualError(t, err, "percentage 5 must be a fraction between 0 and 1; a named storm deductible needs the storm's start and end dates")
+
+	err = Validate(models.DeductibleRule{Peril: "flood", Basis: "tiered"})
+	assert.EqualError(t, err, `unknown peril "flood"; basis "tiered" must be flat or percentage`)
+}
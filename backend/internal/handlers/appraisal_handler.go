@@ -0,0 +1,215 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/claimcoach/backend/internal/models"
+	"github.com/claimcoach/backend/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+type AppraisalHandler struct {
+	service *services.AppraisalService
+}
+
+func NewAppraisalHandler(service *services.AppraisalService) *AppraisalHandler {
+	return &AppraisalHandler{service: service}
+}
+
+// appraisalError maps errors shared by the appraisal endpoints, reporting
+// whether it wrote a response
+func appraisalError(c *gin.Context, err error) bool {
+	switch {
+	case err.Error() == "claim not found":
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "error": "Claim not found"})
+	case errors.Is(err, services.ErrAppraisalNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "error": "Appraisal not found"})
+	case errors.Is(err, services.ErrAppraisalInProgress):
+		c.JSON(http.StatusConflict, gin.H{"success": false, "error": err.Error()})
+	case errors.Is(err, services.ErrInvalidAppraisalTransition), errors.Is(err, services.ErrInvalidAppraisalUpdate):
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+	default:
+		return false
+	}
+	return true
+}
+
+// Invoke drafts an appraisal demand letter and opens an appraisal
+// POST /api/claims/:id/appraisals
+func (h *AppraisalHandler) Invoke(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+	claimID := c.Param("id")
+
+	var input services.InvokeAppraisalInput
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "Invalid request: " + err.Error()})
+			return
+		}
+	}
+
+	appraisal, err := h.service.InvokeAppraisal(c.Request.Context(), claimID, user.OrganizationID, user.ID, input)
+	if err != nil {
+		if appraisalError(c, err) {
+			return
+		}
+		c.JSON(llmErrorStatus(err), gin.H{"success": false, "error": "Failed to generate appraisal demand: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"success": true, "data": appraisal})
+}
+
+// List returns a claim's appraisals
+// GET /api/claims/:id/appraisals
+func (h *AppraisalHandler) List(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+	claimID := c.Param("id")
+
+	appraisals, err := h.service.ListAppraisals(c.Request.Context(), claimID, user.OrganizationID)
+	if err != nil {
+		if appraisalError(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Failed to list appraisals: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": appraisals})
+}
+
+// Get returns one appraisal with its parties and award
+// GET /api/claims/:id/appraisals/:appraisalId
+func (h *AppraisalHandler) Get(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+	claimID := c.Param("id")
+
+	appraisal, err := h.service.GetAppraisal(c.Request.Context(), claimID, c.Param("appraisalId"), user.OrganizationID)
+	if err != nil {
+		if appraisalError(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Failed to get appraisal: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": appraisal})
+}
+
+// MarkInvoked records that the demand was sent to the carrier
+// PATCH /api/claims/:id/appraisals/:appraisalId/invoked
+func (h *AppraisalHandler) MarkInvoked(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+	claimID := c.Param("id")
+
+	var input services.MarkAppraisalInvokedInput
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "Invalid request: " + err.Error()})
+			return
+		}
+	}
+
+	appraisal, err := h.service.MarkAppraisalInvoked(c.Request.Context(), claimID, c.Param("appraisalId"), user.OrganizationID, user.ID, input)
+	if err != nil {
+		if appraisalError(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Failed to update appraisal: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": appraisal})
+}
+
+// SetParty names an appraiser or the umpire
+// PUT /api/claims/:id/appraisals/:appraisalId/parties/:role
+func (h *AppraisalHandler) SetParty(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+	claimID := c.Param("id")
+
+	var input services.AppraisalPartyInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "Invalid request: " + err.Error()})
+		return
+	}
+
+	appraisal, err := h.service.SetAppraisalParty(c.Request.Context(), claimID, c.Param("appraisalId"), user.OrganizationID, user.ID, c.Param("role"), input)
+	if err != nil {
+		if appraisalError(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Failed to save appraisal party: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": appraisal})
+}
+
+// UpdateDeadlines replaces the appraiser or umpire selection deadline
+// PATCH /api/claims/:id/appraisals/:appraisalId/deadlines
+func (h *AppraisalHandler) UpdateDeadlines(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+	claimID := c.Param("id")
+
+	var input services.UpdateAppraisalDeadlinesInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "Invalid request: " + err.Error()})
+		return
+	}
+
+	appraisal, err := h.service.UpdateAppraisalDeadlines(c.Request.Context(), claimID, c.Param("appraisalId"), user.OrganizationID, user.ID, input)
+	if err != nil {
+		if appraisalError(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Failed to update appraisal: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": appraisal})
+}
+
+// RecordAward saves the award per coverage and adds the balance owed as
+// expected payments
+// POST /api/claims/:id/appraisals/:appraisalId/award
+func (h *AppraisalHandler) RecordAward(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+	claimID := c.Param("id")
+
+	var input services.RecordAppraisalAwardInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "Invalid request: " + err.Error()})
+		return
+	}
+
+	appraisal, err := h.service.RecordAppraisalAward(c.Request.Context(), claimID, c.Param("appraisalId"), user.OrganizationID, user.ID, input)
+	if err != nil {
+		if appraisalError(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Failed to record appraisal award: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": appraisal})
+}
+
+// Withdraw closes an appraisal without an award
+// POST /api/claims/:id/appraisals/:appraisalId/withdraw
+func (h *AppraisalHandler) Withdraw(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+	claimID := c.Param("id")
+
+	appraisal, err := h.service.WithdrawAppraisal(c.Request.Context(), claimID, c.Param("appraisalId"), user.OrganizationID, user.ID)
+	if err != nil {
+		if appraisalError(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Failed to withdraw appraisal: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": appraisal})
+}
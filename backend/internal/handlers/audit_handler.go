@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"strings"
 
@@ -12,12 +13,17 @@ import (
 
 // AuditServiceInterface defines the interface for audit operations
 type AuditServiceInterface interface {
-	GenerateIndustryEstimate(ctx context.Context, claimID, userID, orgID string) (string, error)
+	GenerateIndustryEstimate(ctx context.Context, claimID, userID, orgID string, force bool) (string, error)
 	GetAuditReportByClaimID(ctx context.Context, claimID, orgID string) (*models.AuditReport, error)
 	AnalyzeClaimViability(ctx context.Context, claimID, orgID string) (*services.ViabilityAnalysis, error)
 	RunPMBrainAnalysis(ctx context.Context, auditReportID, userID, orgID string) (*services.PMBrainAnalysis, error)
 	GenerateDisputeLetter(ctx context.Context, auditReportID, userID, orgID string) (string, error)
 	GenerateOwnerPitch(ctx context.Context, auditReportID, userID, orgID string) (string, error)
+	StreamDisputeLetter(ctx context.Context, auditReportID, userID, orgID string, onDelta func(text string) error) (string, error)
+	StreamOwnerPitch(ctx context.Context, auditReportID, userID, orgID string, onDelta func(text string) error) (string, error)
+	ListAuditRuns(ctx context.Context, claimID, orgID string) ([]models.AuditRun, error)
+	GetAuditRun(ctx context.Context, claimID, runID, orgID string) (*models.AuditRun, error)
+	CompareAuditRuns(ctx context.Context, claimID, fromID, toID, orgID string) (*services.AuditRunComparison, error)
 }
 
 type AuditHandler struct {
@@ -28,13 +34,15 @@ func NewAuditHandler(service AuditServiceInterface) *AuditHandler {
 	return &AuditHandler{service: service}
 }
 
-// GenerateIndustryEstimate generates an industry-standard estimate from scope sheet
+// GenerateIndustryEstimate generates an industry-standard estimate from scope sheet.
+// ?force=true bypasses the LLM response cache.
 // POST /api/claims/:id/audit/generate
 func (h *AuditHandler) GenerateIndustryEstimate(c *gin.Context) {
 	user := c.MustGet("user").(models.User)
 	claimID := c.Param("id")
+	force := c.Query("force") == "true"
 
-	auditReportID, err := h.service.GenerateIndustryEstimate(c.Request.Context(), claimID, user.ID, user.OrganizationID)
+	auditReportID, err := h.service.GenerateIndustryEstimate(c.Request.Context(), claimID, user.ID, user.OrganizationID, force)
 	if err != nil {
 		// Handle specific errors
 		if err.Error() == "scope sheet not found for claim "+claimID {
@@ -45,7 +53,7 @@ func (h *AuditHandler) GenerateIndustryEstimate(c *gin.Context) {
 			return
 		}
 
-		c.JSON(http.StatusInternalServerError, gin.H{
+		c.JSON(llmErrorStatus(err), gin.H{
 			"success": false,
 			"error":   "Failed to generate industry estimate: " + err.Error(),
 		})
@@ -116,7 +124,7 @@ func (h *AuditHandler) RunPMBrain(c *gin.Context) {
 			c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Failed to run PM Brain analysis: " + err.Error()})
+		c.JSON(llmErrorStatus(err), gin.H{"success": false, "error": "Failed to run PM Brain analysis: " + err.Error()})
 		return
 	}
 
@@ -131,21 +139,33 @@ func (h *AuditHandler) GenerateDisputeLetter(c *gin.Context) {
 
 	letter, err := h.service.GenerateDisputeLetter(c.Request.Context(), auditReportID, user.ID, user.OrganizationID)
 	if err != nil {
-		if strings.Contains(err.Error(), "audit report not found") {
-			c.JSON(http.StatusNotFound, gin.H{"success": false, "error": "Audit report not found"})
-			return
-		}
-		if strings.Contains(err.Error(), "must be run first") || strings.Contains(err.Error(), "only available when") {
-			c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Failed to generate dispute letter: " + err.Error()})
+		status, message := letterError(err, "dispute letter")
+		c.JSON(status, gin.H{"success": false, "error": message})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"success": true, "data": gin.H{"letter": letter}})
 }
 
+// StreamDisputeLetter generates the dispute letter like GenerateDisputeLetter, streaming the
+// text over server-sent events as it is written.
+// POST /api/claims/:id/audit/:auditId/dispute-letter/stream
+func (h *AuditHandler) StreamDisputeLetter(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+	auditReportID := c.Param("auditId")
+	stream := newSSEStream(c)
+
+	// Detached from the request so the dispute letter is still saved if the client disconnects
+	ctx := context.WithoutCancel(c.Request.Context())
+	letter, err := h.service.StreamDisputeLetter(ctx, auditReportID, user.ID, user.OrganizationID, stream.Delta)
+	if err != nil {
+		stream.Error(letterError(err, "dispute letter"))
+		return
+	}
+
+	stream.Done(gin.H{"letter": letter})
+}
+
 // GenerateOwnerPitch generates a plain-English escalation pitch email for the building owner.
 // POST /api/claims/:id/audit/:auditId/owner-pitch
 func (h *AuditHandler) GenerateOwnerPitch(c *gin.Context) {
@@ -154,21 +174,45 @@ func (h *AuditHandler) GenerateOwnerPitch(c *gin.Context) {
 
 	pitch, err := h.service.GenerateOwnerPitch(c.Request.Context(), auditReportID, user.ID, user.OrganizationID)
 	if err != nil {
-		if strings.Contains(err.Error(), "audit report not found") {
-			c.JSON(http.StatusNotFound, gin.H{"success": false, "error": "Audit report not found"})
-			return
-		}
-		if strings.Contains(err.Error(), "must be run first") || strings.Contains(err.Error(), "only available when") {
-			c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Failed to generate owner pitch: " + err.Error()})
+		status, message := letterError(err, "owner pitch")
+		c.JSON(status, gin.H{"success": false, "error": message})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"success": true, "data": gin.H{"pitch": pitch}})
 }
 
+// StreamOwnerPitch generates the owner pitch like GenerateOwnerPitch, streaming the
+// text over server-sent events as it is written.
+// POST /api/claims/:id/audit/:auditId/owner-pitch/stream
+func (h *AuditHandler) StreamOwnerPitch(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+	auditReportID := c.Param("auditId")
+	stream := newSSEStream(c)
+
+	// Detached from the request so the owner pitch is still saved if the client disconnects
+	ctx := context.WithoutCancel(c.Request.Context())
+	pitch, err := h.service.StreamOwnerPitch(ctx, auditReportID, user.ID, user.OrganizationID, stream.Delta)
+	if err != nil {
+		stream.Error(letterError(err, "owner pitch"))
+		return
+	}
+
+	stream.Done(gin.H{"pitch": pitch})
+}
+
+// letterError maps a dispute letter or owner pitch generation error to a
+// status and message
+func letterError(err error, kind string) (int, string) {
+	if strings.Contains(err.Error(), "audit report not found") {
+		return http.StatusNotFound, "Audit report not found"
+	}
+	if strings.Contains(err.Error(), "must be run first") || strings.Contains(err.Error(), "only available when") {
+		return http.StatusBadRequest, err.Error()
+	}
+	return llmErrorStatus(err), "Failed to generate " + kind + ": " + err.Error()
+}
+
 // AnalyzeClaimViability runs the PM Decision Engine on a claim.
 // POST /api/claims/:id/audit/viability
 func (h *AuditHandler) AnalyzeClaimViability(c *gin.Context) {
@@ -192,7 +236,7 @@ func (h *AuditHandler) AnalyzeClaimViability(c *gin.Context) {
 			return
 		}
 
-		c.JSON(http.StatusInternalServerError, gin.H{
+		c.JSON(llmErrorStatus(err), gin.H{
 			"success": false,
 			"error":   "Failed to analyze claim viability: " + err.Error(),
 		})
@@ -205,3 +249,61 @@ func (h *AuditHandler) AnalyzeClaimViability(c *gin.Context) {
 	})
 }
 
+// ListAuditRuns lists every run of a claim's audit, newest first.
+// GET /api/claims/:id/audit/runs
+func (h *AuditHandler) ListAuditRuns(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+	claimID := c.Param("id")
+
+	runs, err := h.service.ListAuditRuns(c.Request.Context(), claimID, user.OrganizationID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Failed to list audit runs: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": runs})
+}
+
+// GetAuditRun returns one audit run with its outputs.
+// GET /api/claims/:id/audit/runs/:runId
+func (h *AuditHandler) GetAuditRun(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+	claimID := c.Param("id")
+
+	run, err := h.service.GetAuditRun(c.Request.Context(), claimID, c.Param("runId"), user.OrganizationID)
+	if err != nil {
+		if errors.Is(err, services.ErrAuditRunNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"success": false, "error": "Audit run not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Failed to get audit run: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": run})
+}
+
+// CompareAuditRuns shows what changed between two audit runs.
+// GET /api/claims/:id/audit/runs/compare?from=:runId&to=:runId
+func (h *AuditHandler) CompareAuditRuns(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+	claimID := c.Param("id")
+
+	fromID, toID := c.Query("from"), c.Query("to")
+	if fromID == "" || toID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "from and to run IDs are required"})
+		return
+	}
+
+	comparison, err := h.service.CompareAuditRuns(c.Request.Context(), claimID, fromID, toID, user.OrganizationID)
+	if err != nil {
+		if errors.Is(err, services.ErrAuditRunNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"success": false, "error": "Audit run not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Failed to compare audit runs: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": comparison})
+}
@@ -9,6 +9,7 @@ import (
 
 	"github.com/claimcoach/backend/internal/llm"
 	"github.com/claimcoach/backend/internal/models"
+	"github.com/claimcoach/backend/internal/services"
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -19,7 +20,7 @@ type MockAuditService struct {
 	mock.Mock
 }
 
-func (m *MockAuditService) GenerateIndustryEstimate(ctx context.Context, claimID, userID, orgID string) (string, error) {
+func (m *MockAuditService) GenerateIndustryEstimate(ctx context.Context, claimID, userID, orgID string, force bool) (string, error) {
 	args := m.Called(ctx, claimID, userID, orgID)
 	return args.String(0), args.Error(1)
 }
@@ -32,22 +33,64 @@ func (m *MockAuditService) GetAuditReportByClaimID(ctx context.Context, claimID,
 	return args.Get(0).(*models.AuditReport), args.Error(1)
 }
 
-func (m *MockAuditService) CompareEstimates(ctx context.Context, auditReportID string, userID string, orgID string) error {
+func (m *MockAuditService) AnalyzeClaimViability(ctx context.Context, claimID, orgID string) (*services.ViabilityAnalysis, error) {
+	args := m.Called(ctx, claimID, orgID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*services.ViabilityAnalysis), args.Error(1)
+}
+
+func (m *MockAuditService) RunPMBrainAnalysis(ctx context.Context, auditReportID, userID, orgID string) (*services.PMBrainAnalysis, error) {
+	args := m.Called(ctx, auditReportID, userID, orgID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*services.PMBrainAnalysis), args.Error(1)
+}
+
+func (m *MockAuditService) GenerateDisputeLetter(ctx context.Context, auditReportID, userID, orgID string) (string, error) {
+	args := m.Called(ctx, auditReportID, userID, orgID)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockAuditService) GenerateOwnerPitch(ctx context.Context, auditReportID, userID, orgID string) (string, error) {
+	args := m.Called(ctx, auditReportID, userID, orgID)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockAuditService) StreamDisputeLetter(ctx context.Context, auditReportID, userID, orgID string, onDelta func(text string) error) (string, error) {
 	args := m.Called(ctx, auditReportID, userID, orgID)
-	return args.Error(0)
+	return args.String(0), args.Error(1)
 }
 
-func (m *MockAuditService) GenerateRebuttal(ctx context.Context, auditReportID string, userID string, orgID string) (string, error) {
+func (m *MockAuditService) StreamOwnerPitch(ctx context.Context, auditReportID, userID, orgID string, onDelta func(text string) error) (string, error) {
 	args := m.Called(ctx, auditReportID, userID, orgID)
 	return args.String(0), args.Error(1)
 }
 
-func (m *MockAuditService) GetRebuttal(ctx context.Context, rebuttalID string, orgID string) (*models.Rebuttal, error) {
-	args := m.Called(ctx, rebuttalID, orgID)
+func (m *MockAuditService) ListAuditRuns(ctx context.Context, claimID, orgID string) ([]models.AuditRun, error) {
+	args := m.Called(ctx, claimID, orgID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.AuditRun), args.Error(1)
+}
+
+func (m *MockAuditService) GetAuditRun(ctx context.Context, claimID, runID, orgID string) (*models.AuditRun, error) {
+	args := m.Called(ctx, claimID, runID, orgID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.AuditRun), args.Error(1)
+}
+
+func (m *MockAuditService) CompareAuditRuns(ctx context.Context, claimID, fromID, toID, orgID string) (*services.AuditRunComparison, error) {
+	args := m.Called(ctx, claimID, fromID, toID, orgID)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
-	return args.Get(0).(*models.Rebuttal), args.Error(1)
+	return args.Get(0).(*services.AuditRunComparison), args.Error(1)
 }
 
 func (m *MockAuditService) Chat(ctx context.Context, messages []llm.Message, temperature float64, maxTokens int) (*llm.ChatResponse, error) {
@@ -78,26 +121,27 @@ func setupAuditTestRouter(mockService *MockAuditService) *gin.Engine {
 	// Register routes
 	r.POST("/api/claims/:id/audit/generate", handler.GenerateIndustryEstimate)
 	r.GET("/api/claims/:id/audit", handler.GetAuditReport)
-	r.POST("/api/claims/:id/audit/:auditId/compare", handler.CompareEstimates)
-	r.POST("/api/claims/:id/audit/:auditId/rebuttal", handler.GenerateRebuttal)
-	r.GET("/api/rebuttals/:id", handler.GetRebuttal)
+	r.POST("/api/claims/:id/audit/:auditId/pm-brain", handler.RunPMBrain)
+	r.POST("/api/claims/:id/audit/:auditId/dispute-letter", handler.GenerateDisputeLetter)
+	r.GET("/api/claims/:id/audit/runs/:runId", handler.GetAuditRun)
 
 	return r
 }
 
-func TestCompareEstimatesHandler_Success(t *testing.T) {
+func TestGenerateIndustryEstimateHandler_Success(t *testing.T) {
 	// Setup
 	mockService := new(MockAuditService)
 	router := setupAuditTestRouter(mockService)
 
-	auditReportID := "test-audit-report-id"
 	claimID := "test-claim-id"
+	auditReport := &models.AuditReport{ID: "test-audit-report-id", ClaimID: claimID}
 
 	// Set up expectations
-	mockService.On("CompareEstimates", mock.Anything, auditReportID, "test-user-id", "test-org-id").Return(nil)
+	mockService.On("GenerateIndustryEstimate", mock.Anything, claimID, "test-user-id", "test-org-id").Return(auditReport.ID, nil)
+	mockService.On("GetAuditReportByClaimID", mock.Anything, claimID, "test-org-id").Return(auditReport, nil)
 
 	// Create request
-	req, _ := http.NewRequest("POST", "/api/claims/"+claimID+"/audit/"+auditReportID+"/compare", nil)
+	req, _ := http.NewRequest("POST", "/api/claims/"+claimID+"/audit/generate", nil)
 	w := httptest.NewRecorder()
 
 	// Execute
@@ -106,103 +150,24 @@ func TestCompareEstimatesHandler_Success(t *testing.T) {
 	// Assert
 	assert.Equal(t, http.StatusOK, w.Code)
 	assert.Contains(t, w.Body.String(), "success")
-	assert.Contains(t, w.Body.String(), "Estimates compared successfully")
-
-	mockService.AssertExpectations(t)
-}
-
-func TestCompareEstimatesHandler_AuditReportNotFound(t *testing.T) {
-	// Setup
-	mockService := new(MockAuditService)
-	router := setupAuditTestRouter(mockService)
-
-	auditReportID := "non-existent-id"
-	claimID := "test-claim-id"
-
-	// Set up expectations
-	mockService.On("CompareEstimates", mock.Anything, auditReportID, "test-user-id", "test-org-id").
-		Return(errors.New("audit report not found"))
-
-	// Create request
-	req, _ := http.NewRequest("POST", "/api/claims/"+claimID+"/audit/"+auditReportID+"/compare", nil)
-	w := httptest.NewRecorder()
-
-	// Execute
-	router.ServeHTTP(w, req)
-
-	// Assert
-	assert.Equal(t, http.StatusNotFound, w.Code)
-	assert.Contains(t, w.Body.String(), "Audit report not found")
-
-	mockService.AssertExpectations(t)
-}
-
-func TestCompareEstimatesHandler_IndustryEstimateNotGenerated(t *testing.T) {
-	// Setup
-	mockService := new(MockAuditService)
-	router := setupAuditTestRouter(mockService)
-
-	auditReportID := "test-audit-report-id"
-	claimID := "test-claim-id"
-
-	// Set up expectations
-	mockService.On("CompareEstimates", mock.Anything, auditReportID, "test-user-id", "test-org-id").
-		Return(errors.New("industry estimate not generated yet"))
-
-	// Create request
-	req, _ := http.NewRequest("POST", "/api/claims/"+claimID+"/audit/"+auditReportID+"/compare", nil)
-	w := httptest.NewRecorder()
-
-	// Execute
-	router.ServeHTTP(w, req)
-
-	// Assert
-	assert.Equal(t, http.StatusBadRequest, w.Code)
-	assert.Contains(t, w.Body.String(), "Industry estimate not generated yet")
-
-	mockService.AssertExpectations(t)
-}
-
-func TestCompareEstimatesHandler_CarrierEstimateNotUploaded(t *testing.T) {
-	// Setup
-	mockService := new(MockAuditService)
-	router := setupAuditTestRouter(mockService)
-
-	auditReportID := "test-audit-report-id"
-	claimID := "test-claim-id"
-
-	// Set up expectations
-	mockService.On("CompareEstimates", mock.Anything, auditReportID, "test-user-id", "test-org-id").
-		Return(errors.New("carrier estimate not found"))
-
-	// Create request
-	req, _ := http.NewRequest("POST", "/api/claims/"+claimID+"/audit/"+auditReportID+"/compare", nil)
-	w := httptest.NewRecorder()
-
-	// Execute
-	router.ServeHTTP(w, req)
-
-	// Assert
-	assert.Equal(t, http.StatusBadRequest, w.Code)
-	assert.Contains(t, w.Body.String(), "Carrier estimate not uploaded yet")
+	assert.Contains(t, w.Body.String(), auditReport.ID)
 
 	mockService.AssertExpectations(t)
 }
 
-func TestCompareEstimatesHandler_CarrierEstimateNotParsed(t *testing.T) {
+func TestGenerateIndustryEstimateHandler_NoScopeSheet(t *testing.T) {
 	// Setup
 	mockService := new(MockAuditService)
 	router := setupAuditTestRouter(mockService)
 
-	auditReportID := "test-audit-report-id"
 	claimID := "test-claim-id"
 
 	// Set up expectations
-	mockService.On("CompareEstimates", mock.Anything, auditReportID, "test-user-id", "test-org-id").
-		Return(errors.New("carrier estimate not parsed yet"))
+	mockService.On("GenerateIndustryEstimate", mock.Anything, claimID, "test-user-id", "test-org-id").
+		Return("", errors.New("scope sheet not found for claim "+claimID))
 
 	// Create request
-	req, _ := http.NewRequest("POST", "/api/claims/"+claimID+"/audit/"+auditReportID+"/compare", nil)
+	req, _ := http.NewRequest("POST", "/api/claims/"+claimID+"/audit/generate", nil)
 	w := httptest.NewRecorder()
 
 	// Execute
@@ -210,59 +175,50 @@ func TestCompareEstimatesHandler_CarrierEstimateNotParsed(t *testing.T) {
 
 	// Assert
 	assert.Equal(t, http.StatusBadRequest, w.Code)
-	assert.Contains(t, w.Body.String(), "Carrier estimate not parsed yet")
+	assert.Contains(t, w.Body.String(), "Scope sheet not found")
 
 	mockService.AssertExpectations(t)
 }
 
-func TestCompareEstimatesHandler_InternalError(t *testing.T) {
+func TestGetAuditReportHandler_NotFound(t *testing.T) {
 	// Setup
 	mockService := new(MockAuditService)
 	router := setupAuditTestRouter(mockService)
 
-	auditReportID := "test-audit-report-id"
 	claimID := "test-claim-id"
 
 	// Set up expectations
-	mockService.On("CompareEstimates", mock.Anything, auditReportID, "test-user-id", "test-org-id").
-		Return(errors.New("database connection failed"))
+	mockService.On("GetAuditReportByClaimID", mock.Anything, claimID, "test-org-id").
+		Return(nil, errors.New("audit report not found"))
 
 	// Create request
-	req, _ := http.NewRequest("POST", "/api/claims/"+claimID+"/audit/"+auditReportID+"/compare", nil)
+	req, _ := http.NewRequest("GET", "/api/claims/"+claimID+"/audit", nil)
 	w := httptest.NewRecorder()
 
 	// Execute
 	router.ServeHTTP(w, req)
 
 	// Assert
-	assert.Equal(t, http.StatusInternalServerError, w.Code)
-	assert.Contains(t, w.Body.String(), "Failed to compare estimates")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Contains(t, w.Body.String(), "Audit report not found")
 
 	mockService.AssertExpectations(t)
 }
 
-func TestGenerateRebuttalHandler_Success(t *testing.T) {
+func TestRunPMBrainHandler_Success(t *testing.T) {
 	// Setup
 	mockService := new(MockAuditService)
 	router := setupAuditTestRouter(mockService)
 
 	auditReportID := "test-audit-report-id"
 	claimID := "test-claim-id"
-	rebuttalID := "test-rebuttal-id"
-	rebuttalContent := "Dear Insurance Adjuster, Regarding Claim #12345..."
-
-	rebuttal := &models.Rebuttal{
-		ID:            rebuttalID,
-		AuditReportID: auditReportID,
-		Content:       rebuttalContent,
-	}
 
 	// Set up expectations
-	mockService.On("GenerateRebuttal", mock.Anything, auditReportID, "test-user-id", "test-org-id").Return(rebuttalID, nil)
-	mockService.On("GetRebuttal", mock.Anything, rebuttalID, "test-org-id").Return(rebuttal, nil)
+	mockService.On("RunPMBrainAnalysis", mock.Anything, auditReportID, "test-user-id", "test-org-id").
+		Return(&services.PMBrainAnalysis{Status: "DISPUTE_OFFER"}, nil)
 
 	// Create request
-	req, _ := http.NewRequest("POST", "/api/claims/"+claimID+"/audit/"+auditReportID+"/rebuttal", nil)
+	req, _ := http.NewRequest("POST", "/api/claims/"+claimID+"/audit/"+auditReportID+"/pm-brain", nil)
 	w := httptest.NewRecorder()
 
 	// Execute
@@ -270,14 +226,12 @@ func TestGenerateRebuttalHandler_Success(t *testing.T) {
 
 	// Assert
 	assert.Equal(t, http.StatusOK, w.Code)
-	assert.Contains(t, w.Body.String(), "success")
-	assert.Contains(t, w.Body.String(), rebuttalID)
-	assert.Contains(t, w.Body.String(), "Dear Insurance Adjuster")
+	assert.Contains(t, w.Body.String(), "DISPUTE_OFFER")
 
 	mockService.AssertExpectations(t)
 }
 
-func TestGenerateRebuttalHandler_AuditReportNotFound(t *testing.T) {
+func TestRunPMBrainHandler_AuditReportNotFound(t *testing.T) {
 	// Setup
 	mockService := new(MockAuditService)
 	router := setupAuditTestRouter(mockService)
@@ -286,11 +240,11 @@ func TestGenerateRebuttalHandler_AuditReportNotFound(t *testing.T) {
 	claimID := "test-claim-id"
 
 	// Set up expectations
-	mockService.On("GenerateRebuttal", mock.Anything, auditReportID, "test-user-id", "test-org-id").
-		Return("", errors.New("audit report not found"))
+	mockService.On("RunPMBrainAnalysis", mock.Anything, auditReportID, "test-user-id", "test-org-id").
+		Return(nil, errors.New("audit report not found"))
 
 	// Create request
-	req, _ := http.NewRequest("POST", "/api/claims/"+claimID+"/audit/"+auditReportID+"/rebuttal", nil)
+	req, _ := http.NewRequest("POST", "/api/claims/"+claimID+"/audit/"+auditReportID+"/pm-brain", nil)
 	w := httptest.NewRecorder()
 
 	// Execute
@@ -303,7 +257,7 @@ func TestGenerateRebuttalHandler_AuditReportNotFound(t *testing.T) {
 	mockService.AssertExpectations(t)
 }
 
-func TestGenerateRebuttalHandler_ComparisonNotAvailable(t *testing.T) {
+func TestRunPMBrainHandler_CarrierEstimateNotParsed(t *testing.T) {
 	// Setup
 	mockService := new(MockAuditService)
 	router := setupAuditTestRouter(mockService)
@@ -312,11 +266,11 @@ func TestGenerateRebuttalHandler_ComparisonNotAvailable(t *testing.T) {
 	claimID := "test-claim-id"
 
 	// Set up expectations
-	mockService.On("GenerateRebuttal", mock.Anything, auditReportID, "test-user-id", "test-org-id").
-		Return("", errors.New("comparison data not available"))
+	mockService.On("RunPMBrainAnalysis", mock.Anything, auditReportID, "test-user-id", "test-org-id").
+		Return(nil, errors.New("carrier estimate not parsed yet"))
 
 	// Create request
-	req, _ := http.NewRequest("POST", "/api/claims/"+claimID+"/audit/"+auditReportID+"/rebuttal", nil)
+	req, _ := http.NewRequest("POST", "/api/claims/"+claimID+"/audit/"+auditReportID+"/pm-brain", nil)
 	w := httptest.NewRecorder()
 
 	// Execute
@@ -324,12 +278,12 @@ func TestGenerateRebuttalHandler_ComparisonNotAvailable(t *testing.T) {
 
 	// Assert
 	assert.Equal(t, http.StatusBadRequest, w.Code)
-	assert.Contains(t, w.Body.String(), "Comparison must be run before generating rebuttal")
+	assert.Contains(t, w.Body.String(), "carrier estimate not parsed yet")
 
 	mockService.AssertExpectations(t)
 }
 
-func TestGenerateRebuttalHandler_InternalError(t *testing.T) {
+func TestRunPMBrainHandler_InternalError(t *testing.T) {
 	// Setup
 	mockService := new(MockAuditService)
 	router := setupAuditTestRouter(mockService)
@@ -338,11 +292,11 @@ func TestGenerateRebuttalHandler_InternalError(t *testing.T) {
 	claimID := "test-claim-id"
 
 	// Set up expectations
-	mockService.On("GenerateRebuttal", mock.Anything, auditReportID, "test-user-id", "test-org-id").
-		Return("", errors.New("database connection failed"))
+	mockService.On("RunPMBrainAnalysis", mock.Anything, auditReportID, "test-user-id", "test-org-id").
+		Return(nil, errors.New("database connection failed"))
 
 	// Create request
-	req, _ := http.NewRequest("POST", "/api/claims/"+claimID+"/audit/"+auditReportID+"/rebuttal", nil)
+	req, _ := http.NewRequest("POST", "/api/claims/"+claimID+"/audit/"+auditReportID+"/pm-brain", nil)
 	w := httptest.NewRecorder()
 
 	// Execute
@@ -350,30 +304,25 @@ func TestGenerateRebuttalHandler_InternalError(t *testing.T) {
 
 	// Assert
 	assert.Equal(t, http.StatusInternalServerError, w.Code)
-	assert.Contains(t, w.Body.String(), "Failed to generate rebuttal")
+	assert.Contains(t, w.Body.String(), "Failed to run PM Brain analysis")
 
 	mockService.AssertExpectations(t)
 }
 
-func TestGetRebuttalHandler_Success(t *testing.T) {
+func TestGenerateDisputeLetterHandler_Success(t *testing.T) {
 	// Setup
 	mockService := new(MockAuditService)
 	router := setupAuditTestRouter(mockService)
 
-	rebuttalID := "test-rebuttal-id"
-	rebuttalContent := "Dear Insurance Adjuster, Regarding Claim #12345..."
-
-	rebuttal := &models.Rebuttal{
-		ID:            rebuttalID,
-		AuditReportID: "test-audit-report-id",
-		Content:       rebuttalContent,
-	}
+	auditReportID := "test-audit-report-id"
+	claimID := "test-claim-id"
 
 	// Set up expectations
-	mockService.On("GetRebuttal", mock.Anything, rebuttalID, "test-org-id").Return(rebuttal, nil)
+	mockService.On("GenerateDisputeLetter", mock.Anything, auditReportID, "test-user-id", "test-org-id").
+		Return("Dear Insurance Adjuster, Regarding Claim #12345...", nil)
 
 	// Create request
-	req, _ := http.NewRequest("GET", "/api/rebuttals/"+rebuttalID, nil)
+	req, _ := http.NewRequest("POST", "/api/claims/"+claimID+"/audit/"+auditReportID+"/dispute-letter", nil)
 	w := httptest.NewRecorder()
 
 	// Execute
@@ -381,59 +330,59 @@ func TestGetRebuttalHandler_Success(t *testing.T) {
 
 	// Assert
 	assert.Equal(t, http.StatusOK, w.Code)
-	assert.Contains(t, w.Body.String(), "success")
-	assert.Contains(t, w.Body.String(), rebuttalID)
 	assert.Contains(t, w.Body.String(), "Dear Insurance Adjuster")
 
 	mockService.AssertExpectations(t)
 }
 
-func TestGetRebuttalHandler_NotFound(t *testing.T) {
+func TestGenerateDisputeLetterHandler_PMBrainNotRun(t *testing.T) {
 	// Setup
 	mockService := new(MockAuditService)
 	router := setupAuditTestRouter(mockService)
 
-	rebuttalID := "non-existent-id"
+	auditReportID := "test-audit-report-id"
+	claimID := "test-claim-id"
 
 	// Set up expectations
-	mockService.On("GetRebuttal", mock.Anything, rebuttalID, "test-org-id").
-		Return(nil, errors.New("rebuttal not found"))
+	mockService.On("GenerateDisputeLetter", mock.Anything, auditReportID, "test-user-id", "test-org-id").
+		Return("", errors.New("PM Brain analysis must be run first"))
 
 	// Create request
-	req, _ := http.NewRequest("GET", "/api/rebuttals/"+rebuttalID, nil)
+	req, _ := http.NewRequest("POST", "/api/claims/"+claimID+"/audit/"+auditReportID+"/dispute-letter", nil)
 	w := httptest.NewRecorder()
 
 	// Execute
 	router.ServeHTTP(w, req)
 
 	// Assert
-	assert.Equal(t, http.StatusNotFound, w.Code)
-	assert.Contains(t, w.Body.String(), "Rebuttal not found")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "must be run first")
 
 	mockService.AssertExpectations(t)
 }
 
-func TestGetRebuttalHandler_InternalError(t *testing.T) {
+func TestGetAuditRunHandler_NotFound(t *testing.T) {
 	// Setup
 	mockService := new(MockAuditService)
 	router := setupAuditTestRouter(mockService)
 
-	rebuttalID := "test-rebuttal-id"
+	claimID := "test-claim-id"
+	runID := "non-existent-id"
 
 	// Set up expectations
-	mockService.On("GetRebuttal", mock.Anything, rebuttalID, "test-org-id").
-		Return(nil, errors.New("database connection failed"))
+	mockService.On("GetAuditRun", mock.Anything, claimID, runID, "test-org-id").
+		Return(nil, services.ErrAuditRunNotFound)
 
 	// Create request
-	req, _ := http.NewRequest("GET", "/api/rebuttals/"+rebuttalID, nil)
+	req, _ := http.NewRequest("GET", "/api/claims/"+claimID+"/audit/runs/"+runID, nil)
 	w := httptest.NewRecorder()
 
 	// Execute
 	router.ServeHTTP(w, req)
 
 	// Assert
-	assert.Equal(t, http.StatusInternalServerError, w.Code)
-	assert.Contains(t, w.Body.String(), "Failed to retrieve rebuttal")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Contains(t, w.Body.String(), "Audit run not found")
 
 	mockService.AssertExpectations(t)
 }
@@ -12,12 +12,14 @@ import (
 type CarrierEstimateHandler struct {
 	service       *services.CarrierEstimateService
 	parserService *services.PDFParserService
+	textService   *services.DocumentTextService
 }
 
-func NewCarrierEstimateHandler(service *services.CarrierEstimateService, parserService *services.PDFParserService) *CarrierEstimateHandler {
+func NewCarrierEstimateHandler(service *services.CarrierEstimateService, parserService *services.PDFParserService, textService *services.DocumentTextService) *CarrierEstimateHandler {
 	return &CarrierEstimateHandler{
 		service:       service,
 		parserService: parserService,
+		textService:   textService,
 	}
 }
 
@@ -60,6 +62,20 @@ func (h *CarrierEstimateHandler) RequestUploadURL(c *gin.Context) {
 			})
 			return
 		}
+		if err.Error() == "carrier estimate not found" {
+			c.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"error":   "Superseded carrier estimate not found",
+			})
+			return
+		}
+		if err == models.ErrSupersedeNotCurrent {
+			c.JSON(http.StatusConflict, gin.H{
+				"success": false,
+				"error":   err.Error(),
+			})
+			return
+		}
 
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
@@ -97,6 +113,13 @@ func (h *CarrierEstimateHandler) ConfirmUpload(c *gin.Context) {
 			})
 			return
 		}
+		if err == models.ErrSupersedeNotCurrent {
+			c.JSON(http.StatusConflict, gin.H{
+				"success": false,
+				"error":   err.Error(),
+			})
+			return
+		}
 
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
@@ -105,19 +128,22 @@ func (h *CarrierEstimateHandler) ConfirmUpload(c *gin.Context) {
 		return
 	}
 
+	extractCarrierEstimateTextAsync(h.textService, estimate.ID)
+
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"data":    estimate,
 	})
 }
 
-// ListCarrierEstimates lists all carrier estimates for a claim
-// GET /api/claims/:id/carrier-estimate
+// ListCarrierEstimates lists the current carrier estimate for a claim
+// GET /api/claims/:id/carrier-estimate?include_superseded=true
 func (h *CarrierEstimateHandler) ListCarrierEstimates(c *gin.Context) {
 	user := c.MustGet("user").(models.User)
 	claimID := c.Param("id")
+	includeSuperseded := c.Query("include_superseded") == "true"
 
-	estimates, err := h.service.GetCarrierEstimatesByClaimID(c.Request.Context(), claimID, user.OrganizationID)
+	estimates, err := h.service.GetCarrierEstimatesByClaimID(c.Request.Context(), claimID, user.OrganizationID, includeSuperseded)
 	if err != nil {
 		if err.Error() == "claim not found" {
 			c.JSON(http.StatusNotFound, gin.H{
@@ -140,19 +166,58 @@ func (h *CarrierEstimateHandler) ListCarrierEstimates(c *gin.Context) {
 	})
 }
 
-// ParseCarrierEstimate triggers parsing of a carrier estimate PDF
+// ListCarrierEstimateVersions returns the version history of a carrier estimate
+// GET /api/claims/:id/carrier-estimate/:estimateId/versions
+func (h *CarrierEstimateHandler) ListCarrierEstimateVersions(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+	claimID := c.Param("id")
+	estimateID := c.Param("estimateId")
+
+	versions, err := h.service.GetCarrierEstimateVersions(c.Request.Context(), claimID, estimateID, user.OrganizationID)
+	if err != nil {
+		if err.Error() == "claim not found" {
+			c.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"error":   "Claim not found",
+			})
+			return
+		}
+		if err.Error() == "carrier estimate not found" {
+			c.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"error":   "Carrier estimate not found",
+			})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to get carrier estimate versions: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    versions,
+	})
+}
+
+// ParseCarrierEstimate triggers parsing of a carrier estimate PDF.
+// ?force=true bypasses the LLM response cache.
 // POST /api/claims/:id/carrier-estimate/:estimateId/parse
 func (h *CarrierEstimateHandler) ParseCarrierEstimate(c *gin.Context) {
 	user := c.MustGet("user").(models.User)
 	claimID := c.Param("id")
 	estimateID := c.Param("estimateId")
+	force := c.Query("force") == "true"
 
 	// Trigger async parsing in a goroutine
 	go func() {
 		// Create a new context that won't be cancelled when the request ends
 		ctx := context.Background()
 
-		err := h.parserService.ParseCarrierEstimate(ctx, estimateID, user.OrganizationID)
+		err := h.parserService.ParseCarrierEstimate(ctx, estimateID, user.OrganizationID, force)
 		if err != nil {
 			// Log the error - in production, you'd want proper logging
 			// For now, the error is stored in the database parse_error field
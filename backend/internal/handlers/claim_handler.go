@@ -281,6 +281,13 @@ func (h *ClaimHandler) UpdateClaimStep(c *gin.Context) {
 			})
 			return
 		}
+		if err.Error() == "contractor not found" {
+			c.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"error":   "Contractor not found",
+			})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
 			"error":   "Failed to update claim step: " + err.Error(),
@@ -26,6 +26,11 @@ func setupTestDB(t *testing.T) *sql.DB {
 		t.Fatalf("Failed to connect to test database: %v", err)
 	}
 
+	// Ping to verify connection
+	if err := db.Ping(); err != nil {
+		t.Skipf("Skipping test: database not available: %v", err)
+	}
+
 	// Clean up tables before test
 	cleanupTables(t, db)
 
@@ -108,7 +113,7 @@ func setupTestRouter(handler *ClaimHandler) *gin.Engine {
 
 func getDBFromHandler(handler *ClaimHandler) *sql.DB {
 	// Access the service's DB through the GetDB method
-	return handler.service.GetDB()
+	return handler.claimService.GetDB()
 }
 
 func createAuthenticatedUser(t *testing.T, db *sql.DB) (string, string, string) {
@@ -187,8 +192,8 @@ func TestPatchClaimEstimate_Success(t *testing.T) {
 	defer db.Close()
 
 	propertyService := services.NewPropertyService(db)
-	claimService := services.NewClaimService(db, propertyService)
-	handler := NewClaimHandler(claimService)
+	claimService := services.NewClaimService(db, propertyService, services.NewPolicyService(db, nil, propertyService))
+	handler := NewClaimHandler(claimService, nil)
 	router := setupTestRouter(handler)
 
 	// Create test data
@@ -233,8 +238,8 @@ func TestPatchClaimEstimate_Unauthorized(t *testing.T) {
 	defer db.Close()
 
 	propertyService := services.NewPropertyService(db)
-	claimService := services.NewClaimService(db, propertyService)
-	handler := NewClaimHandler(claimService)
+	claimService := services.NewClaimService(db, propertyService, services.NewPolicyService(db, nil, propertyService))
+	handler := NewClaimHandler(claimService, nil)
 	router := setupTestRouter(handler)
 
 	// Request without token
@@ -255,8 +260,8 @@ func TestPatchClaimEstimate_InvalidInput(t *testing.T) {
 	defer db.Close()
 
 	propertyService := services.NewPropertyService(db)
-	claimService := services.NewClaimService(db, propertyService)
-	handler := NewClaimHandler(claimService)
+	claimService := services.NewClaimService(db, propertyService, services.NewPolicyService(db, nil, propertyService))
+	handler := NewClaimHandler(claimService, nil)
 	router := setupTestRouter(handler)
 
 	_, _, token := createAuthenticatedUser(t, db)
@@ -0,0 +1,207 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/claimcoach/backend/internal/models"
+	"github.com/claimcoach/backend/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+type ContractorHandler struct {
+	service *services.ContractorService
+}
+
+func NewContractorHandler(service *services.ContractorService) *ContractorHandler {
+	return &ContractorHandler{service: service}
+}
+
+// Create adds a contractor to the organization's directory
+// POST /api/contractors
+func (h *ContractorHandler) Create(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+
+	var input services.CreateContractorInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid request: " + err.Error(),
+		})
+		return
+	}
+
+	contractor, err := h.service.CreateContractor(input, user.OrganizationID)
+	if err != nil {
+		if err.Error() == "contractor with this email already exists" {
+			c.JSON(http.StatusConflict, gin.H{
+				"success": false,
+				"error":   "A contractor with this email already exists",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to create contractor: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    contractor,
+	})
+}
+
+// List returns the organization's contractor directory
+// GET /api/contractors?trade=roofing&search=acme&include_inactive=true
+func (h *ContractorHandler) List(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+
+	filter := services.ContractorFilter{
+		Trade:           c.Query("trade"),
+		Search:          c.Query("search"),
+		IncludeInactive: c.Query("include_inactive") == "true",
+	}
+
+	contractors, err := h.service.ListContractors(user.OrganizationID, filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to get contractors: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    contractors,
+	})
+}
+
+// Get returns a single contractor
+// GET /api/contractors/:id
+func (h *ContractorHandler) Get(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+	contractorID := c.Param("id")
+
+	contractor, err := h.service.GetContractor(contractorID, user.OrganizationID)
+	if err != nil {
+		if err.Error() == "contractor not found" {
+			c.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"error":   "Contractor not found",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to get contractor: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    contractor,
+	})
+}
+
+// Update edits a contractor's profile
+// PATCH /api/contractors/:id
+func (h *ContractorHandler) Update(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+	contractorID := c.Param("id")
+
+	var input services.UpdateContractorInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid request: " + err.Error(),
+		})
+		return
+	}
+
+	contractor, err := h.service.UpdateContractor(contractorID, user.OrganizationID, input)
+	if err != nil {
+		switch err.Error() {
+		case "contractor not found":
+			c.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"error":   "Contractor not found",
+			})
+			return
+		case "contractor with this email already exists":
+			c.JSON(http.StatusConflict, gin.H{
+				"success": false,
+				"error":   "A contractor with this email already exists",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to update contractor: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    contractor,
+	})
+}
+
+// Delete deactivates a contractor; history on claims and magic links is kept
+// DELETE /api/contractors/:id
+func (h *ContractorHandler) Delete(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+	contractorID := c.Param("id")
+
+	err := h.service.DeactivateContractor(contractorID, user.OrganizationID)
+	if err != nil {
+		if err.Error() == "contractor not found" {
+			c.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"error":   "Contractor not found",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to deactivate contractor: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Contractor deactivated",
+	})
+}
+
+// GetStats returns response time, scope submission rate and estimate variance for a contractor
+// GET /api/contractors/:id/stats
+func (h *ContractorHandler) GetStats(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+	contractorID := c.Param("id")
+
+	stats, err := h.service.GetContractorStats(contractorID, user.OrganizationID)
+	if err != nil {
+		if err.Error() == "contractor not found" {
+			c.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"error":   "Contractor not found",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to get contractor stats: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    stats,
+	})
+}
@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/claimcoach/backend/internal/models"
+	"github.com/claimcoach/backend/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+type DeductibleHandler struct {
+	service *services.DeductibleService
+}
+
+func NewDeductibleHandler(service *services.DeductibleService) *DeductibleHandler {
+	return &DeductibleHandler{service: service}
+}
+
+// deductibleError maps errors shared by the deductible endpoints, reporting
+// whether it wrote a response
+func deductibleError(c *gin.Context, err error) bool {
+	switch {
+	case err.Error() == "property not found":
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "error": "Property not found"})
+	case err.Error() == "policy not found":
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "error": "Policy not found"})
+	case err.Error() == "claim not found":
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "error": "Claim not found"})
+	case errors.Is(err, services.ErrDeductibleRuleNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "error": "Deductible rule not found"})
+	case errors.Is(err, services.ErrInvalidDeductibleRule):
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+	default:
+		return false
+	}
+	return true
+}
+
+// ListRules lists the deductible rules of a property's policy
+// GET /api/properties/:id/policy/deductible-rules
+func (h *DeductibleHandler) ListRules(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+	propertyID := c.Param("id")
+
+	rules, err := h.service.ListDeductibleRules(c.Request.Context(), propertyID, user.OrganizationID)
+	if err != nil {
+		if deductibleError(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Failed to list deductible rules: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": rules})
+}
+
+// CreateRule adds a deductible rule to a property's policy
+// POST /api/properties/:id/policy/deductible-rules
+func (h *DeductibleHandler) CreateRule(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+	propertyID := c.Param("id")
+
+	var input services.CreateDeductibleRuleInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "Invalid request: " + err.Error()})
+		return
+	}
+
+	rule, err := h.service.CreateDeductibleRule(c.Request.Context(), propertyID, user.OrganizationID, user.ID, input)
+	if err != nil {
+		if deductibleError(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Failed to create deductible rule: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"success": true, "data": rule})
+}
+
+// DeleteRule removes a deductible rule from a property's policy
+// DELETE /api/properties/:id/policy/deductible-rules/:ruleId
+func (h *DeductibleHandler) DeleteRule(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+	propertyID := c.Param("id")
+
+	err := h.service.DeleteDeductibleRule(c.Request.Context(), propertyID, c.Param("ruleId"), user.OrganizationID)
+	if err != nil {
+		if deductibleError(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Failed to delete deductible rule: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Deductible rule deleted successfully"})
+}
+
+// ResolveClaimDeductible returns the deductible that applies to a claim's loss
+// GET /api/claims/:id/deductible
+func (h *DeductibleHandler) ResolveClaimDeductible(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+	claimID := c.Param("id")
+
+	result, err := h.service.ResolveClaimDeductible(c.Request.Context(), claimID, user.OrganizationID)
+	if err != nil {
+		if deductibleError(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Failed to resolve deductible: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": result})
+}
@@ -1,7 +1,10 @@
 package handlers
 
 import (
+	"fmt"
+	"log"
 	"net/http"
+	"strings"
 
 	"github.com/claimcoach/backend/internal/models"
 	"github.com/claimcoach/backend/internal/services"
@@ -9,11 +12,13 @@ import (
 )
 
 type DocumentHandler struct {
-	service *services.DocumentService
+	service        *services.DocumentService
+	archiveService *services.ArchiveService
+	textService    *services.DocumentTextService
 }
 
-func NewDocumentHandler(service *services.DocumentService) *DocumentHandler {
-	return &DocumentHandler{service: service}
+func NewDocumentHandler(service *services.DocumentService, archiveService *services.ArchiveService, textService *services.DocumentTextService) *DocumentHandler {
+	return &DocumentHandler{service: service, archiveService: archiveService, textService: textService}
 }
 
 // RequestUploadURL generates a presigned upload URL
@@ -53,6 +58,18 @@ func (h *DocumentHandler) RequestUploadURL(c *gin.Context) {
 				"error":   "File type not allowed for this document type",
 			})
 			return
+		case models.ErrSupersededNotFound:
+			c.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"error":   "Superseded document not found",
+			})
+			return
+		case models.ErrSupersedeNotCurrent, models.ErrSupersedeTypeChange:
+			c.JSON(http.StatusConflict, gin.H{
+				"success": false,
+				"error":   err.Error(),
+			})
+			return
 		}
 
 		if err.Error() == "claim not found" {
@@ -99,6 +116,13 @@ func (h *DocumentHandler) ConfirmUpload(c *gin.Context) {
 			})
 			return
 		}
+		if err == models.ErrSupersedeNotCurrent {
+			c.JSON(http.StatusConflict, gin.H{
+				"success": false,
+				"error":   err.Error(),
+			})
+			return
+		}
 
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
@@ -107,19 +131,23 @@ func (h *DocumentHandler) ConfirmUpload(c *gin.Context) {
 		return
 	}
 
+	extractDocumentTextAsync(h.textService, document.ID)
+
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"data":    document,
 	})
 }
 
-// ListDocuments lists all documents for a claim
-// GET /api/claims/:id/documents
+// ListDocuments lists the current documents for a claim
+// GET /api/claims/:id/documents?include_superseded=true
 func (h *DocumentHandler) ListDocuments(c *gin.Context) {
 	user := c.MustGet("user").(models.User)
 	claimID := c.Param("id")
 
-	documents, err := h.service.ListDocuments(claimID, user.OrganizationID)
+	includeSuperseded := c.Query("include_superseded") == "true"
+
+	documents, err := h.service.ListDocuments(claimID, user.OrganizationID, includeSuperseded)
 	if err != nil {
 		if err.Error() == "claim not found" {
 			c.JSON(http.StatusNotFound, gin.H{
@@ -173,3 +201,101 @@ func (h *DocumentHandler) GetDocument(c *gin.Context) {
 		},
 	})
 }
+
+// ListDocumentVersions returns the full version history of a document
+// GET /api/documents/:id/versions
+func (h *DocumentHandler) ListDocumentVersions(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+	documentID := c.Param("id")
+
+	versions, err := h.service.GetDocumentVersions(c.Request.Context(), documentID, user.OrganizationID)
+	if err != nil {
+		if err.Error() == "document not found" {
+			c.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"error":   "Document not found",
+			})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to get document versions: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    versions,
+	})
+}
+
+// DownloadArchive streams a ZIP of the claim's confirmed documents.
+// Optional filters: ?document_ids=a,b and/or ?document_types=contractor_photo,other.
+// Superseded versions are left out unless ?include_superseded=true.
+// GET /api/claims/:id/documents/archive
+func (h *DocumentHandler) DownloadArchive(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+	claimID := c.Param("id")
+
+	input := services.DocumentArchiveInput{
+		DocumentIDs:       splitQueryList(c.QueryArray("document_ids")),
+		DocumentTypes:     splitQueryList(c.QueryArray("document_types")),
+		IncludeSuperseded: c.Query("include_superseded") == "true",
+	}
+
+	archive, err := h.archiveService.PrepareDocumentArchive(c.Request.Context(), claimID, user.OrganizationID, input)
+	if err != nil {
+		if err == models.ErrInvalidDocumentType {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   "Invalid document type",
+			})
+			return
+		}
+		if err.Error() == "claim not found" {
+			c.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"error":   "Claim not found",
+			})
+			return
+		}
+		if err.Error() == "no documents found" {
+			c.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"error":   "No documents found",
+			})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to prepare archive: " + err.Error(),
+		})
+		return
+	}
+
+	// No Content-Length: the archive is written as it is assembled.
+	c.Header("Content-Type", "application/zip")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, archive.Filename))
+	c.Status(http.StatusOK)
+
+	if _, err := h.archiveService.Stream(c.Request.Context(), c.Writer, archive); err != nil {
+		// Headers are already sent, so the client just sees a truncated download.
+		log.Printf("Failed to stream document archive for claim %s: %v", claimID, err)
+	}
+}
+
+// splitQueryList flattens repeated and comma-separated query values.
+func splitQueryList(values []string) []string {
+	var result []string
+	for _, v := range values {
+		for _, part := range strings.Split(v, ",") {
+			if part = strings.TrimSpace(part); part != "" {
+				result = append(result, part)
+			}
+		}
+	}
+	return result
+}
@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/claimcoach/backend/internal/models"
+	"github.com/claimcoach/backend/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+type DocumentTextHandler struct {
+	service *services.DocumentTextService
+}
+
+func NewDocumentTextHandler(service *services.DocumentTextService) *DocumentTextHandler {
+	return &DocumentTextHandler{service: service}
+}
+
+// Search runs a full-text search over the organization's extracted document text
+// GET /api/documents/search?q=matching&claim_id=...&limit=25
+func (h *DocumentTextHandler) Search(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+
+	input := services.SearchDocumentsInput{
+		Query:   c.Query("q"),
+		ClaimID: c.Query("claim_id"),
+	}
+	if limit := c.Query("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   "limit must be a number",
+			})
+			return
+		}
+		input.Limit = n
+	}
+
+	hits, err := h.service.Search(c.Request.Context(), user.OrganizationID, input)
+	if err != nil {
+		if err.Error() == "search query is required" {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   "Search query is required",
+			})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to search documents: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    hits,
+	})
+}
+
+// ExtractClaimText (re)runs text extraction for every document on a claim that
+// has not been indexed yet, e.g. files uploaded before indexing existed.
+// POST /api/claims/:id/documents/extract-text
+func (h *DocumentTextHandler) ExtractClaimText(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+	claimID := c.Param("id")
+
+	if err := h.service.CheckClaimAccess(claimID, user.OrganizationID); err != nil {
+		if err.Error() == "claim not found" {
+			c.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"error":   "Claim not found",
+			})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to start text extraction: " + err.Error(),
+		})
+		return
+	}
+
+	go func() {
+		// Create a new context that won't be cancelled when the request ends
+		count, err := h.service.ExtractPendingForClaim(context.Background(), claimID)
+		if err != nil {
+			log.Printf("Text extraction for claim %s failed: %v", claimID, err)
+			return
+		}
+		log.Printf("Text extraction for claim %s finished (%d files)", claimID, count)
+	}()
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"success": true,
+		"message": "Text extraction started",
+	})
+}
+
+// extractDocumentTextAsync indexes a newly confirmed document in the background.
+// Failures are recorded on the document row by the service.
+func extractDocumentTextAsync(service *services.DocumentTextService, documentID string) {
+	if service == nil {
+		return
+	}
+	go func() {
+		if err := service.ExtractDocumentText(context.Background(), documentID); err != nil {
+			log.Printf("Text extraction for document %s failed: %v", documentID, err)
+		}
+	}()
+}
+
+// extractCarrierEstimateTextAsync indexes a newly confirmed carrier estimate in the background.
+func extractCarrierEstimateTextAsync(service *services.DocumentTextService, estimateID string) {
+	if service == nil {
+		return
+	}
+	go func() {
+		if err := service.ExtractCarrierEstimateText(context.Background(), estimateID); err != nil {
+			log.Printf("Text extraction for carrier estimate %s failed: %v", estimateID, err)
+		}
+	}()
+}
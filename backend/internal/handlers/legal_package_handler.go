@@ -19,13 +19,13 @@ func NewLegalPackageHandler(service *services.LegalPackageService) *LegalPackage
 	return &LegalPackageHandler{service: service}
 }
 
-// Download generates and streams the legal package ZIP.
+// Download generates the legal package and streams it as a ZIP.
 // GET /api/claims/:id/legal-package/download
 func (h *LegalPackageHandler) Download(c *gin.Context) {
 	user := c.MustGet("user").(models.User)
 	claimID := c.Param("id")
 
-	zipBytes, filename, err := h.service.GenerateLegalPackage(c.Request.Context(), claimID, user.OrganizationID)
+	pkg, err := h.service.PrepareLegalPackage(c.Request.Context(), claimID, user.OrganizationID)
 	if err != nil {
 		if strings.Contains(err.Error(), "audit report required") {
 			c.JSON(http.StatusBadRequest, gin.H{
@@ -49,7 +49,11 @@ func (h *LegalPackageHandler) Download(c *gin.Context) {
 		return
 	}
 
-	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
-	c.Header("Content-Length", fmt.Sprintf("%d", len(zipBytes)))
-	c.Data(http.StatusOK, "application/zip", zipBytes)
+	c.Header("Content-Type", "application/zip")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, pkg.Filename))
+	c.Status(http.StatusOK)
+
+	if err := h.service.WriteLegalPackage(c.Request.Context(), c.Writer, pkg); err != nil {
+		log.Printf("Failed to stream legal package for claim %s: %v", claimID, err)
+	}
 }
@@ -0,0 +1,25 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/claimcoach/backend/internal/llm"
+)
+
+// llmErrorStatus is the status for a failed request that called the LLM:
+// the provider's typed failures get a status the client can act on, such as
+// retrying later, and anything else is a 500
+func llmErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, llm.ErrRateLimited):
+		return http.StatusTooManyRequests
+	case errors.Is(err, llm.ErrOverloaded), errors.Is(err, llm.ErrCircuitOpen):
+		return http.StatusServiceUnavailable
+	case errors.Is(err, llm.ErrTimeout):
+		return http.StatusGatewayTimeout
+	case errors.Is(err, llm.ErrInvalidRequest):
+		return http.StatusUnprocessableEntity
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/claimcoach/backend/internal/llm"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLLMErrorStatus(t *testing.T) {
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{llm.ErrRateLimited, http.StatusTooManyRequests},
+		{llm.ErrOverloaded, http.StatusServiceUnavailable},
+		{llm.ErrCircuitOpen, http.StatusServiceUnavailable},
+		{llm.ErrTimeout, http.StatusGatewayTimeout},
+		{llm.ErrInvalidRequest, http.StatusUnprocessableEntity},
+		{errors.New("database is down"), http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		// Services wrap the client's error
+		err := fmt.Errorf("LLM API call failed: %w", tt.err)
+		assert.Equal(t, tt.want, llmErrorStatus(err), tt.err.Error())
+	}
+}
+
+func TestStreamRCVDemandLetter_RateLimitedIs429(t *testing.T) {
+	w := streamRCVDemand(&streamingRCVDemandService{err: fmt.Errorf("LLM API call failed: %w", llm.ErrRateLimited)})
+
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+}
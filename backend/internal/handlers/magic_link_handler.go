@@ -1,19 +1,23 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
+	"strings"
 
 	"github.com/claimcoach/backend/internal/models"
+	"github.com/claimcoach/backend/internal/ratelimit"
 	"github.com/claimcoach/backend/internal/services"
 	"github.com/gin-gonic/gin"
 )
 
 type MagicLinkHandler struct {
-	service *services.MagicLinkService
+	service     *services.MagicLinkService
+	textService *services.DocumentTextService
 }
 
-func NewMagicLinkHandler(service *services.MagicLinkService) *MagicLinkHandler {
-	return &MagicLinkHandler{service: service}
+func NewMagicLinkHandler(service *services.MagicLinkService, textService *services.DocumentTextService) *MagicLinkHandler {
+	return &MagicLinkHandler{service: service, textService: textService}
 }
 
 func (h *MagicLinkHandler) GenerateMagicLink(c *gin.Context) {
@@ -38,6 +42,27 @@ func (h *MagicLinkHandler) GenerateMagicLink(c *gin.Context) {
 			})
 			return
 		}
+		if err.Error() == "contractor not found" {
+			c.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"error":   "Contractor not found",
+			})
+			return
+		}
+		if strings.HasPrefix(err.Error(), "invalid magic link scope") ||
+			strings.HasPrefix(err.Error(), "expires_in_hours must be") ||
+			strings.HasPrefix(err.Error(), "invalid delivery channel") ||
+			strings.HasPrefix(err.Error(), "invalid phone number") ||
+			err.Error() == "contractor phone is required for SMS delivery" ||
+			err.Error() == "contractor name is required" ||
+			err.Error() == "contractor email is required for email delivery" ||
+			err.Error() == "contractor is inactive" {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   err.Error(),
+			})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
 			"error":   "Failed to generate magic link: " + err.Error(),
@@ -64,6 +89,11 @@ func (h *MagicLinkHandler) ValidateToken(c *gin.Context) {
 		return
 	}
 
+	// Unknown tokens count towards the per-IP invalid attempt limit
+	if result.Reason == "not_found" {
+		ratelimit.MarkInvalid(c)
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"data":    result,
@@ -120,12 +150,16 @@ func (h *MagicLinkHandler) RequestUploadURL(c *gin.Context) {
 				"error":   "File type not allowed for this document type",
 			})
 			return
+		case services.ErrUploadQuotaExceeded:
+			c.JSON(http.StatusTooManyRequests, gin.H{
+				"success": false,
+				"error":   "Upload limit reached for this magic link",
+			})
+			return
 		}
 
 		// Check for token validation errors
-		if err.Error() == "invalid or expired token: expired" ||
-			err.Error() == "invalid or expired token: not_found" ||
-			err.Error() == "invalid or expired token: completed" {
+		if isInvalidTokenError(err) {
 			c.JSON(http.StatusUnauthorized, gin.H{
 				"success": false,
 				"error":   "Invalid or expired magic link",
@@ -133,6 +167,14 @@ func (h *MagicLinkHandler) RequestUploadURL(c *gin.Context) {
 			return
 		}
 
+		if errors.Is(err, services.ErrMagicLinkScope) {
+			c.JSON(http.StatusForbidden, gin.H{
+				"success": false,
+				"error":   "This magic link does not allow this action",
+			})
+			return
+		}
+
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
 			"error":   "Failed to generate upload URL: " + err.Error(),
@@ -155,9 +197,7 @@ func (h *MagicLinkHandler) ConfirmUpload(c *gin.Context) {
 	document, err := h.service.ConfirmUploadWithToken(token, documentID)
 	if err != nil {
 		// Check for token validation errors
-		if err.Error() == "invalid or expired token: expired" ||
-			err.Error() == "invalid or expired token: not_found" ||
-			err.Error() == "invalid or expired token: completed" {
+		if isInvalidTokenError(err) {
 			c.JSON(http.StatusUnauthorized, gin.H{
 				"success": false,
 				"error":   "Invalid or expired magic link",
@@ -165,6 +205,14 @@ func (h *MagicLinkHandler) ConfirmUpload(c *gin.Context) {
 			return
 		}
 
+		if errors.Is(err, services.ErrMagicLinkScope) {
+			c.JSON(http.StatusForbidden, gin.H{
+				"success": false,
+				"error":   "This magic link does not allow this action",
+			})
+			return
+		}
+
 		if err.Error() == "document not found or already confirmed" {
 			c.JSON(http.StatusNotFound, gin.H{
 				"success": false,
@@ -180,6 +228,8 @@ func (h *MagicLinkHandler) ConfirmUpload(c *gin.Context) {
 		return
 	}
 
+	extractDocumentTextAsync(h.textService, document.ID)
+
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"data":    document,
@@ -194,9 +244,7 @@ func (h *MagicLinkHandler) ListDocuments(c *gin.Context) {
 	documents, err := h.service.ListDocumentsWithToken(token)
 	if err != nil {
 		// Handle specific errors
-		if err.Error() == "invalid or expired token: expired" ||
-			err.Error() == "invalid or expired token: not_found" ||
-			err.Error() == "invalid or expired token: completed" {
+		if isInvalidTokenError(err) {
 			c.JSON(http.StatusUnauthorized, gin.H{
 				"success": false,
 				"error":   "Invalid or expired magic link",
@@ -204,6 +252,14 @@ func (h *MagicLinkHandler) ListDocuments(c *gin.Context) {
 			return
 		}
 
+		if errors.Is(err, services.ErrMagicLinkScope) {
+			c.JSON(http.StatusForbidden, gin.H{
+				"success": false,
+				"error":   "This magic link does not allow this action",
+			})
+			return
+		}
+
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
 			"error":   "Failed to retrieve documents: " + err.Error(),
@@ -244,3 +300,145 @@ func (h *MagicLinkHandler) GetMagicLinks(c *gin.Context) {
 		"data":    magicLinks,
 	})
 }
+
+// RevokeMagicLink revokes a single magic link on a claim (protected endpoint)
+// POST /api/claims/:id/magic-links/:linkId/revoke
+func (h *MagicLinkHandler) RevokeMagicLink(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+	claimID := c.Param("id")
+	magicLinkID := c.Param("linkId")
+
+	err := h.service.RevokeMagicLink(claimID, magicLinkID, user.OrganizationID, user.ID)
+	if err != nil {
+		if err.Error() == "claim not found" {
+			c.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"error":   "Claim not found",
+			})
+			return
+		}
+		if errors.Is(err, services.ErrMagicLinkNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"error":   "Magic link not found",
+			})
+			return
+		}
+		if errors.Is(err, services.ErrMagicLinkNotActive) {
+			c.JSON(http.StatusConflict, gin.H{
+				"success": false,
+				"error":   "Magic link is not active",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to revoke magic link: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Magic link revoked",
+	})
+}
+
+// UnlockMagicLink reopens a magic link that was locked after failed attempts (protected endpoint)
+// POST /api/claims/:id/magic-links/:linkId/unlock
+func (h *MagicLinkHandler) UnlockMagicLink(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+	claimID := c.Param("id")
+	magicLinkID := c.Param("linkId")
+
+	err := h.service.UnlockMagicLink(claimID, magicLinkID, user.OrganizationID, user.ID)
+	if err != nil {
+		if err.Error() == "claim not found" {
+			c.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"error":   "Claim not found",
+			})
+			return
+		}
+		if errors.Is(err, services.ErrMagicLinkNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"error":   "Magic link not found",
+			})
+			return
+		}
+		if errors.Is(err, services.ErrMagicLinkNotLocked) {
+			c.JSON(http.StatusConflict, gin.H{
+				"success": false,
+				"error":   "Magic link is not locked",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to unlock magic link: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Magic link unlocked",
+	})
+}
+
+// isInvalidTokenError reports whether err came from a failed token validation
+// (expired, not found, completed, revoked or locked link)
+func isInvalidTokenError(err error) bool {
+	return strings.HasPrefix(err.Error(), "invalid or expired token: ")
+}
+
+// ReissueMagicLink sends the contractor a fresh link with the same settings as an expired, revoked or locked one
+// POST /api/claims/:id/magic-links/:linkId/reissue
+func (h *MagicLinkHandler) ReissueMagicLink(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+	claimID := c.Param("id")
+	magicLinkID := c.Param("linkId")
+
+	response, err := h.service.ReissueMagicLink(claimID, magicLinkID, user.OrganizationID, user.ID)
+	if err != nil {
+		if err.Error() == "claim not found" {
+			c.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"error":   "Claim not found",
+			})
+			return
+		}
+		if errors.Is(err, services.ErrMagicLinkNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"error":   "Magic link not found",
+			})
+			return
+		}
+		if errors.Is(err, services.ErrMagicLinkStillLive) {
+			c.JSON(http.StatusConflict, gin.H{
+				"success": false,
+				"error":   "Magic link is still active; revoke it before reissuing",
+			})
+			return
+		}
+		if err.Error() == "contractor is inactive" {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   err.Error(),
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to reissue magic link: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    response,
+	})
+}
@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"net/http"
+
+	"github.com/claimcoach/backend/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+type MagicLinkReminderHandler struct {
+	service    *services.MagicLinkReminderService
+	cronSecret string
+}
+
+func NewMagicLinkReminderHandler(service *services.MagicLinkReminderService, cronSecret string) *MagicLinkReminderHandler {
+	return &MagicLinkReminderHandler{service: service, cronSecret: cronSecret}
+}
+
+// Sweep runs the magic link reminder sweep on behalf of a scheduler.
+// The caller must send the configured CRON_SECRET in the X-Cron-Secret header.
+// POST /internal/magic-links/sweep
+func (h *MagicLinkReminderHandler) Sweep(c *gin.Context) {
+	if h.cronSecret == "" {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"success": false,
+			"error":   "Scheduled sweeps are not configured",
+		})
+		return
+	}
+
+	secret := c.GetHeader("X-Cron-Secret")
+	if subtle.ConstantTimeCompare([]byte(secret), []byte(h.cronSecret)) != 1 {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "Unauthorized",
+		})
+		return
+	}
+
+	result, err := h.service.RunSweep(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to run magic link sweep: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    result,
+	})
+}
@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/claimcoach/backend/internal/models"
+	"github.com/claimcoach/backend/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+type PolicyQAHandler struct {
+	service *services.PolicyQAService
+}
+
+func NewPolicyQAHandler(service *services.PolicyQAService) *PolicyQAHandler {
+	return &PolicyQAHandler{service: service}
+}
+
+// policyQAError maps errors shared by the policy Q&A endpoints, reporting
+// whether it wrote a response
+func policyQAError(c *gin.Context, err error) bool {
+	switch {
+	case err.Error() == "claim not found":
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "error": "Claim not found"})
+	case err.Error() == "property not found":
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "error": "Property not found"})
+	case err.Error() == "policy not found":
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "error": "Policy not found"})
+	case errors.Is(err, services.ErrPolicyQuestionNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "error": "Policy question not found"})
+	case errors.Is(err, services.ErrNoPolicyPDF), errors.Is(err, services.ErrInvalidPolicyQuestion),
+		errors.Is(err, services.ErrPolicyAnswerNotCited):
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+	default:
+		return false
+	}
+	return true
+}
+
+// Ask answers a question about the claim's policy with page-cited quotes
+// POST /api/claims/:id/policy/ask
+func (h *PolicyQAHandler) Ask(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+	claimID := c.Param("id")
+
+	var input services.AskPolicyQuestionInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "Invalid request: " + err.Error()})
+		return
+	}
+
+	question, err := h.service.AskPolicyQuestion(c.Request.Context(), claimID, user.OrganizationID, user.ID, input)
+	if err != nil {
+		if policyQAError(c, err) {
+			return
+		}
+		c.JSON(llmErrorStatus(err), gin.H{"success": false, "error": "Failed to answer policy question: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": question})
+}
+
+// ListConversation returns the claim's policy Q&A conversation
+// GET /api/claims/:id/policy/questions
+func (h *PolicyQAHandler) ListConversation(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+	claimID := c.Param("id")
+
+	questions, err := h.service.ListPolicyQuestions(c.Request.Context(), claimID, user.OrganizationID)
+	if err != nil {
+		if policyQAError(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Failed to list policy questions: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": questions})
+}
+
+// SaveAsNote saves an answer and its quotes as a claim note
+// POST /api/claims/:id/policy/questions/:questionId/note
+func (h *PolicyQAHandler) SaveAsNote(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+	claimID := c.Param("id")
+
+	question, err := h.service.SavePolicyAnswerAsNote(c.Request.Context(), claimID, c.Param("questionId"), user.OrganizationID, user.ID)
+	if err != nil {
+		if policyQAError(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Failed to save claim note: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": question})
+}
@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/claimcoach/backend/internal/models"
+	"github.com/claimcoach/backend/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+type PolicyTermsHandler struct {
+	service *services.PolicyTermsService
+}
+
+func NewPolicyTermsHandler(service *services.PolicyTermsService) *PolicyTermsHandler {
+	return &PolicyTermsHandler{service: service}
+}
+
+// policyTermsError maps errors shared by the policy terms endpoints, reporting
+// whether it wrote a response
+func policyTermsError(c *gin.Context, err error) bool {
+	switch {
+	case err.Error() == "property not found":
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "error": "Property not found"})
+	case err.Error() == "policy not found":
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "error": "Policy not found"})
+	case errors.Is(err, services.ErrPolicyTermsNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "error": "Policy terms not found"})
+	case errors.Is(err, services.ErrNoPolicyPDF), errors.Is(err, services.ErrPolicyTermsNotReviewable),
+		errors.Is(err, services.ErrInvalidPolicyTerms):
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+	default:
+		return false
+	}
+	return true
+}
+
+// Extract reads coverage terms from the policy PDF for PM review
+// POST /api/properties/:id/policy/terms/extract
+func (h *PolicyTermsHandler) Extract(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+	propertyID := c.Param("id")
+
+	terms, err := h.service.ExtractPolicyTerms(c.Request.Context(), propertyID, user.OrganizationID)
+	if err != nil {
+		if policyTermsError(c, err) {
+			return
+		}
+		c.JSON(llmErrorStatus(err), gin.H{"success": false, "error": "Failed to extract policy terms: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": terms})
+}
+
+// Get returns the confirmed policy terms and any extraction awaiting review
+// GET /api/properties/:id/policy/terms
+func (h *PolicyTermsHandler) Get(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+	propertyID := c.Param("id")
+
+	review, err := h.service.GetPolicyTerms(c.Request.Context(), propertyID, user.OrganizationID)
+	if err != nil {
+		if policyTermsError(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Failed to get policy terms: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": review})
+}
+
+// Confirm records the PM's reviewed policy terms
+// POST /api/properties/:id/policy/terms/:termsId/confirm
+func (h *PolicyTermsHandler) Confirm(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+	propertyID := c.Param("id")
+
+	var input services.PolicyTermsInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "Invalid request: " + err.Error()})
+		return
+	}
+
+	terms, err := h.service.ConfirmPolicyTerms(c.Request.Context(), propertyID, c.Param("termsId"), user.OrganizationID, user.ID, input)
+	if err != nil {
+		if policyTermsError(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Failed to confirm policy terms: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": terms})
+}
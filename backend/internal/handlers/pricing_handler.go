@@ -0,0 +1,298 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/claimcoach/backend/internal/address"
+	"github.com/claimcoach/backend/internal/models"
+	"github.com/claimcoach/backend/internal/pricing"
+	"github.com/claimcoach/backend/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// maxPriceImportBytes caps the size of an uploaded price CSV
+const maxPriceImportBytes = 10 << 20
+
+type PricingHandler struct {
+	service         *services.PricingService
+	propertyService *services.PropertyService
+}
+
+func NewPricingHandler(service *services.PricingService, propertyService *services.PropertyService) *PricingHandler {
+	return &PricingHandler{service: service, propertyService: propertyService}
+}
+
+// ListPrices returns the price in effect for every code for the user's organization
+// GET /api/pricing/prices?region=&as_of=YYYY-MM-DD
+func (h *PricingHandler) ListPrices(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+
+	asOf := time.Now()
+	if raw := c.Query("as_of"); raw != "" {
+		parsed, err := time.Parse(pricing.DateLayout, raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   "as_of must be a date in YYYY-MM-DD format",
+			})
+			return
+		}
+		asOf = parsed
+	}
+
+	prices, err := h.service.ResolvePrices(c.Request.Context(), user.OrganizationID, c.Query("region"), asOf)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to get prices: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    pricing.Sorted(prices),
+	})
+}
+
+// ListPriceLists returns imported price lists
+// GET /api/pricing/price-lists
+func (h *PricingHandler) ListPriceLists(c *gin.Context) {
+	lists, err := h.service.ListPriceLists(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to get price lists: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    lists,
+	})
+}
+
+// ListOverrides returns the organization's unit price overrides
+// GET /api/pricing/overrides
+func (h *PricingHandler) ListOverrides(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+
+	overrides, err := h.service.ListOrgUnitPrices(c.Request.Context(), user.OrganizationID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to get price overrides: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    overrides,
+	})
+}
+
+// UpsertOverride sets the organization's price for a code (admins only)
+// PUT /api/pricing/overrides
+func (h *PricingHandler) UpsertOverride(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+	if !requireAdmin(c, user) {
+		return
+	}
+
+	var input services.OrgUnitPriceInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid request body: " + err.Error(),
+		})
+		return
+	}
+
+	override, err := h.service.UpsertOrgUnitPrice(c.Request.Context(), user.OrganizationID, user.ID, input)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidUnitPrice) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   err.Error(),
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to save price override: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    override,
+	})
+}
+
+// ImportOverrides upserts the organization's prices from an uploaded CSV in
+// the "file" form field (admins only)
+// POST /api/pricing/overrides/import
+func (h *PricingHandler) ImportOverrides(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+	if !requireAdmin(c, user) {
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxPriceImportBytes)
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "A CSV file is required in the \"file\" field",
+		})
+		return
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Failed to read uploaded file",
+		})
+		return
+	}
+	defer file.Close()
+
+	count, err := h.service.ImportOrgUnitPrices(c.Request.Context(), user.OrganizationID, user.ID, file)
+	if err != nil {
+		var importErr *pricing.ImportError
+		if errors.As(err, &importErr) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   "Price file is invalid",
+				"rows":    importErr.Rows,
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to import price overrides: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"imported": count,
+		},
+	})
+}
+
+// DeleteOverride removes one of the organization's price overrides (admins only)
+// DELETE /api/pricing/overrides/:id
+func (h *PricingHandler) DeleteOverride(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+	if !requireAdmin(c, user) {
+		return
+	}
+
+	err := h.service.DeleteOrgUnitPrice(c.Request.Context(), user.OrganizationID, c.Param("id"))
+	if err != nil {
+		if errors.Is(err, services.ErrOrgUnitPriceNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"error":   "Price override not found",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to delete price override: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+	})
+}
+
+// ListRegionalModifiers returns regional cost modifiers, optionally for one state
+// GET /api/pricing/regional-modifiers?state=
+func (h *PricingHandler) ListRegionalModifiers(c *gin.Context) {
+	state := ""
+	if raw := c.Query("state"); raw != "" {
+		code, ok := address.NormalizeState(raw)
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   "Unknown state: " + raw,
+			})
+			return
+		}
+		state = code
+	}
+
+	modifiers, err := h.service.ListRegionalModifiers(c.Request.Context(), state)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to get regional modifiers: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    modifiers,
+	})
+}
+
+// GetPropertyAdjustment returns the regional adjustment estimates for a
+// property are priced with
+// GET /api/properties/:id/regional-adjustment
+func (h *PricingHandler) GetPropertyAdjustment(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+
+	property, err := h.propertyService.GetProperty(c.Param("id"), user.OrganizationID)
+	if err != nil {
+		if err.Error() == "property not found" {
+			c.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"error":   "Property not found",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to get property: " + err.Error(),
+		})
+		return
+	}
+
+	adjustment, err := h.service.ResolvePropertyAdjustment(c.Request.Context(), property)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to get regional adjustment: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    adjustment,
+	})
+}
+
+// requireAdmin responds 403 unless the user is an organization admin
+func requireAdmin(c *gin.Context, user models.User) bool {
+	if user.Role == models.UserRoleAdmin {
+		return true
+	}
+	c.JSON(http.StatusForbidden, gin.H{
+		"success": false,
+		"error":   "Only organization admins can change prices",
+	})
+	return false
+}
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/claimcoach/backend/internal/models"
@@ -30,6 +31,13 @@ func (h *PropertyHandler) Create(c *gin.Context) {
 
 	property, err := h.service.CreateProperty(input, user.OrganizationID)
 	if err != nil {
+		if errors.Is(err, services.ErrInvalidPropertyAddress) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   err.Error(),
+			})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
 			"error":   "Failed to create property: " + err.Error(),
@@ -102,6 +110,13 @@ func (h *PropertyHandler) Update(c *gin.Context) {
 
 	property, err := h.service.UpdateProperty(propertyID, user.OrganizationID, input)
 	if err != nil {
+		if errors.Is(err, services.ErrInvalidPropertyAddress) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   err.Error(),
+			})
+			return
+		}
 		if err.Error() == "property not found" {
 			c.JSON(http.StatusNotFound, gin.H{
 				"success": false,
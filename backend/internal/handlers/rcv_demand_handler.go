@@ -12,6 +12,7 @@ import (
 // RCVDemandServiceInterface defines the interface for RCV demand letter operations
 type RCVDemandServiceInterface interface {
 	GenerateRCVDemandLetter(ctx context.Context, claimID, userID, orgID string) (string, error)
+	StreamRCVDemandLetter(ctx context.Context, claimID, userID, orgID string, onDelta func(text string) error) (string, error)
 	GetRCVDemandLetter(ctx context.Context, demandLetterID, orgID string) (*models.RCVDemandLetter, error)
 	ListRCVDemandLettersByClaimID(ctx context.Context, claimID, orgID string) ([]models.RCVDemandLetter, error)
 	MarkAsSent(ctx context.Context, demandLetterID, userID, orgID string, input services.MarkAsSentInput) error
@@ -33,30 +34,16 @@ func (h *RCVDemandHandler) GenerateRCVDemandLetter(c *gin.Context) {
 
 	demandLetterID, err := h.service.GenerateRCVDemandLetter(c.Request.Context(), claimID, user.ID, user.OrganizationID)
 	if err != nil {
-		if err.Error() == "claim not found" {
-			c.JSON(http.StatusNotFound, gin.H{
-				"success": false,
-				"error":   "Claim not found",
-			})
-			return
-		}
-		if err.Error() == "no outstanding RCV payment" {
-			c.JSON(http.StatusBadRequest, gin.H{
-				"success": false,
-				"error":   "No outstanding RCV payment to demand",
-			})
-			return
-		}
-
-		c.JSON(http.StatusInternalServerError, gin.H{
+		status, message := rcvDemandError(err)
+		c.JSON(status, gin.H{
 			"success": false,
-			"error":   "Failed to generate RCV demand letter: " + err.Error(),
+			"error":   message,
 		})
 		return
 	}
 
 	// Get the generated letter to return in response
-	demandLetter, err := h.service.GetRCVDemandLetter(c.Request.Context(), demandLetterID, user.OrganizationID)
+	data, err := h.generatedLetterData(c.Request.Context(), demandLetterID, user.OrganizationID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
@@ -67,15 +54,60 @@ func (h *RCVDemandHandler) GenerateRCVDemandLetter(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"data": gin.H{
-			"demand_letter_id": demandLetterID,
-			"content":          demandLetter.Content,
-			"rcv_outstanding":  demandLetter.RCVOutstanding,
-			"created_at":       demandLetter.CreatedAt,
-		},
+		"data":    data,
 	})
 }
 
+// StreamRCVDemandLetter generates a demand letter like GenerateRCVDemandLetter,
+// streaming the text over server-sent events as it is written
+// POST /api/claims/:id/rcv-demand/generate/stream
+func (h *RCVDemandHandler) StreamRCVDemandLetter(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+	claimID := c.Param("id")
+	stream := newSSEStream(c)
+
+	// Detached from the request so the letter is still saved if the client disconnects
+	ctx := context.WithoutCancel(c.Request.Context())
+	demandLetterID, err := h.service.StreamRCVDemandLetter(ctx, claimID, user.ID, user.OrganizationID, stream.Delta)
+	if err != nil {
+		stream.Error(rcvDemandError(err))
+		return
+	}
+
+	data, err := h.generatedLetterData(ctx, demandLetterID, user.OrganizationID)
+	if err != nil {
+		stream.Error(http.StatusInternalServerError, "Failed to retrieve demand letter: "+err.Error())
+		return
+	}
+
+	stream.Done(data)
+}
+
+// rcvDemandError maps a demand letter generation error to a status and message
+func rcvDemandError(err error) (int, string) {
+	switch err.Error() {
+	case "claim not found":
+		return http.StatusNotFound, "Claim not found"
+	case "no outstanding RCV payment":
+		return http.StatusBadRequest, "No outstanding RCV payment to demand"
+	}
+	return llmErrorStatus(err), "Failed to generate RCV demand letter: " + err.Error()
+}
+
+// generatedLetterData is the response data for a newly generated demand letter
+func (h *RCVDemandHandler) generatedLetterData(ctx context.Context, demandLetterID, orgID string) (gin.H, error) {
+	demandLetter, err := h.service.GetRCVDemandLetter(ctx, demandLetterID, orgID)
+	if err != nil {
+		return nil, err
+	}
+	return gin.H{
+		"demand_letter_id": demandLetterID,
+		"content":          demandLetter.Content,
+		"rcv_outstanding":  demandLetter.RCVOutstanding,
+		"created_at":       demandLetter.CreatedAt,
+	}, nil
+}
+
 // ListRCVDemandLettersByClaimID retrieves all demand letters for a claim
 // GET /api/claims/:id/rcv-demand
 func (h *RCVDemandHandler) ListRCVDemandLettersByClaimID(c *gin.Context) {
@@ -3,25 +3,30 @@ package handlers
 import (
 	"errors"
 	"net/http"
+	"strconv"
 
 	"github.com/claimcoach/backend/internal/models"
+	"github.com/claimcoach/backend/internal/scopecatalog"
 	"github.com/claimcoach/backend/internal/services"
 	"github.com/gin-gonic/gin"
 )
 
 type ScopeSheetHandler struct {
 	scopeSheetService *services.ScopeSheetService
+	reviewService     *services.ScopeSheetReviewService
 	magicLinkService  *services.MagicLinkService
 	claimService      *services.ClaimService
 }
 
 func NewScopeSheetHandler(
 	scopeSheetService *services.ScopeSheetService,
+	reviewService *services.ScopeSheetReviewService,
 	magicLinkService *services.MagicLinkService,
 	claimService *services.ClaimService,
 ) *ScopeSheetHandler {
 	return &ScopeSheetHandler{
 		scopeSheetService: scopeSheetService,
+		reviewService:     reviewService,
 		magicLinkService:  magicLinkService,
 		claimService:      claimService,
 	}
@@ -57,11 +62,15 @@ func (h *ScopeSheetHandler) CreateViaMagicLink(c *gin.Context) {
 		status := http.StatusUnauthorized
 		errorMessage := "Invalid or expired magic link"
 
-		switch validationResult.Status {
+		switch validationResult.Reason {
 		case "expired":
 			errorMessage = "Magic link has expired"
 		case "completed":
 			errorMessage = "Magic link has already been used"
+		case "revoked":
+			errorMessage = "Magic link has been revoked"
+		case "locked":
+			errorMessage = "Magic link has been locked after too many failed attempts"
 		case "not_found":
 			errorMessage = "Magic link not found"
 		}
@@ -73,9 +82,22 @@ func (h *ScopeSheetHandler) CreateViaMagicLink(c *gin.Context) {
 		return
 	}
 
-	// Create the scope sheet
-	scopeSheet, err := h.scopeSheetService.CreateScopeSheet(c.Request.Context(), validationResult.Claim.ID, input)
+	// Check the link's scope covers the scope sheet
+	if !models.MagicLinkScopeAllowsScopeSheet(validationResult.Scope) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"success": false,
+			"error":   "This magic link does not allow scope sheet submission",
+		})
+		return
+	}
+
+	// Create the scope sheet as the claim's next revision
+	magicLinkID := validationResult.MagicLinkID
+	scopeSheet, err := h.scopeSheetService.CreateScopeSheet(c.Request.Context(), validationResult.Claim.ID, &magicLinkID, input)
 	if err != nil {
+		if respondScopeValidationError(c, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
 			"error":   "Failed to create scope sheet: " + err.Error(),
@@ -109,6 +131,16 @@ func (h *ScopeSheetHandler) CreateViaMagicLink(c *gin.Context) {
 	})
 }
 
+// GetCatalog returns the scope category catalog the contractor wizard renders its forms from (public endpoint)
+// GET /api/scope-catalog
+func (h *ScopeSheetHandler) GetCatalog(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    scopecatalog.Current(),
+	})
+}
+
 // GetByClaimID retrieves a scope sheet by claim ID (authenticated endpoint)
 // GET /api/claims/:id/scope-sheet
 func (h *ScopeSheetHandler) GetByClaimID(c *gin.Context) {
@@ -185,6 +217,18 @@ func (h *ScopeSheetHandler) SaveDraft(c *gin.Context) {
 			return
 		}
 
+		if errors.Is(err, services.ErrMagicLinkScope) {
+			c.JSON(http.StatusForbidden, gin.H{
+				"success": false,
+				"error":   "This magic link does not allow scope sheet access",
+			})
+			return
+		}
+
+		if respondScopeValidationError(c, err) {
+			return
+		}
+
 		// Check for invalid draft step
 		if errors.Is(err, services.ErrInvalidDraftStep) {
 			c.JSON(http.StatusBadRequest, gin.H{
@@ -224,6 +268,14 @@ func (h *ScopeSheetHandler) GetDraft(c *gin.Context) {
 			return
 		}
 
+		if errors.Is(err, services.ErrMagicLinkScope) {
+			c.JSON(http.StatusForbidden, gin.H{
+				"success": false,
+				"error":   "This magic link does not allow scope sheet access",
+			})
+			return
+		}
+
 		// Check for draft not found using errors.Is
 		if errors.Is(err, services.ErrDraftNotFound) {
 			c.JSON(http.StatusNotFound, gin.H{
@@ -245,3 +297,181 @@ func (h *ScopeSheetHandler) GetDraft(c *gin.Context) {
 		"data":    scopeSheet,
 	})
 }
+
+// ListRevisions lists every submitted revision of a claim's scope sheet, newest first
+// GET /api/claims/:id/scope-sheet/revisions
+func (h *ScopeSheetHandler) ListRevisions(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+	claimID := c.Param("id")
+
+	if !h.verifyClaim(c, claimID, user.OrganizationID) {
+		return
+	}
+
+	revisions, err := h.scopeSheetService.ListRevisions(c.Request.Context(), claimID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to list scope sheet revisions: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    revisions,
+	})
+}
+
+// GetRevision retrieves one numbered revision with the PM's review comments
+// GET /api/claims/:id/scope-sheet/revisions/:revision
+func (h *ScopeSheetHandler) GetRevision(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+	claimID := c.Param("id")
+
+	revision, err := strconv.Atoi(c.Param("revision"))
+	if err != nil || revision < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "revision must be a positive integer",
+		})
+		return
+	}
+
+	if !h.verifyClaim(c, claimID, user.OrganizationID) {
+		return
+	}
+
+	scopeSheet, err := h.scopeSheetService.GetRevision(c.Request.Context(), claimID, revision)
+	if err != nil {
+		if errors.Is(err, services.ErrScopeSheetNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"error":   "Scope sheet revision not found",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to get scope sheet revision: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    scopeSheet,
+	})
+}
+
+// Approve approves the scope sheet revision awaiting review
+// POST /api/claims/:id/scope-sheet/:scopeSheetId/approve
+func (h *ScopeSheetHandler) Approve(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+
+	scopeSheet, err := h.reviewService.ApproveScopeSheet(c.Request.Context(), c.Param("id"), c.Param("scopeSheetId"), user.OrganizationID, user.ID)
+	if err != nil {
+		h.respondReviewError(c, err, "Failed to approve scope sheet")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    scopeSheet,
+	})
+}
+
+// RequestChanges sends the scope sheet revision awaiting review back to the
+// contractor with comments, reopening a draft on their magic link
+// POST /api/claims/:id/scope-sheet/:scopeSheetId/request-changes
+func (h *ScopeSheetHandler) RequestChanges(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+
+	var input services.RequestScopeChangesInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid request: " + err.Error(),
+		})
+		return
+	}
+
+	result, err := h.reviewService.RequestChanges(c.Request.Context(), c.Param("id"), c.Param("scopeSheetId"), user.OrganizationID, user.ID, input)
+	if err != nil {
+		if errors.Is(err, services.ErrScopeChangesEmpty) || errors.Is(err, services.ErrUnknownScopeArea) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   err.Error(),
+			})
+			return
+		}
+		h.respondReviewError(c, err, "Failed to request scope sheet changes")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    result,
+	})
+}
+
+// verifyClaim checks the claim belongs to the user's organization, writing the
+// error response when it doesn't
+func (h *ScopeSheetHandler) verifyClaim(c *gin.Context, claimID, organizationID string) bool {
+	_, err := h.claimService.GetClaim(claimID, organizationID)
+	if err == nil {
+		return true
+	}
+	if err.Error() == "claim not found" {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "Claim not found",
+		})
+		return false
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{
+		"success": false,
+		"error":   "Failed to verify claim: " + err.Error(),
+	})
+	return false
+}
+
+func (h *ScopeSheetHandler) respondReviewError(c *gin.Context, err error, message string) {
+	switch {
+	case err.Error() == "claim not found":
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "Claim not found",
+		})
+	case errors.Is(err, services.ErrScopeSheetNotFound):
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "Scope sheet not found",
+		})
+	case errors.Is(err, services.ErrScopeSheetNotReviewable):
+		c.JSON(http.StatusConflict, gin.H{
+			"success": false,
+			"error":   "Only the latest revision awaiting review can be approved or sent back",
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   message + ": " + err.Error(),
+		})
+	}
+}
+
+// respondScopeValidationError writes a 400 listing each invalid field when err
+// is a catalog validation failure
+func respondScopeValidationError(c *gin.Context, err error) bool {
+	var validationErr *scopecatalog.ValidationError
+	if !errors.As(err, &validationErr) {
+		return false
+	}
+	c.JSON(http.StatusBadRequest, gin.H{
+		"success": false,
+		"error":   "Scope sheet is invalid",
+		"fields":  validationErr.Fields,
+	})
+	return true
+}
@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/claimcoach/backend/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// emptyTwiML acknowledges an inbound message without replying; the provider
+// sends its own confirmation for STOP and START
+const emptyTwiML = `<?xml version="1.0" encoding="UTF-8"?><Response></Response>`
+
+type SMSWebhookHandler struct {
+	smsService     services.SMSService
+	webhookService *services.SMSWebhookService
+	publicAPIURL   string
+}
+
+func NewSMSWebhookHandler(smsService services.SMSService, webhookService *services.SMSWebhookService, publicAPIURL string) *SMSWebhookHandler {
+	return &SMSWebhookHandler{
+		smsService:     smsService,
+		webhookService: webhookService,
+		publicAPIURL:   publicAPIURL,
+	}
+}
+
+// InboundMessage handles texts sent to our number, honoring STOP and START
+// POST /api/webhooks/sms/inbound
+func (h *SMSWebhookHandler) InboundMessage(c *gin.Context) {
+	if !h.verify(c) {
+		return
+	}
+
+	action, err := h.webhookService.HandleInboundMessage(c.PostForm("From"), c.PostForm("Body"))
+	if err != nil {
+		log.Printf("Warning: failed to handle inbound SMS: %v", err)
+	} else if action != "" {
+		log.Printf("SMS %s: %s", action, c.PostForm("From"))
+	}
+
+	c.Data(http.StatusOK, "text/xml", []byte(emptyTwiML))
+}
+
+// DeliveryStatus records delivery status callbacks for magic link texts
+// POST /api/webhooks/sms/status
+func (h *SMSWebhookHandler) DeliveryStatus(c *gin.Context) {
+	if !h.verify(c) {
+		return
+	}
+
+	err := h.webhookService.RecordDeliveryStatus(c.PostForm("MessageSid"), c.PostForm("MessageStatus"), c.PostForm("ErrorCode"))
+	if err != nil {
+		log.Printf("Warning: failed to record SMS status: %v", err)
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// verify checks the provider's webhook signature against the public URL the
+// provider called, which differs from the request URL behind API Gateway
+func (h *SMSWebhookHandler) verify(c *gin.Context) bool {
+	if err := c.Request.ParseForm(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid form body",
+		})
+		return false
+	}
+
+	baseURL := h.publicAPIURL
+	if baseURL == "" {
+		baseURL = "https://" + c.Request.Host
+	}
+	requestURL := baseURL + c.Request.URL.RequestURI()
+
+	if !h.smsService.ValidateWebhook(requestURL, c.Request.PostForm, c.GetHeader("X-Twilio-Signature")) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"success": false,
+			"error":   "Invalid webhook signature",
+		})
+		return false
+	}
+	return true
+}
@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// sseStream writes a letter generation as server-sent events: a "delta" event
+// with each piece of text as the LLM generates it, then a "done" event with
+// the body the JSON endpoint returns, or an "error" event with its error body.
+// The event stream starts with the first delta, so a request that fails
+// before anything is generated gets the usual JSON error response instead.
+//
+// Where the response can't be flushed as it is written, as behind the Lambda
+// proxy, which buffers the whole body, the deltas are dropped and the request
+// gets the JSON endpoint's response once generation finishes.
+type sseStream struct {
+	c        *gin.Context
+	flushing bool
+	started  bool
+}
+
+func newSSEStream(c *gin.Context) *sseStream {
+	return &sseStream{c: c, flushing: canFlush(c.Writer)}
+}
+
+// canFlush reports whether the writer underneath any wrappers, such as gin's
+// own, can flush. gin's Flush asserts http.Flusher unchecked and panics if not.
+func canFlush(w http.ResponseWriter) bool {
+	for {
+		wrapper, ok := w.(interface{ Unwrap() http.ResponseWriter })
+		if !ok {
+			break
+		}
+		w = wrapper.Unwrap()
+	}
+	_, ok := w.(http.Flusher)
+	return ok
+}
+
+// Delta sends a piece of generated text. It never fails: if the client has
+// gone away, generation carries on so the finished text is still saved.
+func (s *sseStream) Delta(text string) error {
+	if !s.flushing {
+		return nil
+	}
+	s.start()
+	s.c.SSEvent("delta", gin.H{"text": text})
+	s.c.Writer.Flush()
+	return nil
+}
+
+// Done sends the final response body
+func (s *sseStream) Done(data interface{}) {
+	if !s.started {
+		s.c.JSON(http.StatusOK, gin.H{"success": true, "data": data})
+		return
+	}
+	s.c.SSEvent("done", gin.H{"success": true, "data": data})
+	s.c.Writer.Flush()
+}
+
+// Error reports a failure, as a JSON response with status if the event stream
+// has not started yet
+func (s *sseStream) Error(status int, message string) {
+	if !s.started {
+		s.c.JSON(status, gin.H{"success": false, "error": message})
+		return
+	}
+	s.c.SSEvent("error", gin.H{"success": false, "error": message})
+	s.c.Writer.Flush()
+}
+
+func (s *sseStream) start() {
+	if s.started {
+		return
+	}
+	s.started = true
+	header := s.c.Writer.Header()
+	header.Set("Content-Type", "text/event-stream;charset=utf-8")
+	header.Set("Cache-Control", "no-cache")
+	header.Set("Connection", "keep-alive")
+	// Stop reverse proxies like nginx from buffering the stream
+	header.Set("X-Accel-Buffering", "no")
+	s.c.Status(http.StatusOK)
+}
@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	ginadapter "github.com/awslabs/aws-lambda-go-api-proxy/gin"
+	"github.com/claimcoach/backend/internal/models"
+	"github.com/claimcoach/backend/internal/services"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// streamingRCVDemandService streams a fixed letter in pieces
+type streamingRCVDemandService struct {
+	pieces []string
+	err    error
+	saved  string
+}
+
+func (s *streamingRCVDemandService) GenerateRCVDemandLetter(ctx context.Context, claimID, userID, orgID string) (string, error) {
+	return s.StreamRCVDemandLetter(ctx, claimID, userID, orgID, func(string) error { return nil })
+}
+
+func (s *streamingRCVDemandService) StreamRCVDemandLetter(ctx context.Context, claimID, userID, orgID string, onDelta func(text string) error) (string, error) {
+	if s.err != nil {
+		return "", s.err
+	}
+	for _, piece := range s.pieces {
+		if err := onDelta(piece); err != nil {
+			return "", err
+		}
+		s.saved += piece
+	}
+	return "letter-1", nil
+}
+
+func (s *streamingRCVDemandService) GetRCVDemandLetter(ctx context.Context, demandLetterID, orgID string) (*models.RCVDemandLetter, error) {
+	outstanding := 1250.0
+	return &models.RCVDemandLetter{ID: demandLetterID, Content: s.saved, RCVOutstanding: &outstanding, CreatedAt: time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC)}, nil
+}
+
+func (s *streamingRCVDemandService) ListRCVDemandLettersByClaimID(ctx context.Context, claimID, orgID string) ([]models.RCVDemandLetter, error) {
+	return nil, nil
+}
+
+func (s *streamingRCVDemandService) MarkAsSent(ctx context.Context, demandLetterID, userID, orgID string, input services.MarkAsSentInput) error {
+	return nil
+}
+
+func rcvDemandStreamRouter(service RCVDemandServiceInterface) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/claims/:id/rcv-demand/generate/stream", func(c *gin.Context) {
+		c.Set("user", models.User{ID: "user-1", OrganizationID: "org-1"})
+		NewRCVDemandHandler(service).StreamRCVDemandLetter(c)
+	})
+	return router
+}
+
+func streamRCVDemand(service RCVDemandServiceInterface) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/claims/claim-1/rcv-demand/generate/stream", nil)
+	rcvDemandStreamRouter(service).ServeHTTP(w, req)
+	return w
+}
+
+func TestStreamRCVDemandLetter_StreamsDeltasThenDone(t *testing.T) {
+	w := streamRCVDemand(&streamingRCVDemandService{pieces: []string{"Dear ", "Adjuster"}})
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Type"), "text/event-stream")
+	body := w.Body.String()
+	assert.Contains(t, body, "event:delta\ndata:{\"text\":\"Dear \"}\n\n")
+	assert.Contains(t, body, "event:delta\ndata:{\"text\":\"Adjuster\"}\n\n")
+	assert.Contains(t, body, "event:done\n")
+	assert.Contains(t, body, `"content":"Dear Adjuster"`)
+	assert.Contains(t, body, `"demand_letter_id":"letter-1"`)
+}
+
+func TestStreamRCVDemandLetter_ErrorBeforeStreamIsJSON(t *testing.T) {
+	w := streamRCVDemand(&streamingRCVDemandService{err: errors.New("no outstanding RCV payment")})
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Type"), "application/json")
+	assert.Contains(t, w.Body.String(), "No outstanding RCV payment to demand")
+}
+
+func TestStreamRCVDemandLetter_LambdaFallsBackToJSON(t *testing.T) {
+	service := &streamingRCVDemandService{pieces: []string{"Dear ", "Adjuster"}}
+	// The Lambda entrypoint's adapter, whose response writer can't flush
+	proxy := ginadapter.NewV2(rcvDemandStreamRouter(service))
+
+	resp, err := proxy.ProxyWithContext(context.Background(), events.APIGatewayV2HTTPRequest{
+		RawPath: "/claims/claim-1/rcv-demand/generate/stream",
+		RequestContext: events.APIGatewayV2HTTPRequestContext{
+			HTTP: events.APIGatewayV2HTTPRequestContextHTTPDescription{Method: http.MethodPost, Path: "/claims/claim-1/rcv-demand/generate/stream"},
+		},
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, resp.Headers["Content-Type"], "application/json")
+	assert.NotContains(t, resp.Body, "event:")
+	assert.Contains(t, resp.Body, `"content":"Dear Adjuster"`)
+	assert.Contains(t, resp.Body, `"demand_letter_id":"letter-1"`)
+}
@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/claimcoach/backend/internal/models"
+	"github.com/claimcoach/backend/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+type SupplementHandler struct {
+	service *services.SupplementService
+}
+
+func NewSupplementHandler(service *services.SupplementService) *SupplementHandler {
+	return &SupplementHandler{service: service}
+}
+
+// supplementError maps errors shared by the supplement endpoints, reporting
+// whether it wrote a response
+func supplementError(c *gin.Context, err error) bool {
+	switch {
+	case err.Error() == "claim not found":
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "error": "Claim not found"})
+	case err.Error() == "audit report not found":
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "No estimate found. Generate the ClaimCoach estimate first."})
+	case err.Error() == "carrier estimate not found":
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "Carrier estimate not found. Upload and parse it first."})
+	case errors.Is(err, services.ErrSupplementNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "error": "Supplement not found"})
+	case errors.Is(err, services.ErrNothingToSupplement), errors.Is(err, services.ErrInvalidSupplementTransition),
+		errors.Is(err, services.ErrInvalidSupplementUpdate),
+		strings.Contains(err.Error(), "not generated yet"), strings.Contains(err.Error(), "not parsed yet"):
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+	default:
+		return false
+	}
+	return true
+}
+
+// Generate builds a draft supplement from the estimate comparison
+// POST /api/claims/:id/supplements
+func (h *SupplementHandler) Generate(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+	claimID := c.Param("id")
+
+	supplement, err := h.service.GenerateSupplement(c.Request.Context(), claimID, user.OrganizationID, user.ID)
+	if err != nil {
+		if supplementError(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Failed to generate supplement: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"success": true, "data": supplement})
+}
+
+// List returns a claim's supplements
+// GET /api/claims/:id/supplements
+func (h *SupplementHandler) List(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+	claimID := c.Param("id")
+
+	supplements, err := h.service.ListSupplements(c.Request.Context(), claimID, user.OrganizationID)
+	if err != nil {
+		if supplementError(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Failed to list supplements: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": supplements})
+}
+
+// Get returns one supplement with its items
+// GET /api/claims/:id/supplements/:supplementId
+func (h *SupplementHandler) Get(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+	claimID := c.Param("id")
+
+	supplement, err := h.service.GetSupplement(c.Request.Context(), claimID, c.Param("supplementId"), user.OrganizationID)
+	if err != nil {
+		if supplementError(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Failed to get supplement: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": supplement})
+}
+
+// UpdateStatus records sending a supplement or the carrier's response
+// PATCH /api/claims/:id/supplements/:supplementId/status
+func (h *SupplementHandler) UpdateStatus(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+	claimID := c.Param("id")
+
+	var input services.UpdateSupplementStatusInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "Invalid request: " + err.Error()})
+		return
+	}
+
+	supplement, err := h.service.UpdateSupplementStatus(c.Request.Context(), claimID, c.Param("supplementId"), user.OrganizationID, user.ID, input)
+	if err != nil {
+		if supplementError(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Failed to update supplement: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": supplement})
+}
+
+// DownloadPDF renders a supplement as a PDF for the adjuster
+// GET /api/claims/:id/supplements/:supplementId/pdf
+func (h *SupplementHandler) DownloadPDF(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+	claimID := c.Param("id")
+
+	content, filename, err := h.service.RenderSupplementPDF(c.Request.Context(), claimID, c.Param("supplementId"), user.OrganizationID)
+	if err != nil {
+		if supplementError(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Failed to render supplement: " + err.Error()})
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	c.Data(http.StatusOK, "application/pdf", content)
+}
+
+// DownloadCSV renders a supplement as a CSV for the adjuster
+// GET /api/claims/:id/supplements/:supplementId/csv
+func (h *SupplementHandler) DownloadCSV(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+	claimID := c.Param("id")
+
+	content, filename, err := h.service.RenderSupplementCSV(c.Request.Context(), claimID, c.Param("supplementId"), user.OrganizationID)
+	if err != nil {
+		if supplementError(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Failed to render supplement: " + err.Error()})
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	c.Data(http.StatusOK, "text/csv", content)
+}
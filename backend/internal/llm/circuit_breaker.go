@@ -0,0 +1,70 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// Circuit breaker defaults
+const (
+	defaultBreakerThreshold = 5
+	defaultBreakerCooldown  = 30 * time.Second
+)
+
+// circuitBreaker fails requests fast once a provider has failed threshold
+// times in a row. After the cooldown one request is let through as a trial:
+// success closes the circuit and another failure opens it for a further
+// cooldown. Errors that are not the provider's fault, like an invalid
+// request, show it is reachable and reset the count.
+type circuitBreaker struct {
+	threshold int
+	cooldown  time.Duration
+	now       func() time.Time
+
+	mu        sync.Mutex
+	failures  int
+	openUntil time.Time
+}
+
+func newCircuitBreaker(threshold int, cooldown time.Duration) *circuitBreaker {
+	return &circuitBreaker{threshold: threshold, cooldown: cooldown, now: time.Now}
+}
+
+// allow returns ErrCircuitOpen while the circuit is open
+func (b *circuitBreaker) allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.failures < b.threshold {
+		return nil
+	}
+	now := b.now()
+	if now.Before(b.openUntil) {
+		return ErrCircuitOpen
+	}
+	// Let this request through as the trial and hold the rest off until it resolves
+	b.openUntil = now.Add(b.cooldown)
+	return nil
+}
+
+// record updates the breaker with the outcome of a request
+func (b *circuitBreaker) record(err error) {
+	// A caller giving up says nothing about the provider
+	if errors.Is(err, context.Canceled) {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if err == nil || !isProviderFailure(err) {
+		b.failures = 0
+		return
+	}
+	b.failures++
+	if b.failures >= b.threshold {
+		b.openUntil = b.now().Add(b.cooldown)
+	}
+}
@@ -7,27 +7,48 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"math/rand/v2"
 	"net/http"
 	"time"
 )
 
-// ClaudeClient provides access to the Anthropic Claude API.
+// Retry backoff bounds
+const (
+	defaultRetryBase = time.Second
+	maxRetryBackoff  = 30 * time.Second
+	// maxRetryAfter is the longest retry-after the client will wait out;
+	// a longer one is returned to the caller instead
+	maxRetryAfter = time.Minute
+)
+
+// ClaudeClient provides access to the Anthropic Claude API with automatic
+// retries and a circuit breaker. Rate limits, overloads and server errors are
+// retried with jittered exponential backoff, honoring retry-after, and once
+// the API has failed several times in a row requests fail fast with
+// ErrCircuitOpen until it recovers.
 type ClaudeClient struct {
 	apiKey     string
 	model      string
+	maxRetries int
 	httpClient *http.Client
 	baseURL    string
+	retryBase  time.Duration // first backoff delay, doubled on each retry
+	breaker    *circuitBreaker
 }
 
-// NewClaudeClient creates a new Anthropic Claude API client.
-func NewClaudeClient(apiKey, model string, timeoutSeconds int) *ClaudeClient {
+// NewClaudeClient creates a new Anthropic Claude API client. maxRetries is the
+// number of attempts made for each request.
+func NewClaudeClient(apiKey, model string, timeoutSeconds, maxRetries int) *ClaudeClient {
 	return &ClaudeClient{
-		apiKey: apiKey,
-		model:  model,
+		apiKey:     apiKey,
+		model:      model,
+		maxRetries: maxRetries,
 		httpClient: &http.Client{
 			Timeout: time.Duration(timeoutSeconds) * time.Second,
 		},
-		baseURL: "https://api.anthropic.com/v1/messages",
+		baseURL:   "https://api.anthropic.com/v1/messages",
+		retryBase: defaultRetryBase,
+		breaker:   newCircuitBreaker(defaultBreakerThreshold, defaultBreakerCooldown),
 	}
 }
 
@@ -53,6 +74,7 @@ type claudeRequest struct {
 	MaxTokens int             `json:"max_tokens"`
 	System    string          `json:"system,omitempty"`
 	Messages  []claudeMessage `json:"messages"`
+	Stream    bool            `json:"stream,omitempty"`
 }
 
 type claudeResponseContent struct {
@@ -71,7 +93,18 @@ type claudeResponse struct {
 // Chat sends a text chat request to Claude and returns a response in the same
 // shape as ChatResponse so it satisfies the LLMClient interface.
 func (c *ClaudeClient) Chat(ctx context.Context, messages []Message, temperature float64, maxTokens int) (*ChatResponse, error) {
-	// Separate system message from conversation messages
+	responseText, err := c.sendRequest(ctx, c.chatRequest(messages, maxTokens))
+	if err != nil {
+		return nil, err
+	}
+
+	// Return in ChatResponse shape so existing callers work unchanged
+	return assistantResponse(responseText), nil
+}
+
+// chatRequest converts chat messages to a Messages API request, moving the
+// system message to the system field
+func (c *ClaudeClient) chatRequest(messages []Message, maxTokens int) claudeRequest {
 	var systemPrompt string
 	var convMessages []claudeMessage
 	for _, m := range messages {
@@ -87,19 +120,16 @@ func (c *ClaudeClient) Chat(ctx context.Context, messages []Message, temperature
 		}
 	}
 
-	req := claudeRequest{
+	return claudeRequest{
 		Model:     c.model,
 		MaxTokens: maxTokens,
 		System:    systemPrompt,
 		Messages:  convMessages,
 	}
+}
 
-	responseText, err := c.sendRequest(ctx, req)
-	if err != nil {
-		return nil, err
-	}
-
-	// Return in ChatResponse shape so existing callers work unchanged
+// assistantResponse wraps response text in a ChatResponse with a single choice
+func assistantResponse(text string) *ChatResponse {
 	return &ChatResponse{
 		Choices: []struct {
 			Index   int `json:"index"`
@@ -115,11 +145,11 @@ func (c *ClaudeClient) Chat(ctx context.Context, messages []Message, temperature
 					Content string `json:"content"`
 				}{
 					Role:    "assistant",
-					Content: responseText,
+					Content: text,
 				},
 			},
 		},
-	}, nil
+	}
 }
 
 // ParsePDF sends a PDF document to Claude and returns the text response.
@@ -150,48 +180,156 @@ func (c *ClaudeClient) ParsePDF(ctx context.Context, pdfContent []byte, prompt s
 	return c.sendRequest(ctx, req)
 }
 
-// sendRequest marshals and sends a claudeRequest, returning the first text response.
+// ParseImage sends an image (JPEG, PNG, GIF or WebP) to Claude and returns the text response.
+func (c *ClaudeClient) ParseImage(ctx context.Context, imageContent []byte, mediaType string, prompt string, maxTokens int) (string, error) {
+	encodedImage := base64.StdEncoding.EncodeToString(imageContent)
+
+	req := claudeRequest{
+		Model:     c.model,
+		MaxTokens: maxTokens,
+		Messages: []claudeMessage{
+			{
+				Role: "user",
+				Content: []claudeContentBlock{
+					{
+						Type: "image",
+						Source: &claudeDocumentSource{
+							Type:      "base64",
+							MediaType: mediaType,
+							Data:      encodedImage,
+						},
+					},
+					{Type: "text", Text: prompt},
+				},
+			},
+		},
+	}
+
+	return c.sendRequest(ctx, req)
+}
+
+// sendRequest sends a claudeRequest with retries, returning the first text response.
 func (c *ClaudeClient) sendRequest(ctx context.Context, req claudeRequest) (string, error) {
-	body, err := json.Marshal(req)
-	if err != nil {
-		return "", fmt.Errorf("failed to marshal request: %w", err)
+	var text string
+	err := c.withRetry(ctx, func() error {
+		resp, err := c.do(ctx, req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+
+		respBody, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return requestError(fmt.Errorf("failed to read response: %w", err))
+		}
+
+		var claudeResp claudeResponse
+		if err := json.Unmarshal(respBody, &claudeResp); err != nil {
+			return fmt.Errorf("failed to decode response: %w", err)
+		}
+		if len(claudeResp.Content) == 0 {
+			return fmt.Errorf("no content in Claude response")
+		}
+		text = claudeResp.Content[0].Text
+		return nil
+	})
+	return text, err
+}
+
+// withRetry runs attempt until it succeeds, fails with an error that is not
+// retryable, or has been tried maxRetries times. Each attempt goes through
+// the circuit breaker.
+func (c *ClaudeClient) withRetry(ctx context.Context, attempt func() error) error {
+	var err error
+	for i := 0; i < c.maxRetries; i++ {
+		if i > 0 {
+			delay, ok := c.backoff(i, err)
+			if !ok {
+				return err
+			}
+			select {
+			case <-ctx.Done():
+				return err
+			case <-time.After(delay):
+			}
+		}
+
+		if err = c.breaker.allow(); err != nil {
+			return err
+		}
+		err = attempt()
+		c.breaker.record(err)
+		if err == nil || !isRetryable(err) {
+			return err
+		}
+	}
+	if c.maxRetries > 1 {
+		return fmt.Errorf("all %d attempts failed: %w", c.maxRetries, err)
 	}
+	return err
+}
 
-	httpReq, err := http.NewRequestWithContext(ctx, "POST", c.baseURL, bytes.NewBuffer(body))
-	if err != nil {
-		return "", fmt.Errorf("failed to create request: %w", err)
+// backoff returns the delay before retry number retry: the retry-after the
+// API asked for, or exponential backoff with jitter. It reports false when
+// the API asked for a longer wait than the client is willing to make.
+func (c *ClaudeClient) backoff(retry int, err error) (time.Duration, bool) {
+	if after := retryAfter(err); after > 0 {
+		return after, after <= maxRetryAfter
+	}
+	delay := c.retryBase << (retry - 1)
+	if delay <= 0 || delay > maxRetryBackoff {
+		delay = maxRetryBackoff
 	}
+	// Equal jitter: half the delay fixed and half random
+	return delay/2 + rand.N(delay/2+1), true
+}
 
-	httpReq.Header.Set("x-api-key", c.apiKey)
-	httpReq.Header.Set("anthropic-version", "2023-06-01")
-	httpReq.Header.Set("content-type", "application/json")
+// do sends one request, returning the response when the API accepted it and
+// the error it returned otherwise
+func (c *ClaudeClient) do(ctx context.Context, req claudeRequest) (*http.Response, error) {
+	httpReq, err := c.newHTTPRequest(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if req.Stream {
+		httpReq.Header.Set("accept", "text/event-stream")
+	}
 
 	resp, err := c.httpClient.Do(httpReq)
 	if err != nil {
-		return "", fmt.Errorf("failed to make request: %w", err)
+		return nil, requestError(err)
+	}
+	if resp.StatusCode == http.StatusOK {
+		return resp, nil
 	}
 	defer resp.Body.Close()
 
 	respBody, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return "", fmt.Errorf("failed to read response: %w", err)
+		return nil, requestError(fmt.Errorf("failed to read response: %w", err))
 	}
-
 	var claudeResp claudeResponse
-	if err := json.Unmarshal(respBody, &claudeResp); err != nil {
-		return "", fmt.Errorf("failed to decode response: %w", err)
+	errType, message := "", string(respBody)
+	if json.Unmarshal(respBody, &claudeResp) == nil && claudeResp.Error != nil {
+		errType, message = claudeResp.Error.Type, claudeResp.Error.Message
 	}
+	return nil, newClaudeAPIError(resp.StatusCode, errType, message, parseRetryAfter(resp.Header.Get("retry-after")))
+}
 
-	if resp.StatusCode != http.StatusOK {
-		if claudeResp.Error != nil {
-			return "", fmt.Errorf("Claude API error %d: %s", resp.StatusCode, claudeResp.Error.Message)
-		}
-		return "", fmt.Errorf("Claude API returned status %d: %s", resp.StatusCode, string(respBody))
+// newHTTPRequest builds an authenticated Messages API request
+func (c *ClaudeClient) newHTTPRequest(ctx context.Context, req claudeRequest) (*http.Request, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	if len(claudeResp.Content) == 0 {
-		return "", fmt.Errorf("no content in Claude response")
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", c.baseURL, bytes.NewBuffer(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	return claudeResp.Content[0].Text, nil
+	httpReq.Header.Set("x-api-key", c.apiKey)
+	httpReq.Header.Set("anthropic-version", "2023-06-01")
+	httpReq.Header.Set("content-type", "application/json")
+	return httpReq, nil
 }
@@ -0,0 +1,232 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const claudeTextResponse = `{"content":[{"type":"text","text":"Test response"}]}`
+
+// newTestClaudeClient points a client at server with millisecond backoff
+func newTestClaudeClient(server *httptest.Server, maxRetries int) *ClaudeClient {
+	client := NewClaudeClient("test-key", "claude-test", 60, maxRetries)
+	client.baseURL = server.URL
+	client.retryBase = time.Millisecond
+	return client
+}
+
+func chatOnce(client *ClaudeClient) (*ChatResponse, error) {
+	return client.Chat(context.Background(), []Message{{Role: "user", Content: "hi"}}, 0, 100)
+}
+
+func TestClaudeClient_Chat_RetriesOverload(t *testing.T) {
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&attempts, 1) < 3 {
+			w.WriteHeader(529)
+			w.Write([]byte(`{"type":"error","error":{"type":"overloaded_error","message":"Overloaded"}}`))
+			return
+		}
+		w.Write([]byte(claudeTextResponse))
+	}))
+	defer server.Close()
+
+	resp, err := chatOnce(newTestClaudeClient(server, 3))
+
+	require.NoError(t, err)
+	assert.Equal(t, "Test response", resp.Choices[0].Message.Content)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&attempts))
+}
+
+func TestClaudeClient_Chat_ReturnsTypedErrorAfterRetries(t *testing.T) {
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		w.WriteHeader(529)
+		w.Write([]byte(`{"type":"error","error":{"type":"overloaded_error","message":"Overloaded"}}`))
+	}))
+	defer server.Close()
+
+	_, err := chatOnce(newTestClaudeClient(server, 3))
+
+	assert.ErrorIs(t, err, ErrOverloaded)
+	assert.Contains(t, err.Error(), "all 3 attempts failed")
+	assert.Equal(t, int32(3), atomic.LoadInt32(&attempts))
+}
+
+func TestClaudeClient_Chat_HonorsRetryAfter(t *testing.T) {
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&attempts, 1) == 1 {
+			w.Header().Set("retry-after", "0.2")
+			w.WriteHeader(http.StatusTooManyRequests)
+			w.Write([]byte(`{"type":"error","error":{"type":"rate_limit_error","message":"Rate limited"}}`))
+			return
+		}
+		w.Write([]byte(claudeTextResponse))
+	}))
+	defer server.Close()
+
+	start := time.Now()
+	_, err := chatOnce(newTestClaudeClient(server, 3))
+
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, time.Since(start), 200*time.Millisecond)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&attempts))
+}
+
+func TestClaudeClient_Chat_DoesNotWaitOutLongRetryAfter(t *testing.T) {
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		w.Header().Set("retry-after", "3600")
+		w.WriteHeader(http.StatusTooManyRequests)
+		w.Write([]byte(`{"type":"error","error":{"type":"rate_limit_error","message":"Rate limited"}}`))
+	}))
+	defer server.Close()
+
+	_, err := chatOnce(newTestClaudeClient(server, 3))
+
+	assert.ErrorIs(t, err, ErrRateLimited)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&attempts))
+	assert.Equal(t, time.Hour, retryAfter(err))
+}
+
+func TestClaudeClient_Chat_NoRetryOnInvalidRequest(t *testing.T) {
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"type":"error","error":{"type":"invalid_request_error","message":"prompt is too long"}}`))
+	}))
+	defer server.Close()
+
+	_, err := chatOnce(newTestClaudeClient(server, 3))
+
+	assert.ErrorIs(t, err, ErrInvalidRequest)
+	assert.Contains(t, err.Error(), "Claude API error 400: prompt is too long")
+	assert.Equal(t, int32(1), atomic.LoadInt32(&attempts))
+}
+
+func TestClaudeClient_Chat_Timeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+		w.Write([]byte(claudeTextResponse))
+	}))
+	defer server.Close()
+
+	client := newTestClaudeClient(server, 3)
+	client.httpClient.Timeout = 20 * time.Millisecond
+
+	_, err := chatOnce(client)
+
+	assert.ErrorIs(t, err, ErrTimeout)
+}
+
+func TestClaudeClient_CircuitBreaker(t *testing.T) {
+	var attempts int32
+	var healthy atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		if healthy.Load() {
+			w.Write([]byte(claudeTextResponse))
+			return
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(`{"type":"error","error":{"type":"api_error","message":"Internal server error"}}`))
+	}))
+	defer server.Close()
+
+	client := newTestClaudeClient(server, 1)
+	now := time.Now()
+	client.breaker.now = func() time.Time { return now }
+
+	for i := 0; i < defaultBreakerThreshold; i++ {
+		_, err := chatOnce(client)
+		require.Error(t, err)
+	}
+
+	// Open: fails fast without calling the API
+	_, err := chatOnce(client)
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+	assert.Equal(t, int32(defaultBreakerThreshold), atomic.LoadInt32(&attempts))
+
+	// After the cooldown a failed trial reopens it
+	now = now.Add(defaultBreakerCooldown)
+	_, err = chatOnce(client)
+	assert.NotErrorIs(t, err, ErrCircuitOpen)
+	_, err = chatOnce(client)
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+
+	// A successful trial closes it
+	healthy.Store(true)
+	now = now.Add(defaultBreakerCooldown)
+	_, err = chatOnce(client)
+	require.NoError(t, err)
+	_, err = chatOnce(client)
+	require.NoError(t, err)
+}
+
+func TestClaudeClient_CircuitBreaker_IgnoresInvalidRequests(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"type":"error","error":{"type":"invalid_request_error","message":"bad"}}`))
+	}))
+	defer server.Close()
+
+	client := newTestClaudeClient(server, 1)
+	for i := 0; i < defaultBreakerThreshold+1; i++ {
+		_, err := chatOnce(client)
+		assert.ErrorIs(t, err, ErrInvalidRequest)
+	}
+}
+
+func TestClaudeClient_ChatStream_RetriesBeforeFirstDelta(t *testing.T) {
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		if atomic.AddInt32(&attempts, 1) == 1 {
+			fmt.Fprint(w, "event: error\ndata: {\"type\":\"error\",\"error\":{\"type\":\"overloaded_error\",\"message\":\"Overloaded\"}}\n\n")
+			return
+		}
+		fmt.Fprint(w, "data: {\"type\":\"content_block_delta\",\"delta\":{\"type\":\"text_delta\",\"text\":\"Dear\"}}\n\n")
+	}))
+	defer server.Close()
+
+	var deltas []string
+	resp, err := newTestClaudeClient(server, 3).ChatStream(context.Background(), []Message{{Role: "user", Content: "hi"}}, 0, 100,
+		func(text string) error {
+			deltas = append(deltas, text)
+			return nil
+		})
+
+	require.NoError(t, err)
+	assert.Equal(t, "Dear", resp.Choices[0].Message.Content)
+	assert.Equal(t, []string{"Dear"}, deltas)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&attempts))
+}
+
+func TestClaudeClient_ChatStream_NoRetryAfterDelta(t *testing.T) {
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "data: {\"type\":\"content_block_delta\",\"delta\":{\"type\":\"text_delta\",\"text\":\"Dear\"}}\n\n")
+		fmt.Fprint(w, "event: error\ndata: {\"type\":\"error\",\"error\":{\"type\":\"overloaded_error\",\"message\":\"Overloaded\"}}\n\n")
+	}))
+	defer server.Close()
+
+	_, err := newTestClaudeClient(server, 3).ChatStream(context.Background(), []Message{{Role: "user", Content: "hi"}}, 0, 100,
+		func(string) error { return nil })
+
+	assert.ErrorIs(t, err, ErrOverloaded)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&attempts))
+}
@@ -0,0 +1,133 @@
+package llm
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// maxStreamLineSize bounds a single server-sent event line
+const maxStreamLineSize = 1024 * 1024
+
+// claudeStreamEvent is the union of the Messages API streaming events this
+// client reads: message_start, content_block_delta, message_delta and error.
+// Other events (ping, content_block_start/stop, message_stop) are ignored.
+type claudeStreamEvent struct {
+	Type    string `json:"type"`
+	Message *struct {
+		Model string `json:"model"`
+		Usage struct {
+			InputTokens int `json:"input_tokens"`
+		} `json:"usage"`
+	} `json:"message,omitempty"`
+	Delta *struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	} `json:"delta,omitempty"`
+	Usage *struct {
+		OutputTokens int `json:"output_tokens"`
+	} `json:"usage,omitempty"`
+	Error *struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error,omitempty"`
+}
+
+// ChatStream sends a text chat request to Claude using the streaming Messages
+// API, calling onDelta with each piece of text as it arrives. It returns the
+// complete response once the stream ends, in the same shape as Chat. An error
+// returned by onDelta aborts the request. Failures are retried like Chat's
+// until the first text arrives; after that they are returned.
+func (c *ClaudeClient) ChatStream(ctx context.Context, messages []Message, temperature float64, maxTokens int, onDelta func(text string) error) (*ChatResponse, error) {
+	req := c.chatRequest(messages, maxTokens)
+	req.Stream = true
+
+	var response *ChatResponse
+	err := c.withRetry(ctx, func() error {
+		var err error
+		response, err = c.stream(ctx, req, onDelta)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// stream makes one streaming request. Failures after text has been passed to
+// onDelta are wrapped in streamInterrupted so they are not retried.
+func (c *ClaudeClient) stream(ctx context.Context, req claudeRequest, onDelta func(text string) error) (*ChatResponse, error) {
+	resp, err := c.do(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var text strings.Builder
+	var model string
+	var inputTokens, outputTokens int
+	fail := func(err error) (*ChatResponse, error) {
+		if text.Len() > 0 {
+			return nil, &streamInterrupted{err: err}
+		}
+		return nil, err
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxStreamLineSize)
+	for scanner.Scan() {
+		// Every event carries its type in the data payload too, so the
+		// "event:" lines can be skipped
+		data, ok := strings.CutPrefix(scanner.Text(), "data:")
+		if !ok {
+			continue
+		}
+
+		var event claudeStreamEvent
+		if err := json.Unmarshal([]byte(strings.TrimSpace(data)), &event); err != nil {
+			return fail(fmt.Errorf("failed to decode stream event: %w", err))
+		}
+
+		switch event.Type {
+		case "message_start":
+			if event.Message != nil {
+				model = event.Message.Model
+				inputTokens = event.Message.Usage.InputTokens
+			}
+		case "content_block_delta":
+			if event.Delta == nil || event.Delta.Type != "text_delta" || event.Delta.Text == "" {
+				continue
+			}
+			text.WriteString(event.Delta.Text)
+			if err := onDelta(event.Delta.Text); err != nil {
+				return nil, &streamInterrupted{err: err}
+			}
+		case "message_delta":
+			if event.Usage != nil {
+				outputTokens = event.Usage.OutputTokens
+			}
+		case "error":
+			errType, message := "", "stream failed"
+			if event.Error != nil {
+				errType, message = event.Error.Type, event.Error.Message
+			}
+			return fail(newClaudeAPIError(0, errType, message, 0))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fail(requestError(fmt.Errorf("failed to read stream: %w", err)))
+	}
+
+	if text.Len() == 0 {
+		return nil, fmt.Errorf("no content in Claude response")
+	}
+
+	response := assistantResponse(text.String())
+	response.Model = model
+	response.Usage.PromptTokens = inputTokens
+	response.Usage.CompletionTokens = outputTokens
+	response.Usage.TotalTokens = inputTokens + outputTokens
+	return response, nil
+}
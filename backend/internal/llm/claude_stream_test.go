@@ -0,0 +1,126 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClaudeClient_ChatStream_Success(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "test-key", r.Header.Get("x-api-key"))
+		assert.Equal(t, "text/event-stream", r.Header.Get("accept"))
+
+		bodyBytes, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		var request claudeRequest
+		require.NoError(t, json.Unmarshal(bodyBytes, &request))
+		assert.True(t, request.Stream)
+		assert.Equal(t, "Be brief", request.System)
+		require.Len(t, request.Messages, 1)
+		assert.Equal(t, "Write a letter", request.Messages[0].Content[0].Text)
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		events := []string{
+			`event: message_start
+data: {"type":"message_start","message":{"model":"claude-test","usage":{"input_tokens":12}}}`,
+			`event: ping
+data: {"type":"ping"}`,
+			`event: content_block_delta
+data: {"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Dear "}}`,
+			`event: content_block_delta
+data: {"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Adjuster"}}`,
+			`event: message_delta
+data: {"type":"message_delta","delta":{"stop_reason":"end_turn"},"usage":{"output_tokens":3}}`,
+			`event: message_stop
+data: {"type":"message_stop"}`,
+		}
+		for _, e := range events {
+			fmt.Fprintf(w, "%s\n\n", e)
+			w.(http.Flusher).Flush()
+		}
+	}))
+	defer server.Close()
+
+	client := NewClaudeClient("test-key", "claude-test", 60, 1)
+	client.baseURL = server.URL
+
+	var deltas []string
+	resp, err := client.ChatStream(context.Background(), []Message{
+		{Role: "system", Content: "Be brief"},
+		{Role: "user", Content: "Write a letter"},
+	}, 0.3, 100, func(text string) error {
+		deltas = append(deltas, text)
+		return nil
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Dear ", "Adjuster"}, deltas)
+	require.Len(t, resp.Choices, 1)
+	assert.Equal(t, "Dear Adjuster", resp.Choices[0].Message.Content)
+	assert.Equal(t, "claude-test", resp.Model)
+	assert.Equal(t, 12, resp.Usage.PromptTokens)
+	assert.Equal(t, 3, resp.Usage.CompletionTokens)
+	assert.Equal(t, 15, resp.Usage.TotalTokens)
+}
+
+func TestClaudeClient_ChatStream_ErrorEvent(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"delta\":{\"type\":\"text_delta\",\"text\":\"Dear\"}}\n\n")
+		fmt.Fprint(w, "event: error\ndata: {\"type\":\"error\",\"error\":{\"type\":\"overloaded_error\",\"message\":\"Overloaded\"}}\n\n")
+	}))
+	defer server.Close()
+
+	client := NewClaudeClient("test-key", "claude-test", 60, 1)
+	client.baseURL = server.URL
+
+	_, err := client.ChatStream(context.Background(), []Message{{Role: "user", Content: "hi"}}, 0, 100,
+		func(string) error { return nil })
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Overloaded")
+}
+
+func TestClaudeClient_ChatStream_HTTPError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"type":"error","error":{"type":"authentication_error","message":"invalid x-api-key"}}`))
+	}))
+	defer server.Close()
+
+	client := NewClaudeClient("bad-key", "claude-test", 60, 1)
+	client.baseURL = server.URL
+
+	_, err := client.ChatStream(context.Background(), []Message{{Role: "user", Content: "hi"}}, 0, 100,
+		func(string) error { return nil })
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "401")
+	assert.Contains(t, err.Error(), "invalid x-api-key")
+}
+
+func TestClaudeClient_ChatStream_CallbackAborts(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "data: {\"type\":\"content_block_delta\",\"delta\":{\"type\":\"text_delta\",\"text\":\"Dear\"}}\n\n")
+	}))
+	defer server.Close()
+
+	client := NewClaudeClient("test-key", "claude-test", 60, 1)
+	client.baseURL = server.URL
+
+	abort := errors.New("client went away")
+	_, err := client.ChatStream(context.Background(), []Message{{Role: "user", Content: "hi"}}, 0, 100,
+		func(string) error { return abort })
+
+	assert.ErrorIs(t, err, abort)
+}
@@ -0,0 +1,149 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Typed failures of an LLM provider call. Client errors wrap one of these
+// where the failure is recognized, so callers can check with errors.Is.
+var (
+	ErrRateLimited    = errors.New("LLM rate limit exceeded")
+	ErrOverloaded     = errors.New("LLM provider overloaded")
+	ErrInvalidRequest = errors.New("LLM request rejected as invalid")
+	ErrTimeout        = errors.New("LLM request timed out")
+	// ErrCircuitOpen is returned without calling the provider after repeated
+	// consecutive failures
+	ErrCircuitOpen = errors.New("LLM provider unavailable after repeated failures")
+)
+
+// APIError is an error response from a provider API
+type APIError struct {
+	Provider   string
+	StatusCode int // 0 for an error event in a stream
+	Type       string
+	Message    string
+	// RetryAfter is how long the provider asked callers to wait, 0 if it didn't say
+	RetryAfter time.Duration
+	kind       error
+}
+
+func (e *APIError) Error() string {
+	if e.StatusCode == 0 {
+		return fmt.Sprintf("%s API error: %s", e.Provider, e.Message)
+	}
+	return fmt.Sprintf("%s API error %d: %s", e.Provider, e.StatusCode, e.Message)
+}
+
+// Unwrap returns the typed error the response was recognized as, if any
+func (e *APIError) Unwrap() error {
+	return e.kind
+}
+
+// newClaudeAPIError builds an APIError from a Claude status code and error
+// type, recognizing the typed failures
+func newClaudeAPIError(statusCode int, errType, message string, retryAfter time.Duration) *APIError {
+	e := &APIError{
+		Provider:   "Claude",
+		StatusCode: statusCode,
+		Type:       errType,
+		Message:    message,
+		RetryAfter: retryAfter,
+	}
+	switch {
+	case statusCode == http.StatusTooManyRequests || errType == "rate_limit_error":
+		e.kind = ErrRateLimited
+	case statusCode == 529 || statusCode == http.StatusServiceUnavailable || errType == "overloaded_error":
+		e.kind = ErrOverloaded
+	case statusCode == http.StatusRequestTimeout || statusCode == http.StatusGatewayTimeout || errType == "timeout_error":
+		e.kind = ErrTimeout
+	case statusCode == http.StatusBadRequest || statusCode == http.StatusRequestEntityTooLarge ||
+		errType == "invalid_request_error" || errType == "request_too_large":
+		e.kind = ErrInvalidRequest
+	}
+	return e
+}
+
+// parseRetryAfter reads a retry-after header given in seconds or as a date
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.ParseFloat(value, 64); err == nil && seconds > 0 {
+		return time.Duration(seconds * float64(time.Second))
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
+
+// transportError is a request that failed before the provider responded
+type transportError struct {
+	err error
+}
+
+func (e *transportError) Error() string { return fmt.Sprintf("failed to make request: %v", e.err) }
+func (e *transportError) Unwrap() error { return e.err }
+
+// requestError classifies an error from sending a request or reading its
+// response body. Timeouts wrap ErrTimeout.
+func requestError(err error) error {
+	var timeout interface{ Timeout() bool }
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &timeout) && timeout.Timeout()) {
+		return fmt.Errorf("%w: %w", ErrTimeout, err)
+	}
+	if errors.Is(err, context.Canceled) {
+		return err
+	}
+	return &transportError{err: err}
+}
+
+// streamInterrupted is a streaming request that failed after text had been
+// delivered, so it cannot be retried without repeating that text
+type streamInterrupted struct {
+	err error
+}
+
+func (e *streamInterrupted) Error() string { return e.err.Error() }
+func (e *streamInterrupted) Unwrap() error { return e.err }
+
+// isRetryable reports whether a failed request may succeed if sent again
+func isRetryable(err error) bool {
+	var interrupted *streamInterrupted
+	return !errors.As(err, &interrupted) && isTransient(err)
+}
+
+// isTransient reports whether an error is a temporary provider failure
+func isTransient(err error) bool {
+	if errors.Is(err, ErrRateLimited) || errors.Is(err, ErrOverloaded) {
+		return true
+	}
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode >= 500 || (apiErr.StatusCode == 0 && apiErr.Type == "api_error")
+	}
+	var transport *transportError
+	return errors.As(err, &transport)
+}
+
+// isProviderFailure reports whether an error counts against the provider's
+// health: temporary failures and timeouts
+func isProviderFailure(err error) bool {
+	return isTransient(err) || errors.Is(err, ErrTimeout)
+}
+
+// retryAfter returns the delay a provider asked for in an error, 0 if none
+func retryAfter(err error) time.Duration {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.RetryAfter
+	}
+	return 0
+}
@@ -0,0 +1,71 @@
+package models
+
+import "time"
+
+// Appraisal is an invocation of the policy's appraisal condition on a claim,
+// from the demand letter through the appraisers' award
+type Appraisal struct {
+	ID                  string               `json:"id"`
+	ClaimID             string               `json:"claim_id"`
+	Status              string               `json:"status"`
+	DisputedAmount      *float64             `json:"disputed_amount"`
+	DisputeSummary      *string              `json:"dispute_summary"`
+	DemandLetter        string               `json:"demand_letter"`
+	DemandPromptVersion *string              `json:"demand_prompt_version"`
+	InvokedAt           *time.Time           `json:"invoked_at"`
+	AppraiserDeadline   *time.Time           `json:"appraiser_deadline"`
+	UmpireDeadline      *time.Time           `json:"umpire_deadline"`
+	Parties             []AppraisalParty     `json:"parties"`
+	Award               []AppraisalAwardLine `json:"award"`
+	AwardRCVTotal       *float64             `json:"award_rcv_total"`
+	AwardACVTotal       *float64             `json:"award_acv_total"`
+	AwardedAt           *time.Time           `json:"awarded_at"`
+	ACVPaymentID        *string              `json:"acv_payment_id"`
+	RCVPaymentID        *string              `json:"rcv_payment_id"`
+	CreatedByUserID     *string              `json:"created_by_user_id"`
+	CreatedAt           time.Time            `json:"created_at"`
+	UpdatedAt           time.Time            `json:"updated_at"`
+}
+
+// AppraisalParty is an appraiser or the umpire with their contact details
+type AppraisalParty struct {
+	ID         string    `json:"id"`
+	Role       string    `json:"role"`
+	Name       string    `json:"name"`
+	Firm       *string   `json:"firm"`
+	Email      *string   `json:"email"`
+	Phone      *string   `json:"phone"`
+	SelectedAt time.Time `json:"selected_at"`
+}
+
+// AppraisalAwardLine is the amount of loss the award sets for one coverage
+type AppraisalAwardLine struct {
+	Coverage        string  `json:"coverage"`
+	ReplacementCost float64 `json:"replacement_cost"`
+	ActualCashValue float64 `json:"actual_cash_value"`
+}
+
+// Appraisal status constants
+const (
+	AppraisalDraft              = "draft"
+	AppraisalInvoked            = "invoked"
+	AppraisalAppraisersSelected = "appraisers_selected"
+	AppraisalUmpireSelected     = "umpire_selected"
+	AppraisalAwarded            = "awarded"
+	AppraisalWithdrawn          = "withdrawn"
+)
+
+// Appraisal party roles
+const (
+	AppraisalPartyOurAppraiser     = "our_appraiser"
+	AppraisalPartyCarrierAppraiser = "carrier_appraiser"
+	AppraisalPartyUmpire           = "umpire"
+)
+
+// Appraisal award coverages
+const (
+	CoverageDwelling         = "coverage_a"
+	CoverageOtherStructures  = "coverage_b"
+	CoveragePersonalProperty = "coverage_c"
+	CoverageLossOfUse        = "coverage_d"
+)
@@ -3,24 +3,30 @@ package models
 import "time"
 
 type AuditReport struct {
-	ID                      string     `json:"id" db:"id"`
-	ClaimID                 string     `json:"claim_id" db:"claim_id"`
-	ScopeSheetID            string     `json:"scope_sheet_id" db:"scope_sheet_id"`
-	CarrierEstimateID       *string    `json:"carrier_estimate_id" db:"carrier_estimate_id"`
-	GeneratedEstimate       *string    `json:"generated_estimate" db:"generated_estimate"`    // JSON string
-	ComparisonData          *string    `json:"comparison_data" db:"comparison_data"`          // JSON string
-	ViabilityAnalysis       *string    `json:"viability_analysis" db:"viability_analysis"`      // JSON string
-	PMBrainAnalysis         *string    `json:"pm_brain_analysis" db:"pm_brain_analysis"`        // JSON string
-	DisputeLetter           *string    `json:"dispute_letter" db:"dispute_letter"`              // plain text
-	OwnerPitch              *string    `json:"owner_pitch" db:"owner_pitch"`
-	TotalContractorEstimate *float64   `json:"total_contractor_estimate" db:"total_contractor_estimate"`
-	TotalCarrierEstimate    *float64   `json:"total_carrier_estimate" db:"total_carrier_estimate"`
-	TotalDelta              *float64   `json:"total_delta" db:"total_delta"`
-	Status                  string     `json:"status" db:"status"`
-	ErrorMessage            *string    `json:"error_message" db:"error_message"`
-	CreatedByUserID         string     `json:"created_by_user_id" db:"created_by_user_id"`
-	CreatedAt               time.Time  `json:"created_at" db:"created_at"`
-	UpdatedAt               time.Time  `json:"updated_at" db:"updated_at"`
+	ID                           string    `json:"id" db:"id"`
+	ClaimID                      string    `json:"claim_id" db:"claim_id"`
+	ScopeSheetID                 string    `json:"scope_sheet_id" db:"scope_sheet_id"`
+	ScopeSheetRevision           *int      `json:"scope_sheet_revision" db:"scope_sheet_revision"`
+	CarrierEstimateID            *string   `json:"carrier_estimate_id" db:"carrier_estimate_id"`
+	CarrierEstimateVersion       *int      `json:"carrier_estimate_version" db:"carrier_estimate_version"`
+	ContractorEstimateDocumentID *string   `json:"contractor_estimate_document_id" db:"contractor_estimate_document_id"`
+	ContractorEstimateVersion    *int      `json:"contractor_estimate_version" db:"contractor_estimate_version"`
+	GeneratedEstimate            *string   `json:"generated_estimate" db:"generated_estimate"` // JSON string
+	Takeoff                      *string   `json:"takeoff" db:"takeoff"`                       // JSON string
+	ComparisonData               *string   `json:"comparison_data" db:"comparison_data"`       // JSON string
+	ViabilityAnalysis            *string   `json:"viability_analysis" db:"viability_analysis"` // JSON string
+	PMBrainAnalysis              *string   `json:"pm_brain_analysis" db:"pm_brain_analysis"`   // JSON string
+	DisputeLetter                *string   `json:"dispute_letter" db:"dispute_letter"`         // plain text
+	OwnerPitch                   *string   `json:"owner_pitch" db:"owner_pitch"`
+	PromptVersions               string    `json:"prompt_versions" db:"prompt_versions"` // JSON string
+	TotalContractorEstimate      *float64  `json:"total_contractor_estimate" db:"total_contractor_estimate"`
+	TotalCarrierEstimate         *float64  `json:"total_carrier_estimate" db:"total_carrier_estimate"`
+	TotalDelta                   *float64  `json:"total_delta" db:"total_delta"`
+	Status                       string    `json:"status" db:"status"`
+	ErrorMessage                 *string   `json:"error_message" db:"error_message"`
+	CreatedByUserID              string    `json:"created_by_user_id" db:"created_by_user_id"`
+	CreatedAt                    time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt                    time.Time `json:"updated_at" db:"updated_at"`
 }
 
 // Audit report status constants
@@ -30,3 +36,47 @@ const (
 	AuditStatusCompleted  = "completed"
 	AuditStatusFailed     = "failed"
 )
+
+// AuditRun is an immutable snapshot of an audit report taken each time an
+// audit step runs, with the input versions it ran against. Outputs are only
+// loaded when a single run is fetched.
+type AuditRun struct {
+	ID                           string     `json:"id" db:"id"`
+	AuditReportID                string     `json:"audit_report_id" db:"audit_report_id"`
+	ClaimID                      string     `json:"claim_id" db:"claim_id"`
+	Kind                         string     `json:"kind" db:"kind"`
+	ScopeSheetID                 *string    `json:"scope_sheet_id" db:"scope_sheet_id"`
+	ScopeSheetRevision           *int       `json:"scope_sheet_revision" db:"scope_sheet_revision"`
+	CarrierEstimateID            *string    `json:"carrier_estimate_id" db:"carrier_estimate_id"`
+	CarrierEstimateVersion       *int       `json:"carrier_estimate_version" db:"carrier_estimate_version"`
+	ContractorEstimateDocumentID *string    `json:"contractor_estimate_document_id" db:"contractor_estimate_document_id"`
+	ContractorEstimateVersion    *int       `json:"contractor_estimate_version" db:"contractor_estimate_version"`
+	PolicyID                     *string    `json:"policy_id" db:"policy_id"`
+	PolicyUpdatedAt              *time.Time `json:"policy_updated_at" db:"policy_updated_at"`
+	PolicyDocumentID             *string    `json:"policy_document_id" db:"policy_document_id"`
+	PolicyDocumentVersion        *int       `json:"policy_document_version" db:"policy_document_version"`
+	TotalContractorEstimate      *float64   `json:"total_contractor_estimate" db:"total_contractor_estimate"`
+	TotalCarrierEstimate         *float64   `json:"total_carrier_estimate" db:"total_carrier_estimate"`
+	TotalDelta                   *float64   `json:"total_delta" db:"total_delta"`
+	CreatedByUserID              *string    `json:"created_by_user_id" db:"created_by_user_id"`
+	CreatedAt                    time.Time  `json:"created_at" db:"created_at"`
+
+	GeneratedEstimate *string `json:"generated_estimate,omitempty" db:"generated_estimate"` // JSON string
+	Takeoff           *string `json:"takeoff,omitempty" db:"takeoff"`                       // JSON string
+	PMBrainAnalysis   *string `json:"pm_brain_analysis,omitempty" db:"pm_brain_analysis"`   // JSON string
+	ViabilityAnalysis *string `json:"viability_analysis,omitempty" db:"viability_analysis"` // JSON string
+	DisputeLetter     *string `json:"dispute_letter,omitempty" db:"dispute_letter"`
+	OwnerPitch        *string `json:"owner_pitch,omitempty" db:"owner_pitch"`
+	PromptVersions    *string `json:"prompt_versions,omitempty" db:"prompt_versions"` // JSON string
+}
+
+// Audit run kinds, one per audit step. Backfill runs hold the state of
+// reports that existed before runs were recorded.
+const (
+	AuditRunBackfill      = "backfill"
+	AuditRunEstimate      = "estimate"
+	AuditRunPMBrain       = "pm_brain"
+	AuditRunViability     = "viability"
+	AuditRunDisputeLetter = "dispute_letter"
+	AuditRunOwnerPitch    = "owner_pitch"
+)
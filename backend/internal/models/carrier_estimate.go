@@ -3,17 +3,27 @@ package models
 import "time"
 
 type CarrierEstimate struct {
-	ID               string     `json:"id" db:"id"`
-	ClaimID          string     `json:"claim_id" db:"claim_id"`
-	UploadedByUserID string     `json:"uploaded_by_user_id" db:"uploaded_by_user_id"`
-	FilePath         string     `json:"file_path" db:"file_path"`
-	FileName         string     `json:"file_name" db:"file_name"`
-	FileSizeBytes    *int64     `json:"file_size_bytes" db:"file_size_bytes"`
-	ParsedData       *string    `json:"parsed_data" db:"parsed_data"` // JSONB stored as string
-	ParseStatus      string     `json:"parse_status" db:"parse_status"`
-	ParseError       *string    `json:"parse_error" db:"parse_error"`
-	UploadedAt       time.Time  `json:"uploaded_at" db:"uploaded_at"`
-	ParsedAt         *time.Time `json:"parsed_at" db:"parsed_at"`
+	ID                   string     `json:"id" db:"id"`
+	ClaimID              string     `json:"claim_id" db:"claim_id"`
+	UploadedByUserID     string     `json:"uploaded_by_user_id" db:"uploaded_by_user_id"`
+	FilePath             string     `json:"file_path" db:"file_path"`
+	FileName             string     `json:"file_name" db:"file_name"`
+	FileSizeBytes        *int64     `json:"file_size_bytes" db:"file_size_bytes"`
+	ParsedData           *string    `json:"parsed_data" db:"parsed_data"` // JSONB stored as string
+	ParseStatus          string     `json:"parse_status" db:"parse_status"`
+	ParseError           *string    `json:"parse_error" db:"parse_error"`
+	UploadedAt           time.Time  `json:"uploaded_at" db:"uploaded_at"`
+	ParsedAt             *time.Time `json:"parsed_at" db:"parsed_at"`
+	Version              int        `json:"version" db:"version"` // 0 until the upload is confirmed
+	SupersedesEstimateID *string    `json:"supersedes_estimate_id" db:"supersedes_estimate_id"`
+	IsCurrent            bool       `json:"is_current" db:"is_current"`
+	// Long estimates are parsed a page range at a time; ParseChunks holds each
+	// range's status and confidence. TotalsMatch is whether the line items add
+	// up to StatedTotal, nil when the estimate states no total.
+	PageCount   *int     `json:"page_count" db:"page_count"`
+	ParseChunks *string  `json:"parse_chunks" db:"parse_chunks"` // JSONB stored as string
+	StatedTotal *float64 `json:"stated_total" db:"stated_total"`
+	TotalsMatch *bool    `json:"totals_match" db:"totals_match"`
 }
 
 // Parse status constants
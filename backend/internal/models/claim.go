@@ -49,6 +49,7 @@ type Claim struct {
 	StepsCompleted IntArray `json:"steps_completed" db:"steps_completed"`
 
 	// Step-specific fields
+	ContractorID               *string    `json:"contractor_id" db:"contractor_id"`
 	ContractorEmail            *string    `json:"contractor_email" db:"contractor_email"`
 	ContractorName             *string    `json:"contractor_name" db:"contractor_name"`
 	ContractorPhotosUploadedAt *time.Time `json:"contractor_photos_uploaded_at" db:"contractor_photos_uploaded_at"`
@@ -86,3 +87,12 @@ type ClaimActivity struct {
 	Metadata     *string    `json:"metadata" db:"metadata"` // JSON string
 	CreatedAt    time.Time  `json:"created_at" db:"created_at"`
 }
+
+// Claim loss type constants
+const (
+	LossTypeFire  = "fire"
+	LossTypeWater = "water"
+	LossTypeWind  = "wind"
+	LossTypeHail  = "hail"
+	LossTypeOther = "other"
+)
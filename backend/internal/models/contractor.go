@@ -0,0 +1,48 @@
+package models
+
+import "time"
+
+type Contractor struct {
+	ID                            string     `json:"id" db:"id"`
+	OrganizationID                string     `json:"organization_id" db:"organization_id"`
+	Name                          string     `json:"name" db:"name"`
+	CompanyName                   *string    `json:"company_name" db:"company_name"`
+	Trade                         string     `json:"trade" db:"trade"`
+	Email                         *string    `json:"email" db:"email"`
+	Phone                         *string    `json:"phone" db:"phone"`
+	LicenseNumber                 *string    `json:"license_number" db:"license_number"`
+	InsuranceCertificateExpiresAt *time.Time `json:"insurance_certificate_expires_at" db:"insurance_certificate_expires_at"`
+	Notes                         *string    `json:"notes" db:"notes"`
+	IsActive                      bool       `json:"is_active" db:"is_active"`
+	CreatedAt                     time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt                     time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// InsuranceExpired reports whether the contractor's insurance certificate has lapsed as of t
+func (c *Contractor) InsuranceExpired(t time.Time) bool {
+	return c.InsuranceCertificateExpiresAt != nil && c.InsuranceCertificateExpiresAt.Before(t)
+}
+
+// ContractorStats summarizes how a contractor has performed across an organization's claims
+type ContractorStats struct {
+	ContractorID string `json:"contractor_id"`
+	ClaimCount   int    `json:"claim_count"`
+
+	// Response time: from a magic link being sent to the contractor's first
+	// upload or scope sheet submission on that claim
+	LinksSent               int      `json:"links_sent"`
+	LinksResponded          int      `json:"links_responded"`
+	AvgResponseTimeHours    *float64 `json:"avg_response_time_hours"`
+	MedianResponseTimeHours *float64 `json:"median_response_time_hours"`
+
+	// Scope sheets: links that allowed a scope sheet vs. those that got one submitted
+	ScopeSheetRequests   int      `json:"scope_sheet_requests"`
+	ScopeSheetsSubmitted int      `json:"scope_sheets_submitted"`
+	ScopeSubmissionRate  *float64 `json:"scope_submission_rate"`
+
+	// Variance of the contractor's estimate against the carrier's, from completed audits
+	AuditedClaims          int      `json:"audited_claims"`
+	AvgEstimateVariance    *float64 `json:"avg_estimate_variance"`
+	AvgEstimateVariancePct *float64 `json:"avg_estimate_variance_pct"`
+	TotalEstimateVariance  float64  `json:"total_estimate_variance"`
+}
@@ -3,17 +3,20 @@ package models
 import "time"
 
 type Document struct {
-	ID               string    `json:"id" db:"id"`
-	ClaimID          string    `json:"claim_id" db:"claim_id"`
-	UploadedByUserID *string   `json:"uploaded_by_user_id" db:"uploaded_by_user_id"`
-	DocumentType     string    `json:"document_type" db:"document_type"`
-	FileURL          string    `json:"file_url" db:"file_url"`
-	FileName         string    `json:"file_name" db:"file_name"`
-	FileSizeBytes    int64     `json:"file_size_bytes" db:"file_size_bytes"`
-	MimeType         string    `json:"mime_type" db:"mime_type"`
-	Metadata         *string   `json:"metadata,omitempty" db:"metadata"` // JSON string
-	Status           string    `json:"status" db:"status"`               // "pending" or "confirmed"
-	CreatedAt        time.Time `json:"created_at" db:"created_at"`
+	ID                   string    `json:"id" db:"id"`
+	ClaimID              string    `json:"claim_id" db:"claim_id"`
+	UploadedByUserID     *string   `json:"uploaded_by_user_id" db:"uploaded_by_user_id"`
+	DocumentType         string    `json:"document_type" db:"document_type"`
+	FileURL              string    `json:"file_url" db:"file_url"`
+	FileName             string    `json:"file_name" db:"file_name"`
+	FileSizeBytes        int64     `json:"file_size_bytes" db:"file_size_bytes"`
+	MimeType             string    `json:"mime_type" db:"mime_type"`
+	Metadata             *string   `json:"metadata,omitempty" db:"metadata"` // JSON string
+	Status               string    `json:"status" db:"status"`               // "pending" or "confirmed"
+	Version              int       `json:"version" db:"version"`
+	SupersedesDocumentID *string   `json:"supersedes_document_id" db:"supersedes_document_id"`
+	IsCurrent            bool      `json:"is_current" db:"is_current"`
+	CreatedAt            time.Time `json:"created_at" db:"created_at"`
 }
 
 // DocumentType constants
@@ -26,6 +29,16 @@ const (
 	DocumentTypeOther              = "other"
 )
 
+// VersionedDocumentTypes are document types where a claim only ever has one
+// current file. A new upload of one of these types automatically supersedes the
+// current version; other types (e.g. photos) only supersede when asked to.
+// The idx_documents_current_versioned unique index lists the same types.
+var VersionedDocumentTypes = map[string]bool{
+	DocumentTypePolicyPDF:          true,
+	DocumentTypeContractorEstimate: true,
+	DocumentTypeCarrierEstimate:    true,
+}
+
 // FileValidationRule defines validation rules for file uploads
 type FileValidationRule struct {
 	MaxSizeBytes int64
@@ -121,4 +134,7 @@ const (
 	ErrInvalidDocumentType = DocumentError("invalid document type")
 	ErrFileTooLarge        = DocumentError("file size exceeds maximum allowed")
 	ErrInvalidMimeType     = DocumentError("file type not allowed for this document type")
+	ErrSupersededNotFound  = DocumentError("superseded document not found")
+	ErrSupersedeNotCurrent = DocumentError("only the current version of a document can be superseded")
+	ErrSupersedeTypeChange = DocumentError("a new version must have the same document type")
 )
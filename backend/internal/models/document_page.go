@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// DocumentPage holds the extracted text of one page of a claim document or
+// carrier estimate. Exactly one of DocumentID / CarrierEstimateID is set.
+type DocumentPage struct {
+	ID                string    `json:"id" db:"id"`
+	ClaimID           string    `json:"claim_id" db:"claim_id"`
+	DocumentID        *string   `json:"document_id" db:"document_id"`
+	CarrierEstimateID *string   `json:"carrier_estimate_id" db:"carrier_estimate_id"`
+	PageNumber        int       `json:"page_number" db:"page_number"`
+	Content           string    `json:"content" db:"content"`
+	CreatedAt         time.Time `json:"created_at" db:"created_at"`
+}
+
+// Text extraction status constants
+const (
+	TextExtractionPending     = "pending"
+	TextExtractionProcessing  = "processing"
+	TextExtractionCompleted   = "completed"
+	TextExtractionFailed      = "failed"
+	TextExtractionUnsupported = "unsupported" // e.g. HEIC photos the extractor can't read
+)
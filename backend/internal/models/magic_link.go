@@ -9,9 +9,83 @@ type MagicLink struct {
 	ContractorName  string     `json:"contractor_name" db:"contractor_name"`
 	ContractorEmail string     `json:"contractor_email" db:"contractor_email"`
 	ContractorPhone *string    `json:"contractor_phone" db:"contractor_phone"`
+	Scope           string     `json:"scope" db:"scope"` // photo, scope_sheet, proof_of_repair, full
 	ExpiresAt       time.Time  `json:"expires_at" db:"expires_at"`
 	AccessedAt      *time.Time `json:"accessed_at" db:"accessed_at"`
 	AccessCount     int        `json:"access_count" db:"access_count"`
-	Status          string     `json:"status" db:"status"` // active, expired, completed
+	Status          string     `json:"status" db:"status"` // active, expired, completed, revoked
+	RevokedAt       *time.Time `json:"revoked_at" db:"revoked_at"`
+	ReissuedFromID  *string    `json:"reissued_from_id" db:"reissued_from_id"`
+	DeliveryChannel string     `json:"delivery_channel" db:"delivery_channel"` // email, sms, both
 	CreatedAt       time.Time  `json:"created_at" db:"created_at"`
 }
+
+// MagicLinkScope constants
+const (
+	MagicLinkScopePhoto         = "photo"
+	MagicLinkScopeScopeSheet    = "scope_sheet"
+	MagicLinkScopeProofOfRepair = "proof_of_repair"
+	MagicLinkScopeFull          = "full"
+)
+
+// MagicLinkChannel constants: how a link is delivered to the contractor
+const (
+	MagicLinkChannelEmail = "email"
+	MagicLinkChannelSMS   = "sms"
+	MagicLinkChannelBoth  = "both"
+)
+
+// MagicLinkPermissions describes what a contractor holding a link may do
+type MagicLinkPermissions struct {
+	DocumentTypes []string `json:"document_types"`
+	ScopeSheet    bool     `json:"scope_sheet"`
+}
+
+// MagicLinkScopePermissions maps each link scope to the actions it grants.
+// A full link keeps the original behaviour: any document type plus the scope sheet.
+var MagicLinkScopePermissions = map[string]MagicLinkPermissions{
+	MagicLinkScopePhoto: {
+		DocumentTypes: []string{DocumentTypeContractorPhoto},
+	},
+	MagicLinkScopeScopeSheet: {
+		DocumentTypes: []string{},
+		ScopeSheet:    true,
+	},
+	MagicLinkScopeProofOfRepair: {
+		DocumentTypes: []string{DocumentTypeProofOfRepair},
+	},
+	MagicLinkScopeFull: {
+		DocumentTypes: ValidDocumentTypes,
+		ScopeSheet:    true,
+	},
+}
+
+// IsValidMagicLinkScope checks if a magic link scope is valid
+func IsValidMagicLinkScope(scope string) bool {
+	_, ok := MagicLinkScopePermissions[scope]
+	return ok
+}
+
+// MagicLinkScopeAllowsDocumentType checks if a link scope may upload or view a document type
+func MagicLinkScopeAllowsDocumentType(scope string, docType string) bool {
+	for _, allowed := range MagicLinkScopePermissions[scope].DocumentTypes {
+		if allowed == docType {
+			return true
+		}
+	}
+	return false
+}
+
+// MagicLinkScopeAllowsScopeSheet checks if a link scope may view or submit the scope sheet
+func MagicLinkScopeAllowsScopeSheet(scope string) bool {
+	return MagicLinkScopePermissions[scope].ScopeSheet
+}
+
+// MagicLinkReminder kinds. Each is sent at most once per link; the first three
+// go to the contractor, expired_unused goes to the PM who created the link.
+const (
+	MagicLinkReminderNeverOpened       = "never_opened"
+	MagicLinkReminderDraftNotSubmitted = "draft_not_submitted"
+	MagicLinkReminderExpiringSoon      = "expiring_soon"
+	MagicLinkReminderExpiredUnused     = "expired_unused"
+)
@@ -46,6 +46,8 @@ type PaymentSummary struct {
 	RCVDelta         float64 `json:"rcv_delta"`
 	FullyReconciled  bool    `json:"fully_reconciled"`
 	HasDisputes      bool    `json:"has_disputes"`
+	Deductible       float64 `json:"deductible"`
+	DeductibleBasis  string  `json:"deductible_basis"`
 }
 
 // ClaimClosureStatus represents whether a claim can be closed
@@ -17,3 +17,105 @@ type Policy struct {
 	CreatedAt       time.Time  `json:"created_at" db:"created_at"`
 	UpdatedAt       time.Time  `json:"updated_at" db:"updated_at"`
 }
+
+// PolicyTerms are coverage terms extracted from a policy PDF. Extracted terms
+// wait for a PM to review and confirm them; unread terms are nil.
+type PolicyTerms struct {
+	ID                         string     `json:"id"`
+	PolicyID                   string     `json:"policy_id"`
+	ReviewStatus               string     `json:"review_status"`
+	SourcePdfUrl               *string    `json:"source_pdf_url"`
+	CoverageALimit             *float64   `json:"coverage_a_limit"`
+	CoverageBLimit             *float64   `json:"coverage_b_limit"`
+	CoverageCLimit             *float64   `json:"coverage_c_limit"`
+	CoverageDLimit             *float64   `json:"coverage_d_limit"`
+	DeductibleType             *string    `json:"deductible_type"`
+	DeductibleAmount           *float64   `json:"deductible_amount"`
+	DeductiblePercentage       *float64   `json:"deductible_percentage"`
+	RoofSettlement             *string    `json:"roof_settlement"`
+	MatchingEndorsement        *bool      `json:"matching_endorsement"`
+	OrdinanceAndLawEndorsement *bool      `json:"ordinance_and_law_endorsement"`
+	OrdinanceAndLawLimit       *float64   `json:"ordinance_and_law_limit"`
+	Exclusions                 []string   `json:"exclusions"`
+	ExtractionNotes            []string   `json:"extraction_notes"`
+	PromptVersion              *string    `json:"prompt_version"`
+	ReviewedByUserID           *string    `json:"reviewed_by_user_id"`
+	ReviewedAt                 *time.Time `json:"reviewed_at"`
+	CreatedAt                  time.Time  `json:"created_at"`
+	UpdatedAt                  time.Time  `json:"updated_at"`
+}
+
+// Policy terms review status constants
+const (
+	PolicyTermsPendingReview = "pending_review"
+	PolicyTermsConfirmed     = "confirmed"
+	PolicyTermsSuperseded    = "superseded"
+)
+
+// Deductible type constants
+const (
+	DeductibleFlat       = "flat"
+	DeductiblePercentage = "percentage"
+	DeductibleWindHail   = "wind_hail"
+)
+
+// Roof settlement constants
+const (
+	RoofSettlementACV = "acv"
+	RoofSettlementRCV = "rcv"
+)
+
+// DeductibleRule is the deductible for one peril of a policy: a flat amount or
+// a percentage of the Coverage A limit, optionally limited to a date range
+type DeductibleRule struct {
+	ID              string     `json:"id"`
+	PolicyID        string     `json:"policy_id"`
+	Peril           string     `json:"peril"`
+	Basis           string     `json:"basis"`
+	Amount          *float64   `json:"amount"`
+	Percentage      *float64   `json:"percentage"`
+	MinimumAmount   *float64   `json:"minimum_amount"`
+	StartsOn        *time.Time `json:"starts_on"`
+	EndsOn          *time.Time `json:"ends_on"`
+	Source          string     `json:"source"`
+	CreatedByUserID *string    `json:"created_by_user_id"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
+
+// Deductible rule perils. Besides these a rule may name a single claim loss
+// type (fire, water, wind, hail or other).
+const (
+	PerilAllPerils  = "all_perils"
+	PerilWindHail   = "wind_hail"
+	PerilNamedStorm = "named_storm"
+)
+
+// Deductible rule sources
+const (
+	DeductibleSourceManual      = "manual"
+	DeductibleSourcePolicyTerms = "policy_terms"
+)
+
+// PolicyQuestion is one turn of a claim's policy Q&A: a PM's question and the
+// answer grounded in the policy PDF. Answered is false when no provision of
+// the policy could be quoted in support of an answer.
+type PolicyQuestion struct {
+	ID             string           `json:"id"`
+	ClaimID        string           `json:"claim_id"`
+	PolicyID       string           `json:"policy_id"`
+	UserID         *string          `json:"user_id"`
+	Question       string           `json:"question"`
+	Answer         string           `json:"answer"`
+	Citations      []PolicyCitation `json:"citations"`
+	Answered       bool             `json:"answered"`
+	NoteActivityID *string          `json:"note_activity_id"`
+	PromptVersion  *string          `json:"prompt_version"`
+	CreatedAt      time.Time        `json:"created_at"`
+}
+
+// PolicyCitation is a verbatim quote from a page of the policy PDF
+type PolicyCitation struct {
+	Page  int    `json:"page"`
+	Quote string `json:"quote"`
+}
@@ -0,0 +1,47 @@
+package models
+
+import "time"
+
+// PriceList is one imported CSV of unit prices. Its rows carry their own
+// region and effective date.
+type PriceList struct {
+	ID               string    `json:"id" db:"id"`
+	Name             string    `json:"name" db:"name"`
+	Source           *string   `json:"source" db:"source"`
+	RowCount         int       `json:"row_count" db:"row_count"`
+	ImportedByUserID *string   `json:"imported_by_user_id" db:"imported_by_user_id"`
+	ImportedAt       time.Time `json:"imported_at" db:"imported_at"`
+}
+
+// OrgUnitPrice is an organization's own unit cost for a code, taking
+// precedence over imported price lists. An empty Region applies everywhere.
+type OrgUnitPrice struct {
+	ID              string    `json:"id" db:"id"`
+	OrganizationID  string    `json:"organization_id" db:"organization_id"`
+	Code            string    `json:"code" db:"code"`
+	Description     string    `json:"description" db:"description"`
+	Unit            string    `json:"unit" db:"unit"`
+	UnitCost        float64   `json:"unit_cost" db:"unit_cost"`
+	Region          string    `json:"region" db:"region"`
+	EffectiveDate   time.Time `json:"effective_date" db:"effective_date"`
+	Notes           *string   `json:"notes" db:"notes"`
+	CreatedByUserID *string   `json:"created_by_user_id" db:"created_by_user_id"`
+	CreatedAt       time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// RegionalCostModifier scales national unit prices for a ZIP, a county or a
+// whole state. County and ZIP are empty when the modifier is broader.
+type RegionalCostModifier struct {
+	ID            string    `json:"id" db:"id"`
+	State         string    `json:"state" db:"state"`
+	County        string    `json:"county" db:"county"`
+	ZIP           string    `json:"zip" db:"zip"`
+	Region        string    `json:"region" db:"region"`
+	Factor        float64   `json:"factor" db:"factor"`
+	TaxRate       float64   `json:"tax_rate" db:"tax_rate"`
+	EffectiveDate time.Time `json:"effective_date" db:"effective_date"`
+	Source        *string   `json:"source" db:"source"`
+	CreatedAt     time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at" db:"updated_at"`
+}
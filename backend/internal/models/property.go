@@ -7,6 +7,12 @@ type Property struct {
 	OrganizationID  string     `json:"organization_id" db:"organization_id"`
 	Nickname        string     `json:"nickname" db:"nickname"`
 	LegalAddress    string     `json:"legal_address" db:"legal_address"`
+	// Components parsed from LegalAddress; County is entered separately
+	AddressStreet   *string    `json:"address_street" db:"address_street"`
+	AddressCity     *string    `json:"address_city" db:"address_city"`
+	AddressState    *string    `json:"address_state" db:"address_state"`
+	AddressZIP      *string    `json:"address_zip" db:"address_zip"`
+	AddressCounty   *string    `json:"address_county" db:"address_county"`
 	Lat             *float64   `json:"lat" db:"lat"`
 	Lng             *float64   `json:"lng" db:"lng"`
 	OwnerEntityName string     `json:"owner_entity_name" db:"owner_entity_name"`
@@ -15,4 +15,5 @@ type RCVDemandLetter struct {
 	UpdatedAt         time.Time  `json:"updated_at" db:"updated_at"`
 	SentAt            *time.Time `json:"sent_at" db:"sent_at"`
 	SentToEmail       *string    `json:"sent_to_email" db:"sent_to_email"`
+	PromptVersion     *string    `json:"prompt_version" db:"prompt_version"`
 }
@@ -21,13 +21,44 @@ type ScopeSheet struct {
 	Areas            []ScopeArea `json:"areas"`
 	TriageSelections []string    `json:"triage_selections"`
 	GeneralNotes     *string     `json:"general_notes"`
+	CatalogVersion   *string     `json:"catalog_version"`
 
 	// Draft fields
 	IsDraft      bool       `json:"is_draft"`
 	DraftStep    *int       `json:"draft_step,omitempty"`
 	DraftSavedAt *time.Time `json:"draft_saved_at,omitempty"`
 
+	// Revision and review fields; revision and review status are nil on drafts
+	Revision         *int       `json:"revision"`
+	ReviewStatus     *string    `json:"review_status"`
+	ReviewedAt       *time.Time `json:"reviewed_at,omitempty"`
+	ReviewedByUserID *string    `json:"reviewed_by_user_id,omitempty"`
+	ReviewNotes      *string    `json:"review_notes,omitempty"`
+	MagicLinkID      *string    `json:"magic_link_id,omitempty"`
+	ReopenedFromID   *string    `json:"reopened_from_id,omitempty"`
+
+	// Comments the PM left on this revision; on a draft, those left on the revision it reopens
+	Comments []ScopeSheetComment `json:"comments,omitempty"`
+
 	SubmittedAt *time.Time `json:"submitted_at"`
 	CreatedAt   time.Time  `json:"created_at"`
 	UpdatedAt   time.Time  `json:"updated_at"`
 }
+
+// ScopeSheetComment is a PM review comment, optionally pinned to one area
+type ScopeSheetComment struct {
+	ID              string    `json:"id"`
+	ScopeSheetID    string    `json:"scope_sheet_id"`
+	AreaID          *string   `json:"area_id"`
+	Comment         string    `json:"comment"`
+	CreatedByUserID string    `json:"created_by_user_id"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+// Scope sheet review status constants
+const (
+	ScopeReviewPendingReview    = "pending_review"
+	ScopeReviewApproved         = "approved"
+	ScopeReviewChangesRequested = "changes_requested"
+	ScopeReviewSuperseded       = "superseded"
+)
@@ -0,0 +1,64 @@
+package models
+
+import "time"
+
+// Supplement is a line-item supplement request sent to the carrier's adjuster.
+// Supplements are numbered per claim in the order they were generated.
+type Supplement struct {
+	ID                string           `json:"id"`
+	ClaimID           string           `json:"claim_id"`
+	SupplementNumber  int              `json:"supplement_number"`
+	AuditReportID     *string          `json:"audit_report_id"`
+	CarrierEstimateID *string          `json:"carrier_estimate_id"`
+	Status            string           `json:"status"`
+	Items             []SupplementItem `json:"items"`
+	TotalRequested    float64          `json:"total_requested"`
+	ApprovedAmount    *float64         `json:"approved_amount"`
+	CarrierResponse   *string          `json:"carrier_response"`
+	SentAt            *time.Time       `json:"sent_at"`
+	RespondedAt       *time.Time       `json:"responded_at"`
+	CreatedByUserID   *string          `json:"created_by_user_id"`
+	CreatedAt         time.Time        `json:"created_at"`
+	UpdatedAt         time.Time        `json:"updated_at"`
+}
+
+// SupplementItem is one added or adjusted line of a supplement. Quantity and
+// UnitCost are what the industry estimate requires; Amount is what the
+// supplement requests on top of the carrier's allowance.
+type SupplementItem struct {
+	Kind            string             `json:"kind"`
+	Code            string             `json:"code"`
+	Description     string             `json:"description"`
+	Category        string             `json:"category"`
+	Unit            string             `json:"unit"`
+	Quantity        float64            `json:"quantity"`
+	UnitCost        float64            `json:"unit_cost"`
+	CarrierQuantity float64            `json:"carrier_quantity"`
+	CarrierUnitCost float64            `json:"carrier_unit_cost"`
+	Amount          float64            `json:"amount"`
+	CodeRequirement string             `json:"code_requirement,omitempty"`
+	Justification   string             `json:"justification"`
+	Photos          []SupplementPhotos `json:"photos"`
+}
+
+// SupplementPhotos points at the scope sheet photos of one area an item is in
+type SupplementPhotos struct {
+	AreaID   string   `json:"area_id"`
+	Area     string   `json:"area"`
+	PhotoIDs []string `json:"photo_ids"`
+}
+
+// Supplement item kinds
+const (
+	SupplementItemAdded    = "added"
+	SupplementItemAdjusted = "adjusted"
+)
+
+// Supplement status constants
+const (
+	SupplementDraft             = "draft"
+	SupplementSent              = "sent"
+	SupplementApproved          = "approved"
+	SupplementPartiallyApproved = "partially_approved"
+	SupplementDenied            = "denied"
+)
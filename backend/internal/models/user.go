@@ -18,3 +18,9 @@ type Organization struct {
 	CreatedAt time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
 }
+
+// User roles
+const (
+	UserRoleAdmin  = "admin"
+	UserRoleMember = "member"
+)
@@ -0,0 +1,65 @@
+// Package pdfpages reads the pages of PDF documents and copies page ranges
+// out of them, so that a long document can be sent to the LLM a range at a
+// time instead of whole.
+package pdfpages
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+)
+
+func init() {
+	// pdfcpu otherwise writes a config.yml to the user's config directory and
+	// exits the process if it can't, as on Lambda's read-only filesystem
+	model.ConfigPath = "disable"
+}
+
+// Document is a parsed PDF
+type Document struct {
+	ctx *model.Context
+}
+
+// Open parses a PDF document
+func Open(pdf []byte) (*Document, error) {
+	conf := model.NewDefaultConfiguration()
+	conf.ValidationMode = model.ValidationRelaxed
+
+	ctx, err := api.ReadValidateAndOptimize(bytes.NewReader(pdf), conf)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read PDF: %w", err)
+	}
+	return &Document{ctx: ctx}, nil
+}
+
+// PageCount returns the number of pages in the document
+func (d *Document) PageCount() int {
+	return d.ctx.PageCount
+}
+
+// Pages returns a new PDF holding pages first to last of the document,
+// numbered from 1
+func (d *Document) Pages(first, last int) ([]byte, error) {
+	if first < 1 || last < first || last > d.ctx.PageCount {
+		return nil, fmt.Errorf("page range %d-%d is outside the document's %d pages", first, last, d.ctx.PageCount)
+	}
+
+	pageNrs := make([]int, 0, last-first+1)
+	for page := first; page <= last; page++ {
+		pageNrs = append(pageNrs, page)
+	}
+
+	extracted, err := pdfcpu.ExtractPages(d.ctx, pageNrs, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract pages %d-%d: %w", first, last, err)
+	}
+
+	var buf bytes.Buffer
+	if err := api.WriteContext(extracted, &buf); err != nil {
+		return nil, fmt.Errorf("failed to write pages %d-%d: %w", first, last, err)
+	}
+	return buf.Bytes(), nil
+}
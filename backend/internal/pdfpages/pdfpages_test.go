@@ -0,0 +1,59 @@
+package pdfpages
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/go-pdf/fpdf"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testPDF builds a PDF whose pages each print their page number
+func testPDF(t *testing.T, pages int) []byte {
+	pdf := fpdf.New("P", "mm", "Letter", "")
+	pdf.SetFont("Helvetica", "", 12)
+	for page := 1; page <= pages; page++ {
+		pdf.AddPage()
+		pdf.Cell(40, 10, fmt.Sprintf("Page %d", page))
+	}
+	var buf bytes.Buffer
+	require.NoError(t, pdf.Output(&buf))
+	return buf.Bytes()
+}
+
+func TestPages(t *testing.T) {
+	doc, err := Open(testPDF(t, 20))
+	require.NoError(t, err)
+	assert.Equal(t, 20, doc.PageCount())
+
+	chunk, err := doc.Pages(9, 16)
+	require.NoError(t, err)
+
+	extracted, err := Open(chunk)
+	require.NoError(t, err)
+	assert.Equal(t, 8, extracted.PageCount())
+
+	// Ranges can be taken repeatedly from one parsed document
+	last, err := doc.Pages(17, 20)
+	require.NoError(t, err)
+	extracted, err = Open(last)
+	require.NoError(t, err)
+	assert.Equal(t, 4, extracted.PageCount())
+}
+
+func TestPages_OutOfRange(t *testing.T) {
+	doc, err := Open(testPDF(t, 3))
+	require.NoError(t, err)
+
+	_, err = doc.Pages(2, 4)
+	assert.ErrorContains(t, err, "outside the document's 3 pages")
+	_, err = doc.Pages(0, 1)
+	assert.Error(t, err)
+}
+
+func TestOpen_NotAPDF(t *testing.T) {
+	_, err := Open([]byte("not a pdf"))
+	assert.ErrorContains(t, err, "failed to read PDF")
+}
@@ -0,0 +1,67 @@
+package pricing
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Adjustment is the regional cost modifier for a property's location. Factor
+// scales national prices to the local market; Region selects which imported
+// price lists and org overrides apply.
+type Adjustment struct {
+	Region string  `json:"region"`
+	Factor float64 `json:"factor"`
+	// TaxRate is the sales tax applied to the estimate subtotal
+	TaxRate float64 `json:"tax_rate"`
+	// Basis says what the location was matched on, e.g. "ZIP 77002"
+	Basis      string  `json:"basis"`
+	ModifierID *string `json:"modifier_id,omitempty"`
+}
+
+// NoAdjustment prices at national rates, for locations without a modifier
+func NoAdjustment(basis string) Adjustment {
+	return Adjustment{Region: DefaultRegion, Factor: 1, Basis: basis}
+}
+
+// Location is the part of a property address modifiers are keyed on
+type Location struct {
+	State  string
+	County string
+	ZIP    string
+}
+
+// String describes the location for prompts and logs, e.g. "Harris County, TX 77002"
+func (l Location) String() string {
+	var parts []string
+	if l.County != "" {
+		parts = append(parts, titleCase(l.County)+" County,")
+	}
+	if l.State != "" {
+		parts = append(parts, l.State)
+	}
+	if l.ZIP != "" {
+		parts = append(parts, l.ZIP)
+	}
+	return strings.TrimSuffix(strings.Join(parts, " "), ",")
+}
+
+// Applies reports whether the adjustment changes a price. Prices from the
+// location's own regional list or the organization's overrides are already
+// local, so only national and baseline prices are scaled.
+func (a Adjustment) Applies(price Price) bool {
+	return a.Factor > 0 && a.Factor != 1 &&
+		price.Source.Type != SourceOrgOverride && price.Source.Region == DefaultRegion
+}
+
+// Describe summarizes the adjustment in one line, e.g. "x1.08 (ZIP 77002, region tx-houston)"
+func (a Adjustment) Describe() string {
+	return fmt.Sprintf("x%g (%s, region %s)", a.Factor, a.Basis, a.Region)
+}
+
+func titleCase(s string) string {
+	words := strings.Fields(s)
+	for i, w := range words {
+		words[i] = strings.ToUpper(w[:1]) + w[1:]
+	}
+	return strings.Join(words, " ")
+}
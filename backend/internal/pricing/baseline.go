@@ -0,0 +1,74 @@
+package pricing
+
+import (
+	_ "embed"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Baseline price list shipped with the binary. It is the last fallback when no
+// imported price list or org override covers a code, so estimates can always
+// be priced, and it defines the codes the estimate prompt offers the LLM.
+const (
+	BaselineName = "ClaimCoach national baseline"
+	// BaselineVersion is the baseline's effective date. Update it with baseline.csv.
+	BaselineVersion = "2026-01-01"
+)
+
+//go:embed baseline.csv
+var baselineCSV string
+
+var baseline = func() map[string]Price {
+	effective, err := time.Parse(DateLayout, BaselineVersion)
+	if err != nil {
+		panic(fmt.Sprintf("pricing: invalid baseline version: %v", err))
+	}
+	rows, err := ParseCSV(strings.NewReader(baselineCSV), Defaults{Region: DefaultRegion, EffectiveDate: effective})
+	if err != nil {
+		panic(fmt.Sprintf("pricing: invalid baseline price list: %v", err))
+	}
+	prices := make(map[string]Price, len(rows))
+	for _, row := range rows {
+		prices[row.Code] = PriceFromRow(row, Source{
+			Type:          SourceBaseline,
+			Name:          BaselineName,
+			Region:        row.Region,
+			EffectiveDate: FormatDate(row.EffectiveDate),
+		})
+	}
+	return prices
+}()
+
+// Baseline returns a copy of the baseline prices keyed by code
+func Baseline() map[string]Price {
+	prices := make(map[string]Price, len(baseline))
+	for code, price := range baseline {
+		prices[code] = price
+	}
+	return prices
+}
+
+// PriceFromRow builds a Price from a parsed row and where it came from
+func PriceFromRow(row Row, source Source) Price {
+	return Price{
+		Code:         row.Code,
+		Description:  row.Description,
+		Unit:         row.Unit,
+		UnitCost:     row.UnitCost,
+		MaterialCost: row.MaterialCost,
+		LaborCost:    row.LaborCost,
+		Source:       source,
+	}
+}
+
+// Sorted returns prices ordered by code
+func Sorted(prices map[string]Price) []Price {
+	sorted := make([]Price, 0, len(prices))
+	for _, price := range prices {
+		sorted = append(sorted, price)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Code < sorted[j].Code })
+	return sorted
+}
@@ -0,0 +1,297 @@
+package pricing
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MaxImportRows caps the number of rows in one CSV import
+const MaxImportRows = 20000
+
+// Row is one parsed price list row
+type Row struct {
+	Line          int
+	Code          string
+	Description   string
+	Unit          string
+	UnitCost      float64
+	MaterialCost  *float64
+	LaborCost     *float64
+	Region        string
+	EffectiveDate time.Time
+}
+
+// Defaults fill in the region and effective date for rows that leave them
+// blank. With no default region, blank regions stay blank, which org
+// overrides read as "every region".
+type Defaults struct {
+	Region        string
+	EffectiveDate time.Time
+}
+
+// RowError describes one invalid value in a CSV file. Line is the 1-based line
+// number in the file, counting the header.
+type RowError struct {
+	Line    int    `json:"line"`
+	Column  string `json:"column,omitempty"`
+	Message string `json:"message"`
+}
+
+// ImportError is returned when a CSV file has invalid rows. Imports are all
+// or nothing, so no rows are stored when it is returned.
+type ImportError struct {
+	Rows []RowError
+}
+
+func (e *ImportError) Error() string {
+	if len(e.Rows) == 1 {
+		return fmt.Sprintf("invalid price list: line %d: %s", e.Rows[0].Line, e.Rows[0].Message)
+	}
+	return fmt.Sprintf("invalid price list: %d invalid rows", len(e.Rows))
+}
+
+// Column names. code, unit and either unit_cost or both material_cost and
+// labor_cost are required; the rest are optional.
+const (
+	ColumnCode          = "code"
+	ColumnDescription   = "description"
+	ColumnUnit          = "unit"
+	ColumnUnitCost      = "unit_cost"
+	ColumnMaterialCost  = "material_cost"
+	ColumnLaborCost     = "labor_cost"
+	ColumnRegion        = "region"
+	ColumnEffectiveDate = "effective_date"
+)
+
+var knownColumns = map[string]bool{
+	ColumnCode: true, ColumnDescription: true, ColumnUnit: true, ColumnUnitCost: true,
+	ColumnMaterialCost: true, ColumnLaborCost: true, ColumnRegion: true, ColumnEffectiveDate: true,
+}
+
+// maxRowErrors stops collecting errors once a file is clearly wrong
+const maxRowErrors = 100
+
+// ParseCSV reads a price list with a header row. Every row is checked and all
+// problems are reported together as an *ImportError; rows that leave region
+// or effective_date blank take them from defaults.
+func ParseCSV(r io.Reader, defaults Defaults) ([]Row, error) {
+	reader, columns, errs := readHeader(r, knownColumns)
+	if reader == nil {
+		return nil, &ImportError{Rows: errs}
+	}
+	for _, required := range []string{ColumnCode, ColumnUnit} {
+		if _, ok := columns[required]; !ok {
+			errs = append(errs, RowError{Line: 1, Column: required, Message: fmt.Sprintf("missing required column %q", required)})
+		}
+	}
+	_, hasUnitCost := columns[ColumnUnitCost]
+	_, hasMaterial := columns[ColumnMaterialCost]
+	_, hasLabor := columns[ColumnLaborCost]
+	if !hasUnitCost && !(hasMaterial && hasLabor) {
+		errs = append(errs, RowError{Line: 1, Column: ColumnUnitCost,
+			Message: "a unit_cost column, or both material_cost and labor_cost, is required"})
+	}
+	if len(errs) > 0 {
+		return nil, &ImportError{Rows: errs}
+	}
+
+	var rows []Row
+	seen := make(map[string]int)
+	tooMany := false
+	errs = eachRecord(reader, func(line int, record []string) []RowError {
+		if tooMany {
+			return nil
+		}
+		if len(rows) == MaxImportRows {
+			tooMany = true
+			return []RowError{{Line: line, Message: fmt.Sprintf("a price list may have at most %d rows", MaxImportRows)}}
+		}
+
+		p := rowParser{line: line, record: record, columns: columns}
+		row := p.parse(defaults)
+		if len(p.errs) == 0 {
+			key := row.Code + "|" + row.Region + "|" + FormatDate(row.EffectiveDate)
+			if first, dup := seen[key]; dup {
+				p.fail(ColumnCode, fmt.Sprintf("%s is already priced for %s on %s at line %d",
+					row.Code, row.Region, FormatDate(row.EffectiveDate), first))
+			} else {
+				seen[key] = line
+			}
+		}
+
+		rows = append(rows, row)
+		return p.errs
+	})
+
+	if len(errs) > 0 {
+		return nil, &ImportError{Rows: errs}
+	}
+	if len(rows) == 0 {
+		return nil, &ImportError{Rows: []RowError{{Line: 2, Message: "file has no price rows"}}}
+	}
+	return rows, nil
+}
+
+// readHeader reads the header row and maps known column names to their
+// positions. It returns a nil reader if the file has no header at all.
+func readHeader(r io.Reader, known map[string]bool) (*csv.Reader, map[string]int, []RowError) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, nil, []RowError{{Line: 1, Message: "file is empty"}}
+	}
+	if err != nil {
+		return nil, nil, []RowError{{Line: 1, Message: err.Error()}}
+	}
+
+	columns := make(map[string]int, len(header))
+	var errs []RowError
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		if !known[name] {
+			errs = append(errs, RowError{Line: 1, Column: name, Message: fmt.Sprintf("unknown column %q", name)})
+			continue
+		}
+		if _, dup := columns[name]; dup {
+			errs = append(errs, RowError{Line: 1, Column: name, Message: fmt.Sprintf("column %q appears more than once", name)})
+			continue
+		}
+		columns[name] = i
+	}
+	return reader, columns, errs
+}
+
+// eachRecord calls parse for every non-blank record after the header, with
+// its line number in the file, and collects the errors it returns. It stops
+// early on a malformed file or once too many errors have been found.
+func eachRecord(reader *csv.Reader, parse func(line int, record []string) []RowError) []RowError {
+	var errs []RowError
+	for len(errs) < maxRowErrors {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			line := 0
+			if errors.As(err, &parseErr) {
+				line = parseErr.Line
+			}
+			return append(errs, RowError{Line: line, Message: err.Error()})
+		}
+		if blankRecord(record) {
+			continue
+		}
+		line, _ := reader.FieldPos(0)
+		errs = append(errs, parse(line, record)...)
+	}
+	return errs
+}
+
+func blankRecord(record []string) bool {
+	for _, field := range record {
+		if strings.TrimSpace(field) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+type rowParser struct {
+	line    int
+	record  []string
+	columns map[string]int
+	errs    []RowError
+}
+
+func (p *rowParser) fail(column, message string) {
+	p.errs = append(p.errs, RowError{Line: p.line, Column: column, Message: message})
+}
+
+func (p *rowParser) field(column string) string {
+	i, ok := p.columns[column]
+	if !ok || i >= len(p.record) {
+		return ""
+	}
+	return strings.TrimSpace(p.record[i])
+}
+
+// cost parses a non-negative dollar amount, allowing a leading $ and
+// thousands separators. Blank values return nil.
+func (p *rowParser) cost(column string) *float64 {
+	raw := p.field(column)
+	if raw == "" {
+		return nil
+	}
+	value, err := strconv.ParseFloat(strings.ReplaceAll(strings.TrimPrefix(raw, "$"), ",", ""), 64)
+	if err != nil || value < 0 {
+		p.fail(column, fmt.Sprintf("%s must be a non-negative amount, got %q", column, raw))
+		return nil
+	}
+	return &value
+}
+
+// effectiveDate parses the effective_date column, falling back to the default
+func (p *rowParser) effectiveDate(defaults Defaults) time.Time {
+	raw := p.field(ColumnEffectiveDate)
+	if raw == "" {
+		if defaults.EffectiveDate.IsZero() {
+			p.fail(ColumnEffectiveDate, "effective_date is required")
+		}
+		return defaults.EffectiveDate
+	}
+	date, err := time.Parse(DateLayout, raw)
+	if err != nil {
+		p.fail(ColumnEffectiveDate, fmt.Sprintf("effective_date must be YYYY-MM-DD, got %q", raw))
+	}
+	return date
+}
+
+func (p *rowParser) parse(defaults Defaults) Row {
+	row := Row{
+		Line:        p.line,
+		Code:        NormalizeCode(p.field(ColumnCode)),
+		Description: p.field(ColumnDescription),
+		Unit:        NormalizeUnit(p.field(ColumnUnit)),
+	}
+
+	switch {
+	case row.Code == "":
+		p.fail(ColumnCode, "code is required")
+	case !ValidCode(row.Code):
+		p.fail(ColumnCode, fmt.Sprintf("invalid code %q: use letters, digits, '_', '-' or '.'", row.Code))
+	}
+	if !ValidUnit(row.Unit) {
+		p.fail(ColumnUnit, fmt.Sprintf("unsupported unit %q", row.Unit))
+	}
+
+	row.MaterialCost = p.cost(ColumnMaterialCost)
+	row.LaborCost = p.cost(ColumnLaborCost)
+	if unitCost := p.cost(ColumnUnitCost); unitCost != nil {
+		row.UnitCost = *unitCost
+	} else if row.MaterialCost != nil && row.LaborCost != nil {
+		row.UnitCost = round2(*row.MaterialCost + *row.LaborCost)
+	} else if p.field(ColumnUnitCost) == "" {
+		p.fail(ColumnUnitCost, "unit_cost is required unless material_cost and labor_cost are both given")
+	}
+
+	row.Region = NormalizeRegion(p.field(ColumnRegion))
+	if row.Region == "" {
+		row.Region = NormalizeRegion(defaults.Region)
+	}
+	if row.Region != "" && !ValidRegion(row.Region) {
+		p.fail(ColumnRegion, fmt.Sprintf("invalid region %q", row.Region))
+	}
+
+	row.EffectiveDate = p.effectiveDate(defaults)
+
+	return row
+}
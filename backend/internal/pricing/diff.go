@@ -0,0 +1,126 @@
+package pricing
+
+import (
+	"math"
+	"sort"
+)
+
+// Line change types
+const (
+	LineAdded   = "added"
+	LineRemoved = "removed"
+	LineChanged = "changed"
+)
+
+// AmountChange is one amount in two estimates or audit runs
+type AmountChange struct {
+	From  float64 `json:"from"`
+	To    float64 `json:"to"`
+	Delta float64 `json:"delta"`
+}
+
+// NewAmountChange compares two amounts
+func NewAmountChange(from, to float64) AmountChange {
+	return AmountChange{From: from, To: to, Delta: round2(to - from)}
+}
+
+// LineChange is a line item that was added, removed or repriced between two
+// estimates. Lines are matched on code and scope area.
+type LineChange struct {
+	Code         string       `json:"code"`
+	AreaID       string       `json:"area_id,omitempty"`
+	Description  string       `json:"description"`
+	Category     string       `json:"category"`
+	Change       string       `json:"change"`
+	Unit         string       `json:"unit"`
+	FromQuantity float64      `json:"from_quantity"`
+	ToQuantity   float64      `json:"to_quantity"`
+	FromUnitCost float64      `json:"from_unit_cost"`
+	ToUnitCost   float64      `json:"to_unit_cost"`
+	Total        AmountChange `json:"total"`
+}
+
+// EstimateDiff is the difference between two estimates, with line changes
+// ordered by the size of their effect on the total
+type EstimateDiff struct {
+	Subtotal       AmountChange `json:"subtotal"`
+	OverheadProfit AmountChange `json:"overhead_profit"`
+	Tax            AmountChange `json:"tax"`
+	Total          AmountChange `json:"total"`
+	Lines          []LineChange `json:"lines"`
+	UnchangedLines int          `json:"unchanged_lines"`
+}
+
+// DiffEstimates compares two estimates line by line. Repeated lines for the
+// same code and area are combined before comparing.
+func DiffEstimates(from, to *IndustryEstimate) EstimateDiff {
+	diff := EstimateDiff{
+		Subtotal:       NewAmountChange(from.Subtotal, to.Subtotal),
+		OverheadProfit: NewAmountChange(from.OverheadProfit, to.OverheadProfit),
+		Tax:            NewAmountChange(from.Tax, to.Tax),
+		Total:          NewAmountChange(from.Total, to.Total),
+		Lines:          []LineChange{},
+	}
+
+	before, order := combineLines(from.LineItems, nil)
+	after, order := combineLines(to.LineItems, order)
+
+	for _, key := range order {
+		old, hadOld := before[key]
+		cur, hasNew := after[key]
+		change := LineChange{
+			Code:        cur.Code,
+			AreaID:      cur.AreaID,
+			Description: cur.Description,
+			Category:    cur.Category,
+			Unit:        cur.Unit,
+		}
+		switch {
+		case !hasNew:
+			change.Code, change.AreaID, change.Description, change.Category, change.Unit =
+				old.Code, old.AreaID, old.Description, old.Category, old.Unit
+			change.Change = LineRemoved
+		case !hadOld:
+			change.Change = LineAdded
+		case old.Quantity == cur.Quantity && old.UnitCost == cur.UnitCost && old.Unit == cur.Unit:
+			diff.UnchangedLines++
+			continue
+		default:
+			change.Change = LineChanged
+		}
+		change.FromQuantity, change.FromUnitCost = old.Quantity, old.UnitCost
+		change.ToQuantity, change.ToUnitCost = cur.Quantity, cur.UnitCost
+		change.Total = NewAmountChange(old.Total, cur.Total)
+		diff.Lines = append(diff.Lines, change)
+	}
+
+	sort.SliceStable(diff.Lines, func(i, j int) bool {
+		return math.Abs(diff.Lines[i].Total.Delta) > math.Abs(diff.Lines[j].Total.Delta)
+	})
+	return diff
+}
+
+// combineLines keys line items by code and area, summing repeats, and appends
+// keys not yet in order
+func combineLines(lines []LineItem, order []string) (map[string]LineItem, []string) {
+	seen := make(map[string]bool, len(order))
+	for _, key := range order {
+		seen[key] = true
+	}
+	combined := make(map[string]LineItem, len(lines))
+	for _, line := range lines {
+		key := line.Code + "|" + line.AreaID
+		if existing, ok := combined[key]; ok {
+			existing.Quantity = round2(existing.Quantity + line.Quantity)
+			existing.Total = round2(existing.Total + line.Total)
+			combined[key] = existing
+			continue
+		}
+		combined[key] = line
+		if !seen[key] {
+			seen[key] = true
+			order = append(order, key)
+		}
+	}
+	return combined, order
+}
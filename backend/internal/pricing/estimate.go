@@ -0,0 +1,208 @@
+package pricing
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/claimcoach/backend/internal/takeoff"
+)
+
+// OverheadProfitRate is the overhead and profit applied to an estimate subtotal
+const OverheadProfitRate = 0.20
+
+// Quantity sources for a line item
+const (
+	QuantityFromTakeoff = "takeoff"
+	QuantityEstimated   = "estimated"
+)
+
+// MappedItem is one scope item the LLM mapped to a price code. When
+// TakeoffCode names a computed quantity in the item's area, that quantity is
+// used and the LLM's own quantity is ignored.
+type MappedItem struct {
+	Code        string  `json:"code"`
+	Description string  `json:"description"`
+	Quantity    float64 `json:"quantity"`
+	Unit        string  `json:"unit"`
+	Category    string  `json:"category"`
+	AreaID      string  `json:"area_id"`
+	TakeoffCode string  `json:"takeoff_code"`
+}
+
+// LineItem is one priced estimate line
+type LineItem struct {
+	Code           string  `json:"code"`
+	Description    string  `json:"description"`
+	Quantity       float64 `json:"quantity"`
+	Unit           string  `json:"unit"`
+	UnitCost       float64 `json:"unit_cost"`
+	Total          float64 `json:"total"`
+	Category       string  `json:"category"`
+	AreaID         string  `json:"area_id,omitempty"`
+	QuantitySource string  `json:"quantity_source"`
+	PriceSource    Source  `json:"price_source"`
+	// RegionalFactor is set when the unit cost was scaled from a national price
+	RegionalFactor float64 `json:"regional_factor,omitempty"`
+	// Note explains why a line could not be priced
+	Note string `json:"note,omitempty"`
+}
+
+// UncategorizedSection holds line items the LLM gave no category
+const UncategorizedSection = "Other"
+
+// Section is one category of an estimate's line items, in the order the
+// category first appears
+type Section struct {
+	Category  string  `json:"category"`
+	LineCount int     `json:"line_count"`
+	Subtotal  float64 `json:"subtotal"`
+}
+
+// IndustryEstimate is a priced industry estimate, the typed form of
+// audit_reports.generated_estimate. Line items sum to their sections and to
+// Subtotal; O&P and tax are each a rate of Subtotal, and Total adds all three.
+type IndustryEstimate struct {
+	LineItems          []LineItem `json:"line_items"`
+	Sections           []Section  `json:"sections"`
+	Subtotal           float64    `json:"subtotal"`
+	OverheadProfitRate float64    `json:"overhead_profit_rate"`
+	OverheadProfit     float64    `json:"overhead_profit"`
+	TaxRate            float64    `json:"tax_rate"`
+	Tax                float64    `json:"tax"`
+	Total              float64    `json:"total"`
+	Region             string     `json:"region"`
+	RegionalAdjustment Adjustment `json:"regional_adjustment"`
+	PricedAsOf         string     `json:"priced_as_of"`
+	UnpricedCount      int        `json:"unpriced_count"`
+}
+
+// PriceEstimate prices mapped items from prices. Quantities come from the
+// takeoff where the item references one; lines whose code has no price, or
+// whose unit can't be converted to the price's unit, are kept at zero and
+// marked unpriced so a reviewer can see the gap. Items with no quantity are
+// dropped. National prices are scaled by the regional adjustment, and its
+// tax rate applies to the subtotal.
+func PriceEstimate(items []MappedItem, quantities takeoff.Takeoff, prices map[string]Price, adjustment Adjustment, pricedAsOf string) IndustryEstimate {
+	computed := computedQuantities(quantities)
+
+	estimate := IndustryEstimate{
+		LineItems:          []LineItem{},
+		OverheadProfitRate: OverheadProfitRate,
+		TaxRate:            adjustment.TaxRate,
+		Region:             adjustment.Region,
+		RegionalAdjustment: adjustment,
+		PricedAsOf:         pricedAsOf,
+	}
+
+	for _, item := range items {
+		line := LineItem{
+			Code:           NormalizeCode(item.Code),
+			Description:    item.Description,
+			Quantity:       item.Quantity,
+			Unit:           NormalizeUnit(item.Unit),
+			Category:       strings.TrimSpace(item.Category),
+			AreaID:         item.AreaID,
+			QuantitySource: QuantityEstimated,
+		}
+		if line.Category == "" {
+			line.Category = UncategorizedSection
+		}
+		if q, ok := computed[item.AreaID+"|"+item.TakeoffCode]; ok && item.TakeoffCode != "" {
+			line.Quantity = q.Quantity
+			line.Unit = q.Unit
+			line.QuantitySource = QuantityFromTakeoff
+		}
+		if line.Quantity <= 0 || math.IsNaN(line.Quantity) {
+			continue
+		}
+
+		price, ok := prices[line.Code]
+		switch {
+		case !ok:
+			line.PriceSource = Source{Type: SourceUnpriced}
+			line.Note = fmt.Sprintf("no price for code %q", line.Code)
+		default:
+			quantity, converted := ConvertQuantity(line.Quantity, line.Unit, price.Unit)
+			if !converted {
+				line.PriceSource = Source{Type: SourceUnpriced}
+				line.Note = fmt.Sprintf("%s is priced per %s but the quantity is in %s", line.Code, price.Unit, line.Unit)
+				break
+			}
+			line.Quantity = quantity
+			line.Unit = price.Unit
+			line.UnitCost = price.UnitCost
+			if adjustment.Applies(price) {
+				line.UnitCost = round2(price.UnitCost * adjustment.Factor)
+				line.RegionalFactor = adjustment.Factor
+			}
+			line.Total = round2(quantity * line.UnitCost)
+			line.PriceSource = price.Source
+			if line.Description == "" {
+				line.Description = price.Description
+			}
+		}
+
+		if line.PriceSource.Type == SourceUnpriced {
+			estimate.UnpricedCount++
+		}
+		estimate.LineItems = append(estimate.LineItems, line)
+	}
+
+	estimate.Sections, estimate.Subtotal = summarize(estimate.LineItems)
+	estimate.OverheadProfit = round2(estimate.Subtotal * estimate.OverheadProfitRate)
+	estimate.Tax = round2(estimate.Subtotal * estimate.TaxRate)
+	estimate.Total = round2(estimate.Subtotal + estimate.OverheadProfit + estimate.Tax)
+	return estimate
+}
+
+// summarize groups line items into sections and returns the subtotal
+func summarize(lines []LineItem) ([]Section, float64) {
+	sections := []Section{}
+	index := make(map[string]int)
+	subtotal := 0.0
+	for _, line := range lines {
+		i, ok := index[line.Category]
+		if !ok {
+			i = len(sections)
+			index[line.Category] = i
+			sections = append(sections, Section{Category: line.Category})
+		}
+		sections[i].LineCount++
+		sections[i].Subtotal += line.Total
+		subtotal += line.Total
+	}
+	for i := range sections {
+		sections[i].Subtotal = round2(sections[i].Subtotal)
+	}
+	return sections, round2(subtotal)
+}
+
+func computedQuantities(quantities takeoff.Takeoff) map[string]takeoff.Quantity {
+	computed := make(map[string]takeoff.Quantity)
+	for _, area := range quantities.Areas {
+		for _, q := range area.Quantities {
+			computed[area.AreaID+"|"+q.Code] = q
+		}
+	}
+	return computed
+}
+
+// ConvertQuantity converts a quantity between units that differ only by a
+// fixed factor. Roofing squares are 100 SF and square yards are 9 SF.
+func ConvertQuantity(quantity float64, from, to string) (float64, bool) {
+	if from == to {
+		return quantity, true
+	}
+	perSF := map[string]float64{"SF": 1, "SQ": 100, "SY": 9}
+	fromSF, okFrom := perSF[from]
+	toSF, okTo := perSF[to]
+	if !okFrom || !okTo {
+		return 0, false
+	}
+	return round2(quantity * fromSF / toSF), true
+}
+
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
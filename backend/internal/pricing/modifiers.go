@@ -0,0 +1,147 @@
+package pricing
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/claimcoach/backend/internal/address"
+)
+
+// MaxModifierFactor bounds regional modifiers to catch typos like 108 for 1.08
+const MaxModifierFactor = 5.0
+
+// MaxTaxRate bounds sales tax rates to catch percentages entered as 8.25
+const MaxTaxRate = 0.25
+
+// Regional modifier CSV columns. state and factor are required; a row with a
+// zip applies to that ZIP, a row with a county to that county, and a row with
+// neither to the whole state. tax_rate is a fraction, e.g. 0.0825.
+const (
+	ColumnState   = "state"
+	ColumnCounty  = "county"
+	ColumnZIP     = "zip"
+	ColumnFactor  = "factor"
+	ColumnTaxRate = "tax_rate"
+)
+
+var knownModifierColumns = map[string]bool{
+	ColumnState: true, ColumnCounty: true, ColumnZIP: true, ColumnRegion: true,
+	ColumnFactor: true, ColumnTaxRate: true, ColumnEffectiveDate: true,
+}
+
+// ModifierRow is one parsed regional modifier row. Region is the pricing
+// region the location's prices are looked up in.
+type ModifierRow struct {
+	Line          int
+	State         string
+	County        string
+	ZIP           string
+	Region        string
+	Factor        float64
+	TaxRate       float64
+	EffectiveDate time.Time
+}
+
+// ParseModifiersCSV reads regional cost modifiers with a header row. Like
+// ParseCSV it reports every invalid row as an *ImportError. Rows without a
+// region use the national price lists; rows without an effective date take
+// defaults.EffectiveDate.
+func ParseModifiersCSV(r io.Reader, defaults Defaults) ([]ModifierRow, error) {
+	reader, columns, errs := readHeader(r, knownModifierColumns)
+	if reader == nil {
+		return nil, &ImportError{Rows: errs}
+	}
+	for _, required := range []string{ColumnState, ColumnFactor} {
+		if _, ok := columns[required]; !ok {
+			errs = append(errs, RowError{Line: 1, Column: required, Message: fmt.Sprintf("missing required column %q", required)})
+		}
+	}
+	if len(errs) > 0 {
+		return nil, &ImportError{Rows: errs}
+	}
+
+	var rows []ModifierRow
+	seen := make(map[string]int)
+	tooMany := false
+	errs = eachRecord(reader, func(line int, record []string) []RowError {
+		if tooMany {
+			return nil
+		}
+		if len(rows) == MaxImportRows {
+			tooMany = true
+			return []RowError{{Line: line, Message: fmt.Sprintf("a modifier file may have at most %d rows", MaxImportRows)}}
+		}
+
+		p := rowParser{line: line, record: record, columns: columns}
+		row := p.parseModifier(defaults)
+		if len(p.errs) == 0 {
+			key := row.State + "|" + row.County + "|" + row.ZIP + "|" + FormatDate(row.EffectiveDate)
+			if first, dup := seen[key]; dup {
+				p.fail(ColumnState, fmt.Sprintf("this location already has a modifier on %s at line %d", FormatDate(row.EffectiveDate), first))
+			} else {
+				seen[key] = line
+			}
+		}
+
+		rows = append(rows, row)
+		return p.errs
+	})
+
+	if len(errs) > 0 {
+		return nil, &ImportError{Rows: errs}
+	}
+	if len(rows) == 0 {
+		return nil, &ImportError{Rows: []RowError{{Line: 2, Message: "file has no modifier rows"}}}
+	}
+	return rows, nil
+}
+
+func (p *rowParser) parseModifier(defaults Defaults) ModifierRow {
+	row := ModifierRow{
+		Line:   p.line,
+		County: address.NormalizeCounty(p.field(ColumnCounty)),
+		ZIP:    p.field(ColumnZIP),
+		Region: NormalizeRegion(p.field(ColumnRegion)),
+	}
+
+	state, ok := address.NormalizeState(p.field(ColumnState))
+	if !ok {
+		p.fail(ColumnState, fmt.Sprintf("unknown state %q", p.field(ColumnState)))
+	}
+	row.State = state
+
+	if row.ZIP != "" && !address.ValidZIP(row.ZIP) {
+		p.fail(ColumnZIP, fmt.Sprintf("zip must be five digits, got %q", row.ZIP))
+	}
+	if row.ZIP != "" && row.County != "" {
+		p.fail(ColumnCounty, "a modifier applies to a zip or a county, not both")
+	}
+
+	if row.Region == "" {
+		row.Region = DefaultRegion
+	}
+	if !ValidRegion(row.Region) {
+		p.fail(ColumnRegion, fmt.Sprintf("invalid region %q", row.Region))
+	}
+
+	raw := p.field(ColumnFactor)
+	factor, err := strconv.ParseFloat(raw, 64)
+	if err != nil || factor <= 0 || factor > MaxModifierFactor {
+		p.fail(ColumnFactor, fmt.Sprintf("factor must be a number above 0 and at most %g, got %q", MaxModifierFactor, raw))
+	}
+	row.Factor = factor
+
+	if raw := p.field(ColumnTaxRate); raw != "" {
+		rate, err := strconv.ParseFloat(raw, 64)
+		if err != nil || rate < 0 || rate > MaxTaxRate {
+			p.fail(ColumnTaxRate, fmt.Sprintf("tax_rate must be a fraction from 0 to %g, got %q", MaxTaxRate, raw))
+		}
+		row.TaxRate = rate
+	}
+
+	row.EffectiveDate = p.effectiveDate(defaults)
+
+	return row
+}
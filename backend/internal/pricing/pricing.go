@@ -0,0 +1,93 @@
+// Package pricing holds unit costs per line-item code and prices estimates
+// from them. The LLM only maps scope items to codes; every dollar amount in
+// an industry estimate comes from a price in this package and cites where it
+// came from.
+package pricing
+
+import (
+	"regexp"
+	"strings"
+	"time"
+)
+
+// DefaultRegion is the region national price lists are filed under, and the
+// region estimates are priced in when no more specific one is known
+const DefaultRegion = "national"
+
+// DateLayout is the format of effective dates in CSV files and API payloads
+const DateLayout = "2006-01-02"
+
+// Price source types, in increasing order of precedence
+const (
+	SourceBaseline    = "baseline"
+	SourcePriceList   = "price_list"
+	SourceOrgOverride = "org_override"
+	SourceUnpriced    = "unpriced"
+)
+
+// Units a unit cost may be quoted in
+var units = map[string]bool{
+	"SF": true, "SY": true, "LF": true, "SQ": true, "EA": true,
+	"DAY": true, "HR": true, "CY": true, "GAL": true,
+}
+
+var (
+	codePattern   = regexp.MustCompile(`^[A-Z0-9][A-Z0-9_\-.]{0,39}$`)
+	regionPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_\-]{0,39}$`)
+)
+
+// Price is the unit cost in effect for one code
+type Price struct {
+	Code         string   `json:"code"`
+	Description  string   `json:"description"`
+	Unit         string   `json:"unit"`
+	UnitCost     float64  `json:"unit_cost"`
+	MaterialCost *float64 `json:"material_cost,omitempty"`
+	LaborCost    *float64 `json:"labor_cost,omitempty"`
+	Source       Source   `json:"source"`
+}
+
+// Source identifies where a price came from
+type Source struct {
+	Type string `json:"type"`
+	// ID is the price list or org override the price was read from
+	ID            *string `json:"id,omitempty"`
+	Name          string  `json:"name,omitempty"`
+	Region        string  `json:"region,omitempty"`
+	EffectiveDate string  `json:"effective_date,omitempty"`
+}
+
+// NormalizeCode upper-cases and trims a line-item code
+func NormalizeCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// NormalizeUnit upper-cases and trims a unit
+func NormalizeUnit(unit string) string {
+	return strings.ToUpper(strings.TrimSpace(unit))
+}
+
+// NormalizeRegion lower-cases and trims a region
+func NormalizeRegion(region string) string {
+	return strings.ToLower(strings.TrimSpace(region))
+}
+
+// ValidCode reports whether code is a well-formed, normalized line-item code
+func ValidCode(code string) bool {
+	return codePattern.MatchString(code)
+}
+
+// ValidUnit reports whether unit is a normalized unit prices may be quoted in
+func ValidUnit(unit string) bool {
+	return units[unit]
+}
+
+// ValidRegion reports whether region is a well-formed, normalized region
+func ValidRegion(region string) bool {
+	return regionPattern.MatchString(region)
+}
+
+// FormatDate formats an effective date for display and storage in JSON
+func FormatDate(t time.Time) string {
+	return t.Format(DateLayout)
+}
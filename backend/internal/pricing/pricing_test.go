@@ -0,0 +1,365 @@
+package pricing
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/claimcoach/backend/internal/takeoff"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testDefaults = Defaults{Region: "tx-houston", EffectiveDate: time.Date(2026, 7, 1, 0, 0, 0, 0, time.UTC)}
+
+func TestParseCSV(t *testing.T) {
+	rows, err := ParseCSV(strings.NewReader(
+		"Code,Description,Unit,Material_Cost,Labor_Cost,Unit_Cost,Region,Effective_Date\n"+
+			"rfg_shingle_arch,Architectural shingles,sq,150,140,,,\n"+
+			"\n"+
+			"GTR_ALUM,Seamless gutter,LF,,,\"$1,008.50\",National,2026-09-01\n",
+	), testDefaults)
+
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+
+	assert.Equal(t, "RFG_SHINGLE_ARCH", rows[0].Code)
+	assert.Equal(t, "SQ", rows[0].Unit)
+	assert.Equal(t, 290.0, rows[0].UnitCost) // material + labor
+	assert.Equal(t, "tx-houston", rows[0].Region)
+	assert.Equal(t, testDefaults.EffectiveDate, rows[0].EffectiveDate)
+
+	assert.Equal(t, 4, rows[1].Line)
+	assert.Equal(t, 1008.5, rows[1].UnitCost)
+	assert.Nil(t, rows[1].MaterialCost)
+	assert.Equal(t, "national", rows[1].Region)
+	assert.Equal(t, "2026-09-01", FormatDate(rows[1].EffectiveDate))
+}
+
+func TestParseCSV_ReportsEveryInvalidRow(t *testing.T) {
+	_, err := ParseCSV(strings.NewReader(
+		"code,unit,unit_cost,effective_date\n"+
+			"RFG_TEAROFF,SQ,68.50,2026-01-01\n"+
+			"RFG_TEAROFF,SQ,70.00,2026-01-01\n"+
+			"bad code,BUNDLE,-4,01/02/2026\n",
+	), Defaults{Region: DefaultRegion})
+
+	var importErr *ImportError
+	require.True(t, errors.As(err, &importErr))
+	require.Len(t, importErr.Rows, 5)
+	assert.Equal(t, RowError{Line: 3, Column: ColumnCode,
+		Message: "RFG_TEAROFF is already priced for national on 2026-01-01 at line 2"}, importErr.Rows[0])
+	for _, rowErr := range importErr.Rows[1:] {
+		assert.Equal(t, 4, rowErr.Line)
+	}
+	assert.Equal(t, []string{ColumnCode, ColumnUnit, ColumnUnitCost, ColumnEffectiveDate}, []string{
+		importErr.Rows[1].Column, importErr.Rows[2].Column, importErr.Rows[3].Column, importErr.Rows[4].Column,
+	})
+}
+
+func TestParseCSV_RejectsBadHeader(t *testing.T) {
+	_, err := ParseCSV(strings.NewReader("code,price\nRFG_TEAROFF,68.50\n"), testDefaults)
+
+	var importErr *ImportError
+	require.True(t, errors.As(err, &importErr))
+	assert.Len(t, importErr.Rows, 3) // unknown "price", missing unit, missing unit_cost
+	for _, rowErr := range importErr.Rows {
+		assert.Equal(t, 1, rowErr.Line)
+	}
+}
+
+func TestBaseline(t *testing.T) {
+	prices := Baseline()
+
+	require.Contains(t, prices, "RFG_SHINGLE_ARCH")
+	price := prices["RFG_SHINGLE_ARCH"]
+	assert.Equal(t, "SQ", price.Unit)
+	assert.Equal(t, 295.0, price.UnitCost)
+	assert.Equal(t, Source{Type: SourceBaseline, Name: BaselineName, Region: DefaultRegion, EffectiveDate: BaselineVersion}, price.Source)
+
+	// Callers may layer overrides on the copy without changing the baseline
+	delete(prices, "RFG_SHINGLE_ARCH")
+	assert.Contains(t, Baseline(), "RFG_SHINGLE_ARCH")
+}
+
+func TestPriceEstimate(t *testing.T) {
+	overrideID := "override-1"
+	prices := map[string]Price{
+		"RFG_SHINGLE_ARCH": {Code: "RFG_SHINGLE_ARCH", Unit: "SQ", UnitCost: 300,
+			Source: Source{Type: SourceOrgOverride, ID: &overrideID, EffectiveDate: "2026-06-01"}},
+		"RFG_TEAROFF":  {Code: "RFG_TEAROFF", Description: "Tear off", Unit: "SQ", UnitCost: 68.5, Source: Source{Type: SourceBaseline}},
+		"INT_DRYWALL":  {Code: "INT_DRYWALL", Unit: "SF", UnitCost: 2.9, Source: Source{Type: SourceBaseline}},
+		"FNC_WOOD":     {Code: "FNC_WOOD", Unit: "LF", UnitCost: 38.9, Source: Source{Type: SourceBaseline}},
+		"GEN_DUMPSTER": {Code: "GEN_DUMPSTER", Unit: "EA", UnitCost: 585, Source: Source{Type: SourceBaseline}},
+	}
+	quantities := takeoff.Takeoff{Areas: []takeoff.Area{{
+		AreaID: "roof-1",
+		Quantities: []takeoff.Quantity{
+			{Code: "roof_squares", Quantity: 27, Unit: "SQ"},
+			{Code: "roof_area", Quantity: 2400, Unit: "SF"},
+		},
+	}}}
+
+	estimate := PriceEstimate([]MappedItem{
+		// The LLM's quantity is replaced by the takeoff's
+		{Code: "rfg_shingle_arch", Description: "Shingles", Quantity: 30, Unit: "SQ", Category: "Roofing", AreaID: "roof-1", TakeoffCode: "roof_squares"},
+		// SF converts to the price's SQ
+		{Code: "RFG_TEAROFF", Category: "Roofing", AreaID: "roof-1", TakeoffCode: "roof_area"},
+		{Code: "GEN_DUMPSTER", Description: "Dumpster", Quantity: 1, Unit: "EA", Category: "General"},
+		{Code: "FNC_WOOD", Description: "Fence", Quantity: 10, Unit: "SF", Category: "Fencing"},
+		{Code: "RFG_SKYLIGHT", Description: "Skylight", Quantity: 1, Unit: "EA", Category: "Roofing"},
+		{Code: "INT_DRYWALL", Description: "Drywall", Quantity: 0, Unit: "SF"},
+	}, quantities, prices, NoAdjustment("no location on file"), "2026-10-18")
+
+	require.Len(t, estimate.LineItems, 5)
+
+	shingles := estimate.LineItems[0]
+	assert.Equal(t, 27.0, shingles.Quantity)
+	assert.Equal(t, QuantityFromTakeoff, shingles.QuantitySource)
+	assert.Equal(t, 8100.0, shingles.Total)
+	assert.Equal(t, SourceOrgOverride, shingles.PriceSource.Type)
+	assert.Equal(t, &overrideID, shingles.PriceSource.ID)
+
+	tearoff := estimate.LineItems[1]
+	assert.Equal(t, 24.0, tearoff.Quantity)
+	assert.Equal(t, "SQ", tearoff.Unit)
+	assert.Equal(t, 1644.0, tearoff.Total)
+	assert.Equal(t, "Tear off", tearoff.Description)
+
+	assert.Equal(t, QuantityEstimated, estimate.LineItems[2].QuantitySource)
+
+	fence := estimate.LineItems[3]
+	assert.Equal(t, SourceUnpriced, fence.PriceSource.Type)
+	assert.Equal(t, "FNC_WOOD is priced per LF but the quantity is in SF", fence.Note)
+	assert.Zero(t, fence.Total)
+
+	assert.Equal(t, SourceUnpriced, estimate.LineItems[4].PriceSource.Type)
+	assert.Equal(t, 2, estimate.UnpricedCount)
+
+	assert.Equal(t, []Section{
+		{Category: "Roofing", LineCount: 3, Subtotal: 9744},
+		{Category: "General", LineCount: 1, Subtotal: 585},
+		{Category: "Fencing", LineCount: 1, Subtotal: 0},
+	}, estimate.Sections)
+	assert.Equal(t, 10329.0, estimate.Subtotal)
+	assert.Equal(t, 2065.8, estimate.OverheadProfit)
+	assert.Zero(t, estimate.Tax)
+	assert.Equal(t, 12394.8, estimate.Total)
+	assert.NoError(t, estimate.Validate())
+}
+
+func TestPriceEstimate_ScalesOnlyNationalPrices(t *testing.T) {
+	prices := map[string]Price{
+		"RFG_TEAROFF": {Code: "RFG_TEAROFF", Unit: "SQ", UnitCost: 100, Source: Source{Type: SourceBaseline, Region: DefaultRegion}},
+		"GTR_ALUM":    {Code: "GTR_ALUM", Unit: "LF", UnitCost: 10, Source: Source{Type: SourcePriceList, Region: "tx-houston"}},
+		"FNC_WOOD":    {Code: "FNC_WOOD", Unit: "LF", UnitCost: 40, Source: Source{Type: SourceOrgOverride}},
+	}
+	adjustment := Adjustment{Region: "tx-houston", Factor: 1.1, TaxRate: 0.0825, Basis: "ZIP 77002"}
+
+	estimate := PriceEstimate([]MappedItem{
+		{Code: "RFG_TEAROFF", Quantity: 10, Unit: "SQ"},
+		{Code: "GTR_ALUM", Quantity: 100, Unit: "LF"},
+		{Code: "FNC_WOOD", Quantity: 10, Unit: "LF"},
+	}, takeoff.Takeoff{}, prices, adjustment, "2026-10-18")
+
+	require.Len(t, estimate.LineItems, 3)
+	assert.Equal(t, 110.0, estimate.LineItems[0].UnitCost)
+	assert.Equal(t, 1.1, estimate.LineItems[0].RegionalFactor)
+	assert.Equal(t, 10.0, estimate.LineItems[1].UnitCost)
+	assert.Zero(t, estimate.LineItems[1].RegionalFactor)
+	assert.Equal(t, 40.0, estimate.LineItems[2].UnitCost)
+	assert.Equal(t, 2500.0, estimate.Subtotal)
+	assert.Equal(t, 206.25, estimate.Tax)
+	assert.Equal(t, 3206.25, estimate.Total)
+	assert.Equal(t, "tx-houston", estimate.Region)
+	assert.Equal(t, adjustment, estimate.RegionalAdjustment)
+}
+
+func TestParseModifiersCSV(t *testing.T) {
+	rows, err := ParseModifiersCSV(strings.NewReader(
+		"state,county,zip,region,factor,effective_date\n"+
+			"Texas,,,,0.96,\n"+
+			"TX,Harris County,,tx-houston,1.04,\n"+
+			"tx,,77002,tx-houston,1.08,2026-09-01\n",
+	), testDefaults)
+
+	require.NoError(t, err)
+	require.Len(t, rows, 3)
+	assert.Equal(t, ModifierRow{Line: 2, State: "TX", Region: DefaultRegion, Factor: 0.96, EffectiveDate: testDefaults.EffectiveDate}, rows[0])
+	assert.Equal(t, "harris", rows[1].County)
+	assert.Equal(t, "77002", rows[2].ZIP)
+	assert.Equal(t, "2026-09-01", FormatDate(rows[2].EffectiveDate))
+}
+
+func TestParseModifiersCSV_RejectsInvalidRows(t *testing.T) {
+	_, err := ParseModifiersCSV(strings.NewReader(
+		"state,county,zip,factor\n"+
+			"ZZ,,,1.0\n"+
+			"TX,Harris,77002,108\n",
+	), testDefaults)
+
+	var importErr *ImportError
+	require.True(t, errors.As(err, &importErr))
+	assert.Equal(t, []RowError{
+		{Line: 2, Column: ColumnState, Message: `unknown state "ZZ"`},
+		{Line: 3, Column: ColumnCounty, Message: "a modifier applies to a zip or a county, not both"},
+		{Line: 3, Column: ColumnFactor, Message: `factor must be a number above 0 and at most 5, got "108"`},
+	}, importErr.Rows)
+}
+
+func TestLocationString(t *testing.T) {
+	assert.Equal(t, "Harris County, TX 77002", Location{State: "TX", County: "harris", ZIP: "77002"}.String())
+	assert.Equal(t, "TX", Location{State: "TX"}.String())
+}
+
+func TestValidateMapping(t *testing.T) {
+	prices := map[string]Price{
+		"RFG_SHINGLE_ARCH": {Code: "RFG_SHINGLE_ARCH", Unit: "SQ", UnitCost: 295},
+		"FNC_WOOD":         {Code: "FNC_WOOD", Unit: "LF", UnitCost: 38.9},
+	}
+	quantities := takeoff.Takeoff{Areas: []takeoff.Area{{
+		AreaID:     "roof-1",
+		Quantities: []takeoff.Quantity{{Code: "roof_area", Quantity: 2400, Unit: "SF"}},
+	}}}
+	areaIDs := []string{"roof-1", "fence-1"}
+
+	assert.NoError(t, ValidateMapping([]MappedItem{
+		{Code: "rfg_shingle_arch", AreaID: "roof-1", TakeoffCode: "roof_area"},
+		{Code: "FNC_WOOD", AreaID: "fence-1", Quantity: 80, Unit: "LF"},
+	}, areaIDs, quantities, prices))
+
+	err := ValidateMapping([]MappedItem{
+		{Code: "RFG_SKYLIGHT", AreaID: "roof-1", Quantity: 1, Unit: "EA"},
+		{Code: "RFG_SHINGLE_ARCH", AreaID: "roof-1", TakeoffCode: "ridge_length"},
+		{Code: "FNC_WOOD", AreaID: "fence-2", Quantity: 0, Unit: "LF"},
+		{Code: "FNC_WOOD", AreaID: "fence-1", Quantity: 80, Unit: "SF"},
+	}, areaIDs, quantities, prices)
+	var validation *ValidationError
+	require.True(t, errors.As(err, &validation))
+	assert.Equal(t, []string{
+		`line 1: "RFG_SKYLIGHT" is not a price list code`,
+		`line 2: takeoff_code "ridge_length" is not a computed quantity of area "roof-1"`,
+		`line 3: area_id "fence-2" is not a scope area`,
+		`line 3: quantity must be a positive number`,
+		`line 4: FNC_WOOD is priced per LF but the quantity is in "SF"`,
+	}, validation.Problems)
+
+	assert.Error(t, ValidateMapping(nil, areaIDs, quantities, prices))
+}
+
+func TestIndustryEstimateValidate_ChecksArithmetic(t *testing.T) {
+	estimate := IndustryEstimate{
+		LineItems: []LineItem{
+			{Code: "RFG_TEAROFF", Quantity: 24, UnitCost: 68.5, Total: 1644, Category: "Roofing"},
+			{Code: "GEN_DUMPSTER", Quantity: 1, UnitCost: 585, Total: 600, Category: "General"},
+		},
+		Sections:           []Section{{Category: "Roofing", LineCount: 1, Subtotal: 1644}, {Category: "General", LineCount: 1, Subtotal: 585}},
+		Subtotal:           2229,
+		OverheadProfitRate: 0.2,
+		OverheadProfit:     445.8,
+		Total:              2700,
+	}
+
+	var validation *ValidationError
+	require.True(t, errors.As(estimate.Validate(), &validation))
+	assert.Equal(t, []string{
+		"line 2 (GEN_DUMPSTER): total 600.00 should be 585.00 (1 x 585.00)",
+		`section "General": 1 lines totaling 585.00 should be "General" with 1 lines totaling 600.00`,
+		"subtotal 2229.00 should be 2244.00, the sum of the line items",
+		"total 2700.00 should be 2674.80",
+	}, validation.Problems)
+}
+
+func TestParseIndustryEstimate(t *testing.T) {
+	// Estimates stored before sections existed get them derived
+	estimate, err := ParseIndustryEstimate(`{"line_items":[{"code":"RFG_TEAROFF","quantity":24,"unit_cost":68.5,"total":1644,"category":"Roofing"}],` +
+		`"subtotal":1644,"overhead_profit_rate":0.2,"overhead_profit":328.8,"total":1972.8}`)
+	require.NoError(t, err)
+	assert.Equal(t, []Section{{Category: "Roofing", LineCount: 1, Subtotal: 1644}}, estimate.Sections)
+	assert.Equal(t, 1972.8, estimate.Total)
+
+	_, err = ParseIndustryEstimate(`{"line_items":[],"total":100}`)
+	var validation *ValidationError
+	assert.True(t, errors.As(err, &validation))
+
+	_, err = ParseIndustryEstimate(`not json`)
+	assert.True(t, errors.As(err, &validation))
+}
+
+func TestParseIndustryEstimate_Legacy(t *testing.T) {
+	// The shape the LLM wrote before estimates were priced from the price
+	// list: no codes or rates, and arithmetic that doesn't add up
+	estimate, err := ParseIndustryEstimate(`{
+		"line_items": [
+			{"description": "Remove comp shingles", "quantity": 24, "unit": "SQ", "unit_cost": 68.5, "total": 1650, "category": "Roofing"},
+			{"description": "Install architectural shingles", "quantity": 26.4, "unit": "SQ", "unit_cost": 385, "total": 10164, "category": "Roofing"},
+			{"description": "Seamless gutters", "quantity": 200, "unit": "LF", "unit_cost": 12, "total": 2400, "category": "Exterior Trim"}
+		],
+		"subtotal": 14220,
+		"overhead_profit": 2844,
+		"total": 17050
+	}`)
+	require.NoError(t, err)
+
+	assert.Equal(t, []Section{
+		{Category: "Roofing", LineCount: 2, Subtotal: 11814},
+		{Category: "Exterior Trim", LineCount: 1, Subtotal: 2400},
+	}, estimate.Sections)
+	assert.Equal(t, 14220.0, estimate.Subtotal, "stored amounts are kept")
+	assert.Equal(t, 2844.0, estimate.OverheadProfit)
+	assert.Equal(t, 0.2, estimate.OverheadProfitRate)
+	assert.Zero(t, estimate.TaxRate)
+	assert.Equal(t, 17050.0, estimate.Total)
+
+	// Missing amounts are filled in from the line items
+	estimate, err = ParseIndustryEstimate(`{"line_items": [{"description": "Ridge cap", "quantity": 60, "unit": "LF", "unit_cost": 4.5, "total": 270}], "overhead_profit": 54}`)
+	require.NoError(t, err)
+	assert.Equal(t, UncategorizedSection, estimate.LineItems[0].Category)
+	assert.Equal(t, 270.0, estimate.Subtotal)
+	assert.Equal(t, 0.2, estimate.OverheadProfitRate)
+	assert.Equal(t, 324.0, estimate.Total)
+}
+
+func TestDiffEstimates(t *testing.T) {
+	from := &IndustryEstimate{
+		LineItems: []LineItem{
+			{Code: "RFG_TEAROFF", AreaID: "roof", Quantity: 24, Unit: "SQ", UnitCost: 68.5, Total: 1644},
+			{Code: "GEN_DUMPSTER", Quantity: 1, Unit: "EA", UnitCost: 585, Total: 585},
+			{Code: "DRY_HANG", AreaID: "kitchen", Quantity: 100, Unit: "SF", UnitCost: 2, Total: 200},
+		},
+		Subtotal: 2429,
+		Total:    2429,
+	}
+	to := &IndustryEstimate{
+		LineItems: []LineItem{
+			// Split across two lines; combined it is 30 SQ
+			{Code: "RFG_TEAROFF", AreaID: "roof", Quantity: 20, Unit: "SQ", UnitCost: 68.5, Total: 1370},
+			{Code: "RFG_TEAROFF", AreaID: "roof", Quantity: 10, Unit: "SQ", UnitCost: 68.5, Total: 685},
+			{Code: "GEN_DUMPSTER", Quantity: 1, Unit: "EA", UnitCost: 585, Total: 585},
+			{Code: "RFG_DRIP", AreaID: "roof", Quantity: 180, Unit: "LF", UnitCost: 3, Total: 540},
+		},
+		Subtotal: 3180,
+		Total:    3180,
+	}
+
+	diff := DiffEstimates(from, to)
+	assert.Equal(t, AmountChange{From: 2429, To: 3180, Delta: 751}, diff.Total)
+	assert.Equal(t, 1, diff.UnchangedLines)
+	require.Len(t, diff.Lines, 3)
+
+	assert.Equal(t, "RFG_DRIP", diff.Lines[0].Code)
+	assert.Equal(t, LineAdded, diff.Lines[0].Change)
+	assert.Equal(t, 540.0, diff.Lines[0].Total.Delta)
+
+	assert.Equal(t, "RFG_TEAROFF", diff.Lines[1].Code)
+	assert.Equal(t, LineChanged, diff.Lines[1].Change)
+	assert.Equal(t, 24.0, diff.Lines[1].FromQuantity)
+	assert.Equal(t, 30.0, diff.Lines[1].ToQuantity)
+	assert.Equal(t, 411.0, diff.Lines[1].Total.Delta)
+
+	assert.Equal(t, "DRY_HANG", diff.Lines[2].Code)
+	assert.Equal(t, LineRemoved, diff.Lines[2].Change)
+	assert.Equal(t, "kitchen", diff.Lines[2].AreaID)
+	assert.Equal(t, -200.0, diff.Lines[2].Total.Delta)
+}
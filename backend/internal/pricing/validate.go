@@ -0,0 +1,205 @@
+package pricing
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/claimcoach/backend/internal/takeoff"
+)
+
+// centTolerance allows for float error when comparing rounded amounts
+const centTolerance = 0.005
+
+// ValidationError lists every problem found in an LLM code mapping or a
+// generated estimate. The problems are written to be sent back to the LLM in
+// a repair prompt as well as shown to a reviewer.
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	if len(e.Problems) == 1 {
+		return "invalid estimate: " + e.Problems[0]
+	}
+	return fmt.Sprintf("invalid estimate: %d problems: %s", len(e.Problems), strings.Join(e.Problems, "; "))
+}
+
+type problems []string
+
+func (p *problems) add(format string, args ...interface{}) {
+	*p = append(*p, fmt.Sprintf(format, args...))
+}
+
+func (p problems) err() error {
+	if len(p) == 0 {
+		return nil
+	}
+	return &ValidationError{Problems: p}
+}
+
+// ValidateMapping checks the LLM's code mapping against the scope areas, the
+// computed quantities and the price list before it is priced. Every line must
+// use a known code, reference a real area and takeoff quantity when it names
+// one, and carry a positive quantity in a unit the price can be converted to.
+func ValidateMapping(items []MappedItem, areaIDs []string, quantities takeoff.Takeoff, prices map[string]Price) error {
+	var p problems
+	if len(items) == 0 {
+		p.add("line_items is empty; map every repair in the scope sheet to a price list code")
+		return p.err()
+	}
+
+	areas := make(map[string]bool, len(areaIDs))
+	for _, id := range areaIDs {
+		areas[id] = true
+	}
+	computed := computedQuantities(quantities)
+
+	for i, item := range items {
+		n := i + 1
+		code := NormalizeCode(item.Code)
+		price, known := prices[code]
+		if !known {
+			p.add("line %d: %q is not a price list code", n, item.Code)
+		}
+		if item.AreaID != "" && !areas[item.AreaID] {
+			p.add("line %d: area_id %q is not a scope area", n, item.AreaID)
+		}
+
+		quantity, unit := item.Quantity, NormalizeUnit(item.Unit)
+		if item.TakeoffCode != "" {
+			q, ok := computed[item.AreaID+"|"+item.TakeoffCode]
+			if !ok {
+				p.add("line %d: takeoff_code %q is not a computed quantity of area %q", n, item.TakeoffCode, item.AreaID)
+				continue
+			}
+			quantity, unit = q.Quantity, q.Unit
+		}
+		if quantity <= 0 || math.IsNaN(quantity) || math.IsInf(quantity, 0) {
+			p.add("line %d: quantity must be a positive number", n)
+			continue
+		}
+		if known {
+			if _, ok := ConvertQuantity(quantity, unit, price.Unit); !ok {
+				p.add("line %d: %s is priced per %s but the quantity is in %q", n, code, price.Unit, unit)
+			}
+		}
+	}
+	return p.err()
+}
+
+// Validate checks an estimate's arithmetic: each line's total is its quantity
+// times its unit cost, lines sum to their sections and the subtotal, and O&P,
+// tax and the total follow from the subtotal and rates.
+func (e *IndustryEstimate) Validate() error {
+	var p problems
+	if len(e.LineItems) == 0 {
+		p.add("estimate has no line items")
+	}
+	if e.OverheadProfitRate < 0 || e.OverheadProfitRate >= 1 {
+		p.add("overhead_profit_rate %g must be a fraction below 1", e.OverheadProfitRate)
+	}
+	if e.TaxRate < 0 || e.TaxRate >= 1 {
+		p.add("tax_rate %g must be a fraction below 1", e.TaxRate)
+	}
+
+	for i, line := range e.LineItems {
+		n := i + 1
+		if line.Quantity <= 0 {
+			p.add("line %d (%s): quantity must be positive", n, line.Code)
+		}
+		if line.UnitCost < 0 {
+			p.add("line %d (%s): unit_cost must not be negative", n, line.Code)
+		}
+		if want := round2(line.Quantity * line.UnitCost); !sameAmount(line.Total, want) {
+			p.add("line %d (%s): total %.2f should be %.2f (%g x %.2f)", n, line.Code, line.Total, want, line.Quantity, line.UnitCost)
+		}
+	}
+
+	sections, subtotal := summarize(e.LineItems)
+	if len(sections) != len(e.Sections) {
+		p.add("estimate has %d sections but its line items have %d categories", len(e.Sections), len(sections))
+	} else {
+		for i, want := range sections {
+			got := e.Sections[i]
+			if got.Category != want.Category || got.LineCount != want.LineCount || !sameAmount(got.Subtotal, want.Subtotal) {
+				p.add("section %q: %d lines totaling %.2f should be %q with %d lines totaling %.2f",
+					got.Category, got.LineCount, got.Subtotal, want.Category, want.LineCount, want.Subtotal)
+			}
+		}
+	}
+
+	if !sameAmount(e.Subtotal, subtotal) {
+		p.add("subtotal %.2f should be %.2f, the sum of the line items", e.Subtotal, subtotal)
+	}
+	if want := round2(e.Subtotal * e.OverheadProfitRate); !sameAmount(e.OverheadProfit, want) {
+		p.add("overhead_profit %.2f should be %.2f", e.OverheadProfit, want)
+	}
+	if want := round2(e.Subtotal * e.TaxRate); !sameAmount(e.Tax, want) {
+		p.add("tax %.2f should be %.2f", e.Tax, want)
+	}
+	if want := round2(e.Subtotal + e.OverheadProfit + e.Tax); !sameAmount(e.Total, want) {
+		p.add("total %.2f should be %.2f", e.Total, want)
+	}
+	return p.err()
+}
+
+// ParseIndustryEstimate decodes and validates a stored generated estimate.
+// Estimates stored before sections were added get them derived from their
+// line items; their arithmetic is still checked. Legacy estimates, written
+// whole by the LLM before estimates were priced from the price list, are
+// accepted as stored: see parseLegacyEstimate.
+func ParseIndustryEstimate(data string) (*IndustryEstimate, error) {
+	var estimate IndustryEstimate
+	if err := json.Unmarshal([]byte(data), &estimate); err != nil {
+		return nil, &ValidationError{Problems: []string{"estimate is not valid JSON: " + err.Error()}}
+	}
+	var fields map[string]json.RawMessage
+	_ = json.Unmarshal([]byte(data), &fields) // an object, since estimate decoded
+	if _, priced := fields["overhead_profit_rate"]; !priced {
+		return parseLegacyEstimate(&estimate)
+	}
+
+	if estimate.Sections == nil {
+		estimate.Sections, _ = summarize(estimate.LineItems)
+	}
+	if err := estimate.Validate(); err != nil {
+		return nil, err
+	}
+	return &estimate, nil
+}
+
+// parseLegacyEstimate completes an estimate the LLM wrote whole, with an O&P
+// amount but no rate. Its arithmetic was never checked and often doesn't add
+// up, so the stored amounts are kept as they are: sections are summed from the
+// line items, the subtotal falls back to their sum when missing, and the O&P
+// and tax rates are derived from the amounts.
+func parseLegacyEstimate(estimate *IndustryEstimate) (*IndustryEstimate, error) {
+	if len(estimate.LineItems) == 0 {
+		return nil, &ValidationError{Problems: []string{"estimate has no line items"}}
+	}
+	for i := range estimate.LineItems {
+		if estimate.LineItems[i].Category == "" {
+			estimate.LineItems[i].Category = UncategorizedSection
+		}
+	}
+
+	var subtotal float64
+	estimate.Sections, subtotal = summarize(estimate.LineItems)
+	if estimate.Subtotal == 0 {
+		estimate.Subtotal = subtotal
+	}
+	if estimate.Subtotal > 0 {
+		estimate.OverheadProfitRate = math.Round(estimate.OverheadProfit/estimate.Subtotal*10000) / 10000
+		estimate.TaxRate = math.Round(estimate.Tax/estimate.Subtotal*10000) / 10000
+	}
+	if estimate.Total == 0 {
+		estimate.Total = round2(estimate.Subtotal + estimate.OverheadProfit + estimate.Tax)
+	}
+	return estimate, nil
+}
+
+func sameAmount(a, b float64) bool {
+	return math.Abs(a-b) < centTolerance
+}
@@ -0,0 +1,170 @@
+package prompts
+
+import (
+	"time"
+
+	"github.com/claimcoach/backend/internal/pricing"
+	"github.com/claimcoach/backend/internal/takeoff"
+)
+
+// The data each prompt renders from. Fields carry json tags so evaluation
+// fixtures can record them; text that other prompts share, like the policy
+// terms block, is passed in already rendered.
+
+// EstimateData maps a scope sheet to price list codes
+type EstimateData struct {
+	Areas        []EstimateArea  `json:"areas"`
+	GeneralNotes string          `json:"general_notes"`
+	Prices       []pricing.Price `json:"prices"`
+}
+
+// EstimateArea is one scope sheet area with its computed quantities.
+// Dimensions are left out when HasDimensions is false.
+type EstimateArea struct {
+	ID            string             `json:"id"`
+	Category      string             `json:"category"`
+	Tags          []string           `json:"tags"`
+	SquareFootage float64            `json:"square_footage"`
+	HasDimensions bool               `json:"has_dimensions"`
+	Length        float64            `json:"length"`
+	Width         float64            `json:"width"`
+	Notes         string             `json:"notes"`
+	Quantities    []takeoff.Quantity `json:"quantities"`
+	PhotoCount    int                `json:"photo_count"`
+}
+
+// EstimateRepairData lists why an estimate mapping failed validation
+type EstimateRepairData struct {
+	Problems []string `json:"problems"`
+}
+
+// ViabilityData is the claim and policy facts the viability scoring uses
+type ViabilityData struct {
+	LossType        string    `json:"loss_type"`
+	IncidentDate    time.Time `json:"incident_date"`
+	TotalRCV        float64   `json:"total_rcv"`
+	Deductible      float64   `json:"deductible"`
+	DeductibleBasis string    `json:"deductible_basis"`
+	Exclusions      string    `json:"exclusions"`
+	PolicyTerms     string    `json:"policy_terms"`
+}
+
+// PMBrainData compares the industry estimate with the carrier's offer.
+// Location and RegionalAdjustment are empty when unknown.
+type PMBrainData struct {
+	CarrierName        string                    `json:"carrier_name"`
+	PolicyNumber       string                    `json:"policy_number"`
+	ClaimNumber        string                    `json:"claim_number"`
+	LossType           string                    `json:"loss_type"`
+	IncidentDate       time.Time                 `json:"incident_date"`
+	Deductible         float64                   `json:"deductible"`
+	Exclusions         string                    `json:"exclusions"`
+	PolicyTerms        string                    `json:"policy_terms"`
+	Location           string                    `json:"location"`
+	RegionalAdjustment string                    `json:"regional_adjustment"`
+	Estimate           *pricing.IndustryEstimate `json:"estimate"`
+	CarrierParsedData  string                    `json:"carrier_parsed_data"`
+	SourceText         string                    `json:"source_text"`
+}
+
+// DisputeLetterData writes a dispute letter from a PM Brain analysis, passed
+// as the JSON it was stored as
+type DisputeLetterData struct {
+	PropertyAddress string    `json:"property_address"`
+	CarrierName     string    `json:"carrier_name"`
+	PolicyNumber    string    `json:"policy_number"`
+	ClaimNumber     string    `json:"claim_number"`
+	IncidentDate    time.Time `json:"incident_date"`
+	Today           time.Time `json:"today"`
+	AnalysisJSON    string    `json:"analysis_json"`
+	SourceText      string    `json:"source_text"`
+}
+
+// OwnerPitchData asks the building owner to authorize escalation
+type OwnerPitchData struct {
+	PropertyAddress    string            `json:"property_address"`
+	LossType           string            `json:"loss_type"`
+	IncidentDate       time.Time         `json:"incident_date"`
+	CarrierName        string            `json:"carrier_name"`
+	Deductible         float64           `json:"deductible"`
+	ContractorEstimate float64           `json:"contractor_estimate"`
+	CarrierOffer       float64           `json:"carrier_offer"`
+	Gap                float64           `json:"gap"`
+	DeltaDrivers       []DeltaDriver     `json:"delta_drivers"`
+	CoverageDisputes   []CoverageDispute `json:"coverage_disputes"`
+}
+
+// DeltaDriver is a line item with a pricing gap between the estimates
+type DeltaDriver struct {
+	LineItem        string  `json:"line_item"`
+	ContractorPrice float64 `json:"contractor_price"`
+	CarrierPrice    float64 `json:"carrier_price"`
+	Delta           float64 `json:"delta"`
+	Reason          string  `json:"reason"`
+}
+
+// CoverageDispute is an item the carrier denied or partially paid
+type CoverageDispute struct {
+	Item               string `json:"item"`
+	Status             string `json:"status"`
+	ContractorPosition string `json:"contractor_position"`
+}
+
+// CarrierEstimateParseData is the page range of a carrier estimate to extract
+type CarrierEstimateParseData struct {
+	FirstPage int `json:"first_page"`
+	LastPage  int `json:"last_page"`
+	// PageCount is the number of pages in the whole estimate
+	PageCount int `json:"page_count"`
+}
+
+// RCVDemandData demands the recoverable depreciation still owed
+type RCVDemandData struct {
+	ClaimNumber           string  `json:"claim_number"`
+	PropertyNickname      string  `json:"property_nickname"`
+	PropertyAddress       string  `json:"property_address"`
+	LossType              string  `json:"loss_type"`
+	PolicyNumber          string  `json:"policy_number"`
+	Carrier               string  `json:"carrier"`
+	ACVReceived           float64 `json:"acv_received"`
+	RCVExpected           float64 `json:"rcv_expected"`
+	RCVOutstanding        float64 `json:"rcv_outstanding"`
+	PercentageOutstanding float64 `json:"percentage_outstanding"`
+}
+
+// PolicyQAData is a PM's question about the attached policy, with the
+// confirmed terms and the recent turns of the conversation
+type PolicyQAData struct {
+	PolicyTerms string         `json:"policy_terms"`
+	History     []PolicyQATurn `json:"history"`
+	Question    string         `json:"question"`
+}
+
+// PolicyQATurn is an earlier question and its answer
+type PolicyQATurn struct {
+	Question string `json:"question"`
+	Answer   string `json:"answer"`
+}
+
+// AppraisalDemandData demands appraisal of a disputed amount of loss.
+// DisputedAmount is nil and DisputeSummary empty when the PM gave neither.
+type AppraisalDemandData struct {
+	PropertyAddress        string    `json:"property_address"`
+	CarrierName            string    `json:"carrier_name"`
+	PolicyNumber           string    `json:"policy_number"`
+	InsuranceClaimNumber   string    `json:"insurance_claim_number"`
+	ClaimNumber            string    `json:"claim_number"`
+	LossType               string    `json:"loss_type"`
+	IncidentDate           time.Time `json:"incident_date"`
+	ExpectedACV            float64   `json:"expected_acv"`
+	ACVReceived            float64   `json:"acv_received"`
+	ExpectedRCV            float64   `json:"expected_rcv"`
+	RCVReceived            float64   `json:"rcv_received"`
+	Deductible             float64   `json:"deductible"`
+	DeductibleBasis        string    `json:"deductible_basis"`
+	DisputedAmount         *float64  `json:"disputed_amount"`
+	DisputeSummary         string    `json:"dispute_summary"`
+	PolicyTerms            string    `json:"policy_terms"`
+	AppraiserSelectionDays int       `json:"appraiser_selection_days"`
+	UmpireSelectionDays    int       `json:"umpire_selection_days"`
+}
@@ -0,0 +1,324 @@
+package prompts
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/claimcoach/backend/internal/llm"
+)
+
+// Fixture is a recorded prompt input and the LLM's response to it. Evaluating
+// a fixture replays the input through a prompt version and checks that the
+// prompt still renders, still asks for what the parsers need, and that the
+// response has the shape the services expect.
+type Fixture struct {
+	// File is where the fixture was loaded from
+	File   string          `json:"-"`
+	Name   string          `json:"name"`
+	Prompt string          `json:"prompt"`
+	Data   json.RawMessage `json:"data"`
+	// Response is the recorded LLM output the stub LLM replays
+	Response string `json:"response"`
+	// PromptContains are facts from Data the rendered prompt must include
+	PromptContains []string `json:"prompt_contains"`
+}
+
+// LoadFixtures reads every .json fixture under dir, sorted by path
+func LoadFixtures(dir string) ([]Fixture, error) {
+	var fixtures []Fixture
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || filepath.Ext(path) != ".json" {
+			return err
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		var f Fixture
+		if err := json.Unmarshal(content, &f); err != nil {
+			return fmt.Errorf("fixture %s: %w", path, err)
+		}
+		if f.Name == "" {
+			f.Name = strings.TrimSuffix(filepath.Base(path), ".json")
+		}
+		f.File = path
+		fixtures = append(fixtures, f)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(fixtures, func(i, j int) bool { return fixtures[i].File < fixtures[j].File })
+	return fixtures, nil
+}
+
+// StubLLM stands in for the LLM clients, answering every request with a
+// recorded response and keeping the last request it was sent
+type StubLLM struct {
+	Response string
+	Messages []llm.Message
+	Prompt   string
+}
+
+// Chat records the messages and returns the recorded response
+func (s *StubLLM) Chat(ctx context.Context, messages []llm.Message, temperature float64, maxTokens int) (*llm.ChatResponse, error) {
+	s.Messages = messages
+	// ChatResponse's choices are an anonymous struct, so build it from JSON
+	body, err := json.Marshal(map[string]interface{}{
+		"model": "stub",
+		"choices": []map[string]interface{}{
+			{"index": 0, "message": map[string]string{"role": "assistant", "content": s.Response}},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	var response llm.ChatResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// ParsePDF records the prompt and returns the recorded response
+func (s *StubLLM) ParsePDF(ctx context.Context, pdfContent []byte, prompt string, maxTokens int) (string, error) {
+	s.Prompt = prompt
+	return s.Response, nil
+}
+
+// shape is the output a prompt's parser expects
+type shape struct {
+	// pdf prompts are sent with a document through ParsePDF rather than Chat
+	pdf bool
+	// text outputs are letters and emails; the rest are JSON objects
+	text     bool
+	required []string
+	enums    map[string][]string
+	// items lists the keys each element of an array field needs
+	items map[string][]string
+}
+
+var shapes = map[string]shape{
+	Estimate: {
+		required: []string{"line_items"},
+		items:    map[string][]string{"line_items": {"code", "area_id", "takeoff_code", "quantity", "unit"}},
+	},
+	Viability: {
+		required: []string{"recommendation", "net_estimated_recovery", "coverage_score", "economics_score",
+			"top_risks", "required_next_steps", "plain_english_summary"},
+		enums: map[string][]string{"recommendation": {"PURSUE", "PURSUE_WITH_CONDITIONS", "DO_NOT_PURSUE"}},
+	},
+	PMBrain: {
+		required: []string{"status", "plain_english_summary", "total_contractor_estimate", "total_carrier_estimate",
+			"total_delta", "top_delta_drivers", "coverage_disputes", "required_next_steps", "legal_threshold_met"},
+		enums: map[string][]string{"status": {"CLOSE", "DISPUTE_OFFER", "LEGAL_REVIEW", "NEED_DOCS"}},
+		items: map[string][]string{
+			"top_delta_drivers": {"line_item", "contractor_price", "carrier_price", "delta", "reason"},
+			"coverage_disputes": {"item", "status", "contractor_position"},
+		},
+	},
+	DisputeLetter: {text: true},
+	OwnerPitch:    {text: true},
+	RCVDemand:     {text: true},
+	CarrierEstimateParse: {
+		pdf:      true,
+		required: []string{"line_items", "total", "stated_totals", "confidence"},
+		items:    map[string][]string{"line_items": {"page", "description", "quantity", "unit", "unit_cost", "total", "category"}},
+	},
+	PolicyTerms: {
+		pdf: true,
+		required: []string{"coverage_a_limit", "coverage_b_limit", "coverage_c_limit", "coverage_d_limit",
+			"deductible_type", "deductible_amount", "deductible_percentage", "roof_settlement",
+			"matching_endorsement", "ordinance_and_law_endorsement", "ordinance_and_law_limit", "exclusions"},
+	},
+	TextExtraction: {pdf: true, text: true},
+	PolicyQA: {
+		pdf:      true,
+		required: []string{"answer", "citations"},
+		items:    map[string][]string{"citations": {"page", "quote"}},
+	},
+	AppraisalDemand: {text: true},
+}
+
+// newData returns the data type a prompt renders from, nil for none
+func newData(name string) interface{} {
+	switch name {
+	case Estimate:
+		return &EstimateData{}
+	case Viability:
+		return &ViabilityData{}
+	case PMBrain:
+		return &PMBrainData{}
+	case DisputeLetter:
+		return &DisputeLetterData{}
+	case OwnerPitch:
+		return &OwnerPitchData{}
+	case RCVDemand:
+		return &RCVDemandData{}
+	case CarrierEstimateParse:
+		return &CarrierEstimateParseData{}
+	case PolicyQA:
+		return &PolicyQAData{}
+	case AppraisalDemand:
+		return &AppraisalDemandData{}
+	}
+	return nil
+}
+
+// Result is the outcome of evaluating one fixture
+type Result struct {
+	Fixture  Fixture
+	Version  string
+	Problems []string
+}
+
+// Passed reports whether the fixture passed every check
+func (r Result) Passed() bool {
+	return len(r.Problems) == 0
+}
+
+// placeholderPattern catches template placeholders left in a letter, e.g. [Your Name]
+var placeholderPattern = regexp.MustCompile(`\[[A-Z][A-Za-z ]*\]`)
+
+// Evaluate replays a fixture through a prompt version and a stub LLM
+func Evaluate(ctx context.Context, p *Prompt, f Fixture) Result {
+	result := Result{Fixture: f, Version: p.ID()}
+	fail := func(format string, args ...interface{}) {
+		result.Problems = append(result.Problems, fmt.Sprintf(format, args...))
+	}
+
+	s, ok := shapes[p.Name]
+	if !ok {
+		fail("no output shape defined for prompt %s", p.Name)
+		return result
+	}
+	data := newData(p.Name)
+	if data != nil {
+		if err := json.Unmarshal(f.Data, data); err != nil {
+			fail("fixture data does not match the %s prompt's data: %v", p.Name, err)
+			return result
+		}
+	}
+	rendered, err := p.Render(data)
+	if err != nil {
+		fail("%v", err)
+		return result
+	}
+
+	stub := &StubLLM{Response: f.Response}
+	var output, sent string
+	if s.pdf {
+		output, _ = stub.ParsePDF(ctx, nil, rendered.User, 0)
+		sent = stub.Prompt
+	} else {
+		response, _ := stub.Chat(ctx, rendered.Messages(), 0, 0)
+		output = response.Choices[0].Message.Content
+		for _, m := range stub.Messages {
+			sent += m.Content + "\n"
+		}
+	}
+
+	if strings.Contains(sent, "<no value>") {
+		fail("rendered prompt contains <no value>")
+	}
+	for _, want := range f.PromptContains {
+		if !strings.Contains(sent, want) {
+			fail("rendered prompt is missing %q", want)
+		}
+	}
+
+	if s.text {
+		checkText(output, fail)
+		return result
+	}
+	// The prompt has to ask for every field the parser reads
+	for _, key := range s.required {
+		if !strings.Contains(sent, `"`+key+`"`) {
+			fail("prompt does not ask for %q", key)
+		}
+	}
+	for field, keys := range s.items {
+		for _, key := range keys {
+			if !strings.Contains(sent, `"`+key+`"`) {
+				fail("prompt does not ask for %s.%s", field, key)
+			}
+		}
+	}
+	checkJSON(output, s, fail)
+	return result
+}
+
+func checkText(output string, fail func(string, ...interface{})) {
+	output = strings.TrimSpace(output)
+	if output == "" {
+		fail("response is empty")
+		return
+	}
+	if strings.Contains(output, "```") || strings.HasPrefix(output, "#") {
+		fail("response contains markdown")
+	}
+	if placeholder := placeholderPattern.FindString(output); placeholder != "" {
+		fail("response contains placeholder %s", placeholder)
+	}
+}
+
+func checkJSON(output string, s shape, fail func(string, ...interface{})) {
+	start, end := strings.Index(output, "{"), strings.LastIndex(output, "}")
+	if start < 0 || end < start {
+		fail("response has no JSON object")
+		return
+	}
+	var object map[string]interface{}
+	if err := json.Unmarshal([]byte(output[start:end+1]), &object); err != nil {
+		fail("response is not valid JSON: %v", err)
+		return
+	}
+	for _, key := range s.required {
+		if _, ok := object[key]; !ok {
+			fail("response is missing %q", key)
+		}
+	}
+	for key, allowed := range s.enums {
+		value, _ := object[key].(string)
+		if !contains(allowed, value) {
+			fail("response %s %q is not one of %s", key, value, strings.Join(allowed, ", "))
+		}
+	}
+	for field, keys := range s.items {
+		elements, ok := object[field].([]interface{})
+		if !ok {
+			if _, present := object[field]; present {
+				fail("response %s is not an array", field)
+			}
+			continue
+		}
+		for i, element := range elements {
+			item, ok := element.(map[string]interface{})
+			if !ok {
+				fail("response %s[%d] is not an object", field, i)
+				continue
+			}
+			for _, key := range keys {
+				if _, ok := item[key]; !ok {
+					fail("response %s[%d] is missing %q", field, i, key)
+				}
+			}
+		}
+	}
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
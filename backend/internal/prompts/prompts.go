@@ -0,0 +1,234 @@
+// Package prompts is the registry of the LLM prompt templates. Each prompt is
+// a text/template file embedded from templates/<name>/v<N>.tmpl that defines a
+// "user" section and usually a "system" section. Every version of a prompt is
+// kept; the active one is pinned in the active map so a new version can be
+// evaluated against the fixtures before it ships, and the version ID that
+// rendered an artifact is recorded with it.
+package prompts
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/claimcoach/backend/internal/llm"
+)
+
+//go:embed templates
+var templateFS embed.FS
+
+// Prompt names
+const (
+	Estimate             = "estimate"
+	Viability            = "viability"
+	PMBrain              = "pm_brain"
+	DisputeLetter        = "dispute_letter"
+	OwnerPitch           = "owner_pitch"
+	RCVDemand            = "rcv_demand"
+	CarrierEstimateParse = "carrier_estimate_parse"
+	PolicyTerms          = "policy_terms"
+	TextExtraction       = "text_extraction"
+	PolicyQA             = "policy_qa"
+	AppraisalDemand      = "appraisal_demand"
+)
+
+// active pins the version of each prompt the services render
+var active = map[string]int{
+	Estimate:             1,
+	Viability:            1,
+	PMBrain:              1,
+	DisputeLetter:        1,
+	OwnerPitch:           1,
+	RCVDemand:            1,
+	CarrierEstimateParse: 2,
+	PolicyTerms:          1,
+	TextExtraction:       1,
+	PolicyQA:             1,
+	AppraisalDemand:      1,
+}
+
+// Template sections
+const (
+	SectionSystem = "system"
+	SectionUser   = "user"
+	// SectionRepair is the estimate prompt's follow-up for a mapping that
+	// failed validation
+	SectionRepair = "repair"
+)
+
+// Prompt is one version of a prompt template
+type Prompt struct {
+	Name    string
+	Version int
+	tmpl    *template.Template
+}
+
+// ID identifies the prompt version, e.g. "pm_brain/v2". It is what artifacts
+// record as their prompt version.
+func (p *Prompt) ID() string {
+	return fmt.Sprintf("%s/v%d", p.Name, p.Version)
+}
+
+// Rendered is a prompt rendered for one request
+type Rendered struct {
+	Version string
+	System  string
+	User    string
+}
+
+// Messages returns the rendered prompt as chat messages, leaving out an empty
+// system message
+func (r *Rendered) Messages() []llm.Message {
+	var messages []llm.Message
+	if r.System != "" {
+		messages = append(messages, llm.Message{Role: "system", Content: r.System})
+	}
+	return append(messages, llm.Message{Role: "user", Content: r.User})
+}
+
+// Render renders the system and user sections with data
+func (p *Prompt) Render(data interface{}) (*Rendered, error) {
+	r := &Rendered{Version: p.ID()}
+	if p.tmpl.Lookup(SectionSystem) != nil {
+		system, err := p.Execute(SectionSystem, data)
+		if err != nil {
+			return nil, err
+		}
+		r.System = system
+	}
+	user, err := p.Execute(SectionUser, data)
+	if err != nil {
+		return nil, err
+	}
+	r.User = user
+	return r, nil
+}
+
+// Execute renders one named section, such as a follow-up message the prompt
+// defines alongside its user section
+func (p *Prompt) Execute(section string, data interface{}) (string, error) {
+	if p.tmpl.Lookup(section) == nil {
+		return "", fmt.Errorf("prompt %s has no %q section", p.ID(), section)
+	}
+	var buf bytes.Buffer
+	if err := p.tmpl.ExecuteTemplate(&buf, section, data); err != nil {
+		return "", fmt.Errorf("failed to render prompt %s: %w", p.ID(), err)
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
+
+var (
+	loadOnce sync.Once
+	registry map[string]map[int]*Prompt
+	loadErr  error
+)
+
+// load parses every embedded template once
+func load() (map[string]map[int]*Prompt, error) {
+	loadOnce.Do(func() {
+		registry, loadErr = parseTemplates(templateFS)
+	})
+	return registry, loadErr
+}
+
+func parseTemplates(fsys fs.FS) (map[string]map[int]*Prompt, error) {
+	files, err := fs.Glob(fsys, "templates/*/v*.tmpl")
+	if err != nil {
+		return nil, err
+	}
+	prompts := map[string]map[int]*Prompt{}
+	for _, file := range files {
+		name := path.Base(path.Dir(file))
+		version, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(path.Base(file), "v"), ".tmpl"))
+		if err != nil || version < 1 {
+			return nil, fmt.Errorf("prompt template %s: file name must be v<N>.tmpl", file)
+		}
+		content, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return nil, err
+		}
+		tmpl, err := template.New(path.Base(file)).Funcs(funcs).Option("missingkey=error").Parse(string(content))
+		if err != nil {
+			return nil, fmt.Errorf("prompt template %s: %w", file, err)
+		}
+		if tmpl.Lookup(SectionUser) == nil {
+			return nil, fmt.Errorf("prompt template %s: no %q section", file, SectionUser)
+		}
+		if prompts[name] == nil {
+			prompts[name] = map[int]*Prompt{}
+		}
+		prompts[name][version] = &Prompt{Name: name, Version: version, tmpl: tmpl}
+	}
+	return prompts, nil
+}
+
+// Get returns the active version of a prompt
+func Get(name string) (*Prompt, error) {
+	version, ok := active[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown prompt %q", name)
+	}
+	return GetVersion(name, version)
+}
+
+// GetVersion returns a specific version of a prompt
+func GetVersion(name string, version int) (*Prompt, error) {
+	prompts, err := load()
+	if err != nil {
+		return nil, err
+	}
+	p, ok := prompts[name][version]
+	if !ok {
+		return nil, fmt.Errorf("prompt %s/v%d not found", name, version)
+	}
+	return p, nil
+}
+
+// Versions lists the versions of a prompt, oldest first
+func Versions(name string) ([]int, error) {
+	prompts, err := load()
+	if err != nil {
+		return nil, err
+	}
+	versions := make([]int, 0, len(prompts[name]))
+	for v := range prompts[name] {
+		versions = append(versions, v)
+	}
+	sort.Ints(versions)
+	return versions, nil
+}
+
+// Render renders the active version of a prompt
+func Render(name string, data interface{}) (*Rendered, error) {
+	p, err := Get(name)
+	if err != nil {
+		return nil, err
+	}
+	return p.Render(data)
+}
+
+// funcs are the helpers available to every template
+var funcs = template.FuncMap{
+	// money formats an amount as dollars and cents, e.g. $1234.50
+	"money": func(amount float64) string {
+		return fmt.Sprintf("$%.2f", amount)
+	},
+	// date formats a date the way letters spell it out, e.g. January 2, 2006
+	"date": func(t time.Time) string {
+		return t.Format("January 2, 2006")
+	},
+	"join": strings.Join,
+	"json": func(v interface{}) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+}
@@ -0,0 +1,87 @@
+package prompts
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestActivePromptsLoad(t *testing.T) {
+	for name, version := range active {
+		p, err := Get(name)
+		require.NoError(t, err, name)
+		assert.Equal(t, version, p.Version)
+		_, ok := shapes[name]
+		assert.True(t, ok, "%s has no output shape", name)
+	}
+
+	_, err := Get("unknown")
+	assert.Error(t, err)
+	_, err = GetVersion(PMBrain, 999)
+	assert.Error(t, err)
+}
+
+func TestFixtures(t *testing.T) {
+	fixtures, err := LoadFixtures("fixtures")
+	require.NoError(t, err)
+
+	covered := map[string]bool{}
+	for _, f := range fixtures {
+		p, err := Get(f.Prompt)
+		require.NoError(t, err, f.File)
+		covered[f.Prompt] = true
+
+		t.Run(f.Name, func(t *testing.T) {
+			result := Evaluate(context.Background(), p, f)
+			assert.Empty(t, result.Problems)
+		})
+	}
+	for name := range active {
+		assert.True(t, covered[name], "%s has no fixture", name)
+	}
+}
+
+func TestEvaluateCatchesBadResponses(t *testing.T) {
+	p, err := Get(PMBrain)
+	require.NoError(t, err)
+	f := Fixture{
+		Name:     "bad",
+		Prompt:   PMBrain,
+		Data:     []byte(`{"carrier_name": "Acme", "estimate": {"total": 100}}`),
+		Response: `{"status": "MAYBE", "top_delta_drivers": [{"line_item": "Roof"}]}`,
+	}
+	result := Evaluate(context.Background(), p, f)
+	assert.False(t, result.Passed())
+	assert.Contains(t, result.Problems, `response is missing "total_delta"`)
+	assert.Contains(t, result.Problems, `response status "MAYBE" is not one of CLOSE, DISPUTE_OFFER, LEGAL_REVIEW, NEED_DOCS`)
+	assert.Contains(t, result.Problems, `response top_delta_drivers[0] is missing "delta"`)
+
+	letter, err := Get(DisputeLetter)
+	require.NoError(t, err)
+	result = Evaluate(context.Background(), letter, Fixture{
+		Prompt:   DisputeLetter,
+		Data:     []byte(`{}`),
+		Response: "Dear [Adjuster Name],\n\n```\nWe dispute\n```",
+	})
+	assert.Contains(t, result.Problems, "response contains markdown")
+	assert.Contains(t, result.Problems, "response contains placeholder [Adjuster Name]")
+}
+
+func TestRenderedMessages(t *testing.T) {
+	rendered, err := Render(RCVDemand, &RCVDemandData{ClaimNumber: "CLM-1", RCVOutstanding: 1200})
+	require.NoError(t, err)
+	assert.Equal(t, "rcv_demand/v1", rendered.Version)
+
+	messages := rendered.Messages()
+	require.Len(t, messages, 2)
+	assert.Equal(t, "system", messages[0].Role)
+	assert.Contains(t, messages[1].Content, "$1200.00")
+
+	parse, err := Render(CarrierEstimateParse, &CarrierEstimateParseData{FirstPage: 9, LastPage: 16, PageCount: 20})
+	require.NoError(t, err)
+	assert.Empty(t, parse.System)
+	assert.Len(t, parse.Messages(), 1)
+	assert.Contains(t, parse.User, "pages 9 to 16 of a 20-page carrier estimate")
+}
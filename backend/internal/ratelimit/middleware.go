@@ -0,0 +1,133 @@
+package ratelimit
+
+import (
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Rule limits requests sharing the same key to Limit per Window
+type Rule struct {
+	Name   string
+	Key    func(c *gin.Context) string
+	Limit  int
+	Window time.Duration
+}
+
+// Config configures the rate limiting middleware
+type Config struct {
+	// Prefix namespaces the counter keys, e.g. "magic-link"
+	Prefix string
+	// Rules are checked in order on every request; the first one exceeded rejects it
+	Rules []Rule
+	// InvalidAttempts, when set, counts requests that ended in one of
+	// InvalidStatuses or were flagged with MarkInvalid, and rejects further
+	// requests from the same key once the limit is reached. This stops token
+	// enumeration.
+	InvalidAttempts *Rule
+	InvalidStatuses []int
+	// OnLimited is called whenever a request is rejected
+	OnLimited func(c *gin.Context, rule Rule)
+}
+
+// ByIP keys requests by client IP
+func ByIP(c *gin.Context) string {
+	return c.ClientIP()
+}
+
+// ByParam keys requests by a route parameter, e.g. the magic link token
+func ByParam(name string) func(c *gin.Context) string {
+	return func(c *gin.Context) string {
+		return c.Param(name)
+	}
+}
+
+// Middleware rejects requests over any of the configured limits with 429.
+// Store errors fail open so a database hiccup doesn't take the endpoints down.
+func Middleware(store Store, cfg Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+
+		if rule := cfg.InvalidAttempts; rule != nil {
+			if key := rule.Key(c); key != "" {
+				counter, err := store.Get(ctx, counterKey(cfg.Prefix, *rule, key), rule.Window)
+				if err != nil {
+					log.Printf("Warning: rate limit check failed: %v", err)
+				} else if counter.Count >= rule.Limit {
+					reject(c, cfg, *rule, counter)
+					return
+				}
+			}
+		}
+
+		for _, rule := range cfg.Rules {
+			key := rule.Key(c)
+			if key == "" {
+				continue
+			}
+			counter, err := store.Increment(ctx, counterKey(cfg.Prefix, rule, key), rule.Window)
+			if err != nil {
+				log.Printf("Warning: rate limit check failed: %v", err)
+				continue
+			}
+			if counter.Count > rule.Limit {
+				reject(c, cfg, rule, counter)
+				return
+			}
+		}
+
+		c.Next()
+
+		invalid := c.GetBool(invalidAttemptContextKey) || isInvalidStatus(c.Writer.Status(), cfg.InvalidStatuses)
+		if rule := cfg.InvalidAttempts; rule != nil && invalid {
+			if key := rule.Key(c); key != "" {
+				if _, err := store.Increment(ctx, counterKey(cfg.Prefix, *rule, key), rule.Window); err != nil {
+					log.Printf("Warning: failed to record invalid attempt: %v", err)
+				}
+			}
+		}
+	}
+}
+
+func reject(c *gin.Context, cfg Config, rule Rule, counter Counter) {
+	if cfg.OnLimited != nil {
+		cfg.OnLimited(c, rule)
+	}
+
+	retryAfter := int(math.Ceil(counter.ResetIn.Seconds()))
+	if retryAfter < 1 {
+		retryAfter = 1
+	}
+	c.Header("Retry-After", strconv.Itoa(retryAfter))
+	c.JSON(http.StatusTooManyRequests, gin.H{
+		"success": false,
+		"error":   "Too many requests, please try again later",
+		"code":    "RATE_LIMITED",
+	})
+	c.Abort()
+}
+
+func counterKey(prefix string, rule Rule, key string) string {
+	return prefix + ":" + rule.Name + ":" + key
+}
+
+func isInvalidStatus(status int, statuses []int) bool {
+	for _, s := range statuses {
+		if status == s {
+			return true
+		}
+	}
+	return false
+}
+
+const invalidAttemptContextKey = "rate_limit_invalid_attempt"
+
+// MarkInvalid flags the current request as an invalid attempt for handlers
+// that report failures without an error status (e.g. token validation)
+func MarkInvalid(c *gin.Context) {
+	c.Set(invalidAttemptContextKey, true)
+}
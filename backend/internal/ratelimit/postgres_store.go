@@ -0,0 +1,91 @@
+package ratelimit
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// PostgresStore keeps counters in the rate_limit_buckets table so that they are
+// shared across Lambda instances
+type PostgresStore struct {
+	db *sql.DB
+
+	mu          sync.Mutex
+	lastCleanup time.Time
+}
+
+func NewPostgresStore(db *sql.DB) *PostgresStore {
+	return &PostgresStore{db: db}
+}
+
+func (s *PostgresStore) Increment(ctx context.Context, key string, window time.Duration) (Counter, error) {
+	s.cleanup(ctx)
+
+	// Reset the window in the same statement when the previous one has elapsed,
+	// so concurrent requests never lose a hit
+	query := `
+		INSERT INTO rate_limit_buckets (key, window_start, count)
+		VALUES ($1, NOW(), 1)
+		ON CONFLICT (key) DO UPDATE SET
+			count = CASE
+				WHEN rate_limit_buckets.window_start <= NOW() - make_interval(secs => $2) THEN 1
+				ELSE rate_limit_buckets.count + 1
+			END,
+			window_start = CASE
+				WHEN rate_limit_buckets.window_start <= NOW() - make_interval(secs => $2) THEN NOW()
+				ELSE rate_limit_buckets.window_start
+			END
+		RETURNING count, EXTRACT(EPOCH FROM (window_start + make_interval(secs => $2) - NOW()))::float8
+	`
+
+	var counter Counter
+	var resetIn float64
+	err := s.db.QueryRowContext(ctx, query, key, window.Seconds()).Scan(&counter.Count, &resetIn)
+	if err != nil {
+		return Counter{}, fmt.Errorf("failed to increment rate limit counter: %w", err)
+	}
+	counter.ResetIn = time.Duration(resetIn * float64(time.Second))
+
+	return counter, nil
+}
+
+func (s *PostgresStore) Get(ctx context.Context, key string, window time.Duration) (Counter, error) {
+	query := `
+		SELECT count, EXTRACT(EPOCH FROM (window_start + make_interval(secs => $2) - NOW()))::float8
+		FROM rate_limit_buckets
+		WHERE key = $1 AND window_start > NOW() - make_interval(secs => $2)
+	`
+
+	var counter Counter
+	var resetIn float64
+	err := s.db.QueryRowContext(ctx, query, key, window.Seconds()).Scan(&counter.Count, &resetIn)
+	if err == sql.ErrNoRows {
+		return Counter{}, nil
+	}
+	if err != nil {
+		return Counter{}, fmt.Errorf("failed to get rate limit counter: %w", err)
+	}
+	counter.ResetIn = time.Duration(resetIn * float64(time.Second))
+
+	return counter, nil
+}
+
+// cleanup deletes stale buckets at most once per sweepInterval per instance
+func (s *PostgresStore) cleanup(ctx context.Context) {
+	s.mu.Lock()
+	if time.Since(s.lastCleanup) < sweepInterval {
+		s.mu.Unlock()
+		return
+	}
+	s.lastCleanup = time.Now()
+	s.mu.Unlock()
+
+	query := `DELETE FROM rate_limit_buckets WHERE window_start < NOW() - make_interval(secs => $1)`
+	if _, err := s.db.ExecContext(ctx, query, staleAfter.Seconds()); err != nil {
+		log.Printf("Warning: failed to clean up rate limit buckets: %v", err)
+	}
+}
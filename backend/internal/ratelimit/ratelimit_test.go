@@ -0,0 +1,100 @@
+package ratelimit
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryStore_WindowResets(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	store := NewMemoryStore()
+	store.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	for i := 1; i <= 3; i++ {
+		counter, err := store.Increment(ctx, "k", time.Minute)
+		require.NoError(t, err)
+		assert.Equal(t, i, counter.Count)
+	}
+
+	now = now.Add(30 * time.Second)
+	counter, err := store.Get(ctx, "k", time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, 3, counter.Count)
+	assert.Equal(t, 30*time.Second, counter.ResetIn)
+
+	now = now.Add(31 * time.Second)
+	counter, err = store.Get(ctx, "k", time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, 0, counter.Count)
+
+	counter, err = store.Increment(ctx, "k", time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, 1, counter.Count)
+}
+
+func newTestRouter(cfg Config) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	group := r.Group("/links/:token")
+	group.Use(Middleware(NewMemoryStore(), cfg))
+	group.GET("/ok", func(c *gin.Context) { c.Status(http.StatusOK) })
+	group.GET("/invalid", func(c *gin.Context) { c.Status(http.StatusUnauthorized) })
+	group.GET("/marked", func(c *gin.Context) {
+		MarkInvalid(c)
+		c.Status(http.StatusOK)
+	})
+	return r
+}
+
+func get(r *gin.Engine, path string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	req.RemoteAddr = "203.0.113.7:1234"
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestMiddleware_RejectsOverLimitPerToken(t *testing.T) {
+	var limited []string
+	r := newTestRouter(Config{
+		Prefix: "test",
+		Rules:  []Rule{{Name: "token", Key: ByParam("token"), Limit: 2, Window: time.Minute}},
+		OnLimited: func(c *gin.Context, rule Rule) {
+			limited = append(limited, rule.Name+":"+c.Param("token"))
+		},
+	})
+
+	assert.Equal(t, http.StatusOK, get(r, "/links/a/ok").Code)
+	assert.Equal(t, http.StatusOK, get(r, "/links/a/ok").Code)
+
+	w := get(r, "/links/a/ok")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.NotEmpty(t, w.Header().Get("Retry-After"))
+	assert.Contains(t, w.Body.String(), "RATE_LIMITED")
+
+	// Other tokens have their own counter
+	assert.Equal(t, http.StatusOK, get(r, "/links/b/ok").Code)
+	assert.Equal(t, []string{"token:a"}, limited)
+}
+
+func TestMiddleware_InvalidAttemptsBlockIP(t *testing.T) {
+	r := newTestRouter(Config{
+		Prefix:          "test",
+		InvalidAttempts: &Rule{Name: "invalid", Key: ByIP, Limit: 2, Window: time.Minute},
+		InvalidStatuses: []int{http.StatusUnauthorized},
+	})
+
+	assert.Equal(t, http.StatusUnauthorized, get(r, "/links/x/invalid").Code)
+	assert.Equal(t, http.StatusOK, get(r, "/links/y/marked").Code)
+
+	// Both invalid attempts came from the same IP; even a valid request is now rejected
+	assert.Equal(t, http.StatusTooManyRequests, get(r, "/links/z/ok").Code)
+}
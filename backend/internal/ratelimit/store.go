@@ -0,0 +1,94 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Counter is the state of a fixed-window counter
+type Counter struct {
+	Count   int
+	ResetIn time.Duration
+}
+
+// Store keeps fixed-window hit counters keyed by an arbitrary string.
+// The in-memory store is fine for a single server; Lambda needs the Postgres
+// store so that every instance sees the same counters.
+type Store interface {
+	// Increment records a hit for key and returns the counter for the current window
+	Increment(ctx context.Context, key string, window time.Duration) (Counter, error)
+	// Get returns the counter for the current window without recording a hit
+	Get(ctx context.Context, key string, window time.Duration) (Counter, error)
+}
+
+type memoryBucket struct {
+	windowStart time.Time
+	count       int
+}
+
+// MemoryStore is an in-process Store
+type MemoryStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*memoryBucket
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		buckets: make(map[string]*memoryBucket),
+		now:     time.Now,
+	}
+}
+
+func (s *MemoryStore) Increment(ctx context.Context, key string, window time.Duration) (Counter, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.sweep(now)
+
+	bucket, ok := s.buckets[key]
+	if !ok || !now.Before(bucket.windowStart.Add(window)) {
+		bucket = &memoryBucket{windowStart: now}
+		s.buckets[key] = bucket
+	}
+	bucket.count++
+
+	return Counter{Count: bucket.count, ResetIn: bucket.windowStart.Add(window).Sub(now)}, nil
+}
+
+func (s *MemoryStore) Get(ctx context.Context, key string, window time.Duration) (Counter, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	bucket, ok := s.buckets[key]
+	if !ok || !now.Before(bucket.windowStart.Add(window)) {
+		return Counter{}, nil
+	}
+
+	return Counter{Count: bucket.count, ResetIn: bucket.windowStart.Add(window).Sub(now)}, nil
+}
+
+// sweep drops buckets that have been idle for longer than staleAfter so the
+// map does not grow without bound. Callers must hold s.mu.
+func (s *MemoryStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < sweepInterval {
+		return
+	}
+	s.lastSweep = now
+
+	for key, bucket := range s.buckets {
+		if now.Sub(bucket.windowStart) > staleAfter {
+			delete(s.buckets, key)
+		}
+	}
+}
+
+const (
+	sweepInterval = 10 * time.Minute
+	// staleAfter must be longer than the longest rate limit window in use
+	staleAfter = 24 * time.Hour
+)
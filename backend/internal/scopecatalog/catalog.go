@@ -0,0 +1,270 @@
+// Package scopecatalog defines the scope categories a contractor can report
+// damage in, with the tags and dimensions each one accepts. The contractor
+// wizard renders its forms from the catalog, and submitted scope sheets are
+// validated against the same definitions.
+package scopecatalog
+
+// Version identifies the catalog definitions below: a date plus a sequence
+// number for that day. Bump it whenever a category, tag or dimension rule
+// changes; scope sheets record the version they were validated against.
+const Version = "2026-10-18.2"
+
+// Dimension units
+const (
+	UnitSquareFeet = "sq_ft"
+	UnitFeet       = "ft"
+	UnitLinearFeet = "lf"
+	UnitCount      = "ea"
+	UnitPitch      = "in_per_12"
+)
+
+// Category groups, used by the wizard to order and section the triage screen
+const (
+	GroupExterior   = "exterior"
+	GroupInterior   = "interior"
+	GroupMitigation = "mitigation"
+	GroupOther      = "other"
+)
+
+// Catalog is the full set of scope categories at one version
+type Catalog struct {
+	Version    string     `json:"version"`
+	Categories []Category `json:"categories"`
+}
+
+// Category is one kind of damaged area, e.g. a roof or a kitchen
+type Category struct {
+	Key        string      `json:"key"`
+	Label      string      `json:"label"`
+	Group      string      `json:"group"`
+	Tags       []Tag       `json:"tags"`
+	Dimensions []Dimension `json:"dimensions"`
+}
+
+// Tag is a damage observation a contractor can select for a category
+type Tag struct {
+	Key   string `json:"key"`
+	Label string `json:"label"`
+}
+
+// Dimension is a measurement a category accepts. Required dimensions must be
+// greater than zero on submission; drafts may leave them blank.
+type Dimension struct {
+	Key      string  `json:"key"`
+	Label    string  `json:"label"`
+	Unit     string  `json:"unit"`
+	Required bool    `json:"required"`
+	Max      float64 `json:"max"`
+}
+
+var (
+	tagDrywall  = Tag{Key: "Drywall_Damaged", Label: "Drywall Damaged"}
+	tagCeiling  = Tag{Key: "Ceiling_Damaged", Label: "Ceiling Damaged"}
+	tagFlooring = Tag{Key: "Flooring_Damaged", Label: "Flooring Damaged"}
+	tagPaint    = Tag{Key: "Paint_Needed", Label: "Paint Needed"}
+	tagFascia   = Tag{Key: "Fascia_Damaged", Label: "Fascia Damaged"}
+	tagSoffit   = Tag{Key: "Soffit_Damaged", Label: "Soffit Damaged"}
+	tagGutters  = Tag{Key: "Gutters_Damaged", Label: "Gutters Damaged"}
+
+	dimSquareFootage = Dimension{Key: "square_footage", Label: "Square Footage", Unit: UnitSquareFeet, Required: true, Max: 100000}
+	dimRoomLength    = Dimension{Key: "length", Label: "Length", Unit: UnitFeet, Required: true, Max: 200}
+	dimRoomWidth     = Dimension{Key: "width", Label: "Width", Unit: UnitFeet, Required: true, Max: 200}
+	dimCeilingHeight = Dimension{Key: "ceiling_height", Label: "Ceiling Height", Unit: UnitFeet, Max: 30}
+)
+
+// roomDimensions are shared by every interior room
+var roomDimensions = []Dimension{dimRoomLength, dimRoomWidth, dimCeilingHeight}
+
+var current = Catalog{
+	Version: Version,
+	Categories: []Category{
+		{
+			Key:   "roof",
+			Label: "Roof",
+			Group: GroupExterior,
+			Tags: []Tag{
+				{Key: "Type_3Tab_Shingle", Label: "3-Tab Shingle"},
+				{Key: "Type_Architectural_Shingle", Label: "Architectural Shingle"},
+				{Key: "Type_Metal", Label: "Metal Roof"},
+				{Key: "Pitch_Steep", Label: "Steep Pitch"},
+				{Key: "Shingles_Damaged", Label: "Shingles Damaged"},
+				{Key: "Underlayment_Torn", Label: "Underlayment Torn"},
+				{Key: "Decking_Damaged", Label: "Decking Damaged"},
+				{Key: "Vents_Damaged", Label: "Vents Damaged"},
+				{Key: "Flashing_Missing", Label: "Flashing Missing"},
+				tagGutters,
+				tagFascia,
+				tagSoffit,
+				{Key: "Accessories_Damaged", Label: "Accessories Damaged"},
+			},
+			Dimensions: []Dimension{
+				dimSquareFootage,
+				{Key: "pitch", Label: "Pitch (rise per 12)", Unit: UnitPitch, Max: 24},
+				{Key: "eave_length", Label: "Eave Length", Unit: UnitLinearFeet, Max: 2000},
+				{Key: "rake_length", Label: "Rake Length", Unit: UnitLinearFeet, Max: 2000},
+				{Key: "ridge_length", Label: "Ridge & Hip Length", Unit: UnitLinearFeet, Max: 2000},
+				{Key: "stories", Label: "Stories", Unit: UnitCount, Max: 10},
+			},
+		},
+		{
+			Key:   "exterior_walls",
+			Label: "Exterior Walls & Siding",
+			Group: GroupExterior,
+			Tags: []Tag{
+				{Key: "Siding_Damaged", Label: "Siding Damaged"},
+				{Key: "Siding_Paint_Needed", Label: "Siding Paint Needed"},
+				tagFascia,
+				tagSoffit,
+				tagGutters,
+				{Key: "Window_Broken", Label: "Window Broken"},
+				{Key: "Door_Damaged", Label: "Door Damaged"},
+				{Key: "Trim_Damaged", Label: "Trim Damaged"},
+			},
+			Dimensions: []Dimension{
+				dimSquareFootage,
+				{Key: "window_count", Label: "Windows", Unit: UnitCount, Max: 200},
+				{Key: "door_count", Label: "Doors", Unit: UnitCount, Max: 50},
+			},
+		},
+		{
+			Key:   "gutters",
+			Label: "Gutters & Downspouts",
+			Group: GroupExterior,
+			Tags: []Tag{
+				{Key: "Gutters_Dented", Label: "Gutters Dented"},
+				{Key: "Gutters_Detached", Label: "Gutters Detached"},
+				{Key: "Downspouts_Damaged", Label: "Downspouts Damaged"},
+				{Key: "Guards_Damaged", Label: "Gutter Guards Damaged"},
+			},
+			Dimensions: []Dimension{
+				{Key: "linear_feet", Label: "Gutter Length", Unit: UnitLinearFeet, Required: true, Max: 2000},
+				{Key: "downspouts", Label: "Downspouts", Unit: UnitCount, Max: 50},
+			},
+		},
+		{
+			Key:   "interior_kitchen",
+			Label: "Kitchen",
+			Group: GroupInterior,
+			Tags: []Tag{
+				tagDrywall,
+				tagCeiling,
+				tagFlooring,
+				tagPaint,
+				{Key: "Cabinets_Damaged", Label: "Cabinets Damaged"},
+				{Key: "Countertops_Damaged", Label: "Countertops Damaged"},
+				{Key: "Appliances_Damaged", Label: "Appliances Damaged"},
+			},
+			Dimensions: roomDimensions,
+		},
+		{
+			Key:   "interior_bathroom",
+			Label: "Bathroom",
+			Group: GroupInterior,
+			Tags: []Tag{
+				tagDrywall,
+				tagCeiling,
+				tagFlooring,
+				tagPaint,
+				{Key: "Fixtures_Damaged", Label: "Fixtures Damaged"},
+				{Key: "Vanity_Damaged", Label: "Vanity Damaged"},
+			},
+			Dimensions: roomDimensions,
+		},
+		{
+			Key:        "interior_living",
+			Label:      "Living Room",
+			Group:      GroupInterior,
+			Tags:       []Tag{tagDrywall, tagCeiling, tagFlooring, tagPaint},
+			Dimensions: roomDimensions,
+		},
+		{
+			Key:        "interior_bedroom",
+			Label:      "Bedroom",
+			Group:      GroupInterior,
+			Tags:       []Tag{tagDrywall, tagCeiling, tagFlooring, tagPaint},
+			Dimensions: roomDimensions,
+		},
+		{
+			Key:   "interior_other",
+			Label: "Other Room",
+			Group: GroupInterior,
+			Tags: []Tag{
+				tagDrywall,
+				tagCeiling,
+				tagFlooring,
+				tagPaint,
+				{Key: "Doors_Damaged", Label: "Interior Doors Damaged"},
+				{Key: "Trim_Damaged", Label: "Trim Damaged"},
+			},
+			Dimensions: roomDimensions,
+		},
+		{
+			Key:   "water_mitigation",
+			Label: "Water Mitigation",
+			Group: GroupMitigation,
+			Tags: []Tag{
+				{Key: "Standing_Water_Present", Label: "Standing Water Present"},
+				{Key: "Baseboards_Swollen", Label: "Baseboards Swollen"},
+				{Key: "Drywall_Cuts_Needed", Label: "Drywall Cuts Needed"},
+				{Key: "Dehumidifiers_Needed", Label: "Dehumidifiers Needed"},
+				{Key: "Air_Movers_Needed", Label: "Air Movers Needed"},
+			},
+			Dimensions: []Dimension{
+				{Key: "affected_square_footage", Label: "Affected Area", Unit: UnitSquareFeet, Max: 100000},
+				{Key: "drying_days", Label: "Drying Days", Unit: UnitCount, Max: 30},
+			},
+		},
+		{
+			Key:   "fencing_other",
+			Label: "Fencing / Other",
+			Group: GroupOther,
+			Tags: []Tag{
+				{Key: "Fence_Sections_Damaged", Label: "Fence Sections Damaged"},
+				{Key: "Gate_Damaged", Label: "Gate Damaged"},
+				{Key: "Posts_Broken", Label: "Posts Broken"},
+			},
+			Dimensions: []Dimension{
+				{Key: "linear_feet", Label: "Fence Length", Unit: UnitLinearFeet, Max: 5000},
+			},
+		},
+	},
+}
+
+var categoriesByKey = func() map[string]*Category {
+	m := make(map[string]*Category, len(current.Categories))
+	for i := range current.Categories {
+		m[current.Categories[i].Key] = &current.Categories[i]
+	}
+	return m
+}()
+
+// Current returns the catalog at the current Version
+func Current() Catalog {
+	return current
+}
+
+// Lookup returns the category with the given key
+func Lookup(key string) (*Category, bool) {
+	category, ok := categoriesByKey[key]
+	return category, ok
+}
+
+// Tag returns the category's tag with the given key
+func (c *Category) Tag(key string) (Tag, bool) {
+	for _, tag := range c.Tags {
+		if tag.Key == key {
+			return tag, true
+		}
+	}
+	return Tag{}, false
+}
+
+// Dimension returns the category's dimension with the given key
+func (c *Category) Dimension(key string) (Dimension, bool) {
+	for _, dimension := range c.Dimensions {
+		if dimension.Key == key {
+			return dimension, true
+		}
+	}
+	return Dimension{}, false
+}
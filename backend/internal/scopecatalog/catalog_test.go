@@ -0,0 +1,89 @@
+package scopecatalog
+
+import (
+	"testing"
+
+	"github.com/claimcoach/backend/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCatalog_KeysAreUnique(t *testing.T) {
+	seen := map[string]bool{}
+	for _, category := range Current().Categories {
+		assert.False(t, seen[category.Key], "duplicate category %s", category.Key)
+		seen[category.Key] = true
+
+		tags := map[string]bool{}
+		for _, tag := range category.Tags {
+			assert.False(t, tags[tag.Key], "duplicate tag %s in %s", tag.Key, category.Key)
+			tags[tag.Key] = true
+		}
+		for _, dimension := range category.Dimensions {
+			assert.Greater(t, dimension.Max, 0.0, "%s.%s needs a max", category.Key, dimension.Key)
+		}
+	}
+}
+
+func TestValidate_DraftAllowsMissingDimensions(t *testing.T) {
+	areas := []models.ScopeArea{
+		{ID: "a1", CategoryKey: "interior_kitchen", Tags: []string{"Cabinets_Damaged"}, Dimensions: map[string]float64{"length": 12}},
+	}
+
+	assert.NoError(t, Validate(areas, []string{"interior_kitchen"}, nil, false))
+
+	err := Validate(areas, []string{"interior_kitchen"}, nil, true)
+	var validationErr *ValidationError
+	require.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, []FieldError{{
+		Field:   "areas[0].dimensions.width",
+		Code:    CodeRequired,
+		Message: "Width is required for Kitchen",
+	}}, validationErr.Fields)
+}
+
+func TestValidate_ReportsEveryInvalidField(t *testing.T) {
+	areas := []models.ScopeArea{
+		{ID: "a1", CategoryKey: "roof", Tags: []string{"Shingles_Damaged", "Cabinets_Damaged"},
+			Dimensions: map[string]float64{"square_footage": -5, "length": 10}},
+		{ID: "a1", CategoryKey: "pool"},
+		{ID: "", CategoryKey: ""},
+	}
+
+	err := Validate(areas, []string{"roof", "pool"}, nil, false)
+
+	var validationErr *ValidationError
+	require.ErrorAs(t, err, &validationErr)
+	fields := map[string]string{}
+	for _, f := range validationErr.Fields {
+		fields[f.Field] = f.Code
+	}
+	assert.Equal(t, map[string]string{
+		"triage_selections[1]":               CodeUnknownCategory,
+		"areas[0].tags[1]":                   CodeUnknownTag,
+		"areas[0].dimensions.length":         CodeUnknownDim,
+		"areas[0].dimensions.square_footage": CodeOutOfRange,
+		"areas[1].id":                        CodeDuplicate,
+		"areas[1].category_key":              CodeUnknownCategory,
+		"areas[2].id":                        CodeRequired,
+		"areas[2].category_key":              CodeRequired,
+	}, fields)
+}
+
+func TestValidate_SubmissionNeedsAnArea(t *testing.T) {
+	err := Validate(nil, nil, nil, true)
+
+	var validationErr *ValidationError
+	require.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, "areas", validationErr.Fields[0].Field)
+	assert.NoError(t, Validate(nil, nil, nil, false))
+}
+
+func TestNormalize_UsesCatalogLabels(t *testing.T) {
+	areas := []models.ScopeArea{{CategoryKey: "exterior_walls", Category: "Exterior Walls"}, {CategoryKey: "pool", Category: "Pool"}}
+
+	Normalize(areas)
+
+	assert.Equal(t, "Exterior Walls & Siding", areas[0].Category)
+	assert.Equal(t, "Pool", areas[1].Category)
+}
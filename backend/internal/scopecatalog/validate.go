@@ -0,0 +1,174 @@
+package scopecatalog
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/claimcoach/backend/internal/models"
+)
+
+// Limits on free-form scope sheet content
+const (
+	MaxAreas             = 50
+	MaxAreaNotesLength   = 2000
+	MaxGeneralNoteLength = 5000
+)
+
+// Field error codes
+const (
+	CodeRequired        = "required"
+	CodeUnknownCategory = "unknown_category"
+	CodeUnknownTag      = "unknown_tag"
+	CodeUnknownDim      = "unknown_dimension"
+	CodeOutOfRange      = "out_of_range"
+	CodeDuplicate       = "duplicate"
+	CodeTooLong         = "too_long"
+	CodeTooMany         = "too_many"
+)
+
+// FieldError describes one invalid field. Field is a path into the request
+// body, e.g. "areas[2].dimensions.length".
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// ValidationError is returned when a scope sheet doesn't match the catalog
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	if len(e.Fields) == 1 {
+		return fmt.Sprintf("invalid scope sheet: %s: %s", e.Fields[0].Field, e.Fields[0].Message)
+	}
+	return fmt.Sprintf("invalid scope sheet: %d invalid fields", len(e.Fields))
+}
+
+// Validate checks a scope sheet against the current catalog. Drafts are only
+// checked for values that could never be valid; a submission must also be
+// complete, with at least one area and every required dimension filled in.
+// It returns a *ValidationError listing every invalid field, or nil.
+func Validate(areas []models.ScopeArea, triageSelections []string, generalNotes *string, submit bool) error {
+	var v validator
+
+	for i, key := range triageSelections {
+		if _, ok := Lookup(key); !ok {
+			v.add(fmt.Sprintf("triage_selections[%d]", i), CodeUnknownCategory, fmt.Sprintf("unknown category %q", key))
+		}
+	}
+
+	if submit && len(areas) == 0 {
+		v.add("areas", CodeRequired, "at least one area is required")
+	}
+	if len(areas) > MaxAreas {
+		v.add("areas", CodeTooMany, fmt.Sprintf("at most %d areas are allowed", MaxAreas))
+	}
+
+	seenIDs := make(map[string]bool, len(areas))
+	for i, area := range areas {
+		field := fmt.Sprintf("areas[%d]", i)
+
+		switch {
+		case strings.TrimSpace(area.ID) == "":
+			v.add(field+".id", CodeRequired, "area id is required")
+		case seenIDs[area.ID]:
+			v.add(field+".id", CodeDuplicate, fmt.Sprintf("area id %q is used more than once", area.ID))
+		}
+		seenIDs[area.ID] = true
+
+		if len(area.Notes) > MaxAreaNotesLength {
+			v.add(field+".notes", CodeTooLong, fmt.Sprintf("notes must be at most %d characters", MaxAreaNotesLength))
+		}
+
+		category, ok := Lookup(area.CategoryKey)
+		if !ok {
+			if area.CategoryKey == "" {
+				v.add(field+".category_key", CodeRequired, "category is required")
+			} else {
+				v.add(field+".category_key", CodeUnknownCategory, fmt.Sprintf("unknown category %q", area.CategoryKey))
+			}
+			continue
+		}
+
+		v.validateTags(field, category, area.Tags)
+		v.validateDimensions(field, category, area.Dimensions, submit)
+	}
+
+	if generalNotes != nil && len(*generalNotes) > MaxGeneralNoteLength {
+		v.add("general_notes", CodeTooLong, fmt.Sprintf("general notes must be at most %d characters", MaxGeneralNoteLength))
+	}
+
+	if len(v.fields) == 0 {
+		return nil
+	}
+	return &ValidationError{Fields: v.fields}
+}
+
+// Normalize fills in each area's category label from the catalog so the label
+// always matches the key
+func Normalize(areas []models.ScopeArea) {
+	for i := range areas {
+		if category, ok := Lookup(areas[i].CategoryKey); ok {
+			areas[i].Category = category.Label
+		}
+	}
+}
+
+type validator struct {
+	fields []FieldError
+}
+
+func (v *validator) add(field, code, message string) {
+	v.fields = append(v.fields, FieldError{Field: field, Code: code, Message: message})
+}
+
+func (v *validator) validateTags(field string, category *Category, tags []string) {
+	seen := make(map[string]bool, len(tags))
+	for j, key := range tags {
+		tagField := fmt.Sprintf("%s.tags[%d]", field, j)
+		if _, ok := category.Tag(key); !ok {
+			v.add(tagField, CodeUnknownTag, fmt.Sprintf("%q is not a %s tag", key, category.Label))
+			continue
+		}
+		if seen[key] {
+			v.add(tagField, CodeDuplicate, fmt.Sprintf("tag %q is selected more than once", key))
+		}
+		seen[key] = true
+	}
+}
+
+func (v *validator) validateDimensions(field string, category *Category, dimensions map[string]float64, submit bool) {
+	keys := make([]string, 0, len(dimensions))
+	for key := range dimensions {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		value := dimensions[key]
+		dimField := fmt.Sprintf("%s.dimensions.%s", field, key)
+		dimension, ok := category.Dimension(key)
+		if !ok {
+			v.add(dimField, CodeUnknownDim, fmt.Sprintf("%s does not take a %q measurement", category.Label, key))
+			continue
+		}
+		// Zero means "not measured yet"; the required check below catches it on submission
+		if math.IsNaN(value) || value < 0 || value > dimension.Max {
+			v.add(dimField, CodeOutOfRange, fmt.Sprintf("%s must be between 0 and %g %s", dimension.Label, dimension.Max, dimension.Unit))
+		}
+	}
+
+	if !submit {
+		return
+	}
+	for _, dimension := range category.Dimensions {
+		if dimension.Required && dimensions[dimension.Key] <= 0 {
+			v.add(fmt.Sprintf("%s.dimensions.%s", field, dimension.Key), CodeRequired,
+				fmt.Sprintf("%s is required for %s", dimension.Label, category.Label))
+		}
+	}
+}
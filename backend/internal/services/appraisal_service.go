@@ -0,0 +1,763 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/claimcoach/backend/internal/models"
+	"github.com/claimcoach/backend/internal/prompts"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrAppraisalNotFound          = errors.New("appraisal not found")
+	ErrAppraisalInProgress        = errors.New("an appraisal is already open on this claim")
+	ErrInvalidAppraisalTransition = errors.New("invalid appraisal status transition")
+	ErrInvalidAppraisalUpdate     = errors.New("invalid appraisal update")
+)
+
+const (
+	// appraiserSelectionDays is how long the standard appraisal condition gives
+	// each side to name its appraiser after the written demand
+	appraiserSelectionDays = 20
+	// umpireSelectionDays is how long the appraisers have to agree on an umpire
+	// before either side may ask a court to appoint one
+	umpireSelectionDays = 15
+	// appraisalLetterMaxTokens bounds the demand letter
+	appraisalLetterMaxTokens = 2048
+)
+
+// appraisalTransitions are the statuses each appraisal status may move to.
+// Appraisers and the umpire advance the status as they are named; an award
+// can be entered once both appraisers are named, since an umpire is only
+// needed when they disagree.
+var appraisalTransitions = map[string][]string{
+	models.AppraisalDraft:              {models.AppraisalInvoked, models.AppraisalWithdrawn},
+	models.AppraisalInvoked:            {models.AppraisalAppraisersSelected, models.AppraisalWithdrawn},
+	models.AppraisalAppraisersSelected: {models.AppraisalUmpireSelected, models.AppraisalAwarded, models.AppraisalWithdrawn},
+	models.AppraisalUmpireSelected:     {models.AppraisalAwarded, models.AppraisalWithdrawn},
+	models.AppraisalAwarded:            {},
+	models.AppraisalWithdrawn:          {},
+}
+
+// appraisalCoverages names the coverages an award can set an amount for
+var appraisalCoverages = map[string]string{
+	models.CoverageDwelling:         "Coverage A (Dwelling)",
+	models.CoverageOtherStructures:  "Coverage B (Other Structures)",
+	models.CoveragePersonalProperty: "Coverage C (Personal Property)",
+	models.CoverageLossOfUse:        "Coverage D (Loss of Use)",
+}
+
+// AppraisalService runs the appraisal clause workflow for disputed claims:
+// the demand letter, appraiser and umpire selection, and the award
+type AppraisalService struct {
+	db             *sql.DB
+	llmClient      LLMClient
+	claimService   *ClaimService
+	paymentService *PaymentService
+}
+
+// NewAppraisalService creates a new AppraisalService
+func NewAppraisalService(db *sql.DB, llmClient LLMClient, claimService *ClaimService, paymentService *PaymentService) *AppraisalService {
+	return &AppraisalService{
+		db:             db,
+		llmClient:      llmClient,
+		claimService:   claimService,
+		paymentService: paymentService,
+	}
+}
+
+// InvokeAppraisalInput describes the dispute the appraisal demand is about
+type InvokeAppraisalInput struct {
+	DisputedAmount *float64 `json:"disputed_amount"`
+	DisputeSummary *string  `json:"dispute_summary"`
+}
+
+// MarkAppraisalInvokedInput records when the demand reached the carrier.
+// InvokedAt defaults to now.
+type MarkAppraisalInvokedInput struct {
+	InvokedAt *time.Time `json:"invoked_at"`
+}
+
+// AppraisalPartyInput names an appraiser or the umpire
+type AppraisalPartyInput struct {
+	Name  string  `json:"name" binding:"required"`
+	Firm  *string `json:"firm"`
+	Email *string `json:"email"`
+	Phone *string `json:"phone"`
+}
+
+// UpdateAppraisalDeadlinesInput overrides the default selection deadlines
+// where the policy's appraisal condition sets different ones
+type UpdateAppraisalDeadlinesInput struct {
+	AppraiserDeadline *time.Time `json:"appraiser_deadline"`
+	UmpireDeadline    *time.Time `json:"umpire_deadline"`
+}
+
+// RecordAppraisalAwardInput is the award signed by two of the three panel
+// members. AwardedAt defaults to now.
+type RecordAppraisalAwardInput struct {
+	Award     []models.AppraisalAwardLine `json:"award" binding:"required"`
+	AwardedAt *time.Time                  `json:"awarded_at"`
+}
+
+const appraisalColumns = `id, claim_id, status, disputed_amount, dispute_summary, demand_letter,
+	demand_prompt_version, invoked_at, appraiser_deadline, umpire_deadline, award, award_rcv_total, award_acv_total,
+	awarded_at, acv_payment_id, rcv_payment_id, created_by_user_id, created_at, updated_at`
+
+func scanAppraisal(row interface{ Scan(...interface{}) error }) (*models.Appraisal, error) {
+	var a models.Appraisal
+	var award []byte
+	err := row.Scan(
+		&a.ID, &a.ClaimID, &a.Status, &a.DisputedAmount, &a.DisputeSummary, &a.DemandLetter,
+		&a.DemandPromptVersion, &a.InvokedAt, &a.AppraiserDeadline, &a.UmpireDeadline, &award, &a.AwardRCVTotal, &a.AwardACVTotal,
+		&a.AwardedAt, &a.ACVPaymentID, &a.RCVPaymentID, &a.CreatedByUserID, &a.CreatedAt, &a.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(award, &a.Award); err != nil {
+		return nil, fmt.Errorf("invalid appraisal award: %w", err)
+	}
+	a.Parties = []models.AppraisalParty{}
+	return &a, nil
+}
+
+// InvokeAppraisal drafts an appraisal demand letter from the claim, its policy
+// and its payments, and opens an appraisal for the PM to send
+func (s *AppraisalService) InvokeAppraisal(ctx context.Context, claimID, organizationID, userID string, input InvokeAppraisalInput) (*models.Appraisal, error) {
+	if input.DisputedAmount != nil && *input.DisputedAmount < 0 {
+		return nil, fmt.Errorf("%w: disputed_amount must not be negative", ErrInvalidAppraisalUpdate)
+	}
+	if input.DisputeSummary != nil {
+		summary := strings.TrimSpace(*input.DisputeSummary)
+		input.DisputeSummary = &summary
+	}
+
+	if _, err := s.claimService.GetClaim(claimID, organizationID); err != nil {
+		return nil, err
+	}
+	var open int
+	err := s.db.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM appraisals
+		WHERE claim_id = $1 AND status NOT IN ($2, $3)`,
+		claimID, models.AppraisalAwarded, models.AppraisalWithdrawn,
+	).Scan(&open)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check open appraisals: %w", err)
+	}
+	if open > 0 {
+		return nil, ErrAppraisalInProgress
+	}
+
+	header, err := s.appraisalHeader(ctx, claimID)
+	if err != nil {
+		return nil, err
+	}
+	payments, err := s.paymentService.GetPaymentSummary(ctx, claimID, organizationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get payment summary: %w", err)
+	}
+	terms, err := confirmedPolicyTerms(ctx, s.db, claimID)
+	if err != nil {
+		return nil, err
+	}
+
+	rendered, err := prompts.Render(prompts.AppraisalDemand, appraisalDemandPromptData(header, payments, terms, input))
+	if err != nil {
+		return nil, err
+	}
+	response, err := s.llmClient.Chat(ctx, rendered.Messages(), 0.3, appraisalLetterMaxTokens)
+	if err != nil {
+		return nil, fmt.Errorf("LLM API call failed: %w", err)
+	}
+	if len(response.Choices) == 0 {
+		return nil, fmt.Errorf("LLM returned no choices")
+	}
+	letter := strings.TrimSpace(response.Choices[0].Message.Content)
+
+	now := time.Now()
+	created, err := scanAppraisal(s.db.QueryRowContext(ctx, `
+		INSERT INTO appraisals (
+			id, claim_id, status, disputed_amount, dispute_summary, demand_letter,
+			demand_prompt_version, created_by_user_id, created_at, updated_at
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING `+appraisalColumns,
+		uuid.New().String(), claimID, models.AppraisalDraft, input.DisputedAmount, input.DisputeSummary, letter,
+		rendered.Version, userID, now, now,
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to save appraisal: %w", err)
+	}
+
+	metadata := map[string]interface{}{
+		"appraisal_id":    created.ID,
+		"disputed_amount": created.DisputedAmount,
+	}
+	if err := s.logActivity(ctx, s.db, claimID, userID, "appraisal_demand_generated", "Appraisal demand letter generated", metadata); err != nil {
+		log.Printf("Warning: failed to log activity: %v", err)
+	}
+
+	return created, nil
+}
+
+// ListAppraisals returns a claim's appraisals, oldest first
+func (s *AppraisalService) ListAppraisals(ctx context.Context, claimID, organizationID string) ([]models.Appraisal, error) {
+	if _, err := s.claimService.GetClaim(claimID, organizationID); err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+appraisalColumns+`
+		FROM appraisals
+		WHERE claim_id = $1
+		ORDER BY created_at`, claimID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get appraisals: %w", err)
+	}
+	defer rows.Close()
+
+	appraisals := []models.Appraisal{}
+	for rows.Next() {
+		a, err := scanAppraisal(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan appraisal: %w", err)
+		}
+		appraisals = append(appraisals, *a)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate appraisals: %w", err)
+	}
+
+	for i := range appraisals {
+		parties, err := s.loadAppraisalParties(ctx, appraisals[i].ID)
+		if err != nil {
+			return nil, err
+		}
+		appraisals[i].Parties = parties
+	}
+
+	return appraisals, nil
+}
+
+// GetAppraisal returns one of a claim's appraisals with its parties
+func (s *AppraisalService) GetAppraisal(ctx context.Context, claimID, appraisalID, organizationID string) (*models.Appraisal, error) {
+	if _, err := s.claimService.GetClaim(claimID, organizationID); err != nil {
+		return nil, err
+	}
+	return s.getAppraisal(ctx, claimID, appraisalID)
+}
+
+// MarkAppraisalInvoked records that the demand was sent to the carrier and
+// starts the appraiser selection deadline
+func (s *AppraisalService) MarkAppraisalInvoked(ctx context.Context, claimID, appraisalID, organizationID, userID string, input MarkAppraisalInvokedInput) (*models.Appraisal, error) {
+	current, err := s.GetAppraisal(ctx, claimID, appraisalID, organizationID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkAppraisalTransition(current.Status, models.AppraisalInvoked); err != nil {
+		return nil, err
+	}
+
+	invokedAt := time.Now()
+	if input.InvokedAt != nil {
+		invokedAt = *input.InvokedAt
+	}
+	deadline := invokedAt.AddDate(0, 0, appraiserSelectionDays)
+
+	return s.updateAppraisal(ctx, current, userID, "appraisal_invoked",
+		fmt.Sprintf("Appraisal invoked; appraisers to be named by %s", deadline.Format("Jan 2, 2006")),
+		`status = $1, invoked_at = $2, appraiser_deadline = $3`,
+		models.AppraisalInvoked, invokedAt, deadline,
+	)
+}
+
+// SetAppraisalParty names our appraiser, the carrier's appraiser or the
+// umpire, replacing whoever held the role. Naming both appraisers starts the
+// umpire selection deadline.
+func (s *AppraisalService) SetAppraisalParty(ctx context.Context, claimID, appraisalID, organizationID, userID, role string, input AppraisalPartyInput) (*models.Appraisal, error) {
+	name := strings.TrimSpace(input.Name)
+	if name == "" {
+		return nil, fmt.Errorf("%w: name is required", ErrInvalidAppraisalUpdate)
+	}
+	switch role {
+	case models.AppraisalPartyOurAppraiser, models.AppraisalPartyCarrierAppraiser, models.AppraisalPartyUmpire:
+	default:
+		return nil, fmt.Errorf("%w: unknown party role %q", ErrInvalidAppraisalUpdate, role)
+	}
+
+	current, err := s.GetAppraisal(ctx, claimID, appraisalID, organizationID)
+	if err != nil {
+		return nil, err
+	}
+	switch current.Status {
+	case models.AppraisalInvoked, models.AppraisalAppraisersSelected, models.AppraisalUmpireSelected:
+	default:
+		return nil, fmt.Errorf("%w: parties can't be named while the appraisal is %s", ErrInvalidAppraisalTransition, current.Status)
+	}
+	if role == models.AppraisalPartyUmpire && current.Status == models.AppraisalInvoked {
+		return nil, fmt.Errorf("%w: the umpire is chosen after both appraisers are named", ErrInvalidAppraisalTransition)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO appraisal_parties (id, appraisal_id, role, name, firm, email, phone, selected_at, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, NOW(), NOW(), NOW())
+		ON CONFLICT (appraisal_id, role) DO UPDATE SET
+			name = EXCLUDED.name,
+			firm = EXCLUDED.firm,
+			email = EXCLUDED.email,
+			phone = EXCLUDED.phone,
+			selected_at = NOW(),
+			updated_at = NOW()`,
+		uuid.New().String(), current.ID, role, name, input.Firm, input.Email, input.Phone,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save appraisal party: %w", err)
+	}
+
+	next := current.Status
+	switch {
+	case role == models.AppraisalPartyUmpire:
+		next = models.AppraisalUmpireSelected
+	case current.Status == models.AppraisalInvoked:
+		var appraisers int
+		err = tx.QueryRowContext(ctx, `
+			SELECT COUNT(*) FROM appraisal_parties
+			WHERE appraisal_id = $1 AND role IN ($2, $3)`,
+			current.ID, models.AppraisalPartyOurAppraiser, models.AppraisalPartyCarrierAppraiser,
+		).Scan(&appraisers)
+		if err != nil {
+			return nil, fmt.Errorf("failed to count appraisers: %w", err)
+		}
+		if appraisers == 2 {
+			next = models.AppraisalAppraisersSelected
+		}
+	}
+
+	// The status guard keeps a concurrent update from being overwritten
+	res, err := tx.ExecContext(ctx, `
+		UPDATE appraisals SET
+			status = $1,
+			umpire_deadline = CASE WHEN $1 = $2 AND status <> $2 THEN NOW() + make_interval(days => $3) ELSE umpire_deadline END,
+			updated_at = NOW()
+		WHERE id = $4 AND status = $5`,
+		next, models.AppraisalAppraisersSelected, umpireSelectionDays, current.ID, current.Status,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update appraisal: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return nil, fmt.Errorf("%w: appraisal was updated by someone else", ErrInvalidAppraisalTransition)
+	}
+
+	metadata := map[string]interface{}{
+		"appraisal_id": current.ID,
+		"role":         role,
+		"name":         name,
+		"old_status":   current.Status,
+		"new_status":   next,
+	}
+	description := fmt.Sprintf("%s named for appraisal: %s", appraisalRoleLabel(role), name)
+	if err := s.logActivity(ctx, tx, claimID, userID, "appraisal_party_selected", description, metadata); err != nil {
+		return nil, fmt.Errorf("failed to log appraisal activity: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit appraisal party: %w", err)
+	}
+	return s.getAppraisal(ctx, claimID, current.ID)
+}
+
+// UpdateAppraisalDeadlines replaces the appraiser or umpire selection deadline
+func (s *AppraisalService) UpdateAppraisalDeadlines(ctx context.Context, claimID, appraisalID, organizationID, userID string, input UpdateAppraisalDeadlinesInput) (*models.Appraisal, error) {
+	if input.AppraiserDeadline == nil && input.UmpireDeadline == nil {
+		return nil, fmt.Errorf("%w: appraiser_deadline or umpire_deadline is required", ErrInvalidAppraisalUpdate)
+	}
+	current, err := s.GetAppraisal(ctx, claimID, appraisalID, organizationID)
+	if err != nil {
+		return nil, err
+	}
+	if len(appraisalTransitions[current.Status]) == 0 {
+		return nil, fmt.Errorf("%w: the appraisal is %s", ErrInvalidAppraisalTransition, current.Status)
+	}
+
+	return s.updateAppraisal(ctx, current, userID, "appraisal_deadlines_updated", "Appraisal deadlines updated",
+		`appraiser_deadline = COALESCE($1, appraiser_deadline), umpire_deadline = COALESCE($2, umpire_deadline)`,
+		input.AppraiserDeadline, input.UmpireDeadline,
+	)
+}
+
+// WithdrawAppraisal closes an appraisal without an award
+func (s *AppraisalService) WithdrawAppraisal(ctx context.Context, claimID, appraisalID, organizationID, userID string) (*models.Appraisal, error) {
+	current, err := s.GetAppraisal(ctx, claimID, appraisalID, organizationID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkAppraisalTransition(current.Status, models.AppraisalWithdrawn); err != nil {
+		return nil, err
+	}
+
+	return s.updateAppraisal(ctx, current, userID, "appraisal_withdrawn", "Appraisal withdrawn",
+		`status = $1`, models.AppraisalWithdrawn,
+	)
+}
+
+// RecordAppraisalAward saves the award per coverage and adds what the carrier
+// still owes under it as expected payments: the ACV award less the deductible
+// and the ACV already expected, and the recoverable depreciation less the RCV
+// already expected
+func (s *AppraisalService) RecordAppraisalAward(ctx context.Context, claimID, appraisalID, organizationID, userID string, input RecordAppraisalAwardInput) (*models.Appraisal, error) {
+	award, rcvTotal, acvTotal, err := validateAppraisalAward(input.Award)
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := s.GetAppraisal(ctx, claimID, appraisalID, organizationID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkAppraisalTransition(current.Status, models.AppraisalAwarded); err != nil {
+		return nil, err
+	}
+
+	// Compare against the payments as they stood before the award
+	payments, err := s.paymentService.GetPaymentSummary(ctx, claimID, organizationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get payment summary: %w", err)
+	}
+	acvDue, rcvDue := appraisalPaymentsDue(rcvTotal, acvTotal, payments)
+
+	awardedAt := time.Now()
+	if input.AwardedAt != nil {
+		awardedAt = *input.AwardedAt
+	}
+	awardJSON, err := json.Marshal(award)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal appraisal award: %w", err)
+	}
+
+	due := []appraisalPaymentDue{
+		{models.PaymentTypeACV, acvDue, "acv_payment_id"},
+		{models.PaymentTypeRCV, rcvDue, "rcv_payment_id"},
+	}
+	updated, err := s.saveAppraisalAward(ctx, current, userID, string(awardJSON), rcvTotal, acvTotal, awardedAt, due)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.getAppraisal(ctx, claimID, updated.ID)
+}
+
+// appraisalPaymentDue is a balance the carrier owes under an award, linked
+// from the appraisal's column once it is added as an expected payment
+type appraisalPaymentDue struct {
+	paymentType string
+	amount      float64
+	column      string
+}
+
+// saveAppraisalAward moves an appraisal to awarded and adds the balances due
+// under the award as expected payments in one transaction, so a failure
+// leaves the appraisal unawarded and the award can be entered again
+func (s *AppraisalService) saveAppraisalAward(ctx context.Context, current *models.Appraisal, userID, awardJSON string, rcvTotal, acvTotal float64, awardedAt time.Time, due []appraisalPaymentDue) (*models.Appraisal, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	updated, err := s.updateAppraisalTx(ctx, tx, current, userID, "appraisal_award_recorded",
+		fmt.Sprintf("Appraisal award recorded: $%.2f RCV / $%.2f ACV", rcvTotal, acvTotal),
+		`status = $1, award = $2, award_rcv_total = $3, award_acv_total = $4, awarded_at = $5`,
+		models.AppraisalAwarded, awardJSON, rcvTotal, acvTotal, awardedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, d := range due {
+		if d.amount <= 0 {
+			continue
+		}
+		notes := fmt.Sprintf("Appraisal award balance (%s)", strings.ToUpper(d.paymentType))
+		paymentID, err := insertExpectedPayment(ctx, tx, updated.ClaimID, d.paymentType, d.amount, &notes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to add the expected %s payment: %w", d.paymentType, err)
+		}
+		err = s.logActivity(ctx, tx, updated.ClaimID, userID, "payment_expected",
+			fmt.Sprintf("Expected %s payment: $%.2f", d.paymentType, d.amount),
+			map[string]interface{}{
+				"payment_id":      paymentID,
+				"payment_type":    d.paymentType,
+				"expected_amount": d.amount,
+				"appraisal_id":    updated.ID,
+			})
+		if err != nil {
+			return nil, fmt.Errorf("failed to log expected payment activity: %w", err)
+		}
+		_, err = tx.ExecContext(ctx, `UPDATE appraisals SET `+d.column+` = $1 WHERE id = $2`, paymentID, updated.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to link appraisal payment: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit appraisal award: %w", err)
+	}
+	return updated, nil
+}
+
+func (s *AppraisalService) getAppraisal(ctx context.Context, claimID, appraisalID string) (*models.Appraisal, error) {
+	a, err := scanAppraisal(s.db.QueryRowContext(ctx, `
+		SELECT `+appraisalColumns+`
+		FROM appraisals
+		WHERE id = $1 AND claim_id = $2`,
+		appraisalID, claimID,
+	))
+	if err == sql.ErrNoRows {
+		return nil, ErrAppraisalNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get appraisal: %w", err)
+	}
+	parties, err := s.loadAppraisalParties(ctx, a.ID)
+	if err != nil {
+		return nil, err
+	}
+	a.Parties = parties
+	return a, nil
+}
+
+func (s *AppraisalService) loadAppraisalParties(ctx context.Context, appraisalID string) ([]models.AppraisalParty, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, role, name, firm, email, phone, selected_at
+		FROM appraisal_parties
+		WHERE appraisal_id = $1
+		ORDER BY selected_at`, appraisalID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get appraisal parties: %w", err)
+	}
+	defer rows.Close()
+
+	parties := []models.AppraisalParty{}
+	for rows.Next() {
+		var p models.AppraisalParty
+		if err := rows.Scan(&p.ID, &p.Role, &p.Name, &p.Firm, &p.Email, &p.Phone, &p.SelectedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan appraisal party: %w", err)
+		}
+		parties = append(parties, p)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate appraisal parties: %w", err)
+	}
+	return parties, nil
+}
+
+// updateAppraisal applies set to an appraisal still in its current status and
+// logs the change to the claim timeline in the same transaction. Arguments to
+// set are numbered from $1.
+func (s *AppraisalService) updateAppraisal(ctx context.Context, current *models.Appraisal, userID, activityType, description, set string, args ...interface{}) (*models.Appraisal, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	updated, err := s.updateAppraisalTx(ctx, tx, current, userID, activityType, description, set, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit appraisal update: %w", err)
+	}
+	return updated, nil
+}
+
+// updateAppraisalTx is updateAppraisal within a transaction the caller commits
+func (s *AppraisalService) updateAppraisalTx(ctx context.Context, tx *sql.Tx, current *models.Appraisal, userID, activityType, description, set string, args ...interface{}) (*models.Appraisal, error) {
+	n := len(args)
+	args = append(args, current.ID, current.Status)
+	// The status guard keeps a concurrent update from being overwritten
+	updated, err := scanAppraisal(tx.QueryRowContext(ctx, fmt.Sprintf(`
+		UPDATE appraisals SET %s, updated_at = NOW()
+		WHERE id = $%d AND status = $%d
+		RETURNING `+appraisalColumns, set, n+1, n+2),
+		args...,
+	))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("%w: appraisal was updated by someone else", ErrInvalidAppraisalTransition)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to update appraisal: %w", err)
+	}
+
+	metadata := map[string]interface{}{
+		"appraisal_id": updated.ID,
+		"old_status":   current.Status,
+		"new_status":   updated.Status,
+	}
+	if updated.AwardRCVTotal != nil {
+		metadata["award_rcv_total"] = *updated.AwardRCVTotal
+		metadata["award_acv_total"] = *updated.AwardACVTotal
+	}
+	if err := s.logActivity(ctx, tx, updated.ClaimID, userID, activityType, description, metadata); err != nil {
+		return nil, fmt.Errorf("failed to log appraisal activity: %w", err)
+	}
+
+	updated.Parties = current.Parties
+	return updated, nil
+}
+
+func (s *AppraisalService) logActivity(ctx context.Context, exec sqlExecer, claimID, userID, activityType, description string, metadata map[string]interface{}) error {
+	metadataJSON, err := json.Marshal(metadata)
+	if err != nil {
+		return fmt.Errorf("failed to marshal metadata: %w", err)
+	}
+	_, err = exec.ExecContext(ctx, `
+		INSERT INTO claim_activities (id, claim_id, user_id, activity_type, description, metadata, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		uuid.New().String(), claimID, userID, activityType, description, string(metadataJSON), time.Now(),
+	)
+	return err
+}
+
+// appraisalHeaderData is the claim and policy data a demand letter cites
+type appraisalHeaderData struct {
+	claimNumber          string
+	insuranceClaimNumber string
+	propertyAddress      string
+	carrierName          string
+	policyNumber         string
+	lossType             string
+	incidentDate         time.Time
+}
+
+func (s *AppraisalService) appraisalHeader(ctx context.Context, claimID string) (*appraisalHeaderData, error) {
+	var h appraisalHeaderData
+	err := s.db.QueryRowContext(ctx, `
+		SELECT COALESCE(c.claim_number, ''), COALESCE(c.insurance_claim_number, ''), p.legal_address,
+		       COALESCE(pol.carrier_name, ''), COALESCE(pol.policy_number, ''), c.loss_type, c.incident_date
+		FROM claims c
+		INNER JOIN properties p ON c.property_id = p.id
+		LEFT JOIN insurance_policies pol ON pol.id = c.policy_id
+		WHERE c.id = $1`, claimID,
+	).Scan(&h.claimNumber, &h.insuranceClaimNumber, &h.propertyAddress, &h.carrierName, &h.policyNumber, &h.lossType, &h.incidentDate)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("claim not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load claim data: %w", err)
+	}
+	return &h, nil
+}
+
+// appraisalDemandPromptData gathers what a demand for appraisal cites: the
+// claim, the carrier's payments so far and the confirmed policy terms
+func appraisalDemandPromptData(h *appraisalHeaderData, payments *models.PaymentSummary, terms *models.PolicyTerms, input InvokeAppraisalInput) *prompts.AppraisalDemandData {
+	data := &prompts.AppraisalDemandData{
+		PropertyAddress:        h.propertyAddress,
+		CarrierName:            h.carrierName,
+		PolicyNumber:           h.policyNumber,
+		InsuranceClaimNumber:   h.insuranceClaimNumber,
+		ClaimNumber:            h.claimNumber,
+		LossType:               h.lossType,
+		IncidentDate:           h.incidentDate,
+		ExpectedACV:            payments.ExpectedACV,
+		ACVReceived:            payments.TotalACVReceived,
+		ExpectedRCV:            payments.ExpectedRCV,
+		RCVReceived:            payments.TotalRCVReceived,
+		DeductibleBasis:        payments.DeductibleBasis,
+		DisputedAmount:         input.DisputedAmount,
+		PolicyTerms:            policyTermsText(terms),
+		AppraiserSelectionDays: appraiserSelectionDays,
+		UmpireSelectionDays:    umpireSelectionDays,
+	}
+	if payments.Deductible > 0 {
+		data.Deductible = payments.Deductible
+	}
+	if input.DisputeSummary != nil {
+		data.DisputeSummary = *input.DisputeSummary
+	}
+	return data
+}
+
+// checkAppraisalTransition reports whether an appraisal may move from one
+// status to the next
+func checkAppraisalTransition(from, to string) error {
+	for _, next := range appraisalTransitions[from] {
+		if next == to {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w from %s to %s", ErrInvalidAppraisalTransition, from, to)
+}
+
+// validateAppraisalAward checks an award has one line per known coverage with
+// an ACV no greater than its replacement cost, and returns the lines rounded
+// to cents with their totals
+func validateAppraisalAward(lines []models.AppraisalAwardLine) ([]models.AppraisalAwardLine, float64, float64, error) {
+	if len(lines) == 0 {
+		return nil, 0, 0, fmt.Errorf("%w: the award needs at least one coverage", ErrInvalidAppraisalUpdate)
+	}
+	seen := map[string]bool{}
+	award := make([]models.AppraisalAwardLine, 0, len(lines))
+	var rcvTotal, acvTotal float64
+	for _, line := range lines {
+		if _, ok := appraisalCoverages[line.Coverage]; !ok {
+			return nil, 0, 0, fmt.Errorf("%w: unknown coverage %q", ErrInvalidAppraisalUpdate, line.Coverage)
+		}
+		if seen[line.Coverage] {
+			return nil, 0, 0, fmt.Errorf("%w: %s is listed more than once", ErrInvalidAppraisalUpdate, line.Coverage)
+		}
+		seen[line.Coverage] = true
+		line.ReplacementCost = math.Round(line.ReplacementCost*100) / 100
+		line.ActualCashValue = math.Round(line.ActualCashValue*100) / 100
+		if line.ActualCashValue < 0 || line.ReplacementCost < 0 {
+			return nil, 0, 0, fmt.Errorf("%w: %s amounts must not be negative", ErrInvalidAppraisalUpdate, line.Coverage)
+		}
+		if line.ActualCashValue > line.ReplacementCost {
+			return nil, 0, 0, fmt.Errorf("%w: %s actual cash value exceeds its replacement cost", ErrInvalidAppraisalUpdate, line.Coverage)
+		}
+		rcvTotal += line.ReplacementCost
+		acvTotal += line.ActualCashValue
+		award = append(award, line)
+	}
+	return award, math.Round(rcvTotal*100) / 100, math.Round(acvTotal*100) / 100, nil
+}
+
+// appraisalPaymentsDue is what the carrier still owes under an award: the
+// ACV award less the deductible and the ACV payments already expected, and the
+// depreciation the award makes recoverable less the RCV payments already
+// expected. Expected ACV amounts are already net of the deductible.
+func appraisalPaymentsDue(rcvTotal, acvTotal float64, payments *models.PaymentSummary) (float64, float64) {
+	acvDue := math.Max(0, acvTotal-payments.Deductible-payments.ExpectedACV)
+	rcvDue := math.Max(0, rcvTotal-acvTotal-payments.ExpectedRCV)
+	return math.Round(acvDue*100) / 100, math.Round(rcvDue*100) / 100
+}
+
+// appraisalRoleLabel names a party role for the claim timeline
+func appraisalRoleLabel(role string) string {
+	switch role {
+	case models.AppraisalPartyOurAppraiser:
+		return "Our appraiser"
+	case models.AppraisalPartyCarrierAppraiser:
+		return "Carrier's appraiser"
+	default:
+		return "Umpire"
+	}
+}
@@ -0,0 +1,160 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/claimcoach/backend/internal/models"
+	"github.com/claimcoach/backend/internal/prompts"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateAppraisalAward(t *testing.T) {
+	award, rcv, acv, err := validateAppraisalAward([]models.AppraisalAwardLine{
+		{Coverage: models.CoverageDwelling, ReplacementCost: 48250.505, ActualCashValue: 39100},
+		{Coverage: models.CoverageOtherStructures, ReplacementCost: 3200, ActualCashValue: 2900.10},
+	})
+	require.NoError(t, err)
+	assert.Len(t, award, 2)
+	assert.Equal(t, 48250.51, award[0].ReplacementCost)
+	assert.Equal(t, 51450.51, rcv)
+	assert.Equal(t, 42000.10, acv)
+
+	cases := map[string][]models.AppraisalAwardLine{
+		"empty":            {},
+		"unknown coverage": {{Coverage: "coverage_z", ReplacementCost: 10}},
+		"duplicate": {
+			{Coverage: models.CoverageDwelling, ReplacementCost: 10},
+			{Coverage: models.CoverageDwelling, ReplacementCost: 20},
+		},
+		"negative":       {{Coverage: models.CoverageDwelling, ReplacementCost: -1}},
+		"acv exceeds rc": {{Coverage: models.CoverageDwelling, ReplacementCost: 100, ActualCashValue: 150}},
+	}
+	for name, lines := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, _, _, err := validateAppraisalAward(lines)
+			assert.True(t, errors.Is(err, ErrInvalidAppraisalUpdate), "got %v", err)
+		})
+	}
+}
+
+func TestAppraisalPaymentsDue(t *testing.T) {
+	// Carrier expected $20,000 ACV (net of a $2,500 deductible) and $4,000
+	// RCV; the award is $50,000 RCV / $40,000 ACV
+	acv, rcv := appraisalPaymentsDue(50000, 40000, &models.PaymentSummary{
+		ExpectedACV: 20000,
+		ExpectedRCV: 4000,
+		Deductible:  2500,
+	})
+	assert.Equal(t, 17500.0, acv)
+	assert.Equal(t, 6000.0, rcv)
+
+	// An award below what is already expected owes nothing more
+	acv, rcv = appraisalPaymentsDue(10000, 9000, &models.PaymentSummary{ExpectedACV: 12000, ExpectedRCV: 2000})
+	assert.Zero(t, acv)
+	assert.Zero(t, rcv)
+}
+
+func TestCheckAppraisalTransition(t *testing.T) {
+	assert.NoError(t, checkAppraisalTransition(models.AppraisalDraft, models.AppraisalInvoked))
+	assert.NoError(t, checkAppraisalTransition(models.AppraisalAppraisersSelected, models.AppraisalAwarded))
+	assert.True(t, errors.Is(checkAppraisalTransition(models.AppraisalInvoked, models.AppraisalAwarded), ErrInvalidAppraisalTransition))
+	assert.True(t, errors.Is(checkAppraisalTransition(models.AppraisalAwarded, models.AppraisalWithdrawn), ErrInvalidAppraisalTransition))
+}
+
+func TestAppraisalDemandPromptData(t *testing.T) {
+	header := &appraisalHeaderData{
+		claimNumber:          "CLM-2026-001",
+		insuranceClaimNumber: "HO-778812",
+		propertyAddress:      "12 Elm St, Springfield",
+		carrierName:          "Acme Mutual",
+		policyNumber:         "POL-123",
+		lossType:             "hail",
+		incidentDate:         time.Date(2026, 5, 2, 0, 0, 0, 0, time.UTC),
+	}
+	disputed := 18250.0
+	summary := "Carrier allowed spot repairs; the roof needs full replacement."
+	rendered, err := prompts.Render(prompts.AppraisalDemand, appraisalDemandPromptData(header,
+		&models.PaymentSummary{ExpectedACV: 9000, Deductible: 2500, DeductibleBasis: "flat"}, nil,
+		InvokeAppraisalInput{DisputedAmount: &disputed, DisputeSummary: &summary}))
+	require.NoError(t, err)
+	prompt := rendered.User
+
+	assert.Contains(t, prompt, "Policy Number: POL-123")
+	assert.Contains(t, prompt, "Carrier Claim Number: HO-778812")
+	assert.Contains(t, prompt, "Date of Loss: May 2, 2026")
+	assert.Contains(t, prompt, "Amount in dispute: $18250.00")
+	assert.Contains(t, prompt, summary)
+	assert.Contains(t, prompt, "Deductible: $2500.00")
+	assert.Contains(t, prompt, "POLICY TERMS: Not yet confirmed")
+	assert.Contains(t, prompt, "within 20 days")
+}
+
+func TestSaveAppraisalAward(t *testing.T) {
+	current := &models.Appraisal{ID: "appraisal-1", ClaimID: "claim-1", Status: models.AppraisalUmpireSelected}
+	awardedAt := time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)
+	award := `[{"coverage":"dwelling","rcv":42000,"acv":30000}]`
+	due := []appraisalPaymentDue{
+		{models.PaymentTypeACV, 0, "acv_payment_id"},
+		{models.PaymentTypeRCV, 12000, "rcv_payment_id"},
+	}
+	awardedRow := func() *sqlmock.Rows {
+		now := time.Now()
+		return sqlmock.NewRows([]string{
+			"id", "claim_id", "status", "disputed_amount", "dispute_summary", "demand_letter",
+			"demand_prompt_version", "invoked_at", "appraiser_deadline", "umpire_deadline", "award", "award_rcv_total", "award_acv_total",
+			"awarded_at", "acv_payment_id", "rcv_payment_id", "created_by_user_id", "created_at", "updated_at",
+		}).AddRow("appraisal-1", "claim-1", models.AppraisalAwarded, nil, nil, "Demand for appraisal",
+			"appraisal_demand/v1", nil, nil, nil, []byte(award), 42000.0, 30000.0,
+			awardedAt, nil, nil, "user-1", now, now)
+	}
+
+	t.Run("awards and adds the balance in one transaction", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		service := &AppraisalService{db: db}
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`UPDATE appraisals SET status = \$1`).
+			WithArgs(models.AppraisalAwarded, award, 42000.0, 30000.0, awardedAt, "appraisal-1", models.AppraisalUmpireSelected).
+			WillReturnRows(awardedRow())
+		mock.ExpectExec(`INSERT INTO claim_activities`).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`INSERT INTO payments`).
+			WithArgs(sqlmock.AnyArg(), "claim-1", models.PaymentTypeRCV, 0.0, 12000.0, models.PaymentStatusExpected, sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`INSERT INTO claim_activities`).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`UPDATE appraisals SET rcv_payment_id = \$1`).
+			WithArgs(sqlmock.AnyArg(), "appraisal-1").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		updated, err := service.saveAppraisalAward(context.Background(), current, "user-1", award, 42000, 30000, awardedAt, due)
+
+		require.NoError(t, err)
+		assert.Equal(t, models.AppraisalAwarded, updated.Status)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("a failed payment leaves the appraisal unawarded", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		service := &AppraisalService{db: db}
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`UPDATE appraisals SET status = \$1`).WillReturnRows(awardedRow())
+		mock.ExpectExec(`INSERT INTO claim_activities`).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`INSERT INTO payments`).WillReturnError(errors.New("connection reset"))
+		mock.ExpectRollback()
+
+		_, err = service.saveAppraisalAward(context.Background(), current, "user-1", award, 42000, 30000, awardedAt, due)
+
+		assert.ErrorContains(t, err, "failed to add the expected rcv payment")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,285 @@
+package services
+
+import (
+	"archive/zip"
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/claimcoach/backend/internal/models"
+	"github.com/claimcoach/backend/internal/storage"
+)
+
+// ArchiveEntry is a single file written into a streamed ZIP archive. Either
+// StoragePath (fetched from Supabase Storage at write time) or Content (inline,
+// e.g. a generated PDF) is set.
+type ArchiveEntry struct {
+	ZIPPath     string
+	StoragePath string
+	Content     []byte
+}
+
+// Archive is a prepared set of entries plus the download filename. Preparing an
+// archive does not fetch any files — that happens entry-by-entry in Stream.
+type Archive struct {
+	Filename string
+	Entries  []ArchiveEntry
+}
+
+// ArchiveService streams ZIP archives of claim files directly to a writer so
+// that no archive (and at most one file chunk) is ever held in memory.
+type ArchiveService struct {
+	db           *sql.DB
+	storage      StorageClient
+	claimService *ClaimService
+	httpClient   *http.Client
+}
+
+func NewArchiveService(db *sql.DB, storageClient *storage.SupabaseStorage, claimService *ClaimService) *ArchiveService {
+	return &ArchiveService{
+		db:           db,
+		storage:      storageClient,
+		claimService: claimService,
+		httpClient:   &http.Client{Timeout: 2 * time.Minute},
+	}
+}
+
+// DocumentArchiveInput selects which documents go into a claim archive.
+// Empty selections mean "all current confirmed documents". Explicitly listed
+// document IDs are included even when superseded.
+type DocumentArchiveInput struct {
+	DocumentIDs       []string
+	DocumentTypes     []string
+	IncludeSuperseded bool
+}
+
+// PrepareDocumentArchive resolves the selected confirmed documents for a claim
+// into archive entries, grouped into one folder per document type.
+func (s *ArchiveService) PrepareDocumentArchive(ctx context.Context, claimID, organizationID string, input DocumentArchiveInput) (*Archive, error) {
+	for _, docType := range input.DocumentTypes {
+		if !models.IsValidDocumentType(docType) {
+			return nil, models.ErrInvalidDocumentType
+		}
+	}
+
+	claim, err := s.claimService.GetClaim(claimID, organizationID)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT id, document_type, file_url, file_name, is_current
+		FROM documents
+		WHERE claim_id = $1 AND status = 'confirmed'
+		ORDER BY document_type, created_at ASC
+	`
+	rows, err := s.db.QueryContext(ctx, query, claimID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query documents: %w", err)
+	}
+	defer rows.Close()
+
+	wantIDs := toSet(input.DocumentIDs)
+	wantTypes := toSet(input.DocumentTypes)
+
+	var entries []ArchiveEntry
+	for rows.Next() {
+		var id, docType, fileURL, fileName string
+		var isCurrent bool
+		if err := rows.Scan(&id, &docType, &fileURL, &fileName, &isCurrent); err != nil {
+			return nil, fmt.Errorf("failed to scan document: %w", err)
+		}
+		if len(wantIDs) > 0 && !wantIDs[id] {
+			continue
+		}
+		if len(wantIDs) == 0 && !isCurrent && !input.IncludeSuperseded {
+			continue
+		}
+		if len(wantTypes) > 0 && !wantTypes[docType] {
+			continue
+		}
+		entries = append(entries, ArchiveEntry{
+			ZIPPath:     docType + "/" + sanitizeFilename(fileName),
+			StoragePath: fileURL,
+		})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate documents: %w", err)
+	}
+
+	// Carrier estimates live in their own table; include them whenever carrier
+	// estimates are in scope and no explicit document IDs were requested.
+	if len(wantIDs) == 0 && (len(wantTypes) == 0 || wantTypes[models.DocumentTypeCarrierEstimate]) {
+		estimates, err := loadCarrierEstimateEntries(ctx, s.db, claimID, models.DocumentTypeCarrierEstimate+"/", input.IncludeSuperseded)
+		if err != nil {
+			log.Printf("Warning: failed to load carrier estimates for claim %s: %v", claimID, err)
+		}
+		entries = append(entries, estimates...)
+	}
+
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("no documents found")
+	}
+
+	claimNumber := ""
+	if claim.ClaimNumber != nil {
+		claimNumber = *claim.ClaimNumber
+	}
+
+	return &Archive{
+		Filename: archiveFilename("ClaimCoach-Documents", claimNumber, claimID),
+		Entries:  entries,
+	}, nil
+}
+
+// Stream writes the archive to w one entry at a time. Stored files are copied
+// straight from the storage response body into the ZIP entry. Files that cannot
+// be fetched are logged and skipped so the rest of the archive still downloads.
+// Returns the number of entries written.
+func (s *ArchiveService) Stream(ctx context.Context, w io.Writer, archive *Archive) (int, error) {
+	zw := zip.NewWriter(w)
+	used := make(map[string]bool)
+	written := 0
+
+	for _, entry := range archive.Entries {
+		if err := ctx.Err(); err != nil {
+			return written, err
+		}
+
+		var body io.ReadCloser
+		if entry.StoragePath != "" {
+			rc, err := openStoredFile(ctx, s.storage, s.httpClient, entry.StoragePath)
+			if err != nil {
+				log.Printf("Warning: skipping %s in archive: %v", entry.StoragePath, err)
+				continue
+			}
+			body = rc
+		}
+
+		zipPath := uniqueZIPPath(used, entry.ZIPPath)
+		fw, err := zw.Create(zipPath)
+		if err != nil {
+			if body != nil {
+				body.Close()
+			}
+			return written, fmt.Errorf("could not create ZIP entry %s: %w", zipPath, err)
+		}
+
+		if body != nil {
+			_, err = io.Copy(fw, body)
+			body.Close()
+		} else {
+			_, err = fw.Write(entry.Content)
+		}
+		if err != nil {
+			// The entry header is already on the wire, so the archive can't be
+			// repaired — abort rather than emit a silently truncated file.
+			return written, fmt.Errorf("could not write ZIP entry %s: %w", zipPath, err)
+		}
+		written++
+	}
+
+	if err := zw.Close(); err != nil {
+		return written, fmt.Errorf("failed to finalise ZIP: %w", err)
+	}
+	return written, nil
+}
+
+// openStoredFile opens a streaming download of a file in Supabase Storage.
+// The caller must close the returned body.
+func openStoredFile(ctx context.Context, storageClient StorageClient, httpClient *http.Client, filePath string) (io.ReadCloser, error) {
+	signedURL, err := storageClient.GenerateDownloadURL(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("could not generate download URL: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, signedURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("could not create download request: %w", err)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("could not download: %w", err)
+	}
+	if resp.StatusCode >= 400 {
+		resp.Body.Close()
+		return nil, fmt.Errorf("download returned %d", resp.StatusCode)
+	}
+	return resp.Body, nil
+}
+
+// loadCarrierEstimateEntries returns archive entries for the carrier estimates
+// uploaded to a claim, placed under the given ZIP folder. Only the current
+// version is included unless includeSuperseded is set.
+// Note: carrier_estimates has no status column — all records are valid uploads.
+func loadCarrierEstimateEntries(ctx context.Context, db *sql.DB, claimID, folder string, includeSuperseded bool) ([]ArchiveEntry, error) {
+	query := `
+		SELECT file_path, file_name
+		FROM carrier_estimates
+		WHERE claim_id = $1 AND (is_current OR ($2 AND version > 0))
+		ORDER BY uploaded_at ASC
+	`
+	rows, err := db.QueryContext(ctx, query, claimID, includeSuperseded)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []ArchiveEntry
+	for rows.Next() {
+		var filePath, fileName string
+		if err := rows.Scan(&filePath, &fileName); err != nil {
+			continue
+		}
+		entries = append(entries, ArchiveEntry{ZIPPath: folder + sanitizeFilename(fileName), StoragePath: filePath})
+	}
+	return entries, rows.Err()
+}
+
+// archiveFilename builds a download filename such as
+// "ClaimCoach-Documents-CLM-123-2026-01-02.zip".
+func archiveFilename(prefix, claimNumber, claimID string) string {
+	safeClaimNum := strings.NewReplacer("/", "-", " ", "-", "\\", "-").Replace(claimNumber)
+	if safeClaimNum == "" {
+		safeClaimNum = claimID
+		if len(safeClaimNum) > 8 {
+			safeClaimNum = safeClaimNum[:8]
+		}
+	}
+	return fmt.Sprintf("%s-%s-%s.zip", prefix, safeClaimNum, time.Now().Format("2006-01-02"))
+}
+
+// uniqueZIPPath returns p, or p with a " (n)" suffix before the extension when
+// p has already been used in this archive.
+func uniqueZIPPath(used map[string]bool, p string) string {
+	if !used[p] {
+		used[p] = true
+		return p
+	}
+	ext := path.Ext(p)
+	base := strings.TrimSuffix(p, ext)
+	for n := 2; ; n++ {
+		candidate := fmt.Sprintf("%s (%d)%s", base, n, ext)
+		if !used[candidate] {
+			used[candidate] = true
+			return candidate
+		}
+	}
+}
+
+func toSet(values []string) map[string]bool {
+	set := make(map[string]bool, len(values))
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			set[v] = true
+		}
+	}
+	return set
+}
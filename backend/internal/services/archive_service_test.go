@@ -0,0 +1,69 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestArchiveService_Stream_InlineEntries(t *testing.T) {
+	service := &ArchiveService{}
+
+	archive := &Archive{
+		Filename: "test.zip",
+		Entries: []ArchiveEntry{
+			{ZIPPath: "1-Legal-Brief/Attorney-Briefing.pdf", Content: []byte("brief")},
+			{ZIPPath: "other/notes.txt", Content: []byte("first")},
+			{ZIPPath: "other/notes.txt", Content: []byte("second")},
+		},
+	}
+
+	var buf bytes.Buffer
+	written, err := service.Stream(context.Background(), &buf, archive)
+	require.NoError(t, err)
+	assert.Equal(t, 3, written)
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+
+	contents := make(map[string]string)
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		require.NoError(t, err)
+		data, err := io.ReadAll(rc)
+		rc.Close()
+		require.NoError(t, err)
+		contents[f.Name] = string(data)
+	}
+
+	assert.Equal(t, map[string]string{
+		"1-Legal-Brief/Attorney-Briefing.pdf": "brief",
+		"other/notes.txt":                     "first",
+		"other/notes (2).txt":                 "second",
+	}, contents)
+}
+
+func TestArchiveService_Stream_CancelledContext(t *testing.T) {
+	service := &ArchiveService{}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	archive := &Archive{Entries: []ArchiveEntry{{ZIPPath: "a.txt", Content: []byte("a")}}}
+
+	written, err := service.Stream(ctx, io.Discard, archive)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, 0, written)
+}
+
+func TestArchiveFilename(t *testing.T) {
+	name := archiveFilename("ClaimCoach-Documents", "CLM 12/34", "abcdef123456")
+	assert.Regexp(t, `^ClaimCoach-Documents-CLM-12-34-\d{4}-\d{2}-\d{2}\.zip$`, name)
+
+	name = archiveFilename("ClaimCoach-Documents", "", "abcdef123456")
+	assert.Regexp(t, `^ClaimCoach-Documents-abcdef12-\d{4}-\d{2}-\d{2}\.zip$`, name)
+}
@@ -0,0 +1,281 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/claimcoach/backend/internal/models"
+	"github.com/claimcoach/backend/internal/pricing"
+	"github.com/google/uuid"
+)
+
+var ErrAuditRunNotFound = errors.New("audit run not found")
+
+// auditRunTotals reads the totals of a report as of a run: the PM Brain's
+// comparison when it has run, else the generated estimate's total
+const auditRunTotals = `
+	COALESCE((ar.pm_brain_analysis::jsonb->>'total_contractor_estimate')::numeric,
+	         (ar.generated_estimate->>'total')::numeric, ar.total_contractor_estimate),
+	COALESCE((ar.pm_brain_analysis::jsonb->>'total_carrier_estimate')::numeric, ar.total_carrier_estimate),
+	COALESCE((ar.pm_brain_analysis::jsonb->>'total_delta')::numeric, ar.total_delta)`
+
+// snapshotAuditRun records the current state of an audit report, and the
+// claim's current policy and policy PDF, as an immutable audit run
+func snapshotAuditRun(ctx context.Context, tx *sql.Tx, reportID, kind, userID string) error {
+	result, err := tx.ExecContext(ctx, `
+		INSERT INTO audit_runs (
+			id, audit_report_id, claim_id, kind, scope_sheet_id, scope_sheet_revision,
+			carrier_estimate_id, carrier_estimate_version, contractor_estimate_document_id, contractor_estimate_version,
+			policy_id, policy_updated_at, policy_document_id, policy_document_version,
+			generated_estimate, takeoff, pm_brain_analysis, viability_analysis, dispute_letter, owner_pitch, prompt_versions,
+			total_contractor_estimate, total_carrier_estimate, total_delta, created_by_user_id, created_at
+		)
+		SELECT $1, ar.id, ar.claim_id, $3, ar.scope_sheet_id, ar.scope_sheet_revision,
+		       ar.carrier_estimate_id, ar.carrier_estimate_version, ar.contractor_estimate_document_id, ar.contractor_estimate_version,
+		       c.policy_id, ip.updated_at, pd.id, pd.version,
+		       ar.generated_estimate, ar.takeoff, ar.pm_brain_analysis, ar.viability_analysis, ar.dispute_letter, ar.owner_pitch, ar.prompt_versions,
+		       `+auditRunTotals+`, $4, $5
+		FROM audit_reports ar
+		INNER JOIN claims c ON c.id = ar.claim_id
+		LEFT JOIN insurance_policies ip ON ip.id = c.policy_id
+		LEFT JOIN LATERAL (
+			SELECT id, version FROM documents
+			WHERE claim_id = ar.claim_id AND document_type = $6 AND status = 'confirmed' AND is_current
+			ORDER BY created_at DESC LIMIT 1
+		) pd ON true
+		WHERE ar.id = $2`,
+		uuid.New().String(), reportID, kind, nullIfEmpty(userID), time.Now(), models.DocumentTypePolicyPDF,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record audit run: %w", err)
+	}
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return fmt.Errorf("failed to record audit run: audit report %s not found", reportID)
+	}
+	return nil
+}
+
+// saveAuditRun applies an update to an audit report and records the result as
+// an audit run in one transaction, so the history never misses a change
+func (s *AuditService) saveAuditRun(ctx context.Context, reportID, kind, userID, update string, args ...interface{}) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, update, args...); err != nil {
+		return err
+	}
+	if err := snapshotAuditRun(ctx, tx, reportID, kind, userID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+const auditRunSummaryColumns = `run.id, run.audit_report_id, run.claim_id, run.kind,
+	run.scope_sheet_id, run.scope_sheet_revision, run.carrier_estimate_id, run.carrier_estimate_version,
+	run.contractor_estimate_document_id, run.contractor_estimate_version,
+	run.policy_id, run.policy_updated_at, run.policy_document_id, run.policy_document_version,
+	run.total_contractor_estimate, run.total_carrier_estimate, run.total_delta,
+	run.created_by_user_id, run.created_at`
+
+func auditRunSummaryDest(run *models.AuditRun) []interface{} {
+	return []interface{}{
+		&run.ID, &run.AuditReportID, &run.ClaimID, &run.Kind,
+		&run.ScopeSheetID, &run.ScopeSheetRevision, &run.CarrierEstimateID, &run.CarrierEstimateVersion,
+		&run.ContractorEstimateDocumentID, &run.ContractorEstimateVersion,
+		&run.PolicyID, &run.PolicyUpdatedAt, &run.PolicyDocumentID, &run.PolicyDocumentVersion,
+		&run.TotalContractorEstimate, &run.TotalCarrierEstimate, &run.TotalDelta,
+		&run.CreatedByUserID, &run.CreatedAt,
+	}
+}
+
+// ListAuditRuns returns a claim's audit runs, newest first, without their outputs
+func (s *AuditService) ListAuditRuns(ctx context.Context, claimID, orgID string) ([]models.AuditRun, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+auditRunSummaryColumns+`
+		FROM audit_runs run
+		INNER JOIN claims c ON run.claim_id = c.id
+		INNER JOIN properties p ON c.property_id = p.id
+		WHERE run.claim_id = $1 AND p.organization_id = $2
+		ORDER BY run.created_at DESC`, claimID, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get audit runs: %w", err)
+	}
+	defer rows.Close()
+
+	runs := []models.AuditRun{}
+	for rows.Next() {
+		var run models.AuditRun
+		if err := rows.Scan(auditRunSummaryDest(&run)...); err != nil {
+			return nil, fmt.Errorf("failed to scan audit run: %w", err)
+		}
+		runs = append(runs, run)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate audit runs: %w", err)
+	}
+
+	return runs, nil
+}
+
+// GetAuditRun returns one of a claim's audit runs with its outputs
+func (s *AuditService) GetAuditRun(ctx context.Context, claimID, runID, orgID string) (*models.AuditRun, error) {
+	var run models.AuditRun
+	dest := append(auditRunSummaryDest(&run),
+		&run.GeneratedEstimate, &run.Takeoff, &run.PMBrainAnalysis,
+		&run.ViabilityAnalysis, &run.DisputeLetter, &run.OwnerPitch, &run.PromptVersions,
+	)
+	err := s.db.QueryRowContext(ctx, `
+		SELECT `+auditRunSummaryColumns+`,
+		       run.generated_estimate, run.takeoff, run.pm_brain_analysis,
+		       run.viability_analysis, run.dispute_letter, run.owner_pitch, run.prompt_versions
+		FROM audit_runs run
+		INNER JOIN claims c ON run.claim_id = c.id
+		INNER JOIN properties p ON c.property_id = p.id
+		WHERE run.id = $1 AND run.claim_id = $2 AND p.organization_id = $3`,
+		runID, claimID, orgID,
+	).Scan(dest...)
+	if err == sql.ErrNoRows {
+		return nil, ErrAuditRunNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get audit run: %w", err)
+	}
+	return &run, nil
+}
+
+// AuditRunChange is a value that differs between two audit runs. Input
+// versions are described in words, e.g. "revision 2"; "" means none.
+type AuditRunChange struct {
+	Field string `json:"field"`
+	From  string `json:"from"`
+	To    string `json:"to"`
+}
+
+// AuditRunComparison compares two audit runs of a claim. Estimate is nil
+// unless both runs have a generated estimate.
+type AuditRunComparison struct {
+	From                    models.AuditRun       `json:"from"`
+	To                      models.AuditRun       `json:"to"`
+	InputChanges            []AuditRunChange      `json:"input_changes"`
+	TotalContractorEstimate pricing.AmountChange  `json:"total_contractor_estimate"`
+	TotalCarrierEstimate    pricing.AmountChange  `json:"total_carrier_estimate"`
+	TotalDelta              pricing.AmountChange  `json:"total_delta"`
+	StatusChange            *AuditRunChange       `json:"status_change,omitempty"`
+	Estimate                *pricing.EstimateDiff `json:"estimate"`
+}
+
+// CompareAuditRuns shows what changed from one audit run to another: the
+// input versions, the totals and the generated estimate line by line
+func (s *AuditService) CompareAuditRuns(ctx context.Context, claimID, fromID, toID, orgID string) (*AuditRunComparison, error) {
+	from, err := s.GetAuditRun(ctx, claimID, fromID, orgID)
+	if err != nil {
+		return nil, err
+	}
+	to, err := s.GetAuditRun(ctx, claimID, toID, orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	comparison := &AuditRunComparison{
+		InputChanges:            compareRunInputs(from, to),
+		TotalContractorEstimate: pricing.NewAmountChange(deref(from.TotalContractorEstimate), deref(to.TotalContractorEstimate)),
+		TotalCarrierEstimate:    pricing.NewAmountChange(deref(from.TotalCarrierEstimate), deref(to.TotalCarrierEstimate)),
+		TotalDelta:              pricing.NewAmountChange(deref(from.TotalDelta), deref(to.TotalDelta)),
+	}
+
+	if fromStatus, toStatus := pmBrainStatus(from), pmBrainStatus(to); fromStatus != toStatus {
+		comparison.StatusChange = &AuditRunChange{Field: "pm_brain_status", From: fromStatus, To: toStatus}
+	}
+
+	if from.GeneratedEstimate != nil && to.GeneratedEstimate != nil {
+		fromEstimate, err := pricing.ParseIndustryEstimate(*from.GeneratedEstimate)
+		if err != nil {
+			return nil, fmt.Errorf("audit run %s has an invalid estimate: %w", from.ID, err)
+		}
+		toEstimate, err := pricing.ParseIndustryEstimate(*to.GeneratedEstimate)
+		if err != nil {
+			return nil, fmt.Errorf("audit run %s has an invalid estimate: %w", to.ID, err)
+		}
+		diff := pricing.DiffEstimates(fromEstimate, toEstimate)
+		comparison.Estimate = &diff
+	}
+
+	// The comparison carries the changes; the runs themselves are summaries
+	comparison.From, comparison.To = summarizeRun(*from), summarizeRun(*to)
+	return comparison, nil
+}
+
+func compareRunInputs(from, to *models.AuditRun) []AuditRunChange {
+	changes := []AuditRunChange{}
+	add := func(input, fromVersion, toVersion string) {
+		if fromVersion != toVersion {
+			changes = append(changes, AuditRunChange{Field: input, From: fromVersion, To: toVersion})
+		}
+	}
+	add("scope_sheet", describeVersion("revision", from.ScopeSheetID, from.ScopeSheetRevision),
+		describeVersion("revision", to.ScopeSheetID, to.ScopeSheetRevision))
+	add("carrier_estimate", describeVersion("version", from.CarrierEstimateID, from.CarrierEstimateVersion),
+		describeVersion("version", to.CarrierEstimateID, to.CarrierEstimateVersion))
+	add("contractor_estimate", describeVersion("version", from.ContractorEstimateDocumentID, from.ContractorEstimateVersion),
+		describeVersion("version", to.ContractorEstimateDocumentID, to.ContractorEstimateVersion))
+	add("policy", describePolicy(from), describePolicy(to))
+	add("policy_document", describeVersion("version", from.PolicyDocumentID, from.PolicyDocumentVersion),
+		describeVersion("version", to.PolicyDocumentID, to.PolicyDocumentVersion))
+	return changes
+}
+
+// describeVersion names an input version, falling back to its ID for inputs
+// recorded before they were versioned
+func describeVersion(label string, id *string, version *int) string {
+	switch {
+	case id == nil:
+		return ""
+	case version == nil:
+		return *id
+	default:
+		return fmt.Sprintf("%s %d", label, *version)
+	}
+}
+
+func describePolicy(run *models.AuditRun) string {
+	if run.PolicyID == nil {
+		return ""
+	}
+	if run.PolicyUpdatedAt == nil {
+		return *run.PolicyID
+	}
+	return "updated " + run.PolicyUpdatedAt.UTC().Format(time.RFC3339)
+}
+
+func pmBrainStatus(run *models.AuditRun) string {
+	if run.PMBrainAnalysis == nil {
+		return ""
+	}
+	var analysis PMBrainAnalysis
+	if err := json.Unmarshal([]byte(*run.PMBrainAnalysis), &analysis); err != nil {
+		return ""
+	}
+	return analysis.Status
+}
+
+// summarizeRun drops a run's outputs
+func summarizeRun(run models.AuditRun) models.AuditRun {
+	run.GeneratedEstimate, run.Takeoff, run.PMBrainAnalysis = nil, nil, nil
+	run.ViabilityAnalysis, run.DisputeLetter, run.OwnerPitch = nil, nil, nil
+	return run
+}
+
+func deref(v *float64) float64 {
+	if v == nil {
+		return 0
+	}
+	return *v
+}
@@ -0,0 +1,78 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/claimcoach/backend/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSaveAuditRun_RecordsRunWithUpdate(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE audit_reports SET owner_pitch`).
+		WithArgs("pitch", "report-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`INSERT INTO audit_runs`).
+		WithArgs(sqlmock.AnyArg(), "report-1", models.AuditRunOwnerPitch, "user-1", sqlmock.AnyArg(), models.DocumentTypePolicyPDF).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	service := &AuditService{db: db}
+	err = service.saveAuditRun(context.Background(), "report-1", models.AuditRunOwnerPitch, "user-1",
+		`UPDATE audit_reports SET owner_pitch = $1, updated_at = NOW() WHERE id = $2`, "pitch", "report-1")
+	require.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSaveAuditRun_RollsBackWhenRunIsNotRecorded(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE audit_reports SET dispute_letter`).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`INSERT INTO audit_runs`).
+		WillReturnError(errors.New("audit runs are immutable"))
+	mock.ExpectRollback()
+
+	service := &AuditService{db: db}
+	err = service.saveAuditRun(context.Background(), "report-1", models.AuditRunDisputeLetter, "user-1",
+		`UPDATE audit_reports SET dispute_letter = $1, updated_at = NOW() WHERE id = $2`, "letter", "report-1")
+	assert.ErrorContains(t, err, "failed to record audit run")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCompareRunInputs(t *testing.T) {
+	str := func(s string) *string { return &s }
+	num := func(n int) *int { return &n }
+	updated := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	from := &models.AuditRun{
+		ScopeSheetID: str("scope-1"), ScopeSheetRevision: num(1),
+		CarrierEstimateID: str("carrier-1"), CarrierEstimateVersion: num(1),
+		PolicyID: str("policy-1"),
+	}
+	to := &models.AuditRun{
+		ScopeSheetID: str("scope-1"), ScopeSheetRevision: num(2),
+		CarrierEstimateID: str("carrier-1"), CarrierEstimateVersion: num(1),
+		PolicyID: str("policy-1"), PolicyUpdatedAt: &updated,
+		PolicyDocumentID: str("doc-1"), PolicyDocumentVersion: num(1),
+	}
+
+	assert.Equal(t, []AuditRunChange{
+		{Field: "scope_sheet", From: "revision 1", To: "revision 2"},
+		{Field: "policy", From: "policy-1", To: "updated 2026-03-01T12:00:00Z"},
+		{Field: "policy_document", From: "", To: "version 1"},
+	}, compareRunInputs(from, to))
+	assert.Empty(t, compareRunInputs(to, to))
+}
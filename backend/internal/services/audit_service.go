@@ -4,13 +4,18 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"math"
 	"strings"
 	"time"
 
 	"github.com/claimcoach/backend/internal/llm"
 	"github.com/claimcoach/backend/internal/models"
+	"github.com/claimcoach/backend/internal/pricing"
+	"github.com/claimcoach/backend/internal/prompts"
+	"github.com/claimcoach/backend/internal/takeoff"
 	"github.com/google/uuid"
 )
 
@@ -24,21 +29,33 @@ type AuditService struct {
 	db           *sql.DB
 	llmClient    LLMClient
 	scopeService *ScopeSheetService
+	documentText *DocumentTextService // optional: grounds prompts on extracted document text
+	pricing      *PricingService      // optional: without it estimates are priced from the baseline only
+	cache        *LLMCache            // optional: without it every estimate calls the LLM
 }
 
+// groundingTextMaxChars caps how much extracted document text goes into a prompt
+const groundingTextMaxChars = 12000
+
 // NewAuditService creates a new AuditService instance
-func NewAuditService(db *sql.DB, llmClient LLMClient, scopeService *ScopeSheetService) *AuditService {
+func NewAuditService(db *sql.DB, llmClient LLMClient, scopeService *ScopeSheetService, documentText *DocumentTextService, pricingService *PricingService, cache *LLMCache) *AuditService {
 	return &AuditService{
 		db:           db,
 		llmClient:    llmClient,
 		scopeService: scopeService,
+		documentText: documentText,
+		pricing:      pricingService,
+		cache:        cache,
 	}
 }
 
-// GenerateIndustryEstimate generates an industry-standard estimate using AI based on the scope sheet
-func (s *AuditService) GenerateIndustryEstimate(ctx context.Context, claimID, userID, orgID string) (string, error) {
-	// 1. Get the scope sheet for this claim
-	scopeSheet, err := s.scopeService.GetScopeSheetByClaimID(ctx, claimID)
+// GenerateIndustryEstimate generates an industry-standard estimate from the scope sheet.
+// The LLM maps scope items to price codes; quantities come from the takeoff and
+// every unit cost from the pricing tables. The mapping for an unchanged scope
+// sheet and price list is reused from the LLM response cache unless force is set.
+func (s *AuditService) GenerateIndustryEstimate(ctx context.Context, claimID, userID, orgID string, force bool) (string, error) {
+	// 1. Get the scope sheet revision to audit: the latest approved one, else the latest awaiting review
+	scopeSheet, err := s.scopeService.GetAuditableScopeSheet(ctx, claimID)
 	if err != nil {
 		return "", fmt.Errorf("failed to get scope sheet: %w", err)
 	}
@@ -46,145 +63,240 @@ func (s *AuditService) GenerateIndustryEstimate(ctx context.Context, claimID, us
 		return "", fmt.Errorf("scope sheet not found for claim %s", claimID)
 	}
 
-	// 2. Build the prompt from the scope sheet
-	userPrompt := s.buildEstimatePrompt(scopeSheet)
+	// 2. Compute quantities from the scope sheet dimensions and build the prompt around them
+	quantities := takeoff.Compute(scopeSheet.Areas)
+	takeoffJSON, err := json.Marshal(quantities)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal takeoff: %w", err)
+	}
 
-	// 3. Prepare messages for the LLM
-	messages := []llm.Message{
-		{
-			Role: "system",
-			Content: `You are an expert construction estimator specializing in insurance claims.
-Your task is to produce accurate, industry-standard repair estimates.
-Always respond with valid JSON only, no additional text or explanations.`,
-		},
-		{
-			Role:    "user",
-			Content: userPrompt,
-		},
+	// 3. Resolve the property's regional adjustment and the unit prices in effect there
+	pricedAt := time.Now()
+	adjustment := pricing.NoAdjustment("regional pricing not configured")
+	prices := pricing.Baseline()
+	if s.pricing != nil {
+		location, err := s.claimLocation(ctx, claimID)
+		if err != nil {
+			return "", err
+		}
+		adjustment, err = s.pricing.ResolveAdjustment(ctx, location, pricedAt)
+		if err != nil {
+			return "", fmt.Errorf("failed to resolve regional adjustment: %w", err)
+		}
+		prices, err = s.pricing.ResolvePrices(ctx, orgID, adjustment.Region, pricedAt)
+		if err != nil {
+			return "", fmt.Errorf("failed to resolve unit prices: %w", err)
+		}
 	}
 
-	// 4. Call the LLM API — use high token limit since estimate JSON can be large
-	response, err := s.llmClient.Chat(ctx, messages, 0.2, 8000)
+	prompt, err := prompts.Get(prompts.Estimate)
 	if err != nil {
-		return "", fmt.Errorf("LLM API call failed: %w", err)
+		return "", err
+	}
+	rendered, err := prompt.Render(estimatePromptData(scopeSheet, quantities, pricing.Sorted(prices)))
+	if err != nil {
+		return "", err
 	}
 
-	// 5. Extract and validate the response
-	if len(response.Choices) == 0 {
-		return "", fmt.Errorf("LLM returned no choices")
+	// 4. Prepare messages for the LLM
+	messages := rendered.Messages()
+
+	// 5. Call the LLM API — use high token limit since the line item JSON can be large.
+	// A mapping that fails validation is sent back with its problems for repair,
+	// and only a valid one is cached, keyed on the original request.
+	const temperature, maxTokens = 0.2, 8000
+	areaIDs := make([]string, len(scopeSheet.Areas))
+	for i, area := range scopeSheet.Areas {
+		areaIDs[i] = area.ID
+	}
+	inputHash := chatInputHash(messages, temperature, maxTokens)
+	var items []pricing.MappedItem
+	cached := false
+	if !force {
+		if content, ok := s.cache.Get(ctx, orgID, llmEndpointChat, rendered.Version, inputHash); ok {
+			// A cached mapping is validated again in case its codes were retired since
+			if items, err = parseEstimateMapping(content, areaIDs, quantities, prices); err == nil {
+				cached = true
+			} else {
+				log.Printf("Cached estimate mapping for claim %s is no longer valid, regenerating: %v", claimID, err)
+			}
+		}
 	}
+	for attempt := 0; !cached; attempt++ {
+		response, err := s.llmClient.Chat(ctx, messages, temperature, maxTokens)
+		if err != nil {
+			return "", fmt.Errorf("LLM API call failed: %w", err)
+		}
+		if err := s.logAPIUsage(ctx, orgID, response); err != nil {
+			// Log the error but don't fail the request
+			log.Printf("Warning: failed to log API usage: %v", err)
+		}
+		if len(response.Choices) == 0 {
+			return "", fmt.Errorf("LLM returned no choices")
+		}
 
-	estimateJSON := extractJSON(response.Choices[0].Message.Content)
+		content := response.Choices[0].Message.Content
+		var invalid error
+		items, invalid = parseEstimateMapping(content, areaIDs, quantities, prices)
+		if invalid == nil {
+			s.cache.Put(ctx, rendered.Version, inputHash, content)
+			break
+		}
+		if attempt == estimateRepairAttempts {
+			return "", fmt.Errorf("the AI returned an invalid estimate — please try again: %w", invalid)
+		}
+		log.Printf("Estimate mapping for claim %s failed validation, asking for a repair: %v", claimID, invalid)
+		repair, err := prompt.Execute(prompts.SectionRepair, repairPromptData(invalid))
+		if err != nil {
+			return "", err
+		}
+		messages = append(messages,
+			llm.Message{Role: "assistant", Content: content},
+			llm.Message{Role: "user", Content: repair},
+		)
+	}
 
-	// Validate that it's valid JSON
-	var validationCheck map[string]interface{}
-	if err := json.Unmarshal([]byte(estimateJSON), &validationCheck); err != nil {
-		return "", fmt.Errorf("the AI returned a malformed estimate — please try again: %w", err)
+	// 6. Price the mapping and check the arithmetic before storing it
+	estimate := pricing.PriceEstimate(items, quantities, prices, adjustment, pricing.FormatDate(pricedAt))
+	if err := estimate.Validate(); err != nil {
+		return "", fmt.Errorf("generated estimate failed validation: %w", err)
+	}
+	estimateJSON, err := json.Marshal(estimate)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal estimate: %w", err)
 	}
 
-	// 6. Create audit report record
-	reportID, err := s.saveAuditReport(ctx, claimID, scopeSheet.ID, userID, estimateJSON)
+	// 7. Create the audit report, record which input versions it was generated
+	// against and keep it as the first run of the report
+	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
-		return "", fmt.Errorf("failed to save audit report: %w", err)
+		return "", fmt.Errorf("failed to begin transaction: %w", err)
 	}
+	defer tx.Rollback()
 
-	// 7. Log API usage
-	err = s.logAPIUsage(ctx, orgID, response)
+	reportID, err := s.saveAuditReport(ctx, tx, claimID, scopeSheet.ID, scopeSheet.Revision, userID, string(estimateJSON), string(takeoffJSON), rendered.Version)
 	if err != nil {
-		// Log the error but don't fail the request
-		log.Printf("Warning: failed to log API usage: %v", err)
+		return "", fmt.Errorf("failed to save audit report: %w", err)
+	}
+	if err := s.recordInputVersions(ctx, tx, reportID, claimID); err != nil {
+		return "", fmt.Errorf("failed to record input versions: %w", err)
+	}
+	if err := snapshotAuditRun(ctx, tx, reportID, models.AuditRunEstimate, userID); err != nil {
+		return "", err
+	}
+	if err := tx.Commit(); err != nil {
+		return "", fmt.Errorf("failed to commit audit report: %w", err)
 	}
 
 	return reportID, nil
 }
 
-// buildEstimatePrompt creates a structured prompt from the JSONB scope sheet areas
-func (s *AuditService) buildEstimatePrompt(scope *models.ScopeSheet) string {
-	var builder strings.Builder
+// estimateRepairAttempts is how many times an invalid estimate mapping is sent
+// back to the LLM for repair before generation fails
+const estimateRepairAttempts = 2
 
-	builder.WriteString("Based on the following scope sheet data and current industry pricing, ")
-	builder.WriteString("produce a detailed repair estimate in JSON format.\n\n")
-	builder.WriteString("SCOPE SHEET DATA:\n")
-
-	for _, area := range scope.Areas {
-		builder.WriteString(fmt.Sprintf("\n- Area: %s\n", area.Category))
+// parseEstimateMapping decodes and validates the LLM's code mapping. Malformed
+// JSON is reported as a validation problem so it can be repaired too.
+func parseEstimateMapping(content string, areaIDs []string, quantities takeoff.Takeoff, prices map[string]pricing.Price) ([]pricing.MappedItem, error) {
+	var mapping struct {
+		LineItems []pricing.MappedItem `json:"line_items"`
+	}
+	if err := json.Unmarshal([]byte(extractJSON(content)), &mapping); err != nil {
+		return nil, &pricing.ValidationError{Problems: []string{"the response is not the requested JSON object: " + err.Error()}}
+	}
+	if err := pricing.ValidateMapping(mapping.LineItems, areaIDs, quantities, prices); err != nil {
+		return nil, err
+	}
+	return mapping.LineItems, nil
+}
 
-		if len(area.Tags) > 0 {
-			builder.WriteString(fmt.Sprintf("  Damage tags: %s\n", strings.Join(area.Tags, ", ")))
-		}
+// repairPromptData lists the validation problems of an estimate mapping
+func repairPromptData(invalid error) prompts.EstimateRepairData {
+	var validation *pricing.ValidationError
+	if errors.As(invalid, &validation) {
+		return prompts.EstimateRepairData{Problems: validation.Problems}
+	}
+	return prompts.EstimateRepairData{Problems: []string{invalid.Error()}}
+}
 
-		if sqft, ok := area.Dimensions["square_footage"]; ok && sqft > 0 {
-			builder.WriteString(fmt.Sprintf("  Square footage: %.0f sq ft\n", sqft))
-		}
-		if l, okL := area.Dimensions["length"]; okL {
-			if w, okW := area.Dimensions["width"]; okW {
-				builder.WriteString(fmt.Sprintf("  Dimensions: %.0f x %.0f ft\n", l, w))
-			}
-		}
+// claimLocation returns the parsed location of a claim's property
+func (s *AuditService) claimLocation(ctx context.Context, claimID string) (pricing.Location, error) {
+	var location pricing.Location
+	err := s.db.QueryRowContext(ctx, `
+		SELECT COALESCE(p.address_state, ''), COALESCE(p.address_county, ''), COALESCE(p.address_zip, '')
+		FROM claims c
+		INNER JOIN properties p ON c.property_id = p.id
+		WHERE c.id = $1`, claimID,
+	).Scan(&location.State, &location.County, &location.ZIP)
+	if err != nil {
+		return location, fmt.Errorf("failed to get property location: %w", err)
+	}
+	return location, nil
+}
 
-		if area.Notes != "" {
-			builder.WriteString(fmt.Sprintf("  Notes: %s\n", area.Notes))
-		}
+// estimatePromptData describes the JSONB scope sheet areas, the quantities
+// computed from them and the price codes available to the estimate prompt. The
+// LLM maps each repair to a code and the computed quantity it applies to; it
+// does not price anything.
+func estimatePromptData(scope *models.ScopeSheet, quantities takeoff.Takeoff, prices []pricing.Price) *prompts.EstimateData {
+	takeoffByArea := make(map[string]takeoff.Area, len(quantities.Areas))
+	for _, area := range quantities.Areas {
+		takeoffByArea[area.AreaID] = area
+	}
 
-		builder.WriteString(fmt.Sprintf("  Photos: %d image(s) attached\n", len(area.PhotoIDs)))
-	}
-
-	if scope.GeneralNotes != nil && *scope.GeneralNotes != "" {
-		builder.WriteString(fmt.Sprintf("\nGENERAL NOTES: %s\n", *scope.GeneralNotes))
-	}
-
-	builder.WriteString("\nRESPONSE FORMAT:\n")
-	builder.WriteString("Return ONLY a JSON object with this exact structure:\n")
-	builder.WriteString("{\n")
-	builder.WriteString("  \"line_items\": [\n")
-	builder.WriteString("    {\n")
-	builder.WriteString("      \"description\": \"Item description\",\n")
-	builder.WriteString("      \"quantity\": number,\n")
-	builder.WriteString("      \"unit\": \"unit type (e.g., SF, LF, EA)\",\n")
-	builder.WriteString("      \"unit_cost\": number,\n")
-	builder.WriteString("      \"total\": number,\n")
-	builder.WriteString("      \"category\": \"category name (e.g., Roofing, Exterior Trim)\"\n")
-	builder.WriteString("    }\n")
-	builder.WriteString("  ],\n")
-	builder.WriteString("  \"subtotal\": number,\n")
-	builder.WriteString("  \"overhead_profit\": number (typically 20% of subtotal),\n")
-	builder.WriteString("  \"total\": number\n")
-	builder.WriteString("}\n\n")
-	builder.WriteString("Use current 2026 industry-standard pricing for materials and labor. ")
-	builder.WriteString("For each damage tag, include all relevant line items with accurate quantities ")
-	builder.WriteString("derived from the dimensions provided. Tags like 'Pitch_Steep' should trigger ")
-	builder.WriteString("appropriate steep-slope labor charges. Tags like 'Shingles_Damaged' should ")
-	builder.WriteString("include tear-off, underlayment, and shingle replacement line items.")
-
-	return builder.String()
+	data := &prompts.EstimateData{Prices: prices}
+	for _, area := range scope.Areas {
+		length, hasLength := area.Dimensions["length"]
+		width, hasWidth := area.Dimensions["width"]
+		data.Areas = append(data.Areas, prompts.EstimateArea{
+			ID:            area.ID,
+			Category:      area.Category,
+			Tags:          area.Tags,
+			SquareFootage: area.Dimensions["square_footage"],
+			HasDimensions: hasLength && hasWidth,
+			Length:        length,
+			Width:         width,
+			Notes:         area.Notes,
+			Quantities:    takeoffByArea[area.ID].Quantities,
+			PhotoCount:    len(area.PhotoIDs),
+		})
+	}
+	if scope.GeneralNotes != nil {
+		data.GeneralNotes = *scope.GeneralNotes
+	}
+	return data
 }
 
 // saveAuditReport creates and saves an audit report record to the database
-func (s *AuditService) saveAuditReport(ctx context.Context, claimID, scopeSheetID, userID, estimateJSON string) (string, error) {
+func (s *AuditService) saveAuditReport(ctx context.Context, tx *sql.Tx, claimID, scopeSheetID string, scopeSheetRevision *int, userID, estimateJSON, takeoffJSON, promptVersion string) (string, error) {
 	reportID := uuid.New().String()
 	now := time.Now()
 
 	query := `
 		INSERT INTO audit_reports (
-			id, claim_id, scope_sheet_id, generated_estimate,
-			status, created_by_user_id, created_at, updated_at
+			id, claim_id, scope_sheet_id, scope_sheet_revision, generated_estimate, takeoff,
+			status, created_by_user_id, created_at, updated_at, prompt_versions
 		)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, jsonb_build_object($11::text, $12::text))
 		RETURNING id
 	`
 
 	var returnedID string
-	err := s.db.QueryRowContext(
+	err := tx.QueryRowContext(
 		ctx,
 		query,
 		reportID,
 		claimID,
 		scopeSheetID,
+		scopeSheetRevision,
 		estimateJSON,
+		takeoffJSON,
 		models.AuditStatusCompleted,
 		userID,
 		now,
 		now,
+		prompts.Estimate,
+		promptVersion,
 	).Scan(&returnedID)
 
 	if err != nil {
@@ -197,11 +309,12 @@ func (s *AuditService) saveAuditReport(ctx context.Context, claimID, scopeSheetI
 // GetAuditReportByClaimID retrieves the audit report for a claim with ownership verification
 func (s *AuditService) GetAuditReportByClaimID(ctx context.Context, claimID, orgID string) (*models.AuditReport, error) {
 	query := `
-		SELECT ar.id, ar.claim_id, ar.scope_sheet_id, ar.carrier_estimate_id,
-		       ar.generated_estimate, ar.comparison_data, ar.total_contractor_estimate,
+		SELECT ar.id, ar.claim_id, ar.scope_sheet_id, ar.scope_sheet_revision, ar.carrier_estimate_id,
+		       ar.carrier_estimate_version, ar.contractor_estimate_document_id,
+		       ar.contractor_estimate_version, ar.generated_estimate, ar.takeoff, ar.comparison_data, ar.total_contractor_estimate,
 		       ar.total_carrier_estimate, ar.total_delta, ar.status, ar.error_message,
 		       ar.created_by_user_id, ar.created_at, ar.updated_at, ar.viability_analysis,
-		       ar.pm_brain_analysis, ar.dispute_letter, ar.owner_pitch
+		       ar.pm_brain_analysis, ar.dispute_letter, ar.owner_pitch, ar.prompt_versions
 		FROM audit_reports ar
 		INNER JOIN claims c ON ar.claim_id = c.id
 		INNER JOIN properties p ON c.property_id = p.id
@@ -215,8 +328,13 @@ func (s *AuditService) GetAuditReportByClaimID(ctx context.Context, claimID, org
 		&report.ID,
 		&report.ClaimID,
 		&report.ScopeSheetID,
+		&report.ScopeSheetRevision,
 		&report.CarrierEstimateID,
+		&report.CarrierEstimateVersion,
+		&report.ContractorEstimateDocumentID,
+		&report.ContractorEstimateVersion,
 		&report.GeneratedEstimate,
+		&report.Takeoff,
 		&report.ComparisonData,
 		&report.TotalContractorEstimate,
 		&report.TotalCarrierEstimate,
@@ -230,6 +348,7 @@ func (s *AuditService) GetAuditReportByClaimID(ctx context.Context, claimID, org
 		&report.PMBrainAnalysis,
 		&report.DisputeLetter,
 		&report.OwnerPitch,
+		&report.PromptVersions,
 	)
 
 	if err == sql.ErrNoRows {
@@ -245,11 +364,12 @@ func (s *AuditService) GetAuditReportByClaimID(ctx context.Context, claimID, org
 // getAuditReportWithOwnershipCheck gets an audit report and verifies ownership
 func (s *AuditService) getAuditReportWithOwnershipCheck(ctx context.Context, auditReportID, orgID string) (*models.AuditReport, error) {
 	query := `
-		SELECT ar.id, ar.claim_id, ar.scope_sheet_id, ar.carrier_estimate_id,
-		       ar.generated_estimate, ar.comparison_data, ar.total_contractor_estimate,
+		SELECT ar.id, ar.claim_id, ar.scope_sheet_id, ar.scope_sheet_revision, ar.carrier_estimate_id,
+		       ar.carrier_estimate_version, ar.contractor_estimate_document_id,
+		       ar.contractor_estimate_version, ar.generated_estimate, ar.takeoff, ar.comparison_data, ar.total_contractor_estimate,
 		       ar.total_carrier_estimate, ar.total_delta, ar.status, ar.error_message,
 		       ar.created_by_user_id, ar.created_at, ar.updated_at, ar.viability_analysis,
-		       ar.pm_brain_analysis, ar.dispute_letter, ar.owner_pitch
+		       ar.pm_brain_analysis, ar.dispute_letter, ar.owner_pitch, ar.prompt_versions
 		FROM audit_reports ar
 		INNER JOIN claims c ON ar.claim_id = c.id
 		INNER JOIN properties p ON c.property_id = p.id
@@ -261,8 +381,13 @@ func (s *AuditService) getAuditReportWithOwnershipCheck(ctx context.Context, aud
 		&report.ID,
 		&report.ClaimID,
 		&report.ScopeSheetID,
+		&report.ScopeSheetRevision,
 		&report.CarrierEstimateID,
+		&report.CarrierEstimateVersion,
+		&report.ContractorEstimateDocumentID,
+		&report.ContractorEstimateVersion,
 		&report.GeneratedEstimate,
+		&report.Takeoff,
 		&report.ComparisonData,
 		&report.TotalContractorEstimate,
 		&report.TotalCarrierEstimate,
@@ -276,6 +401,7 @@ func (s *AuditService) getAuditReportWithOwnershipCheck(ctx context.Context, aud
 		&report.PMBrainAnalysis,
 		&report.DisputeLetter,
 		&report.OwnerPitch,
+		&report.PromptVersions,
 	)
 
 	if err == sql.ErrNoRows {
@@ -288,14 +414,36 @@ func (s *AuditService) getAuditReportWithOwnershipCheck(ctx context.Context, aud
 	return &report, nil
 }
 
-// getCarrierEstimate retrieves the carrier estimate for a claim
+// recordInputVersions stamps an audit report with the current carrier estimate
+// and contractor estimate document (and their versions) for its claim.
+func (s *AuditService) recordInputVersions(ctx context.Context, tx *sql.Tx, reportID, claimID string) error {
+	query := `
+		UPDATE audit_reports
+		SET (carrier_estimate_id, carrier_estimate_version) = (
+		        SELECT id, version FROM carrier_estimates
+		        WHERE claim_id = $2 AND is_current
+		        ORDER BY uploaded_at DESC LIMIT 1
+		    ),
+		    (contractor_estimate_document_id, contractor_estimate_version) = (
+		        SELECT id, version FROM documents
+		        WHERE claim_id = $2 AND document_type = $3 AND status = 'confirmed' AND is_current
+		        ORDER BY created_at DESC LIMIT 1
+		    ),
+		    updated_at = NOW()
+		WHERE id = $1
+	`
+	_, err := tx.ExecContext(ctx, query, reportID, claimID, models.DocumentTypeContractorEstimate)
+	return err
+}
+
+// getCarrierEstimate retrieves the current carrier estimate for a claim
 func (s *AuditService) getCarrierEstimate(ctx context.Context, claimID string) (*models.CarrierEstimate, error) {
 	query := `
 		SELECT id, claim_id, uploaded_by_user_id, file_path, file_name,
 		       file_size_bytes, parsed_data, parse_status, parse_error,
-		       uploaded_at, parsed_at
+		       uploaded_at, parsed_at, version, supersedes_estimate_id, is_current
 		FROM carrier_estimates
-		WHERE claim_id = $1
+		WHERE claim_id = $1 AND is_current
 		ORDER BY uploaded_at DESC
 		LIMIT 1
 	`
@@ -313,6 +461,9 @@ func (s *AuditService) getCarrierEstimate(ctx context.Context, claimID string) (
 		&estimate.ParseError,
 		&estimate.UploadedAt,
 		&estimate.ParsedAt,
+		&estimate.Version,
+		&estimate.SupersedesEstimateID,
+		&estimate.IsCurrent,
 	)
 
 	if err == sql.ErrNoRows {
@@ -325,6 +476,21 @@ func (s *AuditService) getCarrierEstimate(ctx context.Context, claimID string) (
 	return &estimate, nil
 }
 
+// groundingText returns extracted carrier estimate and policy text for a claim,
+// or "" when text extraction isn't configured or hasn't run yet.
+func (s *AuditService) groundingText(ctx context.Context, claimID string) string {
+	if s.documentText == nil {
+		return ""
+	}
+	text, err := s.documentText.GetGroundingText(ctx, claimID,
+		[]string{models.DocumentTypeCarrierEstimate, models.DocumentTypePolicyPDF}, groundingTextMaxChars)
+	if err != nil {
+		log.Printf("Warning: failed to load grounding text for claim %s: %v", claimID, err)
+		return ""
+	}
+	return text
+}
+
 // logAPIUsage records API usage metrics for billing and monitoring
 func (s *AuditService) logAPIUsage(ctx context.Context, orgID string, response *llm.ChatResponse) error {
 	// Calculate estimated cost
@@ -410,7 +576,7 @@ func (s *AuditService) RunPMBrainAnalysis(ctx context.Context, auditReportID, us
 		return nil, fmt.Errorf("industry estimate not generated yet")
 	}
 
-	// 2. Fetch carrier estimate parsed data
+	// 2. Fetch the current carrier estimate's parsed data
 	carrierEstimate, err := s.getCarrierEstimate(ctx, report.ClaimID)
 	if err != nil {
 		return nil, fmt.Errorf("carrier estimate not found — please upload and parse it first")
@@ -429,6 +595,7 @@ func (s *AuditService) RunPMBrainAnalysis(ctx context.Context, auditReportID, us
 		deductible    float64
 		exclusions    string
 		lossType      string
+		location      pricing.Location
 	}
 	var snap policySnapshot
 	snapQuery := `
@@ -440,7 +607,10 @@ func (s *AuditService) RunPMBrainAnalysis(ctx context.Context, auditReportID, us
 			c.incident_date,
 			ip.deductible_value,
 			COALESCE(ip.exclusions, ''),
-			c.loss_type
+			c.loss_type,
+			COALESCE(p.address_state, ''),
+			COALESCE(p.address_county, ''),
+			COALESCE(p.address_zip, '')
 		FROM claims c
 		INNER JOIN properties p ON c.property_id = p.id
 		INNER JOIN insurance_policies ip ON c.policy_id = ip.id
@@ -455,28 +625,36 @@ func (s *AuditService) RunPMBrainAnalysis(ctx context.Context, auditReportID, us
 		&snap.deductible,
 		&snap.exclusions,
 		&snap.lossType,
+		&snap.location.State,
+		&snap.location.County,
+		&snap.location.ZIP,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch policy context: %w", err)
 	}
+	terms, err := confirmedPolicyTerms(ctx, s.db, report.ClaimID)
+	if err != nil {
+		return nil, err
+	}
+	resolved, err := resolveClaimDeductible(ctx, s.db, report.ClaimID)
+	if err != nil {
+		return nil, err
+	}
+	snap.deductible = resolved.Amount
+	estimate, err := pricing.ParseIndustryEstimate(*report.GeneratedEstimate)
+	if err != nil {
+		return nil, fmt.Errorf("industry estimate is invalid — please regenerate it: %w", err)
+	}
 
 	// 4. Build prompt and call LLM
-	prompt := s.buildPMBrainPrompt(*report.GeneratedEstimate, *carrierEstimate.ParsedData, snap.policyNumber, snap.carrierName, snap.claimNumber, snap.incidentDate, snap.deductible, snap.exclusions, snap.lossType)
-
-	messages := []llm.Message{
-		{
-			Role: "system",
-			Content: `You are an expert insurance claim analyst for a property management company.
-Your job is to compare a contractor's industry-standard estimate with a carrier's insurance offer
-and recommend the best course of action for the property manager.
-Always respond with valid JSON only, no markdown, no additional text.`,
-		},
-		{
-			Role:    "user",
-			Content: prompt,
-		},
+	rendered, err := prompts.Render(prompts.PMBrain, pmBrainPromptData(estimate, *carrierEstimate.ParsedData,
+		snap.policyNumber, snap.carrierName, snap.claimNumber, snap.incidentDate, snap.deductible,
+		snap.exclusions, snap.lossType, snap.location, terms, s.groundingText(ctx, report.ClaimID)))
+	if err != nil {
+		return nil, err
 	}
 
+	messages := rendered.Messages()
 	response, err := s.llmClient.Chat(ctx, messages, 0.2, 4096)
 	if err != nil {
 		return nil, fmt.Errorf("LLM API call failed: %w", err)
@@ -496,11 +674,21 @@ Always respond with valid JSON only, no markdown, no additional text.`,
 		return nil, fmt.Errorf("the AI returned an invalid status '%s' — please try again", analysis.Status)
 	}
 
-	// 6. Save to DB
+	// The generated estimate is priced deterministically for the property's
+	// region, so its total is authoritative over the model's reading of it
+	if estimate.Total > 0 {
+		analysis.TotalContractorEstimate = estimate.Total
+		analysis.TotalDelta = math.Round((estimate.Total-analysis.TotalCarrierEstimate)*100) / 100
+	}
+
+	// 6. Save to DB, recording the carrier estimate version the analysis compared against
 	analysisJSON, _ := json.Marshal(analysis)
-	_, err = s.db.ExecContext(ctx,
-		`UPDATE audit_reports SET pm_brain_analysis = $1, updated_at = NOW() WHERE id = $2`,
-		string(analysisJSON), auditReportID,
+	err = s.saveAuditRun(ctx, auditReportID, models.AuditRunPMBrain, userID,
+		`UPDATE audit_reports
+		 SET pm_brain_analysis = $1, carrier_estimate_id = $2, carrier_estimate_version = $3,
+		     prompt_versions = prompt_versions || jsonb_build_object($4::text, $5::text), updated_at = NOW()
+		 WHERE id = $6`,
+		string(analysisJSON), carrierEstimate.ID, carrierEstimate.Version, prompts.PMBrain, rendered.Version, auditReportID,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to save PM Brain analysis: %w", err)
@@ -512,86 +700,55 @@ Always respond with valid JSON only, no markdown, no additional text.`,
 	return &analysis, nil
 }
 
-// buildPMBrainPrompt constructs the full PM Brain prompt with all three data sources.
-func (s *AuditService) buildPMBrainPrompt(
-	generatedEstimate, carrierParsedData string,
+// pmBrainPromptData gathers the PM Brain prompt's three data sources: the
+// policy snapshot, the industry estimate and the carrier's offer.
+func pmBrainPromptData(
+	estimate *pricing.IndustryEstimate, carrierParsedData string,
 	policyNumber *string, carrierName string,
 	claimNumber *string, incidentDate time.Time,
 	deductible float64, exclusions, lossType string,
-) string {
-	var b strings.Builder
-
-	b.WriteString("You are analyzing an insurance claim for a property management company.\n\n")
-
-	b.WriteString("POLICY SNAPSHOT:\n")
-	b.WriteString(fmt.Sprintf("- Carrier: %s\n", carrierName))
+	location pricing.Location, terms *models.PolicyTerms, sourceText string,
+) *prompts.PMBrainData {
+	data := &prompts.PMBrainData{
+		CarrierName:       carrierName,
+		LossType:          lossType,
+		IncidentDate:      incidentDate,
+		Deductible:        deductible,
+		Exclusions:        exclusions,
+		PolicyTerms:       policyTermsText(terms),
+		Estimate:          estimate,
+		CarrierParsedData: carrierParsedData,
+		SourceText:        sourceText,
+	}
 	if policyNumber != nil {
-		b.WriteString(fmt.Sprintf("- Policy Number: %s\n", *policyNumber))
+		data.PolicyNumber = *policyNumber
 	}
 	if claimNumber != nil {
-		b.WriteString(fmt.Sprintf("- Claim Number: %s\n", *claimNumber))
-	}
-	b.WriteString(fmt.Sprintf("- Loss Type: %s\n", lossType))
-	b.WriteString(fmt.Sprintf("- Incident Date: %s\n", incidentDate.Format("January 2, 2006")))
-	b.WriteString(fmt.Sprintf("- Deductible: $%.2f\n", deductible))
-	if exclusions != "" {
-		b.WriteString(fmt.Sprintf("- Policy Exclusions: %s\n", exclusions))
-	} else {
-		b.WriteString("- Policy Exclusions: None listed\n")
-	}
-	b.WriteString("\n")
-
-	b.WriteString("CLAIMCOACH ESTIMATE (industry-standard, from contractor scope sheet):\n")
-	b.WriteString(generatedEstimate)
-	b.WriteString("\n\n")
-
-	b.WriteString("CARRIER'S OFFER (extracted from their PDF):\n")
-	b.WriteString(carrierParsedData)
-	b.WriteString("\n\n")
-
-	b.WriteString(`Analyze both estimates and return a JSON object with this EXACT schema:
-{
-  "status": "CLOSE" | "DISPUTE_OFFER" | "LEGAL_REVIEW" | "NEED_DOCS",
-  "plain_english_summary": "<2-3 sentences explaining the situation in plain English for a non-expert property manager>",
-  "total_contractor_estimate": <number>,
-  "total_carrier_estimate": <number>,
-  "total_delta": <contractor_total minus carrier_total>,
-  "top_delta_drivers": [
-    {
-      "line_item": "<item name>",
-      "contractor_price": <number>,
-      "carrier_price": <number>,
-      "delta": <contractor_price minus carrier_price>,
-      "reason": "<why this gap exists>"
-    }
-  ],
-  "coverage_disputes": [
-    {
-      "item": "<item name>",
-      "status": "denied" | "partial",
-      "contractor_position": "<what the contractor says should be covered>"
-    }
-  ],
-  "required_next_steps": ["<actionable step 1>", "<actionable step 2>"],
-  "legal_threshold_met": <true | false>
-}
-
-STATUS SELECTION RULES (apply exactly):
-- CLOSE: Carrier paid within 10%% of contractor estimate OR carrier paid more. No action needed.
-- DISPUTE_OFFER: Carrier underpaid by more than 10%% but the gap is less than $15,000. Send a dispute letter combining missing scope items and underpriced line items.
-- LEGAL_REVIEW: Gap is $15,000 or more, OR carrier explicitly denied coverage for major items. Escalate.
-- NEED_DOCS: The carrier PDF data is empty, garbled, or clearly not a line-item estimate. Cannot analyze.
-
-Include the top 3-5 delta drivers sorted by dollar gap descending.
-If no coverage items were denied, return an empty array for coverage_disputes.
-Return ONLY the JSON object, no markdown, no explanation.`)
-
-	return b.String()
+		data.ClaimNumber = *claimNumber
+	}
+	if location.State != "" {
+		data.Location = location.String()
+	}
+	// Estimates generated before regional pricing have no adjustment factor
+	if estimate.RegionalAdjustment.Factor > 0 {
+		data.RegionalAdjustment = estimate.RegionalAdjustment.Describe()
+	}
+	return data
 }
 
 // GenerateDisputeLetter writes a formal Dispute/Supplement Request Letter using the PM Brain analysis.
 // Only valid when pm_brain_analysis status is DISPUTE_OFFER.
 func (s *AuditService) GenerateDisputeLetter(ctx context.Context, auditReportID, userID, orgID string) (string, error) {
+	return s.generateDisputeLetter(ctx, auditReportID, userID, orgID, nil)
+}
+
+// StreamDisputeLetter writes the dispute letter like GenerateDisputeLetter,
+// passing the text to onDelta as the LLM generates it
+func (s *AuditService) StreamDisputeLetter(ctx context.Context, auditReportID, userID, orgID string, onDelta func(text string) error) (string, error) {
+	return s.generateDisputeLetter(ctx, auditReportID, userID, orgID, onDelta)
+}
+
+func (s *AuditService) generateDisputeLetter(ctx context.Context, auditReportID, userID, orgID string, onDelta func(text string) error) (string, error) {
 	// 1. Fetch audit report
 	report, err := s.getAuditReportWithOwnershipCheck(ctx, auditReportID, orgID)
 	if err != nil {
@@ -635,49 +792,29 @@ func (s *AuditService) GenerateDisputeLetter(ctx context.Context, auditReportID,
 
 	// 4. Build letter prompt
 	analysisJSON, _ := json.Marshal(analysis)
-	var b strings.Builder
-	b.WriteString("Write a formal Dispute / Supplement Request Letter for an insurance claim.\n\n")
-	b.WriteString("CLAIM DETAILS:\n")
-	b.WriteString(fmt.Sprintf("- Property Address: %s\n", address))
-	b.WriteString(fmt.Sprintf("- Insurance Carrier: %s\n", carrierName))
+	data := &prompts.DisputeLetterData{
+		PropertyAddress: address,
+		CarrierName:     carrierName,
+		IncidentDate:    incidentDate,
+		Today:           time.Now(),
+		AnalysisJSON:    string(analysisJSON),
+		SourceText:      s.groundingText(ctx, report.ClaimID),
+	}
 	if policyNumber != nil {
-		b.WriteString(fmt.Sprintf("- Policy Number: %s\n", *policyNumber))
+		data.PolicyNumber = *policyNumber
 	}
 	if claimNumber != nil {
-		b.WriteString(fmt.Sprintf("- Claim Number: %s\n", *claimNumber))
-	}
-	b.WriteString(fmt.Sprintf("- Incident Date: %s\n", incidentDate.Format("January 2, 2006")))
-	b.WriteString(fmt.Sprintf("- Today's Date: %s\n\n", time.Now().Format("January 2, 2006")))
-	b.WriteString("PM BRAIN ANALYSIS (use this data for the letter):\n")
-	b.WriteString(string(analysisJSON))
-	b.WriteString("\n\n")
-	b.WriteString(`Write a professional business letter that:
-1. Opens with a formal salutation to the insurance carrier's claims department
-2. References the claim number and policy number in the subject line
-3. States clearly that the property manager is disputing the carrier's estimate
-4. For each top_delta_driver: explains the pricing gap with justification
-5. For any coverage_disputes (denied/partial): argues why those items should be covered with supporting reasoning
-6. States the total additional funds requested (total_delta)
-7. Lists the required_next_steps as a formal request to the carrier
-8. Closes professionally, requesting a written response within 10 business days
-9. Uses plain English — no jargon. Firm but professional and respectful tone.
-
-Format as plain text (no markdown). Include today's date at the top.
-Return ONLY the letter text, no preamble or explanation.`)
+		data.ClaimNumber = *claimNumber
+	}
+	rendered, err := prompts.Render(prompts.DisputeLetter, data)
+	if err != nil {
+		return "", err
+	}
 
 	// 5. Call LLM
-	messages := []llm.Message{
-		{
-			Role:    "system",
-			Content: "You are a professional insurance claim specialist. Write formal, persuasive dispute letters. Return only the letter text, no markdown, no preamble.",
-		},
-		{
-			Role:    "user",
-			Content: b.String(),
-		},
-	}
-
-	response, err := s.llmClient.Chat(ctx, messages, 0.3, 2048)
+	messages := rendered.Messages()
+
+	response, err := chatWithDeltas(ctx, s.llmClient, messages, 0.3, 2048, onDelta)
 	if err != nil {
 		return "", fmt.Errorf("LLM API call failed: %w", err)
 	}
@@ -688,9 +825,11 @@ Return ONLY the letter text, no preamble or explanation.`)
 	letterText := response.Choices[0].Message.Content
 
 	// 6. Save to DB
-	_, err = s.db.ExecContext(ctx,
-		`UPDATE audit_reports SET dispute_letter = $1, updated_at = NOW() WHERE id = $2`,
-		letterText, auditReportID,
+	err = s.saveAuditRun(ctx, auditReportID, models.AuditRunDisputeLetter, userID,
+		`UPDATE audit_reports
+		 SET dispute_letter = $1, prompt_versions = prompt_versions || jsonb_build_object($2::text, $3::text), updated_at = NOW()
+		 WHERE id = $4`,
+		letterText, prompts.DisputeLetter, rendered.Version, auditReportID,
 	)
 	if err != nil {
 		return "", fmt.Errorf("failed to save dispute letter: %w", err)
@@ -706,6 +845,16 @@ Return ONLY the letter text, no preamble or explanation.`)
 // owner requesting authorization to escalate to legal. Only valid when pm_brain_analysis
 // status is LEGAL_REVIEW.
 func (s *AuditService) GenerateOwnerPitch(ctx context.Context, auditReportID, userID, orgID string) (string, error) {
+	return s.generateOwnerPitch(ctx, auditReportID, userID, orgID, nil)
+}
+
+// StreamOwnerPitch writes the owner pitch like GenerateOwnerPitch, passing the
+// text to onDelta as the LLM generates it
+func (s *AuditService) StreamOwnerPitch(ctx context.Context, auditReportID, userID, orgID string, onDelta func(text string) error) (string, error) {
+	return s.generateOwnerPitch(ctx, auditReportID, userID, orgID, onDelta)
+}
+
+func (s *AuditService) generateOwnerPitch(ctx context.Context, auditReportID, userID, orgID string, onDelta func(text string) error) (string, error) {
 	// 1. Fetch audit report with ownership check
 	report, err := s.getAuditReportWithOwnershipCheck(ctx, auditReportID, orgID)
 	if err != nil {
@@ -745,64 +894,38 @@ func (s *AuditService) GenerateOwnerPitch(ctx context.Context, auditReportID, us
 	if err != nil {
 		return "", fmt.Errorf("failed to fetch claim context: %w", err)
 	}
-
-	// 4. Build prompt
-	var b strings.Builder
-	b.WriteString("You are drafting an email for a professional Property Manager to send to their building owner regarding an insurance claim dispute.\n\n")
-	b.WriteString("CLAIM CONTEXT:\n")
-	b.WriteString(fmt.Sprintf("- Property Address: %s\n", address))
-	b.WriteString(fmt.Sprintf("- Loss Type: %s on %s\n", lossType, incidentDate.Format("January 2, 2006")))
-	b.WriteString(fmt.Sprintf("- Insurance Carrier: %s\n", carrierName))
-	b.WriteString(fmt.Sprintf("- Policy Deductible: $%.2f\n\n", deductible))
-	b.WriteString("PM BRAIN ANALYSIS:\n")
-	b.WriteString(fmt.Sprintf("- Contractor Estimate: $%.2f\n", analysis.TotalContractorEstimate))
-	b.WriteString(fmt.Sprintf("- Carrier Offer: $%.2f\n", analysis.TotalCarrierEstimate))
-	b.WriteString(fmt.Sprintf("- Gap (Underpayment): $%.2f\n\n", analysis.TotalDelta))
-
-	if len(analysis.TopDeltaDrivers) > 0 {
-		b.WriteString("TOP DELTA DRIVERS:\n")
-		for _, d := range analysis.TopDeltaDrivers {
-			b.WriteString(fmt.Sprintf("- %s: carrier paid $%.2f vs contractor $%.2f (gap: $%.2f) — %s\n",
-				d.LineItem, d.CarrierPrice, d.ContractorPrice, d.Delta, d.Reason))
-		}
-		b.WriteString("\n")
+	resolved, err := resolveClaimDeductible(ctx, s.db, report.ClaimID)
+	if err != nil {
+		return "", err
 	}
+	deductible = resolved.Amount
 
-	if len(analysis.CoverageDisputes) > 0 {
-		b.WriteString("COVERAGE DISPUTES:\n")
-		for _, cd := range analysis.CoverageDisputes {
-			b.WriteString(fmt.Sprintf("- %s (%s): %s\n", cd.Item, cd.Status, cd.ContractorPosition))
-		}
-		b.WriteString("\n")
+	// 4. Build prompt
+	data := &prompts.OwnerPitchData{
+		PropertyAddress:    address,
+		LossType:           lossType,
+		IncidentDate:       incidentDate,
+		CarrierName:        carrierName,
+		Deductible:         deductible,
+		ContractorEstimate: analysis.TotalContractorEstimate,
+		CarrierOffer:       analysis.TotalCarrierEstimate,
+		Gap:                analysis.TotalDelta,
+	}
+	for _, d := range analysis.TopDeltaDrivers {
+		data.DeltaDrivers = append(data.DeltaDrivers, prompts.DeltaDriver(d))
+	}
+	for _, cd := range analysis.CoverageDisputes {
+		data.CoverageDisputes = append(data.CoverageDisputes, prompts.CoverageDispute(cd))
+	}
+	rendered, err := prompts.Render(prompts.OwnerPitch, data)
+	if err != nil {
+		return "", err
 	}
 
-	b.WriteString(`Write a professional email FROM the Property Manager TO the building owner.
-
-Requirements:
-- Open by stating the carrier has made their final offer on the claim
-- Explain the dollar gap in plain English — why this amount is unacceptable
-- Cite 2-3 specific line items or coverage disputes showing why the carrier's offer is unreasonable
-- Recommend they authorize engaging a public adjuster or real estate attorney
-- Close by asking the owner to reply with their approval to proceed
-- Tone: competent, direct, professional — like a trusted advisor, NOT a lawyer
-- Length: 3-4 short paragraphs, no filler phrases
-- Do NOT include a subject line or "Subject:" prefix
-- Do NOT use placeholder text like "[Your Name]" — write as "your property manager"
-- Return ONLY the email body text, no preamble or explanation`)
-
 	// 5. Call LLM
-	messages := []llm.Message{
-		{
-			Role:    "system",
-			Content: "You are a professional property manager writing a clear, persuasive email to a building owner about an insurance dispute. Write in first person as the property manager. Return only the email body, no markdown, no preamble.",
-		},
-		{
-			Role:    "user",
-			Content: b.String(),
-		},
-	}
-
-	response, err := s.llmClient.Chat(ctx, messages, 0.4, 1500)
+	messages := rendered.Messages()
+
+	response, err := chatWithDeltas(ctx, s.llmClient, messages, 0.4, 1500, onDelta)
 	if err != nil {
 		return "", fmt.Errorf("LLM API call failed: %w", err)
 	}
@@ -813,9 +936,11 @@ Requirements:
 	pitchText := response.Choices[0].Message.Content
 
 	// 6. Save to DB
-	_, err = s.db.ExecContext(ctx,
-		`UPDATE audit_reports SET owner_pitch = $1, updated_at = NOW() WHERE id = $2`,
-		pitchText, auditReportID,
+	err = s.saveAuditRun(ctx, auditReportID, models.AuditRunOwnerPitch, userID,
+		`UPDATE audit_reports
+		 SET owner_pitch = $1, prompt_versions = prompt_versions || jsonb_build_object($2::text, $3::text), updated_at = NOW()
+		 WHERE id = $4`,
+		pitchText, prompts.OwnerPitch, rendered.Version, auditReportID,
 	)
 	if err != nil {
 		return "", fmt.Errorf("failed to save owner pitch: %w", err)
@@ -845,7 +970,9 @@ type viabilityInputs struct {
 	incidentDate    time.Time
 	totalRCV        float64
 	deductibleValue float64
+	deductibleBasis string
 	exclusions      string
+	terms           *models.PolicyTerms
 }
 
 // AnalyzeClaimViability runs the PM Decision Engine to produce a 3-tier recommendation
@@ -858,21 +985,13 @@ func (s *AuditService) AnalyzeClaimViability(ctx context.Context, claimID, orgID
 	}
 
 	// 2. Build the PM Brain prompt
-	userPrompt := s.buildViabilityPrompt(inputs)
+	rendered, err := prompts.Render(prompts.Viability, viabilityPromptData(inputs))
+	if err != nil {
+		return nil, err
+	}
 
 	// 3. Call the LLM — low temperature for deterministic scoring
-	messages := []llm.Message{
-		{
-			Role: "system",
-			Content: `You are an expert Public Adjuster and Property Manager with deep knowledge of insurance claim viability assessment.
-Your task is to evaluate claim facts using exact scoring rules and output a structured JSON analysis.
-Always respond with valid JSON only, no additional text or explanations.`,
-		},
-		{
-			Role:    "user",
-			Content: userPrompt,
-		},
-	}
+	messages := rendered.Messages()
 
 	response, err := s.llmClient.Chat(ctx, messages, 0.1, 1000)
 	if err != nil {
@@ -890,11 +1009,16 @@ Always respond with valid JSON only, no additional text or explanations.`,
 		return nil, fmt.Errorf("invalid JSON response from LLM: %w", err)
 	}
 
-	// 5. Persist the result so it survives page reloads
-	_, _ = s.db.ExecContext(ctx,
-		`UPDATE audit_reports SET viability_analysis = $1, updated_at = NOW() WHERE id = $2`,
-		analysisJSON, inputs.auditReportID,
+	// 5. Persist the result so it survives page reloads, and keep it as a run
+	err = s.saveAuditRun(ctx, inputs.auditReportID, models.AuditRunViability, "",
+		`UPDATE audit_reports
+		 SET viability_analysis = $1, prompt_versions = prompt_versions || jsonb_build_object($2::text, $3::text), updated_at = NOW()
+		 WHERE id = $4`,
+		analysisJSON, prompts.Viability, rendered.Version, inputs.auditReportID,
 	)
+	if err != nil {
+		log.Printf("Warning: failed to save viability analysis for claim %s: %v", claimID, err)
+	}
 
 	return &analysis, nil
 }
@@ -942,76 +1066,38 @@ func (s *AuditService) fetchViabilityInputs(ctx context.Context, claimID, orgID
 		return nil, fmt.Errorf("failed to fetch estimate: %w", err)
 	}
 
-	// Extract the `total` field from the estimate JSON blob.
-	var estimateData map[string]interface{}
-	if err := json.Unmarshal([]byte(estimateJSON), &estimateData); err != nil {
-		return nil, fmt.Errorf("failed to parse estimate data: %w", err)
+	estimate, err := pricing.ParseIndustryEstimate(estimateJSON)
+	if err != nil {
+		return nil, fmt.Errorf("industry estimate is invalid — please regenerate it: %w", err)
 	}
-	totalRCV, ok := estimateData["total"].(float64)
-	if !ok {
-		return nil, fmt.Errorf("estimate is missing required 'total' field")
+	inputs.totalRCV = estimate.Total
+
+	inputs.terms, err = confirmedPolicyTerms(ctx, s.db, claimID)
+	if err != nil {
+		return nil, err
 	}
-	inputs.totalRCV = totalRCV
+	// The deductible depends on the loss type and date under per-peril rules
+	resolved, err := resolveClaimDeductible(ctx, s.db, claimID)
+	if err != nil {
+		return nil, err
+	}
+	inputs.deductibleValue, inputs.deductibleBasis = resolved.Amount, resolved.Basis
 
 	return &inputs, nil
 }
 
-// buildViabilityPrompt constructs the PM Brain prompt with claim inputs and explicit scoring rules.
-func (s *AuditService) buildViabilityPrompt(inputs *viabilityInputs) string {
-	var b strings.Builder
-
-	b.WriteString("Evaluate the following insurance claim and return a viability analysis.\n\n")
-
-	b.WriteString("CLAIM FACTS:\n")
-	b.WriteString(fmt.Sprintf("- Loss Type: %s\n", inputs.lossType))
-	b.WriteString(fmt.Sprintf("- Loss Date: %s\n", inputs.incidentDate.Format("January 2, 2006")))
-	b.WriteString(fmt.Sprintf("- Estimated RCV (Replacement Cost Value): $%.2f\n", inputs.totalRCV))
-	b.WriteString("\n")
-
-	b.WriteString("POLICY DETAILS:\n")
-	b.WriteString(fmt.Sprintf("- Deductible: $%.2f\n", inputs.deductibleValue))
-	if inputs.exclusions != "" {
-		b.WriteString(fmt.Sprintf("- Policy Exclusions: %s\n", inputs.exclusions))
-	} else {
-		b.WriteString("- Policy Exclusions: None listed\n")
-	}
-	b.WriteString("\n")
-
-	b.WriteString("SCORING RULES — apply these exactly:\n\n")
-
-	b.WriteString("A. ECONOMICS SCORE (0-100):\n")
-	b.WriteString("Calculate: net_recovery = Estimated RCV - Deductible\n")
-	b.WriteString("Assign score based on net_recovery:\n")
-	b.WriteString("  - net_recovery < $2,500             → Score: 15\n")
-	b.WriteString("  - $2,500 ≤ net_recovery ≤ $7,500   → Score: 35\n")
-	b.WriteString("  - $7,500 < net_recovery ≤ $20,000  → Score: 60\n")
-	b.WriteString("  - net_recovery > $20,000            → Score: 85\n\n")
-
-	b.WriteString("B. COVERAGE RISK SCORE (0-100):\n")
-	b.WriteString("Start at 100. Apply deductions based on your analysis:\n")
-	b.WriteString("  - If the Policy Exclusions EXPLICITLY exclude the Loss Type (e.g., loss is 'Water', exclusions say 'Flood'): Subtract 60\n")
-	b.WriteString("  - If the Loss Type is ambiguous or unclear relative to coverage: Subtract 20\n")
-	b.WriteString("  - If the Loss Type is Water (risk of repeated seepage clause): Subtract 30\n")
-	b.WriteString("  Note: Multiple deductions may apply. Minimum score is 0.\n\n")
-
-	b.WriteString("C. RECOMMENDATION — choose exactly one:\n")
-	b.WriteString("  - PURSUE:                 Coverage Score >= 70 AND Economics Score >= 50\n")
-	b.WriteString("  - PURSUE_WITH_CONDITIONS: Coverage Score 40-69 OR Economics Score 30-49\n")
-	b.WriteString("  - DO_NOT_PURSUE:          Coverage Score < 40 OR Economics Score < 30 OR net_recovery <= 0\n\n")
-
-	b.WriteString("RESPONSE FORMAT:\n")
-	b.WriteString("Return ONLY this JSON object, no other text:\n")
-	b.WriteString("{\n")
-	b.WriteString("  \"recommendation\": \"PURSUE | PURSUE_WITH_CONDITIONS | DO_NOT_PURSUE\",\n")
-	b.WriteString("  \"net_estimated_recovery\": <number>,\n")
-	b.WriteString("  \"coverage_score\": <integer 0-100>,\n")
-	b.WriteString("  \"economics_score\": <integer 0-100>,\n")
-	b.WriteString("  \"top_risks\": [\"<risk 1>\", \"<risk 2>\"],\n")
-	b.WriteString("  \"required_next_steps\": [\"<step 1 if conditions apply>\"],\n")
-	b.WriteString("  \"plain_english_summary\": \"<1-2 sentence plain English summary>\"\n")
-	b.WriteString("}\n")
-
-	return b.String()
+// viabilityPromptData passes the claim inputs to the viability prompt, which
+// holds the explicit scoring rules
+func viabilityPromptData(inputs *viabilityInputs) *prompts.ViabilityData {
+	return &prompts.ViabilityData{
+		LossType:        inputs.lossType,
+		IncidentDate:    inputs.incidentDate,
+		TotalRCV:        inputs.totalRCV,
+		Deductible:      inputs.deductibleValue,
+		DeductibleBasis: inputs.deductibleBasis,
+		Exclusions:      inputs.exclusions,
+		PolicyTerms:     policyTermsText(inputs.terms),
+	}
 }
 
 // extractJSON strips markdown code fences and extracts the JSON object from an LLM response.
@@ -9,9 +9,13 @@ import (
 
 	"github.com/claimcoach/backend/internal/llm"
 	"github.com/claimcoach/backend/internal/models"
+	"github.com/claimcoach/backend/internal/pricing"
+	"github.com/claimcoach/backend/internal/prompts"
+	"github.com/claimcoach/backend/internal/takeoff"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 // MockLLMClient is a mock implementation of the LLM client for testing
@@ -57,7 +61,7 @@ func TestGenerateIndustryEstimate_Success(t *testing.T) {
 	}
 
 	ctx := context.Background()
-	scopeSheet, err := scopeService.CreateScopeSheet(ctx, claimID, input)
+	scopeSheet, err := scopeService.CreateScopeSheet(ctx, claimID, nil, input)
 	assert.NoError(t, err)
 
 	// Create mock LLM client
@@ -125,10 +129,10 @@ func TestGenerateIndustryEstimate_Success(t *testing.T) {
 	mockLLM.On("Chat", ctx, mock.AnythingOfType("[]llm.Message"), 0.2, 2000).Return(mockResponse, nil)
 
 	// Create audit service with mock LLM client
-	auditService := NewAuditService(db, mockLLM, scopeService)
+	auditService := NewAuditService(db, mockLLM, scopeService, nil, nil, nil)
 
 	// Test
-	reportID, err := auditService.GenerateIndustryEstimate(ctx, claimID, userID, orgID)
+	reportID, err := auditService.GenerateIndustryEstimate(ctx, claimID, userID, orgID, false)
 
 	// Assert
 	assert.NoError(t, err)
@@ -200,11 +204,11 @@ func TestGenerateIndustryEstimate_NoScopeSheet(t *testing.T) {
 	// Create mock LLM client and services
 	mockLLM := new(MockLLMClient)
 	scopeService := NewScopeSheetService(db)
-	auditService := NewAuditService(db, mockLLM, scopeService)
+	auditService := NewAuditService(db, mockLLM, scopeService, nil, nil, nil)
 
 	// Test
 	ctx := context.Background()
-	reportID, err := auditService.GenerateIndustryEstimate(ctx, claimID, userID, orgID)
+	reportID, err := auditService.GenerateIndustryEstimate(ctx, claimID, userID, orgID, false)
 
 	// Assert
 	assert.Error(t, err)
@@ -231,7 +235,7 @@ func TestGenerateIndustryEstimate_InvalidJSON(t *testing.T) {
 	}
 
 	ctx := context.Background()
-	_, err := scopeService.CreateScopeSheet(ctx, claimID, input)
+	_, err := scopeService.CreateScopeSheet(ctx, claimID, nil, input)
 	assert.NoError(t, err)
 
 	// Create mock LLM client with invalid JSON response
@@ -270,10 +274,10 @@ func TestGenerateIndustryEstimate_InvalidJSON(t *testing.T) {
 
 	mockLLM.On("Chat", ctx, mock.AnythingOfType("[]llm.Message"), 0.2, 2000).Return(mockResponse, nil)
 
-	auditService := NewAuditService(db, mockLLM, scopeService)
+	auditService := NewAuditService(db, mockLLM, scopeService, nil, nil, nil)
 
 	// Test
-	reportID, err := auditService.GenerateIndustryEstimate(ctx, claimID, userID, orgID)
+	reportID, err := auditService.GenerateIndustryEstimate(ctx, claimID, userID, orgID, false)
 
 	// Assert
 	assert.Error(t, err)
@@ -284,326 +288,45 @@ func TestGenerateIndustryEstimate_InvalidJSON(t *testing.T) {
 }
 
 func TestBuildEstimatePrompt(t *testing.T) {
-	// Setup - no database needed for this test
-	auditService := &AuditService{db: nil, llmClient: nil}
-
 	notes := "Additional damage to fascia boards"
 	scopeSheet := &models.ScopeSheet{
 		Areas: []models.ScopeArea{
 			{
-				Category:   "Roofing",
-				Tags:       []string{"asphalt_shingles", "8/12", "Shingles_Damaged"},
-				Dimensions: map[string]float64{"square_footage": 2500},
-				Notes:      "Steep pitch, full replacement needed",
+				ID:          "area-roof",
+				CategoryKey: "roof",
+				Category:    "Roofing",
+				Tags:        []string{"asphalt_shingles", "8/12", "Shingles_Damaged"},
+				Dimensions:  map[string]float64{"square_footage": 2500, "pitch": 8},
+				Notes:       "Steep pitch, full replacement needed",
 			},
 			{
-				Category:   "Exterior Trim",
-				Tags:       []string{"fascia_damaged", "fascia_paint"},
-				Dimensions: map[string]float64{"length": 200},
+				ID:          "area-gutters",
+				CategoryKey: "gutters",
+				Category:    "Gutters",
+				Tags:        []string{"gutters_damaged"},
+				Dimensions:  map[string]float64{"linear_feet": 200},
 			},
 		},
 		GeneralNotes: &notes,
 	}
 
-	prompt := auditService.buildEstimatePrompt(scopeSheet)
+	rendered, err := prompts.Render(prompts.Estimate, estimatePromptData(scopeSheet, takeoff.Compute(scopeSheet.Areas), pricing.Sorted(pricing.Baseline())))
+	require.NoError(t, err)
+	prompt := rendered.User
 
 	assert.NotEmpty(t, prompt)
 	assert.Contains(t, prompt, "asphalt_shingles")
 	assert.Contains(t, prompt, "2500")
 	assert.Contains(t, prompt, "8/12")
-	assert.Contains(t, prompt, "200")
+	assert.Contains(t, prompt, "gutter: Gutter, 200 LF")
 	assert.Contains(t, prompt, "Additional damage to fascia boards")
-	assert.Contains(t, prompt, "Xactimate-style estimate")
+	assert.Contains(t, prompt, "Computed quantities")
+	assert.Contains(t, rendered.System, "construction estimator")
 	assert.Contains(t, prompt, "JSON")
 	assert.Contains(t, prompt, "line_items")
 	assert.Contains(t, prompt, "category")
-	assert.Contains(t, prompt, "overhead_profit")
-}
-
-func TestCompareEstimates_Success(t *testing.T) {
-	// Setup
-	db := setupTestDB(t)
-	defer db.Close()
-
-	// Create test data
-	orgID := createTestOrg(t, db)
-	userID := createTestUser(t, db, orgID)
-	propertyID := createTestProperty(t, db, orgID)
-	policyID := createTestPolicy(t, db, propertyID, 10000.0)
-	claimID := createTestClaim(t, db, propertyID, policyID, orgID, userID)
-
-	// Create scope sheet
-	scopeService := NewScopeSheetService(db)
-	input := CreateScopeSheetInput{
-		Areas: []models.ScopeArea{
-			{Category: "Roofing", Tags: []string{"asphalt_shingles"}, Dimensions: map[string]float64{"square_footage": 2000}},
-		},
-	}
-
-	ctx := context.Background()
-	scopeSheet, err := scopeService.CreateScopeSheet(ctx, claimID, input)
-	assert.NoError(t, err)
-
-	// Create industry estimate
-	industryEstimateJSON := `{
-		"line_items": [
-			{"description": "Roof shingles", "quantity": 20, "unit": "square", "unit_cost": 350.00, "total": 7000.00, "category": "Roofing"}
-		],
-		"subtotal": 7000.00,
-		"overhead_profit": 1400.00,
-		"total": 8400.00
-	}`
-
-	auditReportID := createTestAuditReport(t, db, claimID, scopeSheet.ID, userID, industryEstimateJSON)
-
-	// Create carrier estimate
-	carrierEstimateJSON := `{
-		"line_items": [
-			{"description": "Roof shingles", "quantity": 20, "unit": "square", "unit_cost": 300.00, "total": 6000.00, "category": "Roofing"}
-		],
-		"subtotal": 6000.00,
-		"overhead_profit": 1200.00,
-		"total": 7200.00
-	}`
-
-	carrierEstimateID := createTestCarrierEstimate(t, db, claimID, userID, carrierEstimateJSON)
-	assert.NotEmpty(t, carrierEstimateID)
-
-	// Create mock LLM client
-	mockLLM := new(MockLLMClient)
-
-	// Prepare comparison response
-	comparisonResponse := map[string]interface{}{
-		"discrepancies": []map[string]interface{}{
-			{
-				"item":            "Roof shingles",
-				"industry_price":  7000.00,
-				"carrier_price":   6000.00,
-				"delta":           1000.00,
-				"justification":   "Industry standard pricing for high-quality shingles",
-			},
-		},
-		"summary": map[string]interface{}{
-			"total_industry": 8400.00,
-			"total_carrier":  7200.00,
-			"total_delta":    1200.00,
-		},
-	}
-
-	comparisonJSONBytes, _ := json.Marshal(comparisonResponse)
-	mockResponse := &llm.ChatResponse{
-		ID:    "test-comparison-id",
-		Model: "llama-3.1-sonar-large-128k-online",
-		Choices: []struct {
-			Index   int `json:"index"`
-			Message struct {
-				Role    string `json:"role"`
-				Content string `json:"content"`
-			} `json:"message"`
-		}{
-			{
-				Index: 0,
-				Message: struct {
-					Role    string `json:"role"`
-					Content string `json:"content"`
-				}{
-					Role:    "assistant",
-					Content: string(comparisonJSONBytes),
-				},
-			},
-		},
-		Usage: struct {
-			PromptTokens     int `json:"prompt_tokens"`
-			CompletionTokens int `json:"completion_tokens"`
-			TotalTokens      int `json:"total_tokens"`
-		}{
-			PromptTokens:     600,
-			CompletionTokens: 400,
-			TotalTokens:      1000,
-		},
-	}
-
-	// Set up mock expectation
-	mockLLM.On("Chat", ctx, mock.AnythingOfType("[]llm.Message"), 0.2, 3000).Return(mockResponse, nil)
-
-	// Create audit service
-	auditService := NewAuditService(db, mockLLM, scopeService)
-
-	// Test
-	err = auditService.CompareEstimates(ctx, auditReportID, userID, orgID)
-
-	// Assert
-	assert.NoError(t, err)
-
-	// Verify the audit report was updated
-	var updatedReport struct {
-		ComparisonData          *string
-		TotalContractorEstimate *float64
-		TotalCarrierEstimate    *float64
-		TotalDelta              *float64
-		Status                  string
-	}
-	query := `SELECT comparison_data, total_contractor_estimate, total_carrier_estimate, total_delta, status
-	          FROM audit_reports WHERE id = $1`
-	err = db.QueryRowContext(ctx, query, auditReportID).Scan(
-		&updatedReport.ComparisonData,
-		&updatedReport.TotalContractorEstimate,
-		&updatedReport.TotalCarrierEstimate,
-		&updatedReport.TotalDelta,
-		&updatedReport.Status,
-	)
-	assert.NoError(t, err)
-	assert.NotNil(t, updatedReport.ComparisonData)
-	assert.Equal(t, 8400.00, *updatedReport.TotalContractorEstimate)
-	assert.Equal(t, 7200.00, *updatedReport.TotalCarrierEstimate)
-	assert.Equal(t, 1200.00, *updatedReport.TotalDelta)
-	assert.Equal(t, models.AuditStatusCompleted, updatedReport.Status)
-
-	// Verify comparison JSON content
-	var parsedComparison map[string]interface{}
-	err = json.Unmarshal([]byte(*updatedReport.ComparisonData), &parsedComparison)
-	assert.NoError(t, err)
-	assert.NotNil(t, parsedComparison["discrepancies"])
-	assert.NotNil(t, parsedComparison["summary"])
-
-	// Verify mock was called
-	mockLLM.AssertExpectations(t)
-}
-
-func TestCompareEstimates_NoIndustryEstimate(t *testing.T) {
-	// Setup
-	db := setupTestDB(t)
-	defer db.Close()
-
-	// Create test data
-	orgID := createTestOrg(t, db)
-	userID := createTestUser(t, db, orgID)
-	propertyID := createTestProperty(t, db, orgID)
-	policyID := createTestPolicy(t, db, propertyID, 10000.0)
-	claimID := createTestClaim(t, db, propertyID, policyID, orgID, userID)
-
-	// Create scope sheet
-	scopeService := NewScopeSheetService(db)
-	input := CreateScopeSheetInput{Areas: []models.ScopeArea{}}
-
-	ctx := context.Background()
-	scopeSheet, err := scopeService.CreateScopeSheet(ctx, claimID, input)
-	assert.NoError(t, err)
-
-	// Create audit report WITHOUT industry estimate
-	auditReportID := createTestAuditReport(t, db, claimID, scopeSheet.ID, userID, "")
-
-	// Create mock LLM client
-	mockLLM := new(MockLLMClient)
-	auditService := NewAuditService(db, mockLLM, scopeService)
-
-	// Test
-	err = auditService.CompareEstimates(ctx, auditReportID, userID, orgID)
-
-	// Assert
-	assert.Error(t, err)
-	assert.Contains(t, err.Error(), "industry estimate not generated yet")
-}
-
-func TestCompareEstimates_NoCarrierEstimate(t *testing.T) {
-	// Setup
-	db := setupTestDB(t)
-	defer db.Close()
-
-	// Create test data
-	orgID := createTestOrg(t, db)
-	userID := createTestUser(t, db, orgID)
-	propertyID := createTestProperty(t, db, orgID)
-	policyID := createTestPolicy(t, db, propertyID, 10000.0)
-	claimID := createTestClaim(t, db, propertyID, policyID, orgID, userID)
-
-	// Create scope sheet
-	scopeService := NewScopeSheetService(db)
-	input := CreateScopeSheetInput{Areas: []models.ScopeArea{}}
-
-	ctx := context.Background()
-	scopeSheet, err := scopeService.CreateScopeSheet(ctx, claimID, input)
-	assert.NoError(t, err)
-
-	// Create audit report with industry estimate
-	industryEstimateJSON := `{"total": 10000.00}`
-	auditReportID := createTestAuditReport(t, db, claimID, scopeSheet.ID, userID, industryEstimateJSON)
-
-	// DO NOT create carrier estimate
-
-	// Create mock LLM client
-	mockLLM := new(MockLLMClient)
-	auditService := NewAuditService(db, mockLLM, scopeService)
-
-	// Test
-	err = auditService.CompareEstimates(ctx, auditReportID, userID, orgID)
-
-	// Assert
-	assert.Error(t, err)
-	assert.Contains(t, err.Error(), "carrier estimate not found")
-}
-
-func TestCompareEstimates_CarrierEstimateNotParsed(t *testing.T) {
-	// Setup
-	db := setupTestDB(t)
-	defer db.Close()
-
-	// Create test data
-	orgID := createTestOrg(t, db)
-	userID := createTestUser(t, db, orgID)
-	propertyID := createTestProperty(t, db, orgID)
-	policyID := createTestPolicy(t, db, propertyID, 10000.0)
-	claimID := createTestClaim(t, db, propertyID, policyID, orgID, userID)
-
-	// Create scope sheet
-	scopeService := NewScopeSheetService(db)
-	input := CreateScopeSheetInput{Areas: []models.ScopeArea{}}
-
-	ctx := context.Background()
-	scopeSheet, err := scopeService.CreateScopeSheet(ctx, claimID, input)
-	assert.NoError(t, err)
-
-	// Create audit report with industry estimate
-	industryEstimateJSON := `{"total": 10000.00}`
-	auditReportID := createTestAuditReport(t, db, claimID, scopeSheet.ID, userID, industryEstimateJSON)
-
-	// Create carrier estimate WITHOUT parsed data
-	carrierEstimateID := createTestCarrierEstimate(t, db, claimID, userID, "")
-	assert.NotEmpty(t, carrierEstimateID)
-
-	// Create mock LLM client
-	mockLLM := new(MockLLMClient)
-	auditService := NewAuditService(db, mockLLM, scopeService)
-
-	// Test
-	err = auditService.CompareEstimates(ctx, auditReportID, userID, orgID)
-
-	// Assert
-	assert.Error(t, err)
-	assert.Contains(t, err.Error(), "carrier estimate not parsed yet")
-}
-
-func TestCompareEstimates_AuditReportNotFound(t *testing.T) {
-	// Setup
-	db := setupTestDB(t)
-	defer db.Close()
-
-	// Create test data
-	orgID := createTestOrg(t, db)
-	userID := createTestUser(t, db, orgID)
-
-	// Create mock LLM client
-	mockLLM := new(MockLLMClient)
-	scopeService := NewScopeSheetService(db)
-	auditService := NewAuditService(db, mockLLM, scopeService)
-
-	// Test with non-existent audit report ID
-	ctx := context.Background()
-	err := auditService.CompareEstimates(ctx, "non-existent-id", userID, orgID)
-
-	// Assert
-	assert.Error(t, err)
-	assert.Contains(t, err.Error(), "audit report not found")
+	assert.Contains(t, prompt, "RFG_SHINGLE_ARCH")
+	assert.Contains(t, prompt, "takeoff_code")
 }
 
 // Helper function to create test audit report
@@ -642,322 +365,6 @@ func createTestAuditReport(t *testing.T, db *sql.DB, claimID, scopeSheetID, user
 	return returnedID
 }
 
-// Helper function to create test carrier estimate
-func createTestCarrierEstimate(t *testing.T, db *sql.DB, claimID, userID, parsedJSON string) string {
-	estimateID := uuid.New().String()
-	now := time.Now()
-
-	var parsedData *string
-	parseStatus := models.ParseStatusPending
-	if parsedJSON != "" {
-		parsedData = &parsedJSON
-		parseStatus = models.ParseStatusCompleted
-	}
-
-	query := `
-		INSERT INTO carrier_estimates (
-			id, claim_id, uploaded_by_user_id, file_path, file_name,
-			parsed_data, parse_status, uploaded_at
-		)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
-		RETURNING id
-	`
-
-	var returnedID string
-	err := db.QueryRow(
-		query,
-		estimateID,
-		claimID,
-		userID,
-		"test/path.pdf",
-		"test.pdf",
-		parsedData,
-		parseStatus,
-		now,
-	).Scan(&returnedID)
-
-	assert.NoError(t, err)
-	return returnedID
-}
-
-func TestGenerateRebuttal_Success(t *testing.T) {
-	// Setup
-	db := setupTestDB(t)
-	defer db.Close()
-
-	// Create test data
-	orgID := createTestOrg(t, db)
-	userID := createTestUser(t, db, orgID)
-	propertyID := createTestProperty(t, db, orgID)
-	policyID := createTestPolicy(t, db, propertyID, 10000.0)
-	claimID := createTestClaim(t, db, propertyID, policyID, orgID, userID)
-
-	// Create scope sheet
-	scopeService := NewScopeSheetService(db)
-	input := CreateScopeSheetInput{Areas: []models.ScopeArea{}}
-
-	ctx := context.Background()
-	scopeSheet, err := scopeService.CreateScopeSheet(ctx, claimID, input)
-	assert.NoError(t, err)
-
-	// Create audit report with comparison data
-	industryEstimateJSON := `{"total": 8400.00}`
-	auditReportID := createTestAuditReport(t, db, claimID, scopeSheet.ID, userID, industryEstimateJSON)
-
-	// Add comparison data to the audit report
-	comparisonData := map[string]interface{}{
-		"discrepancies": []map[string]interface{}{
-			{
-				"item":            "Roof shingles",
-				"industry_price":  7000.00,
-				"carrier_price":   6000.00,
-				"delta":           1000.00,
-				"justification":   "Industry standard pricing for high-quality materials",
-			},
-		},
-		"summary": map[string]interface{}{
-			"total_industry": 8400.00,
-			"total_carrier":  7200.00,
-			"total_delta":    1200.00,
-		},
-	}
-	comparisonJSON, _ := json.Marshal(comparisonData)
-	updateQuery := `UPDATE audit_reports SET comparison_data = $1, total_contractor_estimate = $2,
-	                total_carrier_estimate = $3, total_delta = $4 WHERE id = $5`
-	_, err = db.Exec(updateQuery, string(comparisonJSON), 8400.00, 7200.00, 1200.00, auditReportID)
-	assert.NoError(t, err)
-
-	// Create mock LLM client
-	mockLLM := new(MockLLMClient)
-
-	// Prepare rebuttal response
-	rebuttalContent := `Date: January 15, 2026
-
-To: Insurance Adjuster
-Subject: Request for Reconsideration - Claim #12345
-
-Dear Adjuster,
-
-I am writing to request a reconsideration of the estimate provided for the property damage claim at 123 Main Street.
-
-After careful review and comparison with industry-standard pricing, we have identified the following discrepancies:
-
-1. Roof Shingles:
-   - Industry Standard Price: $7,000.00
-   - Carrier Estimate: $6,000.00
-   - Difference: $1,000.00
-   - Justification: Industry standard pricing for high-quality materials reflects current market rates for comparable materials and labor.
-
-The total difference between the industry-standard estimate ($8,400.00) and the carrier estimate ($7,200.00) is $1,200.00.
-
-We respectfully request a meeting to discuss these discrepancies and work toward a fair resolution.
-
-Thank you for your consideration.
-
-Sincerely,`
-
-	mockResponse := &llm.ChatResponse{
-		ID:    "test-rebuttal-id",
-		Model: "llama-3.1-sonar-large-128k-online",
-		Choices: []struct {
-			Index   int `json:"index"`
-			Message struct {
-				Role    string `json:"role"`
-				Content string `json:"content"`
-			} `json:"message"`
-		}{
-			{
-				Index: 0,
-				Message: struct {
-					Role    string `json:"role"`
-					Content string `json:"content"`
-				}{
-					Role:    "assistant",
-					Content: rebuttalContent,
-				},
-			},
-		},
-		Usage: struct {
-			PromptTokens     int `json:"prompt_tokens"`
-			CompletionTokens int `json:"completion_tokens"`
-			TotalTokens      int `json:"total_tokens"`
-		}{
-			PromptTokens:     800,
-			CompletionTokens: 500,
-			TotalTokens:      1300,
-		},
-	}
-
-	// Set up mock expectation
-	mockLLM.On("Chat", ctx, mock.AnythingOfType("[]llm.Message"), 0.3, 2000).Return(mockResponse, nil)
-
-	// Create audit service
-	auditService := NewAuditService(db, mockLLM, scopeService)
-
-	// Test
-	rebuttalID, err := auditService.GenerateRebuttal(ctx, auditReportID, userID, orgID)
-
-	// Assert
-	assert.NoError(t, err)
-	assert.NotEmpty(t, rebuttalID)
-
-	// Verify the rebuttal was created
-	var rebuttal models.Rebuttal
-	query := `SELECT id, audit_report_id, content FROM rebuttals WHERE id = $1`
-	err = db.QueryRowContext(ctx, query, rebuttalID).Scan(
-		&rebuttal.ID,
-		&rebuttal.AuditReportID,
-		&rebuttal.Content,
-	)
-	assert.NoError(t, err)
-	assert.Equal(t, auditReportID, rebuttal.AuditReportID)
-	assert.Contains(t, rebuttal.Content, "Request for Reconsideration")
-	assert.Contains(t, rebuttal.Content, "$1,200.00")
-	assert.Contains(t, rebuttal.Content, "Roof Shingles")
-
-	// Verify API usage was logged
-	var apiLogCount int
-	logCountQuery := `SELECT COUNT(*) FROM api_usage_logs WHERE organization_id = $1`
-	err = db.QueryRowContext(ctx, logCountQuery, orgID).Scan(&apiLogCount)
-	assert.NoError(t, err)
-	assert.Greater(t, apiLogCount, 0)
-
-	// Verify mock was called
-	mockLLM.AssertExpectations(t)
-}
-
-func TestGenerateRebuttal_NoComparisonData(t *testing.T) {
-	// Setup
-	db := setupTestDB(t)
-	defer db.Close()
-
-	// Create test data
-	orgID := createTestOrg(t, db)
-	userID := createTestUser(t, db, orgID)
-	propertyID := createTestProperty(t, db, orgID)
-	policyID := createTestPolicy(t, db, propertyID, 10000.0)
-	claimID := createTestClaim(t, db, propertyID, policyID, orgID, userID)
-
-	// Create scope sheet
-	scopeService := NewScopeSheetService(db)
-	input := CreateScopeSheetInput{Areas: []models.ScopeArea{}}
-
-	ctx := context.Background()
-	scopeSheet, err := scopeService.CreateScopeSheet(ctx, claimID, input)
-	assert.NoError(t, err)
-
-	// Create audit report WITHOUT comparison data
-	industryEstimateJSON := `{"total": 8400.00}`
-	auditReportID := createTestAuditReport(t, db, claimID, scopeSheet.ID, userID, industryEstimateJSON)
-
-	// Create mock LLM client
-	mockLLM := new(MockLLMClient)
-	auditService := NewAuditService(db, mockLLM, scopeService)
-
-	// Test
-	rebuttalID, err := auditService.GenerateRebuttal(ctx, auditReportID, userID, orgID)
-
-	// Assert
-	assert.Error(t, err)
-	assert.Empty(t, rebuttalID)
-	assert.Contains(t, err.Error(), "comparison data not available")
-}
-
-func TestGenerateRebuttal_AuditReportNotFound(t *testing.T) {
-	// Setup
-	db := setupTestDB(t)
-	defer db.Close()
-
-	// Create test data
-	orgID := createTestOrg(t, db)
-	userID := createTestUser(t, db, orgID)
-
-	// Create mock LLM client
-	mockLLM := new(MockLLMClient)
-	scopeService := NewScopeSheetService(db)
-	auditService := NewAuditService(db, mockLLM, scopeService)
-
-	// Test with non-existent audit report
-	ctx := context.Background()
-	rebuttalID, err := auditService.GenerateRebuttal(ctx, "non-existent-id", userID, orgID)
-
-	// Assert
-	assert.Error(t, err)
-	assert.Empty(t, rebuttalID)
-	assert.Contains(t, err.Error(), "audit report not found")
-}
-
-func TestGetRebuttal_Success(t *testing.T) {
-	// Setup
-	db := setupTestDB(t)
-	defer db.Close()
-
-	// Create test data
-	orgID := createTestOrg(t, db)
-	userID := createTestUser(t, db, orgID)
-	propertyID := createTestProperty(t, db, orgID)
-	policyID := createTestPolicy(t, db, propertyID, 10000.0)
-	claimID := createTestClaim(t, db, propertyID, policyID, orgID, userID)
-
-	// Create scope sheet
-	scopeService := NewScopeSheetService(db)
-	input := CreateScopeSheetInput{Areas: []models.ScopeArea{}}
-
-	ctx := context.Background()
-	scopeSheet, err := scopeService.CreateScopeSheet(ctx, claimID, input)
-	assert.NoError(t, err)
-
-	// Create audit report
-	industryEstimateJSON := `{"total": 8400.00}`
-	auditReportID := createTestAuditReport(t, db, claimID, scopeSheet.ID, userID, industryEstimateJSON)
-
-	// Create rebuttal directly
-	rebuttalID := uuid.New().String()
-	rebuttalContent := "Test rebuttal letter content"
-	now := time.Now()
-	insertQuery := `INSERT INTO rebuttals (id, audit_report_id, content, created_at, updated_at)
-	                VALUES ($1, $2, $3, $4, $5)`
-	_, err = db.Exec(insertQuery, rebuttalID, auditReportID, rebuttalContent, now, now)
-	assert.NoError(t, err)
-
-	// Create mock LLM client
-	mockLLM := new(MockLLMClient)
-	auditService := NewAuditService(db, mockLLM, scopeService)
-
-	// Test
-	rebuttal, err := auditService.GetRebuttal(ctx, rebuttalID, orgID)
-
-	// Assert
-	assert.NoError(t, err)
-	assert.NotNil(t, rebuttal)
-	assert.Equal(t, rebuttalID, rebuttal.ID)
-	assert.Equal(t, auditReportID, rebuttal.AuditReportID)
-	assert.Equal(t, rebuttalContent, rebuttal.Content)
-}
-
-func TestGetRebuttal_NotFound(t *testing.T) {
-	// Setup
-	db := setupTestDB(t)
-	defer db.Close()
-
-	// Create test data
-	orgID := createTestOrg(t, db)
-
-	// Create mock LLM client
-	mockLLM := new(MockLLMClient)
-	scopeService := NewScopeSheetService(db)
-	auditService := NewAuditService(db, mockLLM, scopeService)
-
-	// Test with non-existent rebuttal
-	ctx := context.Background()
-	rebuttal, err := auditService.GetRebuttal(ctx, "non-existent-id", orgID)
-
-	// Assert
-	assert.Error(t, err)
-	assert.Nil(t, rebuttal)
-	assert.Contains(t, err.Error(), "rebuttal not found")
-}
-
 // ---- Viability Analysis test helpers ----
 
 // createTestPolicyWithExclusions creates a test policy with an exclusions text block
@@ -1033,7 +440,7 @@ func TestAnalyzeClaimViability_PURSUE(t *testing.T) {
 
 	// Estimate total = $25,000 → net_recovery = $20,000 → economics score 85
 	scopeService := NewScopeSheetService(db)
-	scopeSheet, err := scopeService.CreateScopeSheet(ctx, claimID, CreateScopeSheetInput{Areas: []models.ScopeArea{}})
+	scopeSheet, err := scopeService.CreateScopeSheet(ctx, claimID, nil, CreateScopeSheetInput{Areas: []models.ScopeArea{}})
 	assert.NoError(t, err)
 	estimateJSON := `{"line_items":[],"subtotal":20833,"overhead_profit":4167,"total":25000}`
 	createTestAuditReport(t, db, claimID, scopeSheet.ID, userID, estimateJSON)
@@ -1053,7 +460,7 @@ func TestAnalyzeClaimViability_PURSUE(t *testing.T) {
 	mockLLM.On("Chat", ctx, mock.AnythingOfType("[]llm.Message"), 0.1, 1000).
 		Return(makeMockViabilityResponse(string(responseBytes)), nil)
 
-	auditService := NewAuditService(db, mockLLM, scopeService)
+	auditService := NewAuditService(db, mockLLM, scopeService, nil, nil, nil)
 	analysis, err := auditService.AnalyzeClaimViability(ctx, claimID, orgID)
 
 	assert.NoError(t, err)
@@ -1080,7 +487,7 @@ func TestAnalyzeClaimViability_PursueWithConditions(t *testing.T) {
 
 	// Estimate total = $14,000 → net_recovery = $11,000 → economics score 60
 	scopeService := NewScopeSheetService(db)
-	scopeSheet, err := scopeService.CreateScopeSheet(ctx, claimID, CreateScopeSheetInput{Areas: []models.ScopeArea{}})
+	scopeSheet, err := scopeService.CreateScopeSheet(ctx, claimID, nil, CreateScopeSheetInput{Areas: []models.ScopeArea{}})
 	assert.NoError(t, err)
 	estimateJSON := `{"line_items":[],"subtotal":11667,"overhead_profit":2333,"total":14000}`
 	createTestAuditReport(t, db, claimID, scopeSheet.ID, userID, estimateJSON)
@@ -1100,7 +507,7 @@ func TestAnalyzeClaimViability_PursueWithConditions(t *testing.T) {
 	mockLLM.On("Chat", ctx, mock.AnythingOfType("[]llm.Message"), 0.1, 1000).
 		Return(makeMockViabilityResponse(string(responseBytes)), nil)
 
-	auditService := NewAuditService(db, mockLLM, scopeService)
+	auditService := NewAuditService(db, mockLLM, scopeService, nil, nil, nil)
 	analysis, err := auditService.AnalyzeClaimViability(ctx, claimID, orgID)
 
 	assert.NoError(t, err)
@@ -1121,7 +528,7 @@ func TestAnalyzeClaimViability_ClaimNotFound(t *testing.T) {
 	orgID := createTestOrg(t, db)
 	mockLLM := new(MockLLMClient)
 	scopeService := NewScopeSheetService(db)
-	auditService := NewAuditService(db, mockLLM, scopeService)
+	auditService := NewAuditService(db, mockLLM, scopeService, nil, nil, nil)
 
 	analysis, err := auditService.AnalyzeClaimViability(ctx, "non-existent-claim-id", orgID)
 
@@ -1144,7 +551,7 @@ func TestAnalyzeClaimViability_NoEstimate(t *testing.T) {
 
 	mockLLM := new(MockLLMClient)
 	scopeService := NewScopeSheetService(db)
-	auditService := NewAuditService(db, mockLLM, scopeService)
+	auditService := NewAuditService(db, mockLLM, scopeService, nil, nil, nil)
 
 	analysis, err := auditService.AnalyzeClaimViability(ctx, claimID, orgID)
 
@@ -1154,8 +561,6 @@ func TestAnalyzeClaimViability_NoEstimate(t *testing.T) {
 }
 
 func TestBuildViabilityPrompt(t *testing.T) {
-	auditService := &AuditService{db: nil, llmClient: nil}
-
 	inputs := &viabilityInputs{
 		lossType:        "Water",
 		incidentDate:    time.Date(2025, 6, 15, 0, 0, 0, 0, time.UTC),
@@ -1164,7 +569,9 @@ func TestBuildViabilityPrompt(t *testing.T) {
 		exclusions:      "Flood and surface water damage excluded",
 	}
 
-	prompt := auditService.buildViabilityPrompt(inputs)
+	rendered, err := prompts.Render(prompts.Viability, viabilityPromptData(inputs))
+	require.NoError(t, err)
+	prompt := rendered.User
 
 	assert.Contains(t, prompt, "Water")
 	assert.Contains(t, prompt, "$15000.00")
@@ -29,6 +29,9 @@ type RequestCarrierEstimateUploadURLInput struct {
 	FileName string `json:"file_name" binding:"required"`
 	FileSize int64  `json:"file_size" binding:"required"`
 	MimeType string `json:"mime_type" binding:"required"`
+	// SupersedesEstimateID is optional: a confirmed upload always supersedes the
+	// claim's current carrier estimate. When set it must name that estimate.
+	SupersedesEstimateID *string `json:"supersedes_estimate_id"`
 }
 
 type CarrierEstimateUploadURLResponse struct {
@@ -56,20 +59,39 @@ func (s *CarrierEstimateService) RequestUploadURL(claimID string, organizationID
 		return nil, err
 	}
 
+	if input.SupersedesEstimateID != nil {
+		var isCurrent bool
+		err := s.db.QueryRow(
+			`SELECT is_current FROM carrier_estimates WHERE id = $1 AND claim_id = $2`,
+			*input.SupersedesEstimateID, claim.ID,
+		).Scan(&isCurrent)
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("carrier estimate not found")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to load superseded carrier estimate: %w", err)
+		}
+		if !isCurrent {
+			return nil, models.ErrSupersedeNotCurrent
+		}
+	}
+
 	// Generate presigned upload URL with carrier-estimate document type
 	uploadURL, filePath, err := s.storage.GenerateUploadURL(organizationID, claimID, "carrier-estimate", input.FileName)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate upload URL: %w", err)
 	}
 
-	// Create pending carrier estimate record
+	// Create pending carrier estimate record. Version 0 marks it unconfirmed; it
+	// becomes the current version on confirm.
 	estimateID := uuid.New().String()
 	query := `
 		INSERT INTO carrier_estimates (
 			id, claim_id, uploaded_by_user_id, file_path,
-			file_name, file_size_bytes, parse_status, uploaded_at
+			file_name, file_size_bytes, parse_status, uploaded_at,
+			supersedes_estimate_id, version, is_current
 		)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, 0, false)
 		RETURNING id
 	`
 
@@ -83,6 +105,7 @@ func (s *CarrierEstimateService) RequestUploadURL(claimID string, organizationID
 		input.FileSize,
 		models.ParseStatusPending,
 		time.Now(),
+		input.SupersedesEstimateID,
 	).Scan(&estimateID)
 
 	if err != nil {
@@ -96,7 +119,9 @@ func (s *CarrierEstimateService) RequestUploadURL(claimID string, organizationID
 	}, nil
 }
 
-// ConfirmUpload marks a carrier estimate as confirmed after successful upload
+// ConfirmUpload marks a carrier estimate as confirmed after successful upload and
+// makes it the current version, superseding the claim's previous carrier estimate.
+// Confirming an estimate that was already confirmed is a no-op.
 func (s *CarrierEstimateService) ConfirmUpload(claimID string, estimateID string, organizationID string) (*models.CarrierEstimate, error) {
 	// Verify claim ownership
 	_, err := s.claimService.GetClaim(claimID, organizationID)
@@ -104,17 +129,32 @@ func (s *CarrierEstimateService) ConfirmUpload(claimID string, estimateID string
 		return nil, err
 	}
 
+	ctx := context.Background()
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Lock the claim so concurrent confirms promote one version at a time
+	// instead of each finding no current estimate to supersede
+	if err := lockClaim(ctx, tx, claimID); err != nil {
+		return nil, err
+	}
+
 	// Query the carrier estimate to return
 	query := `
 		SELECT id, claim_id, uploaded_by_user_id, file_path, file_name,
 			file_size_bytes, parsed_data, parse_status, parse_error,
-			uploaded_at, parsed_at
+			uploaded_at, parsed_at, version, supersedes_estimate_id, is_current,
+			page_count, parse_chunks, stated_total, totals_match
 		FROM carrier_estimates
 		WHERE id = $1 AND claim_id = $2
+		FOR UPDATE
 	`
 
 	var estimate models.CarrierEstimate
-	err = s.db.QueryRow(query, estimateID, claimID).Scan(
+	err = tx.QueryRowContext(ctx, query, estimateID, claimID).Scan(
 		&estimate.ID,
 		&estimate.ClaimID,
 		&estimate.UploadedByUserID,
@@ -126,6 +166,13 @@ func (s *CarrierEstimateService) ConfirmUpload(claimID string, estimateID string
 		&estimate.ParseError,
 		&estimate.UploadedAt,
 		&estimate.ParsedAt,
+		&estimate.Version,
+		&estimate.SupersedesEstimateID,
+		&estimate.IsCurrent,
+		&estimate.PageCount,
+		&estimate.ParseChunks,
+		&estimate.StatedTotal,
+		&estimate.TotalsMatch,
 	)
 
 	if err == sql.ErrNoRows {
@@ -135,11 +182,76 @@ func (s *CarrierEstimateService) ConfirmUpload(claimID string, estimateID string
 		return nil, fmt.Errorf("failed to confirm carrier estimate: %w", err)
 	}
 
+	if estimate.Version > 0 {
+		return &estimate, nil
+	}
+
+	if err := promoteCarrierEstimateVersion(ctx, tx, &estimate); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit carrier estimate confirmation: %w", err)
+	}
+
 	return &estimate, nil
 }
 
-// GetCarrierEstimatesByClaimID retrieves all carrier estimates for a claim with organization verification
-func (s *CarrierEstimateService) GetCarrierEstimatesByClaimID(ctx context.Context, claimID string, organizationID string) ([]models.CarrierEstimate, error) {
+// promoteCarrierEstimateVersion retires the claim's current carrier estimate (or
+// the explicitly superseded one) and makes estimate the current version.
+func promoteCarrierEstimateVersion(ctx context.Context, tx *sql.Tx, estimate *models.CarrierEstimate) error {
+	var previousID string
+	var previousVersion int
+	var err error
+	if estimate.SupersedesEstimateID != nil {
+		err = tx.QueryRowContext(ctx, `
+			UPDATE carrier_estimates
+			SET is_current = false
+			WHERE id = $1 AND claim_id = $2 AND is_current
+			RETURNING id, version
+		`, *estimate.SupersedesEstimateID, estimate.ClaimID).Scan(&previousID, &previousVersion)
+		if err == sql.ErrNoRows {
+			return models.ErrSupersedeNotCurrent
+		}
+	} else {
+		err = tx.QueryRowContext(ctx, `
+			UPDATE carrier_estimates
+			SET is_current = false
+			WHERE claim_id = $1 AND is_current AND id <> $2
+			RETURNING id, version
+		`, estimate.ClaimID, estimate.ID).Scan(&previousID, &previousVersion)
+		if err == sql.ErrNoRows {
+			// First carrier estimate for this claim
+			err = nil
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to retire previous carrier estimate: %w", err)
+	}
+
+	var supersedesID *string
+	if previousID != "" {
+		supersedesID = &previousID
+	}
+	version := previousVersion + 1
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE carrier_estimates
+		SET version = $1, supersedes_estimate_id = $2, is_current = true
+		WHERE id = $3
+	`, version, supersedesID, estimate.ID)
+	if err != nil {
+		return fmt.Errorf("failed to promote carrier estimate: %w", err)
+	}
+
+	estimate.Version = version
+	estimate.SupersedesEstimateID = supersedesID
+	estimate.IsCurrent = true
+	return nil
+}
+
+// GetCarrierEstimatesByClaimID retrieves the carrier estimates for a claim with organization verification.
+// Only the current version is returned unless includeSuperseded is set; unconfirmed uploads are never returned.
+func (s *CarrierEstimateService) GetCarrierEstimatesByClaimID(ctx context.Context, claimID string, organizationID string, includeSuperseded bool) ([]models.CarrierEstimate, error) {
 	// Verify claim ownership
 	_, err := s.claimService.GetClaim(claimID, organizationID)
 	if err != nil {
@@ -149,13 +261,14 @@ func (s *CarrierEstimateService) GetCarrierEstimatesByClaimID(ctx context.Contex
 	query := `
 		SELECT id, claim_id, uploaded_by_user_id, file_path, file_name,
 			file_size_bytes, parsed_data, parse_status, parse_error,
-			uploaded_at, parsed_at
+			uploaded_at, parsed_at, version, supersedes_estimate_id, is_current,
+			page_count, parse_chunks, stated_total, totals_match
 		FROM carrier_estimates
-		WHERE claim_id = $1
+		WHERE claim_id = $1 AND (is_current OR ($2 AND version > 0))
 		ORDER BY uploaded_at DESC
 	`
 
-	rows, err := s.db.QueryContext(ctx, query, claimID)
+	rows, err := s.db.QueryContext(ctx, query, claimID, includeSuperseded)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query carrier estimates: %w", err)
 	}
@@ -176,6 +289,13 @@ func (s *CarrierEstimateService) GetCarrierEstimatesByClaimID(ctx context.Contex
 			&estimate.ParseError,
 			&estimate.UploadedAt,
 			&estimate.ParsedAt,
+			&estimate.Version,
+			&estimate.SupersedesEstimateID,
+			&estimate.IsCurrent,
+			&estimate.PageCount,
+			&estimate.ParseChunks,
+			&estimate.StatedTotal,
+			&estimate.TotalsMatch,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan carrier estimate: %w", err)
@@ -190,21 +310,111 @@ func (s *CarrierEstimateService) GetCarrierEstimatesByClaimID(ctx context.Contex
 	return estimates, nil
 }
 
-// CreateCarrierEstimate creates a new carrier estimate record
+// GetCarrierEstimateVersions returns every version in the supersede chain that
+// contains estimateID, newest first.
+func (s *CarrierEstimateService) GetCarrierEstimateVersions(ctx context.Context, claimID string, estimateID string, organizationID string) ([]models.CarrierEstimate, error) {
+	// Verify claim ownership
+	_, err := s.claimService.GetClaim(claimID, organizationID)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		WITH RECURSIVE back AS (
+			SELECT id, supersedes_estimate_id FROM carrier_estimates WHERE id = $1 AND claim_id = $2
+			UNION ALL
+			SELECT ce.id, ce.supersedes_estimate_id
+			FROM carrier_estimates ce
+			INNER JOIN back b ON ce.id = b.supersedes_estimate_id
+		),
+		chain AS (
+			SELECT id FROM back WHERE supersedes_estimate_id IS NULL
+			UNION
+			SELECT ce.id
+			FROM carrier_estimates ce
+			INNER JOIN chain ch ON ce.supersedes_estimate_id = ch.id
+		)
+		SELECT ce.id, ce.claim_id, ce.uploaded_by_user_id, ce.file_path, ce.file_name,
+			ce.file_size_bytes, ce.parsed_data, ce.parse_status, ce.parse_error,
+			ce.uploaded_at, ce.parsed_at, ce.version, ce.supersedes_estimate_id, ce.is_current,
+			ce.page_count, ce.parse_chunks, ce.stated_total, ce.totals_match
+		FROM carrier_estimates ce
+		INNER JOIN chain ch ON ce.id = ch.id
+		WHERE ce.version > 0
+		ORDER BY ce.version DESC, ce.uploaded_at DESC
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, estimateID, claimID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query carrier estimate versions: %w", err)
+	}
+	defer rows.Close()
+
+	versions := []models.CarrierEstimate{}
+	for rows.Next() {
+		var estimate models.CarrierEstimate
+		err := rows.Scan(
+			&estimate.ID,
+			&estimate.ClaimID,
+			&estimate.UploadedByUserID,
+			&estimate.FilePath,
+			&estimate.FileName,
+			&estimate.FileSizeBytes,
+			&estimate.ParsedData,
+			&estimate.ParseStatus,
+			&estimate.ParseError,
+			&estimate.UploadedAt,
+			&estimate.ParsedAt,
+			&estimate.Version,
+			&estimate.SupersedesEstimateID,
+			&estimate.IsCurrent,
+			&estimate.PageCount,
+			&estimate.ParseChunks,
+			&estimate.StatedTotal,
+			&estimate.TotalsMatch,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan carrier estimate version: %w", err)
+		}
+		versions = append(versions, estimate)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate carrier estimate versions: %w", err)
+	}
+
+	if len(versions) == 0 {
+		return nil, fmt.Errorf("carrier estimate not found")
+	}
+	return versions, nil
+}
+
+// CreateCarrierEstimate records an already uploaded carrier estimate and makes
+// it the claim's current version, superseding the previous one
 func (s *CarrierEstimateService) CreateCarrierEstimate(ctx context.Context, claimID string, uploadedByUserID string, filePath string, fileName string, fileSize int64) (*models.CarrierEstimate, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := lockClaim(ctx, tx, claimID); err != nil {
+		return nil, err
+	}
+
 	estimateID := uuid.New().String()
 	query := `
 		INSERT INTO carrier_estimates (
 			id, claim_id, uploaded_by_user_id, file_path,
-			file_name, file_size_bytes, parse_status, uploaded_at
+			file_name, file_size_bytes, parse_status, uploaded_at,
+			version, is_current
 		)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, 0, false)
 		RETURNING id, claim_id, uploaded_by_user_id, file_path, file_name,
 			file_size_bytes, parse_status, uploaded_at
 	`
 
 	var estimate models.CarrierEstimate
-	err := s.db.QueryRowContext(
+	err = tx.QueryRowContext(
 		ctx,
 		query,
 		estimateID,
@@ -230,5 +440,12 @@ func (s *CarrierEstimateService) CreateCarrierEstimate(ctx context.Context, clai
 		return nil, fmt.Errorf("failed to create carrier estimate: %w", err)
 	}
 
+	if err := promoteCarrierEstimateVersion(ctx, tx, &estimate); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit carrier estimate: %w", err)
+	}
+
 	return &estimate, nil
 }
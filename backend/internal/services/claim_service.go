@@ -1,6 +1,7 @@
 package services
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
 	"fmt"
@@ -12,10 +13,11 @@ import (
 )
 
 type ComparisonResult struct {
-	Deductible     float64 `json:"deductible"`
-	Estimate       float64 `json:"estimate"`
-	Delta          float64 `json:"delta"`
-	Recommendation string  `json:"recommendation"`
+	Deductible      float64 `json:"deductible"`
+	DeductibleBasis string  `json:"deductible_basis"`
+	Estimate        float64 `json:"estimate"`
+	Delta           float64 `json:"delta"`
+	Recommendation  string  `json:"recommendation"`
 }
 
 type ClaimService struct {
@@ -216,7 +218,7 @@ func (s *ClaimService) GetClaims(organizationID string, statusFilter *string, pr
 			c.deductible_comparison_result, c.insurance_claim_number, c.inspection_datetime,
 			c.assigned_user_id, c.adjuster_name, c.adjuster_phone,
 			c.meeting_datetime, c.created_by_user_id, c.created_at, c.updated_at,
-			c.contractor_estimate_total
+			c.contractor_estimate_total, c.contractor_id
 		FROM claims c
 		INNER JOIN properties p ON c.property_id = p.id
 		WHERE p.organization_id = $1
@@ -274,6 +276,7 @@ func (s *ClaimService) GetClaims(organizationID string, statusFilter *string, pr
 			&claim.CreatedAt,
 			&claim.UpdatedAt,
 			&claim.ContractorEstimateTotal,
+			&claim.ContractorID,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan claim: %w", err)
@@ -296,7 +299,7 @@ func (s *ClaimService) GetClaim(claimID string, organizationID string) (*models.
 			c.deductible_comparison_result, c.insurance_claim_number, c.inspection_datetime,
 			c.assigned_user_id, c.adjuster_name, c.adjuster_phone,
 			c.meeting_datetime, c.created_by_user_id, c.created_at, c.updated_at,
-			c.contractor_estimate_total
+			c.contractor_estimate_total, c.contractor_id
 		FROM claims c
 		INNER JOIN properties p ON c.property_id = p.id
 		WHERE c.id = $1 AND p.organization_id = $2
@@ -329,6 +332,7 @@ func (s *ClaimService) GetClaim(claimID string, organizationID string) (*models.
 		&claim.CreatedAt,
 		&claim.UpdatedAt,
 		&claim.ContractorEstimateTotal,
+		&claim.ContractorID,
 	)
 
 	if err == sql.ErrNoRows {
@@ -546,14 +550,13 @@ func (s *ClaimService) UpdateEstimate(
 			c.deductible_comparison_result, c.insurance_claim_number, c.inspection_datetime,
 			c.assigned_user_id, c.adjuster_name, c.adjuster_phone,
 			c.meeting_datetime, c.created_by_user_id, c.created_at, c.updated_at,
-			c.contractor_estimate_total, p.deductible_value
+			c.contractor_estimate_total
 		FROM claims c
 		JOIN insurance_policies p ON p.id = c.policy_id
 		WHERE c.id = $1
 	`
 
 	var claim models.Claim
-	var deductible float64
 	err := s.db.QueryRow(query, claimID).Scan(
 		&claim.ID,
 		&claim.PropertyID,
@@ -580,7 +583,6 @@ func (s *ClaimService) UpdateEstimate(
 		&claim.CreatedAt,
 		&claim.UpdatedAt,
 		&claim.ContractorEstimateTotal,
-		&deductible,
 	)
 	if err == sql.ErrNoRows {
 		return nil, nil, fmt.Errorf("claim not found")
@@ -598,6 +600,12 @@ func (s *ClaimService) UpdateEstimate(
 		return nil, nil, fmt.Errorf("unauthorized")
 	}
 
+	// Resolve the deductible for this loss type and date
+	resolved, err := resolveClaimDeductible(context.Background(), s.db, claimID)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	// Update estimate
 	updateQuery := `
 		UPDATE claims
@@ -614,6 +622,7 @@ func (s *ClaimService) UpdateEstimate(
 	}
 
 	// Calculate comparison
+	deductible := resolved.Amount
 	delta := estimateTotal - deductible
 	recommendation := "not_worth_filing"
 	if delta > 0 {
@@ -621,20 +630,22 @@ func (s *ClaimService) UpdateEstimate(
 	}
 
 	comparison := &ComparisonResult{
-		Deductible:     deductible,
-		Estimate:       estimateTotal,
-		Delta:          delta,
-		Recommendation: recommendation,
+		Deductible:      deductible,
+		DeductibleBasis: resolved.Basis,
+		Estimate:        estimateTotal,
+		Delta:           delta,
+		Recommendation:  recommendation,
 	}
 
 	// Log activity
 	err = s.logActivity(claimID, &userID, "estimate_entered",
 		fmt.Sprintf("Contractor estimate entered: $%.2f", estimateTotal),
 		map[string]interface{}{
-			"estimate_total": estimateTotal,
-			"deductible":     deductible,
-			"delta":          delta,
-			"recommendation": recommendation,
+			"estimate_total":   estimateTotal,
+			"deductible":       deductible,
+			"deductible_basis": resolved.Basis,
+			"delta":            delta,
+			"recommendation":   recommendation,
 		},
 	)
 	if err != nil {
@@ -678,6 +689,7 @@ type UpdateClaimStepInput struct {
 	CurrentStep                *int      `json:"current_step"`
 	StepsCompleted             *[]int    `json:"steps_completed"`
 	Description                *string   `json:"description"`
+	ContractorID               *string   `json:"contractor_id"` // empty string clears the contractor
 	ContractorEmail            *string   `json:"contractor_email"`
 	ContractorName             *string   `json:"contractor_name"`
 	ContractorEstimateTotal    *float64  `json:"contractor_estimate_total"`
@@ -696,6 +708,12 @@ func (s *ClaimService) UpdateClaimStep(claimID string, organizationID string, in
 		return nil, err
 	}
 
+	// Resolve the contractor from the directory; explicit name/email still win
+	contractorID, err := s.resolveClaimContractor(organizationID, &input)
+	if err != nil {
+		return nil, err
+	}
+
 	// Build dynamic update query
 	query := `UPDATE claims SET updated_at = $1`
 	args := []interface{}{time.Now()}
@@ -717,6 +735,13 @@ func (s *ClaimService) UpdateClaimStep(claimID string, organizationID string, in
 		args = append(args, *input.Description)
 		paramIndex++
 	}
+	if contractorID != nil {
+		query += fmt.Sprintf(", contractor_id = $%d", paramIndex)
+		args = append(args, *contractorID)
+		paramIndex++
+	} else if input.ContractorID != nil {
+		query += ", contractor_id = NULL"
+	}
 	if input.ContractorEmail != nil {
 		query += fmt.Sprintf(", contractor_email = $%d", paramIndex)
 		args = append(args, *input.ContractorEmail)
@@ -772,7 +797,7 @@ func (s *ClaimService) UpdateClaimStep(claimID string, organizationID string, in
 		contractor_email, contractor_name, contractor_photos_uploaded_at,
 		deductible_comparison_result, insurance_claim_number, inspection_datetime,
 		assigned_user_id, adjuster_name, adjuster_phone,
-		meeting_datetime, created_by_user_id, created_at, updated_at`
+		meeting_datetime, created_by_user_id, created_at, updated_at, contractor_id`
 
 	var claim models.Claim
 	err = s.db.QueryRow(query, args...).Scan(
@@ -801,6 +826,7 @@ func (s *ClaimService) UpdateClaimStep(claimID string, organizationID string, in
 		&claim.CreatedByUserID,
 		&claim.CreatedAt,
 		&claim.UpdatedAt,
+		&claim.ContractorID,
 	)
 
 	if err != nil {
@@ -810,6 +836,41 @@ func (s *ClaimService) UpdateClaimStep(claimID string, organizationID string, in
 	return &claim, nil
 }
 
+// resolveClaimContractor returns the contractor ID to store on the claim, or nil
+// to leave it unchanged (or clear it when input.ContractorID is ""). A directory
+// contractor fills in contractor_name/email when they weren't sent; a bare email
+// is matched against the directory.
+func (s *ClaimService) resolveClaimContractor(organizationID string, input *UpdateClaimStepInput) (*string, error) {
+	if input.ContractorID != nil {
+		if *input.ContractorID == "" {
+			return nil, nil
+		}
+		contractor, err := loadContractor(s.db, *input.ContractorID, organizationID)
+		if err != nil {
+			return nil, err
+		}
+		if input.ContractorName == nil {
+			input.ContractorName = &contractor.Name
+		}
+		if input.ContractorEmail == nil && contractor.Email != nil {
+			input.ContractorEmail = contractor.Email
+		}
+		return &contractor.ID, nil
+	}
+
+	if input.ContractorEmail != nil && *input.ContractorEmail != "" {
+		contractor, err := findContractorByEmail(s.db, *input.ContractorEmail, organizationID)
+		if err != nil {
+			return nil, err
+		}
+		if contractor != nil {
+			return &contractor.ID, nil
+		}
+	}
+
+	return nil, nil
+}
+
 func (s *ClaimService) DeleteClaim(claimID string, organizationID string) error {
 	// Verify claim belongs to organization
 	_, err := s.GetClaim(claimID, organizationID)
@@ -835,3 +896,17 @@ func (s *ClaimService) DeleteClaim(claimID string, organizationID string) error
 
 	return nil
 }
+
+// lockClaim locks a claim's row for the rest of the transaction, serializing
+// writers that number a claim's records from the highest existing number
+func lockClaim(ctx context.Context, tx *sql.Tx, claimID string) error {
+	var id string
+	err := tx.QueryRowContext(ctx, `SELECT id FROM claims WHERE id = $1 FOR UPDATE`, claimID).Scan(&id)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("claim not found")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to lock claim: %w", err)
+	}
+	return nil
+}
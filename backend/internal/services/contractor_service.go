@@ -0,0 +1,501 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/claimcoach/backend/internal/models"
+	"github.com/google/uuid"
+)
+
+type ContractorService struct {
+	db *sql.DB
+}
+
+func NewContractorService(db *sql.DB) *ContractorService {
+	return &ContractorService{db: db}
+}
+
+type CreateContractorInput struct {
+	Name                          string       `json:"name" binding:"required"`
+	CompanyName                   *string      `json:"company_name"`
+	Trade                         string       `json:"trade" binding:"omitempty,oneof=general roofing water_mitigation plumbing electrical hvac siding painting flooring other"`
+	Email                         *string      `json:"email" binding:"omitempty,email"`
+	Phone                         *string      `json:"phone"`
+	LicenseNumber                 *string      `json:"license_number"`
+	InsuranceCertificateExpiresAt *models.Date `json:"insurance_certificate_expires_at"`
+	Notes                         *string      `json:"notes"`
+}
+
+type UpdateContractorInput struct {
+	Name                          *string      `json:"name"`
+	CompanyName                   *string      `json:"company_name"`
+	Trade                         *string      `json:"trade" binding:"omitempty,oneof=general roofing water_mitigation plumbing electrical hvac siding painting flooring other"`
+	Email                         *string      `json:"email" binding:"omitempty,email"`
+	Phone                         *string      `json:"phone"`
+	LicenseNumber                 *string      `json:"license_number"`
+	InsuranceCertificateExpiresAt *models.Date `json:"insurance_certificate_expires_at"`
+	Notes                         *string      `json:"notes"`
+	IsActive                      *bool        `json:"is_active"`
+}
+
+// ContractorFilter narrows the contractor directory listing
+type ContractorFilter struct {
+	Trade           string
+	Search          string
+	IncludeInactive bool
+}
+
+const contractorColumns = `id, organization_id, name, company_name, trade, email, phone,
+	license_number, insurance_certificate_expires_at, notes, is_active, created_at, updated_at`
+
+func scanContractor(row interface{ Scan(...interface{}) error }) (*models.Contractor, error) {
+	var contractor models.Contractor
+	err := row.Scan(
+		&contractor.ID,
+		&contractor.OrganizationID,
+		&contractor.Name,
+		&contractor.CompanyName,
+		&contractor.Trade,
+		&contractor.Email,
+		&contractor.Phone,
+		&contractor.LicenseNumber,
+		&contractor.InsuranceCertificateExpiresAt,
+		&contractor.Notes,
+		&contractor.IsActive,
+		&contractor.CreatedAt,
+		&contractor.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &contractor, nil
+}
+
+// normalizeEmail lowercases emails so the directory dedupes by address
+func normalizeEmail(email *string) *string {
+	if email == nil {
+		return nil
+	}
+	normalized := strings.ToLower(strings.TrimSpace(*email))
+	if normalized == "" {
+		return nil
+	}
+	return &normalized
+}
+
+func (s *ContractorService) CreateContractor(input CreateContractorInput, organizationID string) (*models.Contractor, error) {
+	trade := input.Trade
+	if trade == "" {
+		trade = "general"
+	}
+
+	var insuranceExpiresAt *time.Time
+	if input.InsuranceCertificateExpiresAt != nil {
+		t := input.InsuranceCertificateExpiresAt.ToTime()
+		insuranceExpiresAt = &t
+	}
+
+	email := normalizeEmail(input.Email)
+	if email != nil {
+		existing, err := s.FindByEmail(*email, organizationID)
+		if err != nil {
+			return nil, err
+		}
+		if existing != nil {
+			return nil, fmt.Errorf("contractor with this email already exists")
+		}
+	}
+
+	now := time.Now()
+	query := `
+		INSERT INTO contractors (
+			id, organization_id, name, company_name, trade, email, phone,
+			license_number, insurance_certificate_expires_at, notes, is_active, created_at, updated_at
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, true, $11, $12)
+		RETURNING ` + contractorColumns
+
+	contractor, err := scanContractor(s.db.QueryRow(
+		query,
+		uuid.New().String(),
+		organizationID,
+		strings.TrimSpace(input.Name),
+		input.CompanyName,
+		trade,
+		email,
+		input.Phone,
+		input.LicenseNumber,
+		insuranceExpiresAt,
+		input.Notes,
+		now,
+		now,
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create contractor: %w", err)
+	}
+
+	return contractor, nil
+}
+
+func (s *ContractorService) ListContractors(organizationID string, filter ContractorFilter) ([]models.Contractor, error) {
+	query := `SELECT ` + contractorColumns + ` FROM contractors WHERE organization_id = $1`
+	args := []interface{}{organizationID}
+	argPos := 2
+
+	if !filter.IncludeInactive {
+		query += " AND is_active = true"
+	}
+
+	if filter.Trade != "" {
+		query += fmt.Sprintf(" AND trade = $%d", argPos)
+		args = append(args, filter.Trade)
+		argPos++
+	}
+
+	if filter.Search != "" {
+		query += fmt.Sprintf(" AND (name ILIKE $%d OR company_name ILIKE $%d OR email ILIKE $%d)", argPos, argPos, argPos)
+		args = append(args, "%"+filter.Search+"%")
+		argPos++
+	}
+
+	query += " ORDER BY name ASC"
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get contractors: %w", err)
+	}
+	defer rows.Close()
+
+	contractors := []models.Contractor{}
+	for rows.Next() {
+		contractor, err := scanContractor(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan contractor: %w", err)
+		}
+		contractors = append(contractors, *contractor)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate contractors: %w", err)
+	}
+
+	return contractors, nil
+}
+
+func (s *ContractorService) GetContractor(id string, organizationID string) (*models.Contractor, error) {
+	return loadContractor(s.db, id, organizationID)
+}
+
+// FindByEmail returns the organization's contractor with this email, or nil if there is none
+func (s *ContractorService) FindByEmail(email string, organizationID string) (*models.Contractor, error) {
+	return findContractorByEmail(s.db, email, organizationID)
+}
+
+// loadContractor is shared with the claim and magic link services, which
+// resolve contractor IDs without depending on ContractorService
+func loadContractor(db *sql.DB, id string, organizationID string) (*models.Contractor, error) {
+	query := `SELECT ` + contractorColumns + ` FROM contractors WHERE id = $1 AND organization_id = $2`
+
+	contractor, err := scanContractor(db.QueryRow(query, id, organizationID))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("contractor not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get contractor: %w", err)
+	}
+
+	return contractor, nil
+}
+
+func findContractorByEmail(db *sql.DB, email string, organizationID string) (*models.Contractor, error) {
+	query := `SELECT ` + contractorColumns + ` FROM contractors WHERE organization_id = $1 AND LOWER(email) = LOWER($2)`
+
+	contractor, err := scanContractor(db.QueryRow(query, organizationID, strings.TrimSpace(email)))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find contractor: %w", err)
+	}
+
+	return contractor, nil
+}
+
+func (s *ContractorService) UpdateContractor(id string, organizationID string, input UpdateContractorInput) (*models.Contractor, error) {
+	// First, check if contractor exists and belongs to organization
+	_, err := s.GetContractor(id, organizationID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Build dynamic update query
+	query := `UPDATE contractors SET updated_at = $1`
+	args := []interface{}{time.Now()}
+	argPos := 2
+
+	if input.Name != nil {
+		query += fmt.Sprintf(", name = $%d", argPos)
+		args = append(args, strings.TrimSpace(*input.Name))
+		argPos++
+	}
+
+	if input.CompanyName != nil {
+		query += fmt.Sprintf(", company_name = $%d", argPos)
+		args = append(args, *input.CompanyName)
+		argPos++
+	}
+
+	if input.Trade != nil {
+		query += fmt.Sprintf(", trade = $%d", argPos)
+		args = append(args, *input.Trade)
+		argPos++
+	}
+
+	if input.Email != nil {
+		email := normalizeEmail(input.Email)
+		if email != nil {
+			existing, err := s.FindByEmail(*email, organizationID)
+			if err != nil {
+				return nil, err
+			}
+			if existing != nil && existing.ID != id {
+				return nil, fmt.Errorf("contractor with this email already exists")
+			}
+		}
+		query += fmt.Sprintf(", email = $%d", argPos)
+		args = append(args, email)
+		argPos++
+	}
+
+	if input.Phone != nil {
+		query += fmt.Sprintf(", phone = $%d", argPos)
+		args = append(args, *input.Phone)
+		argPos++
+	}
+
+	if input.LicenseNumber != nil {
+		query += fmt.Sprintf(", license_number = $%d", argPos)
+		args = append(args, *input.LicenseNumber)
+		argPos++
+	}
+
+	if input.InsuranceCertificateExpiresAt != nil {
+		query += fmt.Sprintf(", insurance_certificate_expires_at = $%d", argPos)
+		args = append(args, input.InsuranceCertificateExpiresAt.ToTime())
+		argPos++
+	}
+
+	if input.Notes != nil {
+		query += fmt.Sprintf(", notes = $%d", argPos)
+		args = append(args, *input.Notes)
+		argPos++
+	}
+
+	if input.IsActive != nil {
+		query += fmt.Sprintf(", is_active = $%d", argPos)
+		args = append(args, *input.IsActive)
+		argPos++
+	}
+
+	query += fmt.Sprintf(" WHERE id = $%d AND organization_id = $%d RETURNING ", argPos, argPos+1) + contractorColumns
+	args = append(args, id, organizationID)
+
+	contractor, err := scanContractor(s.db.QueryRow(query, args...))
+	if err != nil {
+		return nil, fmt.Errorf("failed to update contractor: %w", err)
+	}
+
+	return contractor, nil
+}
+
+// DeactivateContractor hides a contractor from the directory. Contractors are
+// never hard-deleted because claims and magic links keep referencing them.
+func (s *ContractorService) DeactivateContractor(id string, organizationID string) error {
+	query := `UPDATE contractors SET is_active = false, updated_at = NOW() WHERE id = $1 AND organization_id = $2`
+	result, err := s.db.Exec(query, id, organizationID)
+	if err != nil {
+		return fmt.Errorf("failed to deactivate contractor: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("contractor not found")
+	}
+
+	return nil
+}
+
+// contractorLinkActivity is one magic link sent to a contractor and how they responded to it
+type contractorLinkActivity struct {
+	CreatedAt           time.Time
+	FirstResponseAt     *time.Time
+	ScopeSheetRequested bool
+	ScopeSheetSubmitted bool
+}
+
+// contractorEstimatePair is a contractor's estimate total and the carrier's total for one claim
+type contractorEstimatePair struct {
+	Contractor float64
+	Carrier    float64
+}
+
+// GetContractorStats computes response time, scope submission rate and
+// estimate-versus-carrier variance for a contractor
+func (s *ContractorService) GetContractorStats(id string, organizationID string) (*models.ContractorStats, error) {
+	if _, err := s.GetContractor(id, organizationID); err != nil {
+		return nil, err
+	}
+
+	stats := &models.ContractorStats{ContractorID: id}
+
+	claimCountQuery := `
+		SELECT COUNT(*)
+		FROM claims c
+		JOIN properties p ON p.id = c.property_id
+		WHERE c.contractor_id = $1 AND p.organization_id = $2
+	`
+	if err := s.db.QueryRow(claimCountQuery, id, organizationID).Scan(&stats.ClaimCount); err != nil {
+		return nil, fmt.Errorf("failed to count contractor claims: %w", err)
+	}
+
+	// A response is the first upload or scope sheet submission made through
+	// the link, so other contractors' activity on the claim isn't credited
+	linksQuery := `
+		SELECT ml.created_at, ml.scope IN ('scope_sheet', 'full'),
+			LEAST(
+				(SELECT MIN(d.created_at) FROM documents d
+				 WHERE d.magic_link_id = ml.id AND d.status = 'confirmed'),
+				(SELECT MIN(ss.submitted_at) FROM scope_sheets ss
+				 WHERE ss.magic_link_id = ml.id)
+			),
+			EXISTS(SELECT 1 FROM scope_sheets ss
+				WHERE ss.magic_link_id = ml.id AND ss.submitted_at IS NOT NULL)
+		FROM magic_links ml
+		JOIN claims c ON c.id = ml.claim_id
+		JOIN properties p ON p.id = c.property_id
+		WHERE ml.contractor_id = $1 AND p.organization_id = $2
+	`
+
+	rows, err := s.db.Query(linksQuery, id, organizationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query contractor links: %w", err)
+	}
+	defer rows.Close()
+
+	var links []contractorLinkActivity
+	for rows.Next() {
+		var link contractorLinkActivity
+		if err := rows.Scan(&link.CreatedAt, &link.ScopeSheetRequested, &link.FirstResponseAt, &link.ScopeSheetSubmitted); err != nil {
+			return nil, fmt.Errorf("failed to scan contractor link: %w", err)
+		}
+		links = append(links, link)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate contractor links: %w", err)
+	}
+
+	// Carrier totals come from the latest PM Brain analysis on each claim
+	varianceQuery := `
+		SELECT c.contractor_estimate_total, (ar.pm_brain_analysis::jsonb->>'total_carrier_estimate')::float8
+		FROM claims c
+		JOIN properties p ON p.id = c.property_id
+		JOIN LATERAL (
+			SELECT pm_brain_analysis FROM audit_reports
+			WHERE claim_id = c.id AND pm_brain_analysis IS NOT NULL
+			ORDER BY created_at DESC
+			LIMIT 1
+		) ar ON true
+		WHERE c.contractor_id = $1 AND p.organization_id = $2
+		  AND c.contractor_estimate_total IS NOT NULL
+		  AND ar.pm_brain_analysis::jsonb->>'total_carrier_estimate' IS NOT NULL
+	`
+
+	varianceRows, err := s.db.Query(varianceQuery, id, organizationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query contractor estimate variance: %w", err)
+	}
+	defer varianceRows.Close()
+
+	var pairs []contractorEstimatePair
+	for varianceRows.Next() {
+		var pair contractorEstimatePair
+		if err := varianceRows.Scan(&pair.Contractor, &pair.Carrier); err != nil {
+			return nil, fmt.Errorf("failed to scan contractor estimate variance: %w", err)
+		}
+		pairs = append(pairs, pair)
+	}
+	if err = varianceRows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate contractor estimate variance: %w", err)
+	}
+
+	summarizeContractorStats(stats, links, pairs)
+	return stats, nil
+}
+
+// summarizeContractorStats fills the aggregate fields of stats from raw link activity and estimate pairs
+func summarizeContractorStats(stats *models.ContractorStats, links []contractorLinkActivity, pairs []contractorEstimatePair) {
+	stats.LinksSent = len(links)
+
+	var responseHours []float64
+	for _, link := range links {
+		if link.FirstResponseAt != nil {
+			responseHours = append(responseHours, link.FirstResponseAt.Sub(link.CreatedAt).Hours())
+		}
+		if link.ScopeSheetRequested {
+			stats.ScopeSheetRequests++
+			if link.ScopeSheetSubmitted {
+				stats.ScopeSheetsSubmitted++
+			}
+		}
+	}
+
+	stats.LinksResponded = len(responseHours)
+	if len(responseHours) > 0 {
+		sort.Float64s(responseHours)
+		var sum float64
+		for _, h := range responseHours {
+			sum += h
+		}
+		avg := sum / float64(len(responseHours))
+		stats.AvgResponseTimeHours = &avg
+
+		mid := len(responseHours) / 2
+		median := responseHours[mid]
+		if len(responseHours)%2 == 0 {
+			median = (responseHours[mid-1] + responseHours[mid]) / 2
+		}
+		stats.MedianResponseTimeHours = &median
+	}
+
+	if stats.ScopeSheetRequests > 0 {
+		rate := float64(stats.ScopeSheetsSubmitted) / float64(stats.ScopeSheetRequests)
+		stats.ScopeSubmissionRate = &rate
+	}
+
+	stats.AuditedClaims = len(pairs)
+	if len(pairs) > 0 {
+		var pctSum float64
+		pctCount := 0
+		for _, pair := range pairs {
+			variance := pair.Contractor - pair.Carrier
+			stats.TotalEstimateVariance += variance
+			if pair.Carrier > 0 {
+				pctSum += variance / pair.Carrier * 100
+				pctCount++
+			}
+		}
+		avg := stats.TotalEstimateVariance / float64(len(pairs))
+		stats.AvgEstimateVariance = &avg
+		if pctCount > 0 {
+			avgPct := pctSum / float64(pctCount)
+			stats.AvgEstimateVariancePct = &avgPct
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/claimcoach/backend/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSummarizeContractorStats(t *testing.T) {
+	sent := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	after := func(hours int) *time.Time {
+		t := sent.Add(time.Duration(hours) * time.Hour)
+		return &t
+	}
+
+	links := []contractorLinkActivity{
+		{CreatedAt: sent, FirstResponseAt: after(2), ScopeSheetRequested: true, ScopeSheetSubmitted: true},
+		{CreatedAt: sent, FirstResponseAt: after(10), ScopeSheetRequested: true},
+		{CreatedAt: sent, FirstResponseAt: after(4)},
+		{CreatedAt: sent, ScopeSheetRequested: true},
+	}
+	pairs := []contractorEstimatePair{
+		{Contractor: 12000, Carrier: 10000},
+		{Contractor: 9000, Carrier: 10000},
+	}
+
+	stats := &models.ContractorStats{}
+	summarizeContractorStats(stats, links, pairs)
+
+	assert.Equal(t, 4, stats.LinksSent)
+	assert.Equal(t, 3, stats.LinksResponded)
+	require.NotNil(t, stats.AvgResponseTimeHours)
+	assert.InDelta(t, 16.0/3, *stats.AvgResponseTimeHours, 0.001)
+	require.NotNil(t, stats.MedianResponseTimeHours)
+	assert.Equal(t, 4.0, *stats.MedianResponseTimeHours)
+
+	assert.Equal(t, 3, stats.ScopeSheetRequests)
+	assert.Equal(t, 1, stats.ScopeSheetsSubmitted)
+	require.NotNil(t, stats.ScopeSubmissionRate)
+	assert.InDelta(t, 1.0/3, *stats.ScopeSubmissionRate, 0.001)
+
+	assert.Equal(t, 2, stats.AuditedClaims)
+	assert.Equal(t, 1000.0, stats.TotalEstimateVariance)
+	require.NotNil(t, stats.AvgEstimateVariance)
+	assert.Equal(t, 500.0, *stats.AvgEstimateVariance)
+	require.NotNil(t, stats.AvgEstimateVariancePct)
+	assert.InDelta(t, 5.0, *stats.AvgEstimateVariancePct, 0.001)
+}
+
+func TestSummarizeContractorStats_NoActivity(t *testing.T) {
+	stats := &models.ContractorStats{}
+	summarizeContractorStats(stats, nil, nil)
+
+	assert.Nil(t, stats.AvgResponseTimeHours)
+	assert.Nil(t, stats.ScopeSubmissionRate)
+	assert.Nil(t, stats.AvgEstimateVariance)
+}
+
+func TestMagicLinkService_ResolveContractor_FillsFromDirectory(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	now := time.Now()
+	mock.ExpectQuery(`SELECT (.+) FROM contractors WHERE id = \$1 AND organization_id = \$2`).
+		WithArgs("contractor-1", "org-1").
+		WillReturnRows(sqlmock.NewRows([]string{
+			"id", "organization_id", "name", "company_name", "trade", "email", "phone",
+			"license_number", "insurance_certificate_expires_at", "notes", "is_active", "created_at", "updated_at",
+		}).AddRow("contractor-1", "org-1", "Dana Ruiz", "Ruiz Roofing", "roofing", "dana@ruizroofing.com", "555-0100",
+			nil, nil, nil, true, now, now))
+
+	service := &MagicLinkService{db: db}
+	contractorID := "contractor-1"
+	input := GenerateMagicLinkInput{ContractorID: &contractorID}
+
+	resolved, err := service.resolveContractor("org-1", &input)
+
+	require.NoError(t, err)
+	require.NotNil(t, resolved)
+	assert.Equal(t, "contractor-1", *resolved)
+	assert.Equal(t, "Dana Ruiz", input.ContractorName)
+	assert.Equal(t, "dana@ruizroofing.com", input.ContractorEmail)
+	require.NotNil(t, input.ContractorPhone)
+	assert.Equal(t, "555-0100", *input.ContractorPhone)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
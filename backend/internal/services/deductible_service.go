@@ -0,0 +1,250 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/claimcoach/backend/internal/deductible"
+	"github.com/claimcoach/backend/internal/models"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrDeductibleRuleNotFound = errors.New("deductible rule not found")
+	ErrInvalidDeductibleRule  = errors.New("invalid deductible rule")
+)
+
+// DeductibleService manages a policy's per-peril deductible rules and
+// resolves the deductible that applies to a claim
+type DeductibleService struct {
+	db            *sql.DB
+	policyService *PolicyService
+	claimService  *ClaimService
+}
+
+// NewDeductibleService creates a new DeductibleService
+func NewDeductibleService(db *sql.DB, policyService *PolicyService, claimService *ClaimService) *DeductibleService {
+	return &DeductibleService{
+		db:            db,
+		policyService: policyService,
+		claimService:  claimService,
+	}
+}
+
+// CreateDeductibleRuleInput describes a manually entered deductible rule.
+// Percentages are fractions of the Coverage A limit.
+type CreateDeductibleRuleInput struct {
+	Peril         string       `json:"peril" binding:"required"`
+	Basis         string       `json:"basis" binding:"required"`
+	Amount        *float64     `json:"amount"`
+	Percentage    *float64     `json:"percentage"`
+	MinimumAmount *float64     `json:"minimum_amount"`
+	StartsOn      *models.Date `json:"starts_on"`
+	EndsOn        *models.Date `json:"ends_on"`
+}
+
+const deductibleRuleColumns = `id, policy_id, peril, basis, amount, percentage, minimum_amount,
+	starts_on, ends_on, source, created_by_user_id, created_at, updated_at`
+
+func scanDeductibleRule(row interface{ Scan(...interface{}) error }) (*models.DeductibleRule, error) {
+	var rule models.DeductibleRule
+	err := row.Scan(
+		&rule.ID, &rule.PolicyID, &rule.Peril, &rule.Basis, &rule.Amount, &rule.Percentage, &rule.MinimumAmount,
+		&rule.StartsOn, &rule.EndsOn, &rule.Source, &rule.CreatedByUserID, &rule.CreatedAt, &rule.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
+
+// ListDeductibleRules returns a property's policy deductible rules
+func (s *DeductibleService) ListDeductibleRules(ctx context.Context, propertyID, organizationID string) ([]models.DeductibleRule, error) {
+	policy, err := s.policyService.GetPolicy(propertyID, organizationID)
+	if err != nil {
+		return nil, err
+	}
+	return loadDeductibleRules(ctx, s.db, policy.ID)
+}
+
+// CreateDeductibleRule adds a manual deductible rule to a property's policy
+func (s *DeductibleService) CreateDeductibleRule(ctx context.Context, propertyID, organizationID, userID string, input CreateDeductibleRuleInput) (*models.DeductibleRule, error) {
+	policy, err := s.policyService.GetPolicy(propertyID, organizationID)
+	if err != nil {
+		return nil, err
+	}
+
+	rule := models.DeductibleRule{
+		Peril:         input.Peril,
+		Basis:         input.Basis,
+		Amount:        input.Amount,
+		Percentage:    input.Percentage,
+		MinimumAmount: input.MinimumAmount,
+	}
+	if input.StartsOn != nil {
+		t := input.StartsOn.ToTime()
+		rule.StartsOn = &t
+	}
+	if input.EndsOn != nil {
+		t := input.EndsOn.ToTime()
+		rule.EndsOn = &t
+	}
+	if err := deductible.Validate(rule); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidDeductibleRule, err)
+	}
+
+	now := time.Now()
+	created, err := scanDeductibleRule(s.db.QueryRowContext(ctx, `
+		INSERT INTO policy_deductible_rules (
+			id, policy_id, peril, basis, amount, percentage, minimum_amount,
+			starts_on, ends_on, source, created_by_user_id, created_at, updated_at
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+		RETURNING `+deductibleRuleColumns,
+		uuid.New().String(), policy.ID, rule.Peril, rule.Basis, rule.Amount, rule.Percentage, rule.MinimumAmount,
+		rule.StartsOn, rule.EndsOn, models.DeductibleSourceManual, userID, now, now,
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create deductible rule: %w", err)
+	}
+	return created, nil
+}
+
+// DeleteDeductibleRule removes a deductible rule from a property's policy
+func (s *DeductibleService) DeleteDeductibleRule(ctx context.Context, propertyID, ruleID, organizationID string) error {
+	policy, err := s.policyService.GetPolicy(propertyID, organizationID)
+	if err != nil {
+		return err
+	}
+
+	result, err := s.db.ExecContext(ctx,
+		`DELETE FROM policy_deductible_rules WHERE id = $1 AND policy_id = $2`, ruleID, policy.ID)
+	if err != nil {
+		return fmt.Errorf("failed to delete deductible rule: %w", err)
+	}
+	if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+		return ErrDeductibleRuleNotFound
+	}
+	return nil
+}
+
+// ResolveClaimDeductible returns the deductible that applies to a claim
+func (s *DeductibleService) ResolveClaimDeductible(ctx context.Context, claimID, organizationID string) (*deductible.Result, error) {
+	if _, err := s.claimService.GetClaim(claimID, organizationID); err != nil {
+		return nil, err
+	}
+	result, err := resolveClaimDeductible(ctx, s.db, claimID)
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func loadDeductibleRules(ctx context.Context, db *sql.DB, policyID string) ([]models.DeductibleRule, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT `+deductibleRuleColumns+`
+		FROM policy_deductible_rules
+		WHERE policy_id = $1
+		ORDER BY created_at`, policyID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get deductible rules: %w", err)
+	}
+	defer rows.Close()
+
+	rules := []models.DeductibleRule{}
+	for rows.Next() {
+		rule, err := scanDeductibleRule(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan deductible rule: %w", err)
+		}
+		rules = append(rules, *rule)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate deductible rules: %w", err)
+	}
+
+	return rules, nil
+}
+
+// resolveClaimDeductible resolves a claim's deductible from its policy's rules,
+// its loss type and date and the confirmed Coverage A limit
+func resolveClaimDeductible(ctx context.Context, db *sql.DB, claimID string) (deductible.Result, error) {
+	var lossType, policyID string
+	var lossDate time.Time
+	var policy deductible.Policy
+	err := db.QueryRowContext(ctx, `
+		SELECT c.loss_type, c.incident_date, ip.id, ip.deductible_value, pt.coverage_a_limit
+		FROM claims c
+		INNER JOIN insurance_policies ip ON ip.id = c.policy_id
+		LEFT JOIN policy_terms pt ON pt.policy_id = ip.id AND pt.review_status = $2
+		WHERE c.id = $1`,
+		claimID, models.PolicyTermsConfirmed,
+	).Scan(&lossType, &lossDate, &policyID, &policy.Deductible, &policy.CoverageALimit)
+	if err == sql.ErrNoRows {
+		return deductible.Result{}, fmt.Errorf("claim not found")
+	}
+	if err != nil {
+		return deductible.Result{}, fmt.Errorf("failed to get claim policy: %w", err)
+	}
+
+	policy.Rules, err = loadDeductibleRules(ctx, db, policyID)
+	if err != nil {
+		return deductible.Result{}, err
+	}
+	return deductible.Resolve(policy, lossType, lossDate), nil
+}
+
+// syncTermsDeductibleRules replaces the rules derived from policy terms with
+// the deductible of newly confirmed terms. Manual rules are left alone.
+func syncTermsDeductibleRules(ctx context.Context, tx *sql.Tx, terms *models.PolicyTerms, userID string) error {
+	_, err := tx.ExecContext(ctx,
+		`DELETE FROM policy_deductible_rules WHERE policy_id = $1 AND source = $2`,
+		terms.PolicyID, models.DeductibleSourcePolicyTerms)
+	if err != nil {
+		return fmt.Errorf("failed to clear policy terms deductible rules: %w", err)
+	}
+
+	rule, ok := termsDeductibleRule(terms)
+	if !ok {
+		return nil
+	}
+	now := time.Now()
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO policy_deductible_rules (
+			id, policy_id, peril, basis, amount, percentage, source, created_by_user_id, created_at, updated_at
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`,
+		uuid.New().String(), terms.PolicyID, rule.Peril, rule.Basis, rule.Amount, rule.Percentage,
+		models.DeductibleSourcePolicyTerms, userID, now, now,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save policy terms deductible rule: %w", err)
+	}
+	return nil
+}
+
+// termsDeductibleRule is the deductible rule stated by policy terms. A
+// wind/hail deductible becomes a wind/hail rule; flat and percentage
+// deductibles apply to all perils.
+func termsDeductibleRule(terms *models.PolicyTerms) (models.DeductibleRule, bool) {
+	if terms.DeductibleType == nil {
+		return models.DeductibleRule{}, false
+	}
+	rule := models.DeductibleRule{Peril: models.PerilAllPerils}
+	if *terms.DeductibleType == models.DeductibleWindHail {
+		rule.Peril = models.PerilWindHail
+	}
+	switch {
+	case *terms.DeductibleType != models.DeductibleFlat && terms.DeductiblePercentage != nil:
+		rule.Basis, rule.Percentage = models.DeductiblePercentage, terms.DeductiblePercentage
+	case terms.DeductibleAmount != nil:
+		rule.Basis, rule.Amount = models.DeductibleFlat, terms.DeductibleAmount
+	default:
+		return models.DeductibleRule{}, false
+	}
+	return rule, true
+}
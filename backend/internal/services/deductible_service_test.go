@@ -0,0 +1,74 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/claimcoach/backend/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTermsDeductibleRule(t *testing.T) {
+	str := func(s string) *string { return &s }
+	num := func(f float64) *float64 { return &f }
+
+	rule, ok := termsDeductibleRule(&models.PolicyTerms{DeductibleType: str(models.DeductibleWindHail), DeductiblePercentage: num(0.02)})
+	require.True(t, ok)
+	assert.Equal(t, models.PerilWindHail, rule.Peril)
+	assert.Equal(t, models.DeductiblePercentage, rule.Basis)
+	assert.Equal(t, 0.02, *rule.Percentage)
+
+	rule, ok = termsDeductibleRule(&models.PolicyTerms{DeductibleType: str(models.DeductibleFlat), DeductibleAmount: num(2500)})
+	require.True(t, ok)
+	assert.Equal(t, models.PerilAllPerils, rule.Peril)
+	assert.Equal(t, models.DeductibleFlat, rule.Basis)
+	assert.Equal(t, 2500.0, *rule.Amount)
+
+	_, ok = termsDeductibleRule(&models.PolicyTerms{DeductibleType: str(models.DeductiblePercentage)})
+	assert.False(t, ok)
+	_, ok = termsDeductibleRule(&models.PolicyTerms{})
+	assert.False(t, ok)
+}
+
+func TestResolveClaimDeductible_UsesPerilRule(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	lossDate := time.Date(2026, 6, 3, 0, 0, 0, 0, time.UTC)
+	mock.ExpectQuery(`SELECT c.loss_type, c.incident_date`).
+		WithArgs("claim-1", models.PolicyTermsConfirmed).
+		WillReturnRows(sqlmock.NewRows([]string{"loss_type", "incident_date", "id", "deductible_value", "coverage_a_limit"}).
+			AddRow(models.LossTypeHail, lossDate, "policy-1", 1000.0, 400000.0))
+	now := time.Now()
+	mock.ExpectQuery(`FROM policy_deductible_rules`).
+		WithArgs("policy-1").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "policy_id", "peril", "basis", "amount", "percentage", "minimum_amount",
+			"starts_on", "ends_on", "source", "created_by_user_id", "created_at", "updated_at"}).
+			AddRow("rule-1", "policy-1", models.PerilAllPerils, models.DeductibleFlat, 1000.0, nil, nil,
+				nil, nil, models.DeductibleSourcePolicyTerms, "user-1", now, now).
+			AddRow("rule-2", "policy-1", models.PerilWindHail, models.DeductiblePercentage, nil, 0.02, nil,
+				nil, nil, models.DeductibleSourceManual, "user-1", now, now))
+
+	result, err := resolveClaimDeductible(context.Background(), db, "claim-1")
+	require.NoError(t, err)
+	assert.Equal(t, 8000.0, result.Amount)
+	assert.Equal(t, "rule-2", result.RuleID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestResolveClaimDeductible_ClaimNotFound(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectQuery(`SELECT c.loss_type, c.incident_date`).
+		WithArgs("missing", models.PolicyTermsConfirmed).
+		WillReturnRows(sqlmock.NewRows([]string{"loss_type", "incident_date", "id", "deductible_value", "coverage_a_limit"}))
+
+	_, err = resolveClaimDeductible(context.Background(), db, "missing")
+	assert.EqualError(t, err, "claim not found")
+}
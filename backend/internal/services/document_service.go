@@ -32,6 +32,9 @@ type RequestUploadURLInput struct {
 	FileSize     int64  `json:"file_size" binding:"required"`
 	MimeType     string `json:"mime_type" binding:"required"`
 	DocumentType string `json:"document_type" binding:"required"`
+	// SupersedesDocumentID marks this upload as a new version of an existing
+	// document. Optional — single-version types supersede automatically.
+	SupersedesDocumentID *string `json:"supersedes_document_id"`
 }
 
 type UploadURLResponse struct {
@@ -59,6 +62,12 @@ func (s *DocumentService) RequestUploadURL(claimID string, organizationID string
 		return nil, err
 	}
 
+	if input.SupersedesDocumentID != nil {
+		if err := validateSupersedes(context.Background(), s.db, claim.ID, input.DocumentType, *input.SupersedesDocumentID); err != nil {
+			return nil, err
+		}
+	}
+
 	// Clean up old pending documents for this claim
 	if err := s.cleanupAbandonedPendingDocuments(claimID); err != nil {
 		log.Printf("Warning: failed to cleanup abandoned documents: %v", err)
@@ -70,14 +79,16 @@ func (s *DocumentService) RequestUploadURL(claimID string, organizationID string
 		return nil, fmt.Errorf("failed to generate upload URL: %w", err)
 	}
 
-	// Create pending document record
+	// Create pending document record. It only becomes the current version once
+	// the upload is confirmed.
 	documentID := uuid.New().String()
 	query := `
 		INSERT INTO documents (
 			id, claim_id, uploaded_by_user_id, document_type, file_url,
-			file_name, file_size_bytes, mime_type, status, created_at
+			file_name, file_size_bytes, mime_type, status, created_at,
+			supersedes_document_id, is_current
 		)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, false)
 		RETURNING id
 	`
 
@@ -93,6 +104,7 @@ func (s *DocumentService) RequestUploadURL(claimID string, organizationID string
 		input.MimeType,
 		"pending",
 		time.Now(),
+		input.SupersedesDocumentID,
 	).Scan(&documentID)
 
 	if err != nil {
@@ -127,35 +139,10 @@ func (s *DocumentService) ConfirmUpload(claimID string, documentID string, organ
 		return nil, err
 	}
 
-	// Update document status to confirmed
-	query := `
-		UPDATE documents
-		SET status = 'confirmed'
-		WHERE id = $1 AND claim_id = $2 AND status = 'pending'
-		RETURNING id, claim_id, uploaded_by_user_id, document_type, file_url,
-			file_name, file_size_bytes, mime_type, metadata, status, created_at
-	`
-
-	var doc models.Document
-	err = s.db.QueryRow(query, documentID, claimID).Scan(
-		&doc.ID,
-		&doc.ClaimID,
-		&doc.UploadedByUserID,
-		&doc.DocumentType,
-		&doc.FileURL,
-		&doc.FileName,
-		&doc.FileSizeBytes,
-		&doc.MimeType,
-		&doc.Metadata,
-		&doc.Status,
-		&doc.CreatedAt,
-	)
-
-	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("document not found or already confirmed")
-	}
+	// Update document status to confirmed and make it the current version
+	doc, err := confirmDocumentUpload(context.Background(), s.db, claimID, documentID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to confirm document: %w", err)
+		return nil, err
 	}
 
 	// Create activity log
@@ -163,6 +150,10 @@ func (s *DocumentService) ConfirmUpload(claimID string, documentID string, organ
 		"document_id":   documentID,
 		"document_type": doc.DocumentType,
 		"file_name":     doc.FileName,
+		"version":       doc.Version,
+	}
+	if doc.SupersedesDocumentID != nil {
+		metadata["supersedes_document_id"] = *doc.SupersedesDocumentID
 	}
 	metadataJSON, _ := json.Marshal(metadata)
 	metadataStr := string(metadataJSON)
@@ -174,11 +165,12 @@ func (s *DocumentService) ConfirmUpload(claimID string, documentID string, organ
 		log.Printf("Warning: failed to log activity: %v", err)
 	}
 
-	return &doc, nil
+	return doc, nil
 }
 
-// ListDocuments returns all confirmed documents for a claim
-func (s *DocumentService) ListDocuments(claimID string, organizationID string) ([]models.Document, error) {
+// ListDocuments returns the confirmed documents for a claim. Superseded versions
+// are omitted unless includeSuperseded is set.
+func (s *DocumentService) ListDocuments(claimID string, organizationID string, includeSuperseded bool) ([]models.Document, error) {
 	// Verify claim ownership
 	_, err := s.claimService.GetClaim(claimID, organizationID)
 	if err != nil {
@@ -187,13 +179,14 @@ func (s *DocumentService) ListDocuments(claimID string, organizationID string) (
 
 	query := `
 		SELECT id, claim_id, uploaded_by_user_id, document_type, file_url,
-			file_name, file_size_bytes, mime_type, metadata, status, created_at
+			file_name, file_size_bytes, mime_type, metadata, status,
+			version, supersedes_document_id, is_current, created_at
 		FROM documents
-		WHERE claim_id = $1 AND status = 'confirmed'
+		WHERE claim_id = $1 AND status = 'confirmed' AND (is_current OR $2)
 		ORDER BY created_at DESC
 	`
 
-	rows, err := s.db.Query(query, claimID)
+	rows, err := s.db.Query(query, claimID, includeSuperseded)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list documents: %w", err)
 	}
@@ -213,6 +206,9 @@ func (s *DocumentService) ListDocuments(claimID string, organizationID string) (
 			&doc.MimeType,
 			&doc.Metadata,
 			&doc.Status,
+			&doc.Version,
+			&doc.SupersedesDocumentID,
+			&doc.IsCurrent,
 			&doc.CreatedAt,
 		)
 		if err != nil {
@@ -233,7 +229,8 @@ func (s *DocumentService) GetDocument(documentID string, organizationID string)
 	// Query document with organization verification
 	query := `
 		SELECT d.id, d.claim_id, d.uploaded_by_user_id, d.document_type, d.file_url,
-			d.file_name, d.file_size_bytes, d.mime_type, d.metadata, d.status, d.created_at
+			d.file_name, d.file_size_bytes, d.mime_type, d.metadata, d.status,
+			d.version, d.supersedes_document_id, d.is_current, d.created_at
 		FROM documents d
 		INNER JOIN claims c ON d.claim_id = c.id
 		INNER JOIN properties p ON c.property_id = p.id
@@ -252,6 +249,9 @@ func (s *DocumentService) GetDocument(documentID string, organizationID string)
 		&doc.MimeType,
 		&doc.Metadata,
 		&doc.Status,
+		&doc.Version,
+		&doc.SupersedesDocumentID,
+		&doc.IsCurrent,
 		&doc.CreatedAt,
 	)
 
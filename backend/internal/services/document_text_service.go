@@ -0,0 +1,590 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/claimcoach/backend/internal/models"
+	"github.com/claimcoach/backend/internal/prompts"
+	"github.com/claimcoach/backend/internal/storage"
+)
+
+// TextExtractionClient defines the LLM calls used to transcribe documents
+type TextExtractionClient interface {
+	ParsePDF(ctx context.Context, pdfContent []byte, prompt string, maxTokens int) (string, error)
+	ParseImage(ctx context.Context, imageContent []byte, mediaType string, prompt string, maxTokens int) (string, error)
+}
+
+const (
+	// textExtractionMaxTokens bounds a single transcription response. Very long
+	// PDFs are truncated at this budget rather than failing outright.
+	textExtractionMaxTokens = 16000
+	// maxExtractionFileBytes guards against pulling huge files into memory
+	maxExtractionFileBytes = 32 * 1024 * 1024
+	// textExtractionTimeout is longer than any extraction can run, which is
+	// bounded by the Lambda timeout. A row left processing for longer was
+	// abandoned by a run that died and is extracted again.
+	textExtractionTimeout = 15 * time.Minute
+)
+
+// extractableImageTypes are the image MIME types the extractor can read.
+// HEIC photos are marked unsupported.
+var extractableImageTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+}
+
+var pageMarkerPattern = regexp.MustCompile(`(?m)^=== PAGE (\d+) ===[ \t]*$`)
+
+// DocumentTextService extracts per-page text from claim documents and carrier
+// estimates, indexes it for full-text search and serves it back as grounding
+// for audit prompts.
+type DocumentTextService struct {
+	db           *sql.DB
+	storage      StorageClient
+	extractor    TextExtractionClient
+	claimService *ClaimService
+	httpClient   *http.Client
+}
+
+// NewDocumentTextService creates a new DocumentTextService
+func NewDocumentTextService(db *sql.DB, storageClient *storage.SupabaseStorage, extractor TextExtractionClient, claimService *ClaimService) *DocumentTextService {
+	return &DocumentTextService{
+		db:           db,
+		storage:      storageClient,
+		extractor:    extractor,
+		claimService: claimService,
+		httpClient:   &http.Client{Timeout: 60 * time.Second},
+	}
+}
+
+// textSource identifies one file to extract: either a document or a carrier estimate
+type textSource struct {
+	table    string // "documents" or "carrier_estimates"
+	idColumn string // page column referencing the source
+	id       string
+	claimID  string
+	filePath string
+	mimeType string
+	status   string
+}
+
+// ExtractDocumentText transcribes a confirmed document and replaces its indexed pages.
+// Documents that are already extracted (or being extracted) are skipped.
+// An extraction abandoned for longer than textExtractionTimeout is restarted.
+func (s *DocumentTextService) ExtractDocumentText(ctx context.Context, documentID string) error {
+	src := textSource{table: "documents", idColumn: "document_id", id: documentID}
+	err := s.db.QueryRowContext(ctx, `
+		SELECT claim_id, file_url, mime_type, text_extraction_status
+		FROM documents
+		WHERE id = $1 AND status = 'confirmed'
+	`, documentID).Scan(&src.claimID, &src.filePath, &src.mimeType, &src.status)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("document not found")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get document: %w", err)
+	}
+
+	return s.extract(ctx, src)
+}
+
+// ExtractCarrierEstimateText transcribes a confirmed carrier estimate PDF and replaces its indexed pages.
+// Estimates that are already extracted (or being extracted) are skipped.
+// An extraction abandoned for longer than textExtractionTimeout is restarted.
+func (s *DocumentTextService) ExtractCarrierEstimateText(ctx context.Context, estimateID string) error {
+	src := textSource{table: "carrier_estimates", idColumn: "carrier_estimate_id", id: estimateID, mimeType: "application/pdf"}
+	err := s.db.QueryRowContext(ctx, `
+		SELECT claim_id, file_path, text_extraction_status
+		FROM carrier_estimates
+		WHERE id = $1 AND version > 0
+	`, estimateID).Scan(&src.claimID, &src.filePath, &src.status)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("carrier estimate not found")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get carrier estimate: %w", err)
+	}
+
+	return s.extract(ctx, src)
+}
+
+// ExtractPendingForClaim extracts text for every confirmed document and carrier
+// estimate on a claim that has not been extracted yet, failed previously, or
+// was abandoned mid-extraction. Intended to run in the background; returns the
+// number of files attempted.
+func (s *DocumentTextService) ExtractPendingForClaim(ctx context.Context, claimID string) (int, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT 'document', id FROM documents
+		WHERE claim_id = $1 AND status = 'confirmed'
+		  AND (text_extraction_status IN ('pending', 'failed')
+		       OR (text_extraction_status = 'processing'
+		           AND COALESCE(text_extraction_started_at, '-infinity') < NOW() - make_interval(secs => $2)))
+		UNION ALL
+		SELECT 'carrier_estimate', id FROM carrier_estimates
+		WHERE claim_id = $1 AND version > 0
+		  AND (text_extraction_status IN ('pending', 'failed')
+		       OR (text_extraction_status = 'processing'
+		           AND COALESCE(text_extraction_started_at, '-infinity') < NOW() - make_interval(secs => $2)))
+	`, claimID, textExtractionTimeout.Seconds())
+	if err != nil {
+		return 0, fmt.Errorf("failed to list pending extractions: %w", err)
+	}
+
+	type pending struct{ kind, id string }
+	var queue []pending
+	for rows.Next() {
+		var p pending
+		if err := rows.Scan(&p.kind, &p.id); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan pending extraction: %w", err)
+		}
+		queue = append(queue, p)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("failed to iterate pending extractions: %w", err)
+	}
+
+	for _, p := range queue {
+		var err error
+		if p.kind == "carrier_estimate" {
+			err = s.ExtractCarrierEstimateText(ctx, p.id)
+		} else {
+			err = s.ExtractDocumentText(ctx, p.id)
+		}
+		if err != nil {
+			// Status and error are recorded on the row; keep going with the rest
+			log.Printf("Warning: text extraction failed for %s %s: %v", p.kind, p.id, err)
+		}
+	}
+	return len(queue), nil
+}
+
+// CheckClaimAccess verifies claim ownership before a background
+// ExtractPendingForClaim run.
+func (s *DocumentTextService) CheckClaimAccess(claimID, organizationID string) error {
+	_, err := s.claimService.GetClaim(claimID, organizationID)
+	return err
+}
+
+func (s *DocumentTextService) extract(ctx context.Context, src textSource) error {
+	if src.status == models.TextExtractionCompleted {
+		return nil
+	}
+
+	if src.mimeType != "application/pdf" && !extractableImageTypes[src.mimeType] {
+		reason := fmt.Sprintf("text extraction not supported for %s", src.mimeType)
+		s.setExtractionStatus(ctx, src, models.TextExtractionUnsupported, &reason)
+		return nil
+	}
+
+	claimed, err := s.claimExtraction(ctx, src)
+	if err != nil || !claimed {
+		return err
+	}
+
+	pages, promptVersion, err := s.transcribe(ctx, src)
+	if err != nil {
+		msg := err.Error()
+		s.setExtractionStatus(ctx, src, models.TextExtractionFailed, &msg)
+		return err
+	}
+
+	if err := s.savePages(ctx, src, pages, promptVersion); err != nil {
+		msg := err.Error()
+		s.setExtractionStatus(ctx, src, models.TextExtractionFailed, &msg)
+		return err
+	}
+
+	s.setExtractionStatus(ctx, src, models.TextExtractionCompleted, nil)
+	return nil
+}
+
+func (s *DocumentTextService) transcribe(ctx context.Context, src textSource) ([]string, string, error) {
+	body, err := openStoredFile(ctx, s.storage, s.httpClient, src.filePath)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to download file: %w", err)
+	}
+	defer body.Close()
+
+	content, err := io.ReadAll(io.LimitReader(body, maxExtractionFileBytes+1))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read file: %w", err)
+	}
+	if len(content) > maxExtractionFileBytes {
+		return nil, "", fmt.Errorf("file too large for text extraction")
+	}
+
+	return s.transcribeContent(ctx, content, src.mimeType)
+}
+
+// transcribeContent transcribes a downloaded PDF or image into its pages and
+// returns the version of the prompt that transcribed it
+func (s *DocumentTextService) transcribeContent(ctx context.Context, content []byte, mimeType string) ([]string, string, error) {
+	rendered, err := prompts.Render(prompts.TextExtraction, nil)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var text string
+	if mimeType == "application/pdf" {
+		text, err = s.extractor.ParsePDF(ctx, content, rendered.User, textExtractionMaxTokens)
+	} else {
+		text, err = s.extractor.ParseImage(ctx, content, mimeType, rendered.User, textExtractionMaxTokens)
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("LLM request failed: %w", err)
+	}
+
+	return splitPages(text), rendered.Version, nil
+}
+
+// GetPolicyPages returns the per-page text of a policy PDF, transcribing it the
+// first time it is asked for and after a new PDF is uploaded. content is the
+// PDF at pdfPath, which callers have already downloaded. Slice index i is
+// page i+1.
+func (s *DocumentTextService) GetPolicyPages(ctx context.Context, policyID, pdfPath string, content []byte) ([]string, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT page_number, content
+		FROM policy_pages
+		WHERE policy_id = $1 AND pdf_path = $2
+		ORDER BY page_number
+	`, policyID, pdfPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load policy text: %w", err)
+	}
+	var pages []string
+	for rows.Next() {
+		var page int
+		var text string
+		if err := rows.Scan(&page, &text); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan policy text: %w", err)
+		}
+		for len(pages) < page {
+			pages = append(pages, "")
+		}
+		pages[page-1] = text
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate policy text: %w", err)
+	}
+	if len(pages) > 0 {
+		return pages, nil
+	}
+
+	pages, promptVersion, err := s.transcribeContent(ctx, content, "application/pdf")
+	if err != nil {
+		return nil, err
+	}
+	if err := s.savePolicyPages(ctx, policyID, pdfPath, pages, promptVersion); err != nil {
+		// The text is still good for this request; it is transcribed again next time
+		log.Printf("Warning: failed to save text for policy %s: %v", policyID, err)
+	}
+	return pages, nil
+}
+
+// savePolicyPages replaces the stored text of a policy in one transaction
+func (s *DocumentTextService) savePolicyPages(ctx context.Context, policyID, pdfPath string, pages []string, promptVersion string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM policy_pages WHERE policy_id = $1`, policyID); err != nil {
+		return fmt.Errorf("failed to clear previous pages: %w", err)
+	}
+	for i, content := range pages {
+		if content == "" {
+			continue
+		}
+		// A concurrent transcription of the same policy may have saved its pages first
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO policy_pages (policy_id, pdf_path, page_number, content, prompt_version)
+			VALUES ($1, $2, $3, $4, $5)
+			ON CONFLICT (policy_id, page_number) DO UPDATE
+			SET pdf_path = EXCLUDED.pdf_path, content = EXCLUDED.content, prompt_version = EXCLUDED.prompt_version
+		`, policyID, pdfPath, i+1, content, promptVersion)
+		if err != nil {
+			return fmt.Errorf("failed to save page %d: %w", i+1, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit pages: %w", err)
+	}
+	return nil
+}
+
+// splitPages splits a transcription on its "=== PAGE n ===" markers. Text with
+// no markers is treated as a single page. Returned slice index i is page i+1;
+// pages the model skipped are left empty.
+func splitPages(text string) []string {
+	matches := pageMarkerPattern.FindAllStringSubmatchIndex(text, -1)
+	if len(matches) == 0 {
+		if strings.TrimSpace(text) == "" {
+			return nil
+		}
+		return []string{strings.TrimSpace(text)}
+	}
+
+	var pages []string
+	for i, m := range matches {
+		pageNum, err := strconv.Atoi(text[m[2]:m[3]])
+		if err != nil || pageNum < 1 {
+			continue
+		}
+		end := len(text)
+		if i+1 < len(matches) {
+			end = matches[i+1][0]
+		}
+		for len(pages) < pageNum {
+			pages = append(pages, "")
+		}
+		content := strings.TrimSpace(text[m[1]:end])
+		if pages[pageNum-1] != "" && content != "" {
+			content = pages[pageNum-1] + "\n" + content
+		} else if content == "" {
+			content = pages[pageNum-1]
+		}
+		pages[pageNum-1] = content
+	}
+	return pages
+}
+
+// savePages replaces the indexed pages for a source in one transaction,
+// recording the version of the prompt that transcribed them
+func (s *DocumentTextService) savePages(ctx context.Context, src textSource, pages []string, promptVersion string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx,
+		fmt.Sprintf(`DELETE FROM document_pages WHERE %s = $1`, src.idColumn), src.id,
+	); err != nil {
+		return fmt.Errorf("failed to clear previous pages: %w", err)
+	}
+
+	insert := fmt.Sprintf(`
+		INSERT INTO document_pages (claim_id, %s, page_number, content)
+		VALUES ($1, $2, $3, $4)
+	`, src.idColumn)
+	for i, content := range pages {
+		if content == "" {
+			continue
+		}
+		if _, err := tx.ExecContext(ctx, insert, src.claimID, src.id, i+1, content); err != nil {
+			return fmt.Errorf("failed to save page %d: %w", i+1, err)
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx,
+		fmt.Sprintf(`UPDATE %s SET text_extraction_prompt_version = $1 WHERE id = $2`, src.table), promptVersion, src.id,
+	); err != nil {
+		return fmt.Errorf("failed to record prompt version: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit pages: %w", err)
+	}
+	return nil
+}
+
+// claimExtraction marks a source as processing unless it is completed or
+// another run started on it within textExtractionTimeout. It returns false
+// when the source is left to the run already on it.
+func (s *DocumentTextService) claimExtraction(ctx context.Context, src textSource) (bool, error) {
+	query := fmt.Sprintf(`
+		UPDATE %s
+		SET text_extraction_status = 'processing',
+		    text_extraction_error = NULL,
+		    text_extraction_started_at = NOW()
+		WHERE id = $1
+		  AND text_extraction_status <> 'completed'
+		  AND (text_extraction_status <> 'processing'
+		       OR COALESCE(text_extraction_started_at, '-infinity') < NOW() - make_interval(secs => $2))
+	`, src.table)
+	result, err := s.db.ExecContext(ctx, query, src.id, textExtractionTimeout.Seconds())
+	if err != nil {
+		return false, fmt.Errorf("failed to start text extraction: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to start text extraction: %w", err)
+	}
+	return rows > 0, nil
+}
+
+func (s *DocumentTextService) setExtractionStatus(ctx context.Context, src textSource, status string, errorMsg *string) {
+	query := fmt.Sprintf(`
+		UPDATE %s
+		SET text_extraction_status = $1,
+		    text_extraction_error = $2,
+		    text_extracted_at = CASE WHEN $1 = 'completed' THEN NOW() ELSE text_extracted_at END
+		WHERE id = $3
+	`, src.table)
+	if _, err := s.db.ExecContext(ctx, query, status, errorMsg, src.id); err != nil {
+		log.Printf("Warning: failed to update text extraction status for %s %s: %v", src.table, src.id, err)
+	}
+}
+
+// SearchDocumentsInput holds the search parameters
+type SearchDocumentsInput struct {
+	Query   string
+	ClaimID string // optional: restrict to a single claim
+	Limit   int
+}
+
+// DocumentSearchHit is one matching page
+type DocumentSearchHit struct {
+	ClaimID           string  `json:"claim_id"`
+	ClaimNumber       *string `json:"claim_number"`
+	DocumentID        *string `json:"document_id"`
+	CarrierEstimateID *string `json:"carrier_estimate_id"`
+	FileName          string  `json:"file_name"`
+	DocumentType      string  `json:"document_type"`
+	IsCurrent         bool    `json:"is_current"`
+	PageNumber        int     `json:"page_number"`
+	Snippet           string  `json:"snippet"`
+	Rank              float64 `json:"rank"`
+}
+
+// Search runs a full-text query over the organization's indexed pages.
+// Query syntax follows websearch_to_tsquery: quoted phrases, OR, and -exclusions.
+func (s *DocumentTextService) Search(ctx context.Context, organizationID string, input SearchDocumentsInput) ([]DocumentSearchHit, error) {
+	if strings.TrimSpace(input.Query) == "" {
+		return nil, fmt.Errorf("search query is required")
+	}
+	if input.Limit <= 0 || input.Limit > 100 {
+		input.Limit = 25
+	}
+
+	query := `
+		SELECT dp.claim_id, c.claim_number, dp.document_id, dp.carrier_estimate_id,
+		       COALESCE(d.file_name, ce.file_name),
+		       COALESCE(d.document_type, 'carrier_estimate'),
+		       COALESCE(d.is_current, ce.is_current),
+		       dp.page_number,
+		       ts_headline('english', dp.content, q,
+		           'StartSel=**, StopSel=**, MaxWords=30, MinWords=10, MaxFragments=2'),
+		       ts_rank(dp.search_vector, q) AS rank
+		FROM document_pages dp
+		CROSS JOIN websearch_to_tsquery('english', $2) q
+		INNER JOIN claims c ON dp.claim_id = c.id
+		INNER JOIN properties p ON c.property_id = p.id
+		LEFT JOIN documents d ON dp.document_id = d.id
+		LEFT JOIN carrier_estimates ce ON dp.carrier_estimate_id = ce.id
+		WHERE p.organization_id = $1
+		  AND dp.search_vector @@ q
+		  AND ($3 = '' OR dp.claim_id::text = $3)
+		ORDER BY rank DESC, dp.claim_id, dp.page_number
+		LIMIT $4
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, organizationID, input.Query, input.ClaimID, input.Limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search documents: %w", err)
+	}
+	defer rows.Close()
+
+	hits := []DocumentSearchHit{}
+	for rows.Next() {
+		var h DocumentSearchHit
+		err := rows.Scan(
+			&h.ClaimID,
+			&h.ClaimNumber,
+			&h.DocumentID,
+			&h.CarrierEstimateID,
+			&h.FileName,
+			&h.DocumentType,
+			&h.IsCurrent,
+			&h.PageNumber,
+			&h.Snippet,
+			&h.Rank,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan search hit: %w", err)
+		}
+		hits = append(hits, h)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate search hits: %w", err)
+	}
+
+	return hits, nil
+}
+
+// GetGroundingText returns the extracted text of a claim's current documents
+// of the given types (use models.DocumentTypeCarrierEstimate for carrier
+// estimates), labelled by file and page and truncated to maxChars. Returns an
+// empty string when nothing has been extracted.
+func (s *DocumentTextService) GetGroundingText(ctx context.Context, claimID string, documentTypes []string, maxChars int) (string, error) {
+	if len(documentTypes) == 0 {
+		return "", nil
+	}
+	placeholders := make([]string, len(documentTypes))
+	args := []interface{}{claimID}
+	for i, t := range documentTypes {
+		placeholders[i] = fmt.Sprintf("$%d", i+2)
+		args = append(args, t)
+	}
+
+	query := `
+		SELECT COALESCE(d.document_type, 'carrier_estimate') AS doc_type,
+		       COALESCE(d.file_name, ce.file_name), dp.page_number, dp.content
+		FROM document_pages dp
+		LEFT JOIN documents d ON dp.document_id = d.id
+		LEFT JOIN carrier_estimates ce ON dp.carrier_estimate_id = ce.id
+		WHERE dp.claim_id = $1
+		  AND COALESCE(d.is_current, ce.is_current)
+		  AND COALESCE(d.document_type, 'carrier_estimate') IN (` + strings.Join(placeholders, ", ") + `)
+		ORDER BY doc_type, COALESCE(d.created_at, ce.uploaded_at), dp.page_number
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return "", fmt.Errorf("failed to load document text: %w", err)
+	}
+	defer rows.Close()
+
+	var b strings.Builder
+	for rows.Next() {
+		var docType, fileName, content string
+		var page int
+		if err := rows.Scan(&docType, &fileName, &page, &content); err != nil {
+			return "", fmt.Errorf("failed to scan document text: %w", err)
+		}
+		section := fmt.Sprintf("[%s: %s, page %d]\n%s\n\n", docType, fileName, page, content)
+		if maxChars > 0 && b.Len()+len(section) > maxChars {
+			remaining := maxChars - b.Len()
+			for remaining > 0 && !utf8.RuneStart(section[remaining]) {
+				remaining--
+			}
+			if remaining > 0 {
+				b.WriteString(section[:remaining])
+			}
+			b.WriteString("\n[... truncated ...]\n")
+			break
+		}
+		b.WriteString(section)
+	}
+	if err := rows.Err(); err != nil {
+		return "", fmt.Errorf("failed to iterate document text: %w", err)
+	}
+
+	return strings.TrimSpace(b.String()), nil
+}
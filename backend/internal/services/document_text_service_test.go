@@ -0,0 +1,219 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/claimcoach/backend/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitPages(t *testing.T) {
+	t.Run("splits on page markers", func(t *testing.T) {
+		text := "=== PAGE 1 ===\nRoof replacement\nRidge vent 40 LF\n=== PAGE 2 ===\nMatching is not covered\n"
+		pages := splitPages(text)
+		require.Len(t, pages, 2)
+		assert.Equal(t, "Roof replacement\nRidge vent 40 LF", pages[0])
+		assert.Equal(t, "Matching is not covered", pages[1])
+	})
+
+	t.Run("no markers is a single page", func(t *testing.T) {
+		pages := splitPages("  Photo of damaged shingles  ")
+		assert.Equal(t, []string{"Photo of damaged shingles"}, pages)
+	})
+
+	t.Run("empty text has no pages", func(t *testing.T) {
+		assert.Empty(t, splitPages("   \n"))
+	})
+
+	t.Run("skipped pages stay empty", func(t *testing.T) {
+		pages := splitPages("=== PAGE 1 ===\nCover\n=== PAGE 3 ===\nSummary")
+		require.Len(t, pages, 3)
+		assert.Equal(t, "Cover", pages[0])
+		assert.Equal(t, "", pages[1])
+		assert.Equal(t, "Summary", pages[2])
+	})
+}
+
+func TestDocumentTextService_Search_RequiresQuery(t *testing.T) {
+	service := &DocumentTextService{}
+	_, err := service.Search(context.Background(), "org-1", SearchDocumentsInput{Query: "  "})
+	assert.EqualError(t, err, "search query is required")
+}
+
+func TestDocumentTextService_GetGroundingText_Truncates(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	service := &DocumentTextService{db: db}
+
+	mock.ExpectQuery(`SELECT (.+) FROM document_pages dp`).
+		WithArgs("claim-1", models.DocumentTypeCarrierEstimate).
+		WillReturnRows(sqlmock.NewRows([]string{"doc_type", "file_name", "page_number", "content"}).
+			AddRow("carrier_estimate", "estimate.pdf", 1, strings.Repeat("a", 50)).
+			AddRow("carrier_estimate", "estimate.pdf", 2, strings.Repeat("b", 50)))
+
+	text, err := service.GetGroundingText(context.Background(), "claim-1", []string{models.DocumentTypeCarrierEstimate}, 100)
+
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(text, "[carrier_estimate: estimate.pdf, page 1]"))
+	assert.Contains(t, text, "[... truncated ...]")
+	assert.NotContains(t, text, "page 2]\n"+strings.Repeat("b", 50))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// textExtractorFunc transcribes PDFs with a function; images are not used here
+type textExtractorFunc func(pdfContent []byte) (string, error)
+
+func (f textExtractorFunc) ParsePDF(ctx context.Context, pdfContent []byte, prompt string, maxTokens int) (string, error) {
+	return f(pdfContent)
+}
+
+func (f textExtractorFunc) ParseImage(ctx context.Context, imageContent []byte, mediaType string, prompt string, maxTokens int) (string, error) {
+	return f(imageContent)
+}
+
+func TestDocumentTextService_ExtractDocumentText(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("%PDF-1.4"))
+	}))
+	defer server.Close()
+
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	calls := 0
+	service := &DocumentTextService{
+		db:         db,
+		storage:    &MockStorageClient{DownloadURLFunc: func(string) (string, error) { return server.URL, nil }},
+		httpClient: server.Client(),
+		extractor: textExtractorFunc(func([]byte) (string, error) {
+			calls++
+			return "=== PAGE 1 ===\nRoof replacement", nil
+		}),
+	}
+	expectDocument := func(status string) {
+		mock.ExpectQuery(`SELECT claim_id, file_url, mime_type, text_extraction_status FROM documents`).
+			WithArgs("doc-1").
+			WillReturnRows(sqlmock.NewRows([]string{"claim_id", "file_url", "mime_type", "text_extraction_status"}).
+				AddRow("claim-1", "claims/doc.pdf", "application/pdf", status))
+	}
+
+	t.Run("leaves a document to the run extracting it", func(t *testing.T) {
+		expectDocument(models.TextExtractionProcessing)
+		mock.ExpectExec(`UPDATE documents SET text_extraction_status = 'processing'`).
+			WithArgs("doc-1", textExtractionTimeout.Seconds()).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		require.NoError(t, service.ExtractDocumentText(context.Background(), "doc-1"))
+		assert.Zero(t, calls)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("restarts an abandoned extraction", func(t *testing.T) {
+		expectDocument(models.TextExtractionProcessing)
+		mock.ExpectExec(`UPDATE documents SET text_extraction_status = 'processing'`).
+			WithArgs("doc-1", textExtractionTimeout.Seconds()).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectBegin()
+		mock.ExpectExec(`DELETE FROM document_pages WHERE document_id`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(`INSERT INTO document_pages`).
+			WithArgs("claim-1", "doc-1", 1, "Roof replacement").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`UPDATE documents SET text_extraction_prompt_version = \$1`).
+			WithArgs("text_extraction/v1", "doc-1").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+		mock.ExpectExec(`UPDATE documents SET text_extraction_status = \$1`).
+			WithArgs(models.TextExtractionCompleted, nil, "doc-1").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		require.NoError(t, service.ExtractDocumentText(context.Background(), "doc-1"))
+		assert.Equal(t, 1, calls)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestDocumentTextService_ExtractCarrierEstimateText_SkipsUnconfirmed(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	calls := 0
+	service := &DocumentTextService{
+		db: db,
+		extractor: textExtractorFunc(func([]byte) (string, error) {
+			calls++
+			return "", nil
+		}),
+	}
+
+	// An estimate whose upload was never confirmed is still version 0
+	mock.ExpectQuery(`FROM carrier_estimates\s+WHERE id = \$1 AND version > 0`).
+		WithArgs("estimate-1").
+		WillReturnRows(sqlmock.NewRows([]string{"claim_id", "file_path", "text_extraction_status"}))
+
+	err = service.ExtractCarrierEstimateText(context.Background(), "estimate-1")
+
+	assert.EqualError(t, err, "carrier estimate not found")
+	assert.Zero(t, calls)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDocumentTextService_GetPolicyPages(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	calls := 0
+	service := &DocumentTextService{
+		db: db,
+		extractor: textExtractorFunc(func([]byte) (string, error) {
+			calls++
+			return "=== PAGE 1 ===\nDeclarations\n=== PAGE 3 ===\nWe will pay to replace undamaged siding.", nil
+		}),
+	}
+	pageColumns := []string{"page_number", "content"}
+
+	t.Run("transcribes a policy once", func(t *testing.T) {
+		mock.ExpectQuery(`FROM policy_pages`).
+			WithArgs("policy-1", "policies/v1.pdf").
+			WillReturnRows(sqlmock.NewRows(pageColumns))
+		mock.ExpectBegin()
+		mock.ExpectExec(`DELETE FROM policy_pages WHERE policy_id`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(`INSERT INTO policy_pages`).
+			WithArgs("policy-1", "policies/v1.pdf", 1, "Declarations", "text_extraction/v1").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`INSERT INTO policy_pages`).
+			WithArgs("policy-1", "policies/v1.pdf", 3, "We will pay to replace undamaged siding.", "text_extraction/v1").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		pages, err := service.GetPolicyPages(context.Background(), "policy-1", "policies/v1.pdf", []byte("%PDF-1.4"))
+		require.NoError(t, err)
+		assert.Equal(t, []string{"Declarations", "", "We will pay to replace undamaged siding."}, pages)
+		assert.Equal(t, 1, calls)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("serves stored text", func(t *testing.T) {
+		mock.ExpectQuery(`FROM policy_pages`).
+			WithArgs("policy-1", "policies/v1.pdf").
+			WillReturnRows(sqlmock.NewRows(pageColumns).
+				AddRow(1, "Declarations").
+				AddRow(3, "We will pay to replace undamaged siding."))
+
+		pages, err := service.GetPolicyPages(context.Background(), "policy-1", "policies/v1.pdf", []byte("%PDF-1.4"))
+		require.NoError(t, err)
+		assert.Equal(t, []string{"Declarations", "", "We will pay to replace undamaged siding."}, pages)
+		assert.Equal(t, 1, calls)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
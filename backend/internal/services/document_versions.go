@@ -0,0 +1,225 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/claimcoach/backend/internal/models"
+)
+
+// validateSupersedes checks that a new upload may supersede the given document:
+// it must belong to the same claim, be confirmed, have the same type and be the
+// current version of its chain.
+func validateSupersedes(ctx context.Context, db *sql.DB, claimID, documentType, supersedesID string) error {
+	var existingType string
+	var isCurrent bool
+	err := db.QueryRowContext(ctx, `
+		SELECT document_type, is_current
+		FROM documents
+		WHERE id = $1 AND claim_id = $2 AND status = 'confirmed'
+	`, supersedesID, claimID).Scan(&existingType, &isCurrent)
+	if err == sql.ErrNoRows {
+		return models.ErrSupersededNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load superseded document: %w", err)
+	}
+	if existingType != documentType {
+		return models.ErrSupersedeTypeChange
+	}
+	if !isCurrent {
+		return models.ErrSupersedeNotCurrent
+	}
+	return nil
+}
+
+// promoteDocumentVersion makes a just-confirmed document the current version of
+// its chain. When the upload did not name a document to supersede and its type
+// is single-version (see models.VersionedDocumentTypes), the claim's current
+// document of that type is superseded automatically. Must run in the same
+// transaction as the confirm so a failed promotion leaves the upload pending.
+func promoteDocumentVersion(ctx context.Context, tx *sql.Tx, doc *models.Document) error {
+	supersedesID := doc.SupersedesDocumentID
+
+	if supersedesID == nil && models.VersionedDocumentTypes[doc.DocumentType] {
+		var currentID string
+		err := tx.QueryRowContext(ctx, `
+			SELECT id
+			FROM documents
+			WHERE claim_id = $1 AND document_type = $2 AND status = 'confirmed'
+			  AND is_current AND id <> $3
+			ORDER BY created_at DESC
+			LIMIT 1
+			FOR UPDATE
+		`, doc.ClaimID, doc.DocumentType, doc.ID).Scan(&currentID)
+		if err != nil && err != sql.ErrNoRows {
+			return fmt.Errorf("failed to find current document version: %w", err)
+		}
+		if err == nil {
+			supersedesID = &currentID
+		}
+	}
+
+	version := 1
+	if supersedesID != nil {
+		var previousVersion int
+		err := tx.QueryRowContext(ctx, `
+			UPDATE documents
+			SET is_current = false
+			WHERE id = $1 AND claim_id = $2 AND is_current
+			RETURNING version
+		`, *supersedesID, doc.ClaimID).Scan(&previousVersion)
+		if err == sql.ErrNoRows {
+			// Someone else superseded it between request and confirm
+			return models.ErrSupersedeNotCurrent
+		}
+		if err != nil {
+			return fmt.Errorf("failed to retire previous document version: %w", err)
+		}
+		version = previousVersion + 1
+	}
+
+	_, err := tx.ExecContext(ctx, `
+		UPDATE documents
+		SET version = $1, supersedes_document_id = $2, is_current = true
+		WHERE id = $3
+	`, version, supersedesID, doc.ID)
+	if err != nil {
+		return fmt.Errorf("failed to promote document version: %w", err)
+	}
+
+	doc.Version = version
+	doc.SupersedesDocumentID = supersedesID
+	doc.IsCurrent = true
+	return nil
+}
+
+// confirmDocumentUpload flips a pending document to confirmed and promotes it
+// to the current version in one transaction. Shared by PM and magic-link uploads.
+func confirmDocumentUpload(ctx context.Context, db *sql.DB, claimID, documentID string) (*models.Document, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Lock the claim so concurrent confirms promote one version at a time
+	// instead of each finding no current version to supersede
+	if err := lockClaim(ctx, tx, claimID); err != nil {
+		return nil, err
+	}
+
+	query := `
+		UPDATE documents
+		SET status = 'confirmed'
+		WHERE id = $1 AND claim_id = $2 AND status = 'pending'
+		RETURNING id, claim_id, uploaded_by_user_id, document_type, file_url,
+			file_name, file_size_bytes, mime_type, metadata, status,
+			version, supersedes_document_id, is_current, created_at
+	`
+
+	var doc models.Document
+	err = tx.QueryRowContext(ctx, query, documentID, claimID).Scan(
+		&doc.ID,
+		&doc.ClaimID,
+		&doc.UploadedByUserID,
+		&doc.DocumentType,
+		&doc.FileURL,
+		&doc.FileName,
+		&doc.FileSizeBytes,
+		&doc.MimeType,
+		&doc.Metadata,
+		&doc.Status,
+		&doc.Version,
+		&doc.SupersedesDocumentID,
+		&doc.IsCurrent,
+		&doc.CreatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("document not found or already confirmed")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to confirm document: %w", err)
+	}
+
+	if err := promoteDocumentVersion(ctx, tx, &doc); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit document confirmation: %w", err)
+	}
+	return &doc, nil
+}
+
+// GetDocumentVersions returns every confirmed version in the supersede chain
+// that contains documentID, newest first.
+func (s *DocumentService) GetDocumentVersions(ctx context.Context, documentID string, organizationID string) ([]models.Document, error) {
+	// Walk back to the first version, then forward through everything that
+	// supersedes it.
+	query := `
+		WITH RECURSIVE back AS (
+			SELECT id, supersedes_document_id FROM documents WHERE id = $1
+			UNION ALL
+			SELECT d.id, d.supersedes_document_id
+			FROM documents d
+			INNER JOIN back b ON d.id = b.supersedes_document_id
+		),
+		chain AS (
+			SELECT id FROM back WHERE supersedes_document_id IS NULL
+			UNION
+			SELECT d.id
+			FROM documents d
+			INNER JOIN chain ch ON d.supersedes_document_id = ch.id
+		)
+		SELECT d.id, d.claim_id, d.uploaded_by_user_id, d.document_type, d.file_url,
+			d.file_name, d.file_size_bytes, d.mime_type, d.metadata, d.status,
+			d.version, d.supersedes_document_id, d.is_current, d.created_at
+		FROM documents d
+		INNER JOIN chain ch ON d.id = ch.id
+		INNER JOIN claims c ON d.claim_id = c.id
+		INNER JOIN properties p ON c.property_id = p.id
+		WHERE p.organization_id = $2 AND d.status = 'confirmed'
+		ORDER BY d.version DESC, d.created_at DESC
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, documentID, organizationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query document versions: %w", err)
+	}
+	defer rows.Close()
+
+	versions := []models.Document{}
+	for rows.Next() {
+		var doc models.Document
+		err := rows.Scan(
+			&doc.ID,
+			&doc.ClaimID,
+			&doc.UploadedByUserID,
+			&doc.DocumentType,
+			&doc.FileURL,
+			&doc.FileName,
+			&doc.FileSizeBytes,
+			&doc.MimeType,
+			&doc.Metadata,
+			&doc.Status,
+			&doc.Version,
+			&doc.SupersedesDocumentID,
+			&doc.IsCurrent,
+			&doc.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan document version: %w", err)
+		}
+		versions = append(versions, doc)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate document versions: %w", err)
+	}
+
+	if len(versions) == 0 {
+		return nil, fmt.Errorf("document not found")
+	}
+	return versions, nil
+}
@@ -0,0 +1,110 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/claimcoach/backend/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var confirmedDocumentColumns = []string{
+	"id", "claim_id", "uploaded_by_user_id", "document_type", "file_url",
+	"file_name", "file_size_bytes", "mime_type", "metadata", "status",
+	"version", "supersedes_document_id", "is_current", "created_at",
+}
+
+// expectClaimLock mocks lockClaim taking the claim's row lock
+func expectClaimLock(mock sqlmock.Sqlmock, claimID string) {
+	mock.ExpectQuery(`SELECT id FROM claims WHERE id = \$1 FOR UPDATE`).
+		WithArgs(claimID).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(claimID))
+}
+
+func TestConfirmDocumentUpload_AutoSupersedesCurrentEstimate(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	now := time.Now()
+	mock.ExpectBegin()
+	expectClaimLock(mock, "claim-1")
+	mock.ExpectQuery(`UPDATE documents\s+SET status = 'confirmed'`).
+		WithArgs("doc-2", "claim-1").
+		WillReturnRows(sqlmock.NewRows(confirmedDocumentColumns).
+			AddRow("doc-2", "claim-1", nil, models.DocumentTypeContractorEstimate, "path/v2.pdf",
+				"v2.pdf", 1024, "application/pdf", nil, "confirmed", 1, nil, false, now))
+	mock.ExpectQuery(`SELECT id\s+FROM documents`).
+		WithArgs("claim-1", models.DocumentTypeContractorEstimate, "doc-2").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("doc-1"))
+	mock.ExpectQuery(`UPDATE documents\s+SET is_current = false`).
+		WithArgs("doc-1", "claim-1").
+		WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(1))
+	mock.ExpectExec(`UPDATE documents\s+SET version = \$1`).
+		WithArgs(2, sqlmock.AnyArg(), "doc-2").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	doc, err := confirmDocumentUpload(context.Background(), db, "claim-1", "doc-2")
+
+	require.NoError(t, err)
+	assert.Equal(t, 2, doc.Version)
+	assert.True(t, doc.IsCurrent)
+	require.NotNil(t, doc.SupersedesDocumentID)
+	assert.Equal(t, "doc-1", *doc.SupersedesDocumentID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestConfirmDocumentUpload_PhotosDoNotAutoSupersede(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	now := time.Now()
+	mock.ExpectBegin()
+	expectClaimLock(mock, "claim-1")
+	mock.ExpectQuery(`UPDATE documents\s+SET status = 'confirmed'`).
+		WithArgs("photo-2", "claim-1").
+		WillReturnRows(sqlmock.NewRows(confirmedDocumentColumns).
+			AddRow("photo-2", "claim-1", nil, models.DocumentTypeContractorPhoto, "path/p.jpg",
+				"p.jpg", 2048, "image/jpeg", nil, "confirmed", 1, nil, false, now))
+	mock.ExpectExec(`UPDATE documents\s+SET version = \$1`).
+		WithArgs(1, sqlmock.AnyArg(), "photo-2").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	doc, err := confirmDocumentUpload(context.Background(), db, "claim-1", "photo-2")
+
+	require.NoError(t, err)
+	assert.Equal(t, 1, doc.Version)
+	assert.Nil(t, doc.SupersedesDocumentID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestConfirmDocumentUpload_SupersededVersionNoLongerCurrent(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	now := time.Now()
+	mock.ExpectBegin()
+	expectClaimLock(mock, "claim-1")
+	mock.ExpectQuery(`UPDATE documents\s+SET status = 'confirmed'`).
+		WithArgs("doc-3", "claim-1").
+		WillReturnRows(sqlmock.NewRows(confirmedDocumentColumns).
+			AddRow("doc-3", "claim-1", nil, models.DocumentTypeOther, "path/o.pdf",
+				"o.pdf", 1024, "application/pdf", nil, "confirmed", 1, "doc-1", false, now))
+	mock.ExpectQuery(`UPDATE documents\s+SET is_current = false`).
+		WithArgs("doc-1", "claim-1").
+		WillReturnRows(sqlmock.NewRows([]string{"version"}))
+	mock.ExpectRollback()
+
+	doc, err := confirmDocumentUpload(context.Background(), db, "claim-1", "doc-3")
+
+	assert.Nil(t, doc)
+	assert.ErrorIs(t, err, models.ErrSupersedeNotCurrent)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
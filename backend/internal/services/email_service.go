@@ -16,6 +16,9 @@ type EmailService interface {
 	SendOwnerApprovalEmail(input SendOwnerApprovalEmailInput) error
 	SendLegalPartnerEmail(input SendLegalPartnerEmailInput) error
 	SendPMConfirmationEmail(input SendPMConfirmationEmailInput) error
+	SendMagicLinkReminderEmail(input SendMagicLinkReminderEmailInput) error
+	SendMagicLinkExpiredEmail(input SendMagicLinkExpiredEmailInput) error
+	SendScopeChangesRequestedEmail(input SendScopeChangesRequestedEmailInput) error
 }
 
 // SendPMConfirmationEmailInput contains data for the PM confirmation after legal package is sent.
@@ -36,6 +39,44 @@ type SendMagicLinkEmailInput struct {
 	ExpiresAt       time.Time
 }
 
+// SendMagicLinkReminderEmailInput contains all data needed to nudge a contractor about an outstanding magic link.
+// Kind is one of the models.MagicLinkReminder* constants and selects the wording.
+type SendMagicLinkReminderEmailInput struct {
+	To              string
+	Kind            string
+	ContractorName  string
+	PropertyName    string
+	PropertyAddress string
+	LossType        string
+	MagicLinkURL    string
+	ExpiresAt       time.Time
+}
+
+// SendMagicLinkExpiredEmailInput contains all data needed to tell a PM their magic link expired unused.
+type SendMagicLinkExpiredEmailInput struct {
+	To              string
+	PMName          string
+	ContractorName  string
+	ContractorEmail string
+	PropertyName    string
+	PropertyAddress string
+	ExpiredAt       time.Time
+	ReissueURL      string
+}
+
+// SendScopeChangesRequestedEmailInput contains all data needed to ask a contractor to correct a scope sheet.
+// Comments are already formatted, one per area (or general) comment.
+type SendScopeChangesRequestedEmailInput struct {
+	To              string
+	ContractorName  string
+	PropertyName    string
+	PropertyAddress string
+	Revision        int
+	ReviewNotes     string
+	Comments        []string
+	MagicLinkURL    string
+}
+
 // SendOwnerApprovalEmailInput contains all data needed to send the homeowner approval request email.
 type SendOwnerApprovalEmailInput struct {
 	To              string
@@ -180,6 +221,24 @@ func (s *MockEmailService) SendPMConfirmationEmail(input SendPMConfirmationEmail
 	return nil
 }
 
+func (s *MockEmailService) SendMagicLinkReminderEmail(input SendMagicLinkReminderEmailInput) error {
+	log.Printf("[MOCK EMAIL] Magic link reminder (%s) to: %s | URL: %s | Expires: %s",
+		input.Kind, input.To, input.MagicLinkURL, input.ExpiresAt.Format(time.RFC3339))
+	return nil
+}
+
+func (s *MockEmailService) SendMagicLinkExpiredEmail(input SendMagicLinkExpiredEmailInput) error {
+	log.Printf("[MOCK EMAIL] Magic link expired unused to: %s | Contractor: %s | Reissue: %s",
+		input.To, input.ContractorEmail, input.ReissueURL)
+	return nil
+}
+
+func (s *MockEmailService) SendScopeChangesRequestedEmail(input SendScopeChangesRequestedEmailInput) error {
+	log.Printf("[MOCK EMAIL] Scope sheet changes requested (revision %d) to: %s | %d comments | URL: %s",
+		input.Revision, input.To, len(input.Comments), input.MagicLinkURL)
+	return nil
+}
+
 // SendClaimCoachNotification logs ClaimCoach notification to console for development
 func (s *MockEmailService) SendClaimCoachNotification(claim *models.Claim) error {
 	log.Println("=======================================================")
@@ -1,7 +1,6 @@
 package services
 
 import (
-	"archive/zip"
 	"bytes"
 	"context"
 	"database/sql"
@@ -9,27 +8,26 @@ import (
 	"fmt"
 	"io"
 	"log"
-	"net/http"
 	"strings"
 	"time"
 	"unicode"
 
-	"github.com/claimcoach/backend/internal/storage"
+	"github.com/claimcoach/backend/internal/pricing"
 	"github.com/go-pdf/fpdf"
 )
 
 // LegalPackageService assembles the attorney ZIP package for LEGAL_REVIEW claims.
 type LegalPackageService struct {
-	db           *sql.DB
-	storage      *storage.SupabaseStorage
-	auditService *AuditService
+	db             *sql.DB
+	auditService   *AuditService
+	archiveService *ArchiveService
 }
 
-func NewLegalPackageService(db *sql.DB, storageClient *storage.SupabaseStorage, auditService *AuditService) *LegalPackageService {
+func NewLegalPackageService(db *sql.DB, auditService *AuditService, archiveService *ArchiveService) *LegalPackageService {
 	return &LegalPackageService{
-		db:           db,
-		storage:      storageClient,
-		auditService: auditService,
+		db:             db,
+		auditService:   auditService,
+		archiveService: archiveService,
 	}
 }
 
@@ -44,64 +42,70 @@ type legalClaimData struct {
 	PolicyNumber  string
 	PolicyPDFPath *string // storage path, may be nil
 	PMBrain       *PMBrainAnalysis
+	Estimate      *pricing.IndustryEstimate // nil when no industry estimate was generated
 }
 
-// GenerateLegalPackage builds the complete ZIP bundle and returns its bytes and filename.
-func (s *LegalPackageService) GenerateLegalPackage(ctx context.Context, claimID, orgID string) ([]byte, string, error) {
+// PrepareLegalPackage generates the attorney briefing PDF and resolves every
+// supporting file into a streamable archive. Files are not fetched until the
+// archive is written with WriteLegalPackage.
+func (s *LegalPackageService) PrepareLegalPackage(ctx context.Context, claimID, orgID string) (*Archive, error) {
 	// 1. Load claim + property + policy data
 	data, err := s.loadClaimData(ctx, claimID, orgID)
 	if err != nil {
-		return nil, "", err
+		return nil, err
 	}
 
 	// 2. Load audit report and parse PMBrainAnalysis
 	report, err := s.auditService.GetAuditReportByClaimID(ctx, claimID, orgID)
 	if err != nil || report == nil {
-		return nil, "", fmt.Errorf("audit report required before generating legal package")
+		return nil, fmt.Errorf("audit report required before generating legal package")
 	}
 	if report.PMBrainAnalysis == nil {
-		return nil, "", fmt.Errorf("audit report required before generating legal package")
+		return nil, fmt.Errorf("audit report required before generating legal package")
 	}
 	var pmBrain PMBrainAnalysis
 	if err := json.Unmarshal([]byte(*report.PMBrainAnalysis), &pmBrain); err != nil {
-		return nil, "", fmt.Errorf("failed to parse audit data: %w", err)
+		return nil, fmt.Errorf("failed to parse audit data: %w", err)
 	}
 	data.PMBrain = &pmBrain
+	if report.GeneratedEstimate != nil && *report.GeneratedEstimate != "" {
+		estimate, err := pricing.ParseIndustryEstimate(*report.GeneratedEstimate)
+		if err != nil {
+			return nil, fmt.Errorf("industry estimate is invalid — please regenerate it: %w", err)
+		}
+		data.Estimate = estimate
+	}
 
 	// 3. Generate attorney briefing PDF
 	pdfBytes, err := s.generateBriefingPDF(data)
 	if err != nil {
-		return nil, "", fmt.Errorf("failed to generate PDF: %w", err)
+		return nil, fmt.Errorf("failed to generate PDF: %w", err)
 	}
 
 	// 4. Load documents from DB categorized by type
 	docsByType, err := s.loadDocuments(ctx, claimID)
 	if err != nil {
-		return nil, "", fmt.Errorf("failed to load documents: %w", err)
+		return nil, fmt.Errorf("failed to load documents: %w", err)
 	}
 
 	// 5. Load carrier estimates (separate table)
-	carrierEstimates, err := s.loadCarrierEstimates(ctx, claimID)
+	carrierEstimates, err := loadCarrierEstimateEntries(ctx, s.db, claimID, "2-Carrier-Documents/", false)
 	if err != nil {
 		// Non-fatal: log and continue
 		log.Printf("Warning: failed to load carrier estimates for claim %s: %v", claimID, err)
 		carrierEstimates = nil
 	}
 
-	// 6. Assemble and return ZIP
-	zipBytes, err := s.assembleZIP(pdfBytes, data, docsByType, carrierEstimates)
-	if err != nil {
-		return nil, "", fmt.Errorf("failed to assemble ZIP: %w", err)
-	}
-
-	// Build a clean filename
-	safeClaimNum := strings.NewReplacer("/", "-", " ", "-", "\\", "-").Replace(data.ClaimNumber)
-	if safeClaimNum == "" {
-		safeClaimNum = claimID[:8]
-	}
-	filename := fmt.Sprintf("ClaimCoach-Legal-Package-%s-%s.zip", safeClaimNum, time.Now().Format("2006-01-02"))
+	return &Archive{
+		Filename: archiveFilename("ClaimCoach-Legal-Package", data.ClaimNumber, claimID),
+		Entries:  s.packageEntries(pdfBytes, data, docsByType, carrierEstimates),
+	}, nil
+}
 
-	return zipBytes, filename, nil
+// WriteLegalPackage streams a prepared legal package ZIP to w.
+func (s *LegalPackageService) WriteLegalPackage(ctx context.Context, w io.Writer, pkg *Archive) error {
+	_, err := s.archiveService.Stream(ctx, w, pkg)
+	return err
 }
 
 // loadClaimData fetches claim + property + policy in one query.
@@ -153,12 +157,12 @@ func (s *LegalPackageService) loadClaimData(ctx context.Context, claimID, orgID
 	return &d, nil
 }
 
-// loadDocuments fetches all confirmed documents for the claim grouped by type.
+// loadDocuments fetches the current confirmed documents for the claim grouped by type.
 func (s *LegalPackageService) loadDocuments(ctx context.Context, claimID string) (map[string][]docEntry, error) {
 	query := `
 		SELECT document_type, file_url, file_name
 		FROM documents
-		WHERE claim_id = $1 AND status = 'confirmed'
+		WHERE claim_id = $1 AND status = 'confirmed' AND is_current
 		ORDER BY document_type, created_at ASC
 	`
 	rows, err := s.db.QueryContext(ctx, query, claimID)
@@ -183,114 +187,33 @@ type docEntry struct {
 	fileName string
 }
 
-// loadCarrierEstimates fetches carrier estimates from the separate carrier_estimates table.
-// Note: carrier_estimates has no status column — all records are valid uploads.
-func (s *LegalPackageService) loadCarrierEstimates(ctx context.Context, claimID string) ([]docEntry, error) {
-	query := `
-		SELECT file_path, file_name
-		FROM carrier_estimates
-		WHERE claim_id = $1
-		ORDER BY uploaded_at ASC
-	`
-	rows, err := s.db.QueryContext(ctx, query, claimID)
-	if err != nil {
-		return nil, err
+// folderEntries maps docEntries to archive entries inside the given ZIP folder.
+func folderEntries(folder string, docs []docEntry) []ArchiveEntry {
+	entries := make([]ArchiveEntry, 0, len(docs))
+	for _, d := range docs {
+		entries = append(entries, ArchiveEntry{ZIPPath: folder + sanitizeFilename(d.fileName), StoragePath: d.fileURL})
 	}
-	defer rows.Close()
-
-	var entries []docEntry
-	for rows.Next() {
-		var filePath, fileName string
-		if err := rows.Scan(&filePath, &fileName); err != nil {
-			continue
-		}
-		entries = append(entries, docEntry{fileURL: filePath, fileName: fileName})
-	}
-	return entries, rows.Err()
+	return entries
 }
 
-// fetchFile downloads a file from Supabase Storage and returns its bytes.
-// Returns nil bytes (not an error) if the file can't be fetched, so the ZIP
-// continues building with remaining files.
-func (s *LegalPackageService) fetchFile(filePath string) []byte {
-	signedURL, err := s.storage.GenerateDownloadURL(filePath)
-	if err != nil {
-		log.Printf("Warning: could not generate download URL for %s: %v", filePath, err)
-		return nil
-	}
-
-	resp, err := http.Get(signedURL) //nolint:noctx
-	if err != nil {
-		log.Printf("Warning: could not download %s: %v", filePath, err)
-		return nil
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode >= 400 {
-		log.Printf("Warning: download returned %d for %s", resp.StatusCode, filePath)
-		return nil
-	}
-
-	data, err := io.ReadAll(resp.Body)
-	if err != nil {
-		log.Printf("Warning: could not read response body for %s: %v", filePath, err)
-		return nil
-	}
-	return data
-}
-
-// addFilesToZIP fetches and writes a slice of docEntries into the given ZIP folder.
-// Returns the number of files successfully added.
-func (s *LegalPackageService) addFilesToZIP(w *zip.Writer, folder string, entries []docEntry) int {
-	added := 0
-	for _, e := range entries {
-		fileBytes := s.fetchFile(e.fileURL)
-		if fileBytes == nil {
-			continue
-		}
-		zipPath := folder + sanitizeFilename(e.fileName)
-		fw, err := w.Create(zipPath)
-		if err != nil {
-			log.Printf("Warning: could not create ZIP entry %s: %v", zipPath, err)
-			continue
-		}
-		if _, err := fw.Write(fileBytes); err != nil {
-			log.Printf("Warning: could not write ZIP entry %s: %v", zipPath, err)
-			continue
-		}
-		added++
-	}
-	return added
-}
-
-// assembleZIP builds the final ZIP in memory from PDF + all document categories.
-func (s *LegalPackageService) assembleZIP(
+// packageEntries lays out the legal package folder structure from the briefing
+// PDF and all document categories.
+func (s *LegalPackageService) packageEntries(
 	pdfBytes []byte,
 	data *legalClaimData,
 	docsByType map[string][]docEntry,
-	carrierEstimates []docEntry,
-) ([]byte, error) {
-	var buf bytes.Buffer
-	zw := zip.NewWriter(&buf)
-
+	carrierEstimates []ArchiveEntry,
+) []ArchiveEntry {
 	// 1-Legal-Brief/ — always present
-	fw, err := zw.Create("1-Legal-Brief/Attorney-Briefing.pdf")
-	if err != nil {
-		return nil, fmt.Errorf("could not create legal brief ZIP entry: %w", err)
-	}
-	if _, err := fw.Write(pdfBytes); err != nil {
-		return nil, fmt.Errorf("could not write legal brief to ZIP: %w", err)
-	}
+	entries := []ArchiveEntry{{ZIPPath: "1-Legal-Brief/Attorney-Briefing.pdf", Content: pdfBytes}}
 
 	// 2-Carrier-Documents/
-	var carrierDocs []docEntry
-	carrierDocs = append(carrierDocs, carrierEstimates...)
-	carrierDocs = append(carrierDocs, docsByType["carrier_estimate"]...)
-	s.addFilesToZIP(zw, "2-Carrier-Documents/", carrierDocs)
+	entries = append(entries, carrierEstimates...)
+	entries = append(entries, folderEntries("2-Carrier-Documents/", docsByType["carrier_estimate"])...)
 
 	// 3-ClaimCoach-Documents/
-	s.addFilesToZIP(zw, "3-ClaimCoach-Documents/", docsByType["contractor_estimate"])
-	s.addFilesToZIP(zw, "3-ClaimCoach-Documents/photos/", docsByType["contractor_photo"])
+	entries = append(entries, folderEntries("3-ClaimCoach-Documents/", docsByType["contractor_estimate"])...)
+	entries = append(entries, folderEntries("3-ClaimCoach-Documents/photos/", docsByType["contractor_photo"])...)
 
 	// 4-Policy-Documents/
 	var policyDocs []docEntry
@@ -298,18 +221,15 @@ func (s *LegalPackageService) assembleZIP(
 		policyDocs = append(policyDocs, docEntry{fileURL: *data.PolicyPDFPath, fileName: "Policy.pdf"})
 	}
 	policyDocs = append(policyDocs, docsByType["policy_pdf"]...)
-	s.addFilesToZIP(zw, "4-Policy-Documents/", policyDocs)
+	entries = append(entries, folderEntries("4-Policy-Documents/", policyDocs)...)
 
 	// 5-Additional-Evidence/
 	var evidenceDocs []docEntry
 	evidenceDocs = append(evidenceDocs, docsByType["proof_of_repair"]...)
 	evidenceDocs = append(evidenceDocs, docsByType["other"]...)
-	s.addFilesToZIP(zw, "5-Additional-Evidence/", evidenceDocs)
+	entries = append(entries, folderEntries("5-Additional-Evidence/", evidenceDocs)...)
 
-	if err := zw.Close(); err != nil {
-		return nil, fmt.Errorf("failed to finalise ZIP: %w", err)
-	}
-	return buf.Bytes(), nil
+	return entries
 }
 
 // generateBriefingPDF produces the attorney one-pager as a PDF using fpdf.
@@ -410,6 +330,17 @@ func (s *LegalPackageService) generateBriefingPDF(data *legalClaimData) ([]byte,
 	kv("ClaimCoach Estimate (Net Position):", formatCurrency(pmb.TotalContractorEstimate))
 	kv("Carrier Net Position (carrier offer):", formatCurrency(pmb.TotalCarrierEstimate))
 	kv("Delta (underpayment):", formatCurrency(pmb.TotalDelta))
+	if est := data.Estimate; est != nil {
+		pdf.Ln(2)
+		for _, section := range est.Sections {
+			kv("   "+section.Category+":", fmt.Sprintf("%s (%d items)", formatCurrency(section.Subtotal), section.LineCount))
+		}
+		kv("   Overhead & Profit:", formatCurrency(est.OverheadProfit))
+		if est.Tax > 0 {
+			kv("   Sales Tax:", formatCurrency(est.Tax))
+		}
+		kv("   ClaimCoach Estimate Total:", formatCurrency(est.Total))
+	}
 	pdf.Ln(4)
 
 	// ── Section 4: Key Dispute Issues & Drivers ───────────────────────────────
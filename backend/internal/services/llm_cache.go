@@ -0,0 +1,171 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/claimcoach/backend/internal/llm"
+	"github.com/google/uuid"
+)
+
+// Endpoints recorded in api_usage_logs
+const (
+	llmEndpointChat     = "chat/completions"
+	llmEndpointParsePDF = "pdf/parse"
+)
+
+// llmCacheSweepInterval is how often each instance deletes expired entries
+const llmCacheSweepInterval = time.Hour
+
+// LLMCache is a content-addressed cache of LLM responses in the
+// llm_response_cache table. Entries are keyed on the provider, model, prompt
+// version and a hash of the normalized inputs, and expire after the TTL.
+// Callers store a response only once it has passed validation, so a bad
+// response is never replayed. A nil cache never hits, and cache errors are
+// logged rather than failing the request.
+type LLMCache struct {
+	db       *sql.DB
+	provider string
+	model    string
+	ttl      time.Duration
+
+	mu          sync.Mutex
+	lastCleanup time.Time
+}
+
+// NewLLMCache creates a cache for responses from one provider and model
+func NewLLMCache(db *sql.DB, provider, model string, ttl time.Duration) *LLMCache {
+	return &LLMCache{db: db, provider: provider, model: model, ttl: ttl}
+}
+
+// chatInputHash hashes a chat request. Message text is normalized so
+// whitespace-only differences in a rendered prompt still hit.
+func chatInputHash(messages []llm.Message, temperature float64, maxTokens int) string {
+	normalized := make([]llm.Message, len(messages))
+	for i, m := range messages {
+		normalized[i] = llm.Message{Role: m.Role, Content: normalizePromptText(m.Content)}
+	}
+	body, _ := json.Marshal(struct {
+		Messages    []llm.Message `json:"messages"`
+		Temperature float64       `json:"temperature"`
+		MaxTokens   int           `json:"max_tokens"`
+	}{normalized, temperature, maxTokens})
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
+
+// pdfInputHash hashes a PDF parsing request: the document bytes and the prompt
+// sent with them
+func pdfInputHash(pdfContent []byte, prompt string, maxTokens int) string {
+	document := sha256.Sum256(pdfContent)
+	body, _ := json.Marshal(struct {
+		Document  string `json:"document"`
+		Prompt    string `json:"prompt"`
+		MaxTokens int    `json:"max_tokens"`
+	}{hex.EncodeToString(document[:]), normalizePromptText(prompt), maxTokens})
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
+
+// normalizePromptText unifies line endings and drops trailing spaces on each
+// line and around the text
+func normalizePromptText(s string) string {
+	lines := strings.Split(strings.ReplaceAll(s, "\r\n", "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t")
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+// key combines the request's identity into the cache key
+func (c *LLMCache) key(promptVersion, inputHash string) string {
+	sum := sha256.Sum256([]byte(strings.Join([]string{c.provider, c.model, promptVersion, inputHash}, "\x00")))
+	return hex.EncodeToString(sum[:])
+}
+
+// Get returns the cached response to a request, recording the hit in the
+// organization's usage log at zero cost
+func (c *LLMCache) Get(ctx context.Context, orgID, endpoint, promptVersion, inputHash string) (string, bool) {
+	if c == nil {
+		return "", false
+	}
+
+	var response string
+	err := c.db.QueryRowContext(ctx, `
+		UPDATE llm_response_cache
+		SET hit_count = hit_count + 1, last_hit_at = NOW()
+		WHERE cache_key = $1 AND expires_at > NOW()
+		RETURNING response`,
+		c.key(promptVersion, inputHash),
+	).Scan(&response)
+	if err == sql.ErrNoRows {
+		return "", false
+	}
+	if err != nil {
+		log.Printf("Warning: failed to read LLM response cache: %v", err)
+		return "", false
+	}
+
+	_, err = c.db.ExecContext(ctx, `
+		INSERT INTO api_usage_logs (
+			id, organization_id, model, endpoint,
+			prompt_tokens, completion_tokens, total_tokens,
+			estimated_cost, cache_hit, created_at
+		)
+		VALUES ($1, $2, $3, $4, 0, 0, 0, 0, true, $5)`,
+		uuid.New().String(), nullIfEmpty(orgID), c.model, endpoint, time.Now(),
+	)
+	if err != nil {
+		log.Printf("Warning: failed to log LLM cache hit: %v", err)
+	}
+
+	return response, true
+}
+
+// Put stores a response, replacing any earlier entry for the same request
+func (c *LLMCache) Put(ctx context.Context, promptVersion, inputHash, response string) {
+	if c == nil {
+		return
+	}
+	c.cleanup(ctx)
+
+	now := time.Now()
+	_, err := c.db.ExecContext(ctx, `
+		INSERT INTO llm_response_cache (
+			cache_key, provider, model, prompt_version, input_hash, response, created_at, expires_at
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (cache_key) DO UPDATE SET
+			response = EXCLUDED.response,
+			hit_count = 0,
+			last_hit_at = NULL,
+			created_at = EXCLUDED.created_at,
+			expires_at = EXCLUDED.expires_at`,
+		c.key(promptVersion, inputHash), c.provider, c.model, promptVersion, inputHash, response, now, now.Add(c.ttl),
+	)
+	if err != nil {
+		log.Printf("Warning: failed to write LLM response cache: %v", err)
+	}
+}
+
+// cleanup deletes expired entries at most once per llmCacheSweepInterval per instance
+func (c *LLMCache) cleanup(ctx context.Context) {
+	c.mu.Lock()
+	if time.Since(c.lastCleanup) < llmCacheSweepInterval {
+		c.mu.Unlock()
+		return
+	}
+	c.lastCleanup = time.Now()
+	c.mu.Unlock()
+
+	if _, err := c.db.ExecContext(ctx, `DELETE FROM llm_response_cache WHERE expires_at <= NOW()`); err != nil {
+		log.Printf("Warning: failed to clean up LLM response cache: %v", err)
+	}
+}
@@ -0,0 +1,122 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/claimcoach/backend/internal/llm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChatInputHash(t *testing.T) {
+	messages := []llm.Message{
+		{Role: "system", Content: "You are an estimator."},
+		{Role: "user", Content: "Map these items:\n- Roof  \n"},
+	}
+	hash := chatInputHash(messages, 0.2, 8000)
+
+	// Line endings and trailing whitespace don't change the request
+	assert.Equal(t, hash, chatInputHash([]llm.Message{
+		{Role: "system", Content: "You are an estimator.\r\n"},
+		{Role: "user", Content: "Map these items:\r\n- Roof"},
+	}, 0.2, 8000))
+
+	assert.NotEqual(t, hash, chatInputHash(messages, 0.3, 8000))
+	assert.NotEqual(t, hash, chatInputHash(messages, 0.2, 4000))
+	assert.NotEqual(t, hash, chatInputHash([]llm.Message{messages[1]}, 0.2, 8000))
+}
+
+func TestLLMCacheKey(t *testing.T) {
+	cache := NewLLMCache(nil, "anthropic", "model-a", time.Hour)
+	key := cache.key("estimate/v1", "abc")
+
+	assert.NotEqual(t, key, cache.key("estimate/v2", "abc"))
+	assert.NotEqual(t, key, NewLLMCache(nil, "anthropic", "model-b", time.Hour).key("estimate/v1", "abc"))
+	assert.NotEqual(t, key, NewLLMCache(nil, "other", "model-a", time.Hour).key("estimate/v1", "abc"))
+}
+
+func TestLLMCacheGet(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+	cache := NewLLMCache(db, "anthropic", "model-a", time.Hour)
+
+	t.Run("hit is logged at zero cost", func(t *testing.T) {
+		mock.ExpectQuery(`UPDATE llm_response_cache`).
+			WithArgs(cache.key("estimate/v1", "abc")).
+			WillReturnRows(sqlmock.NewRows([]string{"response"}).AddRow(`{"line_items":[]}`))
+		mock.ExpectExec(`INSERT INTO api_usage_logs`).
+			WithArgs(sqlmock.AnyArg(), "org-1", "model-a", llmEndpointChat, sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		response, ok := cache.Get(context.Background(), "org-1", llmEndpointChat, "estimate/v1", "abc")
+		assert.True(t, ok)
+		assert.Equal(t, `{"line_items":[]}`, response)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("miss", func(t *testing.T) {
+		mock.ExpectQuery(`UPDATE llm_response_cache`).
+			WillReturnRows(sqlmock.NewRows([]string{"response"}))
+
+		_, ok := cache.Get(context.Background(), "org-1", llmEndpointChat, "estimate/v1", "def")
+		assert.False(t, ok)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("nil cache never hits", func(t *testing.T) {
+		var none *LLMCache
+		_, ok := none.Get(context.Background(), "org-1", llmEndpointChat, "estimate/v1", "abc")
+		assert.False(t, ok)
+		none.Put(context.Background(), "estimate/v1", "abc", "response")
+	})
+}
+
+func TestParsePDFWithClaude_Cache(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	pdfContent := estimatePDF(t, 1)
+	response := `{"line_items": [{"description": "Ridge cap", "quantity": 60, "unit": "LF", "unit_cost": 4.5, "total": 270, "category": "Roofing"}], "total": 270}`
+	calls := 0
+	service := &PDFParserService{
+		db: db,
+		pdfClient: &MockPDFParserClient{
+			ParsePDFFunc: func(ctx context.Context, pdf []byte, prompt string, maxTokens int) (string, error) {
+				calls++
+				return response, nil
+			},
+		},
+		cache: NewLLMCache(db, "anthropic", "model-a", time.Hour),
+	}
+
+	t.Run("hit skips the LLM", func(t *testing.T) {
+		mock.ExpectQuery(`UPDATE llm_response_cache`).
+			WillReturnRows(sqlmock.NewRows([]string{"response"}).AddRow(response))
+		mock.ExpectExec(`INSERT INTO api_usage_logs`).WillReturnResult(sqlmock.NewResult(0, 1))
+
+		result, err := service.parsePDFWithClaude(context.Background(), pdfContent, "org-1", false)
+		require.NoError(t, err)
+		assert.Equal(t, "carrier_estimate_parse/v2", result.PromptVersion)
+		assert.Len(t, result.Data.LineItems, 1)
+		assert.Zero(t, calls)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("force calls the LLM and refreshes the entry", func(t *testing.T) {
+		mock.ExpectExec(`DELETE FROM llm_response_cache`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(`INSERT INTO llm_response_cache`).
+			WithArgs(sqlmock.AnyArg(), "anthropic", "model-a", "carrier_estimate_parse/v2", sqlmock.AnyArg(), response, sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		result, err := service.parsePDFWithClaude(context.Background(), pdfContent, "org-1", true)
+		require.NoError(t, err)
+		assert.Equal(t, 270.0, result.Data.Total)
+		assert.Equal(t, 1, calls)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,39 @@
+package services
+
+import (
+	"context"
+
+	"github.com/claimcoach/backend/internal/llm"
+)
+
+// StreamingLLMClient is an LLMClient that can also stream a chat response as
+// it is generated, like llm.ClaudeClient
+type StreamingLLMClient interface {
+	LLMClient
+	ChatStream(ctx context.Context, messages []llm.Message, temperature float64, maxTokens int, onDelta func(text string) error) (*llm.ChatResponse, error)
+}
+
+// chatWithDeltas sends a chat request, passing the response text to onDelta
+// as it arrives. Clients that cannot stream deliver the whole text in a single
+// delta, and a nil onDelta makes a plain Chat call. The returned response is
+// the same either way, so callers persist it exactly as they would without
+// streaming.
+func chatWithDeltas(ctx context.Context, client LLMClient, messages []llm.Message, temperature float64, maxTokens int, onDelta func(text string) error) (*llm.ChatResponse, error) {
+	if onDelta == nil {
+		return client.Chat(ctx, messages, temperature, maxTokens)
+	}
+	if streaming, ok := client.(StreamingLLMClient); ok {
+		return streaming.ChatStream(ctx, messages, temperature, maxTokens, onDelta)
+	}
+
+	response, err := client.Chat(ctx, messages, temperature, maxTokens)
+	if err != nil {
+		return nil, err
+	}
+	if len(response.Choices) > 0 {
+		if err := onDelta(response.Choices[0].Message.Content); err != nil {
+			return nil, err
+		}
+	}
+	return response, nil
+}
@@ -0,0 +1,63 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/claimcoach/backend/internal/llm"
+	"github.com/claimcoach/backend/internal/prompts"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// streamingStubLLM streams its response in fixed pieces
+type streamingStubLLM struct {
+	prompts.StubLLM
+	pieces []string
+}
+
+func (s *streamingStubLLM) ChatStream(ctx context.Context, messages []llm.Message, temperature float64, maxTokens int, onDelta func(text string) error) (*llm.ChatResponse, error) {
+	for _, piece := range s.pieces {
+		if err := onDelta(piece); err != nil {
+			return nil, err
+		}
+	}
+	return s.Chat(ctx, messages, temperature, maxTokens)
+}
+
+func TestChatWithDeltas_Streams(t *testing.T) {
+	client := &streamingStubLLM{StubLLM: prompts.StubLLM{Response: "Dear Adjuster"}, pieces: []string{"Dear ", "Adjuster"}}
+
+	var deltas []string
+	response, err := chatWithDeltas(context.Background(), client, nil, 0.3, 100, func(text string) error {
+		deltas = append(deltas, text)
+		return nil
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Dear ", "Adjuster"}, deltas)
+	assert.Equal(t, "Dear Adjuster", response.Choices[0].Message.Content)
+}
+
+func TestChatWithDeltas_NonStreamingClientSendsOneDelta(t *testing.T) {
+	client := &prompts.StubLLM{Response: "Dear Adjuster"}
+
+	var deltas []string
+	response, err := chatWithDeltas(context.Background(), client, nil, 0.3, 100, func(text string) error {
+		deltas = append(deltas, text)
+		return nil
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Dear Adjuster"}, deltas)
+	assert.Equal(t, "Dear Adjuster", response.Choices[0].Message.Content)
+}
+
+func TestChatWithDeltas_NilCallbackDoesNotStream(t *testing.T) {
+	client := &streamingStubLLM{StubLLM: prompts.StubLLM{Response: "Dear Adjuster"}, pieces: []string{"unexpected"}}
+
+	response, err := chatWithDeltas(context.Background(), client, nil, 0.3, 100, nil)
+
+	require.NoError(t, err)
+	assert.Equal(t, "Dear Adjuster", response.Choices[0].Message.Content)
+}
@@ -0,0 +1,520 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/claimcoach/backend/internal/config"
+	"github.com/claimcoach/backend/internal/models"
+)
+
+// contractorRespondedSQL is true once the contractor has uploaded a document or
+// submitted a scope sheet through the link
+const contractorRespondedSQL = `(
+	EXISTS (SELECT 1 FROM documents d
+		WHERE d.magic_link_id = ml.id AND d.status = 'confirmed')
+	OR EXISTS (SELECT 1 FROM scope_sheets ss
+		WHERE ss.magic_link_id = ml.id AND ss.is_draft = false
+		  AND ss.submitted_at IS NOT NULL)
+)`
+
+// maxReminderAttempts bounds the sends tried for one reminder. A failed send is
+// retried by the following sweeps until it succeeds or runs out of attempts.
+const maxReminderAttempts = 3
+
+// reminderSettledSQL is true for a magic_link_reminders row r that was sent,
+// or that failed maxReminderAttempts times and is given up on
+var reminderSettledSQL = fmt.Sprintf(`(r.email_error IS NULL OR r.attempts >= %d)`, maxReminderAttempts)
+
+// reminderLinkColumns are selected for every link the sweep acts on, in reminderLink field order
+const reminderLinkColumns = `
+	ml.id, ml.claim_id, ml.token, ml.contractor_name, ml.contractor_email, ml.contractor_phone,
+	ml.delivery_channel, ml.expires_at, c.loss_type, p.nickname, p.legal_address
+`
+
+type MagicLinkReminderService struct {
+	db           *sql.DB
+	cfg          *config.Config
+	emailService EmailService
+	smsService   SMSService
+	claimService *ClaimService
+}
+
+func NewMagicLinkReminderService(db *sql.DB, cfg *config.Config, emailService EmailService, smsService SMSService, claimService *ClaimService) *MagicLinkReminderService {
+	return &MagicLinkReminderService{
+		db:           db,
+		cfg:          cfg,
+		emailService: emailService,
+		smsService:   smsService,
+		claimService: claimService,
+	}
+}
+
+// ReminderSweepResult counts what a single sweep did
+type ReminderSweepResult struct {
+	NeverOpened       int `json:"never_opened"`
+	DraftNotSubmitted int `json:"draft_not_submitted"`
+	ExpiringSoon      int `json:"expiring_soon"`
+	Expired           int `json:"expired"`
+	PMNotified        int `json:"pm_notified"`
+	Failed            int `json:"failed"`
+}
+
+// reminderLink is an active magic link with the claim and property details the reminders need
+type reminderLink struct {
+	ID              string
+	ClaimID         string
+	Token           string
+	ContractorName  string
+	ContractorEmail string
+	ContractorPhone *string
+	DeliveryChannel string
+	ExpiresAt       time.Time
+	LossType        string
+	PropertyName    string
+	PropertyAddress string
+}
+
+// RunSweep sends due contractor reminders, then expires lapsed links and tells
+// the creating PM about any that went unused. Each reminder kind is sent at most
+// once per link, so overlapping or repeated sweeps are safe; a reminder whose
+// send failed is tried again by the next sweep.
+func (s *MagicLinkReminderService) RunSweep(ctx context.Context) (*ReminderSweepResult, error) {
+	result := &ReminderSweepResult{}
+
+	// Never opened: sent N hours ago and the contractor hasn't clicked it
+	if hours := s.cfg.MagicLinkReminderNeverOpenedHours; hours > 0 {
+		query := `
+			SELECT ` + reminderLinkColumns + `
+			FROM magic_links ml
+			JOIN claims c ON c.id = ml.claim_id
+			JOIN properties p ON p.id = c.property_id
+			WHERE ml.status = 'active' AND ml.expires_at > NOW()
+			  AND ml.access_count = 0
+			  AND ml.created_at <= NOW() - make_interval(hours => $1)
+			  AND NOT EXISTS (SELECT 1 FROM magic_link_reminders r
+				WHERE r.magic_link_id = ml.id AND r.kind = 'never_opened' AND ` + reminderSettledSQL + `)
+		`
+		sent, err := s.remindContractors(ctx, models.MagicLinkReminderNeverOpened, query, hours, result)
+		if err != nil {
+			return nil, err
+		}
+		result.NeverOpened = sent
+	}
+
+	// Draft not submitted: a scope sheet draft was saved through the link (or
+	// reopened by a PM change request) but has sat untouched for N hours without
+	// a final submission
+	if hours := s.cfg.MagicLinkReminderDraftHours; hours > 0 {
+		query := `
+			SELECT ` + reminderLinkColumns + `
+			FROM magic_links ml
+			JOIN claims c ON c.id = ml.claim_id
+			JOIN properties p ON p.id = c.property_id
+			JOIN scope_sheets draft ON draft.claim_id = ml.claim_id AND draft.is_draft = true
+			WHERE ml.status = 'active' AND ml.expires_at > NOW()
+			  AND ml.scope IN ('scope_sheet', 'full')
+			  AND draft.draft_saved_at >= ml.created_at
+			  AND draft.draft_saved_at <= NOW() - make_interval(hours => $1)
+			  AND NOT EXISTS (SELECT 1 FROM scope_sheets ss
+				WHERE ss.claim_id = ml.claim_id AND ss.is_draft = false
+				  AND ss.submitted_at >= draft.created_at)
+			  AND NOT EXISTS (SELECT 1 FROM magic_link_reminders r
+				WHERE r.magic_link_id = ml.id AND r.kind = 'draft_not_submitted' AND ` + reminderSettledSQL + `)
+		`
+		sent, err := s.remindContractors(ctx, models.MagicLinkReminderDraftNotSubmitted, query, hours, result)
+		if err != nil {
+			return nil, err
+		}
+		result.DraftNotSubmitted = sent
+	}
+
+	// Expiring soon: within N hours of expiry with no response yet. Links whose
+	// whole lifetime is shorter than the window are skipped, so a short-lived
+	// link doesn't get a reminder the moment it is sent.
+	if hours := s.cfg.MagicLinkReminderExpiringHours; hours > 0 {
+		query := `
+			SELECT ` + reminderLinkColumns + `
+			FROM magic_links ml
+			JOIN claims c ON c.id = ml.claim_id
+			JOIN properties p ON p.id = c.property_id
+			WHERE ml.status = 'active' AND ml.expires_at > NOW()
+			  AND ml.expires_at <= NOW() + make_interval(hours => $1)
+			  AND ml.created_at <= NOW() - make_interval(hours => $1)
+			  AND NOT ` + contractorRespondedSQL + `
+			  AND NOT EXISTS (SELECT 1 FROM magic_link_reminders r
+				WHERE r.magic_link_id = ml.id AND r.kind = 'expiring_soon' AND ` + reminderSettledSQL + `)
+		`
+		sent, err := s.remindContractors(ctx, models.MagicLinkReminderExpiringSoon, query, hours, result)
+		if err != nil {
+			return nil, err
+		}
+		result.ExpiringSoon = sent
+	}
+
+	if err := s.retryExpiryNotices(ctx, result); err != nil {
+		return nil, err
+	}
+	if err := s.expireLinks(ctx, result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// remindContractors reminds the contractor on every link the query returns,
+// over the channels the link was delivered on, and reports how many reminders
+// went out; failures are counted on result
+func (s *MagicLinkReminderService) remindContractors(ctx context.Context, kind string, query string, hours int, result *ReminderSweepResult) (int, error) {
+	links, err := s.queryReminderLinks(ctx, query, hours)
+	if err != nil {
+		return 0, fmt.Errorf("failed to find %s reminders: %w", kind, err)
+	}
+
+	sent := 0
+	for _, link := range links {
+		claimed, err := s.claimReminder(ctx, link.ID, kind, link.ContractorEmail)
+		if err != nil {
+			fmt.Printf("Warning: failed to record %s reminder for magic link %s: %v\n", kind, link.ID, err)
+			result.Failed++
+			continue
+		}
+		if !claimed {
+			// Another sweep got here first
+			continue
+		}
+
+		err = s.sendReminder(kind, link)
+		if err != nil {
+			fmt.Printf("Warning: failed to send %s reminder for magic link %s: %v\n", kind, link.ID, err)
+			s.recordReminderError(ctx, link.ID, kind, err)
+			result.Failed++
+			continue
+		}
+		sent++
+
+		description := fmt.Sprintf("Reminder sent to contractor: %s (%s)", link.ContractorName, contractorContact(link.ContractorEmail, link.ContractorPhone))
+		s.logActivity(link.ClaimID, "magic_link_reminder_sent", description, map[string]interface{}{
+			"magic_link_id":    link.ID,
+			"contractor_email": link.ContractorEmail,
+			"delivery_channel": link.DeliveryChannel,
+			"kind":             kind,
+		})
+	}
+
+	return sent, nil
+}
+
+// sendReminder sends a reminder by email, SMS or both, as the link itself was
+// sent. It fails only when no channel delivered it, so a reminder that reached
+// the contractor one way isn't retried and sent again the other way.
+func (s *MagicLinkReminderService) sendReminder(kind string, link reminderLink) error {
+	linkURL := fmt.Sprintf("%s/upload/%s", s.cfg.FrontendURL, link.Token)
+
+	var errs []error
+	if link.DeliveryChannel != models.MagicLinkChannelSMS {
+		err := s.emailService.SendMagicLinkReminderEmail(SendMagicLinkReminderEmailInput{
+			To:              link.ContractorEmail,
+			Kind:            kind,
+			ContractorName:  link.ContractorName,
+			PropertyName:    link.PropertyName,
+			PropertyAddress: link.PropertyAddress,
+			LossType:        link.LossType,
+			MagicLinkURL:    linkURL,
+			ExpiresAt:       link.ExpiresAt,
+		})
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if link.DeliveryChannel != models.MagicLinkChannelEmail {
+		if err := s.sendReminderSMS(kind, link, linkURL); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	switch {
+	case len(errs) == 0:
+		return nil
+	case link.DeliveryChannel == models.MagicLinkChannelBoth && len(errs) == 1:
+		fmt.Printf("Warning: %s reminder for magic link %s went out one way only: %v\n", kind, link.ID, errs[0])
+		return nil
+	}
+	return errors.Join(errs...)
+}
+
+// sendReminderSMS texts a reminder unless the number has opted out
+func (s *MagicLinkReminderService) sendReminderSMS(kind string, link reminderLink, linkURL string) error {
+	if link.ContractorPhone == nil || *link.ContractorPhone == "" {
+		return fmt.Errorf("magic link has no phone number")
+	}
+	optedOut, err := isPhoneOptedOut(s.db, *link.ContractorPhone)
+	if err != nil {
+		return err
+	}
+	if optedOut {
+		return fmt.Errorf("recipient has opted out of SMS")
+	}
+	_, err = s.smsService.SendMagicLinkSMS(SendMagicLinkSMSInput{
+		To:             *link.ContractorPhone,
+		Kind:           kind,
+		ContractorName: link.ContractorName,
+		PropertyName:   link.PropertyName,
+		MagicLinkURL:   linkURL,
+		ExpiresAt:      link.ExpiresAt,
+	})
+	return err
+}
+
+// expireLinks marks lapsed active links as expired and notifies the creating PM
+// about each one the contractor never responded to
+func (s *MagicLinkReminderService) expireLinks(ctx context.Context, result *ReminderSweepResult) error {
+	query := `
+		SELECT ` + reminderLinkColumns + `,
+			` + contractorRespondedSQL + `,
+			u.email, u.name
+		FROM magic_links ml
+		JOIN claims c ON c.id = ml.claim_id
+		JOIN properties p ON p.id = c.property_id
+		LEFT JOIN users u ON u.id = ml.created_by_user_id
+		WHERE ml.status = 'active' AND ml.expires_at <= NOW()
+	`
+
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return fmt.Errorf("failed to find expired magic links: %w", err)
+	}
+
+	type expiredLink struct {
+		reminderLink
+		Responded bool
+		PMEmail   *string
+		PMName    *string
+	}
+	var links []expiredLink
+	for rows.Next() {
+		var link expiredLink
+		if err := rows.Scan(
+			&link.ID, &link.ClaimID, &link.Token, &link.ContractorName, &link.ContractorEmail, &link.ContractorPhone,
+			&link.DeliveryChannel, &link.ExpiresAt, &link.LossType, &link.PropertyName, &link.PropertyAddress,
+			&link.Responded, &link.PMEmail, &link.PMName,
+		); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan expired magic link: %w", err)
+		}
+		links = append(links, link)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read expired magic links: %w", err)
+	}
+
+	for _, link := range links {
+		res, err := s.db.ExecContext(ctx, `UPDATE magic_links SET status = 'expired' WHERE id = $1 AND status = 'active'`, link.ID)
+		if err != nil {
+			fmt.Printf("Warning: failed to expire magic link %s: %v\n", link.ID, err)
+			result.Failed++
+			continue
+		}
+		if affected, _ := res.RowsAffected(); affected == 0 {
+			// Revoked, locked or expired by another sweep in the meantime
+			continue
+		}
+		result.Expired++
+
+		if link.Responded {
+			continue
+		}
+
+		description := fmt.Sprintf("Magic link expired unused for contractor: %s (%s)", link.ContractorName, contractorContact(link.ContractorEmail, link.ContractorPhone))
+		s.logActivity(link.ClaimID, "magic_link_expired", description, map[string]interface{}{
+			"magic_link_id":    link.ID,
+			"contractor_email": link.ContractorEmail,
+		})
+
+		s.notifyExpiredUnused(ctx, link.reminderLink, link.PMEmail, link.PMName, result)
+	}
+
+	return nil
+}
+
+// retryExpiryNotices resends the expiry notices whose email failed in an
+// earlier sweep. Their links are already expired, so expireLinks won't find
+// them again.
+func (s *MagicLinkReminderService) retryExpiryNotices(ctx context.Context, result *ReminderSweepResult) error {
+	query := `
+		SELECT ` + reminderLinkColumns + `, u.email, u.name
+		FROM magic_links ml
+		JOIN claims c ON c.id = ml.claim_id
+		JOIN properties p ON p.id = c.property_id
+		JOIN magic_link_reminders r ON r.magic_link_id = ml.id AND r.kind = 'expired_unused'
+		LEFT JOIN users u ON u.id = ml.created_by_user_id
+		WHERE ml.status = 'expired' AND NOT ` + reminderSettledSQL + `
+	`
+
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return fmt.Errorf("failed to find failed expiry notices: %w", err)
+	}
+
+	type failedNotice struct {
+		reminderLink
+		PMEmail *string
+		PMName  *string
+	}
+	var notices []failedNotice
+	for rows.Next() {
+		var n failedNotice
+		if err := rows.Scan(
+			&n.ID, &n.ClaimID, &n.Token, &n.ContractorName, &n.ContractorEmail, &n.ContractorPhone,
+			&n.DeliveryChannel, &n.ExpiresAt, &n.LossType, &n.PropertyName, &n.PropertyAddress,
+			&n.PMEmail, &n.PMName,
+		); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan failed expiry notice: %w", err)
+		}
+		notices = append(notices, n)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read failed expiry notices: %w", err)
+	}
+
+	for _, n := range notices {
+		s.notifyExpiredUnused(ctx, n.reminderLink, n.PMEmail, n.PMName, result)
+	}
+	return nil
+}
+
+// notifyExpiredUnused emails the creating PM that a link expired without a
+// response, with a link to reissue it; failures are counted on result
+func (s *MagicLinkReminderService) notifyExpiredUnused(ctx context.Context, link reminderLink, pmEmail, pmName *string, result *ReminderSweepResult) {
+	if pmEmail == nil {
+		return
+	}
+
+	claimed, err := s.claimReminder(ctx, link.ID, models.MagicLinkReminderExpiredUnused, *pmEmail)
+	if err != nil {
+		fmt.Printf("Warning: failed to record expiry notice for magic link %s: %v\n", link.ID, err)
+		result.Failed++
+		return
+	}
+	if !claimed {
+		return
+	}
+
+	name := ""
+	if pmName != nil {
+		name = *pmName
+	}
+	err = s.emailService.SendMagicLinkExpiredEmail(SendMagicLinkExpiredEmailInput{
+		To:              *pmEmail,
+		PMName:          name,
+		ContractorName:  link.ContractorName,
+		ContractorEmail: link.ContractorEmail,
+		PropertyName:    link.PropertyName,
+		PropertyAddress: link.PropertyAddress,
+		ExpiredAt:       link.ExpiresAt,
+		ReissueURL:      fmt.Sprintf("%s/claims/%s?reissue_magic_link=%s", s.cfg.FrontendURL, link.ClaimID, link.ID),
+	})
+	if err != nil {
+		fmt.Printf("Warning: failed to send expiry notice for magic link %s: %v\n", link.ID, err)
+		s.recordReminderError(ctx, link.ID, models.MagicLinkReminderExpiredUnused, err)
+		result.Failed++
+		return
+	}
+	result.PMNotified++
+}
+
+func (s *MagicLinkReminderService) queryReminderLinks(ctx context.Context, query string, args ...interface{}) ([]reminderLink, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var links []reminderLink
+	for rows.Next() {
+		var link reminderLink
+		if err := rows.Scan(
+			&link.ID, &link.ClaimID, &link.Token, &link.ContractorName, &link.ContractorEmail, &link.ContractorPhone,
+			&link.DeliveryChannel, &link.ExpiresAt, &link.LossType, &link.PropertyName, &link.PropertyAddress,
+		); err != nil {
+			return nil, err
+		}
+		links = append(links, link)
+	}
+	return links, rows.Err()
+}
+
+// claimReminder records that a reminder is being sent. It reports false when
+// the reminder was already sent for this link, e.g. by a concurrent sweep, or
+// its sends failed maxReminderAttempts times. A reminder whose last send
+// failed is claimed again for another attempt.
+func (s *MagicLinkReminderService) claimReminder(ctx context.Context, magicLinkID string, kind string, recipient string) (bool, error) {
+	query := `
+		INSERT INTO magic_link_reminders (magic_link_id, kind, recipient_email)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (magic_link_id, kind) DO UPDATE
+		SET recipient_email = EXCLUDED.recipient_email,
+			sent_at = NOW(),
+			email_error = NULL,
+			attempts = magic_link_reminders.attempts + 1
+		WHERE magic_link_reminders.email_error IS NOT NULL
+		  AND magic_link_reminders.attempts < $4
+		RETURNING id
+	`
+
+	var id string
+	err := s.db.QueryRowContext(ctx, query, magicLinkID, kind, recipient, maxReminderAttempts).Scan(&id)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (s *MagicLinkReminderService) recordReminderError(ctx context.Context, magicLinkID string, kind string, sendErr error) {
+	query := `UPDATE magic_link_reminders SET email_error = $1 WHERE magic_link_id = $2 AND kind = $3`
+	if _, err := s.db.ExecContext(ctx, query, sendErr.Error(), magicLinkID, kind); err != nil {
+		fmt.Printf("Warning: failed to record reminder error: %v\n", err)
+	}
+}
+
+func (s *MagicLinkReminderService) logActivity(claimID string, activityType string, description string, metadata map[string]interface{}) {
+	metadataJSON, _ := json.Marshal(metadata)
+	metadataStr := string(metadataJSON)
+
+	if err := s.claimService.createActivity(claimID, nil, activityType, description, &metadataStr); err != nil {
+		// Don't fail the sweep if activity logging fails
+		fmt.Printf("Warning: failed to log activity: %v\n", err)
+	}
+}
+
+// Start runs the sweep every interval until ctx is cancelled
+func (s *MagicLinkReminderService) Start(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			result, err := s.RunSweep(ctx)
+			if err != nil {
+				log.Printf("Warning: magic link reminder sweep failed: %v", err)
+				continue
+			}
+			log.Printf("Magic link reminder sweep: %d never opened, %d drafts, %d expiring, %d expired, %d PMs notified, %d failed",
+				result.NeverOpened, result.DraftNotSubmitted, result.ExpiringSoon, result.Expired, result.PMNotified, result.Failed)
+		}
+	}
+}
@@ -0,0 +1,230 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/claimcoach/backend/internal/config"
+	"github.com/claimcoach/backend/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingEmailService captures reminder emails instead of logging them,
+// failing every send when err is set
+type recordingEmailService struct {
+	MockEmailService
+	reminders []SendMagicLinkReminderEmailInput
+	expired   []SendMagicLinkExpiredEmailInput
+	err       error
+}
+
+func (s *recordingEmailService) SendMagicLinkReminderEmail(input SendMagicLinkReminderEmailInput) error {
+	s.reminders = append(s.reminders, input)
+	return s.err
+}
+
+func (s *recordingEmailService) SendMagicLinkExpiredEmail(input SendMagicLinkExpiredEmailInput) error {
+	s.expired = append(s.expired, input)
+	return s.err
+}
+
+// recordingSMSService captures texted reminders
+type recordingSMSService struct {
+	MockSMSService
+	sent []SendMagicLinkSMSInput
+}
+
+func (s *recordingSMSService) SendMagicLinkSMS(input SendMagicLinkSMSInput) (*SMSSendResult, error) {
+	s.sent = append(s.sent, input)
+	return &SMSSendResult{MessageID: "SM123", Status: "queued"}, nil
+}
+
+var reminderLinkRowColumns = []string{
+	"id", "claim_id", "token", "contractor_name", "contractor_email", "contractor_phone",
+	"delivery_channel", "expires_at", "loss_type", "nickname", "legal_address",
+}
+
+func TestMagicLinkReminderService_RunSweep_NeverOpened(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	emails := &recordingEmailService{}
+	cfg := &config.Config{FrontendURL: "https://app.test", MagicLinkReminderNeverOpenedHours: 24}
+	service := NewMagicLinkReminderService(db, cfg, emails, NewMockSMSService(), &ClaimService{db: db})
+
+	expiresAt := time.Now().Add(48 * time.Hour)
+	mock.ExpectQuery(`ml.access_count = 0`).
+		WithArgs(24).
+		WillReturnRows(sqlmock.NewRows(reminderLinkRowColumns).
+			AddRow("link-1", "claim-1", "token-1", "Roofer", "roofer@example.com", nil, models.MagicLinkChannelEmail, expiresAt, "wind", "Oak Apartments", "1 Main St").
+			AddRow("link-2", "claim-2", "token-2", "Plumber", "plumber@example.com", nil, models.MagicLinkChannelEmail, expiresAt, "water", "Elm Court", "2 Elm St"))
+	mock.ExpectQuery(`INSERT INTO magic_link_reminders`).
+		WithArgs("link-1", models.MagicLinkReminderNeverOpened, "roofer@example.com", maxReminderAttempts).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("reminder-1"))
+	mock.ExpectExec(`INSERT INTO claim_activities`).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	// link-2 was reminded by a concurrent sweep
+	mock.ExpectQuery(`INSERT INTO magic_link_reminders`).
+		WithArgs("link-2", models.MagicLinkReminderNeverOpened, "plumber@example.com", maxReminderAttempts).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectQuery(`r.kind = 'expired_unused'`).
+		WillReturnRows(sqlmock.NewRows(append(reminderLinkRowColumns, "email", "name")))
+	mock.ExpectQuery(`ml.expires_at <= NOW\(\)`).
+		WillReturnRows(sqlmock.NewRows(append(reminderLinkRowColumns, "responded", "email", "name")))
+
+	result, err := service.RunSweep(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.NeverOpened)
+	assert.Equal(t, 0, result.Failed)
+	require.Len(t, emails.reminders, 1)
+	assert.Equal(t, "roofer@example.com", emails.reminders[0].To)
+	assert.Equal(t, models.MagicLinkReminderNeverOpened, emails.reminders[0].Kind)
+	assert.Equal(t, "https://app.test/upload/token-1", emails.reminders[0].MagicLinkURL)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMagicLinkReminderService_RunSweep_RemindsOverDeliveryChannel(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	emails := &recordingEmailService{}
+	texts := &recordingSMSService{}
+	cfg := &config.Config{FrontendURL: "https://app.test", MagicLinkReminderNeverOpenedHours: 24}
+	service := NewMagicLinkReminderService(db, cfg, emails, texts, &ClaimService{db: db})
+
+	expiresAt := time.Now().Add(48 * time.Hour)
+	mock.ExpectQuery(`ml.access_count = 0`).
+		WithArgs(24).
+		WillReturnRows(sqlmock.NewRows(reminderLinkRowColumns).
+			AddRow("link-1", "claim-1", "token-1", "Roofer", "roofer@example.com", "+15125550100", models.MagicLinkChannelSMS, expiresAt, "wind", "Oak Apartments", "1 Main St").
+			AddRow("link-2", "claim-2", "token-2", "Plumber", "plumber@example.com", "+15125550101", models.MagicLinkChannelSMS, expiresAt, "water", "Elm Court", "2 Elm St"))
+	mock.ExpectQuery(`INSERT INTO magic_link_reminders`).
+		WithArgs("link-1", models.MagicLinkReminderNeverOpened, "roofer@example.com", maxReminderAttempts).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("reminder-1"))
+	mock.ExpectQuery(`SELECT EXISTS\(SELECT 1 FROM sms_opt_outs`).
+		WithArgs("+15125550100").
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	mock.ExpectExec(`INSERT INTO claim_activities`).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	// link-2's contractor replied STOP, so the reminder has nowhere to go
+	mock.ExpectQuery(`INSERT INTO magic_link_reminders`).
+		WithArgs("link-2", models.MagicLinkReminderNeverOpened, "plumber@example.com", maxReminderAttempts).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("reminder-2"))
+	mock.ExpectQuery(`SELECT EXISTS\(SELECT 1 FROM sms_opt_outs`).
+		WithArgs("+15125550101").
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	mock.ExpectExec(`UPDATE magic_link_reminders SET email_error`).
+		WithArgs("recipient has opted out of SMS", "link-2", models.MagicLinkReminderNeverOpened).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`r.kind = 'expired_unused'`).
+		WillReturnRows(sqlmock.NewRows(append(reminderLinkRowColumns, "email", "name")))
+	mock.ExpectQuery(`ml.expires_at <= NOW\(\)`).
+		WillReturnRows(sqlmock.NewRows(append(reminderLinkRowColumns, "responded", "email", "name")))
+
+	result, err := service.RunSweep(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.NeverOpened)
+	assert.Equal(t, 1, result.Failed)
+	assert.Empty(t, emails.reminders)
+	require.Len(t, texts.sent, 1)
+	assert.Equal(t, "+15125550100", texts.sent[0].To)
+	assert.Equal(t, models.MagicLinkReminderNeverOpened, texts.sent[0].Kind)
+	assert.Equal(t, "https://app.test/upload/token-1", texts.sent[0].MagicLinkURL)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMagicLinkReminderService_RunSweep_ExpiredUnusedNotifiesPM(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	emails := &recordingEmailService{}
+	cfg := &config.Config{FrontendURL: "https://app.test"}
+	service := NewMagicLinkReminderService(db, cfg, emails, NewMockSMSService(), &ClaimService{db: db})
+
+	expiredAt := time.Now().Add(-time.Hour)
+	mock.ExpectQuery(`r.kind = 'expired_unused'`).
+		WillReturnRows(sqlmock.NewRows(append(reminderLinkRowColumns, "email", "name")))
+	mock.ExpectQuery(`ml.expires_at <= NOW\(\)`).
+		WillReturnRows(sqlmock.NewRows(append(reminderLinkRowColumns, "responded", "email", "name")).
+			AddRow("link-1", "claim-1", "token-1", "Roofer", "roofer@example.com", nil, models.MagicLinkChannelEmail, expiredAt, "wind", "Oak Apartments", "1 Main St", false, "pm@example.com", "Pat").
+			AddRow("link-2", "claim-2", "token-2", "Plumber", "plumber@example.com", nil, models.MagicLinkChannelEmail, expiredAt, "water", "Elm Court", "2 Elm St", true, "pm@example.com", "Pat"))
+	mock.ExpectExec(`UPDATE magic_links SET status = 'expired'`).
+		WithArgs("link-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`INSERT INTO claim_activities`).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`INSERT INTO magic_link_reminders`).
+		WithArgs("link-1", models.MagicLinkReminderExpiredUnused, "pm@example.com", maxReminderAttempts).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("reminder-1"))
+	// link-2 got a response, so it just expires quietly
+	mock.ExpectExec(`UPDATE magic_links SET status = 'expired'`).
+		WithArgs("link-2").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	result, err := service.RunSweep(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.Expired)
+	assert.Equal(t, 1, result.PMNotified)
+	require.Len(t, emails.expired, 1)
+	assert.Equal(t, "pm@example.com", emails.expired[0].To)
+	assert.Equal(t, "https://app.test/claims/claim-1?reissue_magic_link=link-1", emails.expired[0].ReissueURL)
+	assert.Empty(t, emails.reminders)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMagicLinkReminderService_RunSweep_FailedSendIsRetried(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	emails := &recordingEmailService{err: errors.New("sendgrid unavailable")}
+	cfg := &config.Config{FrontendURL: "https://app.test", MagicLinkReminderNeverOpenedHours: 24}
+	service := NewMagicLinkReminderService(db, cfg, emails, NewMockSMSService(), &ClaimService{db: db})
+
+	expiresAt := time.Now().Add(48 * time.Hour)
+	mock.ExpectQuery(`r.attempts >= 3`).
+		WithArgs(24).
+		WillReturnRows(sqlmock.NewRows(reminderLinkRowColumns).
+			AddRow("link-1", "claim-1", "token-1", "Roofer", "roofer@example.com", nil, models.MagicLinkChannelEmail, expiresAt, "wind", "Oak Apartments", "1 Main St"))
+	// The row left by the failed send is claimed again for another attempt
+	mock.ExpectQuery(`ON CONFLICT \(magic_link_id, kind\) DO UPDATE`).
+		WithArgs("link-1", models.MagicLinkReminderNeverOpened, "roofer@example.com", maxReminderAttempts).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("reminder-1"))
+	mock.ExpectExec(`UPDATE magic_link_reminders SET email_error`).
+		WithArgs("sendgrid unavailable", "link-1", models.MagicLinkReminderNeverOpened).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	// An expiry notice that failed in an earlier sweep is sent again
+	expiredAt := time.Now().Add(-time.Hour)
+	mock.ExpectQuery(`r.kind = 'expired_unused'`).
+		WillReturnRows(sqlmock.NewRows(append(reminderLinkRowColumns, "email", "name")).
+			AddRow("link-2", "claim-2", "token-2", "Plumber", "plumber@example.com", nil, models.MagicLinkChannelEmail, expiredAt, "water", "Elm Court", "2 Elm St", "pm@example.com", "Pat"))
+	mock.ExpectQuery(`INSERT INTO magic_link_reminders`).
+		WithArgs("link-2", models.MagicLinkReminderExpiredUnused, "pm@example.com", maxReminderAttempts).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("reminder-2"))
+	mock.ExpectExec(`UPDATE magic_link_reminders SET email_error`).
+		WithArgs("sendgrid unavailable", "link-2", models.MagicLinkReminderExpiredUnused).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`ml.expires_at <= NOW\(\)`).
+		WillReturnRows(sqlmock.NewRows(append(reminderLinkRowColumns, "responded", "email", "name")))
+
+	result, err := service.RunSweep(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, 0, result.NeverOpened)
+	assert.Equal(t, 0, result.PMNotified)
+	assert.Equal(t, 2, result.Failed)
+	assert.Len(t, emails.reminders, 1)
+	require.Len(t, emails.expired, 1)
+	assert.Equal(t, "https://app.test/claims/claim-2?reissue_magic_link=link-2", emails.expired[0].ReissueURL)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
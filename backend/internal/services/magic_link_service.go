@@ -1,8 +1,10 @@
 package services
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 
@@ -12,39 +14,75 @@ import (
 	"github.com/google/uuid"
 )
 
+// Default and maximum lifetime of a magic link, in hours
+const (
+	DefaultMagicLinkExpiryHours = 72
+	MaxMagicLinkExpiryHours     = 30 * 24
+)
+
+// Sentinel errors for magic link operations
+var (
+	ErrMagicLinkScope     = errors.New("magic link scope does not permit this action")
+	ErrMagicLinkNotFound  = errors.New("magic link not found")
+	ErrMagicLinkNotActive = errors.New("magic link is not active")
+	ErrMagicLinkStillLive = errors.New("magic link is still active")
+	ErrMagicLinkNotLocked = errors.New("magic link is not locked")
+
+	ErrUploadQuotaExceeded = errors.New("magic link upload quota exceeded")
+)
+
 type MagicLinkService struct {
 	db           *sql.DB
 	cfg          *config.Config
 	storage      *storage.SupabaseStorage
 	claimService *ClaimService
 	emailService EmailService
+	smsService   SMSService
 }
 
-func NewMagicLinkService(db *sql.DB, cfg *config.Config, storageClient *storage.SupabaseStorage, claimService *ClaimService, emailService EmailService) *MagicLinkService {
+func NewMagicLinkService(db *sql.DB, cfg *config.Config, storageClient *storage.SupabaseStorage, claimService *ClaimService, emailService EmailService, smsService SMSService) *MagicLinkService {
 	return &MagicLinkService{
 		db:           db,
 		cfg:          cfg,
 		storage:      storageClient,
 		claimService: claimService,
 		emailService: emailService,
+		smsService:   smsService,
 	}
 }
 
+// GenerateMagicLinkInput identifies the contractor either by directory ID or by
+// name and contact details: an email for the email channel, a phone for SMS.
+// With a contractor ID, omitted contact details come from the directory.
 type GenerateMagicLinkInput struct {
-	ContractorName  string  `json:"contractor_name" binding:"required"`
-	ContractorEmail string  `json:"contractor_email" binding:"required,email"`
+	ContractorID    *string `json:"contractor_id"`
+	ContractorName  string  `json:"contractor_name"`
+	ContractorEmail string  `json:"contractor_email" binding:"omitempty,email"`
 	ContractorPhone *string `json:"contractor_phone"`
+	Scope           string  `json:"scope" binding:"omitempty,oneof=photo scope_sheet proof_of_repair full"`
+	Channel         string  `json:"channel" binding:"omitempty,oneof=email sms both"`
+	ExpiresInHours  *int    `json:"expires_in_hours" binding:"omitempty,min=1,max=720"`
+	MaxUploads      *int    `json:"max_uploads" binding:"omitempty,min=1"`
+	MaxUploadBytes  *int64  `json:"max_upload_bytes" binding:"omitempty,min=1"`
 }
 
 type MagicLinkResponse struct {
-	MagicLinkID     string     `json:"magic_link_id"`
-	Token           string     `json:"token"`
-	LinkURL         string     `json:"link_url"`
-	ContractorName  string     `json:"contractor_name"`
-	ContractorEmail string     `json:"contractor_email"`
-	ContractorPhone *string    `json:"contractor_phone,omitempty"`
-	ExpiresAt       time.Time  `json:"expires_at"`
-	Status          string     `json:"status"`
+	MagicLinkID     string    `json:"magic_link_id"`
+	Token           string    `json:"token"`
+	LinkURL         string    `json:"link_url"`
+	ContractorID    *string   `json:"contractor_id"`
+	ContractorName  string    `json:"contractor_name"`
+	ContractorEmail string    `json:"contractor_email"`
+	ContractorPhone *string   `json:"contractor_phone,omitempty"`
+	Scope           string    `json:"scope"`
+	MaxUploads      int       `json:"max_uploads"`
+	MaxUploadBytes  int64     `json:"max_upload_bytes"`
+	ExpiresAt       time.Time `json:"expires_at"`
+	Status          string    `json:"status"`
+	DeliveryChannel string    `json:"delivery_channel"`
+	EmailSent       bool      `json:"email_sent"`
+	SMSSent         bool      `json:"sms_sent"`
+	SMSStatus       *string   `json:"sms_status"`
 }
 
 func (s *MagicLinkService) GenerateMagicLink(claimID string, organizationID string, userID string, input GenerateMagicLinkInput) (*MagicLinkResponse, error) {
@@ -54,11 +92,59 @@ func (s *MagicLinkService) GenerateMagicLink(claimID string, organizationID stri
 		return nil, err
 	}
 
+	// Step 1b: Resolve the delivery channel and the contractor from the directory
+	if input.Channel == "" {
+		input.Channel = models.MagicLinkChannelEmail
+	}
+	if input.Channel != models.MagicLinkChannelEmail && input.Channel != models.MagicLinkChannelSMS && input.Channel != models.MagicLinkChannelBoth {
+		return nil, fmt.Errorf("invalid delivery channel: %s", input.Channel)
+	}
+	contractorID, err := s.resolveContractor(organizationID, &input)
+	if err != nil {
+		return nil, err
+	}
+
 	// Step 2: Generate cryptographically secure token (UUID v4)
 	token := uuid.New().String()
 
-	// Step 3: Calculate expiration (72 hours from now)
-	expiresAt := time.Now().Add(72 * time.Hour)
+	// Step 3: Resolve scope and expiration (72 hours from now unless requested otherwise).
+	// Several links may be active on a claim at once, e.g. one per trade.
+	scope := input.Scope
+	if scope == "" {
+		scope = models.MagicLinkScopeFull
+	}
+	if !models.IsValidMagicLinkScope(scope) {
+		return nil, fmt.Errorf("invalid magic link scope: %s", scope)
+	}
+
+	expiryHours := DefaultMagicLinkExpiryHours
+	if input.ExpiresInHours != nil {
+		expiryHours = *input.ExpiresInHours
+	}
+	if expiryHours < 1 || expiryHours > MaxMagicLinkExpiryHours {
+		return nil, fmt.Errorf("expires_in_hours must be between 1 and %d", MaxMagicLinkExpiryHours)
+	}
+	expiresAt := time.Now().Add(time.Duration(expiryHours) * time.Hour)
+
+	// Delivery channel: texting the link needs a usable phone number
+	channel := input.Channel
+	if channel != models.MagicLinkChannelEmail {
+		phone, err := NormalizePhoneNumber(*input.ContractorPhone)
+		if err != nil {
+			return nil, err
+		}
+		input.ContractorPhone = &phone
+	}
+
+	// Upload quotas cap how much a single link can push into storage
+	maxUploads := s.cfg.MagicLinkMaxUploads
+	if input.MaxUploads != nil {
+		maxUploads = *input.MaxUploads
+	}
+	maxUploadBytes := s.cfg.MagicLinkMaxUploadBytes
+	if input.MaxUploadBytes != nil {
+		maxUploadBytes = *input.MaxUploadBytes
+	}
 
 	// Step 4: Insert into database
 	magicLink := &models.MagicLink{
@@ -68,6 +154,8 @@ func (s *MagicLinkService) GenerateMagicLink(claimID string, organizationID stri
 		ContractorName:  input.ContractorName,
 		ContractorEmail: input.ContractorEmail,
 		ContractorPhone: input.ContractorPhone,
+		Scope:           scope,
+		DeliveryChannel: channel,
 		ExpiresAt:       expiresAt,
 		AccessCount:     0,
 		Status:          "active",
@@ -78,11 +166,12 @@ func (s *MagicLinkService) GenerateMagicLink(claimID string, organizationID stri
 		INSERT INTO magic_links (
 			id, claim_id, token, contractor_name, contractor_email, contractor_phone,
 			expires_at, accessed_at, access_count, status, created_at, created_by_user_id,
-			email_sent, email_sent_at, email_error
+			email_sent, email_sent_at, email_error, scope, max_uploads, max_upload_bytes, contractor_id,
+			delivery_channel
 		)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20)
 		RETURNING id, claim_id, token, contractor_name, contractor_email, contractor_phone,
-			expires_at, accessed_at, access_count, status, created_at
+			scope, expires_at, accessed_at, access_count, status, created_at
 	`
 
 	err = s.db.QueryRow(
@@ -102,6 +191,11 @@ func (s *MagicLinkService) GenerateMagicLink(claimID string, organizationID stri
 		false,   // email_sent (will be updated after email attempt)
 		nil,     // email_sent_at
 		nil,     // email_error
+		magicLink.Scope,
+		maxUploads,
+		maxUploadBytes,
+		contractorID,
+		magicLink.DeliveryChannel,
 	).Scan(
 		&magicLink.ID,
 		&magicLink.ClaimID,
@@ -109,6 +203,7 @@ func (s *MagicLinkService) GenerateMagicLink(claimID string, organizationID stri
 		&magicLink.ContractorName,
 		&magicLink.ContractorEmail,
 		&magicLink.ContractorPhone,
+		&magicLink.Scope,
 		&magicLink.ExpiresAt,
 		&magicLink.AccessedAt,
 		&magicLink.AccessCount,
@@ -125,11 +220,15 @@ func (s *MagicLinkService) GenerateMagicLink(claimID string, organizationID stri
 		"contractor_name":  input.ContractorName,
 		"contractor_email": input.ContractorEmail,
 		"magic_link_id":    magicLink.ID,
+		"contractor_id":    contractorID,
+		"scope":            magicLink.Scope,
+		"delivery_channel": magicLink.DeliveryChannel,
+		"expires_at":       magicLink.ExpiresAt,
 	}
 	metadataJSON, _ := json.Marshal(metadata)
 	metadataStr := string(metadataJSON)
 
-	description := fmt.Sprintf("Magic link generated for contractor: %s (%s)", input.ContractorName, input.ContractorEmail)
+	description := fmt.Sprintf("Magic link generated for contractor: %s (%s)", input.ContractorName, contractorContact(input.ContractorEmail, input.ContractorPhone))
 	err = s.claimService.createActivity(claimID, &userID, "magic_link_generated", description, &metadataStr)
 	if err != nil {
 		// Don't fail the entire operation if activity logging fails
@@ -139,7 +238,9 @@ func (s *MagicLinkService) GenerateMagicLink(claimID string, organizationID stri
 	// Step 6: Build frontend URL
 	linkURL := fmt.Sprintf("%s/upload/%s", s.cfg.FrontendURL, token)
 
-	// Step 7: Get claim and property data for email
+	// Step 7: Get claim and property data for the notifications
+	var emailSent, smsSent bool
+	var smsStatus *string
 	claim, err := s.claimService.GetClaim(claimID, organizationID)
 	if err != nil {
 		// Log error but don't fail magic link creation
@@ -149,49 +250,29 @@ func (s *MagicLinkService) GenerateMagicLink(claimID string, organizationID stri
 		var propertyName, propertyAddress string
 		propertyQuery := `SELECT nickname, legal_address FROM properties WHERE id = $1`
 		err = s.db.QueryRow(propertyQuery, claim.PropertyID).Scan(&propertyName, &propertyAddress)
-		
+
 		if err != nil {
 			fmt.Printf("Warning: failed to get property for email: %v\n", err)
 		} else {
-			// Send email notification
-			emailInput := SendMagicLinkEmailInput{
-				To:              input.ContractorEmail,
-				ContractorName:  input.ContractorName,
-				PropertyName:    propertyName,
-				PropertyAddress: propertyAddress,
-				LossType:        claim.LossType,
-				MagicLinkURL:    linkURL,
-				ExpiresAt:       expiresAt,
+			if channel != models.MagicLinkChannelSMS {
+				emailSent = s.sendMagicLinkEmail(magicLink.ID, SendMagicLinkEmailInput{
+					To:              input.ContractorEmail,
+					ContractorName:  input.ContractorName,
+					PropertyName:    propertyName,
+					PropertyAddress: propertyAddress,
+					LossType:        claim.LossType,
+					MagicLinkURL:    linkURL,
+					ExpiresAt:       expiresAt,
+				})
 			}
-
-			err = s.emailService.SendMagicLinkEmail(emailInput)
-			
-			// Track email send result
-			var emailSent bool
-			var emailSentAt *time.Time
-			var emailError *string
-			
-			if err != nil {
-				// Log error but don't fail magic link creation
-				fmt.Printf("Warning: Failed to send email notification: %v\n", err)
-				emailSent = false
-				errMsg := err.Error()
-				emailError = &errMsg
-			} else {
-				emailSent = true
-				now := time.Now()
-				emailSentAt = &now
-			}
-			
-			// Update magic link with email send status
-			updateQuery := `
-				UPDATE magic_links
-				SET email_sent = $1, email_sent_at = $2, email_error = $3
-				WHERE id = $4
-			`
-			_, updateErr := s.db.Exec(updateQuery, emailSent, emailSentAt, emailError, magicLink.ID)
-			if updateErr != nil {
-				fmt.Printf("Warning: Failed to update email send status: %v\n", updateErr)
+			if channel != models.MagicLinkChannelEmail {
+				smsSent, smsStatus = s.sendMagicLinkSMS(magicLink.ID, SendMagicLinkSMSInput{
+					To:             *input.ContractorPhone,
+					ContractorName: input.ContractorName,
+					PropertyName:   propertyName,
+					MagicLinkURL:   linkURL,
+					ExpiresAt:      expiresAt,
+				})
 			}
 		}
 	}
@@ -201,25 +282,180 @@ func (s *MagicLinkService) GenerateMagicLink(claimID string, organizationID stri
 		MagicLinkID:     magicLink.ID,
 		Token:           magicLink.Token,
 		LinkURL:         linkURL,
+		ContractorID:    contractorID,
 		ContractorName:  magicLink.ContractorName,
 		ContractorEmail: magicLink.ContractorEmail,
 		ContractorPhone: magicLink.ContractorPhone,
+		Scope:           magicLink.Scope,
+		MaxUploads:      maxUploads,
+		MaxUploadBytes:  maxUploadBytes,
 		ExpiresAt:       magicLink.ExpiresAt,
 		Status:          magicLink.Status,
+		DeliveryChannel: magicLink.DeliveryChannel,
+		EmailSent:       emailSent,
+		SMSSent:         smsSent,
+		SMSStatus:       smsStatus,
 	}
 
 	return response, nil
 }
 
+// sendMagicLinkEmail emails the link and records the outcome on the magic link
+func (s *MagicLinkService) sendMagicLinkEmail(magicLinkID string, emailInput SendMagicLinkEmailInput) bool {
+	err := s.emailService.SendMagicLinkEmail(emailInput)
+
+	// Track email send result
+	var emailSent bool
+	var emailSentAt *time.Time
+	var emailError *string
+
+	if err != nil {
+		// Log error but don't fail magic link creation
+		fmt.Printf("Warning: Failed to send email notification: %v\n", err)
+		emailSent = false
+		errMsg := err.Error()
+		emailError = &errMsg
+	} else {
+		emailSent = true
+		now := time.Now()
+		emailSentAt = &now
+	}
+
+	// Update magic link with email send status
+	updateQuery := `
+		UPDATE magic_links
+		SET email_sent = $1, email_sent_at = $2, email_error = $3
+		WHERE id = $4
+	`
+	_, updateErr := s.db.Exec(updateQuery, emailSent, emailSentAt, emailError, magicLinkID)
+	if updateErr != nil {
+		fmt.Printf("Warning: Failed to update email send status: %v\n", updateErr)
+	}
+
+	return emailSent
+}
+
+// sendMagicLinkSMS texts the link unless the number has opted out, and records
+// the outcome on the magic link. Failures never fail link creation.
+func (s *MagicLinkService) sendMagicLinkSMS(magicLinkID string, smsInput SendMagicLinkSMSInput) (bool, *string) {
+	var smsSent bool
+	var smsSentAt *time.Time
+	var smsStatus, smsMessageID, smsError *string
+
+	optedOut, err := isPhoneOptedOut(s.db, smsInput.To)
+	switch {
+	case err != nil:
+		fmt.Printf("Warning: %v\n", err)
+		errMsg := err.Error()
+		smsError = &errMsg
+	case optedOut:
+		status := SMSStatusOptedOut
+		smsStatus = &status
+		errMsg := "recipient has opted out of SMS"
+		smsError = &errMsg
+	default:
+		result, err := s.smsService.SendMagicLinkSMS(smsInput)
+		if err != nil {
+			// Log error but don't fail magic link creation
+			fmt.Printf("Warning: Failed to send SMS notification: %v\n", err)
+			status := "failed"
+			smsStatus = &status
+			errMsg := err.Error()
+			smsError = &errMsg
+		} else {
+			smsSent = true
+			now := time.Now()
+			smsSentAt = &now
+			smsStatus = &result.Status
+			smsMessageID = &result.MessageID
+		}
+	}
+
+	updateQuery := `
+		UPDATE magic_links
+		SET sms_sent = $1, sms_sent_at = $2, sms_status = $3, sms_message_id = $4, sms_error = $5
+		WHERE id = $6
+	`
+	_, updateErr := s.db.Exec(updateQuery, smsSent, smsSentAt, smsStatus, smsMessageID, smsError, magicLinkID)
+	if updateErr != nil {
+		fmt.Printf("Warning: Failed to update SMS send status: %v\n", updateErr)
+	}
+
+	return smsSent, smsStatus
+}
+
+// resolveContractor links the magic link to a directory contractor. An explicit
+// contractor ID fills in missing contact details; otherwise the email is matched
+// against the directory so links typed by hand still attach to the right profile.
+// input.Channel must already be resolved; the contractor needs the contact
+// details it delivers to.
+func (s *MagicLinkService) resolveContractor(organizationID string, input *GenerateMagicLinkInput) (*string, error) {
+	var contractor *models.Contractor
+	if input.ContractorID != nil && *input.ContractorID != "" {
+		found, err := loadContractor(s.db, *input.ContractorID, organizationID)
+		if err != nil {
+			return nil, err
+		}
+		if !found.IsActive {
+			return nil, fmt.Errorf("contractor is inactive")
+		}
+		contractor = found
+
+		if input.ContractorName == "" {
+			input.ContractorName = contractor.Name
+		}
+		if input.ContractorEmail == "" && contractor.Email != nil {
+			input.ContractorEmail = *contractor.Email
+		}
+		if input.ContractorPhone == nil {
+			input.ContractorPhone = contractor.Phone
+		}
+	} else if input.ContractorEmail != "" {
+		found, err := findContractorByEmail(s.db, input.ContractorEmail, organizationID)
+		if err != nil {
+			return nil, err
+		}
+		contractor = found
+	}
+
+	// Each delivery channel needs its own contact detail: an email for email,
+	// a phone for SMS and both for both
+	if input.ContractorName == "" {
+		return nil, fmt.Errorf("contractor name is required")
+	}
+	if input.Channel != models.MagicLinkChannelSMS && input.ContractorEmail == "" {
+		return nil, fmt.Errorf("contractor email is required for email delivery")
+	}
+	if input.Channel != models.MagicLinkChannelEmail && (input.ContractorPhone == nil || *input.ContractorPhone == "") {
+		return nil, fmt.Errorf("contractor phone is required for SMS delivery")
+	}
+
+	if contractor == nil {
+		return nil, nil
+	}
+	return &contractor.ID, nil
+}
+
+// contractorContact is how activity descriptions identify a link's contractor:
+// by email, or by phone for a link sent only by SMS
+func contractorContact(email string, phone *string) string {
+	if email == "" && phone != nil {
+		return *phone
+	}
+	return email
+}
+
 // ValidationResult contains the result of token validation
 type ValidationResult struct {
-	Valid          bool         `json:"valid"`
-	Reason         string       `json:"reason,omitempty"` // "expired", "not_found", "completed"
-	MagicLinkID    string       `json:"magic_link_id,omitempty"`
-	Claim          *ClaimInfo   `json:"claim,omitempty"`
-	ContractorName string       `json:"contractor_name,omitempty"`
-	ExpiresAt      time.Time    `json:"expires_at,omitempty"`
-	Status         string       `json:"status,omitempty"`
+	Valid          bool                         `json:"valid"`
+	Reason         string                       `json:"reason,omitempty"` // "expired", "not_found", "completed", "revoked", "locked"
+	MagicLinkID    string                       `json:"magic_link_id,omitempty"`
+	Claim          *ClaimInfo                   `json:"claim,omitempty"`
+	ContractorName string                       `json:"contractor_name,omitempty"`
+	ExpiresAt      time.Time                    `json:"expires_at,omitempty"`
+	Status         string                       `json:"status,omitempty"`
+	Scope          string                       `json:"scope,omitempty"`
+	Permissions    *models.MagicLinkPermissions `json:"permissions,omitempty"`
 }
 
 // ClaimInfo contains minimal claim information for validation response
@@ -242,7 +478,7 @@ func (s *MagicLinkService) ValidateToken(token string) (*ValidationResult, error
 	// Query with joins to get all needed data in one query
 	query := `
 		SELECT
-			ml.id, ml.claim_id, ml.contractor_name, ml.expires_at, ml.status,
+			ml.id, ml.claim_id, ml.contractor_name, ml.expires_at, ml.status, ml.scope,
 			c.claim_number, c.loss_type, c.incident_date,
 			p.nickname, p.legal_address
 		FROM magic_links ml
@@ -251,7 +487,7 @@ func (s *MagicLinkService) ValidateToken(token string) (*ValidationResult, error
 		WHERE ml.token = $1
 	`
 
-	var magicLinkID, claimID, contractorName, status string
+	var magicLinkID, claimID, contractorName, status, scope string
 	var expiresAt, incidentDate time.Time
 	var claimNumber *string
 	var lossType, nickname, legalAddress string
@@ -262,6 +498,7 @@ func (s *MagicLinkService) ValidateToken(token string) (*ValidationResult, error
 		&contractorName,
 		&expiresAt,
 		&status,
+		&scope,
 		&claimNumber,
 		&lossType,
 		&incidentDate,
@@ -281,6 +518,14 @@ func (s *MagicLinkService) ValidateToken(token string) (*ValidationResult, error
 		return nil, fmt.Errorf("failed to query magic link: %w", err)
 	}
 
+	// A revoked or locked link stays that way even after its expiry passes
+	if status == "revoked" || status == "locked" {
+		return &ValidationResult{
+			Valid:  false,
+			Reason: status,
+		}, nil
+	}
+
 	// Check if expired (expires_at < now)
 	if time.Now().After(expiresAt) {
 		return &ValidationResult{
@@ -312,12 +557,15 @@ func (s *MagicLinkService) ValidateToken(token string) (*ValidationResult, error
 	}
 
 	// Return valid result with claim data
+	permissions := models.MagicLinkScopePermissions[scope]
 	result := &ValidationResult{
 		Valid:          true,
 		MagicLinkID:    magicLinkID,
 		ContractorName: contractorName,
 		ExpiresAt:      expiresAt,
 		Status:         status,
+		Scope:          scope,
+		Permissions:    &permissions,
 		Claim: &ClaimInfo{
 			ID:           claimID,
 			ClaimNumber:  claimNumber,
@@ -345,16 +593,27 @@ func (s *MagicLinkService) RequestUploadURLWithToken(token string, fileName stri
 		return nil, fmt.Errorf("invalid or expired token: %s", validation.Reason)
 	}
 
-	// Step 2: Validate document type and file
+	// Step 2: Validate document type and file. These are the contractor's own
+	// mistakes, so they don't count towards locking the link.
 	if !models.IsValidDocumentType(documentType) {
 		return nil, models.ErrInvalidDocumentType
 	}
 
+	if !models.MagicLinkScopeAllowsDocumentType(validation.Scope, documentType) {
+		return nil, ErrMagicLinkScope
+	}
+
 	err = models.ValidateFile(documentType, fileSize, mimeType)
 	if err != nil {
 		return nil, err
 	}
 
+	// Reserve quota before handing out an upload URL. Requested-but-never-confirmed
+	// uploads still count, otherwise the quota wouldn't stop upload flooding.
+	if err := s.reserveUpload(validation.MagicLinkID, fileSize); err != nil {
+		return nil, err
+	}
+
 	// Step 3: Get claim and organization info from validation
 	claimID := validation.Claim.ID
 
@@ -377,14 +636,15 @@ func (s *MagicLinkService) RequestUploadURLWithToken(token string, fileName stri
 		return nil, fmt.Errorf("failed to generate upload URL: %w", err)
 	}
 
-	// Step 5: Create pending document record (uploaded_by_user_id = NULL for contractor uploads)
+	// Step 5: Create pending document record (uploaded_by_user_id = NULL for contractor uploads),
+	// recording the link it came through
 	documentID := uuid.New().String()
 	query := `
 		INSERT INTO documents (
 			id, claim_id, uploaded_by_user_id, document_type, file_url,
-			file_name, file_size_bytes, mime_type, status, created_at
+			file_name, file_size_bytes, mime_type, status, created_at, is_current, magic_link_id
 		)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, false, $11)
 		RETURNING id
 	`
 
@@ -400,6 +660,7 @@ func (s *MagicLinkService) RequestUploadURLWithToken(token string, fileName stri
 		mimeType,
 		"pending",
 		time.Now(),
+		validation.MagicLinkID,
 	).Scan(&documentID)
 
 	if err != nil {
@@ -427,44 +688,39 @@ func (s *MagicLinkService) ConfirmUploadWithToken(token string, documentID strin
 
 	claimID := validation.Claim.ID
 
-	// Step 2: Update document status to confirmed
-	query := `
-		UPDATE documents
-		SET status = 'confirmed'
-		WHERE id = $1 AND claim_id = $2 AND status = 'pending'
-		RETURNING id, claim_id, uploaded_by_user_id, document_type, file_url,
-			file_name, file_size_bytes, mime_type, metadata, status, created_at
-	`
-
-	var doc models.Document
-	err = s.db.QueryRow(query, documentID, claimID).Scan(
-		&doc.ID,
-		&doc.ClaimID,
-		&doc.UploadedByUserID,
-		&doc.DocumentType,
-		&doc.FileURL,
-		&doc.FileName,
-		&doc.FileSizeBytes,
-		&doc.MimeType,
-		&doc.Metadata,
-		&doc.Status,
-		&doc.CreatedAt,
-	)
-
-	if err == sql.ErrNoRows {
+	// Step 2: Make sure the document was requested through this link, since any
+	// link on the claim could otherwise confirm another trade's upload. Guessing
+	// at other documents counts towards locking the link; confirming its own
+	// upload twice, as a retry does, does not.
+	var status string
+	var uploadLinkID *string
+	docQuery := `SELECT status, magic_link_id FROM documents WHERE id = $1 AND claim_id = $2`
+	err = s.db.QueryRow(docQuery, documentID, claimID).Scan(&status, &uploadLinkID)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("failed to get document: %w", err)
+	}
+	if err == sql.ErrNoRows || uploadLinkID == nil || *uploadLinkID != validation.MagicLinkID {
+		s.recordFailedAttempt(validation.MagicLinkID)
 		return nil, fmt.Errorf("document not found or already confirmed")
 	}
+	if status != "pending" {
+		return nil, fmt.Errorf("document not found or already confirmed")
+	}
+
+	// Step 3: Update document status to confirmed and make it the current version
+	doc, err := confirmDocumentUpload(context.Background(), s.db, claimID, documentID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to confirm document: %w", err)
+		return nil, err
 	}
 
-	// Step 3: Create activity log (no user_id since contractor uploads)
+	// Step 4: Create activity log (no user_id since contractor uploads)
 	metadata := map[string]interface{}{
 		"document_id":      documentID,
 		"document_type":    doc.DocumentType,
 		"file_name":        doc.FileName,
 		"contractor_name":  validation.ContractorName,
 		"uploaded_via":     "magic_link",
+		"magic_link_id":    validation.MagicLinkID,
 	}
 	metadataJSON, _ := json.Marshal(metadata)
 	metadataStr := string(metadataJSON)
@@ -476,10 +732,11 @@ func (s *MagicLinkService) ConfirmUploadWithToken(token string, documentID strin
 		fmt.Printf("Warning: failed to log activity: %v\n", err)
 	}
 
-	return &doc, nil
+	return doc, nil
 }
 
-// ListDocumentsWithToken retrieves all documents for a claim using magic link token (no auth required)
+// ListDocumentsWithToken retrieves the claim's current documents that the link's
+// scope is allowed to see, using magic link token (no auth required)
 func (s *MagicLinkService) ListDocumentsWithToken(token string) ([]models.Document, error) {
 	// Step 1: Validate the token
 	validation, err := s.ValidateToken(token)
@@ -493,12 +750,16 @@ func (s *MagicLinkService) ListDocumentsWithToken(token string) ([]models.Docume
 
 	claimID := validation.Claim.ID
 
-	// Step 2: Fetch all documents for this claim
+	if len(validation.Permissions.DocumentTypes) == 0 {
+		return nil, ErrMagicLinkScope
+	}
+
+	// Step 2: Fetch the documents for this claim, filtered to the scope's document types
 	query := `
 		SELECT id, claim_id, uploaded_by_user_id, document_type, file_url,
 			file_name, file_size_bytes, mime_type, status, created_at
 		FROM documents
-		WHERE claim_id = $1 AND status = 'active'
+		WHERE claim_id = $1 AND status = 'confirmed' AND is_current = true
 		ORDER BY created_at DESC
 	`
 
@@ -526,6 +787,9 @@ func (s *MagicLinkService) ListDocumentsWithToken(token string) ([]models.Docume
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan document: %w", err)
 		}
+		if !models.MagicLinkScopeAllowsDocumentType(validation.Scope, doc.DocumentType) {
+			continue
+		}
 		documents = append(documents, doc)
 	}
 
@@ -536,37 +800,292 @@ func (s *MagicLinkService) ListDocumentsWithToken(token string) ([]models.Docume
 	return documents, nil
 }
 
-// invalidatePreviousLinks marks all active magic links for a claim as expired
-func (s *MagicLinkService) invalidatePreviousLinks(claimID string) error {
+// reserveUpload counts an upload against the link's quota, failing with
+// ErrUploadQuotaExceeded when the count or byte limit would be exceeded
+func (s *MagicLinkService) reserveUpload(magicLinkID string, fileSize int64) error {
+	query := `
+		UPDATE magic_links
+		SET upload_count = upload_count + 1, upload_bytes = upload_bytes + $2
+		WHERE id = $1 AND upload_count < max_uploads AND upload_bytes + $2 <= max_upload_bytes
+	`
+
+	result, err := s.db.Exec(query, magicLinkID, fileSize)
+	if err != nil {
+		return fmt.Errorf("failed to reserve upload quota: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return ErrUploadQuotaExceeded
+	}
+
+	return nil
+}
+
+// recordFailedAttempt counts an attempt to use a link on something it wasn't
+// issued for and locks the link once MagicLinkLockoutAttempts is reached. Errors are logged, not returned,
+// so the caller still reports the original failure.
+func (s *MagicLinkService) recordFailedAttempt(magicLinkID string) {
 	query := `
 		UPDATE magic_links
-		SET status = 'expired'
-		WHERE claim_id = $1 AND status = 'active'
+		SET failed_attempt_count = failed_attempt_count + 1,
+			status = CASE WHEN failed_attempt_count + 1 >= $2 AND status = 'active' THEN 'locked' ELSE status END,
+			locked_at = CASE WHEN failed_attempt_count + 1 >= $2 AND status = 'active' THEN NOW() ELSE locked_at END
+		WHERE id = $1
+		RETURNING claim_id, status, failed_attempt_count
 	`
 
-	_, err := s.db.Exec(query, claimID)
+	var claimID, status string
+	var failedAttempts int
+	err := s.db.QueryRow(query, magicLinkID, s.cfg.MagicLinkLockoutAttempts).Scan(&claimID, &status, &failedAttempts)
 	if err != nil {
-		return fmt.Errorf("failed to invalidate previous links: %w", err)
+		fmt.Printf("Warning: failed to record failed magic link attempt: %v\n", err)
+		return
+	}
+
+	// Only the attempt that crossed the threshold logs the lock
+	if status != "locked" || failedAttempts != s.cfg.MagicLinkLockoutAttempts {
+		return
+	}
+
+	metadata := map[string]interface{}{
+		"magic_link_id":        magicLinkID,
+		"failed_attempt_count": failedAttempts,
+	}
+	metadataJSON, _ := json.Marshal(metadata)
+	metadataStr := string(metadataJSON)
+
+	description := fmt.Sprintf("Magic link locked after %d failed attempts", failedAttempts)
+	err = s.claimService.createActivity(claimID, nil, "magic_link_locked", description, &metadataStr)
+	if err != nil {
+		fmt.Printf("Warning: failed to log activity: %v\n", err)
+	}
+}
+
+// UnlockMagicLink reopens a link that was locked after failed attempts and
+// resets its count, for when the PM knows the contractor is genuine
+func (s *MagicLinkService) UnlockMagicLink(claimID string, magicLinkID string, organizationID string, userID string) error {
+	// Validate claim ownership
+	_, err := s.claimService.GetClaim(claimID, organizationID)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		UPDATE magic_links
+		SET status = 'active', locked_at = NULL, failed_attempt_count = 0
+		WHERE id = $1 AND claim_id = $2 AND status = 'locked'
+		RETURNING contractor_name, contractor_email
+	`
+
+	var contractorName, contractorEmail string
+	err = s.db.QueryRow(query, magicLinkID, claimID).Scan(&contractorName, &contractorEmail)
+	if err == sql.ErrNoRows {
+		var exists bool
+		existsQuery := `SELECT EXISTS(SELECT 1 FROM magic_links WHERE id = $1 AND claim_id = $2)`
+		if err := s.db.QueryRow(existsQuery, magicLinkID, claimID).Scan(&exists); err != nil {
+			return fmt.Errorf("failed to check magic link: %w", err)
+		}
+		if !exists {
+			return ErrMagicLinkNotFound
+		}
+		return ErrMagicLinkNotLocked
+	}
+	if err != nil {
+		return fmt.Errorf("failed to unlock magic link: %w", err)
+	}
+
+	metadata := map[string]interface{}{
+		"contractor_name":  contractorName,
+		"contractor_email": contractorEmail,
+		"magic_link_id":    magicLinkID,
+	}
+	metadataJSON, _ := json.Marshal(metadata)
+	metadataStr := string(metadataJSON)
+
+	description := fmt.Sprintf("Magic link unlocked for contractor: %s (%s)", contractorName, contractorEmail)
+	err = s.claimService.createActivity(claimID, &userID, "magic_link_unlocked", description, &metadataStr)
+	if err != nil {
+		// Don't fail the entire operation if activity logging fails
+		fmt.Printf("Warning: failed to log activity: %v\n", err)
+	}
+
+	return nil
+}
+
+// RecordRateLimitEvent notes on the link that a request using its token was
+// rate limited, so the PM can see abuse on the magic link list
+func (s *MagicLinkService) RecordRateLimitEvent(ctx context.Context, token string) error {
+	query := `
+		UPDATE magic_links
+		SET rate_limited_count = rate_limited_count + 1, last_rate_limited_at = NOW()
+		WHERE token = $1
+	`
+
+	if _, err := s.db.ExecContext(ctx, query, token); err != nil {
+		return fmt.Errorf("failed to record rate limit event: %w", err)
 	}
 
 	return nil
 }
 
+// RevokeMagicLink revokes an active magic link so it can no longer be used.
+// Other links on the claim are left untouched.
+func (s *MagicLinkService) RevokeMagicLink(claimID string, magicLinkID string, organizationID string, userID string) error {
+	// Validate claim ownership
+	_, err := s.claimService.GetClaim(claimID, organizationID)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		UPDATE magic_links
+		SET status = 'revoked', revoked_at = NOW(), revoked_by_user_id = $1
+		WHERE id = $2 AND claim_id = $3 AND status = 'active'
+		RETURNING contractor_name, contractor_email
+	`
+
+	var contractorName, contractorEmail string
+	err = s.db.QueryRow(query, userID, magicLinkID, claimID).Scan(&contractorName, &contractorEmail)
+	if err == sql.ErrNoRows {
+		var exists bool
+		existsQuery := `SELECT EXISTS(SELECT 1 FROM magic_links WHERE id = $1 AND claim_id = $2)`
+		if err := s.db.QueryRow(existsQuery, magicLinkID, claimID).Scan(&exists); err != nil {
+			return fmt.Errorf("failed to check magic link: %w", err)
+		}
+		if !exists {
+			return ErrMagicLinkNotFound
+		}
+		return ErrMagicLinkNotActive
+	}
+	if err != nil {
+		return fmt.Errorf("failed to revoke magic link: %w", err)
+	}
+
+	metadata := map[string]interface{}{
+		"contractor_name":  contractorName,
+		"contractor_email": contractorEmail,
+		"magic_link_id":    magicLinkID,
+	}
+	metadataJSON, _ := json.Marshal(metadata)
+	metadataStr := string(metadataJSON)
+
+	description := fmt.Sprintf("Magic link revoked for contractor: %s (%s)", contractorName, contractorEmail)
+	err = s.claimService.createActivity(claimID, &userID, "magic_link_revoked", description, &metadataStr)
+	if err != nil {
+		// Don't fail the entire operation if activity logging fails
+		fmt.Printf("Warning: failed to log activity: %v\n", err)
+	}
+
+	return nil
+}
+
+// ReissueMagicLink sends the contractor a fresh link with the same contractor,
+// scope, quotas and lifetime as a link that expired, was revoked or was locked
+func (s *MagicLinkService) ReissueMagicLink(claimID string, magicLinkID string, organizationID string, userID string) (*MagicLinkResponse, error) {
+	// Validate claim ownership
+	_, err := s.claimService.GetClaim(claimID, organizationID)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT contractor_id, contractor_name, contractor_email, contractor_phone,
+			scope, delivery_channel, max_uploads, max_upload_bytes, status, created_at, expires_at
+		FROM magic_links
+		WHERE id = $1 AND claim_id = $2
+	`
+
+	var input GenerateMagicLinkInput
+	var maxUploads int
+	var maxUploadBytes int64
+	var status string
+	var createdAt, expiresAt time.Time
+	err = s.db.QueryRow(query, magicLinkID, claimID).Scan(
+		&input.ContractorID,
+		&input.ContractorName,
+		&input.ContractorEmail,
+		&input.ContractorPhone,
+		&input.Scope,
+		&input.Channel,
+		&maxUploads,
+		&maxUploadBytes,
+		&status,
+		&createdAt,
+		&expiresAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, ErrMagicLinkNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get magic link: %w", err)
+	}
+
+	// A link the contractor can still use should be revoked before it is replaced
+	if status == "active" && time.Now().Before(expiresAt) {
+		return nil, ErrMagicLinkStillLive
+	}
+
+	expiryHours := int(expiresAt.Sub(createdAt).Round(time.Hour) / time.Hour)
+	if expiryHours < 1 {
+		expiryHours = 1
+	}
+	if expiryHours > MaxMagicLinkExpiryHours {
+		expiryHours = MaxMagicLinkExpiryHours
+	}
+	input.ExpiresInHours = &expiryHours
+	input.MaxUploads = &maxUploads
+	input.MaxUploadBytes = &maxUploadBytes
+
+	response, err := s.GenerateMagicLink(claimID, organizationID, userID, input)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = s.db.Exec(`UPDATE magic_links SET reissued_from_id = $1 WHERE id = $2`, magicLinkID, response.MagicLinkID)
+	if err != nil {
+		// The new link works either way; only the history link is missing
+		fmt.Printf("Warning: failed to record reissued magic link: %v\n", err)
+	}
+
+	return response, nil
+}
+
 // MagicLinkWithSender contains magic link information with sender details
 type MagicLinkWithSender struct {
 	ID              string     `json:"id"`
+	ContractorID    *string    `json:"contractor_id"`
 	ContractorName  string     `json:"contractor_name"`
 	ContractorEmail string     `json:"contractor_email"`
 	ContractorPhone *string    `json:"contractor_phone"`
+	Scope           string     `json:"scope"`
 	Status          string     `json:"status"`
 	CreatedAt       time.Time  `json:"created_at"`
 	ExpiresAt       time.Time  `json:"expires_at"`
+	RevokedAt       *time.Time `json:"revoked_at"`
+	LockedAt        *time.Time `json:"locked_at"`
+	FailedAttempts  int        `json:"failed_attempt_count"`
+	UploadCount     int        `json:"upload_count"`
+	UploadBytes     int64      `json:"upload_bytes"`
+	MaxUploads      int        `json:"max_uploads"`
+	MaxUploadBytes  int64      `json:"max_upload_bytes"`
+	RateLimited     int        `json:"rate_limited_count"`
+	LastRateLimited *time.Time `json:"last_rate_limited_at"`
 	AccessedAt      *time.Time `json:"accessed_at"`
 	AccessCount     int        `json:"access_count"`
 	EmailSent       bool       `json:"email_sent"`
 	EmailSentAt     *time.Time `json:"email_sent_at"`
 	EmailError      *string    `json:"email_error"`
+	DeliveryChannel string     `json:"delivery_channel"`
+	SMSSent         bool       `json:"sms_sent"`
+	SMSSentAt       *time.Time `json:"sms_sent_at"`
+	SMSStatus       *string    `json:"sms_status"`
+	SMSError        *string    `json:"sms_error"`
 	CreatedByUser   *UserInfo  `json:"created_by_user"`
+	ReissuedFromID  *string    `json:"reissued_from_id"`
 	LinkURL         string     `json:"link_url"`
 }
 
@@ -588,10 +1107,13 @@ func (s *MagicLinkService) GetMagicLinksByClaimID(claimID string, organizationID
 	// Query magic links with user join
 	query := `
 		SELECT
-			ml.id, ml.contractor_name, ml.contractor_email, ml.contractor_phone,
-			ml.status, ml.created_at, ml.expires_at, ml.accessed_at, ml.access_count,
-			ml.email_sent, ml.email_sent_at, ml.email_error, ml.token,
-			ml.created_by_user_id,
+			ml.id, ml.contractor_id, ml.contractor_name, ml.contractor_email, ml.contractor_phone,
+			ml.scope, ml.status, ml.created_at, ml.expires_at, ml.revoked_at, ml.accessed_at, ml.access_count,
+			ml.locked_at, ml.failed_attempt_count, ml.upload_count, ml.upload_bytes,
+			ml.max_uploads, ml.max_upload_bytes, ml.rate_limited_count, ml.last_rate_limited_at,
+			ml.email_sent, ml.email_sent_at, ml.email_error,
+			ml.delivery_channel, ml.sms_sent, ml.sms_sent_at, ml.sms_status, ml.sms_error,
+			ml.token, ml.reissued_from_id, ml.created_by_user_id,
 			u.name, u.email
 		FROM magic_links ml
 		LEFT JOIN users u ON u.id = ml.created_by_user_id
@@ -614,18 +1136,35 @@ func (s *MagicLinkService) GetMagicLinksByClaimID(claimID string, organizationID
 
 		err := rows.Scan(
 			&ml.ID,
+			&ml.ContractorID,
 			&ml.ContractorName,
 			&ml.ContractorEmail,
 			&ml.ContractorPhone,
+			&ml.Scope,
 			&ml.Status,
 			&ml.CreatedAt,
 			&ml.ExpiresAt,
+			&ml.RevokedAt,
 			&ml.AccessedAt,
 			&ml.AccessCount,
+			&ml.LockedAt,
+			&ml.FailedAttempts,
+			&ml.UploadCount,
+			&ml.UploadBytes,
+			&ml.MaxUploads,
+			&ml.MaxUploadBytes,
+			&ml.RateLimited,
+			&ml.LastRateLimited,
 			&ml.EmailSent,
 			&ml.EmailSentAt,
 			&ml.EmailError,
+			&ml.DeliveryChannel,
+			&ml.SMSSent,
+			&ml.SMSSentAt,
+			&ml.SMSStatus,
+			&ml.SMSError,
 			&token,
+			&ml.ReissuedFromID,
 			&createdByUserID,
 			&userName,
 			&userEmail,
@@ -0,0 +1,274 @@
+package services
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/claimcoach/backend/internal/config"
+	"github.com/claimcoach/backend/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func expectValidToken(mock sqlmock.Sqlmock, token, scope, status string) {
+	mock.ExpectQuery(`SELECT (.+) FROM magic_links ml`).
+		WithArgs(token).
+		WillReturnRows(sqlmock.NewRows([]string{
+			"id", "claim_id", "contractor_name", "expires_at", "status", "scope",
+			"claim_number", "loss_type", "incident_date", "nickname", "legal_address",
+		}).AddRow("link-1", "claim-1", "Roofer", time.Now().Add(time.Hour), status, scope,
+			nil, "wind", time.Now(), "Oak Apartments", "1 Main St"))
+	if status == "active" {
+		mock.ExpectExec(`UPDATE magic_links\s+SET access_count`).
+			WithArgs(token).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+}
+
+func expectFailedAttempt(mock sqlmock.Sqlmock, status string, failedAttempts int) {
+	mock.ExpectQuery(`UPDATE magic_links\s+SET failed_attempt_count`).
+		WithArgs("link-1", 10).
+		WillReturnRows(sqlmock.NewRows([]string{"claim_id", "status", "failed_attempt_count"}).
+			AddRow("claim-1", status, failedAttempts))
+}
+
+func newTestMagicLinkService(db *sql.DB) *MagicLinkService {
+	return &MagicLinkService{db: db, cfg: &config.Config{MagicLinkLockoutAttempts: 10}}
+}
+
+func TestMagicLinkService_ResolveContractor_RequiresContactForChannel(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	service := &MagicLinkService{db: db}
+	phone := "+15125550100"
+
+	// A field contractor with only a phone can get an SMS-only link
+	contractorID, err := service.resolveContractor("org-1", &GenerateMagicLinkInput{
+		ContractorName: "Field Roofer", ContractorPhone: &phone, Channel: models.MagicLinkChannelSMS,
+	})
+	require.NoError(t, err)
+	assert.Nil(t, contractorID)
+
+	cases := map[string]struct {
+		input GenerateMagicLinkInput
+		want  string
+	}{
+		"no name": {
+			GenerateMagicLinkInput{ContractorPhone: &phone, Channel: models.MagicLinkChannelSMS},
+			"contractor name is required",
+		},
+		"email without an email": {
+			GenerateMagicLinkInput{ContractorName: "Roofer", ContractorPhone: &phone, Channel: models.MagicLinkChannelEmail},
+			"contractor email is required for email delivery",
+		},
+		"sms without a phone": {
+			GenerateMagicLinkInput{ContractorName: "Roofer", Channel: models.MagicLinkChannelSMS},
+			"contractor phone is required for SMS delivery",
+		},
+		"both without an email": {
+			GenerateMagicLinkInput{ContractorName: "Roofer", ContractorPhone: &phone, Channel: models.MagicLinkChannelBoth},
+			"contractor email is required for email delivery",
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, err := service.resolveContractor("org-1", &tc.input)
+			assert.EqualError(t, err, tc.want)
+		})
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMagicLinkService_RequestUploadURLWithToken_EnforcesScope(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	service := newTestMagicLinkService(db)
+	expectValidToken(mock, "token-1", models.MagicLinkScopePhoto, "active")
+
+	resp, err := service.RequestUploadURLWithToken("token-1", "estimate.pdf", 1024, "application/pdf", models.DocumentTypeContractorEstimate)
+
+	assert.Nil(t, resp)
+	assert.ErrorIs(t, err, ErrMagicLinkScope)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMagicLinkService_ConfirmUploadWithToken_RejectsOtherLinksDocument(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	service := newTestMagicLinkService(db)
+	expectValidToken(mock, "token-1", models.MagicLinkScopeProofOfRepair, "active")
+	mock.ExpectQuery(`SELECT status, magic_link_id FROM documents`).
+		WithArgs("doc-1", "claim-1").
+		WillReturnRows(sqlmock.NewRows([]string{"status", "magic_link_id"}).AddRow("pending", "link-2"))
+	expectFailedAttempt(mock, "active", 2)
+
+	doc, err := service.ConfirmUploadWithToken("token-1", "doc-1")
+
+	assert.Nil(t, doc)
+	assert.EqualError(t, err, "document not found or already confirmed")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMagicLinkService_ConfirmUploadWithToken_RetryDoesNotCount(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	service := newTestMagicLinkService(db)
+	expectValidToken(mock, "token-1", models.MagicLinkScopePhoto, "active")
+	mock.ExpectQuery(`SELECT status, magic_link_id FROM documents`).
+		WithArgs("doc-1", "claim-1").
+		WillReturnRows(sqlmock.NewRows([]string{"status", "magic_link_id"}).AddRow("confirmed", "link-1"))
+
+	doc, err := service.ConfirmUploadWithToken("token-1", "doc-1")
+
+	assert.Nil(t, doc)
+	assert.EqualError(t, err, "document not found or already confirmed")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMagicLinkService_RequestUploadURLWithToken_InvalidFileDoesNotCount(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	service := newTestMagicLinkService(db)
+	expectValidToken(mock, "token-1", models.MagicLinkScopePhoto, "active")
+
+	resp, err := service.RequestUploadURLWithToken("token-1", "roof.exe", 1024, "application/x-msdownload", models.DocumentTypeContractorPhoto)
+
+	assert.Nil(t, resp)
+	assert.Error(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMagicLinkService_ListDocumentsWithToken_ScopeSheetOnly(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	service := newTestMagicLinkService(db)
+	expectValidToken(mock, "token-1", models.MagicLinkScopeScopeSheet, "active")
+
+	docs, err := service.ListDocumentsWithToken("token-1")
+
+	assert.Nil(t, docs)
+	assert.ErrorIs(t, err, ErrMagicLinkScope)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMagicLinkService_ValidateToken_Revoked(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	service := newTestMagicLinkService(db)
+	expectValidToken(mock, "token-1", models.MagicLinkScopeFull, "revoked")
+
+	result, err := service.ValidateToken("token-1")
+
+	require.NoError(t, err)
+	assert.False(t, result.Valid)
+	assert.Equal(t, "revoked", result.Reason)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMagicLinkService_RequestUploadURLWithToken_QuotaExceeded(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	service := newTestMagicLinkService(db)
+	expectValidToken(mock, "token-1", models.MagicLinkScopePhoto, "active")
+	mock.ExpectExec(`UPDATE magic_links\s+SET upload_count = upload_count \+ 1`).
+		WithArgs("link-1", int64(1024)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	resp, err := service.RequestUploadURLWithToken("token-1", "roof.jpg", 1024, "image/jpeg", models.DocumentTypeContractorPhoto)
+
+	assert.Nil(t, resp)
+	assert.ErrorIs(t, err, ErrUploadQuotaExceeded)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMagicLinkService_ValidateToken_Locked(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	service := newTestMagicLinkService(db)
+	expectValidToken(mock, "token-1", models.MagicLinkScopeFull, "locked")
+
+	result, err := service.ValidateToken("token-1")
+
+	require.NoError(t, err)
+	assert.False(t, result.Valid)
+	assert.Equal(t, "locked", result.Reason)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMagicLinkService_UnlockMagicLink(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	service := newTestMagicLinkService(db)
+	propertyService := NewPropertyService(db)
+	service.claimService = NewClaimService(db, propertyService, NewPolicyService(db, nil, propertyService))
+
+	t.Run("unlocks a locked link", func(t *testing.T) {
+		expectClaimOwnership(mock, "claim-1", "org-1")
+		mock.ExpectQuery(`UPDATE magic_links\s+SET status = 'active', locked_at = NULL, failed_attempt_count = 0`).
+			WithArgs("link-1", "claim-1").
+			WillReturnRows(sqlmock.NewRows([]string{"contractor_name", "contractor_email"}).AddRow("Roofer", "roofer@example.com"))
+		mock.ExpectExec(`INSERT INTO claim_activities`).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		require.NoError(t, service.UnlockMagicLink("claim-1", "link-1", "org-1", "user-1"))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("link that isn't locked", func(t *testing.T) {
+		expectClaimOwnership(mock, "claim-1", "org-1")
+		mock.ExpectQuery(`UPDATE magic_links`).
+			WithArgs("link-1", "claim-1").
+			WillReturnRows(sqlmock.NewRows([]string{"contractor_name", "contractor_email"}))
+		mock.ExpectQuery(`SELECT EXISTS`).
+			WithArgs("link-1", "claim-1").
+			WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+
+		err := service.UnlockMagicLink("claim-1", "link-1", "org-1", "user-1")
+		assert.ErrorIs(t, err, ErrMagicLinkNotLocked)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestMagicLinkService_SendMagicLinkSMS_SkipsOptedOutNumber(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	service := newTestMagicLinkService(db)
+	service.smsService = NewMockSMSService()
+	mock.ExpectQuery(`SELECT EXISTS\(SELECT 1 FROM sms_opt_outs`).
+		WithArgs("+15551234567").
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	mock.ExpectExec(`UPDATE magic_links\s+SET sms_sent`).
+		WithArgs(false, nil, SMSStatusOptedOut, nil, "recipient has opted out of SMS", "link-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	sent, status := service.sendMagicLinkSMS("link-1", SendMagicLinkSMSInput{To: "+15551234567"})
+
+	assert.False(t, sent)
+	require.NotNil(t, status)
+	assert.Equal(t, SMSStatusOptedOut, *status)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"math"
 
 	"github.com/claimcoach/backend/internal/models"
 	"github.com/google/uuid"
@@ -25,11 +26,15 @@ func NewPaymentService(db *sql.DB, claimService *ClaimService) *PaymentService {
 	}
 }
 
-// CreateExpectedPaymentInput contains data for creating an expected payment
+// CreateExpectedPaymentInput contains data for creating an expected payment.
+// When GrossAmount is set on an ACV payment, the expected amount is the gross
+// settlement less the claim's deductible, which carriers withhold from the
+// first payment.
 type CreateExpectedPaymentInput struct {
-	PaymentType    string  `json:"payment_type"`
-	ExpectedAmount float64 `json:"expected_amount"`
-	Notes          *string `json:"notes"`
+	PaymentType    string   `json:"payment_type"`
+	ExpectedAmount float64  `json:"expected_amount"`
+	GrossAmount    *float64 `json:"gross_amount"`
+	Notes          *string  `json:"notes"`
 }
 
 // CreateExpectedPayment creates an expected payment record
@@ -43,42 +48,67 @@ func (s *PaymentService) CreateExpectedPayment(ctx context.Context, claimID, use
 		return "", fmt.Errorf("claim not found")
 	}
 
-	// Generate payment ID
-	paymentID := uuid.New().String()
+	metadata := map[string]interface{}{}
+	if input.GrossAmount != nil {
+		input.ExpectedAmount = *input.GrossAmount
+		if input.PaymentType == models.PaymentTypeACV {
+			resolved, err := resolveClaimDeductible(ctx, s.db, claimID)
+			if err != nil {
+				return "", err
+			}
+			input.ExpectedAmount = math.Max(0, *input.GrossAmount-resolved.Amount)
+			metadata["deductible"] = resolved.Amount
+			metadata["deductible_basis"] = resolved.Basis
+		}
+		metadata["gross_amount"] = *input.GrossAmount
+	}
 
 	// Insert payment with expected status
+	paymentID, err := insertExpectedPayment(ctx, s.db, claimID, input.PaymentType, input.ExpectedAmount, input.Notes)
+	if err != nil {
+		return "", err
+	}
+
+	// Log activity
+	metadata["payment_id"] = paymentID
+	metadata["payment_type"] = input.PaymentType
+	metadata["expected_amount"] = input.ExpectedAmount
+	err = s.logActivity(ctx, claimID, userID, "payment_expected", fmt.Sprintf("Expected %s payment: $%.2f", input.PaymentType, input.ExpectedAmount), metadata)
+	if err != nil {
+		log.Printf("Warning: failed to log activity: %v", err)
+	}
+
+	return paymentID, nil
+}
+
+// sqlExecer runs a statement on the database or within a transaction
+type sqlExecer interface {
+	ExecContext(context.Context, string, ...interface{}) (sql.Result, error)
+}
+
+// insertExpectedPayment adds a payment in expected status and returns its ID
+func insertExpectedPayment(ctx context.Context, exec sqlExecer, claimID, paymentType string, expectedAmount float64, notes *string) (string, error) {
+	paymentID := uuid.New().String()
 	query := `
 		INSERT INTO payments (
 			id, claim_id, payment_type, amount, expected_amount, status, notes
 		) VALUES ($1, $2, $3, $4, $5, $6, $7)
 	`
 
-	_, err = s.db.ExecContext(
+	_, err := exec.ExecContext(
 		ctx,
 		query,
 		paymentID,
 		claimID,
-		input.PaymentType,
+		paymentType,
 		0.0, // Amount starts at 0 until received
-		input.ExpectedAmount,
+		expectedAmount,
 		models.PaymentStatusExpected,
-		input.Notes,
+		notes,
 	)
 	if err != nil {
 		return "", fmt.Errorf("failed to create payment: %w", err)
 	}
-
-	// Log activity
-	metadata := map[string]interface{}{
-		"payment_id":      paymentID,
-		"payment_type":    input.PaymentType,
-		"expected_amount": input.ExpectedAmount,
-	}
-	err = s.logActivity(ctx, claimID, userID, "payment_expected", fmt.Sprintf("Expected %s payment: $%.2f", input.PaymentType, input.ExpectedAmount), metadata)
-	if err != nil {
-		log.Printf("Warning: failed to log activity: %v", err)
-	}
-
 	return paymentID, nil
 }
 
@@ -351,6 +381,14 @@ func (s *PaymentService) GetPaymentSummary(ctx context.Context, claimID, orgID s
 	summary.ACVDelta = summary.TotalACVReceived - summary.ExpectedACV
 	summary.RCVDelta = summary.TotalRCVReceived - summary.ExpectedRCV
 
+	// Show the deductible expected payments are net of
+	resolved, err := resolveClaimDeductible(ctx, s.db, claimID)
+	if err != nil {
+		return nil, err
+	}
+	summary.Deductible = resolved.Amount
+	summary.DeductibleBasis = resolved.Basis
+
 	return summary, nil
 }
 
@@ -0,0 +1,261 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"strings"
+
+	"github.com/claimcoach/backend/internal/models"
+	"github.com/claimcoach/backend/internal/pdfpages"
+	"github.com/claimcoach/backend/internal/prompts"
+)
+
+// Carrier estimates are parsed a page range at a time so that no response
+// outgrows the output budget and comes back as truncated JSON. Each request
+// attaches only its range's pages, copied out of the estimate into a new PDF.
+const (
+	parsePagesPerChunk  = 8
+	parseChunkMaxTokens = 8000
+	// parseMaxPages bounds the pages parsed from one estimate at the 100
+	// pages Claude accepts in a PDF
+	parseMaxPages = 100
+)
+
+// ParseChunk is the outcome of parsing one page range of a carrier estimate,
+// recorded in carrier_estimates.parse_chunks
+type ParseChunk struct {
+	FirstPage int    `json:"first_page"`
+	LastPage  int    `json:"last_page"`
+	Status    string `json:"status"` // models.ParseStatusCompleted or models.ParseStatusFailed
+	LineItems int    `json:"line_items"`
+	// Confidence is the model's 0-1 rating of how completely it read the range
+	Confidence float64 `json:"confidence"`
+	Error      string  `json:"error,omitempty"`
+}
+
+// chunkResponse is the LLM's extraction of one page range
+type chunkResponse struct {
+	LineItems    []LineItem `json:"line_items"`
+	Total        float64    `json:"total"`
+	StatedTotals struct {
+		LineItemTotal        float64 `json:"line_item_total"`
+		ReplacementCostValue float64 `json:"replacement_cost_value"`
+	} `json:"stated_totals"`
+	Confidence float64 `json:"confidence"`
+}
+
+// chunkOutcome is a parsed page range with its response, nil if it failed
+type chunkOutcome struct {
+	chunk    ParseChunk
+	response *chunkResponse
+}
+
+// parseResult is a carrier estimate parsed chunk by chunk. StatedTotal and
+// TotalsMatch are nil when the estimate states no line item total.
+type parseResult struct {
+	Data          *ParsedEstimateData
+	PromptVersion string
+	PageCount     int
+	Chunks        []ParseChunk
+	StatedTotal   *float64
+	TotalsMatch   *bool
+}
+
+// parsePDFWithClaude sends the PDF to Claude for extraction and structuring
+// one page range at a time. A range whose response cannot be parsed is split
+// in half and retried down to single pages. The chunks' line items are merged
+// and checked against the estimate's stated total.
+func (s *PDFParserService) parsePDFWithClaude(ctx context.Context, pdfContent []byte, organizationID string, force bool) (*parseResult, error) {
+	prompt, err := prompts.Get(prompts.CarrierEstimateParse)
+	if err != nil {
+		return nil, err
+	}
+
+	doc, err := pdfpages.Open(pdfContent)
+	if err != nil {
+		return nil, err
+	}
+	pageCount := doc.PageCount()
+	if pageCount > parseMaxPages {
+		return nil, fmt.Errorf("estimate has %d pages; at most %d can be parsed", pageCount, parseMaxPages)
+	}
+
+	var outcomes []chunkOutcome
+	for first := 1; first <= pageCount; first += parsePagesPerChunk {
+		last := min(first+parsePagesPerChunk-1, pageCount)
+		chunks, err := s.parsePageRange(ctx, prompt, pdfContent, doc, organizationID, force, first, last)
+		if err != nil {
+			return nil, err
+		}
+		outcomes = append(outcomes, chunks...)
+	}
+
+	return mergeChunks(outcomes, prompt.ID(), pageCount)
+}
+
+// parsePageRange extracts the line items on pages first to last, splitting
+// the range when the response cannot be parsed. A failed LLM request fails
+// the whole parse, since the next range would most likely fail too.
+func (s *PDFParserService) parsePageRange(ctx context.Context, prompt *prompts.Prompt, pdfContent []byte, doc *pdfpages.Document, organizationID string, force bool, first, last int) ([]chunkOutcome, error) {
+	pages, err := doc.Pages(first, last)
+	if err != nil {
+		return nil, err
+	}
+	rendered, err := prompt.Render(&prompts.CarrierEstimateParseData{FirstPage: first, LastPage: last, PageCount: doc.PageCount()})
+	if err != nil {
+		return nil, err
+	}
+	chunk := ParseChunk{FirstPage: first, LastPage: last}
+
+	// Keyed on the whole estimate and the prompt, which names the range: the
+	// extracted pages carry the time they were written and never hash the same
+	inputHash := pdfInputHash(pdfContent, rendered.User, parseChunkMaxTokens)
+	if !force {
+		if cached, ok := s.cache.Get(ctx, organizationID, llmEndpointParsePDF, rendered.Version, inputHash); ok {
+			if response, err := parseChunkResponse(cached); err == nil {
+				return []chunkOutcome{completedChunk(chunk, response)}, nil
+			}
+		}
+	}
+
+	responseText, err := s.pdfClient.ParsePDF(ctx, pages, rendered.User, parseChunkMaxTokens)
+	if err != nil {
+		return nil, fmt.Errorf("LLM request failed: %w", err)
+	}
+
+	response, err := parseChunkResponse(responseText)
+	if err != nil {
+		if last > first {
+			// Most often the response was cut off; half the pages need half the tokens
+			mid := (first + last) / 2
+			head, err := s.parsePageRange(ctx, prompt, pdfContent, doc, organizationID, force, first, mid)
+			if err != nil {
+				return nil, err
+			}
+			tail, err := s.parsePageRange(ctx, prompt, pdfContent, doc, organizationID, force, mid+1, last)
+			if err != nil {
+				return nil, err
+			}
+			return append(head, tail...), nil
+		}
+		chunk.Status = models.ParseStatusFailed
+		chunk.Error = err.Error()
+		return []chunkOutcome{{chunk: chunk}}, nil
+	}
+	s.cache.Put(ctx, rendered.Version, inputHash, responseText)
+
+	return []chunkOutcome{completedChunk(chunk, response)}, nil
+}
+
+func completedChunk(chunk ParseChunk, response *chunkResponse) chunkOutcome {
+	chunk.Status = models.ParseStatusCompleted
+	chunk.LineItems = len(response.LineItems)
+	chunk.Confidence = math.Max(0, math.Min(1, response.Confidence))
+	return chunkOutcome{chunk: chunk, response: response}
+}
+
+// parseChunkResponse decodes the LLM's extraction of a page range. A range
+// without line items, like a cover or photo page, is not an error.
+func parseChunkResponse(responseText string) (*chunkResponse, error) {
+	// Extract JSON if there's surrounding text
+	jsonStart := strings.Index(responseText, "{")
+	jsonEnd := strings.LastIndex(responseText, "}")
+	if jsonStart >= 0 && jsonEnd > jsonStart {
+		responseText = responseText[jsonStart : jsonEnd+1]
+	}
+
+	var response chunkResponse
+	if err := json.Unmarshal([]byte(responseText), &response); err != nil {
+		return nil, fmt.Errorf("failed to parse LLM response as JSON: %w", err)
+	}
+	return &response, nil
+}
+
+// mergeChunks combines the chunks' line items in page order and cross-checks
+// their sum against the line item total the estimate states. Items reported
+// outside their chunk's range are dropped, since that range's own chunk
+// extracts them, and an item repeated on either side of a chunk boundary (a
+// line that continues across the page break) is kept once.
+func mergeChunks(outcomes []chunkOutcome, promptVersion string, pageCount int) (*parseResult, error) {
+	result := &parseResult{Data: &ParsedEstimateData{LineItems: []LineItem{}}, PromptVersion: promptVersion, PageCount: pageCount}
+
+	var statedLineItemTotal, statedRCV float64
+	var firstErr string
+	// The previous chunk's items, to catch repeats across the boundary
+	var previous map[string]int
+	for _, o := range outcomes {
+		result.Chunks = append(result.Chunks, o.chunk)
+		if o.response == nil {
+			if firstErr == "" {
+				firstErr = o.chunk.Error
+			}
+			previous = nil
+			continue
+		}
+
+		current := map[string]int{}
+		for _, item := range o.response.LineItems {
+			if item.Page > 0 && (item.Page < o.chunk.FirstPage || item.Page > o.chunk.LastPage) {
+				continue
+			}
+			key := lineItemKey(item)
+			if page, ok := previous[key]; ok && (page == 0 || item.Page == 0 || item.Page-page <= 1) {
+				delete(previous, key)
+				continue
+			}
+			current[key] = item.Page
+			result.Data.LineItems = append(result.Data.LineItems, item)
+		}
+		previous = current
+
+		if o.response.StatedTotals.LineItemTotal > 0 {
+			statedLineItemTotal = o.response.StatedTotals.LineItemTotal
+		}
+		if o.response.StatedTotals.ReplacementCostValue > 0 {
+			statedRCV = o.response.StatedTotals.ReplacementCostValue
+		}
+	}
+
+	if len(result.Data.LineItems) == 0 {
+		if firstErr != "" {
+			return nil, fmt.Errorf("%s", firstErr)
+		}
+		return nil, fmt.Errorf("no line items extracted from document")
+	}
+
+	var sum float64
+	for _, item := range result.Data.LineItems {
+		sum += item.Total
+	}
+	sum = math.Round(sum*100) / 100
+
+	result.Data.Total = sum
+	if statedRCV > 0 {
+		result.Data.Total = statedRCV
+	}
+	if statedLineItemTotal > 0 {
+		match := totalsMatch(sum, statedLineItemTotal)
+		result.StatedTotal = &statedLineItemTotal
+		result.TotalsMatch = &match
+		if !match {
+			log.Printf("Warning: carrier estimate line items sum to $%.2f but the estimate states $%.2f", sum, statedLineItemTotal)
+		}
+	}
+
+	return result, nil
+}
+
+// lineItemKey identifies a line item regardless of the page it was read from
+func lineItemKey(item LineItem) string {
+	return fmt.Sprintf("%s|%g|%s|%.2f", strings.ToLower(strings.Join(strings.Fields(item.Description), " ")),
+		item.Quantity, strings.ToUpper(item.Unit), item.Total)
+}
+
+// totalsMatch reports whether the summed line items agree with a stated
+// total to within a dollar or half a percent, allowing for rounding
+func totalsMatch(sum, stated float64) bool {
+	return math.Abs(sum-stated) <= math.Max(1, stated*0.005)
+}
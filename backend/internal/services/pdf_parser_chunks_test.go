@@ -0,0 +1,207 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"testing"
+
+	"github.com/claimcoach/backend/internal/llm"
+	"github.com/claimcoach/backend/internal/models"
+	"github.com/claimcoach/backend/internal/pdfpages"
+	"github.com/go-pdf/fpdf"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var pageRangePattern = regexp.MustCompile(`pages (\d+) to (\d+)`)
+
+// estimatePDF builds a PDF of the given number of pages
+func estimatePDF(t *testing.T, pages int) []byte {
+	pdf := fpdf.New("P", "mm", "Letter", "")
+	pdf.SetFont("Helvetica", "", 12)
+	for page := 1; page <= pages; page++ {
+		pdf.AddPage()
+		pdf.Cell(40, 10, fmt.Sprintf("Estimate page %d", page))
+	}
+	var buf bytes.Buffer
+	require.NoError(t, pdf.Output(&buf))
+	return buf.Bytes()
+}
+
+// pageRange reads the page range a parse prompt asks for and checks that
+// the attached PDF holds just those pages
+func pageRange(t *testing.T, prompt string, pdf []byte) (int, int) {
+	m := pageRangePattern.FindStringSubmatch(prompt)
+	require.NotNil(t, m, "prompt names no page range")
+	first, _ := strconv.Atoi(m[1])
+	last, _ := strconv.Atoi(m[2])
+
+	doc, err := pdfpages.Open(pdf)
+	require.NoError(t, err)
+	assert.Equal(t, last-first+1, doc.PageCount(), "pages attached for %d-%d", first, last)
+	return first, last
+}
+
+func TestParsePDFWithClaude_ChunksLongEstimates(t *testing.T) {
+	var ranges []string
+	service := &PDFParserService{
+		pdfClient: &MockPDFParserClient{
+			ParsePDFFunc: func(ctx context.Context, pdf []byte, prompt string, maxTokens int) (string, error) {
+				first, last := pageRange(t, prompt, pdf)
+				ranges = append(ranges, fmt.Sprintf("%d-%d", first, last))
+				assert.Equal(t, parseChunkMaxTokens, maxTokens)
+				switch first {
+				case 1:
+					// Ridge cap runs over the page break into the next chunk, and
+					// a page 9 item leaked into this range
+					return `{"line_items": [
+						{"page": 3, "description": "Remove shingles", "quantity": 19, "unit": "SQ", "total": 1900},
+						{"page": 8, "description": "Ridge cap", "quantity": 60, "unit": "LF", "total": 270},
+						{"page": 9, "description": "Drip edge", "quantity": 120, "unit": "LF", "total": 360}
+					], "confidence": 0.9}`, nil
+				case 9:
+					return `{"line_items": [
+						{"page": 9, "description": "Ridge  cap", "quantity": 60, "unit": "lf", "total": 270},
+						{"page": 9, "description": "Drip edge", "quantity": 120, "unit": "LF", "total": 360}
+					], "confidence": 0.8}`, nil
+				default:
+					return `{"line_items": [
+						{"page": 18, "description": "Gutter", "quantity": 40, "unit": "LF", "total": 400}
+					], "stated_totals": {"line_item_total": 2930, "replacement_cost_value": 3200}, "confidence": 1.4}`, nil
+				}
+			},
+		},
+	}
+
+	result, err := service.parsePDFWithClaude(context.Background(), estimatePDF(t, 20), "org-1", false)
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"1-8", "9-16", "17-20"}, ranges)
+	assert.Equal(t, 20, result.PageCount)
+
+	var descriptions []string
+	for _, item := range result.Data.LineItems {
+		descriptions = append(descriptions, item.Description)
+	}
+	assert.Equal(t, []string{"Remove shingles", "Ridge cap", "Drip edge", "Gutter"}, descriptions)
+	assert.Equal(t, 3200.0, result.Data.Total)
+
+	require.NotNil(t, result.StatedTotal)
+	assert.Equal(t, 2930.0, *result.StatedTotal)
+	require.NotNil(t, result.TotalsMatch)
+	assert.True(t, *result.TotalsMatch)
+
+	require.Len(t, result.Chunks, 3)
+	assert.Equal(t, ParseChunk{FirstPage: 1, LastPage: 8, Status: models.ParseStatusCompleted, LineItems: 3, Confidence: 0.9}, result.Chunks[0])
+	assert.Equal(t, 1.0, result.Chunks[2].Confidence)
+}
+
+func TestParsePDFWithClaude_SplitsTruncatedRanges(t *testing.T) {
+	var ranges []string
+	service := &PDFParserService{
+		pdfClient: &MockPDFParserClient{
+			ParsePDFFunc: func(ctx context.Context, pdf []byte, prompt string, maxTokens int) (string, error) {
+				first, last := pageRange(t, prompt, pdf)
+				ranges = append(ranges, fmt.Sprintf("%d-%d", first, last))
+				switch {
+				case last-first >= 2:
+					// Too many items for the output budget: cut off mid-array
+					return `{"line_items": [{"page": 1, "description": "Remove`, nil
+				case first == 7 && last == 7:
+					return `unreadable`, nil
+				case first == 7:
+					return `{"line_items": [{"page": 7, "description": "Cut off`, nil
+				}
+				return fmt.Sprintf(`{"line_items": [{"page": %d, "description": "Item %d-%d", "quantity": 1, "unit": "EA", "total": 100}], "confidence": 0.9}`, first, first, last), nil
+			},
+		},
+	}
+
+	result, err := service.parsePDFWithClaude(context.Background(), estimatePDF(t, 8), "org-1", false)
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"1-8", "1-4", "1-2", "3-4", "5-8", "5-6", "7-8", "7-7", "8-8"}, ranges)
+	assert.Len(t, result.Data.LineItems, 4)
+	assert.Equal(t, 400.0, result.Data.Total)
+	assert.Nil(t, result.TotalsMatch, "no stated total to check against")
+
+	require.Len(t, result.Chunks, 5)
+	failed := result.Chunks[3]
+	assert.Equal(t, 7, failed.FirstPage)
+	assert.Equal(t, models.ParseStatusFailed, failed.Status)
+	assert.Contains(t, failed.Error, "failed to parse LLM response as JSON")
+}
+
+func TestParsePDFWithClaude_FlagsTotalsMismatch(t *testing.T) {
+	service := &PDFParserService{
+		pdfClient: &MockPDFParserClient{
+			ParsePDFFunc: func(ctx context.Context, pdf []byte, prompt string, maxTokens int) (string, error) {
+				return `{"line_items": [{"page": 1, "description": "Remove shingles", "quantity": 19, "unit": "SQ", "total": 1900}],
+					"stated_totals": {"line_item_total": 5400}}`, nil
+			},
+		},
+	}
+
+	result, err := service.parsePDFWithClaude(context.Background(), estimatePDF(t, 2), "org-1", false)
+
+	require.NoError(t, err)
+	assert.Equal(t, 1900.0, result.Data.Total)
+	require.NotNil(t, result.TotalsMatch)
+	assert.False(t, *result.TotalsMatch)
+	assert.Equal(t, 5400.0, *result.StatedTotal)
+}
+
+func TestParsePDFWithClaude_RejectsOversizedEstimates(t *testing.T) {
+	calls := 0
+	service := &PDFParserService{
+		pdfClient: &MockPDFParserClient{
+			ParsePDFFunc: func(ctx context.Context, pdf []byte, prompt string, maxTokens int) (string, error) {
+				calls++
+				return `{"line_items": []}`, nil
+			},
+		},
+	}
+
+	_, err := service.parsePDFWithClaude(context.Background(), estimatePDF(t, parseMaxPages+1), "org-1", false)
+
+	assert.ErrorContains(t, err, "estimate has 101 pages; at most 100 can be parsed")
+	assert.Equal(t, 0, calls)
+}
+
+func TestParsePDFWithClaude_RejectsUnreadablePDF(t *testing.T) {
+	calls := 0
+	service := &PDFParserService{
+		pdfClient: &MockPDFParserClient{
+			ParsePDFFunc: func(ctx context.Context, pdf []byte, prompt string, maxTokens int) (string, error) {
+				calls++
+				return `{"line_items": []}`, nil
+			},
+		},
+	}
+
+	_, err := service.parsePDFWithClaude(context.Background(), []byte("%PDF-1.4 truncated"), "org-1", false)
+
+	assert.ErrorContains(t, err, "failed to read PDF")
+	assert.Equal(t, 0, calls)
+}
+
+func TestParsePDFWithClaude_LLMErrorFailsParse(t *testing.T) {
+	service := &PDFParserService{
+		pdfClient: &MockPDFParserClient{
+			ParsePDFFunc: func(ctx context.Context, pdf []byte, prompt string, maxTokens int) (string, error) {
+				if first, _ := pageRange(t, prompt, pdf); first == 9 {
+					return "", llm.ErrOverloaded
+				}
+				return `{"line_items": [{"page": 1, "description": "Remove shingles", "total": 1900}]}`, nil
+			},
+		},
+	}
+
+	_, err := service.parsePDFWithClaude(context.Background(), estimatePDF(t, 16), "org-1", false)
+
+	assert.True(t, errors.Is(err, llm.ErrOverloaded))
+}
@@ -7,7 +7,6 @@ import (
 	"fmt"
 	"io"
 	"net/http"
-	"strings"
 	"time"
 
 	"github.com/claimcoach/backend/internal/models"
@@ -35,20 +34,23 @@ type PDFParserService struct {
 	storage     StorageClient
 	pdfClient   PDFParserClient
 	claimGetter ClaimGetter
+	cache       *LLMCache // optional: without it every parse calls the LLM
 }
 
 // NewPDFParserService creates a new PDF parser service
-func NewPDFParserService(db *sql.DB, storageClient *storage.SupabaseStorage, pdfClient PDFParserClient, claimService *ClaimService) *PDFParserService {
+func NewPDFParserService(db *sql.DB, storageClient *storage.SupabaseStorage, pdfClient PDFParserClient, claimService *ClaimService, cache *LLMCache) *PDFParserService {
 	return &PDFParserService{
 		db:          db,
 		storage:     storageClient,
 		pdfClient:   pdfClient,
 		claimGetter: claimService,
+		cache:       cache,
 	}
 }
 
 // LineItem represents a parsed line item from the carrier estimate
 type LineItem struct {
+	Page        int     `json:"page,omitempty"` // 0 when the page is unknown
 	Description string  `json:"description"`
 	Quantity    float64 `json:"quantity"`
 	Unit        string  `json:"unit"`
@@ -57,14 +59,17 @@ type LineItem struct {
 	Category    string  `json:"category"`
 }
 
-// ParsedEstimateData represents the structured data extracted from a PDF
+// ParsedEstimateData represents the structured data extracted from a PDF.
+// Total is the replacement cost value the estimate states, or the sum of the
+// line items when none was found.
 type ParsedEstimateData struct {
 	LineItems []LineItem `json:"line_items"`
 	Total     float64    `json:"total"`
 }
 
-// ParseCarrierEstimate downloads and parses a carrier estimate PDF
-func (s *PDFParserService) ParseCarrierEstimate(ctx context.Context, carrierEstimateID string, organizationID string) error {
+// ParseCarrierEstimate downloads and parses a carrier estimate PDF. A PDF parsed
+// before is read from the LLM response cache unless force is set.
+func (s *PDFParserService) ParseCarrierEstimate(ctx context.Context, carrierEstimateID string, organizationID string, force bool) error {
 	// Get the carrier estimate record
 	estimate, err := s.getCarrierEstimate(ctx, carrierEstimateID)
 	if err != nil {
@@ -90,8 +95,8 @@ func (s *PDFParserService) ParseCarrierEstimate(ctx context.Context, carrierEsti
 		return fmt.Errorf("failed to download PDF: %w", err)
 	}
 
-	// Parse PDF using Claude (extract and structure in one step)
-	parsedData, err := s.parsePDFWithClaude(ctx, pdfContent)
+	// Parse PDF using Claude (extract and structure in one step), a page range at a time
+	result, err := s.parsePDFWithClaude(ctx, pdfContent, organizationID, force)
 	if err != nil {
 		parseError := fmt.Sprintf("Failed to parse PDF: %v", err)
 		s.updateParseStatus(ctx, carrierEstimateID, models.ParseStatusFailed, &parseError)
@@ -99,7 +104,7 @@ func (s *PDFParserService) ParseCarrierEstimate(ctx context.Context, carrierEsti
 	}
 
 	// Convert parsed data to JSON string
-	parsedDataJSON, err := json.Marshal(parsedData)
+	parsedDataJSON, err := json.Marshal(result.Data)
 	if err != nil {
 		parseError := fmt.Sprintf("Failed to marshal parsed data: %v", err)
 		s.updateParseStatus(ctx, carrierEstimateID, models.ParseStatusFailed, &parseError)
@@ -108,7 +113,7 @@ func (s *PDFParserService) ParseCarrierEstimate(ctx context.Context, carrierEsti
 
 	// Update database with parsed data
 	parsedDataStr := string(parsedDataJSON)
-	if err := s.updateParsedData(ctx, carrierEstimateID, &parsedDataStr); err != nil {
+	if err := s.updateParsedData(ctx, carrierEstimateID, &parsedDataStr, result); err != nil {
 		parseError := fmt.Sprintf("Failed to save parsed data: %v", err)
 		s.updateParseStatus(ctx, carrierEstimateID, models.ParseStatusFailed, &parseError)
 		return fmt.Errorf("failed to update parsed data: %w", err)
@@ -117,56 +122,6 @@ func (s *PDFParserService) ParseCarrierEstimate(ctx context.Context, carrierEsti
 	return nil
 }
 
-// parsePDFWithClaude sends the PDF directly to Claude for extraction and structuring
-func (s *PDFParserService) parsePDFWithClaude(ctx context.Context, pdfContent []byte) (*ParsedEstimateData, error) {
-	prompt := `Extract all line items from this carrier estimate PDF.
-
-Return a JSON object with this exact structure:
-{
-  "line_items": [
-    {
-      "description": "string",
-      "quantity": number,
-      "unit": "string",
-      "unit_cost": number,
-      "total": number,
-      "category": "string"
-    }
-  ],
-  "total": number
-}
-
-Rules:
-- Extract ALL line items, not just summaries
-- Use 0 for missing numeric values
-- Use empty string for missing text values
-- category should be the work type (e.g., Roofing, Siding, Exterior, Interior, etc.)
-- Return ONLY valid JSON, no additional text or explanation`
-
-	responseText, err := s.pdfClient.ParsePDF(ctx, pdfContent, prompt, 4000)
-	if err != nil {
-		return nil, fmt.Errorf("LLM request failed: %w", err)
-	}
-
-	// Extract JSON if there's surrounding text
-	jsonStart := strings.Index(responseText, "{")
-	jsonEnd := strings.LastIndex(responseText, "}")
-	if jsonStart >= 0 && jsonEnd > jsonStart {
-		responseText = responseText[jsonStart : jsonEnd+1]
-	}
-
-	var parsedData ParsedEstimateData
-	if err := json.Unmarshal([]byte(responseText), &parsedData); err != nil {
-		return nil, fmt.Errorf("failed to parse LLM response as JSON: %w (response: %s)", err, responseText)
-	}
-
-	if len(parsedData.LineItems) == 0 {
-		return nil, fmt.Errorf("no line items extracted from document")
-	}
-
-	return &parsedData, nil
-}
-
 // getCarrierEstimate retrieves a carrier estimate by ID
 func (s *PDFParserService) getCarrierEstimate(ctx context.Context, estimateID string) (*models.CarrierEstimate, error) {
 	query := `
@@ -218,19 +173,31 @@ func (s *PDFParserService) updateParseStatus(ctx context.Context, estimateID str
 	return nil
 }
 
-// updateParsedData updates the parsed data and marks parsing as completed
-func (s *PDFParserService) updateParsedData(ctx context.Context, estimateID string, parsedData *string) error {
+// updateParsedData updates the parsed data, the prompt version and chunks
+// that produced it and the totals cross-check, and marks parsing as completed
+func (s *PDFParserService) updateParsedData(ctx context.Context, estimateID string, parsedData *string, result *parseResult) error {
+	chunks, err := json.Marshal(result.Chunks)
+	if err != nil {
+		return fmt.Errorf("failed to marshal parse chunks: %w", err)
+	}
+
 	query := `
 		UPDATE carrier_estimates
 		SET parsed_data = $1,
 			parse_status = $2,
 			parse_error = NULL,
-			parsed_at = $3
-		WHERE id = $4
+			parsed_at = $3,
+			parse_prompt_version = $4,
+			page_count = $5,
+			parse_chunks = $6,
+			stated_total = $7,
+			totals_match = $8
+		WHERE id = $9
 	`
 
 	now := time.Now()
-	_, err := s.db.ExecContext(ctx, query, parsedData, models.ParseStatusCompleted, now, estimateID)
+	_, err = s.db.ExecContext(ctx, query, parsedData, models.ParseStatusCompleted, now, result.PromptVersion,
+		result.PageCount, string(chunks), result.StatedTotal, result.TotalsMatch, estimateID)
 	if err != nil {
 		return fmt.Errorf("failed to update parsed data: %w", err)
 	}
@@ -158,11 +158,21 @@ func TestUpdateParsedData(t *testing.T) {
 	parsedData := `{"line_items": []}`
 
 	t.Run("success", func(t *testing.T) {
+		pageCount, statedTotal, totalsMatch := 12, 12350.0, true
+		result := &parseResult{
+			PromptVersion: "carrier_estimate_parse/v2",
+			PageCount:     pageCount,
+			Chunks:        []ParseChunk{{FirstPage: 1, LastPage: 8, Status: models.ParseStatusCompleted, LineItems: 2, Confidence: 0.9}},
+			StatedTotal:   &statedTotal,
+			TotalsMatch:   &totalsMatch,
+		}
 		mock.ExpectExec("UPDATE carrier_estimates").
-			WithArgs(&parsedData, models.ParseStatusCompleted, sqlmock.AnyArg(), estimateID).
+			WithArgs(&parsedData, models.ParseStatusCompleted, sqlmock.AnyArg(), "carrier_estimate_parse/v2",
+				pageCount, `[{"first_page":1,"last_page":8,"status":"completed","line_items":2,"confidence":0.9}]`,
+				&statedTotal, &totalsMatch, estimateID).
 			WillReturnResult(sqlmock.NewResult(0, 1))
 
-		err := service.updateParsedData(context.Background(), estimateID, &parsedData)
+		err := service.updateParsedData(context.Background(), estimateID, &parsedData, result)
 		assert.NoError(t, err)
 		assert.NoError(t, mock.ExpectationsWereMet())
 	})
@@ -173,12 +183,12 @@ func TestParsePDFWithClaude(t *testing.T) {
 	assert.NoError(t, err)
 	defer db.Close()
 
-	pdfContent := []byte("%PDF-1.4 fake content")
+	pdfContent := estimatePDF(t, 2)
 
 	t.Run("success", func(t *testing.T) {
 		mockClient := &MockPDFParserClient{
 			ParsePDFFunc: func(ctx context.Context, pdf []byte, prompt string, maxTokens int) (string, error) {
-				assert.Equal(t, pdfContent, pdf)
+				pageRange(t, prompt, pdf)
 				return `{
 					"line_items": [
 						{
@@ -208,8 +218,10 @@ func TestParsePDFWithClaude(t *testing.T) {
 			pdfClient: mockClient,
 		}
 
-		parsedData, err := service.parsePDFWithClaude(context.Background(), pdfContent)
+		result, err := service.parsePDFWithClaude(context.Background(), pdfContent, "org-123", false)
 		assert.NoError(t, err)
+		assert.Equal(t, "carrier_estimate_parse/v2", result.PromptVersion)
+		parsedData := result.Data
 		assert.NotNil(t, parsedData)
 		assert.Len(t, parsedData.LineItems, 2)
 		assert.Equal(t, "Remove shingles", parsedData.LineItems[0].Description)
@@ -229,9 +241,9 @@ func TestParsePDFWithClaude(t *testing.T) {
 			pdfClient: mockClient,
 		}
 
-		parsedData, err := service.parsePDFWithClaude(context.Background(), pdfContent)
+		result, err := service.parsePDFWithClaude(context.Background(), pdfContent, "org-123", false)
 		assert.Error(t, err)
-		assert.Nil(t, parsedData)
+		assert.Nil(t, result)
 		assert.Contains(t, err.Error(), "LLM request failed")
 	})
 
@@ -247,9 +259,9 @@ func TestParsePDFWithClaude(t *testing.T) {
 			pdfClient: mockClient,
 		}
 
-		parsedData, err := service.parsePDFWithClaude(context.Background(), pdfContent)
+		result, err := service.parsePDFWithClaude(context.Background(), pdfContent, "org-123", false)
 		assert.Error(t, err)
-		assert.Nil(t, parsedData)
+		assert.Nil(t, result)
 		assert.Contains(t, err.Error(), "failed to parse LLM response as JSON")
 	})
 
@@ -265,9 +277,9 @@ func TestParsePDFWithClaude(t *testing.T) {
 			pdfClient: mockClient,
 		}
 
-		parsedData, err := service.parsePDFWithClaude(context.Background(), pdfContent)
+		result, err := service.parsePDFWithClaude(context.Background(), pdfContent, "org-123", false)
 		assert.Error(t, err)
-		assert.Nil(t, parsedData)
+		assert.Nil(t, result)
 		assert.Contains(t, err.Error(), "no line items extracted")
 	})
 }
@@ -311,7 +323,7 @@ func TestParseCarrierEstimate_AuthorizationFlow(t *testing.T) {
 			claimGetter: ClaimGetter(mockClaimService),
 		}
 
-		err := service.ParseCarrierEstimate(context.Background(), estimateID, organizationID)
+		err := service.ParseCarrierEstimate(context.Background(), estimateID, organizationID, false)
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "unauthorized access")
 		assert.NoError(t, mock.ExpectationsWereMet())
@@ -46,7 +46,7 @@ func TestPhase6IntegrationSuccess(t *testing.T) {
 		FasciaPaint:       true,
 	}
 
-	scopeSheet, err := scopeService.CreateScopeSheet(ctx, claimID, scopeInput)
+	scopeSheet, err := scopeService.CreateScopeSheet(ctx, claimID, nil, scopeInput)
 	require.NoError(t, err)
 	require.NotNil(t, scopeSheet)
 	assert.NotEmpty(t, scopeSheet.ID)
@@ -257,10 +257,10 @@ Property Manager`
 		},
 	}, nil).Once()
 
-	auditService := NewAuditService(db, mockLLM, scopeService)
+	auditService := NewAuditService(db, mockLLM, scopeService, nil, nil, nil)
 
 	// Generate industry estimate
-	reportID, err := auditService.GenerateIndustryEstimate(ctx, claimID, userID, orgID)
+	reportID, err := auditService.GenerateIndustryEstimate(ctx, claimID, userID, orgID, false)
 	require.NoError(t, err)
 	require.NotEmpty(t, reportID)
 
@@ -407,7 +407,7 @@ func TestPhase6OwnershipChecks(t *testing.T) {
 	scopeService := NewScopeSheetService(db)
 	roofType := "metal"
 	scopeInput := CreateScopeSheetInput{RoofType: &roofType}
-	_, err := scopeService.CreateScopeSheet(ctx, claim1ID, scopeInput)
+	_, err := scopeService.CreateScopeSheet(ctx, claim1ID, nil, scopeInput)
 	require.NoError(t, err)
 
 	// Create audit report for org1
@@ -444,9 +444,9 @@ func TestPhase6OwnershipChecks(t *testing.T) {
 			TotalTokens:      300,
 		},
 	}, nil)
-	auditService := NewAuditService(db, mockLLM, scopeService)
+	auditService := NewAuditService(db, mockLLM, scopeService, nil, nil, nil)
 
-	reportID, err := auditService.GenerateIndustryEstimate(ctx, claim1ID, user1ID, org1ID)
+	reportID, err := auditService.GenerateIndustryEstimate(ctx, claim1ID, user1ID, org1ID, false)
 	require.NoError(t, err)
 
 	// Create org2
@@ -488,17 +488,17 @@ func TestPhase6ErrorHandling(t *testing.T) {
 
 	scopeService := NewScopeSheetService(db)
 	mockLLM := new(MockLLMClient)
-	auditService := NewAuditService(db, mockLLM, scopeService)
+	auditService := NewAuditService(db, mockLLM, scopeService, nil, nil, nil)
 
 	// Test: Generate industry estimate without scope sheet (should fail)
-	_, err := auditService.GenerateIndustryEstimate(ctx, claimID, userID, orgID)
+	_, err := auditService.GenerateIndustryEstimate(ctx, claimID, userID, orgID, false)
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "scope sheet not found")
 
 	// Create scope sheet
 	roofType := "tile"
 	scopeInput := CreateScopeSheetInput{RoofType: &roofType}
-	_, err = scopeService.CreateScopeSheet(ctx, claimID, scopeInput)
+	_, err = scopeService.CreateScopeSheet(ctx, claimID, nil, scopeInput)
 	require.NoError(t, err)
 
 	// Test: Generate estimate with invalid JSON response
@@ -534,7 +534,7 @@ func TestPhase6ErrorHandling(t *testing.T) {
 		},
 	}, nil).Once()
 
-	_, err = auditService.GenerateIndustryEstimate(ctx, claimID, userID, orgID)
+	_, err = auditService.GenerateIndustryEstimate(ctx, claimID, userID, orgID, false)
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "invalid JSON")
 
@@ -572,7 +572,7 @@ func TestPhase6ErrorHandling(t *testing.T) {
 		},
 	}, nil).Once()
 
-	reportID, err := auditService.GenerateIndustryEstimate(ctx, claimID, userID, orgID)
+	reportID, err := auditService.GenerateIndustryEstimate(ctx, claimID, userID, orgID, false)
 	require.NoError(t, err)
 
 	// Try to compare without carrier estimate
@@ -0,0 +1,353 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/claimcoach/backend/internal/models"
+	"github.com/claimcoach/backend/internal/prompts"
+	"github.com/claimcoach/backend/internal/storage"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrPolicyQuestionNotFound = errors.New("policy question not found")
+	ErrInvalidPolicyQuestion  = errors.New("invalid policy question")
+	ErrPolicyAnswerNotCited   = errors.New("only answers quoting the policy can be saved as notes")
+)
+
+const (
+	// policyQAMaxTokens bounds an answer, which is a short JSON object
+	policyQAMaxTokens = 1500
+	// policyQAHistoryTurns is how many earlier turns of the conversation are
+	// sent with a question so follow-ups can refer back to them
+	policyQAHistoryTurns = 6
+	// policyQuestionMaxChars keeps a question to a reasonable prompt size
+	policyQuestionMaxChars = 2000
+)
+
+// policyNotAnsweredText replaces any answer the policy's text does not support
+const policyNotAnsweredText = "I couldn't find a provision in this policy that answers that question. Check the declarations page and endorsements, or confirm with the carrier."
+
+// PolicyQAService answers a PM's questions about a claim's policy from the
+// policy PDF, keeping the conversation per claim
+type PolicyQAService struct {
+	db                  *sql.DB
+	storage             StorageClient
+	llmClient           PDFParserClient
+	claimService        *ClaimService
+	policyService       *PolicyService
+	documentTextService *DocumentTextService
+	httpClient          *http.Client
+}
+
+// NewPolicyQAService creates a new PolicyQAService
+func NewPolicyQAService(db *sql.DB, storageClient *storage.SupabaseStorage, llmClient PDFParserClient, claimService *ClaimService, policyService *PolicyService, documentTextService *DocumentTextService) *PolicyQAService {
+	return &PolicyQAService{
+		db:                  db,
+		storage:             storageClient,
+		llmClient:           llmClient,
+		claimService:        claimService,
+		policyService:       policyService,
+		documentTextService: documentTextService,
+		httpClient:          &http.Client{Timeout: 60 * time.Second},
+	}
+}
+
+// AskPolicyQuestionInput is a PM's question about a claim's policy
+type AskPolicyQuestionInput struct {
+	Question string `json:"question" binding:"required"`
+}
+
+// policyAnswer is the LLM's response to a question
+type policyAnswer struct {
+	Answer    string                  `json:"answer"`
+	Citations []models.PolicyCitation `json:"citations"`
+}
+
+const policyQuestionColumns = `id, claim_id, policy_id, user_id, question, answer, citations, answered,
+	note_activity_id, prompt_version, created_at`
+
+func scanPolicyQuestion(row interface{ Scan(...interface{}) error }) (*models.PolicyQuestion, error) {
+	var q models.PolicyQuestion
+	var citations []byte
+	err := row.Scan(
+		&q.ID, &q.ClaimID, &q.PolicyID, &q.UserID, &q.Question, &q.Answer, &citations, &q.Answered,
+		&q.NoteActivityID, &q.PromptVersion, &q.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(citations, &q.Citations); err != nil {
+		return nil, fmt.Errorf("invalid citations: %w", err)
+	}
+	return &q, nil
+}
+
+// AskPolicyQuestion answers a question from the claim's policy PDF and its
+// confirmed terms, and records the turn in the claim's conversation. Quotes are
+// checked against the policy's transcribed text and dropped when the cited page
+// doesn't contain them; answers left with no quote are replaced with a
+// not-found answer.
+func (s *PolicyQAService) AskPolicyQuestion(ctx context.Context, claimID, organizationID, userID string, input AskPolicyQuestionInput) (*models.PolicyQuestion, error) {
+	question := strings.TrimSpace(input.Question)
+	if question == "" {
+		return nil, fmt.Errorf("%w: question is required", ErrInvalidPolicyQuestion)
+	}
+	if len(question) > policyQuestionMaxChars {
+		return nil, fmt.Errorf("%w: question must be at most %d characters", ErrInvalidPolicyQuestion, policyQuestionMaxChars)
+	}
+
+	claim, err := s.claimService.GetClaim(claimID, organizationID)
+	if err != nil {
+		return nil, err
+	}
+	policy, err := s.policyService.GetPolicy(claim.PropertyID, organizationID)
+	if err != nil {
+		return nil, err
+	}
+	if policy.PolicyPdfUrl == nil || *policy.PolicyPdfUrl == "" {
+		return nil, ErrNoPolicyPDF
+	}
+
+	history, err := s.loadPolicyQuestions(ctx, claimID)
+	if err != nil {
+		return nil, err
+	}
+	if len(history) > policyQAHistoryTurns {
+		history = history[len(history)-policyQAHistoryTurns:]
+	}
+	terms, err := confirmedPolicyTerms(ctx, s.db, claimID)
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := readPolicyPDF(ctx, s.storage, s.httpClient, *policy.PolicyPdfUrl)
+	if err != nil {
+		return nil, err
+	}
+	pages, err := s.documentTextService.GetPolicyPages(ctx, policy.ID, *policy.PolicyPdfUrl, content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract policy text: %w", err)
+	}
+	rendered, err := prompts.Render(prompts.PolicyQA, policyQuestionPromptData(terms, history, question))
+	if err != nil {
+		return nil, err
+	}
+	response, err := s.llmClient.ParsePDF(ctx, content, rendered.User, policyQAMaxTokens)
+	if err != nil {
+		return nil, fmt.Errorf("LLM request failed: %w", err)
+	}
+	answer, err := parsePolicyAnswer(response)
+	if err != nil {
+		return nil, err
+	}
+	answer.Citations = verifyPolicyCitations(answer.Citations, pages)
+	answered := len(answer.Citations) > 0
+	if !answered {
+		answer.Answer = policyNotAnsweredText
+	}
+
+	citationsJSON, _ := json.Marshal(answer.Citations)
+	saved, err := scanPolicyQuestion(s.db.QueryRowContext(ctx, `
+		INSERT INTO policy_questions (id, claim_id, policy_id, user_id, question, answer, citations, answered, prompt_version, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING `+policyQuestionColumns,
+		uuid.New().String(), claimID, policy.ID, userID, question, answer.Answer, string(citationsJSON), answered, rendered.Version, time.Now(),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to save policy question: %w", err)
+	}
+	return saved, nil
+}
+
+// ListPolicyQuestions returns a claim's policy Q&A conversation, oldest first
+func (s *PolicyQAService) ListPolicyQuestions(ctx context.Context, claimID, organizationID string) ([]models.PolicyQuestion, error) {
+	if _, err := s.claimService.GetClaim(claimID, organizationID); err != nil {
+		return nil, err
+	}
+	return s.loadPolicyQuestions(ctx, claimID)
+}
+
+// SavePolicyAnswerAsNote adds an answer and its quotes to the claim as a
+// comment activity. Saving an answer again returns it unchanged.
+func (s *PolicyQAService) SavePolicyAnswerAsNote(ctx context.Context, claimID, questionID, organizationID, userID string) (*models.PolicyQuestion, error) {
+	if _, err := s.claimService.GetClaim(claimID, organizationID); err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	q, err := scanPolicyQuestion(tx.QueryRowContext(ctx, `
+		SELECT `+policyQuestionColumns+`
+		FROM policy_questions
+		WHERE id = $1 AND claim_id = $2
+		FOR UPDATE`,
+		questionID, claimID,
+	))
+	if err == sql.ErrNoRows {
+		return nil, ErrPolicyQuestionNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get policy question: %w", err)
+	}
+	if q.NoteActivityID != nil {
+		return q, nil
+	}
+	if !q.Answered {
+		return nil, ErrPolicyAnswerNotCited
+	}
+
+	metadata, _ := json.Marshal(map[string]interface{}{
+		"policy_question_id": q.ID,
+		"citations":          q.Citations,
+	})
+	activityID := uuid.New().String()
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO claim_activities (id, claim_id, user_id, activity_type, description, metadata, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		activityID, claimID, userID, "comment", formatPolicyAnswerNote(q), string(metadata), time.Now(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save claim note: %w", err)
+	}
+	_, err = tx.ExecContext(ctx, `UPDATE policy_questions SET note_activity_id = $1 WHERE id = $2`, activityID, q.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to link claim note: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit claim note: %w", err)
+	}
+	q.NoteActivityID = &activityID
+	return q, nil
+}
+
+func (s *PolicyQAService) loadPolicyQuestions(ctx context.Context, claimID string) ([]models.PolicyQuestion, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+policyQuestionColumns+`
+		FROM policy_questions
+		WHERE claim_id = $1
+		ORDER BY created_at`, claimID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get policy questions: %w", err)
+	}
+	defer rows.Close()
+
+	questions := []models.PolicyQuestion{}
+	for rows.Next() {
+		q, err := scanPolicyQuestion(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan policy question: %w", err)
+		}
+		questions = append(questions, *q)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate policy questions: %w", err)
+	}
+
+	return questions, nil
+}
+
+// policyQuestionPromptData gathers the policy Q&A prompt's context: the
+// confirmed terms and recent turns of the conversation
+func policyQuestionPromptData(terms *models.PolicyTerms, history []models.PolicyQuestion, question string) *prompts.PolicyQAData {
+	data := &prompts.PolicyQAData{
+		PolicyTerms: policyTermsText(terms),
+		Question:    question,
+	}
+	for _, turn := range history {
+		data.History = append(data.History, prompts.PolicyQATurn{Question: turn.Question, Answer: turn.Answer})
+	}
+	return data
+}
+
+// parsePolicyAnswer decodes the LLM's answer, dropping citations without a
+// page or a quote
+func parsePolicyAnswer(response string) (*policyAnswer, error) {
+	var answer policyAnswer
+	if err := json.Unmarshal([]byte(extractJSON(response)), &answer); err != nil {
+		return nil, fmt.Errorf("failed to parse LLM response as JSON: %w", err)
+	}
+	answer.Answer = strings.TrimSpace(answer.Answer)
+
+	citations := []models.PolicyCitation{}
+	for _, c := range answer.Citations {
+		c.Quote = strings.TrimSpace(c.Quote)
+		if c.Page < 1 || c.Quote == "" {
+			continue
+		}
+		citations = append(citations, c)
+	}
+	if answer.Answer == "" {
+		citations = []models.PolicyCitation{}
+	}
+	answer.Citations = citations
+	return &answer, nil
+}
+
+// verifyPolicyCitations keeps the citations whose quote appears on the page
+// they cite. pages is the policy's transcribed text, index i being page i+1.
+// Matching ignores case, whitespace and typographic quotes and dashes, and a
+// quote elided with "..." matches when each part appears on the page in order.
+func verifyPolicyCitations(citations []models.PolicyCitation, pages []string) []models.PolicyCitation {
+	verified := []models.PolicyCitation{}
+	for _, c := range citations {
+		if c.Page > len(pages) {
+			continue
+		}
+		page := normalizeQuoteText(pages[c.Page-1])
+		found := false
+		for _, part := range strings.Split(strings.ReplaceAll(c.Quote, "…", "..."), "...") {
+			part = normalizeQuoteText(part)
+			if part == "" {
+				continue
+			}
+			i := strings.Index(page, part)
+			if i < 0 {
+				found = false
+				break
+			}
+			page = page[i+len(part):]
+			found = true
+		}
+		if found {
+			verified = append(verified, c)
+		}
+	}
+	return verified
+}
+
+var quoteTextReplacer = strings.NewReplacer(
+	"\u2018", "'", "\u2019", "'", "\u201c", `"`, "\u201d", `"`,
+	"\u2013", "-", "\u2014", "-", "\u00a0", " ",
+)
+
+// normalizeQuoteText lowercases text, straightens quotes and dashes and
+// collapses whitespace so a quote matches the transcription it came from
+func normalizeQuoteText(text string) string {
+	return strings.Join(strings.Fields(strings.ToLower(quoteTextReplacer.Replace(text))), " ")
+}
+
+// formatPolicyAnswerNote renders an answer and its quotes as a claim note
+func formatPolicyAnswerNote(q *models.PolicyQuestion) string {
+	var b strings.Builder
+	b.WriteString("Policy Q&A: " + q.Question + "\n\n")
+	b.WriteString(q.Answer + "\n\n")
+	b.WriteString("Policy citations:\n")
+	for _, c := range q.Citations {
+		b.WriteString(fmt.Sprintf("- Page %d: \"%s\"\n", c.Page, c.Quote))
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
@@ -0,0 +1,106 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/claimcoach/backend/internal/models"
+	"github.com/claimcoach/backend/internal/prompts"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePolicyAnswer(t *testing.T) {
+	answer, err := parsePolicyAnswer("```json\n" + `{
+		"answer": "Yes, matching is covered for siding.",
+		"citations": [
+			{"page": 14, "quote": "  We will pay to replace undamaged siding to achieve a reasonably uniform appearance. "},
+			{"page": 0, "quote": "No page"},
+			{"page": 3, "quote": ""}
+		]
+	}` + "\n```")
+	require.NoError(t, err)
+	assert.Equal(t, "Yes, matching is covered for siding.", answer.Answer)
+	assert.Equal(t, []models.PolicyCitation{
+		{Page: 14, Quote: "We will pay to replace undamaged siding to achieve a reasonably uniform appearance."},
+	}, answer.Citations)
+}
+
+func TestParsePolicyAnswer_BlankAnswerHasNoCitations(t *testing.T) {
+	answer, err := parsePolicyAnswer(`{"answer": " ", "citations": [{"page": 2, "quote": "Roof surfaces"}]}`)
+	require.NoError(t, err)
+	assert.Empty(t, answer.Citations)
+}
+
+func TestParsePolicyAnswer_RejectsInvalidJSON(t *testing.T) {
+	_, err := parsePolicyAnswer("The policy covers matching.")
+	assert.Error(t, err)
+}
+
+func TestVerifyPolicyCitations(t *testing.T) {
+	pages := []string{
+		"DECLARATIONS\nPolicy period: 1/1/2026 to 1/1/2027",
+		"",
+		"We will pay to replace undamaged siding to achieve a\nreasonably uniform appearance. This does not apply to roof surfaces \u2013 see Endorsement HO 04 90.",
+	}
+	citations := []models.PolicyCitation{
+		{Page: 3, Quote: "we will pay to replace undamaged siding to achieve a reasonably uniform appearance."},
+		{Page: 3, Quote: "We will pay to replace undamaged siding ... roof surfaces - see Endorsement HO 04 90."},
+		{Page: 1, Quote: "We will pay to replace undamaged siding"},
+		{Page: 3, Quote: "Matching applies to all roof surfaces."},
+		{Page: 3, Quote: "roof surfaces … undamaged siding"},
+		{Page: 4, Quote: "Declarations"},
+	}
+
+	assert.Equal(t, citations[:2], verifyPolicyCitations(citations, pages))
+	assert.Empty(t, verifyPolicyCitations(citations, nil))
+}
+
+func TestPolicyQuestionPromptData(t *testing.T) {
+	history := []models.PolicyQuestion{
+		{Question: "What is the roof settlement?", Answer: "Roofs are settled at actual cash value."},
+	}
+	rendered, err := prompts.Render(prompts.PolicyQA, policyQuestionPromptData(nil, history, "Does that apply to hail?"))
+	require.NoError(t, err)
+	prompt := rendered.User
+
+	assert.Contains(t, prompt, "POLICY TERMS: Not yet confirmed")
+	assert.Contains(t, prompt, "Q: What is the roof settlement?\nA: Roofs are settled at actual cash value.")
+	assert.Contains(t, prompt, "QUESTION: Does that apply to hail?")
+	assert.True(t, strings.Index(prompt, "EARLIER IN THIS CONVERSATION") < strings.Index(prompt, "QUESTION:"))
+}
+
+func TestFormatPolicyAnswerNote(t *testing.T) {
+	note := formatPolicyAnswerNote(&models.PolicyQuestion{
+		Question:  "Is matching covered?",
+		Answer:    "Yes, for siding.",
+		Citations: []models.PolicyCitation{{Page: 14, Quote: "We will pay to replace undamaged siding."}},
+	})
+	assert.Equal(t, "Policy Q&A: Is matching covered?\n\nYes, for siding.\n\nPolicy citations:\n- Page 14: \"We will pay to replace undamaged siding.\"", note)
+}
+
+func TestLoadPolicyQuestions(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	now := time.Now()
+	mock.ExpectQuery(`FROM policy_questions`).
+		WithArgs("claim-1").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "claim_id", "policy_id", "user_id", "question", "answer", "citations", "answered",
+			"note_activity_id", "prompt_version", "created_at"}).
+			AddRow("q-1", "claim-1", "policy-1", "user-1", "Is matching covered?", "Yes.",
+				[]byte(`[{"page": 14, "quote": "We will pay to replace undamaged siding."}]`), true, nil, "policy_qa/v1", now))
+
+	service := &PolicyQAService{db: db}
+	questions, err := service.loadPolicyQuestions(context.Background(), "claim-1")
+	require.NoError(t, err)
+	require.Len(t, questions, 1)
+	assert.Equal(t, 14, questions[0].Citations[0].Page)
+	assert.True(t, questions[0].Answered)
+	assert.Nil(t, questions[0].NoteActivityID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
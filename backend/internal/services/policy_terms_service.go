@@ -0,0 +1,524 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/claimcoach/backend/internal/models"
+	"github.com/claimcoach/backend/internal/prompts"
+	"github.com/claimcoach/backend/internal/storage"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrNoPolicyPDF              = errors.New("no PDF uploaded for this policy")
+	ErrPolicyTermsNotFound      = errors.New("policy terms not found")
+	ErrPolicyTermsNotReviewable = errors.New("policy terms are not pending review")
+	ErrInvalidPolicyTerms       = errors.New("invalid policy terms")
+)
+
+// policyTermsMaxTokens bounds the extraction response, which is a small JSON object
+const policyTermsMaxTokens = 2000
+
+// PolicyTermsService extracts structured coverage terms from policy PDFs and
+// lets a PM review and confirm them
+type PolicyTermsService struct {
+	db            *sql.DB
+	storage       StorageClient
+	extractor     PDFParserClient
+	policyService *PolicyService
+	httpClient    *http.Client
+}
+
+// NewPolicyTermsService creates a new PolicyTermsService
+func NewPolicyTermsService(db *sql.DB, storageClient *storage.SupabaseStorage, extractor PDFParserClient, policyService *PolicyService) *PolicyTermsService {
+	return &PolicyTermsService{
+		db:            db,
+		storage:       storageClient,
+		extractor:     extractor,
+		policyService: policyService,
+		httpClient:    &http.Client{Timeout: 60 * time.Second},
+	}
+}
+
+// PolicyTermsInput is both the extractor's response and the terms a PM
+// confirms. A PM confirms the full set of terms, including corrections.
+type PolicyTermsInput struct {
+	CoverageALimit             *float64 `json:"coverage_a_limit"`
+	CoverageBLimit             *float64 `json:"coverage_b_limit"`
+	CoverageCLimit             *float64 `json:"coverage_c_limit"`
+	CoverageDLimit             *float64 `json:"coverage_d_limit"`
+	DeductibleType             *string  `json:"deductible_type"`
+	DeductibleAmount           *float64 `json:"deductible_amount"`
+	DeductiblePercentage       *float64 `json:"deductible_percentage"`
+	RoofSettlement             *string  `json:"roof_settlement"`
+	MatchingEndorsement        *bool    `json:"matching_endorsement"`
+	OrdinanceAndLawEndorsement *bool    `json:"ordinance_and_law_endorsement"`
+	OrdinanceAndLawLimit       *float64 `json:"ordinance_and_law_limit"`
+	Exclusions                 []string `json:"exclusions"`
+}
+
+// PolicyTermsReview is a policy's confirmed terms and the extraction waiting
+// for review, either of which may be nil
+type PolicyTermsReview struct {
+	Confirmed *models.PolicyTerms `json:"confirmed"`
+	Pending   *models.PolicyTerms `json:"pending"`
+}
+
+const policyTermsColumns = `id, policy_id, review_status, source_pdf_url,
+	coverage_a_limit, coverage_b_limit, coverage_c_limit, coverage_d_limit,
+	deductible_type, deductible_amount, deductible_percentage, roof_settlement,
+	matching_endorsement, ordinance_and_law_endorsement, ordinance_and_law_limit,
+	exclusions, extraction_notes, prompt_version, reviewed_by_user_id, reviewed_at, created_at, updated_at`
+
+func scanPolicyTerms(row interface{ Scan(...interface{}) error }) (*models.PolicyTerms, error) {
+	var terms models.PolicyTerms
+	var exclusions, notes []byte
+	err := row.Scan(
+		&terms.ID, &terms.PolicyID, &terms.ReviewStatus, &terms.SourcePdfUrl,
+		&terms.CoverageALimit, &terms.CoverageBLimit, &terms.CoverageCLimit, &terms.CoverageDLimit,
+		&terms.DeductibleType, &terms.DeductibleAmount, &terms.DeductiblePercentage, &terms.RoofSettlement,
+		&terms.MatchingEndorsement, &terms.OrdinanceAndLawEndorsement, &terms.OrdinanceAndLawLimit,
+		&exclusions, &notes, &terms.PromptVersion, &terms.ReviewedByUserID, &terms.ReviewedAt, &terms.CreatedAt, &terms.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(exclusions, &terms.Exclusions); err != nil {
+		return nil, fmt.Errorf("invalid exclusions: %w", err)
+	}
+	if err := json.Unmarshal(notes, &terms.ExtractionNotes); err != nil {
+		return nil, fmt.Errorf("invalid extraction notes: %w", err)
+	}
+	return &terms, nil
+}
+
+// ExtractPolicyTerms reads the policy PDF with the LLM and stores the terms
+// for PM review, replacing any extraction still waiting for review
+func (s *PolicyTermsService) ExtractPolicyTerms(ctx context.Context, propertyID, organizationID string) (*models.PolicyTerms, error) {
+	policy, err := s.policyService.GetPolicy(propertyID, organizationID)
+	if err != nil {
+		return nil, err
+	}
+	if policy.PolicyPdfUrl == nil || *policy.PolicyPdfUrl == "" {
+		return nil, ErrNoPolicyPDF
+	}
+
+	content, err := readPolicyPDF(ctx, s.storage, s.httpClient, *policy.PolicyPdfUrl)
+	if err != nil {
+		return nil, err
+	}
+
+	rendered, err := prompts.Render(prompts.PolicyTerms, nil)
+	if err != nil {
+		return nil, err
+	}
+	response, err := s.extractor.ParsePDF(ctx, content, rendered.User, policyTermsMaxTokens)
+	if err != nil {
+		return nil, fmt.Errorf("LLM request failed: %w", err)
+	}
+	input, notes, err := parseExtractedPolicyTerms(response)
+	if err != nil {
+		return nil, err
+	}
+
+	exclusionsJSON, _ := json.Marshal(input.Exclusions)
+	notesJSON, _ := json.Marshal(notes)
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE policy_terms SET review_status = $1, updated_at = NOW()
+		WHERE policy_id = $2 AND review_status = $3`,
+		models.PolicyTermsSuperseded, policy.ID, models.PolicyTermsPendingReview,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to supersede pending policy terms: %w", err)
+	}
+
+	now := time.Now()
+	terms, err := scanPolicyTerms(tx.QueryRowContext(ctx, `
+		INSERT INTO policy_terms (
+			id, policy_id, review_status, source_pdf_url,
+			coverage_a_limit, coverage_b_limit, coverage_c_limit, coverage_d_limit,
+			deductible_type, deductible_amount, deductible_percentage, roof_settlement,
+			matching_endorsement, ordinance_and_law_endorsement, ordinance_and_law_limit,
+			exclusions, extraction_notes, prompt_version, created_at, updated_at
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20)
+		RETURNING `+policyTermsColumns,
+		uuid.New().String(), policy.ID, models.PolicyTermsPendingReview, policy.PolicyPdfUrl,
+		input.CoverageALimit, input.CoverageBLimit, input.CoverageCLimit, input.CoverageDLimit,
+		input.DeductibleType, input.DeductibleAmount, input.DeductiblePercentage, input.RoofSettlement,
+		input.MatchingEndorsement, input.OrdinanceAndLawEndorsement, input.OrdinanceAndLawLimit,
+		string(exclusionsJSON), string(notesJSON), rendered.Version, now, now,
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to save policy terms: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit policy terms: %w", err)
+	}
+	return terms, nil
+}
+
+// GetPolicyTerms returns a policy's confirmed terms and any extraction
+// waiting for review
+func (s *PolicyTermsService) GetPolicyTerms(ctx context.Context, propertyID, organizationID string) (*PolicyTermsReview, error) {
+	policy, err := s.policyService.GetPolicy(propertyID, organizationID)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+policyTermsColumns+`
+		FROM policy_terms
+		WHERE policy_id = $1 AND review_status IN ($2, $3)
+		ORDER BY created_at DESC`,
+		policy.ID, models.PolicyTermsConfirmed, models.PolicyTermsPendingReview,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get policy terms: %w", err)
+	}
+	defer rows.Close()
+
+	review := &PolicyTermsReview{}
+	for rows.Next() {
+		terms, err := scanPolicyTerms(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan policy terms: %w", err)
+		}
+		switch {
+		case terms.ReviewStatus == models.PolicyTermsConfirmed && review.Confirmed == nil:
+			review.Confirmed = terms
+		case terms.ReviewStatus == models.PolicyTermsPendingReview && review.Pending == nil:
+			review.Pending = terms
+		}
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate policy terms: %w", err)
+	}
+
+	return review, nil
+}
+
+// ConfirmPolicyTerms records a PM's review of an extraction. The input is the
+// full set of terms as reviewed; it replaces the extracted values and
+// supersedes the previously confirmed terms.
+func (s *PolicyTermsService) ConfirmPolicyTerms(ctx context.Context, propertyID, termsID, organizationID, userID string, input PolicyTermsInput) (*models.PolicyTerms, error) {
+	policy, err := s.policyService.GetPolicy(propertyID, organizationID)
+	if err != nil {
+		return nil, err
+	}
+
+	normalizePolicyTerms(&input)
+	if problems := checkPolicyTerms(&input, false); len(problems) > 0 {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidPolicyTerms, strings.Join(problems, "; "))
+	}
+	exclusionsJSON, _ := json.Marshal(input.Exclusions)
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var status string
+	err = tx.QueryRowContext(ctx,
+		`SELECT review_status FROM policy_terms WHERE id = $1 AND policy_id = $2 FOR UPDATE`,
+		termsID, policy.ID,
+	).Scan(&status)
+	if err == sql.ErrNoRows {
+		return nil, ErrPolicyTermsNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get policy terms: %w", err)
+	}
+	if status != models.PolicyTermsPendingReview {
+		return nil, ErrPolicyTermsNotReviewable
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE policy_terms SET review_status = $1, updated_at = NOW()
+		WHERE policy_id = $2 AND review_status = $3`,
+		models.PolicyTermsSuperseded, policy.ID, models.PolicyTermsConfirmed,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to supersede confirmed policy terms: %w", err)
+	}
+
+	terms, err := scanPolicyTerms(tx.QueryRowContext(ctx, `
+		UPDATE policy_terms SET
+			coverage_a_limit = $1, coverage_b_limit = $2, coverage_c_limit = $3, coverage_d_limit = $4,
+			deductible_type = $5, deductible_amount = $6, deductible_percentage = $7, roof_settlement = $8,
+			matching_endorsement = $9, ordinance_and_law_endorsement = $10, ordinance_and_law_limit = $11,
+			exclusions = $12, review_status = $13, reviewed_by_user_id = $14, reviewed_at = $15, updated_at = $15
+		WHERE id = $16
+		RETURNING `+policyTermsColumns,
+		input.CoverageALimit, input.CoverageBLimit, input.CoverageCLimit, input.CoverageDLimit,
+		input.DeductibleType, input.DeductibleAmount, input.DeductiblePercentage, input.RoofSettlement,
+		input.MatchingEndorsement, input.OrdinanceAndLawEndorsement, input.OrdinanceAndLawLimit,
+		string(exclusionsJSON), models.PolicyTermsConfirmed, userID, time.Now(), termsID,
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to confirm policy terms: %w", err)
+	}
+	if err := syncTermsDeductibleRules(ctx, tx, terms, userID); err != nil {
+		return nil, err
+	}
+
+	// Touch the policy so audit runs record that its terms changed
+	_, err = tx.ExecContext(ctx, `UPDATE insurance_policies SET updated_at = NOW() WHERE id = $1`, policy.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update policy: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit policy terms: %w", err)
+	}
+	return terms, nil
+}
+
+// readPolicyPDF downloads a policy PDF for the LLM to read
+func readPolicyPDF(ctx context.Context, storageClient StorageClient, httpClient *http.Client, path string) ([]byte, error) {
+	body, err := openStoredFile(ctx, storageClient, httpClient, path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download policy PDF: %w", err)
+	}
+	defer body.Close()
+
+	content, err := io.ReadAll(io.LimitReader(body, maxExtractionFileBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read policy PDF: %w", err)
+	}
+	if len(content) > maxExtractionFileBytes {
+		return nil, fmt.Errorf("policy PDF too large for extraction")
+	}
+	return content, nil
+}
+
+// parseExtractedPolicyTerms decodes the extractor's response. Values that fail
+// validation are discarded rather than failing the extraction; they, and any
+// key terms the policy did not state, are returned as notes for the reviewer.
+func parseExtractedPolicyTerms(response string) (*PolicyTermsInput, []string, error) {
+	var input PolicyTermsInput
+	if err := json.Unmarshal([]byte(extractJSON(response)), &input); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse LLM response as JSON: %w", err)
+	}
+
+	normalizePolicyTerms(&input)
+	notes := []string{}
+	// Percentages are sometimes given as whole numbers despite the prompt
+	if p := input.DeductiblePercentage; p != nil && *p >= 1 && *p < 100 {
+		fraction := *p / 100
+		notes = append(notes, fmt.Sprintf("deductible_percentage %g was read as %g%%", *p, *p))
+		input.DeductiblePercentage = &fraction
+	}
+	notes = append(notes, checkPolicyTerms(&input, true)...)
+
+	missing := []struct {
+		name  string
+		unset bool
+	}{
+		{"Coverage A limit", input.CoverageALimit == nil},
+		{"Deductible type", input.DeductibleType == nil},
+		{"Roof settlement", input.RoofSettlement == nil},
+		{"Matching endorsement", input.MatchingEndorsement == nil},
+		{"Ordinance and law endorsement", input.OrdinanceAndLawEndorsement == nil},
+	}
+	for _, term := range missing {
+		if term.unset {
+			notes = append(notes, term.name+" was not found in the policy")
+		}
+	}
+	return &input, notes, nil
+}
+
+// normalizePolicyTerms lowercases the enumerated terms, treats blank ones as
+// unset and drops blank exclusions
+func normalizePolicyTerms(input *PolicyTermsInput) {
+	for _, value := range []**string{&input.DeductibleType, &input.RoofSettlement} {
+		if *value == nil {
+			continue
+		}
+		*value = nullIfEmpty(strings.ToLower(strings.TrimSpace(**value)))
+	}
+	exclusions := []string{}
+	for _, exclusion := range input.Exclusions {
+		if exclusion = strings.TrimSpace(exclusion); exclusion != "" {
+			exclusions = append(exclusions, exclusion)
+		}
+	}
+	input.Exclusions = exclusions
+}
+
+// checkPolicyTerms lists the problems with a set of terms. With discard set,
+// each invalid value is also cleared.
+func checkPolicyTerms(input *PolicyTermsInput, discard bool) []string {
+	var problems []string
+	reject := func(problem string, clear func()) {
+		problems = append(problems, problem)
+		if discard {
+			clear()
+		}
+	}
+
+	amounts := []struct {
+		name  string
+		value **float64
+	}{
+		{"coverage_a_limit", &input.CoverageALimit},
+		{"coverage_b_limit", &input.CoverageBLimit},
+		{"coverage_c_limit", &input.CoverageCLimit},
+		{"coverage_d_limit", &input.CoverageDLimit},
+		{"deductible_amount", &input.DeductibleAmount},
+		{"ordinance_and_law_limit", &input.OrdinanceAndLawLimit},
+	}
+	for _, amount := range amounts {
+		if v := *amount.value; v != nil && *v < 0 {
+			reject(amount.name+" must not be negative", func() { *amount.value = nil })
+		}
+	}
+
+	if p := input.DeductiblePercentage; p != nil && (*p <= 0 || *p >= 1) {
+		reject(fmt.Sprintf("deductible_percentage %g must be a fraction between 0 and 1", *p), func() { input.DeductiblePercentage = nil })
+	}
+
+	if t := input.DeductibleType; t != nil {
+		switch *t {
+		case models.DeductibleFlat:
+			if input.DeductibleAmount == nil {
+				problems = append(problems, "a flat deductible needs deductible_amount")
+			}
+		case models.DeductiblePercentage:
+			if input.DeductiblePercentage == nil {
+				problems = append(problems, "a percentage deductible needs deductible_percentage")
+			}
+		case models.DeductibleWindHail:
+			if input.DeductibleAmount == nil && input.DeductiblePercentage == nil {
+				problems = append(problems, "a wind/hail deductible needs deductible_amount or deductible_percentage")
+			}
+		default:
+			reject(fmt.Sprintf("deductible_type %q must be flat, percentage or wind_hail", *t), func() { input.DeductibleType = nil })
+		}
+	}
+
+	if r := input.RoofSettlement; r != nil && *r != models.RoofSettlementACV && *r != models.RoofSettlementRCV {
+		reject(fmt.Sprintf("roof_settlement %q must be acv or rcv", *r), func() { input.RoofSettlement = nil })
+	}
+
+	return problems
+}
+
+// confirmedPolicyTerms returns the confirmed terms of a claim's policy, or nil
+// when none have been confirmed
+func confirmedPolicyTerms(ctx context.Context, db *sql.DB, claimID string) (*models.PolicyTerms, error) {
+	terms, err := scanPolicyTerms(db.QueryRowContext(ctx, `
+		SELECT `+policyTermsColumns+`
+		FROM policy_terms
+		WHERE review_status = $1 AND policy_id = (SELECT policy_id FROM claims WHERE id = $2)`,
+		models.PolicyTermsConfirmed, claimID,
+	))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get confirmed policy terms: %w", err)
+	}
+	return terms, nil
+}
+
+// policyTermsText renders a claim's policy terms for a prompt template
+func policyTermsText(terms *models.PolicyTerms) string {
+	var b strings.Builder
+	writePolicyTerms(&b, terms)
+	return b.String()
+}
+
+// writePolicyTerms adds a claim's policy terms to a prompt
+func writePolicyTerms(b *strings.Builder, terms *models.PolicyTerms) {
+	if terms == nil {
+		b.WriteString("POLICY TERMS: Not yet confirmed by the PM. Do not assume coverage limits, roof settlement or endorsements.\n\n")
+		return
+	}
+
+	b.WriteString("CONFIRMED POLICY TERMS (extracted from the policy PDF and confirmed by the PM — treat as authoritative):\n")
+	limits := []struct {
+		name  string
+		limit *float64
+	}{
+		{"Coverage A (Dwelling)", terms.CoverageALimit},
+		{"Coverage B (Other Structures)", terms.CoverageBLimit},
+		{"Coverage C (Personal Property)", terms.CoverageCLimit},
+		{"Coverage D (Loss of Use)", terms.CoverageDLimit},
+	}
+	for _, l := range limits {
+		if l.limit != nil {
+			b.WriteString(fmt.Sprintf("- %s: $%.2f\n", l.name, *l.limit))
+		}
+	}
+	if deductible := describeDeductible(terms); deductible != "" {
+		b.WriteString("- Deductible: " + deductible + "\n")
+	}
+	if terms.RoofSettlement != nil {
+		if *terms.RoofSettlement == models.RoofSettlementACV {
+			b.WriteString("- Roof Settlement: Actual cash value (roof depreciation is not recoverable)\n")
+		} else {
+			b.WriteString("- Roof Settlement: Replacement cost value\n")
+		}
+	}
+	if terms.MatchingEndorsement != nil {
+		b.WriteString(fmt.Sprintf("- Matching Endorsement: %s\n", yesNo(*terms.MatchingEndorsement)))
+	}
+	if terms.OrdinanceAndLawEndorsement != nil {
+		line := "- Ordinance & Law Endorsement: " + yesNo(*terms.OrdinanceAndLawEndorsement)
+		if *terms.OrdinanceAndLawEndorsement && terms.OrdinanceAndLawLimit != nil {
+			line += fmt.Sprintf(", limit $%.2f", *terms.OrdinanceAndLawLimit)
+		}
+		b.WriteString(line + "\n")
+	}
+	if len(terms.Exclusions) > 0 {
+		b.WriteString("- Listed Exclusions: " + strings.Join(terms.Exclusions, "; ") + "\n")
+	}
+	b.WriteString("\n")
+}
+
+func describeDeductible(terms *models.PolicyTerms) string {
+	if terms.DeductibleType == nil {
+		return ""
+	}
+	var amount string
+	switch {
+	case *terms.DeductibleType != models.DeductibleFlat && terms.DeductiblePercentage != nil:
+		amount = fmt.Sprintf("%g%% of Coverage A", *terms.DeductiblePercentage*100)
+		if terms.CoverageALimit != nil {
+			amount += fmt.Sprintf(" ($%.2f)", *terms.DeductiblePercentage*(*terms.CoverageALimit))
+		}
+	case terms.DeductibleAmount != nil:
+		amount = fmt.Sprintf("$%.2f", *terms.DeductibleAmount)
+	}
+	if *terms.DeductibleType == models.DeductibleWindHail {
+		return "separate wind/hail deductible of " + amount + "; other perils use the policy deductible"
+	}
+	return amount
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "Yes"
+	}
+	return "No"
+}
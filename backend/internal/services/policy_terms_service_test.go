@@ -0,0 +1,127 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/claimcoach/backend/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseExtractedPolicyTerms(t *testing.T) {
+	response := "```json\n" + `{
+		"coverage_a_limit": 300000,
+		"coverage_b_limit": 30000,
+		"coverage_c_limit": -5,
+		"deductible_type": "Percentage",
+		"deductible_percentage": 2,
+		"roof_settlement": "ACV",
+		"matching_endorsement": null,
+		"ordinance_and_law_endorsement": true,
+		"ordinance_and_law_limit": 30000,
+		"exclusions": ["Flood", " ", "Earth movement"]
+	}` + "\n```"
+
+	input, notes, err := parseExtractedPolicyTerms(response)
+	require.NoError(t, err)
+
+	assert.Equal(t, 300000.0, *input.CoverageALimit)
+	assert.Nil(t, input.CoverageCLimit, "negative limits are discarded")
+	assert.Equal(t, models.DeductiblePercentage, *input.DeductibleType)
+	assert.Equal(t, 0.02, *input.DeductiblePercentage)
+	assert.Equal(t, models.RoofSettlementACV, *input.RoofSettlement)
+	assert.Equal(t, []string{"Flood", "Earth movement"}, input.Exclusions)
+	assert.Equal(t, []string{
+		"deductible_percentage 2 was read as 2%",
+		"coverage_c_limit must not be negative",
+		"Matching endorsement was not found in the policy",
+	}, notes)
+}
+
+func TestParseExtractedPolicyTerms_RejectsInvalidJSON(t *testing.T) {
+	_, _, err := parseExtractedPolicyTerms("I could not read this policy.")
+	assert.Error(t, err)
+}
+
+func TestCheckPolicyTerms(t *testing.T) {
+	typ, roof := "wind_hail", "replacement"
+	input := PolicyTermsInput{DeductibleType: &typ, RoofSettlement: &roof}
+
+	problems := checkPolicyTerms(&input, false)
+	assert.Equal(t, []string{
+		"a wind/hail deductible needs deductible_amount or deductible_percentage",
+		`roof_settlement "replacement" must be acv or rcv`,
+	}, problems)
+	assert.NotNil(t, input.RoofSettlement, "values are only cleared when discarding")
+
+	amount := 1500.0
+	flat := models.DeductibleFlat
+	assert.Empty(t, checkPolicyTerms(&PolicyTermsInput{DeductibleType: &flat, DeductibleAmount: &amount}, false))
+}
+
+func TestWritePolicyTerms(t *testing.T) {
+	limit, pct := 250000.0, 0.02
+	percentage, acv, yes := models.DeductiblePercentage, models.RoofSettlementACV, true
+
+	var b strings.Builder
+	writePolicyTerms(&b, &models.PolicyTerms{
+		CoverageALimit:             &limit,
+		DeductibleType:             &percentage,
+		DeductiblePercentage:       &pct,
+		RoofSettlement:             &acv,
+		OrdinanceAndLawEndorsement: &yes,
+		Exclusions:                 []string{"Flood"},
+	})
+	prompt := b.String()
+	assert.Contains(t, prompt, "- Coverage A (Dwelling): $250000.00\n")
+	assert.Contains(t, prompt, "- Deductible: 2% of Coverage A ($5000.00)\n")
+	assert.Contains(t, prompt, "- Roof Settlement: Actual cash value")
+	assert.Contains(t, prompt, "- Ordinance & Law Endorsement: Yes\n")
+	assert.Contains(t, prompt, "- Listed Exclusions: Flood\n")
+
+	b.Reset()
+	writePolicyTerms(&b, nil)
+	assert.Contains(t, b.String(), "Not yet confirmed by the PM")
+}
+
+func TestConfirmedPolicyTerms(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	columns := []string{
+		"id", "policy_id", "review_status", "source_pdf_url",
+		"coverage_a_limit", "coverage_b_limit", "coverage_c_limit", "coverage_d_limit",
+		"deductible_type", "deductible_amount", "deductible_percentage", "roof_settlement",
+		"matching_endorsement", "ordinance_and_law_endorsement", "ordinance_and_law_limit",
+		"exclusions", "extraction_notes", "prompt_version", "reviewed_by_user_id", "reviewed_at", "created_at", "updated_at",
+	}
+	now := time.Now()
+	mock.ExpectQuery(`SELECT .+ FROM policy_terms`).
+		WithArgs(models.PolicyTermsConfirmed, "claim-1").
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(
+			"terms-1", "policy-1", models.PolicyTermsConfirmed, nil,
+			250000.0, nil, nil, nil,
+			models.DeductibleFlat, 2500.0, nil, models.RoofSettlementRCV,
+			true, false, nil,
+			[]byte(`["Flood"]`), []byte(`[]`), "policy_terms/v1", "user-1", now, now, now,
+		))
+	mock.ExpectQuery(`SELECT .+ FROM policy_terms`).
+		WithArgs(models.PolicyTermsConfirmed, "claim-2").
+		WillReturnRows(sqlmock.NewRows(columns))
+
+	terms, err := confirmedPolicyTerms(context.Background(), db, "claim-1")
+	require.NoError(t, err)
+	assert.Equal(t, "terms-1", terms.ID)
+	assert.Equal(t, []string{"Flood"}, terms.Exclusions)
+	assert.Equal(t, 2500.0, *terms.DeductibleAmount)
+
+	terms, err = confirmedPolicyTerms(context.Background(), db, "claim-2")
+	require.NoError(t, err)
+	assert.Nil(t, terms)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
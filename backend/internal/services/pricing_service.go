@@ -0,0 +1,559 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/claimcoach/backend/internal/models"
+	"github.com/claimcoach/backend/internal/pricing"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrOrgUnitPriceNotFound = errors.New("unit price override not found")
+	ErrInvalidUnitPrice     = errors.New("invalid unit price")
+)
+
+// PricingService stores unit price lists and org overrides, and resolves the
+// price in effect for each code. Lookups fall back from the organization's
+// overrides, to imported price lists for the region, to national price lists,
+// to the baseline shipped with the binary.
+type PricingService struct {
+	db *sql.DB
+}
+
+func NewPricingService(db *sql.DB) *PricingService {
+	return &PricingService{db: db}
+}
+
+// ImportPriceListInput describes a price list CSV being imported. Region and
+// EffectiveDate fill in rows that leave those columns blank; Region defaults
+// to national.
+type ImportPriceListInput struct {
+	Name             string
+	Source           *string
+	Region           string
+	EffectiveDate    time.Time
+	ImportedByUserID *string
+}
+
+// OrgUnitPriceInput sets an organization's unit cost for a code. An empty
+// region applies in every region; the effective date defaults to today.
+type OrgUnitPriceInput struct {
+	Code          string       `json:"code" binding:"required"`
+	Description   string       `json:"description"`
+	Unit          string       `json:"unit" binding:"required"`
+	UnitCost      *float64     `json:"unit_cost" binding:"required"`
+	Region        string       `json:"region"`
+	EffectiveDate *models.Date `json:"effective_date"`
+	Notes         *string      `json:"notes"`
+}
+
+const orgUnitPriceColumns = `id, organization_id, code, description, unit, unit_cost, region,
+	effective_date, notes, created_by_user_id, created_at, updated_at`
+
+func scanOrgUnitPrice(row interface{ Scan(...interface{}) error }) (*models.OrgUnitPrice, error) {
+	var price models.OrgUnitPrice
+	err := row.Scan(
+		&price.ID,
+		&price.OrganizationID,
+		&price.Code,
+		&price.Description,
+		&price.Unit,
+		&price.UnitCost,
+		&price.Region,
+		&price.EffectiveDate,
+		&price.Notes,
+		&price.CreatedByUserID,
+		&price.CreatedAt,
+		&price.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &price, nil
+}
+
+// ImportPriceList parses a price list CSV and stores it. The import is all or
+// nothing: invalid rows are returned as a *pricing.ImportError and nothing is
+// stored.
+func (s *PricingService) ImportPriceList(ctx context.Context, input ImportPriceListInput, r io.Reader) (*models.PriceList, error) {
+	name := strings.TrimSpace(input.Name)
+	if name == "" {
+		return nil, fmt.Errorf("%w: price list name is required", ErrInvalidUnitPrice)
+	}
+	region := pricing.NormalizeRegion(input.Region)
+	if region == "" {
+		region = pricing.DefaultRegion
+	}
+
+	rows, err := pricing.ParseCSV(r, pricing.Defaults{Region: region, EffectiveDate: input.EffectiveDate})
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var list models.PriceList
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO price_lists (id, name, source, row_count, imported_by_user_id, imported_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, name, source, row_count, imported_by_user_id, imported_at`,
+		uuid.New().String(), name, input.Source, len(rows), input.ImportedByUserID, time.Now(),
+	).Scan(&list.ID, &list.Name, &list.Source, &list.RowCount, &list.ImportedByUserID, &list.ImportedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create price list: %w", err)
+	}
+
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO unit_prices (
+			id, price_list_id, code, description, unit, region, effective_date,
+			material_cost, labor_cost, unit_cost
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare unit price insert: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, row := range rows {
+		_, err := stmt.ExecContext(ctx,
+			uuid.New().String(), list.ID, row.Code, row.Description, row.Unit, row.Region,
+			pricing.FormatDate(row.EffectiveDate), row.MaterialCost, row.LaborCost, row.UnitCost,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to insert unit price on line %d: %w", row.Line, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit price list: %w", err)
+	}
+
+	return &list, nil
+}
+
+// ListPriceLists returns imported price lists, newest first
+func (s *PricingService) ListPriceLists(ctx context.Context) ([]models.PriceList, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, name, source, row_count, imported_by_user_id, imported_at
+		FROM price_lists
+		ORDER BY imported_at DESC`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get price lists: %w", err)
+	}
+	defer rows.Close()
+
+	lists := []models.PriceList{}
+	for rows.Next() {
+		var list models.PriceList
+		if err := rows.Scan(&list.ID, &list.Name, &list.Source, &list.RowCount, &list.ImportedByUserID, &list.ImportedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan price list: %w", err)
+		}
+		lists = append(lists, list)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate price lists: %w", err)
+	}
+
+	return lists, nil
+}
+
+// ResolvePrices returns the price in effect for every known code for an
+// organization in a region on a date, keyed by code
+func (s *PricingService) ResolvePrices(ctx context.Context, orgID, region string, asOf time.Time) (map[string]pricing.Price, error) {
+	region = pricing.NormalizeRegion(region)
+	if region == "" {
+		region = pricing.DefaultRegion
+	}
+	date := pricing.FormatDate(asOf)
+
+	prices := pricing.Baseline()
+
+	// Imported lists: the region's own price beats a national one, then the
+	// latest effective date, then the latest import
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT DISTINCT ON (up.code)
+			up.code, up.description, up.unit, up.unit_cost, up.material_cost, up.labor_cost,
+			up.region, up.effective_date, pl.id, pl.name
+		FROM unit_prices up
+		JOIN price_lists pl ON pl.id = up.price_list_id
+		WHERE up.region IN ($1, $2) AND up.effective_date <= $3
+		ORDER BY up.code, (up.region = $1) DESC, up.effective_date DESC, pl.imported_at DESC`,
+		region, pricing.DefaultRegion, date,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get unit prices: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var price pricing.Price
+		var priceRegion, listID string
+		var effective time.Time
+		if err := rows.Scan(
+			&price.Code, &price.Description, &price.Unit, &price.UnitCost, &price.MaterialCost, &price.LaborCost,
+			&priceRegion, &effective, &listID, &price.Source.Name,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan unit price: %w", err)
+		}
+		price.Source.Type = pricing.SourcePriceList
+		price.Source.ID = &listID
+		price.Source.Region = priceRegion
+		price.Source.EffectiveDate = pricing.FormatDate(effective)
+		if price.Description == "" {
+			price.Description = prices[price.Code].Description
+		}
+		prices[price.Code] = price
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate unit prices: %w", err)
+	}
+
+	overrides, err := s.db.QueryContext(ctx, `
+		SELECT DISTINCT ON (code) id, code, description, unit, unit_cost, region, effective_date
+		FROM org_unit_prices
+		WHERE organization_id = $1 AND region IN ($2, '') AND effective_date <= $3
+		ORDER BY code, (region = $2) DESC, effective_date DESC`,
+		orgID, region, date,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get unit price overrides: %w", err)
+	}
+	defer overrides.Close()
+
+	for overrides.Next() {
+		var price pricing.Price
+		var id, overrideRegion string
+		var effective time.Time
+		if err := overrides.Scan(&id, &price.Code, &price.Description, &price.Unit, &price.UnitCost, &overrideRegion, &effective); err != nil {
+			return nil, fmt.Errorf("failed to scan unit price override: %w", err)
+		}
+		price.Source = pricing.Source{
+			Type:          pricing.SourceOrgOverride,
+			ID:            &id,
+			Name:          "Organization price",
+			Region:        overrideRegion,
+			EffectiveDate: pricing.FormatDate(effective),
+		}
+		if price.Description == "" {
+			price.Description = prices[price.Code].Description
+		}
+		prices[price.Code] = price
+	}
+	if err = overrides.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate unit price overrides: %w", err)
+	}
+
+	return prices, nil
+}
+
+// ListOrgUnitPrices returns an organization's overrides, by code and newest first
+func (s *PricingService) ListOrgUnitPrices(ctx context.Context, orgID string) ([]models.OrgUnitPrice, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+orgUnitPriceColumns+`
+		FROM org_unit_prices
+		WHERE organization_id = $1
+		ORDER BY code, region, effective_date DESC`, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get unit price overrides: %w", err)
+	}
+	defer rows.Close()
+
+	prices := []models.OrgUnitPrice{}
+	for rows.Next() {
+		price, err := scanOrgUnitPrice(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan unit price override: %w", err)
+		}
+		prices = append(prices, *price)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate unit price overrides: %w", err)
+	}
+
+	return prices, nil
+}
+
+// UpsertOrgUnitPrice sets an organization's price for a code, replacing any
+// override for the same code, region and effective date
+func (s *PricingService) UpsertOrgUnitPrice(ctx context.Context, orgID, userID string, input OrgUnitPriceInput) (*models.OrgUnitPrice, error) {
+	row := pricing.Row{
+		Code:          pricing.NormalizeCode(input.Code),
+		Description:   strings.TrimSpace(input.Description),
+		Unit:          pricing.NormalizeUnit(input.Unit),
+		Region:        pricing.NormalizeRegion(input.Region),
+		EffectiveDate: today(),
+	}
+	if input.UnitCost != nil {
+		row.UnitCost = *input.UnitCost
+	}
+	if input.EffectiveDate != nil {
+		row.EffectiveDate = input.EffectiveDate.ToTime()
+	}
+
+	switch {
+	case !pricing.ValidCode(row.Code):
+		return nil, fmt.Errorf("%w: invalid code %q", ErrInvalidUnitPrice, input.Code)
+	case !pricing.ValidUnit(row.Unit):
+		return nil, fmt.Errorf("%w: unsupported unit %q", ErrInvalidUnitPrice, input.Unit)
+	case input.UnitCost == nil || row.UnitCost < 0:
+		return nil, fmt.Errorf("%w: unit_cost must be a non-negative amount", ErrInvalidUnitPrice)
+	case row.Region != "" && !pricing.ValidRegion(row.Region):
+		return nil, fmt.Errorf("%w: invalid region %q", ErrInvalidUnitPrice, input.Region)
+	}
+
+	return upsertOrgUnitPrice(ctx, s.db, orgID, userID, row, input.Notes)
+}
+
+// ImportOrgUnitPrices upserts an organization's overrides from a CSV file.
+// Rows without a region apply in every region; rows without an effective
+// date take effect today. Like price list imports, it is all or nothing.
+func (s *PricingService) ImportOrgUnitPrices(ctx context.Context, orgID, userID string, r io.Reader) (int, error) {
+	rows, err := pricing.ParseCSV(r, pricing.Defaults{EffectiveDate: today()})
+	if err != nil {
+		return 0, err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, row := range rows {
+		if _, err := upsertOrgUnitPrice(ctx, tx, orgID, userID, row, nil); err != nil {
+			return 0, fmt.Errorf("line %d: %w", row.Line, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit unit price overrides: %w", err)
+	}
+
+	return len(rows), nil
+}
+
+// DeleteOrgUnitPrice removes one of an organization's overrides
+func (s *PricingService) DeleteOrgUnitPrice(ctx context.Context, orgID, id string) error {
+	result, err := s.db.ExecContext(ctx,
+		`DELETE FROM org_unit_prices WHERE id = $1 AND organization_id = $2`, id, orgID)
+	if err != nil {
+		return fmt.Errorf("failed to delete unit price override: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to delete unit price override: %w", err)
+	}
+	if affected == 0 {
+		return ErrOrgUnitPriceNotFound
+	}
+	return nil
+}
+
+const regionalModifierColumns = `id, state, county, zip, region, factor, tax_rate, effective_date,
+	source, created_at, updated_at`
+
+func scanRegionalModifier(row interface{ Scan(...interface{}) error }) (*models.RegionalCostModifier, error) {
+	var modifier models.RegionalCostModifier
+	err := row.Scan(
+		&modifier.ID,
+		&modifier.State,
+		&modifier.County,
+		&modifier.ZIP,
+		&modifier.Region,
+		&modifier.Factor,
+		&modifier.TaxRate,
+		&modifier.EffectiveDate,
+		&modifier.Source,
+		&modifier.CreatedAt,
+		&modifier.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &modifier, nil
+}
+
+// ImportRegionalModifiers upserts regional cost modifiers from a CSV file,
+// replacing any modifier for the same location and effective date. Rows
+// without an effective date take effect on effective. It is all or nothing.
+func (s *PricingService) ImportRegionalModifiers(ctx context.Context, source *string, effective time.Time, r io.Reader) (int, error) {
+	rows, err := pricing.ParseModifiersCSV(r, pricing.Defaults{EffectiveDate: effective})
+	if err != nil {
+		return 0, err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO regional_cost_modifiers (
+			id, state, county, zip, region, factor, tax_rate, effective_date, source, created_at, updated_at
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		ON CONFLICT (state, county, zip, effective_date) DO UPDATE SET
+			region = EXCLUDED.region,
+			factor = EXCLUDED.factor,
+			tax_rate = EXCLUDED.tax_rate,
+			source = EXCLUDED.source,
+			updated_at = EXCLUDED.updated_at`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prepare regional modifier insert: %w", err)
+	}
+	defer stmt.Close()
+
+	now := time.Now()
+	for _, row := range rows {
+		_, err := stmt.ExecContext(ctx,
+			uuid.New().String(), row.State, row.County, row.ZIP, row.Region, row.Factor, row.TaxRate,
+			pricing.FormatDate(row.EffectiveDate), source, now, now,
+		)
+		if err != nil {
+			return 0, fmt.Errorf("failed to save regional modifier on line %d: %w", row.Line, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit regional modifiers: %w", err)
+	}
+
+	return len(rows), nil
+}
+
+// ListRegionalModifiers returns regional modifiers, optionally for one state,
+// ordered by location and newest first
+func (s *PricingService) ListRegionalModifiers(ctx context.Context, state string) ([]models.RegionalCostModifier, error) {
+	query := `SELECT ` + regionalModifierColumns + ` FROM regional_cost_modifiers`
+	args := []interface{}{}
+	if state != "" {
+		query += ` WHERE state = $1`
+		args = append(args, state)
+	}
+	query += ` ORDER BY state, county, zip, effective_date DESC`
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get regional modifiers: %w", err)
+	}
+	defer rows.Close()
+
+	modifiers := []models.RegionalCostModifier{}
+	for rows.Next() {
+		modifier, err := scanRegionalModifier(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan regional modifier: %w", err)
+		}
+		modifiers = append(modifiers, *modifier)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate regional modifiers: %w", err)
+	}
+
+	return modifiers, nil
+}
+
+// ResolveAdjustment finds the regional modifier in effect for a location on a
+// date. A ZIP modifier beats a county one, which beats the state's; within a
+// level the latest effective date wins. Locations without a state, or with no
+// matching modifier, are priced at national rates.
+func (s *PricingService) ResolveAdjustment(ctx context.Context, loc pricing.Location, asOf time.Time) (pricing.Adjustment, error) {
+	if loc.State == "" {
+		return pricing.NoAdjustment("no state on file"), nil
+	}
+
+	modifier, err := scanRegionalModifier(s.db.QueryRowContext(ctx, `
+		SELECT `+regionalModifierColumns+`
+		FROM regional_cost_modifiers
+		WHERE state = $1 AND effective_date <= $4
+			AND ((zip <> '' AND zip = $2)
+				OR (zip = '' AND county <> '' AND county = $3)
+				OR (zip = '' AND county = ''))
+		ORDER BY (zip <> '') DESC, (county <> '') DESC, effective_date DESC
+		LIMIT 1`,
+		loc.State, loc.ZIP, loc.County, pricing.FormatDate(asOf),
+	))
+	if err == sql.ErrNoRows {
+		return pricing.NoAdjustment("no regional modifier for " + loc.String()), nil
+	}
+	if err != nil {
+		return pricing.Adjustment{}, fmt.Errorf("failed to get regional modifier: %w", err)
+	}
+
+	matched := pricing.Location{State: modifier.State, County: modifier.County, ZIP: modifier.ZIP}
+	basis := matched.String()
+	if modifier.ZIP != "" {
+		basis = "ZIP " + modifier.ZIP
+	}
+	return pricing.Adjustment{
+		Region:     modifier.Region,
+		Factor:     modifier.Factor,
+		TaxRate:    modifier.TaxRate,
+		Basis:      basis,
+		ModifierID: &modifier.ID,
+	}, nil
+}
+
+// ResolvePropertyAdjustment finds the regional modifier in effect today for a
+// property's parsed address
+func (s *PricingService) ResolvePropertyAdjustment(ctx context.Context, property *models.Property) (pricing.Adjustment, error) {
+	var location pricing.Location
+	if property.AddressState != nil {
+		location.State = *property.AddressState
+	}
+	if property.AddressCounty != nil {
+		location.County = *property.AddressCounty
+	}
+	if property.AddressZIP != nil {
+		location.ZIP = *property.AddressZIP
+	}
+	return s.ResolveAdjustment(ctx, location, today())
+}
+
+type queryRower interface {
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+func upsertOrgUnitPrice(ctx context.Context, db queryRower, orgID, userID string, row pricing.Row, notes *string) (*models.OrgUnitPrice, error) {
+	now := time.Now()
+	price, err := scanOrgUnitPrice(db.QueryRowContext(ctx, `
+		INSERT INTO org_unit_prices (
+			id, organization_id, code, description, unit, unit_cost, region,
+			effective_date, notes, created_by_user_id, created_at, updated_at
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		ON CONFLICT (organization_id, code, region, effective_date) DO UPDATE SET
+			description = EXCLUDED.description,
+			unit = EXCLUDED.unit,
+			unit_cost = EXCLUDED.unit_cost,
+			notes = COALESCE(EXCLUDED.notes, org_unit_prices.notes),
+			updated_at = EXCLUDED.updated_at
+		RETURNING `+orgUnitPriceColumns,
+		uuid.New().String(), orgID, row.Code, row.Description, row.Unit, row.UnitCost, row.Region,
+		pricing.FormatDate(row.EffectiveDate), notes, userID, now, now,
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to save unit price override: %w", err)
+	}
+	return price, nil
+}
+
+// today is the current UTC date, used as the default effective date
+func today() time.Time {
+	return time.Now().UTC().Truncate(24 * time.Hour)
+}
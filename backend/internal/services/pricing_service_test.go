@@ -0,0 +1,194 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/claimcoach/backend/internal/pricing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPricingService_ResolvePricesLayersSources(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	effective := time.Date(2026, 7, 1, 0, 0, 0, 0, time.UTC)
+	mock.ExpectQuery(`FROM unit_prices up`).
+		WithArgs("tx-houston", pricing.DefaultRegion, "2026-10-18").
+		WillReturnRows(sqlmock.NewRows([]string{"code", "description", "unit", "unit_cost", "material_cost", "labor_cost", "region", "effective_date", "id", "name"}).
+			AddRow("RFG_SHINGLE_ARCH", "", "SQ", 310.0, nil, nil, "tx-houston", effective, "list-1", "Q3 Houston").
+			AddRow("GTR_ALUM", "Gutter", "LF", 8.9, 4.4, 4.5, "national", effective, "list-2", "Q3 National"))
+	mock.ExpectQuery(`FROM org_unit_prices`).
+		WithArgs("org-1", "tx-houston", "2026-10-18").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "code", "description", "unit", "unit_cost", "region", "effective_date"}).
+			AddRow("override-1", "GTR_ALUM", "", "LF", 7.5, "", effective))
+
+	service := NewPricingService(db)
+	prices, err := service.ResolvePrices(context.Background(), "org-1", " TX-Houston ", time.Date(2026, 10, 18, 15, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+
+	shingles := prices["RFG_SHINGLE_ARCH"]
+	assert.Equal(t, 310.0, shingles.UnitCost)
+	assert.Equal(t, pricing.SourcePriceList, shingles.Source.Type)
+	assert.Equal(t, "Q3 Houston", shingles.Source.Name)
+	assert.Equal(t, "2026-07-01", shingles.Source.EffectiveDate)
+	// A blank description falls back to the baseline's
+	assert.Equal(t, pricing.Baseline()["RFG_SHINGLE_ARCH"].Description, shingles.Description)
+
+	gutter := prices["GTR_ALUM"]
+	assert.Equal(t, 7.5, gutter.UnitCost)
+	assert.Equal(t, pricing.SourceOrgOverride, gutter.Source.Type)
+	assert.Equal(t, "override-1", *gutter.Source.ID)
+	assert.Equal(t, "Gutter", gutter.Description)
+
+	assert.Equal(t, pricing.SourceBaseline, prices["RFG_TEAROFF"].Source.Type)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPricingService_ImportPriceList(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO price_lists`).
+		WithArgs(sqlmock.AnyArg(), "Q3 National", nil, 2, nil, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "source", "row_count", "imported_by_user_id", "imported_at"}).
+			AddRow("list-1", "Q3 National", nil, 2, nil, time.Now()))
+	insert := mock.ExpectPrepare(`INSERT INTO unit_prices`)
+	insert.ExpectExec().
+		WithArgs(sqlmock.AnyArg(), "list-1", "RFG_TEAROFF", "", "SQ", "national", "2026-07-01", nil, nil, 70.0).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	insert.ExpectExec().
+		WithArgs(sqlmock.AnyArg(), "list-1", "GTR_ALUM", "", "LF", "tx-houston", "2026-07-01", nil, nil, 8.5).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	service := NewPricingService(db)
+	list, err := service.ImportPriceList(context.Background(), ImportPriceListInput{
+		Name:          "Q3 National",
+		EffectiveDate: time.Date(2026, 7, 1, 0, 0, 0, 0, time.UTC),
+	}, strings.NewReader("code,unit,unit_cost,region\nRFG_TEAROFF,SQ,70,\nGTR_ALUM,LF,8.50,tx-houston\n"))
+
+	require.NoError(t, err)
+	assert.Equal(t, 2, list.RowCount)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPricingService_ImportPriceListStoresNothingWhenARowIsInvalid(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	service := NewPricingService(db)
+	_, err = service.ImportPriceList(context.Background(), ImportPriceListInput{Name: "Bad"},
+		strings.NewReader("code,unit,unit_cost\nRFG_TEAROFF,SQ,70\n"))
+
+	var importErr *pricing.ImportError
+	require.True(t, errors.As(err, &importErr))
+	assert.Equal(t, "effective_date is required", importErr.Rows[0].Message)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPricingService_UpsertOrgUnitPriceValidates(t *testing.T) {
+	service := NewPricingService(nil)
+	cost := 10.0
+
+	_, err := service.UpsertOrgUnitPrice(context.Background(), "org-1", "user-1", OrgUnitPriceInput{Code: "RFG_TEAROFF", Unit: "BUNDLE", UnitCost: &cost})
+	assert.ErrorIs(t, err, ErrInvalidUnitPrice)
+
+	negative := -1.0
+	_, err = service.UpsertOrgUnitPrice(context.Background(), "org-1", "user-1", OrgUnitPriceInput{Code: "RFG_TEAROFF", Unit: "SQ", UnitCost: &negative})
+	assert.ErrorIs(t, err, ErrInvalidUnitPrice)
+}
+
+func TestPricingService_DeleteOrgUnitPriceNotFound(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectExec(`DELETE FROM org_unit_prices`).
+		WithArgs("override-1", "org-2").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	err = NewPricingService(db).DeleteOrgUnitPrice(context.Background(), "org-2", "override-1")
+	assert.ErrorIs(t, err, ErrOrgUnitPriceNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+var regionalModifierRow = []string{"id", "state", "county", "zip", "region", "factor", "tax_rate", "effective_date", "source", "created_at", "updated_at"}
+
+func TestPricingService_ResolveAdjustment(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	effective := time.Date(2026, 7, 1, 0, 0, 0, 0, time.UTC)
+	mock.ExpectQuery(`FROM regional_cost_modifiers`).
+		WithArgs("TX", "77002", "harris", "2026-10-18").
+		WillReturnRows(sqlmock.NewRows(regionalModifierRow).
+			AddRow("modifier-1", "TX", "", "77002", "tx-houston", 1.08, 0.0825, effective, nil, effective, effective))
+	mock.ExpectQuery(`FROM regional_cost_modifiers`).
+		WithArgs("TX", "", "harris", "2026-10-18").
+		WillReturnRows(sqlmock.NewRows(regionalModifierRow).
+			AddRow("modifier-2", "TX", "harris", "", "tx-houston", 1.04, 0.0825, effective, nil, effective, effective))
+	mock.ExpectQuery(`FROM regional_cost_modifiers`).
+		WithArgs("VT", "", "", "2026-10-18").
+		WillReturnRows(sqlmock.NewRows(regionalModifierRow))
+
+	service := NewPricingService(db)
+	asOf := time.Date(2026, 10, 18, 15, 0, 0, 0, time.UTC)
+
+	adjustment, err := service.ResolveAdjustment(context.Background(), pricing.Location{State: "TX", County: "harris", ZIP: "77002"}, asOf)
+	require.NoError(t, err)
+	assert.Equal(t, "tx-houston", adjustment.Region)
+	assert.Equal(t, 1.08, adjustment.Factor)
+	assert.Equal(t, 0.0825, adjustment.TaxRate)
+	assert.Equal(t, "ZIP 77002", adjustment.Basis)
+	assert.Equal(t, "modifier-1", *adjustment.ModifierID)
+
+	adjustment, err = service.ResolveAdjustment(context.Background(), pricing.Location{State: "TX", County: "harris"}, asOf)
+	require.NoError(t, err)
+	assert.Equal(t, "Harris County, TX", adjustment.Basis)
+
+	adjustment, err = service.ResolveAdjustment(context.Background(), pricing.Location{State: "VT"}, asOf)
+	require.NoError(t, err)
+	assert.Equal(t, pricing.NoAdjustment("no regional modifier for VT"), adjustment)
+
+	// Without a state there is nothing to look up
+	adjustment, err = service.ResolveAdjustment(context.Background(), pricing.Location{ZIP: "77002"}, asOf)
+	require.NoError(t, err)
+	assert.Equal(t, 1.0, adjustment.Factor)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPricingService_ImportRegionalModifiers(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	source := "2026 cost survey"
+	mock.ExpectBegin()
+	insert := mock.ExpectPrepare(`INSERT INTO regional_cost_modifiers`)
+	insert.ExpectExec().
+		WithArgs(sqlmock.AnyArg(), "TX", "", "", "national", 0.96, 0.0, "2026-07-01", &source, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	insert.ExpectExec().
+		WithArgs(sqlmock.AnyArg(), "TX", "", "77002", "tx-houston", 1.08, 0.0825, "2026-07-01", &source, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	service := NewPricingService(db)
+	count, err := service.ImportRegionalModifiers(context.Background(), &source, time.Date(2026, 7, 1, 0, 0, 0, 0, time.UTC),
+		strings.NewReader("state,zip,region,factor,tax_rate\nTexas,,,0.96,\nTX,77002,tx-houston,1.08,0.0825\n"))
+
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
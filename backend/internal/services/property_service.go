@@ -2,13 +2,18 @@ package services
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
+	"github.com/claimcoach/backend/internal/address"
 	"github.com/claimcoach/backend/internal/models"
 	"github.com/google/uuid"
 )
 
+var ErrInvalidPropertyAddress = errors.New("invalid property address")
+
 type PropertyService struct {
 	db *sql.DB
 }
@@ -17,11 +22,17 @@ func NewPropertyService(db *sql.DB) *PropertyService {
 	return &PropertyService{db: db}
 }
 
+// Address components are parsed from legal_address. AddressState and
+// AddressZIP correct a parse that got them wrong; AddressCounty can't be
+// parsed and is only set explicitly.
 type CreatePropertyInput struct {
 	Nickname        string  `json:"nickname" binding:"required"`
 	LegalAddress    string  `json:"legal_address" binding:"required"`
 	OwnerEntityName string  `json:"owner_entity_name" binding:"required"`
 	MortgageBankID  *string `json:"mortgage_bank_id"`
+	AddressState    *string `json:"address_state"`
+	AddressZIP      *string `json:"address_zip"`
+	AddressCounty   *string `json:"address_county"`
 }
 
 type UpdatePropertyInput struct {
@@ -29,49 +40,27 @@ type UpdatePropertyInput struct {
 	LegalAddress    *string `json:"legal_address"`
 	OwnerEntityName *string `json:"owner_entity_name"`
 	MortgageBankID  *string `json:"mortgage_bank_id"`
+	AddressState    *string `json:"address_state"`
+	AddressZIP      *string `json:"address_zip"`
+	AddressCounty   *string `json:"address_county"`
 }
 
-func (s *PropertyService) CreateProperty(input CreatePropertyInput, organizationID string) (*models.Property, error) {
-	property := &models.Property{
-		ID:              uuid.New().String(),
-		OrganizationID:  organizationID,
-		Nickname:        input.Nickname,
-		LegalAddress:    input.LegalAddress,
-		OwnerEntityName: input.OwnerEntityName,
-		MortgageBankID:  input.MortgageBankID,
-		Status:          "draft",
-		CreatedAt:       time.Now(),
-		UpdatedAt:       time.Now(),
-	}
-
-	query := `
-		INSERT INTO properties (
-			id, organization_id, nickname, legal_address, lat, lng,
-			owner_entity_name, mortgage_bank_id, status, created_at, updated_at
-		)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
-		RETURNING id, organization_id, nickname, legal_address, lat, lng,
-			owner_entity_name, mortgage_bank_id, status, created_at, updated_at
-	`
+const propertyColumns = `id, organization_id, nickname, legal_address,
+	address_street, address_city, address_state, address_zip, address_county, lat, lng,
+	owner_entity_name, mortgage_bank_id, status, created_at, updated_at`
 
-	err := s.db.QueryRow(
-		query,
-		property.ID,
-		property.OrganizationID,
-		property.Nickname,
-		property.LegalAddress,
-		nil, // lat
-		nil, // lng
-		property.OwnerEntityName,
-		property.MortgageBankID,
-		property.Status,
-		property.CreatedAt,
-		property.UpdatedAt,
-	).Scan(
+func scanProperty(row interface{ Scan(...interface{}) error }) (*models.Property, error) {
+	var property models.Property
+	err := row.Scan(
 		&property.ID,
 		&property.OrganizationID,
 		&property.Nickname,
 		&property.LegalAddress,
+		&property.AddressStreet,
+		&property.AddressCity,
+		&property.AddressState,
+		&property.AddressZIP,
+		&property.AddressCounty,
 		&property.Lat,
 		&property.Lng,
 		&property.OwnerEntityName,
@@ -80,7 +69,86 @@ func (s *PropertyService) CreateProperty(input CreatePropertyInput, organization
 		&property.CreatedAt,
 		&property.UpdatedAt,
 	)
+	if err != nil {
+		return nil, err
+	}
+	return &property, nil
+}
 
+// parseAddress splits a legal address into components, applying explicit
+// state and ZIP corrections
+func parseAddress(legalAddress string, state, zip *string) (address.Components, error) {
+	components := address.Parse(legalAddress)
+	if state != nil {
+		components.State = ""
+		if strings.TrimSpace(*state) != "" {
+			code, ok := address.NormalizeState(*state)
+			if !ok {
+				return components, fmt.Errorf("%w: unknown state %q", ErrInvalidPropertyAddress, *state)
+			}
+			components.State = code
+		}
+	}
+	if zip != nil {
+		components.ZIP = strings.TrimSpace(*zip)
+		if components.ZIP != "" && !address.ValidZIP(components.ZIP) {
+			return components, fmt.Errorf("%w: zip must be five digits", ErrInvalidPropertyAddress)
+		}
+	}
+	return components, nil
+}
+
+// normalizeCounty stores counties without their "County" suffix so they match
+// regional modifiers; a blank county clears it
+func normalizeCounty(county *string) *string {
+	if county == nil {
+		return nil
+	}
+	return nullIfEmpty(address.NormalizeCounty(*county))
+}
+
+func nullIfEmpty(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+func (s *PropertyService) CreateProperty(input CreatePropertyInput, organizationID string) (*models.Property, error) {
+	components, err := parseAddress(input.LegalAddress, input.AddressState, input.AddressZIP)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	query := `
+		INSERT INTO properties (
+			id, organization_id, nickname, legal_address,
+			address_street, address_city, address_state, address_zip, address_county, lat, lng,
+			owner_entity_name, mortgage_bank_id, status, created_at, updated_at
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)
+		RETURNING ` + propertyColumns
+
+	property, err := scanProperty(s.db.QueryRow(
+		query,
+		uuid.New().String(),
+		organizationID,
+		input.Nickname,
+		input.LegalAddress,
+		nullIfEmpty(components.Street),
+		nullIfEmpty(components.City),
+		nullIfEmpty(components.State),
+		nullIfEmpty(components.ZIP),
+		normalizeCounty(input.AddressCounty),
+		nil, // lat
+		nil, // lng
+		input.OwnerEntityName,
+		input.MortgageBankID,
+		"draft",
+		now,
+		now,
+	))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create property: %w", err)
 	}
@@ -90,8 +158,7 @@ func (s *PropertyService) CreateProperty(input CreatePropertyInput, organization
 
 func (s *PropertyService) GetProperties(organizationID string) ([]models.Property, error) {
 	query := `
-		SELECT id, organization_id, nickname, legal_address, lat, lng,
-			owner_entity_name, mortgage_bank_id, status, created_at, updated_at
+		SELECT ` + propertyColumns + `
 		FROM properties
 		WHERE organization_id = $1
 		ORDER BY created_at DESC
@@ -105,24 +172,11 @@ func (s *PropertyService) GetProperties(organizationID string) ([]models.Propert
 
 	properties := []models.Property{}
 	for rows.Next() {
-		var property models.Property
-		err := rows.Scan(
-			&property.ID,
-			&property.OrganizationID,
-			&property.Nickname,
-			&property.LegalAddress,
-			&property.Lat,
-			&property.Lng,
-			&property.OwnerEntityName,
-			&property.MortgageBankID,
-			&property.Status,
-			&property.CreatedAt,
-			&property.UpdatedAt,
-		)
+		property, err := scanProperty(rows)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan property: %w", err)
 		}
-		properties = append(properties, property)
+		properties = append(properties, *property)
 	}
 
 	if err = rows.Err(); err != nil {
@@ -134,27 +188,12 @@ func (s *PropertyService) GetProperties(organizationID string) ([]models.Propert
 
 func (s *PropertyService) GetProperty(id string, organizationID string) (*models.Property, error) {
 	query := `
-		SELECT id, organization_id, nickname, legal_address, lat, lng,
-			owner_entity_name, mortgage_bank_id, status, created_at, updated_at
+		SELECT ` + propertyColumns + `
 		FROM properties
 		WHERE id = $1 AND organization_id = $2
 	`
 
-	var property models.Property
-	err := s.db.QueryRow(query, id, organizationID).Scan(
-		&property.ID,
-		&property.OrganizationID,
-		&property.Nickname,
-		&property.LegalAddress,
-		&property.Lat,
-		&property.Lng,
-		&property.OwnerEntityName,
-		&property.MortgageBankID,
-		&property.Status,
-		&property.CreatedAt,
-		&property.UpdatedAt,
-	)
-
+	property, err := scanProperty(s.db.QueryRow(query, id, organizationID))
 	if err == sql.ErrNoRows {
 		return nil, fmt.Errorf("property not found")
 	}
@@ -162,7 +201,7 @@ func (s *PropertyService) GetProperty(id string, organizationID string) (*models
 		return nil, fmt.Errorf("failed to get property: %w", err)
 	}
 
-	return &property, nil
+	return property, nil
 }
 
 func (s *PropertyService) UpdateProperty(id string, organizationID string, input UpdatePropertyInput) (*models.Property, error) {
@@ -177,53 +216,62 @@ func (s *PropertyService) UpdateProperty(id string, organizationID string, input
 	args := []interface{}{time.Now()}
 	argPos := 2
 
-	if input.Nickname != nil {
-		query += fmt.Sprintf(", nickname = $%d", argPos)
-		args = append(args, *input.Nickname)
+	set := func(column string, value interface{}) {
+		query += fmt.Sprintf(", %s = $%d", column, argPos)
+		args = append(args, value)
 		argPos++
 	}
 
-	if input.LegalAddress != nil {
-		query += fmt.Sprintf(", legal_address = $%d", argPos)
-		args = append(args, *input.LegalAddress)
-		argPos++
+	if input.Nickname != nil {
+		set("nickname", *input.Nickname)
+	}
+
+	// Re-parse the address when it or a correction to it changes
+	if input.LegalAddress != nil || input.AddressState != nil || input.AddressZIP != nil {
+		legalAddress := existing.LegalAddress
+		if input.LegalAddress != nil {
+			legalAddress = *input.LegalAddress
+			set("legal_address", legalAddress)
+		}
+		state, zip := input.AddressState, input.AddressZIP
+		if input.LegalAddress == nil {
+			// Keep the stored value of whichever component isn't being corrected
+			if state == nil {
+				state = existing.AddressState
+			}
+			if zip == nil {
+				zip = existing.AddressZIP
+			}
+		}
+		components, err := parseAddress(legalAddress, state, zip)
+		if err != nil {
+			return nil, err
+		}
+		set("address_street", nullIfEmpty(components.Street))
+		set("address_city", nullIfEmpty(components.City))
+		set("address_state", nullIfEmpty(components.State))
+		set("address_zip", nullIfEmpty(components.ZIP))
+	}
+
+	if input.AddressCounty != nil {
+		set("address_county", normalizeCounty(input.AddressCounty))
 	}
 
 	if input.OwnerEntityName != nil {
-		query += fmt.Sprintf(", owner_entity_name = $%d", argPos)
-		args = append(args, *input.OwnerEntityName)
-		argPos++
+		set("owner_entity_name", *input.OwnerEntityName)
 	}
 
 	if input.MortgageBankID != nil {
-		query += fmt.Sprintf(", mortgage_bank_id = $%d", argPos)
-		args = append(args, *input.MortgageBankID)
-		argPos++
+		set("mortgage_bank_id", *input.MortgageBankID)
 	}
 
 	query += fmt.Sprintf(" WHERE id = $%d AND organization_id = $%d", argPos, argPos+1)
 	args = append(args, id, organizationID)
 
 	query += `
-		RETURNING id, organization_id, nickname, legal_address, lat, lng,
-			owner_entity_name, mortgage_bank_id, status, created_at, updated_at
-	`
-
-	var property models.Property
-	err = s.db.QueryRow(query, args...).Scan(
-		&property.ID,
-		&property.OrganizationID,
-		&property.Nickname,
-		&property.LegalAddress,
-		&property.Lat,
-		&property.Lng,
-		&property.OwnerEntityName,
-		&property.MortgageBankID,
-		&property.Status,
-		&property.CreatedAt,
-		&property.UpdatedAt,
-	)
+		RETURNING ` + propertyColumns
 
+	property, err := scanProperty(s.db.QueryRow(query, args...))
 	if err != nil {
 		return nil, fmt.Errorf("failed to update property: %w", err)
 	}
@@ -233,5 +281,5 @@ func (s *PropertyService) UpdateProperty(id string, organizationID string, input
 		return existing, nil
 	}
 
-	return &property, nil
+	return property, nil
 }
@@ -7,8 +7,8 @@ import (
 	"fmt"
 	"log"
 
-	"github.com/claimcoach/backend/internal/llm"
 	"github.com/claimcoach/backend/internal/models"
+	"github.com/claimcoach/backend/internal/prompts"
 	"github.com/google/uuid"
 )
 
@@ -32,6 +32,16 @@ func NewRCVDemandService(db *sql.DB, llmClient LLMClient, claimService *ClaimSer
 
 // GenerateRCVDemandLetter generates a demand letter for outstanding RCV payments
 func (s *RCVDemandService) GenerateRCVDemandLetter(ctx context.Context, claimID, userID, orgID string) (string, error) {
+	return s.generateRCVDemandLetter(ctx, claimID, userID, orgID, nil)
+}
+
+// StreamRCVDemandLetter generates a demand letter like GenerateRCVDemandLetter,
+// passing the text to onDelta as the LLM generates it
+func (s *RCVDemandService) StreamRCVDemandLetter(ctx context.Context, claimID, userID, orgID string, onDelta func(text string) error) (string, error) {
+	return s.generateRCVDemandLetter(ctx, claimID, userID, orgID, onDelta)
+}
+
+func (s *RCVDemandService) generateRCVDemandLetter(ctx context.Context, claimID, userID, orgID string, onDelta func(text string) error) (string, error) {
 	// Get claim details
 	claim, err := s.claimService.GetClaim(claimID, orgID)
 	if err != nil {
@@ -60,23 +70,15 @@ func (s *RCVDemandService) GenerateRCVDemandLetter(ctx context.Context, claimID,
 	}
 
 	// Build prompt
-	prompt := s.buildRCVDemandPrompt(claimContext, summary.TotalACVReceived, summary.ExpectedRCV, rcvOutstanding)
+	rendered, err := prompts.Render(prompts.RCVDemand, s.rcvDemandPromptData(claimContext, summary.TotalACVReceived, summary.ExpectedRCV, rcvOutstanding))
+	if err != nil {
+		return "", err
+	}
 
 	// Call LLM
-	messages := []llm.Message{
-		{
-			Role: "system",
-			Content: `You are a professional insurance claim specialist writing RCV (Replacement Cost Value) demand letters.
-Your letters should be formal, professional, and persuasive while maintaining a respectful tone.
-Return only the letter content, no additional commentary or explanation.`,
-		},
-		{
-			Role:    "user",
-			Content: prompt,
-		},
-	}
+	messages := rendered.Messages()
 
-	response, err := s.llmClient.Chat(ctx, messages, 0.3, 1500)
+	response, err := chatWithDeltas(ctx, s.llmClient, messages, 0.3, 1500, onDelta)
 	if err != nil {
 		return "", fmt.Errorf("LLM API call failed: %w", err)
 	}
@@ -88,7 +90,7 @@ Return only the letter content, no additional commentary or explanation.`,
 	letterContent := response.Choices[0].Message.Content
 
 	// Save demand letter
-	demandLetterID, err := s.saveRCVDemandLetter(ctx, claimID, userID, letterContent, summary.TotalACVReceived, summary.ExpectedRCV, rcvOutstanding)
+	demandLetterID, err := s.saveRCVDemandLetter(ctx, claimID, userID, letterContent, summary.TotalACVReceived, summary.ExpectedRCV, rcvOutstanding, rendered.Version)
 	if err != nil {
 		return "", fmt.Errorf("failed to save demand letter: %w", err)
 	}
@@ -137,6 +139,7 @@ func (s *RCVDemandService) GetRCVDemandLetter(ctx context.Context, demandLetterI
 		&letter.UpdatedAt,
 		&letter.SentAt,
 		&letter.SentToEmail,
+		&letter.PromptVersion,
 	)
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -181,6 +184,7 @@ func (s *RCVDemandService) ListRCVDemandLettersByClaimID(ctx context.Context, cl
 			&letter.UpdatedAt,
 			&letter.SentAt,
 			&letter.SentToEmail,
+			&letter.PromptVersion,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan demand letter: %w", err)
@@ -282,58 +286,35 @@ func (s *RCVDemandService) getClaimContext(ctx context.Context, claimID string)
 	return context, nil
 }
 
-// Helper: buildRCVDemandPrompt builds the LLM prompt for demand letter generation
-func (s *RCVDemandService) buildRCVDemandPrompt(claimContext map[string]interface{}, acvReceived, rcvExpected, rcvOutstanding float64) string {
-	percentageOutstanding := (rcvOutstanding / rcvExpected) * 100
-
-	prompt := fmt.Sprintf(`Generate a professional RCV (Replacement Cost Value) demand letter for an insurance claim.
-
-CLAIM DETAILS:
-- Claim Number: %s
-- Property: %s (%s)
-- Loss Type: %s
-- Policy Number: %s
-- Carrier: %s
-
-PAYMENT SUMMARY:
-- ACV Received: $%.2f
-- RCV Expected: $%.2f
-- RCV Outstanding: $%.2f (%.1f%% of total RCV)
-
-REQUIREMENTS:
-1. Use formal business letter format
-2. Include current date
-3. Reference claim number and policy prominently
-4. Explain that ACV has been received and repairs completed
-5. Request the outstanding RCV payment
-6. Be professional and respectful but firm
-7. Request a response within 30 days
-8. Include appropriate closing
-
-Return only the letter content. Do not include any meta-commentary or explanations.`,
-		claimContext["claim_number"],
-		claimContext["property_nickname"],
-		claimContext["property_address"],
-		claimContext["loss_type"],
-		claimContext["policy_number"],
-		claimContext["carrier"],
-		acvReceived,
-		rcvExpected,
-		rcvOutstanding,
-		percentageOutstanding,
-	)
+// Helper: rcvDemandPromptData fills the demand letter prompt from the claim context
+func (s *RCVDemandService) rcvDemandPromptData(claimContext map[string]interface{}, acvReceived, rcvExpected, rcvOutstanding float64) *prompts.RCVDemandData {
+	value := func(key string) string {
+		v, _ := claimContext[key].(string)
+		return v
+	}
 
-	return prompt
+	return &prompts.RCVDemandData{
+		ClaimNumber:           value("claim_number"),
+		PropertyNickname:      value("property_nickname"),
+		PropertyAddress:       value("property_address"),
+		LossType:              value("loss_type"),
+		PolicyNumber:          value("policy_number"),
+		Carrier:               value("carrier"),
+		ACVReceived:           acvReceived,
+		RCVExpected:           rcvExpected,
+		RCVOutstanding:        rcvOutstanding,
+		PercentageOutstanding: (rcvOutstanding / rcvExpected) * 100,
+	}
 }
 
 // Helper: saveRCVDemandLetter saves the generated demand letter to database
-func (s *RCVDemandService) saveRCVDemandLetter(ctx context.Context, claimID, userID, content string, acvReceived, rcvExpected, rcvOutstanding float64) (string, error) {
+func (s *RCVDemandService) saveRCVDemandLetter(ctx context.Context, claimID, userID, content string, acvReceived, rcvExpected, rcvOutstanding float64, promptVersion string) (string, error) {
 	demandLetterID := uuid.New().String()
 
 	query := `
 		INSERT INTO rcv_demand_letters (
-			id, claim_id, content, acv_received, rcv_expected, rcv_outstanding, created_by_user_id
-		) VALUES ($1, $2, $3, $4, $5, $6, $7)
+			id, claim_id, content, acv_received, rcv_expected, rcv_outstanding, created_by_user_id, prompt_version
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
 	`
 
 	_, err := s.db.ExecContext(
@@ -346,6 +327,7 @@ func (s *RCVDemandService) saveRCVDemandLetter(ctx context.Context, claimID, use
 		rcvExpected,
 		rcvOutstanding,
 		userID,
+		promptVersion,
 	)
 	if err != nil {
 		return "", fmt.Errorf("failed to save demand letter: %w", err)
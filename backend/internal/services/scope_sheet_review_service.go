@@ -0,0 +1,323 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/claimcoach/backend/internal/config"
+	"github.com/claimcoach/backend/internal/models"
+	"github.com/google/uuid"
+)
+
+// Sentinel errors for scope sheet review
+var (
+	ErrScopeChangesEmpty = errors.New("notes or at least one comment is required")
+	ErrUnknownScopeArea  = errors.New("area not found on scope sheet")
+)
+
+// ScopeSheetReviewService lets a PM approve a submitted scope sheet revision or
+// send it back to the contractor with comments
+type ScopeSheetReviewService struct {
+	db           *sql.DB
+	cfg          *config.Config
+	claimService *ClaimService
+	emailService EmailService
+}
+
+// NewScopeSheetReviewService creates a new ScopeSheetReviewService instance
+func NewScopeSheetReviewService(db *sql.DB, cfg *config.Config, claimService *ClaimService, emailService EmailService) *ScopeSheetReviewService {
+	return &ScopeSheetReviewService{
+		db:           db,
+		cfg:          cfg,
+		claimService: claimService,
+		emailService: emailService,
+	}
+}
+
+// ScopeCommentInput is one PM comment; AreaID pins it to an area on the sheet
+type ScopeCommentInput struct {
+	AreaID  *string `json:"area_id"`
+	Comment string  `json:"comment" binding:"required"`
+}
+
+// RequestScopeChangesInput is the PM's change request for a revision
+type RequestScopeChangesInput struct {
+	Notes    *string             `json:"notes"`
+	Comments []ScopeCommentInput `json:"comments" binding:"dive"`
+}
+
+// RequestScopeChangesResult is the revision sent back and the draft reopened for the contractor
+type RequestScopeChangesResult struct {
+	ScopeSheet         *models.ScopeSheet `json:"scope_sheet"`
+	DraftID            string             `json:"draft_id"`
+	ContractorNotified bool               `json:"contractor_notified"`
+}
+
+// ApproveScopeSheet approves the revision awaiting review
+func (s *ScopeSheetReviewService) ApproveScopeSheet(ctx context.Context, claimID, scopeSheetID, orgID, userID string) (*models.ScopeSheet, error) {
+	if _, err := s.claimService.GetClaim(claimID, orgID); err != nil {
+		return nil, err
+	}
+
+	query := `
+		UPDATE scope_sheets
+		SET review_status = $1, reviewed_at = NOW(), reviewed_by_user_id = $2, updated_at = NOW()
+		WHERE id = $3 AND claim_id = $4 AND is_draft = false AND review_status = $5
+		RETURNING ` + scopeSheetColumns
+
+	row := s.db.QueryRowContext(ctx, query,
+		models.ScopeReviewApproved, userID, scopeSheetID, claimID, models.ScopeReviewPendingReview,
+	)
+	scopeSheet, err := scanScopeSheet(row)
+	if err == sql.ErrNoRows {
+		return nil, s.notReviewableError(ctx, claimID, scopeSheetID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to approve scope sheet: %w", err)
+	}
+
+	s.logActivity(claimID, userID, "scope_sheet_approved",
+		fmt.Sprintf("Scope sheet revision %d approved", derefInt(scopeSheet.Revision)),
+		map[string]interface{}{"scope_sheet_id": scopeSheet.ID, "revision": scopeSheet.Revision},
+	)
+
+	return scopeSheet, nil
+}
+
+// RequestChanges sends the revision awaiting review back to the contractor. The
+// PM's comments are stored against the revision and the claim's draft is reopened
+// from it, so the contractor's magic link opens the wizard with their previous
+// answers. An in-progress draft is kept as is and only linked to the revision.
+func (s *ScopeSheetReviewService) RequestChanges(ctx context.Context, claimID, scopeSheetID, orgID, userID string, input RequestScopeChangesInput) (*RequestScopeChangesResult, error) {
+	notes := trimmedOrNil(input.Notes)
+	if notes == nil && len(input.Comments) == 0 {
+		return nil, ErrScopeChangesEmpty
+	}
+
+	if _, err := s.claimService.GetClaim(claimID, orgID); err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	row := tx.QueryRowContext(ctx, `
+		SELECT `+scopeSheetColumns+`
+		FROM scope_sheets
+		WHERE id = $1 AND claim_id = $2 AND is_draft = false
+		FOR UPDATE
+	`, scopeSheetID, claimID)
+	scopeSheet, err := scanScopeSheet(row)
+	if err == sql.ErrNoRows {
+		return nil, ErrScopeSheetNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get scope sheet: %w", err)
+	}
+	if scopeSheet.ReviewStatus == nil || *scopeSheet.ReviewStatus != models.ScopeReviewPendingReview {
+		return nil, ErrScopeSheetNotReviewable
+	}
+
+	areaIDs := make(map[string]bool, len(scopeSheet.Areas))
+	for _, area := range scopeSheet.Areas {
+		areaIDs[area.ID] = true
+	}
+	for _, comment := range input.Comments {
+		if comment.AreaID != nil && !areaIDs[*comment.AreaID] {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownScopeArea, *comment.AreaID)
+		}
+	}
+
+	now := time.Now()
+	_, err = tx.ExecContext(ctx, `
+		UPDATE scope_sheets
+		SET review_status = $1, reviewed_at = $2, reviewed_by_user_id = $3, review_notes = $4, updated_at = $2
+		WHERE id = $5
+	`, models.ScopeReviewChangesRequested, now, userID, notes, scopeSheetID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update scope sheet review: %w", err)
+	}
+
+	comments := make([]models.ScopeSheetComment, 0, len(input.Comments))
+	for _, c := range input.Comments {
+		comment := models.ScopeSheetComment{
+			ID:              uuid.New().String(),
+			ScopeSheetID:    scopeSheetID,
+			AreaID:          c.AreaID,
+			Comment:         strings.TrimSpace(c.Comment),
+			CreatedByUserID: userID,
+			CreatedAt:       now,
+		}
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO scope_sheet_comments (id, scope_sheet_id, area_id, comment, created_by_user_id, created_at)
+			VALUES ($1, $2, $3, $4, $5, $6)
+		`, comment.ID, comment.ScopeSheetID, comment.AreaID, comment.Comment, comment.CreatedByUserID, comment.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to insert scope sheet comment: %w", err)
+		}
+		comments = append(comments, comment)
+	}
+
+	var draftID string
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO scope_sheets (
+			id, claim_id, areas, triage_selections, general_notes, catalog_version,
+			is_draft, draft_step, draft_saved_at, created_at, updated_at,
+			magic_link_id, reopened_from_id
+		)
+		SELECT $1, claim_id, areas, triage_selections, general_notes, catalog_version,
+			true, 0, $2, $2, $2,
+			magic_link_id, id
+		FROM scope_sheets
+		WHERE id = $3
+		ON CONFLICT (claim_id) WHERE is_draft = true
+		DO UPDATE SET
+			reopened_from_id = EXCLUDED.reopened_from_id,
+			updated_at       = EXCLUDED.updated_at
+		RETURNING id
+	`, uuid.New().String(), now, scopeSheetID).Scan(&draftID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reopen draft: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit change request: %w", err)
+	}
+
+	status := models.ScopeReviewChangesRequested
+	scopeSheet.ReviewStatus = &status
+	scopeSheet.ReviewedAt = &now
+	scopeSheet.ReviewedByUserID = &userID
+	scopeSheet.ReviewNotes = notes
+	scopeSheet.Comments = comments
+	scopeSheet.UpdatedAt = now
+
+	s.logActivity(claimID, userID, "scope_sheet_changes_requested",
+		fmt.Sprintf("Changes requested on scope sheet revision %d", derefInt(scopeSheet.Revision)),
+		map[string]interface{}{"scope_sheet_id": scopeSheetID, "revision": scopeSheet.Revision, "comment_count": len(comments)},
+	)
+
+	return &RequestScopeChangesResult{
+		ScopeSheet:         scopeSheet,
+		DraftID:            draftID,
+		ContractorNotified: s.notifyContractor(ctx, scopeSheet),
+	}, nil
+}
+
+// notifyContractor emails the contractor on the magic link that submitted the
+// revision. It reports false when that link can no longer be used, in which case
+// the PM needs to reissue it; email failures are only logged.
+func (s *ScopeSheetReviewService) notifyContractor(ctx context.Context, scopeSheet *models.ScopeSheet) bool {
+	if scopeSheet.MagicLinkID == nil {
+		return false
+	}
+
+	var token, contractorName, contractorEmail string
+	var propertyName, propertyAddress string
+	err := s.db.QueryRowContext(ctx, `
+		SELECT ml.token, ml.contractor_name, ml.contractor_email, p.nickname, p.legal_address
+		FROM magic_links ml
+		INNER JOIN claims c ON c.id = ml.claim_id
+		INNER JOIN properties p ON p.id = c.property_id
+		WHERE ml.id = $1 AND ml.status = 'active' AND ml.expires_at > NOW()
+	`, *scopeSheet.MagicLinkID).Scan(&token, &contractorName, &contractorEmail, &propertyName, &propertyAddress)
+	if err == sql.ErrNoRows {
+		return false
+	}
+	if err != nil {
+		fmt.Printf("Warning: failed to look up magic link for scope sheet %s: %v\n", scopeSheet.ID, err)
+		return false
+	}
+	if contractorEmail == "" {
+		return false
+	}
+
+	comments := make([]string, 0, len(scopeSheet.Comments))
+	for _, comment := range scopeSheet.Comments {
+		comments = append(comments, formatScopeComment(scopeSheet.Areas, comment))
+	}
+
+	emailInput := SendScopeChangesRequestedEmailInput{
+		To:              contractorEmail,
+		ContractorName:  contractorName,
+		PropertyName:    propertyName,
+		PropertyAddress: propertyAddress,
+		Revision:        derefInt(scopeSheet.Revision),
+		Comments:        comments,
+		MagicLinkURL:    fmt.Sprintf("%s/upload/%s", s.cfg.FrontendURL, token),
+	}
+	if scopeSheet.ReviewNotes != nil {
+		emailInput.ReviewNotes = *scopeSheet.ReviewNotes
+	}
+
+	if err := s.emailService.SendScopeChangesRequestedEmail(emailInput); err != nil {
+		fmt.Printf("Warning: failed to send scope changes email for scope sheet %s: %v\n", scopeSheet.ID, err)
+		return false
+	}
+	return true
+}
+
+// notReviewableError explains why a revision could not be reviewed
+func (s *ScopeSheetReviewService) notReviewableError(ctx context.Context, claimID, scopeSheetID string) error {
+	var exists bool
+	err := s.db.QueryRowContext(ctx,
+		`SELECT EXISTS(SELECT 1 FROM scope_sheets WHERE id = $1 AND claim_id = $2 AND is_draft = false)`,
+		scopeSheetID, claimID,
+	).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("failed to check scope sheet: %w", err)
+	}
+	if !exists {
+		return ErrScopeSheetNotFound
+	}
+	return ErrScopeSheetNotReviewable
+}
+
+func (s *ScopeSheetReviewService) logActivity(claimID, userID, activityType, description string, metadata map[string]interface{}) {
+	metadataJSON, _ := json.Marshal(metadata)
+	metadataStr := string(metadataJSON)
+
+	if err := s.claimService.createActivity(claimID, &userID, activityType, description, &metadataStr); err != nil {
+		// Don't fail the review if activity logging fails
+		fmt.Printf("Warning: failed to log activity: %v\n", err)
+	}
+}
+
+// formatScopeComment prefixes an area comment with the area's category
+func formatScopeComment(areas []models.ScopeArea, comment models.ScopeSheetComment) string {
+	if comment.AreaID == nil {
+		return comment.Comment
+	}
+	for _, area := range areas {
+		if area.ID == *comment.AreaID {
+			return area.Category + ": " + comment.Comment
+		}
+	}
+	return comment.Comment
+}
+
+func trimmedOrNil(s *string) *string {
+	if s == nil {
+		return nil
+	}
+	trimmed := strings.TrimSpace(*s)
+	if trimmed == "" {
+		return nil
+	}
+	return &trimmed
+}
+
+func derefInt(i *int) int {
+	if i == nil {
+		return 0
+	}
+	return *i
+}
@@ -0,0 +1,228 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/claimcoach/backend/internal/config"
+	"github.com/claimcoach/backend/internal/models"
+	"github.com/claimcoach/backend/internal/scopecatalog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var scopeSheetRowColumns = []string{
+	"id", "claim_id", "areas", "triage_selections", "general_notes", "catalog_version",
+	"is_draft", "draft_step", "draft_saved_at",
+	"revision", "review_status", "reviewed_at", "reviewed_by_user_id", "review_notes",
+	"magic_link_id", "reopened_from_id",
+	"submitted_at", "created_at", "updated_at",
+}
+
+// recordingScopeEmailService captures change request emails instead of logging them
+type recordingScopeEmailService struct {
+	MockEmailService
+	changesRequested []SendScopeChangesRequestedEmailInput
+}
+
+func (s *recordingScopeEmailService) SendScopeChangesRequestedEmail(input SendScopeChangesRequestedEmailInput) error {
+	s.changesRequested = append(s.changesRequested, input)
+	return nil
+}
+
+func newTestScopeSheetReviewService(db *sql.DB, emailService EmailService) *ScopeSheetReviewService {
+	propertyService := NewPropertyService(db)
+	claimService := NewClaimService(db, propertyService, NewPolicyService(db, nil, propertyService))
+	return NewScopeSheetReviewService(db, &config.Config{FrontendURL: "https://app.test"}, claimService, emailService)
+}
+
+// expectClaimOwnership expects ClaimService.GetClaim's ownership query. The
+// property and policy lookups that follow are best effort and left unmatched.
+func expectClaimOwnership(mock sqlmock.Sqlmock, claimID, orgID string) {
+	now := time.Now()
+	mock.ExpectQuery(`SELECT c.id, c.property_id(.+)FROM claims c`).
+		WithArgs(claimID, orgID).
+		WillReturnRows(sqlmock.NewRows([]string{
+			"id", "property_id", "policy_id", "claim_number", "loss_type", "incident_date",
+			"status", "filed_at", "description", "current_step", "steps_completed",
+			"contractor_email", "contractor_name", "contractor_photos_uploaded_at",
+			"deductible_comparison_result", "insurance_claim_number", "inspection_datetime",
+			"assigned_user_id", "adjuster_name", "adjuster_phone",
+			"meeting_datetime", "created_by_user_id", "created_at", "updated_at",
+			"contractor_estimate_total", "contractor_id",
+		}).AddRow(claimID, "property-1", "policy-1", nil, "wind", now,
+			"draft", nil, nil, 1, nil,
+			nil, nil, nil,
+			nil, nil, nil,
+			nil, nil, nil,
+			nil, "user-1", now, now,
+			nil, nil))
+}
+
+func pendingScopeSheetRow(t *testing.T) *sqlmock.Rows {
+	areas, err := json.Marshal([]models.ScopeArea{
+		{ID: "area-roof", Category: "Roof", CategoryKey: "roof"},
+		{ID: "area-kitchen", Category: "Kitchen", CategoryKey: "kitchen"},
+	})
+	require.NoError(t, err)
+
+	now := time.Now()
+	return sqlmock.NewRows(scopeSheetRowColumns).AddRow(
+		"sheet-2", "claim-1", areas, []byte(`["roof"]`), nil, "2026-10-18",
+		false, nil, nil,
+		2, models.ScopeReviewPendingReview, nil, nil, nil,
+		"link-1", nil,
+		now, now, now,
+	)
+}
+
+func TestScopeSheetReviewService_RequestChanges_RequiresNotesOrComments(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	service := newTestScopeSheetReviewService(db, NewMockEmailService())
+	blank := "   "
+
+	result, err := service.RequestChanges(context.Background(), "claim-1", "sheet-2", "org-1", "user-1",
+		RequestScopeChangesInput{Notes: &blank})
+
+	assert.Nil(t, result)
+	assert.ErrorIs(t, err, ErrScopeChangesEmpty)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestScopeSheetReviewService_RequestChanges_RejectsUnknownArea(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	service := newTestScopeSheetReviewService(db, NewMockEmailService())
+	expectClaimOwnership(mock, "claim-1", "org-1")
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT (.+) FROM scope_sheets\s+WHERE id = \$1 AND claim_id = \$2 AND is_draft = false\s+FOR UPDATE`).
+		WithArgs("sheet-2", "claim-1").
+		WillReturnRows(pendingScopeSheetRow(t))
+	mock.ExpectRollback()
+
+	areaID := "area-garage"
+	result, err := service.RequestChanges(context.Background(), "claim-1", "sheet-2", "org-1", "user-1",
+		RequestScopeChangesInput{Comments: []ScopeCommentInput{{AreaID: &areaID, Comment: "Missing photos"}}})
+
+	assert.Nil(t, result)
+	assert.ErrorIs(t, err, ErrUnknownScopeArea)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestScopeSheetReviewService_RequestChanges_ReopensDraftAndEmailsContractor(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	emailService := &recordingScopeEmailService{}
+	service := newTestScopeSheetReviewService(db, emailService)
+	expectClaimOwnership(mock, "claim-1", "org-1")
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT (.+) FROM scope_sheets\s+WHERE id = \$1 AND claim_id = \$2 AND is_draft = false\s+FOR UPDATE`).
+		WithArgs("sheet-2", "claim-1").
+		WillReturnRows(pendingScopeSheetRow(t))
+	mock.ExpectExec(`UPDATE scope_sheets\s+SET review_status = \$1`).
+		WithArgs(models.ScopeReviewChangesRequested, sqlmock.AnyArg(), "user-1", "Please recheck the roof", "sheet-2").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`INSERT INTO scope_sheet_comments`).
+		WithArgs(sqlmock.AnyArg(), "sheet-2", "area-roof", "Add the ridge vent measurements", "user-1", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`INSERT INTO scope_sheets(.+)SELECT(.+)ON CONFLICT \(claim_id\) WHERE is_draft = true`).
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), "sheet-2").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("draft-1"))
+	mock.ExpectCommit()
+	mock.ExpectExec(`INSERT INTO claim_activities`).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`SELECT ml.token(.+)FROM magic_links ml`).
+		WithArgs("link-1").
+		WillReturnRows(sqlmock.NewRows([]string{"token", "contractor_name", "contractor_email", "nickname", "legal_address"}).
+			AddRow("token-1", "Roofer", "roofer@example.com", "Oak Apartments", "1 Main St"))
+
+	notes := " Please recheck the roof "
+	areaID := "area-roof"
+	result, err := service.RequestChanges(context.Background(), "claim-1", "sheet-2", "org-1", "user-1",
+		RequestScopeChangesInput{
+			Notes:    &notes,
+			Comments: []ScopeCommentInput{{AreaID: &areaID, Comment: "Add the ridge vent measurements"}},
+		})
+
+	require.NoError(t, err)
+	assert.Equal(t, "draft-1", result.DraftID)
+	assert.True(t, result.ContractorNotified)
+	assert.Equal(t, models.ScopeReviewChangesRequested, *result.ScopeSheet.ReviewStatus)
+	require.Len(t, result.ScopeSheet.Comments, 1)
+
+	require.Len(t, emailService.changesRequested, 1)
+	sent := emailService.changesRequested[0]
+	assert.Equal(t, "roofer@example.com", sent.To)
+	assert.Equal(t, 2, sent.Revision)
+	assert.Equal(t, "Please recheck the roof", sent.ReviewNotes)
+	assert.Equal(t, []string{"Roof: Add the ridge vent measurements"}, sent.Comments)
+	assert.Equal(t, "https://app.test/upload/token-1", sent.MagicLinkURL)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestScopeSheetReviewService_ApproveScopeSheet_NotReviewable(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	service := newTestScopeSheetReviewService(db, NewMockEmailService())
+	expectClaimOwnership(mock, "claim-1", "org-1")
+	mock.ExpectQuery(`UPDATE scope_sheets\s+SET review_status = \$1`).
+		WithArgs(models.ScopeReviewApproved, "user-1", "sheet-1", "claim-1", models.ScopeReviewPendingReview).
+		WillReturnRows(sqlmock.NewRows(scopeSheetRowColumns))
+	mock.ExpectQuery(`SELECT EXISTS`).
+		WithArgs("sheet-1", "claim-1").
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+
+	scopeSheet, err := service.ApproveScopeSheet(context.Background(), "claim-1", "sheet-1", "org-1", "user-1")
+
+	assert.Nil(t, scopeSheet)
+	assert.ErrorIs(t, err, ErrScopeSheetNotReviewable)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestScopeSheetService_CreateScopeSheet_NumbersRevisionAndSupersedes(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	service := NewScopeSheetService(db)
+	linkID := "link-1"
+
+	mock.ExpectBegin()
+	expectClaimLock(mock, "claim-1")
+	mock.ExpectQuery(`INSERT INTO scope_sheets(.+)SELECT COALESCE\(MAX\(revision\), 0\) \+ 1`).
+		WithArgs(sqlmock.AnyArg(), "claim-1", sqlmock.AnyArg(), sqlmock.AnyArg(), nil, scopecatalog.Version,
+			sqlmock.AnyArg(), sqlmock.AnyArg(), models.ScopeReviewPendingReview, &linkID).
+		WillReturnRows(pendingScopeSheetRow(t))
+	mock.ExpectExec(`UPDATE scope_sheets\s+SET review_status = \$1`).
+		WithArgs(models.ScopeReviewSuperseded, "claim-1", sqlmock.AnyArg(),
+			models.ScopeReviewPendingReview, models.ScopeReviewChangesRequested).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`DELETE FROM scope_sheets WHERE claim_id = \$1 AND is_draft = true`).
+		WithArgs("claim-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	scopeSheet, err := service.CreateScopeSheet(context.Background(), "claim-1", &linkID, CreateScopeSheetInput{
+		Areas: []models.ScopeArea{
+			{ID: "area-roof", CategoryKey: "roof", Tags: []string{"Shingles_Damaged"}, Dimensions: map[string]float64{"square_footage": 2400}},
+		},
+	})
+
+	require.NoError(t, err)
+	require.NotNil(t, scopeSheet.Revision)
+	assert.Equal(t, 2, *scopeSheet.Revision)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
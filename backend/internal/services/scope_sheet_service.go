@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/claimcoach/backend/internal/models"
+	"github.com/claimcoach/backend/internal/scopecatalog"
 	"github.com/google/uuid"
 )
 
@@ -17,8 +18,20 @@ var (
 	ErrTokenInvalid     = errors.New("magic link token is invalid or expired")
 	ErrDraftNotFound    = errors.New("draft not found")
 	ErrInvalidDraftStep = errors.New("draft_step must be a non-negative integer")
+
+	ErrScopeSheetNotFound      = errors.New("scope sheet not found")
+	ErrScopeSheetNotReviewable = errors.New("scope sheet is not awaiting review")
 )
 
+// scopeSheetColumns is the column list scanScopeSheet expects, in order
+const scopeSheetColumns = `
+	id, claim_id, areas, triage_selections, general_notes, catalog_version,
+	is_draft, draft_step, draft_saved_at,
+	revision, review_status, reviewed_at, reviewed_by_user_id, review_notes,
+	magic_link_id, reopened_from_id,
+	submitted_at, created_at, updated_at
+`
+
 type ScopeSheetService struct {
 	db *sql.DB
 }
@@ -37,8 +50,15 @@ type CreateScopeSheetInput struct {
 	DraftStep        *int               `json:"draft_step"`
 }
 
-// CreateScopeSheet creates a new submitted scope sheet for a claim
-func (s *ScopeSheetService) CreateScopeSheet(ctx context.Context, claimID string, input CreateScopeSheetInput) (*models.ScopeSheet, error) {
+// CreateScopeSheet creates the next numbered revision of a claim's scope sheet.
+// The new revision awaits PM review, earlier revisions still awaiting review are
+// superseded, and the claim's draft is consumed. magicLinkID may be nil.
+func (s *ScopeSheetService) CreateScopeSheet(ctx context.Context, claimID string, magicLinkID *string, input CreateScopeSheetInput) (*models.ScopeSheet, error) {
+	if err := scopecatalog.Validate(input.Areas, input.TriageSelections, input.GeneralNotes, true); err != nil {
+		return nil, err
+	}
+	scopecatalog.Normalize(input.Areas)
+
 	scopeSheetID := uuid.New().String()
 	now := time.Now()
 
@@ -51,34 +71,70 @@ func (s *ScopeSheetService) CreateScopeSheet(ctx context.Context, claimID string
 		return nil, fmt.Errorf("failed to marshal triage_selections: %w", err)
 	}
 
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Lock the claim so concurrent submissions number their revisions one
+	// after the other instead of colliding on the same one
+	if err := lockClaim(ctx, tx, claimID); err != nil {
+		return nil, err
+	}
+
 	query := `
 		INSERT INTO scope_sheets (
-			id, claim_id, areas, triage_selections, general_notes,
-			is_draft, submitted_at, created_at, updated_at
+			id, claim_id, areas, triage_selections, general_notes, catalog_version,
+			is_draft, submitted_at, created_at, updated_at,
+			revision, review_status, magic_link_id
 		)
-		VALUES ($1, $2, $3, $4, $5, false, NULL, $6, $7)
-		RETURNING
-			id, claim_id, areas, triage_selections, general_notes,
-			is_draft, draft_step, draft_saved_at, submitted_at, created_at, updated_at
-	`
+		VALUES ($1, $2, $3, $4, $5, $6, false, NULL, $7, $8,
+			(SELECT COALESCE(MAX(revision), 0) + 1 FROM scope_sheets WHERE claim_id = $2),
+			$9, $10)
+		RETURNING ` + scopeSheetColumns
 
-	row := s.db.QueryRowContext(ctx, query,
-		scopeSheetID, claimID, areasJSON, triageJSON, input.GeneralNotes,
-		now, now,
+	row := tx.QueryRowContext(ctx, query,
+		scopeSheetID, claimID, areasJSON, triageJSON, input.GeneralNotes, scopecatalog.Version,
+		now, now, models.ScopeReviewPendingReview, magicLinkID,
 	)
-	return scanScopeSheet(row)
+	scopeSheet, err := scanScopeSheet(row)
+	if err != nil {
+		return nil, fmt.Errorf("failed to insert scope sheet: %w", err)
+	}
+
+	supersedeQuery := `
+		UPDATE scope_sheets
+		SET review_status = $1, updated_at = NOW()
+		WHERE claim_id = $2 AND id <> $3 AND review_status IN ($4, $5)
+	`
+	_, err = tx.ExecContext(ctx, supersedeQuery,
+		models.ScopeReviewSuperseded, claimID, scopeSheetID,
+		models.ScopeReviewPendingReview, models.ScopeReviewChangesRequested,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to supersede earlier revisions: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM scope_sheets WHERE claim_id = $1 AND is_draft = true`, claimID); err != nil {
+		return nil, fmt.Errorf("failed to clear draft: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit scope sheet: %w", err)
+	}
+
+	return scopeSheet, nil
 }
 
-// GetScopeSheetByClaimID retrieves a submitted scope sheet by claim ID.
+// GetScopeSheetByClaimID retrieves the latest submitted revision of a claim's scope sheet.
 // Returns nil if not found (not an error).
 func (s *ScopeSheetService) GetScopeSheetByClaimID(ctx context.Context, claimID string) (*models.ScopeSheet, error) {
 	query := `
-		SELECT
-			id, claim_id, areas, triage_selections, general_notes,
-			is_draft, draft_step, draft_saved_at, submitted_at, created_at, updated_at
+		SELECT ` + scopeSheetColumns + `
 		FROM scope_sheets
 		WHERE claim_id = $1 AND is_draft = false
-		ORDER BY created_at DESC
+		ORDER BY revision DESC NULLS LAST, created_at DESC
 		LIMIT 1
 	`
 
@@ -87,9 +143,89 @@ func (s *ScopeSheetService) GetScopeSheetByClaimID(ctx context.Context, claimID
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
+	if err != nil {
+		return nil, err
+	}
+
+	if scopeSheet.Comments, err = s.listComments(ctx, scopeSheet.ID); err != nil {
+		return nil, err
+	}
+	return scopeSheet, nil
+}
+
+// GetAuditableScopeSheet returns the revision an audit should be built from:
+// the latest approved revision, or else the latest one still awaiting review.
+// Revisions sent back for changes are never audited. Returns nil if none qualifies.
+func (s *ScopeSheetService) GetAuditableScopeSheet(ctx context.Context, claimID string) (*models.ScopeSheet, error) {
+	query := `
+		SELECT ` + scopeSheetColumns + `
+		FROM scope_sheets
+		WHERE claim_id = $1 AND is_draft = false AND review_status IN ($2, $3)
+		ORDER BY review_status = $2 DESC, revision DESC
+		LIMIT 1
+	`
+
+	row := s.db.QueryRowContext(ctx, query, claimID, models.ScopeReviewApproved, models.ScopeReviewPendingReview)
+	scopeSheet, err := scanScopeSheet(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
 	return scopeSheet, err
 }
 
+// ListRevisions returns every submitted revision of a claim's scope sheet, newest first
+func (s *ScopeSheetService) ListRevisions(ctx context.Context, claimID string) ([]models.ScopeSheet, error) {
+	query := `
+		SELECT ` + scopeSheetColumns + `
+		FROM scope_sheets
+		WHERE claim_id = $1 AND is_draft = false
+		ORDER BY revision DESC NULLS LAST, created_at DESC
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, claimID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query scope sheet revisions: %w", err)
+	}
+	defer rows.Close()
+
+	revisions := []models.ScopeSheet{}
+	for rows.Next() {
+		scopeSheet, err := scanScopeSheet(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan scope sheet revision: %w", err)
+		}
+		revisions = append(revisions, *scopeSheet)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate scope sheet revisions: %w", err)
+	}
+
+	return revisions, nil
+}
+
+// GetRevision retrieves one numbered revision with its review comments
+func (s *ScopeSheetService) GetRevision(ctx context.Context, claimID string, revision int) (*models.ScopeSheet, error) {
+	query := `
+		SELECT ` + scopeSheetColumns + `
+		FROM scope_sheets
+		WHERE claim_id = $1 AND revision = $2
+	`
+
+	row := s.db.QueryRowContext(ctx, query, claimID, revision)
+	scopeSheet, err := scanScopeSheet(row)
+	if err == sql.ErrNoRows {
+		return nil, ErrScopeSheetNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if scopeSheet.Comments, err = s.listComments(ctx, scopeSheet.ID); err != nil {
+		return nil, err
+	}
+	return scopeSheet, nil
+}
+
 // SubmitScopeSheet marks a scope sheet as submitted by setting submitted_at to NOW()
 func (s *ScopeSheetService) SubmitScopeSheet(ctx context.Context, scopeSheetID string) error {
 	query := `UPDATE scope_sheets SET submitted_at = NOW(), updated_at = NOW() WHERE id = $1`
@@ -110,23 +246,28 @@ func (s *ScopeSheetService) SubmitScopeSheet(ctx context.Context, scopeSheetID s
 	return nil
 }
 
-// validateMagicLinkToken validates a magic link token and returns the associated claim ID
-func (s *ScopeSheetService) validateMagicLinkToken(ctx context.Context, token string) (string, error) {
-	var claimID string
-	query := `SELECT claim_id FROM magic_links WHERE token = $1 AND status = 'active' AND expires_at > NOW()`
-	err := s.db.QueryRowContext(ctx, query, token).Scan(&claimID)
+// validateMagicLinkToken validates a magic link token and returns the associated
+// claim ID and magic link ID.
+// Returns ErrMagicLinkScope if the link's scope does not cover the scope sheet.
+func (s *ScopeSheetService) validateMagicLinkToken(ctx context.Context, token string) (string, string, error) {
+	var claimID, magicLinkID, scope string
+	query := `SELECT claim_id, id, scope FROM magic_links WHERE token = $1 AND status = 'active' AND expires_at > NOW()`
+	err := s.db.QueryRowContext(ctx, query, token).Scan(&claimID, &magicLinkID, &scope)
 	if err == sql.ErrNoRows {
-		return "", ErrTokenInvalid
+		return "", "", ErrTokenInvalid
 	}
 	if err != nil {
-		return "", fmt.Errorf("failed to validate token: %w", err)
+		return "", "", fmt.Errorf("failed to validate token: %w", err)
 	}
-	return claimID, nil
+	if !models.MagicLinkScopeAllowsScopeSheet(scope) {
+		return "", "", ErrMagicLinkScope
+	}
+	return claimID, magicLinkID, nil
 }
 
 // SaveScopeDraft saves or updates a draft scope sheet (UPSERT via ON CONFLICT)
 func (s *ScopeSheetService) SaveScopeDraft(ctx context.Context, token string, draft *CreateScopeSheetInput) (*models.ScopeSheet, error) {
-	claimID, err := s.validateMagicLinkToken(ctx, token)
+	claimID, magicLinkID, err := s.validateMagicLinkToken(ctx, token)
 	if err != nil {
 		return nil, err
 	}
@@ -134,6 +275,10 @@ func (s *ScopeSheetService) SaveScopeDraft(ctx context.Context, token string, dr
 	if draft.DraftStep != nil && *draft.DraftStep < 0 {
 		return nil, ErrInvalidDraftStep
 	}
+	if err := scopecatalog.Validate(draft.Areas, draft.TriageSelections, draft.GeneralNotes, false); err != nil {
+		return nil, err
+	}
+	scopecatalog.Normalize(draft.Areas)
 
 	areasJSON, err := json.Marshal(draft.Areas)
 	if err != nil {
@@ -149,42 +294,41 @@ func (s *ScopeSheetService) SaveScopeDraft(ctx context.Context, token string, dr
 
 	query := `
 		INSERT INTO scope_sheets (
-			id, claim_id, areas, triage_selections, general_notes,
-			is_draft, draft_step, draft_saved_at, submitted_at, created_at, updated_at
+			id, claim_id, areas, triage_selections, general_notes, catalog_version,
+			is_draft, draft_step, draft_saved_at, submitted_at, created_at, updated_at,
+			magic_link_id
 		)
-		VALUES ($1, $2, $3, $4, $5, true, $6, $7, NULL, $8, $9)
+		VALUES ($1, $2, $3, $4, $5, $6, true, $7, $8, NULL, $9, $10, $11)
 		ON CONFLICT (claim_id) WHERE is_draft = true
 		DO UPDATE SET
 			areas             = EXCLUDED.areas,
 			triage_selections = EXCLUDED.triage_selections,
 			general_notes     = EXCLUDED.general_notes,
+			catalog_version   = EXCLUDED.catalog_version,
 			draft_step        = EXCLUDED.draft_step,
 			draft_saved_at    = EXCLUDED.draft_saved_at,
-			updated_at        = EXCLUDED.updated_at
-		RETURNING
-			id, claim_id, areas, triage_selections, general_notes,
-			is_draft, draft_step, draft_saved_at, submitted_at, created_at, updated_at
-	`
+			updated_at        = EXCLUDED.updated_at,
+			magic_link_id     = EXCLUDED.magic_link_id
+		RETURNING ` + scopeSheetColumns
 
 	row := s.db.QueryRowContext(ctx, query,
-		scopeSheetID, claimID, areasJSON, triageJSON, draft.GeneralNotes,
-		draft.DraftStep, now, now, now,
+		scopeSheetID, claimID, areasJSON, triageJSON, draft.GeneralNotes, scopecatalog.Version,
+		draft.DraftStep, now, now, now, magicLinkID,
 	)
 	return scanScopeSheet(row)
 }
 
 // GetScopeDraft retrieves the draft scope sheet for a claim via magic link token.
+// A draft reopened by a PM change request carries that revision's review notes and comments.
 // Returns ErrDraftNotFound if no draft exists.
 func (s *ScopeSheetService) GetScopeDraft(ctx context.Context, token string) (*models.ScopeSheet, error) {
-	claimID, err := s.validateMagicLinkToken(ctx, token)
+	claimID, _, err := s.validateMagicLinkToken(ctx, token)
 	if err != nil {
 		return nil, err
 	}
 
 	query := `
-		SELECT
-			id, claim_id, areas, triage_selections, general_notes,
-			is_draft, draft_step, draft_saved_at, submitted_at, created_at, updated_at
+		SELECT ` + scopeSheetColumns + `
 		FROM scope_sheets
 		WHERE claim_id = $1 AND is_draft = true
 	`
@@ -194,19 +338,66 @@ func (s *ScopeSheetService) GetScopeDraft(ctx context.Context, token string) (*m
 	if err == sql.ErrNoRows {
 		return nil, ErrDraftNotFound
 	}
-	return scopeSheet, err
+	if err != nil {
+		return nil, err
+	}
+
+	if scopeSheet.ReopenedFromID != nil {
+		err = s.db.QueryRowContext(ctx, `SELECT review_notes FROM scope_sheets WHERE id = $1`, *scopeSheet.ReopenedFromID).Scan(&scopeSheet.ReviewNotes)
+		if err != nil && err != sql.ErrNoRows {
+			return nil, fmt.Errorf("failed to get review notes: %w", err)
+		}
+		if scopeSheet.Comments, err = s.listComments(ctx, *scopeSheet.ReopenedFromID); err != nil {
+			return nil, err
+		}
+	}
+	return scopeSheet, nil
+}
+
+// listComments returns the review comments on a scope sheet, oldest first
+func (s *ScopeSheetService) listComments(ctx context.Context, scopeSheetID string) ([]models.ScopeSheetComment, error) {
+	query := `
+		SELECT id, scope_sheet_id, area_id, comment, created_by_user_id, created_at
+		FROM scope_sheet_comments
+		WHERE scope_sheet_id = $1
+		ORDER BY created_at
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, scopeSheetID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query scope sheet comments: %w", err)
+	}
+	defer rows.Close()
+
+	var comments []models.ScopeSheetComment
+	for rows.Next() {
+		var comment models.ScopeSheetComment
+		if err := rows.Scan(&comment.ID, &comment.ScopeSheetID, &comment.AreaID, &comment.Comment, &comment.CreatedByUserID, &comment.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan scope sheet comment: %w", err)
+		}
+		comments = append(comments, comment)
+	}
+	return comments, rows.Err()
+}
+
+// scopeSheetScanner is satisfied by both *sql.Row and *sql.Rows
+type scopeSheetScanner interface {
+	Scan(dest ...interface{}) error
 }
 
-// scanScopeSheet scans a single DB row into a ScopeSheet, unmarshalling JSONB columns.
-func scanScopeSheet(row *sql.Row) (*models.ScopeSheet, error) {
+// scanScopeSheet scans a single DB row (selected with scopeSheetColumns) into a
+// ScopeSheet, unmarshalling JSONB columns.
+func scanScopeSheet(row scopeSheetScanner) (*models.ScopeSheet, error) {
 	var ss models.ScopeSheet
 	var areasJSON []byte
 	var triageJSON []byte
 
 	err := row.Scan(
 		&ss.ID, &ss.ClaimID,
-		&areasJSON, &triageJSON, &ss.GeneralNotes,
+		&areasJSON, &triageJSON, &ss.GeneralNotes, &ss.CatalogVersion,
 		&ss.IsDraft, &ss.DraftStep, &ss.DraftSavedAt,
+		&ss.Revision, &ss.ReviewStatus, &ss.ReviewedAt, &ss.ReviewedByUserID, &ss.ReviewNotes,
+		&ss.MagicLinkID, &ss.ReopenedFromID,
 		&ss.SubmittedAt, &ss.CreatedAt, &ss.UpdatedAt,
 	)
 	if err != nil {
@@ -156,7 +156,7 @@ func TestCreateScopeSheet_Success(t *testing.T) {
 
 	// Test
 	ctx := context.Background()
-	scopeSheet, err := service.CreateScopeSheet(ctx, claimID, input)
+	scopeSheet, err := service.CreateScopeSheet(ctx, claimID, nil, input)
 
 	// Assert
 	assert.NoError(t, err)
@@ -195,7 +195,7 @@ func TestGetScopeSheetByClaimID_Success(t *testing.T) {
 		RoofType: &roofType,
 	}
 	ctx := context.Background()
-	created, err := service.CreateScopeSheet(ctx, claimID, input)
+	created, err := service.CreateScopeSheet(ctx, claimID, nil, input)
 	assert.NoError(t, err)
 
 	// Test
@@ -254,7 +254,7 @@ func TestSubmitScopeSheet_Success(t *testing.T) {
 		RoofType: &roofType,
 	}
 	ctx := context.Background()
-	scopeSheet, err := service.CreateScopeSheet(ctx, claimID, input)
+	scopeSheet, err := service.CreateScopeSheet(ctx, claimID, nil, input)
 	assert.NoError(t, err)
 	assert.Nil(t, scopeSheet.SubmittedAt)
 
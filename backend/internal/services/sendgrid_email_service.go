@@ -3,6 +3,8 @@ package services
 import (
 	"encoding/base64"
 	"fmt"
+	"html"
+	"strings"
 
 	"github.com/claimcoach/backend/internal/models"
 	"github.com/sendgrid/sendgrid-go"
@@ -337,6 +339,125 @@ func (s *SendGridEmailService) SendPMConfirmationEmail(input SendPMConfirmationE
 	return s.sendEmail(input.To, input.Subject, input.HTMLBody)
 }
 
+// SendMagicLinkReminderEmail nudges a contractor about an outstanding magic link using SendGrid
+func (s *SendGridEmailService) SendMagicLinkReminderEmail(input SendMagicLinkReminderEmailInput) error {
+	var subject, message string
+	switch input.Kind {
+	case models.MagicLinkReminderDraftNotSubmitted:
+		subject = fmt.Sprintf("Reminder: Finish Your Scope Sheet - %s Claim", input.LossType)
+		message = "You started a scope sheet for this claim but haven't submitted it yet. Your progress is saved, so you can pick up where you left off."
+	case models.MagicLinkReminderExpiringSoon:
+		subject = fmt.Sprintf("Upload Link Expiring Soon - %s Claim", input.LossType)
+		message = "Your upload link for this claim is about to expire. Please upload your photos and estimate before it does."
+	default:
+		subject = fmt.Sprintf("Reminder: Upload Request - %s Claim", input.LossType)
+		message = "We're still waiting on your photos and estimate for this claim. It only takes a few minutes using the secure link below."
+	}
+
+	htmlBody := fmt.Sprintf(`<!DOCTYPE html>
+<html>
+<head><meta charset="UTF-8"><title>Upload Reminder</title></head>
+<body style="font-family: Arial, sans-serif; line-height: 1.6; color: #333; background-color: #f5f5f5; margin: 0; padding: 0;">
+  <div style="max-width: 600px; margin: 0 auto; padding: 20px;">
+    <div style="background: white; border-radius: 8px; padding: 30px; box-shadow: 0 2px 4px rgba(0,0,0,0.1);">
+      <p>Hi %s,</p>
+      <p>%s</p>
+      <p><strong>Property:</strong> %s<br><strong>Address:</strong> %s<br><strong>Loss Type:</strong> %s</p>
+      <div style="text-align: center; margin: 30px 0;">
+        <a href="%s" style="background: #111827; color: white; padding: 14px 28px; text-decoration: none; border-radius: 8px; display: inline-block; font-weight: bold; font-size: 15px;">Open Upload Link</a>
+      </div>
+      <p style="color: #6b7280; font-size: 13px;">This link expires on <strong>%s</strong>. If you have questions, please contact the property manager.</p>
+    </div>
+  </div>
+</body>
+</html>`,
+		input.ContractorName,
+		message,
+		input.PropertyName,
+		input.PropertyAddress,
+		input.LossType,
+		input.MagicLinkURL,
+		input.ExpiresAt.Format("Mon, Jan 2, 2006 at 3:04 PM MST"),
+	)
+	return s.sendEmail(input.To, subject, htmlBody)
+}
+
+// SendMagicLinkExpiredEmail tells the PM who created a magic link that it expired unused, with a reissue link
+func (s *SendGridEmailService) SendMagicLinkExpiredEmail(input SendMagicLinkExpiredEmailInput) error {
+	subject := fmt.Sprintf("Contractor Link Expired Unused - %s", input.PropertyName)
+	htmlBody := fmt.Sprintf(`<!DOCTYPE html>
+<html>
+<head><meta charset="UTF-8"><title>Contractor Link Expired</title></head>
+<body style="font-family: Arial, sans-serif; line-height: 1.6; color: #333; background-color: #f5f5f5; margin: 0; padding: 0;">
+  <div style="max-width: 600px; margin: 0 auto; padding: 20px;">
+    <div style="background: white; border-radius: 8px; padding: 30px; box-shadow: 0 2px 4px rgba(0,0,0,0.1);">
+      <p>Hi %s,</p>
+      <p>The upload link you sent to <strong>%s</strong> (%s) for <strong>%s</strong> (%s) expired on %s without any uploads or a submitted scope sheet.</p>
+      <p>You can send the contractor a fresh link with the same settings in one click:</p>
+      <div style="text-align: center; margin: 30px 0;">
+        <a href="%s" style="background: #111827; color: white; padding: 14px 28px; text-decoration: none; border-radius: 8px; display: inline-block; font-weight: bold; font-size: 15px;">Reissue Link</a>
+      </div>
+    </div>
+  </div>
+</body>
+</html>`,
+		input.PMName,
+		input.ContractorName,
+		input.ContractorEmail,
+		input.PropertyName,
+		input.PropertyAddress,
+		input.ExpiredAt.Format("Monday, January 2, 2006"),
+		input.ReissueURL,
+	)
+	return s.sendEmail(input.To, subject, htmlBody)
+}
+
+// SendScopeChangesRequestedEmail asks a contractor to correct a submitted scope sheet
+func (s *SendGridEmailService) SendScopeChangesRequestedEmail(input SendScopeChangesRequestedEmailInput) error {
+	subject := fmt.Sprintf("Changes Requested on Your Scope Sheet - %s", input.PropertyName)
+
+	var notes string
+	if input.ReviewNotes != "" {
+		notes = fmt.Sprintf(`<p style="background: #f9fafb; border-left: 4px solid #111827; padding: 12px 16px;">%s</p>`, html.EscapeString(input.ReviewNotes))
+	}
+	var comments strings.Builder
+	if len(input.Comments) > 0 {
+		comments.WriteString(`<ul style="padding-left: 20px;">`)
+		for _, comment := range input.Comments {
+			comments.WriteString("<li>" + html.EscapeString(comment) + "</li>")
+		}
+		comments.WriteString("</ul>")
+	}
+
+	htmlBody := fmt.Sprintf(`<!DOCTYPE html>
+<html>
+<head><meta charset="UTF-8"><title>Scope Sheet Changes Requested</title></head>
+<body style="font-family: Arial, sans-serif; line-height: 1.6; color: #333; background-color: #f5f5f5; margin: 0; padding: 0;">
+  <div style="max-width: 600px; margin: 0 auto; padding: 20px;">
+    <div style="background: white; border-radius: 8px; padding: 30px; box-shadow: 0 2px 4px rgba(0,0,0,0.1);">
+      <p>Hi %s,</p>
+      <p>The property manager reviewed revision %d of your scope sheet for <strong>%s</strong> (%s) and asked for a few changes.</p>
+      %s
+      %s
+      <p>Your previous answers are saved, so you only need to update the areas mentioned above and resubmit:</p>
+      <div style="text-align: center; margin: 30px 0;">
+        <a href="%s" style="background: #111827; color: white; padding: 14px 28px; text-decoration: none; border-radius: 8px; display: inline-block; font-weight: bold; font-size: 15px;">Update Scope Sheet</a>
+      </div>
+    </div>
+  </div>
+</body>
+</html>`,
+		input.ContractorName,
+		input.Revision,
+		input.PropertyName,
+		input.PropertyAddress,
+		notes,
+		comments.String(),
+		input.MagicLinkURL,
+	)
+	return s.sendEmail(input.To, subject, htmlBody)
+}
+
 // sendEmail is a helper method that sends an email via SendGrid
 func (s *SendGridEmailService) sendEmail(to, subject, htmlBody string) error {
 	from := mail.NewEmail(s.fromName, s.fromEmail)
@@ -0,0 +1,140 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/claimcoach/backend/internal/models"
+)
+
+// SMSService defines the interface for sending text messages
+type SMSService interface {
+	SendMagicLinkSMS(input SendMagicLinkSMSInput) (*SMSSendResult, error)
+	// ValidateWebhook checks that an inbound or status webhook came from the provider
+	ValidateWebhook(requestURL string, params url.Values, signature string) bool
+}
+
+// SendMagicLinkSMSInput contains all data needed to text a magic link to a contractor
+type SendMagicLinkSMSInput struct {
+	To             string // E.164
+	Kind           string // models.MagicLinkReminder* for a reminder, empty for the link itself
+	ContractorName string
+	PropertyName   string
+	MagicLinkURL   string
+	ExpiresAt      time.Time
+}
+
+// SMSSendResult is the provider's acknowledgement of an outgoing message
+type SMSSendResult struct {
+	MessageID string
+	Status    string
+}
+
+// SMS delivery statuses recorded on magic links. Provider statuses (queued, sent,
+// delivered, undelivered, failed, ...) are stored as reported; opted_out is ours.
+const (
+	SMSStatusOptedOut = "opted_out"
+)
+
+// smsStatusRank orders provider statuses so a late callback can't move a
+// message backwards, e.g. "sent" arriving after "delivered"
+var smsStatusRank = map[string]int{
+	"accepted":    1,
+	"queued":      1,
+	"sending":     2,
+	"sent":        3,
+	"delivered":   4,
+	"read":        4,
+	"undelivered": 4,
+	"failed":      4,
+	"canceled":    4,
+}
+
+// Inbound keywords carriers and Twilio treat as opt-out and opt-in requests
+var (
+	smsOptOutKeywords = map[string]bool{"STOP": true, "STOPALL": true, "UNSUBSCRIBE": true, "CANCEL": true, "END": true, "QUIT": true}
+	smsOptInKeywords  = map[string]bool{"START": true, "UNSTOP": true, "YES": true}
+)
+
+// NormalizePhoneNumber converts a phone number to E.164. Ten-digit numbers are
+// assumed to be US numbers.
+func NormalizePhoneNumber(raw string) (string, error) {
+	trimmed := strings.TrimSpace(raw)
+	var digits strings.Builder
+	for _, r := range trimmed {
+		if r >= '0' && r <= '9' {
+			digits.WriteRune(r)
+		}
+	}
+	d := digits.String()
+
+	switch {
+	case strings.HasPrefix(trimmed, "+") && len(d) >= 8 && len(d) <= 15:
+		return "+" + d, nil
+	case len(d) == 10:
+		return "+1" + d, nil
+	case len(d) == 11 && strings.HasPrefix(d, "1"):
+		return "+" + d, nil
+	}
+	return "", fmt.Errorf("invalid phone number: %s", raw)
+}
+
+// magicLinkSMSBody is the text sent with a magic link or a reminder about it.
+// It stays short enough for two SMS segments and always carries opt-out
+// instructions.
+func magicLinkSMSBody(input SendMagicLinkSMSInput) string {
+	var request string
+	switch input.Kind {
+	case models.MagicLinkReminderNeverOpened:
+		request = "a reminder to upload photos and your estimate for"
+	case models.MagicLinkReminderDraftNotSubmitted:
+		request = "your scope sheet is saved but not yet submitted for"
+	case models.MagicLinkReminderExpiringSoon:
+		request = "your upload link is about to expire for"
+	default:
+		request = "please upload photos and your estimate for"
+	}
+	return fmt.Sprintf("ClaimCoach: Hi %s, %s %s: %s (link expires %s). Reply STOP to opt out.",
+		input.ContractorName,
+		request,
+		input.PropertyName,
+		input.MagicLinkURL,
+		input.ExpiresAt.Format("Jan 2 3:04 PM MST"),
+	)
+}
+
+// isPhoneOptedOut reports whether a number has replied STOP
+func isPhoneOptedOut(db *sql.DB, phone string) (bool, error) {
+	var optedOut bool
+	err := db.QueryRow(`SELECT EXISTS(SELECT 1 FROM sms_opt_outs WHERE phone_number = $1)`, phone).Scan(&optedOut)
+	if err != nil {
+		return false, fmt.Errorf("failed to check SMS opt-out: %w", err)
+	}
+	return optedOut, nil
+}
+
+// MockSMSService is a development implementation that logs text messages
+type MockSMSService struct{}
+
+// NewMockSMSService creates a new mock SMS service
+func NewMockSMSService() *MockSMSService {
+	return &MockSMSService{}
+}
+
+func (s *MockSMSService) SendMagicLinkSMS(input SendMagicLinkSMSInput) (*SMSSendResult, error) {
+	log.Printf("[MOCK SMS] To: %s | %s", input.To, magicLinkSMSBody(input))
+	return &SMSSendResult{
+		MessageID: "mock-" + fmt.Sprint(time.Now().UnixNano()),
+		Status:    "sent",
+	}, nil
+}
+
+// ValidateWebhook rejects every webhook: with no provider configured none can
+// be genuine, and accepting them would let anyone post a STOP for any number
+func (s *MockSMSService) ValidateWebhook(requestURL string, params url.Values, signature string) bool {
+	return false
+}
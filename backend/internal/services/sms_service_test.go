@@ -0,0 +1,159 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizePhoneNumber(t *testing.T) {
+	tests := []struct {
+		raw     string
+		want    string
+		wantErr bool
+	}{
+		{raw: "(555) 123-4567", want: "+15551234567"},
+		{raw: "1-555-123-4567", want: "+15551234567"},
+		{raw: "+44 20 7946 0958", want: "+442079460958"},
+		{raw: "555-1234", wantErr: true},
+		{raw: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			got, err := NormalizePhoneNumber(tt.raw)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestTwilioSMSService_SendMagicLinkSMS(t *testing.T) {
+	var gotForm url.Values
+	var gotUser, gotPass string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/2010-04-01/Accounts/AC123/Messages.json", r.URL.Path)
+		gotUser, gotPass, _ = r.BasicAuth()
+		require.NoError(t, r.ParseForm())
+		gotForm = r.PostForm
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"sid": "SM456", "status": "queued"}`))
+	}))
+	defer server.Close()
+
+	service := NewTwilioSMSService("AC123", "secret", "+15550000000", server.URL, "https://api.test/api/webhooks/sms/status")
+	result, err := service.SendMagicLinkSMS(SendMagicLinkSMSInput{
+		To:             "+15551234567",
+		ContractorName: "Roofer",
+		PropertyName:   "Oak Apartments",
+		MagicLinkURL:   "https://app.test/upload/token-1",
+		ExpiresAt:      time.Now().Add(72 * time.Hour),
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, "SM456", result.MessageID)
+	assert.Equal(t, "queued", result.Status)
+	assert.Equal(t, "AC123", gotUser)
+	assert.Equal(t, "secret", gotPass)
+	assert.Equal(t, "+15551234567", gotForm.Get("To"))
+	assert.Equal(t, "+15550000000", gotForm.Get("From"))
+	assert.Equal(t, "https://api.test/api/webhooks/sms/status", gotForm.Get("StatusCallback"))
+	assert.Contains(t, gotForm.Get("Body"), "https://app.test/upload/token-1")
+	assert.Contains(t, gotForm.Get("Body"), "Reply STOP to opt out")
+}
+
+func TestTwilioSMSService_SendMagicLinkSMS_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"code": 21610, "message": "Attempt to send to unsubscribed recipient"}`))
+	}))
+	defer server.Close()
+
+	service := NewTwilioSMSService("AC123", "secret", "+15550000000", server.URL, "")
+	result, err := service.SendMagicLinkSMS(SendMagicLinkSMSInput{To: "+15551234567"})
+
+	assert.Nil(t, result)
+	assert.ErrorContains(t, err, "21610")
+}
+
+func TestTwilioSMSService_ValidateWebhook(t *testing.T) {
+	service := NewTwilioSMSService("AC123", "secret", "+15550000000", "https://api.twilio.com", "")
+	requestURL := "https://api.test/api/webhooks/sms/inbound"
+	params := url.Values{"From": {"+15551234567"}, "Body": {"STOP"}, "MessageSid": {"SM1"}}
+
+	mac := hmac.New(sha1.New, []byte("secret"))
+	mac.Write([]byte(requestURL + "BodySTOPFrom+15551234567MessageSidSM1"))
+	signature := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+
+	assert.True(t, service.ValidateWebhook(requestURL, params, signature))
+	assert.False(t, service.ValidateWebhook(requestURL, params, ""))
+	assert.False(t, service.ValidateWebhook(requestURL+"?x=1", params, signature))
+
+	params.Set("Body", "START")
+	assert.False(t, service.ValidateWebhook(requestURL, params, signature))
+}
+
+func TestSMSWebhookService_HandleInboundMessage(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	service := NewSMSWebhookService(db)
+
+	mock.ExpectExec(`INSERT INTO sms_opt_outs`).
+		WithArgs("+15551234567", "STOP").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	action, err := service.HandleInboundMessage("+1 (555) 123-4567", " stop ")
+	require.NoError(t, err)
+	assert.Equal(t, "opted_out", action)
+
+	mock.ExpectExec(`DELETE FROM sms_opt_outs`).
+		WithArgs("+15551234567").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	action, err = service.HandleInboundMessage("+15551234567", "START")
+	require.NoError(t, err)
+	assert.Equal(t, "opted_in", action)
+
+	action, err = service.HandleInboundMessage("+15551234567", "on my way")
+	require.NoError(t, err)
+	assert.Equal(t, "", action)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSMSWebhookService_RecordDeliveryStatus_NeverMovesBackwards(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	service := NewSMSWebhookService(db)
+
+	// "sent" arriving after "delivered" is ignored
+	mock.ExpectQuery(`SELECT id, sms_status FROM magic_links`).
+		WithArgs("SM1").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "sms_status"}).AddRow("link-1", "delivered"))
+	require.NoError(t, service.RecordDeliveryStatus("SM1", "sent", ""))
+
+	mock.ExpectQuery(`SELECT id, sms_status FROM magic_links`).
+		WithArgs("SM2").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "sms_status"}).AddRow("link-2", "sent"))
+	mock.ExpectExec(`UPDATE magic_links`).
+		WithArgs("undelivered", "provider error code 30003", "link-2").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	require.NoError(t, service.RecordDeliveryStatus("SM2", "undelivered", "30003"))
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,86 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// SMSWebhookService applies inbound messages and delivery status callbacks from the SMS provider
+type SMSWebhookService struct {
+	db *sql.DB
+}
+
+func NewSMSWebhookService(db *sql.DB) *SMSWebhookService {
+	return &SMSWebhookService{db: db}
+}
+
+// HandleInboundMessage honors STOP and START keywords from a contractor's
+// phone. It returns "opted_out", "opted_in", or "" when the message was not a keyword.
+func (s *SMSWebhookService) HandleInboundMessage(from string, body string) (string, error) {
+	phone, err := NormalizePhoneNumber(from)
+	if err != nil {
+		return "", err
+	}
+
+	keyword := strings.ToUpper(strings.TrimSpace(body))
+	switch {
+	case smsOptOutKeywords[keyword]:
+		query := `
+			INSERT INTO sms_opt_outs (phone_number, keyword, opted_out_at)
+			VALUES ($1, $2, NOW())
+			ON CONFLICT (phone_number) DO UPDATE SET keyword = EXCLUDED.keyword, opted_out_at = NOW()
+		`
+		if _, err := s.db.Exec(query, phone, keyword); err != nil {
+			return "", fmt.Errorf("failed to record SMS opt-out: %w", err)
+		}
+		return "opted_out", nil
+	case smsOptInKeywords[keyword]:
+		if _, err := s.db.Exec(`DELETE FROM sms_opt_outs WHERE phone_number = $1`, phone); err != nil {
+			return "", fmt.Errorf("failed to clear SMS opt-out: %w", err)
+		}
+		return "opted_in", nil
+	}
+
+	return "", nil
+}
+
+// RecordDeliveryStatus updates the magic link that sent messageID with the
+// provider's latest delivery status. Statuses never move backwards.
+func (s *SMSWebhookService) RecordDeliveryStatus(messageID string, status string, errorCode string) error {
+	status = strings.ToLower(strings.TrimSpace(status))
+	rank, ok := smsStatusRank[status]
+	if !ok {
+		return fmt.Errorf("unknown SMS status: %s", status)
+	}
+
+	var smsError *string
+	if errorCode != "" {
+		msg := "provider error code " + errorCode
+		smsError = &msg
+	}
+
+	var currentStatus *string
+	var linkID string
+	err := s.db.QueryRow(`SELECT id, sms_status FROM magic_links WHERE sms_message_id = $1`, messageID).Scan(&linkID, &currentStatus)
+	if err == sql.ErrNoRows {
+		// Not one of ours, or the link has been deleted
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to find magic link for SMS: %w", err)
+	}
+	if currentStatus != nil && smsStatusRank[*currentStatus] > rank {
+		return nil
+	}
+
+	query := `
+		UPDATE magic_links
+		SET sms_status = $1, sms_error = COALESCE($2, sms_error)
+		WHERE id = $3
+	`
+	if _, err := s.db.Exec(query, status, smsError, linkID); err != nil {
+		return fmt.Errorf("failed to update SMS status: %w", err)
+	}
+	return nil
+}
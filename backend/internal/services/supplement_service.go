@@ -0,0 +1,534 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/claimcoach/backend/internal/models"
+	"github.com/claimcoach/backend/internal/pricing"
+	"github.com/claimcoach/backend/internal/supplement"
+	"github.com/go-pdf/fpdf"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrSupplementNotFound          = errors.New("supplement not found")
+	ErrNothingToSupplement         = errors.New("the carrier estimate already covers every priced line of the industry estimate")
+	ErrInvalidSupplementTransition = errors.New("invalid supplement status transition")
+	ErrInvalidSupplementUpdate     = errors.New("invalid supplement update")
+)
+
+// supplementTransitions are the statuses each supplement status may move to.
+// A draft is sent to the adjuster, who approves, partially approves or denies it.
+var supplementTransitions = map[string][]string{
+	models.SupplementDraft:             {models.SupplementSent},
+	models.SupplementSent:              {models.SupplementApproved, models.SupplementPartiallyApproved, models.SupplementDenied},
+	models.SupplementApproved:          {},
+	models.SupplementPartiallyApproved: {},
+	models.SupplementDenied:            {},
+}
+
+// SupplementService builds line-item supplement requests from the estimate
+// comparison and tracks them through the carrier's response
+type SupplementService struct {
+	db           *sql.DB
+	auditService *AuditService
+	claimService *ClaimService
+}
+
+// NewSupplementService creates a new SupplementService
+func NewSupplementService(db *sql.DB, auditService *AuditService, claimService *ClaimService) *SupplementService {
+	return &SupplementService{
+		db:           db,
+		auditService: auditService,
+		claimService: claimService,
+	}
+}
+
+// UpdateSupplementStatusInput records sending a supplement or the carrier's
+// response to it. ApprovedAmount is required for a partial approval.
+type UpdateSupplementStatusInput struct {
+	Status          string   `json:"status" binding:"required"`
+	ApprovedAmount  *float64 `json:"approved_amount"`
+	CarrierResponse *string  `json:"carrier_response"`
+}
+
+const supplementColumns = `id, claim_id, supplement_number, audit_report_id, carrier_estimate_id, status,
+	items, total_requested, approved_amount, carrier_response, sent_at, responded_at,
+	created_by_user_id, created_at, updated_at`
+
+func scanSupplement(row interface{ Scan(...interface{}) error }) (*models.Supplement, error) {
+	var s models.Supplement
+	var items []byte
+	err := row.Scan(
+		&s.ID, &s.ClaimID, &s.SupplementNumber, &s.AuditReportID, &s.CarrierEstimateID, &s.Status,
+		&items, &s.TotalRequested, &s.ApprovedAmount, &s.CarrierResponse, &s.SentAt, &s.RespondedAt,
+		&s.CreatedByUserID, &s.CreatedAt, &s.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(items, &s.Items); err != nil {
+		return nil, fmt.Errorf("invalid supplement items: %w", err)
+	}
+	return &s, nil
+}
+
+// GenerateSupplement compares the claim's industry estimate with its current
+// carrier estimate and saves the omitted and under-allowed items as a draft
+// supplement
+func (s *SupplementService) GenerateSupplement(ctx context.Context, claimID, organizationID, userID string) (*models.Supplement, error) {
+	report, err := s.auditService.GetAuditReportByClaimID(ctx, claimID, organizationID)
+	if err != nil {
+		return nil, err
+	}
+	if report.GeneratedEstimate == nil || *report.GeneratedEstimate == "" {
+		return nil, fmt.Errorf("industry estimate not generated yet")
+	}
+	estimate, err := pricing.ParseIndustryEstimate(*report.GeneratedEstimate)
+	if err != nil {
+		return nil, fmt.Errorf("industry estimate is invalid — please regenerate it: %w", err)
+	}
+
+	carrierEstimate, err := s.auditService.getCarrierEstimate(ctx, claimID)
+	if err != nil {
+		return nil, err
+	}
+	if carrierEstimate.ParsedData == nil || *carrierEstimate.ParsedData == "" {
+		return nil, fmt.Errorf("carrier estimate not parsed yet")
+	}
+	var parsed ParsedEstimateData
+	if err := json.Unmarshal([]byte(*carrierEstimate.ParsedData), &parsed); err != nil {
+		return nil, fmt.Errorf("failed to parse carrier estimate data: %w", err)
+	}
+	carrier := make([]supplement.CarrierLine, 0, len(parsed.LineItems))
+	for _, line := range parsed.LineItems {
+		carrier = append(carrier, supplement.CarrierLine{
+			Description: line.Description,
+			Quantity:    line.Quantity,
+			Unit:        line.Unit,
+			UnitCost:    line.UnitCost,
+			Total:       line.Total,
+		})
+	}
+
+	areas, err := s.scopeAreas(ctx, report.ScopeSheetID)
+	if err != nil {
+		return nil, err
+	}
+
+	items := supplement.Build(estimate, carrier, areas)
+	if len(items) == 0 {
+		return nil, ErrNothingToSupplement
+	}
+	itemsJSON, err := json.Marshal(items)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal supplement items: %w", err)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Lock the claim so concurrent generations take consecutive supplement
+	// numbers instead of colliding on the same one
+	if err := lockClaim(ctx, tx, claimID); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	created, err := scanSupplement(tx.QueryRowContext(ctx, `
+		INSERT INTO supplements (
+			id, claim_id, supplement_number, audit_report_id, carrier_estimate_id, status,
+			items, total_requested, created_by_user_id, created_at, updated_at
+		)
+		VALUES (
+			$1, $2, (SELECT COALESCE(MAX(supplement_number), 0) + 1 FROM supplements WHERE claim_id = $2),
+			$3, $4, $5, $6, $7, $8, $9, $10
+		)
+		RETURNING `+supplementColumns,
+		uuid.New().String(), claimID, report.ID, carrierEstimate.ID, models.SupplementDraft,
+		string(itemsJSON), supplement.Total(items), userID, now, now,
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to save supplement: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit supplement: %w", err)
+	}
+	return created, nil
+}
+
+// ListSupplements returns a claim's supplements, oldest first
+func (s *SupplementService) ListSupplements(ctx context.Context, claimID, organizationID string) ([]models.Supplement, error) {
+	if _, err := s.claimService.GetClaim(claimID, organizationID); err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+supplementColumns+`
+		FROM supplements
+		WHERE claim_id = $1
+		ORDER BY supplement_number`, claimID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get supplements: %w", err)
+	}
+	defer rows.Close()
+
+	supplements := []models.Supplement{}
+	for rows.Next() {
+		sup, err := scanSupplement(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan supplement: %w", err)
+		}
+		supplements = append(supplements, *sup)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate supplements: %w", err)
+	}
+
+	return supplements, nil
+}
+
+// GetSupplement returns one of a claim's supplements
+func (s *SupplementService) GetSupplement(ctx context.Context, claimID, supplementID, organizationID string) (*models.Supplement, error) {
+	if _, err := s.claimService.GetClaim(claimID, organizationID); err != nil {
+		return nil, err
+	}
+	return s.getSupplement(ctx, claimID, supplementID)
+}
+
+// UpdateSupplementStatus moves a supplement to its next status, recording
+// when it was sent or answered and what the carrier approved
+func (s *SupplementService) UpdateSupplementStatus(ctx context.Context, claimID, supplementID, organizationID, userID string, input UpdateSupplementStatusInput) (*models.Supplement, error) {
+	current, err := s.GetSupplement(ctx, claimID, supplementID, organizationID)
+	if err != nil {
+		return nil, err
+	}
+
+	allowed := false
+	for _, next := range supplementTransitions[current.Status] {
+		if input.Status == next {
+			allowed = true
+			break
+		}
+	}
+	if !allowed {
+		return nil, fmt.Errorf("%w from %s to %s", ErrInvalidSupplementTransition, current.Status, input.Status)
+	}
+
+	approved := input.ApprovedAmount
+	switch input.Status {
+	case models.SupplementApproved:
+		if approved == nil {
+			approved = &current.TotalRequested
+		}
+	case models.SupplementPartiallyApproved:
+		if approved == nil {
+			return nil, fmt.Errorf("%w: approved_amount is required for a partial approval", ErrInvalidSupplementUpdate)
+		}
+		if *approved >= current.TotalRequested {
+			return nil, fmt.Errorf("%w: a partial approval must be less than the $%.2f requested", ErrInvalidSupplementUpdate, current.TotalRequested)
+		}
+	case models.SupplementDenied:
+		zero := 0.0
+		approved = &zero
+	default:
+		approved = nil
+	}
+	if approved != nil && *approved < 0 {
+		return nil, fmt.Errorf("%w: approved_amount must not be negative", ErrInvalidSupplementUpdate)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// The status guard keeps a concurrent update from being overwritten
+	updated, err := scanSupplement(tx.QueryRowContext(ctx, `
+		UPDATE supplements SET
+			status = $1,
+			approved_amount = COALESCE($2, approved_amount),
+			carrier_response = COALESCE($3, carrier_response),
+			sent_at = CASE WHEN $1 = $4 THEN NOW() ELSE sent_at END,
+			responded_at = CASE WHEN $1 <> $4 THEN NOW() ELSE responded_at END,
+			updated_at = NOW()
+		WHERE id = $5 AND status = $6
+		RETURNING `+supplementColumns,
+		input.Status, approved, input.CarrierResponse, models.SupplementSent, current.ID, current.Status,
+	))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("%w: supplement was updated by someone else", ErrInvalidSupplementTransition)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to update supplement: %w", err)
+	}
+
+	metadata, _ := json.Marshal(map[string]interface{}{
+		"supplement_id":   updated.ID,
+		"old_status":      current.Status,
+		"new_status":      updated.Status,
+		"total_requested": updated.TotalRequested,
+		"approved_amount": updated.ApprovedAmount,
+	})
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO claim_activities (id, claim_id, user_id, activity_type, description, metadata, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		uuid.New().String(), claimID, userID, "supplement_status_changed",
+		fmt.Sprintf("Supplement #%d %s", updated.SupplementNumber, strings.ReplaceAll(updated.Status, "_", " ")),
+		string(metadata), time.Now(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to log supplement activity: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit supplement update: %w", err)
+	}
+	return updated, nil
+}
+
+// RenderSupplementCSV returns a supplement as a CSV for the adjuster, with
+// its download filename
+func (s *SupplementService) RenderSupplementCSV(ctx context.Context, claimID, supplementID, organizationID string) ([]byte, string, error) {
+	sup, err := s.GetSupplement(ctx, claimID, supplementID, organizationID)
+	if err != nil {
+		return nil, "", err
+	}
+	var buf bytes.Buffer
+	if err := supplement.WriteCSV(&buf, sup.Items); err != nil {
+		return nil, "", fmt.Errorf("failed to write supplement CSV: %w", err)
+	}
+	header, err := s.supplementHeader(ctx, claimID)
+	if err != nil {
+		return nil, "", err
+	}
+	return buf.Bytes(), supplementFilename(header.claimNumber, sup) + ".csv", nil
+}
+
+// RenderSupplementPDF returns a supplement as a PDF for the adjuster, with
+// its download filename
+func (s *SupplementService) RenderSupplementPDF(ctx context.Context, claimID, supplementID, organizationID string) ([]byte, string, error) {
+	sup, err := s.GetSupplement(ctx, claimID, supplementID, organizationID)
+	if err != nil {
+		return nil, "", err
+	}
+	header, err := s.supplementHeader(ctx, claimID)
+	if err != nil {
+		return nil, "", err
+	}
+	pdfBytes, err := generateSupplementPDF(header, sup)
+	if err != nil {
+		return nil, "", err
+	}
+	return pdfBytes, supplementFilename(header.claimNumber, sup) + ".pdf", nil
+}
+
+func (s *SupplementService) getSupplement(ctx context.Context, claimID, supplementID string) (*models.Supplement, error) {
+	sup, err := scanSupplement(s.db.QueryRowContext(ctx, `
+		SELECT `+supplementColumns+`
+		FROM supplements
+		WHERE id = $1 AND claim_id = $2`,
+		supplementID, claimID,
+	))
+	if err == sql.ErrNoRows {
+		return nil, ErrSupplementNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get supplement: %w", err)
+	}
+	return sup, nil
+}
+
+// scopeAreas returns the areas of the scope sheet an estimate was generated
+// from, for their photos
+func (s *SupplementService) scopeAreas(ctx context.Context, scopeSheetID string) ([]supplement.Area, error) {
+	var areasJSON []byte
+	err := s.db.QueryRowContext(ctx, `SELECT areas FROM scope_sheets WHERE id = $1`, scopeSheetID).Scan(&areasJSON)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get scope sheet areas: %w", err)
+	}
+	var scopeAreas []models.ScopeArea
+	if err := json.Unmarshal(areasJSON, &scopeAreas); err != nil {
+		return nil, fmt.Errorf("failed to parse scope sheet areas: %w", err)
+	}
+	areas := make([]supplement.Area, 0, len(scopeAreas))
+	for _, a := range scopeAreas {
+		areas = append(areas, supplement.Area{ID: a.ID, Name: a.Category, PhotoIDs: a.PhotoIDs})
+	}
+	return areas, nil
+}
+
+// supplementHeaderData identifies the claim on a supplement sent to the adjuster
+type supplementHeaderData struct {
+	claimNumber          string
+	insuranceClaimNumber string
+	propertyAddress      string
+	carrierName          string
+	policyNumber         string
+	lossType             string
+	incidentDate         time.Time
+}
+
+func (s *SupplementService) supplementHeader(ctx context.Context, claimID string) (*supplementHeaderData, error) {
+	var h supplementHeaderData
+	err := s.db.QueryRowContext(ctx, `
+		SELECT c.claim_number, COALESCE(c.insurance_claim_number, ''), p.legal_address,
+		       COALESCE(pol.carrier_name, ''), COALESCE(pol.policy_number, ''), c.loss_type, c.incident_date
+		FROM claims c
+		INNER JOIN properties p ON c.property_id = p.id
+		LEFT JOIN insurance_policies pol ON pol.id = c.policy_id
+		WHERE c.id = $1`, claimID,
+	).Scan(&h.claimNumber, &h.insuranceClaimNumber, &h.propertyAddress, &h.carrierName, &h.policyNumber, &h.lossType, &h.incidentDate)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("claim not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load claim data: %w", err)
+	}
+	return &h, nil
+}
+
+func supplementFilename(claimNumber string, sup *models.Supplement) string {
+	safeClaimNum := strings.NewReplacer("/", "-", " ", "-", "\\", "-").Replace(claimNumber)
+	if safeClaimNum == "" {
+		safeClaimNum = sup.ClaimID
+		if len(safeClaimNum) > 8 {
+			safeClaimNum = safeClaimNum[:8]
+		}
+	}
+	return fmt.Sprintf("Supplement-%s-%d", safeClaimNum, sup.SupplementNumber)
+}
+
+// generateSupplementPDF lays out a supplement as a landscape table the
+// adjuster can price line by line, followed by each item's justification
+func generateSupplementPDF(h *supplementHeaderData, sup *models.Supplement) ([]byte, error) {
+	pdf := fpdf.New("L", "mm", "A4", "")
+	pdf.SetMargins(12, 12, 12)
+	pdf.SetAutoPageBreak(true, 12)
+	pdf.AddPage()
+
+	pageW := 273.0 // 297 - 12*2
+
+	// ── Header ───────────────────────────────────────────────────────────────
+	pdf.SetFont("Helvetica", "B", 15)
+	pdf.SetTextColor(15, 23, 42)
+	pdf.CellFormat(pageW, 9, fmt.Sprintf("SUPPLEMENT REQUEST #%d", sup.SupplementNumber), "", 1, "L", false, 0, "")
+	pdf.SetFont("Helvetica", "", 9)
+	pdf.SetTextColor(71, 85, 105)
+	details := []string{
+		"Property: " + h.propertyAddress,
+		"Carrier: " + h.carrierName,
+		"Policy Number: " + h.policyNumber,
+		"Carrier Claim Number: " + h.insuranceClaimNumber,
+		fmt.Sprintf("Loss: %s on %s", h.lossType, h.incidentDate.Format("January 2, 2006")),
+		"Prepared: " + sup.CreatedAt.Format("January 2, 2006"),
+	}
+	for _, d := range details {
+		pdf.CellFormat(pageW, 5, latin1Safe(d), "", 1, "L", false, 0, "")
+	}
+	pdf.Ln(3)
+
+	// ── Line items ───────────────────────────────────────────────────────────
+	widths := []float64{10, 22, 83, 12, 22, 22, 22, 22, 30, 28}
+	headers := []string{"#", "Type", "Description", "Unit", "Quantity", "Unit Cost", "Carrier Qty", "Carrier Cost", "Requested", "Photos"}
+	pdf.SetFont("Helvetica", "B", 8)
+	pdf.SetFillColor(226, 232, 240)
+	pdf.SetTextColor(15, 23, 42)
+	for i, title := range headers {
+		pdf.CellFormat(widths[i], 7, title, "1", 0, "C", true, 0, "")
+	}
+	pdf.Ln(-1)
+
+	pdf.SetFont("Helvetica", "", 8)
+	for i, item := range sup.Items {
+		photoCount := 0
+		for _, ref := range item.Photos {
+			photoCount += len(ref.PhotoIDs)
+		}
+		row := []string{
+			fmt.Sprintf("%d", i+1),
+			strings.ToUpper(item.Kind[:1]) + item.Kind[1:],
+			latin1Safe(truncateRunes(item.Description, 55)),
+			item.Unit,
+			fmt.Sprintf("%g", item.Quantity),
+			fmt.Sprintf("$%.2f", item.UnitCost),
+			fmt.Sprintf("%g", item.CarrierQuantity),
+			fmt.Sprintf("$%.2f", item.CarrierUnitCost),
+			fmt.Sprintf("$%.2f", item.Amount),
+			fmt.Sprintf("%d", photoCount),
+		}
+		for j, cell := range row {
+			align := "R"
+			if j == 1 || j == 2 || j == 3 {
+				align = "L"
+			}
+			pdf.CellFormat(widths[j], 6, cell, "1", 0, align, false, 0, "")
+		}
+		pdf.Ln(-1)
+	}
+	pdf.SetFont("Helvetica", "B", 9)
+	labelW := 0.0
+	for _, w := range widths[:8] {
+		labelW += w
+	}
+	pdf.CellFormat(labelW, 7, "TOTAL REQUESTED", "1", 0, "R", false, 0, "")
+	pdf.CellFormat(widths[8], 7, fmt.Sprintf("$%.2f", sup.TotalRequested), "1", 0, "R", false, 0, "")
+	pdf.CellFormat(widths[9], 7, "", "1", 1, "R", false, 0, "")
+	pdf.Ln(5)
+
+	// ── Justifications ───────────────────────────────────────────────────────
+	pdf.SetFont("Helvetica", "B", 11)
+	pdf.CellFormat(pageW, 7, "JUSTIFICATION", "", 1, "L", false, 0, "")
+	for i, item := range sup.Items {
+		pdf.SetFont("Helvetica", "B", 9)
+		pdf.SetTextColor(15, 23, 42)
+		title := fmt.Sprintf("%d. %s (%s)", i+1, item.Description, item.Code)
+		if item.CodeRequirement != "" {
+			title += "  -  " + item.CodeRequirement
+		}
+		pdf.MultiCell(pageW, 5, latin1Safe(title), "", "L", false)
+		pdf.SetFont("Helvetica", "", 9)
+		pdf.SetTextColor(30, 41, 59)
+		pdf.MultiCell(pageW, 5, latin1Safe("   "+item.Justification), "", "L", false)
+		if photos := supplement.DescribePhotos(item.Photos); photos != "" {
+			pdf.SetFont("Helvetica", "I", 8)
+			pdf.SetTextColor(71, 85, 105)
+			pdf.MultiCell(pageW, 5, latin1Safe("   Supporting photos - "+photos), "", "L", false)
+		}
+		pdf.Ln(1)
+	}
+
+	if pdf.Error() != nil {
+		return nil, fmt.Errorf("PDF generation error: %w", pdf.Error())
+	}
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, fmt.Errorf("PDF output error: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func truncateRunes(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n-3]) + "..."
+}
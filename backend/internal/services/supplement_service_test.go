@@ -0,0 +1,42 @@
+package services
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/claimcoach/backend/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateSupplementPDF(t *testing.T) {
+	header := &supplementHeaderData{
+		claimNumber:     "CLM-2026-001",
+		propertyAddress: "12 Elm St, Springfield",
+		carrierName:     "Acme Mutual",
+		lossType:        "hail",
+		incidentDate:    time.Date(2026, 5, 2, 0, 0, 0, 0, time.UTC),
+	}
+	sup := &models.Supplement{
+		SupplementNumber: 1,
+		TotalRequested:   671,
+		CreatedAt:        time.Now(),
+		Items: []models.SupplementItem{{
+			Kind: models.SupplementItemAdded, Code: "RFG_DRIP_EDGE", Description: "Drip edge", Unit: "LF",
+			Quantity: 220, UnitCost: 3.05, Amount: 671, CodeRequirement: "IRC R905.2.8.5",
+			Justification: "Omitted from the carrier estimate — required.",
+			Photos:        []models.SupplementPhotos{{AreaID: "roof", Area: "Roof", PhotoIDs: []string{"p1"}}},
+		}},
+	}
+
+	pdf, err := generateSupplementPDF(header, sup)
+	require.NoError(t, err)
+	assert.True(t, bytes.HasPrefix(pdf, []byte("%PDF")))
+}
+
+func TestSupplementFilename(t *testing.T) {
+	sup := &models.Supplement{ClaimID: "0123456789abcdef", SupplementNumber: 2}
+	assert.Equal(t, "Supplement-CLM-2026-001-2", supplementFilename("CLM 2026/001", sup))
+	assert.Equal(t, "Supplement-01234567-2", supplementFilename("", sup))
+}
@@ -0,0 +1,126 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// TwilioSMSService sends text messages through the Twilio Messages API. The
+// base URL is configurable so any Twilio-compatible provider can be used.
+type TwilioSMSService struct {
+	accountSID        string
+	authToken         string
+	fromNumber        string
+	baseURL           string
+	statusCallbackURL string
+	httpClient        *http.Client
+}
+
+// NewTwilioSMSService creates a new Twilio SMS service. statusCallbackURL may be
+// empty, in which case delivery status stays at what the send call reported.
+func NewTwilioSMSService(accountSID, authToken, fromNumber, baseURL, statusCallbackURL string) *TwilioSMSService {
+	return &TwilioSMSService{
+		accountSID:        accountSID,
+		authToken:         authToken,
+		fromNumber:        fromNumber,
+		baseURL:           strings.TrimRight(baseURL, "/"),
+		statusCallbackURL: statusCallbackURL,
+		httpClient: &http.Client{
+			Timeout: 15 * time.Second,
+		},
+	}
+}
+
+type twilioMessageResponse struct {
+	SID          string  `json:"sid"`
+	Status       string  `json:"status"`
+	ErrorCode    *int    `json:"error_code"`
+	ErrorMessage *string `json:"error_message"`
+	Code         int     `json:"code"`
+	Message      string  `json:"message"`
+}
+
+// SendMagicLinkSMS texts a magic link to a contractor
+func (s *TwilioSMSService) SendMagicLinkSMS(input SendMagicLinkSMSInput) (*SMSSendResult, error) {
+	form := url.Values{}
+	form.Set("To", input.To)
+	form.Set("From", s.fromNumber)
+	form.Set("Body", magicLinkSMSBody(input))
+	if s.statusCallbackURL != "" {
+		form.Set("StatusCallback", s.statusCallbackURL)
+	}
+
+	endpoint := fmt.Sprintf("%s/2010-04-01/Accounts/%s/Messages.json", s.baseURL, s.accountSID)
+	req, err := http.NewRequest(http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create SMS request: %w", err)
+	}
+	req.SetBasicAuth(s.accountSID, s.authToken)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send SMS: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read SMS response: %w", err)
+	}
+
+	var message twilioMessageResponse
+	if err := json.Unmarshal(body, &message); err != nil {
+		return nil, fmt.Errorf("SMS service error: %d - %s", resp.StatusCode, string(body))
+	}
+	if resp.StatusCode >= 400 {
+		return nil, fmt.Errorf("SMS service error: %d - %s (code %d)", resp.StatusCode, message.Message, message.Code)
+	}
+	if message.ErrorMessage != nil && *message.ErrorMessage != "" {
+		return nil, fmt.Errorf("SMS service error: %s", *message.ErrorMessage)
+	}
+
+	return &SMSSendResult{
+		MessageID: message.SID,
+		Status:    message.Status,
+	}, nil
+}
+
+// ValidateWebhook checks the X-Twilio-Signature header: a base64 HMAC-SHA1, keyed
+// by the auth token, of the full request URL followed by each POST parameter's
+// name and value in name order
+func (s *TwilioSMSService) ValidateWebhook(requestURL string, params url.Values, signature string) bool {
+	if signature == "" {
+		return false
+	}
+
+	keys := make([]string, 0, len(params))
+	for key := range params {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var payload strings.Builder
+	payload.WriteString(requestURL)
+	for _, key := range keys {
+		for _, value := range params[key] {
+			payload.WriteString(key)
+			payload.WriteString(value)
+		}
+	}
+
+	mac := hmac.New(sha1.New, []byte(s.authToken))
+	mac.Write([]byte(payload.String()))
+	expected := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+
+	return hmac.Equal([]byte(expected), []byte(signature))
+}
@@ -0,0 +1,354 @@
+// Package supplement builds a line-item supplement request from the
+// comparison of a priced industry estimate with the carrier's estimate. Every
+// requested item is a line of the industry estimate the carrier omitted or
+// under-allowed, so its quantity and price are as deterministic as the
+// estimate itself.
+package supplement
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"unicode"
+
+	"github.com/claimcoach/backend/internal/models"
+	"github.com/claimcoach/backend/internal/pricing"
+)
+
+const (
+	// matchThreshold is the share of the shorter description's words two
+	// descriptions must have in common to be the same item
+	matchThreshold = 0.5
+	// quantityTolerance and unitCostTolerance keep rounding differences out
+	// of the supplement
+	quantityTolerance = 0.03
+	unitCostTolerance = 0.05
+)
+
+// CarrierLine is one line of the carrier's parsed estimate
+type CarrierLine struct {
+	Description string
+	Quantity    float64
+	Unit        string
+	UnitCost    float64
+	Total       float64
+}
+
+// Area is a scope sheet area and the photos taken of it
+type Area struct {
+	ID       string
+	Name     string
+	PhotoIDs []string
+}
+
+// requirement is the standard justification for a commonly omitted item
+type requirement struct {
+	code          string
+	justification string
+}
+
+// requirements justify items carriers commonly leave out of roof estimates
+var requirements = map[string]requirement{
+	"RFG_DRIP_EDGE":     {"IRC R905.2.8.5", "Drip edge is required at eaves and rakes of shingle roofs and is replaced with the roof covering."},
+	"RFG_ICE_WATER":     {"IRC R905.1.2", "An ice barrier is required at the eaves where ice damming occurs and is installed whenever the roof covering is replaced."},
+	"RFG_UNDERLAYMENT":  {"IRC R905.1.1", "Underlayment is required beneath asphalt shingles and cannot be reused once the roof covering is torn off."},
+	"RFG_FLASHING_STEP": {"IRC R905.2.8.3", "Step flashing is required where the roof meets a sidewall and cannot be reused when the shingles are replaced."},
+	"RFG_STARTER":       {"", "Shingle manufacturers require a starter course at eaves and rakes; without it the first course is not sealed and the warranty is void."},
+	"RFG_RIDGE_CAP":     {"", "Hip and ridge cap shingles are a separate component from the field shingles and are replaced with the roof covering."},
+	"RFG_FLASHING_PIPE": {"", "Pipe jack flashings are destroyed on removal and are replaced with the roof covering."},
+	"RFG_TEAROFF":       {"", "The existing roof covering must be removed before the replacement can be installed."},
+	"RFG_STEEP_7_9":     {"", "Steep roof labor applies to the measured roof pitch."},
+	"RFG_STEEP_10_12":   {"", "Steep roof labor applies to the measured roof pitch."},
+	"RFG_HIGH":          {"", "High roof labor applies to roofs two stories or taller."},
+	"GEN_PERMIT":        {"Local building code", "The jurisdiction requires a building permit for this scope of repair."},
+	"GEN_DUMPSTER":      {"", "Debris from the repair has to be hauled away."},
+}
+
+// stopWords carry no meaning when matching descriptions
+var stopWords = map[string]bool{
+	"a": true, "and": true, "of": true, "the": true, "in": true, "per": true, "with": true,
+	"w": true, "r": true, "rr": true, "remove": true, "replace": true,
+}
+
+// required is one code of the industry estimate with its lines combined
+type required struct {
+	line    pricing.LineItem
+	areaIDs []string
+	carrier []CarrierLine
+}
+
+// Build compares an industry estimate with the carrier's lines and returns the
+// items to request, in estimate order. Each carrier line is matched to the
+// estimate code whose description it most resembles; an estimate code with no
+// carrier line is added, and one the carrier allowed too little quantity or
+// too low a unit cost for is adjusted. Unpriced lines are never requested.
+func Build(estimate *pricing.IndustryEstimate, carrier []CarrierLine, areas []Area) []models.SupplementItem {
+	byCode := map[string]*required{}
+	var order []string
+	for _, line := range estimate.LineItems {
+		if line.UnitCost <= 0 || line.Quantity <= 0 {
+			continue
+		}
+		r, ok := byCode[line.Code]
+		if !ok {
+			r = &required{line: line}
+			byCode[line.Code] = r
+			order = append(order, line.Code)
+		} else {
+			r.line.Quantity = round2(r.line.Quantity + line.Quantity)
+			r.line.Total = round2(r.line.Total + line.Total)
+		}
+		if line.AreaID != "" && !contains(r.areaIDs, line.AreaID) {
+			r.areaIDs = append(r.areaIDs, line.AreaID)
+		}
+	}
+
+	for _, c := range carrier {
+		if code := bestMatch(c, byCode, order); code != "" {
+			byCode[code].carrier = append(byCode[code].carrier, c)
+		}
+	}
+
+	areasByID := make(map[string]Area, len(areas))
+	for _, a := range areas {
+		areasByID[a.ID] = a
+	}
+
+	items := []models.SupplementItem{}
+	for _, code := range order {
+		r := byCode[code]
+		item, ok := compare(r)
+		if !ok {
+			continue
+		}
+		item.Photos = photos(r.areaIDs, areasByID)
+		item.CodeRequirement, item.Justification = justify(item, r.areaIDs, areasByID)
+		items = append(items, item)
+	}
+	return items
+}
+
+// Total is the amount a supplement requests
+func Total(items []models.SupplementItem) float64 {
+	var total float64
+	for _, item := range items {
+		total += item.Amount
+	}
+	return round2(total)
+}
+
+// compare decides whether the carrier's allowance for a code falls short
+func compare(r *required) (models.SupplementItem, bool) {
+	line := r.line
+	item := models.SupplementItem{
+		Code:        line.Code,
+		Description: line.Description,
+		Category:    line.Category,
+		Unit:        line.Unit,
+		Quantity:    line.Quantity,
+		UnitCost:    line.UnitCost,
+	}
+	if len(r.carrier) == 0 {
+		item.Kind = models.SupplementItemAdded
+		item.Amount = line.Total
+		return item, true
+	}
+
+	var carrierTotal float64
+	for _, c := range r.carrier {
+		unit := pricing.NormalizeUnit(c.Unit)
+		if unit == "" {
+			unit = line.Unit
+		}
+		if quantity, ok := pricing.ConvertQuantity(c.Quantity, unit, line.Unit); ok {
+			item.CarrierQuantity += quantity
+		}
+		carrierTotal += c.Total
+	}
+	item.CarrierQuantity = round2(item.CarrierQuantity)
+	if item.CarrierQuantity > 0 {
+		item.CarrierUnitCost = round2(carrierTotal / item.CarrierQuantity)
+	}
+
+	shortQuantity := item.CarrierQuantity < line.Quantity*(1-quantityTolerance)
+	shortUnitCost := item.CarrierQuantity > 0 && item.CarrierUnitCost < line.UnitCost*(1-unitCostTolerance)
+	item.Amount = round2(line.Total - carrierTotal)
+	if !(shortQuantity || shortUnitCost) || item.Amount <= 0 {
+		return models.SupplementItem{}, false
+	}
+	item.Kind = models.SupplementItemAdjusted
+	return item, true
+}
+
+// bestMatch returns the estimate code a carrier line is for, or "" when it
+// matches none. Units that cannot be converted never match.
+func bestMatch(c CarrierLine, byCode map[string]*required, order []string) string {
+	words := tokenize(c.Description)
+	unit := pricing.NormalizeUnit(c.Unit)
+	best, bestScore := "", 0.0
+	for _, code := range order {
+		line := byCode[code].line
+		if unit != "" {
+			if _, ok := pricing.ConvertQuantity(1, unit, line.Unit); !ok {
+				continue
+			}
+		}
+		score := math.Max(overlap(words, tokenize(line.Description)), overlap(words, codeWords(code)))
+		if score > bestScore {
+			best, bestScore = code, score
+		}
+	}
+	if bestScore < matchThreshold {
+		return ""
+	}
+	return best
+}
+
+// overlap is the share of the shorter word set found in the other
+func overlap(a, b map[string]bool) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	shared := 0
+	for w := range a {
+		if b[w] {
+			shared++
+		}
+	}
+	return float64(shared) / math.Min(float64(len(a)), float64(len(b)))
+}
+
+// tokenize lower-cases a description into words, dropping stop words and
+// plural endings
+func tokenize(s string) map[string]bool {
+	words := map[string]bool{}
+	for _, w := range strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if stopWords[w] {
+			continue
+		}
+		if len(w) > 3 && strings.HasSuffix(w, "s") && !strings.HasSuffix(w, "ss") {
+			w = strings.TrimSuffix(w, "s")
+		}
+		words[w] = true
+	}
+	return words
+}
+
+// codeWords are the words of a code after its category prefix, so
+// RFG_DRIP_EDGE matches "drip edge"
+func codeWords(code string) map[string]bool {
+	parts := strings.Split(code, "_")
+	if len(parts) > 1 {
+		parts = parts[1:]
+	}
+	return tokenize(strings.Join(parts, " "))
+}
+
+func justify(item models.SupplementItem, areaIDs []string, areas map[string]Area) (string, string) {
+	var names []string
+	for _, id := range areaIDs {
+		if a, ok := areas[id]; ok && a.Name != "" {
+			names = append(names, a.Name)
+		}
+	}
+	where := "the scoped damage"
+	if len(names) > 0 {
+		where = strings.Join(names, ", ")
+	}
+
+	var reason string
+	switch {
+	case item.Kind == models.SupplementItemAdded:
+		reason = fmt.Sprintf("Omitted from the carrier estimate; %g %s is required to repair %s.", item.Quantity, item.Unit, where)
+	case item.CarrierQuantity < item.Quantity*(1-quantityTolerance):
+		reason = fmt.Sprintf("Carrier allowed %g %s; the measured scope of %s requires %g %s.",
+			item.CarrierQuantity, item.Unit, where, item.Quantity, item.Unit)
+	default:
+		reason = fmt.Sprintf("Carrier unit cost of $%.2f/%s is below the current unit cost of $%.2f/%s.",
+			item.CarrierUnitCost, item.Unit, item.UnitCost, item.Unit)
+	}
+	req, ok := requirements[item.Code]
+	if !ok {
+		return "", reason
+	}
+	return req.code, reason + " " + req.justification
+}
+
+func photos(areaIDs []string, areas map[string]Area) []models.SupplementPhotos {
+	refs := []models.SupplementPhotos{}
+	for _, id := range areaIDs {
+		a, ok := areas[id]
+		if !ok || len(a.PhotoIDs) == 0 {
+			continue
+		}
+		refs = append(refs, models.SupplementPhotos{AreaID: a.ID, Area: a.Name, PhotoIDs: a.PhotoIDs})
+	}
+	return refs
+}
+
+// csvHeader is the header row of a supplement CSV
+var csvHeader = []string{
+	"Kind", "Code", "Description", "Category", "Unit", "Quantity", "Unit Cost",
+	"Carrier Quantity", "Carrier Unit Cost", "Amount Requested", "Code Requirement", "Justification", "Photos",
+}
+
+// WriteCSV writes a supplement's items as a CSV for the adjuster, ending with
+// a total row
+func WriteCSV(w io.Writer, items []models.SupplementItem) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(csvHeader); err != nil {
+		return err
+	}
+	for _, item := range items {
+		record := []string{
+			item.Kind, item.Code, item.Description, item.Category, item.Unit,
+			formatNumber(item.Quantity), fmt.Sprintf("%.2f", item.UnitCost),
+			formatNumber(item.CarrierQuantity), fmt.Sprintf("%.2f", item.CarrierUnitCost),
+			fmt.Sprintf("%.2f", item.Amount), item.CodeRequirement, item.Justification, DescribePhotos(item.Photos),
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	total := make([]string, len(csvHeader))
+	total[0], total[9] = "Total", fmt.Sprintf("%.2f", Total(items))
+	if err := writer.Write(total); err != nil {
+		return err
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// DescribePhotos lists an item's photos by area, e.g. "Roof: p1, p2"
+func DescribePhotos(refs []models.SupplementPhotos) string {
+	parts := make([]string, 0, len(refs))
+	for _, ref := range refs {
+		name := ref.Area
+		if name == "" {
+			name = ref.AreaID
+		}
+		parts = append(parts, name+": "+strings.Join(ref.PhotoIDs, ", "))
+	}
+	return strings.Join(parts, "; ")
+}
+
+func formatNumber(v float64) string {
+	return fmt.Sprintf("%g", v)
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
@@ -0,0 +1,104 @@
+package supplement
+
+import (
+	"bytes"
+	"encoding/csv"
+	"testing"
+
+	"github.com/claimcoach/backend/internal/models"
+	"github.com/claimcoach/backend/internal/pricing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testEstimate() *pricing.IndustryEstimate {
+	return &pricing.IndustryEstimate{LineItems: []pricing.LineItem{
+		{Code: "RFG_SHINGLE_ARCH", Description: "Architectural (laminated) composition shingles", Quantity: 30, Unit: "SQ", UnitCost: 295, Total: 8850, Category: "Roofing", AreaID: "roof"},
+		{Code: "RFG_DRIP_EDGE", Description: "Drip edge", Quantity: 220, Unit: "LF", UnitCost: 3.05, Total: 671, Category: "Roofing", AreaID: "roof"},
+		{Code: "RFG_STARTER", Description: "Asphalt starter strip", Quantity: 180, Unit: "LF", UnitCost: 1.95, Total: 351, Category: "Roofing", AreaID: "roof"},
+		{Code: "RFG_RIDGE_CAP", Description: "Hip and ridge cap shingles", Quantity: 60, Unit: "LF", UnitCost: 6.45, Total: 387, Category: "Roofing", AreaID: "roof"},
+		{Code: "GTR_ALUM", Description: "5 in. seamless aluminum gutter", Quantity: 40, Unit: "LF", UnitCost: 8.15, Total: 326, Category: "Gutters", AreaID: "front"},
+		{Code: "GTR_ALUM", Description: "5 in. seamless aluminum gutter", Quantity: 40, Unit: "LF", UnitCost: 8.15, Total: 326, Category: "Gutters", AreaID: "rear"},
+		{Code: "UNKNOWN", Description: "Custom cupola", Quantity: 1, Unit: "EA", Category: "Roofing", Note: "no price"},
+	}}
+}
+
+func TestBuild(t *testing.T) {
+	carrier := []CarrierLine{
+		// Same shingles in square feet, fully allowed
+		{Description: "Laminated - comp. shingle rfg. - w/out felt", Quantity: 3000, Unit: "SF", UnitCost: 2.95, Total: 8850},
+		// Starter allowed under a different description, too short
+		{Description: "R&R Starter - universal - composition", Quantity: 100, Unit: "LF", UnitCost: 1.95, Total: 195},
+		// Ridge cap allowed in full but underpriced
+		{Description: "Hip / Ridge cap - composition shingles", Quantity: 60, Unit: "LF", UnitCost: 4.00, Total: 240},
+		// Gutters allowed in full across both elevations
+		{Description: "Gutter / downspout - aluminum - up to 5\"", Quantity: 80, Unit: "LF", UnitCost: 8.15, Total: 652},
+		// Matches nothing in the estimate
+		{Description: "Window screen", Quantity: 2, Unit: "EA", UnitCost: 40, Total: 80},
+	}
+	areas := []Area{{ID: "roof", Name: "Roof", PhotoIDs: []string{"p1", "p2"}}, {ID: "front", Name: "Front elevation"}}
+
+	items := Build(testEstimate(), carrier, areas)
+	require.Len(t, items, 3)
+
+	drip := items[0]
+	assert.Equal(t, models.SupplementItemAdded, drip.Kind)
+	assert.Equal(t, "RFG_DRIP_EDGE", drip.Code)
+	assert.Equal(t, 671.0, drip.Amount)
+	assert.Equal(t, "IRC R905.2.8.5", drip.CodeRequirement)
+	assert.Contains(t, drip.Justification, "Omitted from the carrier estimate; 220 LF is required to repair Roof.")
+	assert.Equal(t, []models.SupplementPhotos{{AreaID: "roof", Area: "Roof", PhotoIDs: []string{"p1", "p2"}}}, drip.Photos)
+
+	starter := items[1]
+	assert.Equal(t, models.SupplementItemAdjusted, starter.Kind)
+	assert.Equal(t, 100.0, starter.CarrierQuantity)
+	assert.Equal(t, 156.0, starter.Amount)
+	assert.Contains(t, starter.Justification, "Carrier allowed 100 LF; the measured scope of Roof requires 180 LF.")
+
+	ridge := items[2]
+	assert.Equal(t, "RFG_RIDGE_CAP", ridge.Code)
+	assert.Equal(t, 4.0, ridge.CarrierUnitCost)
+	assert.Equal(t, 147.0, ridge.Amount)
+	assert.Contains(t, ridge.Justification, "Carrier unit cost of $4.00/LF is below the current unit cost of $6.45/LF.")
+
+	assert.Equal(t, 974.0, Total(items))
+}
+
+func TestBuild_NothingMissing(t *testing.T) {
+	estimate := &pricing.IndustryEstimate{LineItems: []pricing.LineItem{
+		{Code: "RFG_DRIP_EDGE", Description: "Drip edge", Quantity: 220, Unit: "LF", UnitCost: 3.05, Total: 671},
+	}}
+	carrier := []CarrierLine{{Description: "Drip edge - aluminum", Quantity: 218, Unit: "LF", UnitCost: 3.10, Total: 675.8}}
+	assert.Empty(t, Build(estimate, carrier, nil))
+}
+
+func TestBuild_UnitsMustConvert(t *testing.T) {
+	estimate := &pricing.IndustryEstimate{LineItems: []pricing.LineItem{
+		{Code: "RFG_DRIP_EDGE", Description: "Drip edge", Quantity: 220, Unit: "LF", UnitCost: 3.05, Total: 671},
+	}}
+	carrier := []CarrierLine{{Description: "Drip edge", Quantity: 1, Unit: "EA", UnitCost: 671, Total: 671}}
+	items := Build(estimate, carrier, nil)
+	require.Len(t, items, 1)
+	assert.Equal(t, models.SupplementItemAdded, items[0].Kind)
+	assert.Empty(t, items[0].Photos)
+}
+
+func TestWriteCSV(t *testing.T) {
+	items := []models.SupplementItem{{
+		Kind: models.SupplementItemAdded, Code: "RFG_DRIP_EDGE", Description: "Drip edge", Category: "Roofing", Unit: "LF",
+		Quantity: 220, UnitCost: 3.05, Amount: 671, CodeRequirement: "IRC R905.2.8.5", Justification: "Omitted, required",
+		Photos: []models.SupplementPhotos{{AreaID: "roof", Area: "Roof", PhotoIDs: []string{"p1", "p2"}}},
+	}}
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteCSV(&buf, items))
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 3)
+	assert.Equal(t, csvHeader, records[0])
+	assert.Equal(t, []string{"added", "RFG_DRIP_EDGE", "Drip edge", "Roofing", "LF", "220", "3.05", "0", "0.00", "671.00",
+		"IRC R905.2.8.5", "Omitted, required", "Roof: p1, p2"}, records[1])
+	assert.Equal(t, "Total", records[2][0])
+	assert.Equal(t, "671.00", records[2][9])
+}
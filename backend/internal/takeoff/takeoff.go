@@ -0,0 +1,317 @@
+// Package takeoff computes repair quantities from scope sheet dimensions. The
+// math is deterministic so the same scope sheet always produces the same
+// quantities; the LLM is only asked to price them.
+package takeoff
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/claimcoach/backend/internal/models"
+	"github.com/claimcoach/backend/internal/scopecatalog"
+)
+
+// Version identifies the takeoff rules below. Bump it whenever a formula,
+// waste factor or default changes so stored takeoffs can be told apart.
+const Version = "1"
+
+// Quantity units, matching the units the estimate prompt asks line items in
+const (
+	UnitSquareFeet = "SF"
+	UnitLinearFeet = "LF"
+	UnitSquares    = "SQ" // 100 SF of roofing
+	UnitEach       = "EA"
+	UnitDays       = "DAY"
+)
+
+// Takeoff is the computed quantities for a whole scope sheet
+type Takeoff struct {
+	Version string `json:"version"`
+	Areas   []Area `json:"areas"`
+}
+
+// Area is the computed quantities for one scope area
+type Area struct {
+	AreaID      string     `json:"area_id"`
+	CategoryKey string     `json:"category_key"`
+	Category    string     `json:"category"`
+	Quantities  []Quantity `json:"quantities"`
+	// Assumptions lists defaults used in place of missing measurements
+	Assumptions []string `json:"assumptions,omitempty"`
+}
+
+// Quantity is one computed quantity. Basis explains how it was derived so a
+// reviewer can check the math.
+type Quantity struct {
+	Code        string  `json:"code"`
+	Description string  `json:"description"`
+	Quantity    float64 `json:"quantity"`
+	Unit        string  `json:"unit"`
+	Basis       string  `json:"basis"`
+}
+
+// Defaults for measurements contractors often leave out
+const (
+	defaultCeilingHeight = 8.0
+	defaultDryingDays    = 3.0
+	windowOpeningSF      = 15.0
+	doorOpeningSF        = 21.0
+	downspoutSpacingLF   = 35.0
+	fencePostSpacingLF   = 8.0
+	airMoverCoverageSF   = 50.0
+	dehumidifierSF       = 1000.0
+	sidingWasteFactor    = 0.10
+	flooringWasteFactor  = 0.10
+	steepPitchThreshold  = 8.0 // rise per 12 at which steep-slope labor applies
+)
+
+// Compute runs the takeoff for every area. Areas whose category isn't in the
+// catalog are skipped.
+func Compute(areas []models.ScopeArea) Takeoff {
+	result := Takeoff{Version: Version, Areas: []Area{}}
+	for _, area := range areas {
+		category, ok := scopecatalog.Lookup(area.CategoryKey)
+		if !ok {
+			continue
+		}
+
+		a := &Area{AreaID: area.ID, CategoryKey: area.CategoryKey, Category: category.Label, Quantities: []Quantity{}}
+		tags := make(map[string]bool, len(area.Tags))
+		for _, tag := range area.Tags {
+			tags[tag] = true
+		}
+		dims := area.Dimensions
+
+		switch {
+		case area.CategoryKey == "roof":
+			roof(a, dims, tags)
+		case area.CategoryKey == "exterior_walls":
+			exteriorWalls(a, dims, tags)
+		case area.CategoryKey == "gutters":
+			gutters(a, dims)
+		case category.Group == scopecatalog.GroupInterior:
+			room(a, dims, tags)
+		case area.CategoryKey == "water_mitigation":
+			waterMitigation(a, dims)
+		case area.CategoryKey == "fencing_other":
+			fencing(a, dims, tags)
+		}
+
+		result.Areas = append(result.Areas, *a)
+	}
+	return result
+}
+
+// RoofWasteFactor is the shingle waste allowance for a roof pitch (rise per
+// 12). Steeper roofs need more cutting around hips and valleys.
+func RoofWasteFactor(pitch float64) float64 {
+	switch {
+	case pitch >= 10:
+		return 0.15
+	case pitch >= 7:
+		return 0.12
+	default:
+		return 0.10
+	}
+}
+
+func roof(a *Area, dims map[string]float64, tags map[string]bool) {
+	sf := dims["square_footage"]
+	if sf <= 0 {
+		a.assume("no roof square footage; roofing quantities not computed")
+		return
+	}
+
+	pitch := dims["pitch"]
+	pitchBasis := fmt.Sprintf("%g/12 pitch", pitch)
+	if pitch <= 0 {
+		if tags["Pitch_Steep"] {
+			pitch = steepPitchThreshold
+			a.assume("pitch not measured; Steep Pitch tag treated as 8/12")
+		} else {
+			pitch = 4
+			a.assume("pitch not measured; assumed 4/12")
+		}
+		pitchBasis = fmt.Sprintf("assumed %g/12 pitch", pitch)
+	}
+	waste := RoofWasteFactor(pitch)
+
+	squares := sf / 100
+	a.add("roof_area", "Roof surface area", sf, UnitSquareFeet, "measured")
+	a.add("roof_squares", "Roofing with waste", roundUpThird(squares*(1+waste)), UnitSquares,
+		fmt.Sprintf("%s SF / 100 x %s waste for %s, rounded up to a bundle", num(sf), pct(waste), pitchBasis))
+	a.add("underlayment", "Underlayment", round2(squares), UnitSquares, fmt.Sprintf("%s SF / 100", num(sf)))
+
+	eave, rake := dims["eave_length"], dims["rake_length"]
+	if eave <= 0 && rake <= 0 {
+		// Treat the roof as square in plan: perimeter = 4 x sqrt(area), half eaves, half rakes
+		side := math.Sqrt(sf)
+		eave, rake = math.Ceil(2*side), math.Ceil(2*side)
+		a.assume(fmt.Sprintf("eave and rake lengths not measured; estimated %s LF each from a square roof of %s SF", num(eave), num(sf)))
+	}
+	a.add("drip_edge", "Drip edge", math.Ceil(eave+rake), UnitLinearFeet,
+		fmt.Sprintf("%s LF eaves + %s LF rakes", num(eave), num(rake)))
+	a.add("starter_strip", "Starter strip", math.Ceil(eave), UnitLinearFeet, "eave length")
+	if ridge := dims["ridge_length"]; ridge > 0 {
+		a.add("ridge_cap", "Ridge and hip cap", math.Ceil(ridge), UnitLinearFeet, "measured")
+	}
+	if tags["Gutters_Damaged"] {
+		a.add("gutter", "Gutter", math.Ceil(eave), UnitLinearFeet, "eave length")
+	}
+	if tags["Decking_Damaged"] {
+		sheets := math.Ceil(sf / 32)
+		a.add("decking", "Roof decking (4x8 sheets)", sheets, UnitEach, fmt.Sprintf("%s SF / 32 SF per sheet", num(sf)))
+	}
+	if pitch >= steepPitchThreshold {
+		a.add("steep_charge", "Steep-slope labor", round2(squares), UnitSquares, pitchBasis)
+	}
+	if stories := dims["stories"]; stories >= 2 {
+		a.add("high_roof_charge", "High-roof labor (2+ stories)", round2(squares), UnitSquares, fmt.Sprintf("%g stories", stories))
+	}
+}
+
+func exteriorWalls(a *Area, dims map[string]float64, tags map[string]bool) {
+	gross := dims["square_footage"]
+	if gross <= 0 {
+		a.assume("no wall square footage; siding quantities not computed")
+		return
+	}
+
+	windows, doors := dims["window_count"], dims["door_count"]
+	openings := windows*windowOpeningSF + doors*doorOpeningSF
+	net := math.Max(0, gross-openings)
+	a.add("wall_area_gross", "Exterior wall area", gross, UnitSquareFeet, "measured")
+	a.add("wall_area_net", "Exterior wall area net of openings", net, UnitSquareFeet,
+		fmt.Sprintf("%s SF - %g windows x %g SF - %g doors x %g SF", num(gross), windows, windowOpeningSF, doors, doorOpeningSF))
+	if windows == 0 && doors == 0 {
+		a.assume("no window or door counts; no openings deducted")
+	}
+
+	if tags["Siding_Damaged"] {
+		a.add("siding", "Siding with waste", math.Ceil(net*(1+sidingWasteFactor)), UnitSquareFeet,
+			fmt.Sprintf("%s SF net x %s waste", num(net), pct(sidingWasteFactor)))
+	}
+	if tags["Siding_Paint_Needed"] {
+		a.add("exterior_paint", "Exterior paint", math.Ceil(net), UnitSquareFeet, "net wall area")
+	}
+}
+
+func gutters(a *Area, dims map[string]float64) {
+	lf := dims["linear_feet"]
+	if lf <= 0 {
+		a.assume("no gutter length; gutter quantities not computed")
+		return
+	}
+	a.add("gutter", "Gutter", math.Ceil(lf), UnitLinearFeet, "measured")
+
+	downspouts := dims["downspouts"]
+	basis := "measured"
+	if downspouts <= 0 {
+		downspouts = math.Max(1, math.Ceil(lf/downspoutSpacingLF))
+		basis = fmt.Sprintf("%s LF / %g LF per downspout", num(lf), downspoutSpacingLF)
+		a.assume("downspouts not counted; estimated one per 35 LF of gutter")
+	}
+	a.add("downspouts", "Downspouts", downspouts, UnitEach, basis)
+}
+
+func room(a *Area, dims map[string]float64, tags map[string]bool) {
+	length, width := dims["length"], dims["width"]
+	if length <= 0 || width <= 0 {
+		a.assume("room length or width missing; room quantities not computed")
+		return
+	}
+	height := dims["ceiling_height"]
+	if height <= 0 {
+		height = defaultCeilingHeight
+		a.assume("ceiling height not measured; assumed 8 ft")
+	}
+
+	floor := length * width
+	perimeter := 2 * (length + width)
+	walls := perimeter * height
+	dimsBasis := fmt.Sprintf("%g x %g ft", length, width)
+
+	a.add("floor_area", "Floor area", round2(floor), UnitSquareFeet, dimsBasis)
+	a.add("wall_area", "Wall area", round2(walls), UnitSquareFeet, fmt.Sprintf("%s LF perimeter x %g ft height", num(perimeter), height))
+	a.add("perimeter", "Baseboard", math.Ceil(perimeter), UnitLinearFeet, "room perimeter")
+
+	if tags["Flooring_Damaged"] {
+		a.add("flooring", "Flooring with waste", math.Ceil(floor*(1+flooringWasteFactor)), UnitSquareFeet,
+			fmt.Sprintf("%s SF x %s waste", num(floor), pct(flooringWasteFactor)))
+	}
+	if tags["Drywall_Damaged"] {
+		a.add("drywall_walls", "Wall drywall", math.Ceil(walls), UnitSquareFeet, "wall area")
+	}
+	if tags["Ceiling_Damaged"] {
+		a.add("drywall_ceiling", "Ceiling drywall", math.Ceil(floor), UnitSquareFeet, "floor area")
+	}
+	if tags["Paint_Needed"] || tags["Drywall_Damaged"] || tags["Ceiling_Damaged"] {
+		a.add("interior_paint", "Interior paint (walls and ceiling)", math.Ceil(walls+floor), UnitSquareFeet, "wall area + ceiling area")
+	}
+}
+
+func waterMitigation(a *Area, dims map[string]float64) {
+	sf := dims["affected_square_footage"]
+	if sf <= 0 {
+		a.assume("no affected area; drying equipment not computed")
+		return
+	}
+	days := dims["drying_days"]
+	if days <= 0 {
+		days = defaultDryingDays
+		a.assume("drying days not given; assumed 3")
+	}
+
+	airMovers := math.Ceil(sf / airMoverCoverageSF)
+	dehumidifiers := math.Max(1, math.Ceil(sf/dehumidifierSF))
+	a.add("affected_area", "Affected area", sf, UnitSquareFeet, "measured")
+	a.add("air_mover_days", "Air mover rental", airMovers*days, UnitDays,
+		fmt.Sprintf("%g air movers (1 per %g SF) x %g days", airMovers, airMoverCoverageSF, days))
+	a.add("dehumidifier_days", "Dehumidifier rental", dehumidifiers*days, UnitDays,
+		fmt.Sprintf("%g dehumidifiers (1 per %g SF) x %g days", dehumidifiers, dehumidifierSF, days))
+}
+
+func fencing(a *Area, dims map[string]float64, tags map[string]bool) {
+	lf := dims["linear_feet"]
+	if lf > 0 {
+		a.add("fence", "Fence", math.Ceil(lf), UnitLinearFeet, "measured")
+		if tags["Posts_Broken"] {
+			a.add("fence_posts", "Fence posts", math.Ceil(lf/fencePostSpacingLF)+1, UnitEach,
+				fmt.Sprintf("%s LF / %g LF spacing + 1", num(lf), fencePostSpacingLF))
+		}
+	}
+	if tags["Gate_Damaged"] {
+		a.add("gate", "Gate", 1, UnitEach, "Gate Damaged tag")
+	}
+}
+
+func (a *Area) add(code, description string, quantity float64, unit, basis string) {
+	a.Quantities = append(a.Quantities, Quantity{
+		Code:        code,
+		Description: description,
+		Quantity:    quantity,
+		Unit:        unit,
+		Basis:       basis,
+	})
+}
+
+func (a *Area) assume(assumption string) {
+	a.Assumptions = append(a.Assumptions, assumption)
+}
+
+// roundUpThird rounds roofing squares up to the next bundle (three per square)
+func roundUpThird(squares float64) float64 {
+	return round2(math.Ceil(squares*3-1e-9) / 3)
+}
+
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+func num(v float64) string {
+	return fmt.Sprintf("%g", round2(v))
+}
+
+func pct(v float64) string {
+	return fmt.Sprintf("%g%%", v*100)
+}
@@ -0,0 +1,106 @@
+package takeoff
+
+import (
+	"testing"
+
+	"github.com/claimcoach/backend/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func quantities(area Area) map[string]float64 {
+	byCode := map[string]float64{}
+	for _, q := range area.Quantities {
+		byCode[q.Code] = q.Quantity
+	}
+	return byCode
+}
+
+func TestRoofWasteFactor(t *testing.T) {
+	assert.Equal(t, 0.10, RoofWasteFactor(4))
+	assert.Equal(t, 0.12, RoofWasteFactor(7))
+	assert.Equal(t, 0.15, RoofWasteFactor(12))
+}
+
+func TestCompute_Roof(t *testing.T) {
+	result := Compute([]models.ScopeArea{{
+		ID:          "roof-1",
+		CategoryKey: "roof",
+		Tags:        []string{"Shingles_Damaged", "Decking_Damaged"},
+		Dimensions: map[string]float64{
+			"square_footage": 2400, "pitch": 9, "eave_length": 120, "rake_length": 80, "ridge_length": 40, "stories": 2,
+		},
+	}})
+
+	require.Len(t, result.Areas, 1)
+	assert.Equal(t, Version, result.Version)
+	assert.Empty(t, result.Areas[0].Assumptions)
+	assert.Equal(t, map[string]float64{
+		"roof_area":        2400,
+		"roof_squares":     27, // 24 SQ x 1.12 = 26.88, rounded up to a bundle
+		"underlayment":     24,
+		"drip_edge":        200,
+		"starter_strip":    120,
+		"ridge_cap":        40,
+		"decking":          75,
+		"steep_charge":     24,
+		"high_roof_charge": 24,
+	}, quantities(result.Areas[0]))
+}
+
+func TestCompute_RoofEstimatesPerimeterWhenNotMeasured(t *testing.T) {
+	result := Compute([]models.ScopeArea{{
+		ID:          "roof-1",
+		CategoryKey: "roof",
+		Dimensions:  map[string]float64{"square_footage": 1600},
+	}})
+
+	q := quantities(result.Areas[0])
+	assert.Equal(t, 17.67, q["roof_squares"]) // 16 SQ x 1.10 = 17.6 -> 17 2/3
+	assert.Equal(t, 160.0, q["drip_edge"])
+	assert.NotContains(t, q, "steep_charge")
+	assert.Len(t, result.Areas[0].Assumptions, 2)
+}
+
+func TestCompute_ExteriorWallsNetOfOpenings(t *testing.T) {
+	result := Compute([]models.ScopeArea{{
+		ID:          "walls-1",
+		CategoryKey: "exterior_walls",
+		Tags:        []string{"Siding_Damaged"},
+		Dimensions:  map[string]float64{"square_footage": 1000, "window_count": 4, "door_count": 1},
+	}})
+
+	q := quantities(result.Areas[0])
+	assert.Equal(t, 919.0, q["wall_area_net"]) // 1000 - 60 - 21
+	assert.Equal(t, 1011.0, q["siding"])
+}
+
+func TestCompute_InteriorRoom(t *testing.T) {
+	result := Compute([]models.ScopeArea{{
+		ID:          "kitchen-1",
+		CategoryKey: "interior_kitchen",
+		Tags:        []string{"Flooring_Damaged", "Drywall_Damaged"},
+		Dimensions:  map[string]float64{"length": 12, "width": 10},
+	}})
+
+	q := quantities(result.Areas[0])
+	assert.Equal(t, 120.0, q["floor_area"])
+	assert.Equal(t, 352.0, q["wall_area"]) // 44 LF x 8 ft default height
+	assert.Equal(t, 132.0, q["flooring"])
+	assert.Equal(t, 472.0, q["interior_paint"])
+	assert.Equal(t, []string{"ceiling height not measured; assumed 8 ft"}, result.Areas[0].Assumptions)
+}
+
+func TestCompute_GuttersAndMitigation(t *testing.T) {
+	result := Compute([]models.ScopeArea{
+		{ID: "g-1", CategoryKey: "gutters", Dimensions: map[string]float64{"linear_feet": 150}},
+		{ID: "w-1", CategoryKey: "water_mitigation", Dimensions: map[string]float64{"affected_square_footage": 400, "drying_days": 4}},
+		{ID: "x-1", CategoryKey: "pool"},
+	})
+
+	require.Len(t, result.Areas, 2)
+	assert.Equal(t, 5.0, quantities(result.Areas[0])["downspouts"])
+	mitigation := quantities(result.Areas[1])
+	assert.Equal(t, 32.0, mitigation["air_mover_days"])
+	assert.Equal(t, 4.0, mitigation["dehumidifier_days"])
+}
@@ -1,4 +1,5 @@
-import { useQuery } from '@tanstack/react-query'
+import { useQuery, useMutation, useQueryClient } from '@tanstack/react-query'
+import { useSearchParams } from 'react-router-dom'
 import api from '../lib/api'
 
 interface MagicLink {
@@ -6,7 +7,7 @@ interface MagicLink {
   contractor_name: string
   contractor_email: string
   contractor_phone: string | null
-  status: 'active' | 'expired' | 'completed'
+  status: 'active' | 'expired' | 'completed' | 'revoked' | 'locked'
   created_at: string
   expires_at: string
   accessed_at: string | null
@@ -20,6 +21,7 @@ interface MagicLink {
     email: string
   } | null
   link_url: string
+  reissued_from_id: string | null
 }
 
 interface MagicLinkHistoryProps {
@@ -27,6 +29,9 @@ interface MagicLinkHistoryProps {
 }
 
 export default function MagicLinkHistory({ claimId }: MagicLinkHistoryProps) {
+  const queryClient = useQueryClient()
+  const [searchParams, setSearchParams] = useSearchParams()
+
   // Fetch magic links
   const { data: magicLinks, isLoading } = useQuery({
     queryKey: ['magic-links', claimId],
@@ -36,6 +41,36 @@ export default function MagicLinkHistory({ claimId }: MagicLinkHistoryProps) {
     },
   })
 
+  // The "Reissue Link" button in the expired link email opens the claim with
+  // ?reissue_magic_link=<id>; ask the PM to confirm before sending a new link
+  const reissueLinkId = searchParams.get('reissue_magic_link')
+  const reissueLink = magicLinks?.find((link) => link.id === reissueLinkId)
+  const alreadyReissued = magicLinks?.some((link) => link.reissued_from_id === reissueLinkId)
+
+  const dismissReissue = () => {
+    const next = new URLSearchParams(searchParams)
+    next.delete('reissue_magic_link')
+    setSearchParams(next, { replace: true })
+  }
+
+  const reissueMutation = useMutation({
+    mutationFn: (linkId: string) => api.post(`/api/claims/${claimId}/magic-links/${linkId}/reissue`),
+    onSuccess: () => {
+      queryClient.invalidateQueries({ queryKey: ['magic-links', claimId] })
+      queryClient.invalidateQueries({ queryKey: ['claim-activities', claimId] })
+      dismissReissue()
+    },
+  })
+
+  // A link locked after failed attempts can be reopened once the PM knows the contractor is genuine
+  const unlockMutation = useMutation({
+    mutationFn: (linkId: string) => api.post(`/api/claims/${claimId}/magic-links/${linkId}/unlock`),
+    onSuccess: () => {
+      queryClient.invalidateQueries({ queryKey: ['magic-links', claimId] })
+      queryClient.invalidateQueries({ queryKey: ['claim-activities', claimId] })
+    },
+  })
+
   const formatDateTime = (dateString?: string | null) => {
     if (!dateString) return 'N/A'
     return new Date(dateString).toLocaleString('en-US', {
@@ -52,6 +87,8 @@ export default function MagicLinkHistory({ claimId }: MagicLinkHistoryProps) {
       active: { color: 'bg-green-100 text-green-800', label: 'Active' },
       expired: { color: 'bg-gray-100 text-gray-800', label: 'Expired' },
       completed: { color: 'bg-blue-100 text-blue-800', label: 'Completed' },
+      revoked: { color: 'bg-red-100 text-red-800', label: 'Revoked' },
+      locked: { color: 'bg-red-100 text-red-800', label: 'Locked' },
     }
     const config = statusConfig[status] || statusConfig.active
     return (
@@ -103,6 +140,50 @@ export default function MagicLinkHistory({ claimId }: MagicLinkHistoryProps) {
         </p>
       </div>
 
+      {reissueLinkId && !isLoading && (
+        <div className="mx-6 mt-5 rounded-md border border-amber-200 bg-amber-50 p-4">
+          {!reissueLink ? (
+            <p className="text-sm text-amber-800">This magic link was not found on this claim.</p>
+          ) : alreadyReissued ? (
+            <p className="text-sm text-amber-800">
+              The link for {reissueLink.contractor_name} has already been reissued.
+            </p>
+          ) : (
+            <>
+              <p className="text-sm text-amber-800">
+                Send {reissueLink.contractor_name} ({reissueLink.contractor_email}) a new link with the same
+                scope and lifetime to replace the {reissueLink.status === 'active' ? 'expired' : reissueLink.status} one?
+              </p>
+              {reissueMutation.isError && (
+                <p className="mt-2 text-sm text-red-700">
+                  {(reissueMutation.error as any)?.response?.data?.error || 'Failed to reissue magic link'}
+                </p>
+              )}
+              <div className="mt-3 flex gap-2">
+                <button
+                  onClick={() => reissueMutation.mutate(reissueLink.id)}
+                  disabled={reissueMutation.isPending}
+                  className="px-3 py-1.5 text-sm font-medium text-white bg-gray-900 rounded-md hover:bg-gray-800 disabled:opacity-50"
+                >
+                  {reissueMutation.isPending ? 'Reissuing...' : 'Reissue Link'}
+                </button>
+                <button
+                  onClick={dismissReissue}
+                  className="px-3 py-1.5 text-sm font-medium text-gray-700 bg-white border border-gray-300 rounded-md hover:bg-gray-50"
+                >
+                  Cancel
+                </button>
+              </div>
+            </>
+          )}
+          {(!reissueLink || alreadyReissued) && (
+            <button onClick={dismissReissue} className="mt-2 text-sm font-medium text-amber-900 underline">
+              Dismiss
+            </button>
+          )}
+        </div>
+      )}
+
       <div className="px-6 py-5">
         {isLoading ? (
           <div className="text-center py-4 text-gray-600">
@@ -162,6 +243,15 @@ export default function MagicLinkHistory({ claimId }: MagicLinkHistoryProps) {
                     </td>
                     <td className="px-4 py-4 whitespace-nowrap">
                       {getStatusBadge(link.status)}
+                      {link.status === 'locked' && (
+                        <button
+                          onClick={() => unlockMutation.mutate(link.id)}
+                          disabled={unlockMutation.isPending}
+                          className="ml-2 text-xs font-medium text-gray-700 underline hover:text-gray-900 disabled:opacity-50"
+                        >
+                          Unlock
+                        </button>
+                      )}
                     </td>
                     <td className="px-4 py-4 whitespace-nowrap text-sm">
                       {getEmailStatusIcon(link.email_sent, link.email_error)}
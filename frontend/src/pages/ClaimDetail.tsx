@@ -64,6 +64,7 @@ interface GeneratedEstimate {
   line_items: LineItem[]
   subtotal: number
   overhead_profit: number
+  tax?: number
   total: number
 }
 
@@ -364,6 +365,13 @@ function AuditSection({ claimId, hasScopeSheet }: AuditSectionProps) {
                       <td className="px-4 py-3 text-sm font-semibold text-gray-900">{formatCurrency(generatedEstimate.overhead_profit)}</td>
                       <td></td>
                     </tr>
+                    {!!generatedEstimate.tax && (
+                      <tr>
+                        <td colSpan={4} className="px-4 py-3 text-sm font-medium text-gray-900 text-right">Sales Tax:</td>
+                        <td className="px-4 py-3 text-sm font-semibold text-gray-900">{formatCurrency(generatedEstimate.tax)}</td>
+                        <td></td>
+                      </tr>
+                    )}
                     <tr>
                       <td colSpan={4} className="px-4 py-3 text-sm font-bold text-gray-900 text-right">Total:</td>
                       <td className="px-4 py-3 text-sm font-bold text-gray-900">{formatCurrency(generatedEstimate.total)}</td>